@@ -0,0 +1,35 @@
+// This command runs a dedicated game server without a window, a renderer or audio, so it can run in a container.
+// It loads the MPQ files from the OpenDiablo2 config file and stops gracefully on an interrupt or SIGTERM.
+//
+// Flags:
+// -config [path] The config file (default: the config file of the game)
+// -mpq [directory] The Diablo II directory with the MPQ files (default: MpqPath of the config file)
+// -players [count] The maximum number of players (default: 8)
+// -games [count] The maximum number of games, 0 for no limit (default: 32)
+// -game [name] The game the clients join when they do not give a game name, empty for none (default: OpenDiablo2)
+// -difficulty [difficulty] The difficulty of the default game, from 0 (normal) to 2 (hell) (default: 0)
+// -loglevel [level] The log level, from 0 (none) to 4 (debug) (default: LogLevel of the config file)
+// -closed Only accept the accounts of the server, which stores their characters
+// -accounts [directory] The accounts and their characters (default: OpenDiablo2/Accounts in the user config directory)
+// -create-account [name:password] Create an account and exit
+// -create-token [name] Create a token for an account, which is used instead of the password, print it and exit
+// -operators [accounts] The comma separated accounts of a closed server that can run /kick
+// -scripts [paths] Comma separated scripts that add chat commands
+//
+// Usage:
+// First run `go install .` in this directory.
+// Then run d2server(.exe), the server listens on port 6669 over both TCP and UDP.
+//
+// d2server -mpq /opt/diablo2 -players 4
+//
+// On a closed server the clients authenticate with `--account` and `--password` or `--token`, and play the
+// characters of the account. A new character is created when the account has no character of the name.
+//
+// d2server -create-account kashya:rogue
+// d2server -mpq /opt/diablo2 -closed
+//
+// Players type /help in the chat for the commands of the server. Scripts add commands with addChatCommand,
+// the function is called with the name of the player and the arguments, and returns the answer:
+//
+// addChatCommand("roll", "rolls a die", function(player, args) { return player + " rolls " + (1 + Math.floor(Math.random() * 6)) })
+package main
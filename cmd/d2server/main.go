@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2filestore"
+)
+
+var errInvalidAccount = errors.New("expected the account as name:password")
+
+type options struct {
+	configPath    string
+	mpqPath       string
+	maxPlayers    int
+	maxGames      int
+	defaultGame   string
+	difficulty    int
+	logLevel      int
+	closed        bool
+	accountsPath  string
+	createAccount string
+	createToken   string
+	operators     string
+	scripts       string
+}
+
+func main() {
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	opts := &options{}
+
+	flag.StringVar(&opts.configPath, "config", "", "config file")
+	flag.StringVar(&opts.mpqPath, "mpq", "", "directory with the MPQ files")
+	flag.IntVar(&opts.maxPlayers, "players", d2networking.ServerMaxPlayersDefault, "maximum number of players")
+	flag.IntVar(&opts.maxGames, "games", d2server.DefaultMaxGames, "maximum number of games, 0 for no limit")
+	flag.StringVar(&opts.defaultGame, "game", d2server.DefaultGameName, "the game clients join without a game name, "+
+		"empty for none")
+	flag.IntVar(&opts.difficulty, "difficulty", int(d2enum.DifficultyNormal), "difficulty of the default game, 0 to 2")
+	flag.IntVar(&opts.logLevel, "loglevel", d2util.LogLevelUnspecified, "log level")
+	flag.BoolVar(&opts.closed, "closed", false, "only accept the accounts of the server")
+	flag.StringVar(&opts.accountsPath, "accounts", defaultAccountsPath(), "directory with the accounts and characters")
+	flag.StringVar(&opts.createAccount, "create-account", "", "create an account, as name:password, and exit")
+	flag.StringVar(&opts.createToken, "create-token", "", "create a token for an account, print it and exit")
+	flag.StringVar(&opts.operators, "operators", "", "comma separated accounts that can run the commands of operators")
+	flag.StringVar(&opts.scripts, "scripts", "", "comma separated scripts that add chat commands")
+	flag.Parse()
+
+	var err error
+
+	switch {
+	case opts.createAccount != "":
+		err = createAccount(opts)
+	case opts.createToken != "":
+		err = createToken(opts)
+	default:
+		err = run(opts)
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// defaultAccountsPath returns the directory for the accounts next to the saves of the game
+func defaultAccountsPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "Accounts"
+	}
+
+	return filepath.Join(configDir, "OpenDiablo2", "Accounts")
+}
+
+func createAccount(opts *options) error {
+	parts := strings.SplitN(opts.createAccount, ":", 2) //nolint:gomnd // name and password
+	if len(parts) != 2 || parts[1] == "" {              //nolint:gomnd // name and password
+		return errInvalidAccount
+	}
+
+	store, err := d2filestore.NewFileStore(opts.accountsPath, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := store.CreateAccount(parts[0], parts[1]); err != nil {
+		return err
+	}
+
+	log.Printf("Created account %s in %s", parts[0], opts.accountsPath)
+
+	return nil
+}
+
+func createToken(opts *options) error {
+	store, err := d2filestore.NewFileStore(opts.accountsPath, nil)
+	if err != nil {
+		return err
+	}
+
+	token, err := store.CreateToken(opts.createToken)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(token)
+
+	return nil
+}
+
+func run(opts *options) error {
+	config, err := loadConfig(opts.configPath)
+	if err != nil {
+		return err
+	}
+
+	if opts.mpqPath != "" {
+		config.MpqPath = opts.mpqPath
+	}
+
+	if opts.logLevel != d2util.LogLevelUnspecified {
+		config.LogLevel = opts.logLevel
+	}
+
+	asset, err := loadAssets(config)
+	if err != nil {
+		return err
+	}
+
+	maxPlayers := d2math.ClampInt(opts.maxPlayers, d2networking.ServerMinPlayers, d2networking.ServerMaxPlayersDefault)
+
+	server, err := d2server.NewGameServer(asset, true, maxPlayers)
+	if err != nil {
+		return err
+	}
+
+	server.SetMaxGames(opts.maxGames)
+
+	if opts.defaultGame != "" {
+		// the default game is kept when it is empty, so the clients always find it
+		_, err := server.CreateGame(&d2server.GameOptions{
+			Name:       opts.defaultGame,
+			Difficulty: d2enum.DifficultyType(opts.difficulty),
+			MaxPlayers: maxPlayers,
+			Persistent: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.closed {
+		heroes, err := d2hero.NewHeroStateFactory(asset)
+		if err != nil {
+			return err
+		}
+
+		store, err := d2filestore.NewFileStore(opts.accountsPath, heroes)
+		if err != nil {
+			return err
+		}
+
+		server.SetClosed(store, store)
+
+		log.Printf("Closed server, the accounts are in %s", opts.accountsPath)
+	}
+
+	if opts.operators != "" {
+		server.SetOperators(strings.Split(opts.operators, ",")...)
+	}
+
+	if opts.scripts != "" {
+		for _, script := range strings.Split(opts.scripts, ",") {
+			if err := server.RunScript(script); err != nil {
+				return fmt.Errorf("%s: %w", script, err)
+			}
+		}
+	}
+
+	if err := server.Start(); err != nil {
+		return err
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	sig := <-signals
+
+	log.Printf("Received %s, stopping server", sig)
+
+	server.Stop()
+
+	return nil
+}
+
+// loadConfig loads the given config file, or the config file of the game. The default configuration is used
+// when there is no config file, without saving it.
+func loadConfig(configPath string) (*d2config.Configuration, error) {
+	paths := []string{configPath}
+	if configPath == "" {
+		paths = []string{d2config.LocalConfigPath(), d2config.DefaultConfigPath()}
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(filepath.Clean(path))
+		if os.IsNotExist(err) && configPath == "" {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		config := &d2config.Configuration{}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+
+		config.SetPath(path)
+
+		log.Printf("Loaded configuration file from %s", path)
+
+		return config, nil
+	}
+
+	log.Print("No configuration file found, using the default configuration")
+
+	return d2config.DefaultConfig(), nil
+}
+
+// loadAssets adds the MPQ files to a new asset manager and loads the records
+func loadAssets(config *d2config.Configuration) (*d2asset.AssetManager, error) {
+	asset, err := d2asset.NewAssetManager()
+	if err != nil {
+		return nil, err
+	}
+
+	asset.SetLogLevel(config.LogLevel)
+
+	for _, mpqName := range config.MpqLoadOrder {
+		srcPath := filepath.Join(filepath.Clean(config.MpqPath), mpqName)
+
+		if _, err := asset.AddSource(srcPath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := asset.LoadRecordTables(); err != nil {
+		return nil, err
+	}
+
+	if err := asset.LoadStringTables(); err != nil {
+		return nil, err
+	}
+
+	return asset, nil
+}
@@ -21,15 +21,14 @@ import (
 	"sync"
 	"syscall"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data"
 
 	"github.com/pkg/profile"
 	"golang.org/x/image/colornames"
 	"gopkg.in/alecthomas/kingpin.v2"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	ebiten2 "github.com/OpenDiablo2/OpenDiablo2/d2core/d2audio/ebiten"
@@ -41,9 +40,11 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2term"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2gamescreen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2game/d2player"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
 	"github.com/OpenDiablo2/OpenDiablo2/d2script"
 )
 
@@ -77,6 +78,7 @@ type App struct {
 	tAllocSamples     *ring.Ring
 	guiManager        *d2gui.GuiManager
 	config            *d2config.Configuration
+	keyMap            *d2player.KeyMap
 	logger            *d2util.Logger
 	errorMessage      error
 	*Options
@@ -87,6 +89,10 @@ type Options struct {
 	printVersion *bool
 	Debug        *bool
 	profiler     *string
+	jsonPackets  *bool
+	udp          *bool
+	credentials  *d2netpacket.Credentials
+	network      *d2client.NetworkConditions
 	Server       *d2networking.ServerOptions
 	LogLevel     *d2util.LogLevel
 }
@@ -130,7 +136,16 @@ func updateNOOP() error {
 	return nil
 }
 
+// startDedicatedServer runs a game server in the GUI app, cmd/d2server runs one without linking the renderer
 func (a *App) startDedicatedServer() error {
+	if err := a.initConfig(a.config); err != nil {
+		return err
+	}
+
+	if err := a.initDataDictionaries(); err != nil {
+		return err
+	}
+
 	min, max := d2networking.ServerMinPlayers, d2networking.ServerMaxPlayersDefault
 	maxPlayers := d2math.ClampInt(*a.Options.Server.MaxPlayers, min, max)
 
@@ -226,6 +241,26 @@ func (a *App) parseArguments() {
 		playersArg  = "players"
 		playersDesc = "Sets the number of max players for the dedicated server"
 
+		jsonPacketsArg  = "jsonpackets"
+		jsonPacketsDesc = "Sends network packets to remote servers as JSON instead of binary, for debugging"
+
+		udpArg  = "udp"
+		udpDesc = "Connects to remote servers over UDP instead of TCP"
+
+		accountArg   = "account"
+		accountDesc  = "Sets the account that is used on closed servers"
+		passwordArg  = "password"
+		passwordDesc = "Sets the password of the account"
+		tokenArg     = "token"
+		tokenDesc    = "Sets a token of the account, which is used instead of the password"
+
+		latencyArg     = "latency"
+		latencyDesc    = "Delays the network packets of the game by this much in each direction, for testing (e.g. 100ms)"
+		jitterArg      = "jitter"
+		jitterDesc     = "Varies the simulated latency of each network packet by up to this much"
+		packetLossArg  = "packetloss"
+		packetLossDesc = "Drops this share (0 to 1) of the network packets that can be lost, for testing"
+
 		loggingArg   = "loglevel"
 		loggingShort = 'l'
 		loggingDesc  = "Enables verbose logging. Log levels will include those below it. " +
@@ -241,6 +276,16 @@ func (a *App) parseArguments() {
 	a.Options.Server.Dedicated = kingpin.Flag(serverArg, serverDesc).Short(serverShort).Bool()
 	a.Options.printVersion = kingpin.Flag(versionArg, versionDesc).Short(versionShort).Bool()
 	a.Options.Server.MaxPlayers = kingpin.Flag(playersArg, playersDesc).Int()
+	a.Options.jsonPackets = kingpin.Flag(jsonPacketsArg, jsonPacketsDesc).Bool()
+	a.Options.udp = kingpin.Flag(udpArg, udpDesc).Bool()
+	a.Options.credentials = &d2netpacket.Credentials{}
+	kingpin.Flag(accountArg, accountDesc).StringVar(&a.Options.credentials.Account)
+	kingpin.Flag(passwordArg, passwordDesc).StringVar(&a.Options.credentials.Password)
+	kingpin.Flag(tokenArg, tokenDesc).StringVar(&a.Options.credentials.Token)
+	a.Options.network = &d2client.NetworkConditions{}
+	kingpin.Flag(latencyArg, latencyDesc).DurationVar(&a.Options.network.Latency)
+	kingpin.Flag(jitterArg, jitterDesc).DurationVar(&a.Options.network.Jitter)
+	kingpin.Flag(packetLossArg, packetLossDesc).Float64Var(&a.Options.network.PacketLoss)
 	a.Options.LogLevel = kingpin.Flag(loggingArg, loggingDesc).
 		Short(loggingShort).
 		Default(strconv.Itoa(d2util.LogLevelUnspecified)).
@@ -396,6 +441,9 @@ func (a *App) initialize() error {
 		{"quit", "exits the game", a.quitGame},
 		{"screen-gui", "enters the gui playground screen", a.enterGuiPlayground},
 		{"js", "eval JS scripts", a.evalJS},
+		{"bindkey", "binds a key to a game event: <event> <primary|secondary> <key>", a.bindKey},
+		{"keymapreset", "resets the key bindings to the defaults", a.resetKeyMap},
+		{"keymapexport", "exports the key bindings to a file", a.exportKeyMap},
 	}
 
 	for idx := range terminalActions {
@@ -406,6 +454,13 @@ func (a *App) initialize() error {
 		}
 	}
 
+	keyMap, err := d2player.LoadKeyMap(a.config.KeyMapPath())
+	if err != nil {
+		a.logger.Errorf("key bindings in %s: %v", a.config.KeyMapPath(), err)
+	}
+
+	a.keyMap = keyMap
+
 	gui, err := d2gui.CreateGuiManager(a.asset, a.inputManager)
 	if err != nil {
 		return err
@@ -455,90 +510,13 @@ func (a *App) initConfig(config *d2config.Configuration) error {
 }
 
 func (a *App) initDataDictionaries() error {
-	dictPaths := []string{
-		d2resource.LevelType, d2resource.LevelPreset, d2resource.LevelWarp,
-		d2resource.ObjectType, d2resource.ObjectDetails, d2resource.Weapons,
-		d2resource.Armor, d2resource.Misc, d2resource.Books, d2resource.ItemTypes,
-		d2resource.UniqueItems, d2resource.Missiles, d2resource.SoundSettings,
-		d2resource.MonStats, d2resource.MonStats2, d2resource.MonPreset,
-		d2resource.MonProp, d2resource.MonType, d2resource.MonMode,
-		d2resource.MagicPrefix, d2resource.MagicSuffix, d2resource.ItemStatCost,
-		d2resource.ItemRatio, d2resource.StorePage, d2resource.Overlays,
-		d2resource.CharStats, d2resource.Hireling, d2resource.Experience,
-		d2resource.Gems, d2resource.QualityItems, d2resource.Runes,
-		d2resource.DifficultyLevels, d2resource.AutoMap, d2resource.LevelDetails,
-		d2resource.LevelMaze, d2resource.LevelSubstitutions, d2resource.CubeRecipes,
-		d2resource.SuperUniques, d2resource.Inventory, d2resource.Skills,
-		d2resource.SkillCalc, d2resource.MissileCalc, d2resource.Properties,
-		d2resource.SkillDesc, d2resource.BodyLocations, d2resource.Sets,
-		d2resource.SetItems, d2resource.AutoMagic, d2resource.TreasureClass,
-		d2resource.TreasureClassEx, d2resource.States, d2resource.SoundEnvirons,
-		d2resource.Shrines, d2resource.ElemType, d2resource.PlrMode,
-		d2resource.PetType, d2resource.NPC, d2resource.MonsterUniqueModifier,
-		d2resource.MonsterEquipment, d2resource.UniqueAppellation, d2resource.MonsterLevel,
-		d2resource.MonsterSound, d2resource.MonsterSequence, d2resource.PlayerClass,
-		d2resource.MonsterPlacement, d2resource.ObjectGroup, d2resource.CompCode,
-		d2resource.MonsterAI, d2resource.RarePrefix, d2resource.RareSuffix,
-		d2resource.Events, d2resource.Colors, d2resource.ArmorType,
-		d2resource.WeaponClass, d2resource.PlayerType, d2resource.Composite,
-		d2resource.HitClass, d2resource.UniquePrefix, d2resource.UniqueSuffix,
-		d2resource.CubeModifier, d2resource.CubeType, d2resource.HirelingDescription,
-		d2resource.LowQualityItems,
-	}
-
 	a.logger.Info("Initializing asset manager")
 
-	for _, path := range dictPaths {
-		err := a.asset.LoadRecords(path)
-		if err != nil {
-			return err
-		}
-	}
-
-	err := a.initAnimationData(d2resource.AnimationData)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-const (
-	fmtLoadAnimData = "loading animation data from: %s"
-)
-
-func (a *App) initAnimationData(path string) error {
-	animDataBytes, err := a.asset.LoadFile(path)
-	if err != nil {
-		return err
-	}
-
-	a.logger.Debug(fmt.Sprintf(fmtLoadAnimData, path))
-
-	animData := d2data.LoadAnimationData(animDataBytes)
-
-	a.logger.Infof("Loaded %d animation data records", len(animData))
-
-	a.asset.Records.Animation.Data = animData
-
-	return nil
+	return a.asset.LoadRecordTables()
 }
 
 func (a *App) loadStrings() error {
-	tablePaths := []string{
-		d2resource.PatchStringTable,
-		d2resource.ExpansionStringTable,
-		d2resource.StringTable,
-	}
-
-	for _, tablePath := range tablePaths {
-		_, err := a.asset.LoadStringTable(tablePath)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return a.asset.LoadStringTables()
 }
 
 func (a *App) renderDebug(target d2interface.Surface) {
@@ -824,6 +802,57 @@ func (a *App) setTimeScale(timeScale float64) {
 	}
 }
 
+func (a *App) bindKey(eventName, slot, keyName string) {
+	gameEvent, found := d2enum.GameEventFromString(eventName)
+	if !found {
+		a.terminal.OutputErrorf("unknown game event: %s", eventName)
+		return
+	}
+
+	combo, err := d2player.ParseKeyCombo(keyName)
+	if err != nil {
+		a.terminal.OutputErrorf("%v", err)
+		return
+	}
+
+	switch slot {
+	case "primary":
+		a.keyMap.SetPrimaryBinding(gameEvent, combo)
+	case "secondary":
+		a.keyMap.SetSecondaryBinding(gameEvent, combo)
+	default:
+		a.terminal.OutputErrorf("the binding must be primary or secondary")
+		return
+	}
+
+	if err := a.keyMap.Save(); err != nil {
+		a.terminal.OutputErrorf("could not save the key bindings: %v", err)
+		return
+	}
+
+	a.terminal.OutputInfof("%s is now bound to %s", gameEvent, combo.GetString())
+}
+
+func (a *App) resetKeyMap() {
+	a.keyMap.Reset()
+
+	if err := a.keyMap.Save(); err != nil {
+		a.terminal.OutputErrorf("could not save the key bindings: %v", err)
+		return
+	}
+
+	a.terminal.OutputInfof("key bindings reset to the defaults")
+}
+
+func (a *App) exportKeyMap(path string) {
+	if err := a.keyMap.Export(path); err != nil {
+		a.terminal.OutputErrorf("could not export the key bindings: %v", err)
+		return
+	}
+
+	a.terminal.OutputInfof("key bindings exported to %s", path)
+}
+
 func (a *App) quitGame() {
 	os.Exit(0)
 }
@@ -919,18 +948,29 @@ func (a *App) ToSelectHero(connType d2clientconnectiontype.ClientConnectionType,
 
 // ToCreateGame forces the game to transition to the Create Game screen
 func (a *App) ToCreateGame(filePath string, connType d2clientconnectiontype.ClientConnectionType, host string) {
+	if connType == d2clientconnectiontype.LANClient && *a.Options.udp {
+		connType = d2clientconnectiontype.LANClientUDP
+	}
+
 	gameClient, err := d2client.Create(connType, a.asset, a.scriptEngine)
 	if err != nil {
 		log.Print(err)
 	}
 
+	if *a.Options.jsonPackets {
+		gameClient.SetPacketEncoding(d2netpacket.JSONEncoding)
+	}
+
+	gameClient.SetCredentials(*a.Options.credentials)
+	gameClient.SetNetworkConditions(*a.Options.network)
+
 	if err = gameClient.Open(host, filePath); err != nil {
-		errorMessage := fmt.Sprintf("can not connect to the host: %s", host)
+		errorMessage := fmt.Sprintf("can not connect to the host: %s\n%s", host, err)
 		fmt.Println(errorMessage)
 		a.ToMainMenu(errorMessage)
 	} else {
 		a.screen.SetNextScreen(d2gamescreen.CreateGame(
-			a, a.asset, a.ui, a.renderer, a.inputManager, a.audio, gameClient, a.terminal, a.guiManager,
+			a, a.asset, a.ui, a.renderer, a.inputManager, a.audio, gameClient, a.terminal, a.guiManager, a.keyMap,
 		))
 	}
 }
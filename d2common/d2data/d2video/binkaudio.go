@@ -0,0 +1,347 @@
+package d2video
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+const (
+	audioQuantLevels  = 96
+	audioQuantStep    = 0.15289164787221953823 // 0.0664 / log10(e)
+	audioPacketHeader = 32                     // the number of decoded samples, which is not needed
+	audioFloatBits    = 23
+	audioMaxSample    = 32767
+	audioMinSample    = -32768
+)
+
+// audioDecoder decodes the packets of one audio track. Each packet holds blocks of frequency
+// coefficients, which are transformed with a DCT or a real inverse FFT, and overlap the previous block.
+type audioDecoder struct {
+	channels      int // the channels that are transformed, RDFT tracks transform interleaved samples
+	trackChannels int
+	dct           bool
+	oldFormat     bool
+	frameLength   int
+	overlap       int
+	bands         []int
+	root          float64
+	quant         [audioQuantLevels]float64
+	coefficients  [][]float64
+	previous      [][]float64
+	first         bool
+}
+
+func newAudioDecoder(revision byte, track *BinkAudioTrack) *audioDecoder {
+	d := &audioDecoder{
+		trackChannels: 1,
+		dct:           track.Algorithm == BinkAudioAlgorithmDCT,
+		oldFormat:     revision == 'b',
+		first:         true,
+	}
+
+	if track.Stereo {
+		d.trackChannels = 2
+	}
+
+	sampleRate := int(track.AudioSampleRateHz)
+	frameLengthBits := audioFrameLengthBits(sampleRate)
+	d.channels = d.trackChannels
+
+	if !d.dct {
+		sampleRate *= d.trackChannels
+		d.channels = 1
+
+		if !d.oldFormat && d.trackChannels == 2 {
+			frameLengthBits++
+		}
+	}
+
+	d.frameLength = 1 << uint(frameLengthBits)
+	d.overlap = d.frameLength / 16 //nolint:gomnd // overlap is a 16th of a block
+
+	if d.dct {
+		d.root = float64(d.frameLength) / (math.Sqrt(float64(d.frameLength)) * -audioMinSample)
+	} else {
+		d.root = 2 / (math.Sqrt(float64(d.frameLength)) * -audioMinSample) //nolint:gomnd // scale of the RDFT
+	}
+
+	for i := range d.quant {
+		d.quant[i] = math.Exp(float64(i)*audioQuantStep) * d.root
+	}
+
+	d.initBands(sampleRate)
+
+	d.coefficients = make([][]float64, d.channels)
+	d.previous = make([][]float64, d.channels)
+
+	for ch := range d.coefficients {
+		d.coefficients[ch] = make([]float64, d.frameLength)
+		d.previous[ch] = make([]float64, d.overlap)
+	}
+
+	return d
+}
+
+//nolint:gomnd // block sizes of 512, 1024 and 2048 samples
+func audioFrameLengthBits(sampleRate int) int {
+	switch {
+	case sampleRate < 22050:
+		return 9
+	case sampleRate < 44100:
+		return 10
+	}
+
+	return 11
+}
+
+// initBands splits the coefficients into the bands of the critical frequencies below half the sample rate
+func (d *audioDecoder) initBands(sampleRate int) {
+	halfRate := (sampleRate + 1) / 2 //nolint:gomnd // nyquist frequency
+	numBands := 1
+
+	for ; numBands < len(criticalFrequencies); numBands++ {
+		if halfRate <= criticalFrequencies[numBands-1] {
+			break
+		}
+	}
+
+	d.bands = make([]int, numBands+1)
+	d.bands[0] = 2
+
+	for i := 1; i < numBands; i++ {
+		d.bands[i] = (criticalFrequencies[i-1] * d.frameLength / halfRate) &^ 1
+	}
+
+	d.bands[numBands] = d.frameLength
+}
+
+// decode decodes an audio packet to interleaved 16 bit samples
+func (d *audioDecoder) decode(packet []byte) ([]int16, error) {
+	r := newBitReader(packet)
+	r.skip(audioPacketHeader)
+
+	samples := make([]int16, 0, len(packet)*2) //nolint:gomnd // rough estimate
+
+	for r.bitsLeft() > 0 {
+		d.decodeBlock(r)
+
+		if err := r.err(); err != nil {
+			return samples, err
+		}
+
+		samples = d.appendSamples(samples)
+
+		r.align32()
+	}
+
+	return samples, nil
+}
+
+func (d *audioDecoder) decodeBlock(r *bitReader) {
+	if d.dct {
+		r.skip(2) //nolint:gomnd // unused bits
+	}
+
+	for _, coefficients := range d.coefficients {
+		d.readCoefficients(r, coefficients)
+
+		if d.dct {
+			coefficients[0] *= 2
+			inverseDCT(coefficients)
+		} else {
+			inverseRDFT(coefficients)
+		}
+	}
+
+	count := float64(d.overlap * d.channels)
+
+	for ch, out := range d.coefficients {
+		if !d.first {
+			for i, j := 0, ch; i < d.overlap; i, j = i+1, j+d.channels {
+				out[i] = (d.previous[ch][i]*(count-float64(j)) + out[i]*float64(j)) / count
+			}
+		}
+
+		copy(d.previous[ch], out[d.frameLength-d.overlap:])
+	}
+
+	d.first = false
+}
+
+// readCoefficients reads the coefficients of a channel. The first two are floats, the others are read
+// in runs that share a bit width, and are scaled by the quantizer of their band.
+//nolint:gomnd // bit sizes
+func (d *audioDecoder) readCoefficients(r *bitReader, coefficients []float64) {
+	for i := 0; i < 2; i++ {
+		if d.oldFormat {
+			coefficients[i] = float64(math.Float32frombits(uint32(r.readBits(32)))) * d.root
+		} else {
+			coefficients[i] = readAudioFloat(r) * d.root
+		}
+	}
+
+	quant := make([]float64, len(d.bands)-1)
+
+	for i := range quant {
+		index := r.readBits(8)
+		if index >= audioQuantLevels {
+			index = audioQuantLevels - 1
+		}
+
+		quant[i] = d.quant[index]
+	}
+
+	band := 0
+	q := quant[0]
+
+	for i := 2; i < d.frameLength; {
+		end := i + 8
+
+		switch {
+		case d.oldFormat:
+			end = i + 16
+		case r.readBit() == 1:
+			end = i + audioRunLengths[r.readBits(4)]*8
+		}
+
+		if end > d.frameLength {
+			end = d.frameLength
+		}
+
+		width := r.readBits(4)
+
+		for ; i < end; i++ {
+			for band < len(quant) && d.bands[band] <= i {
+				q = quant[band]
+				band++
+			}
+
+			coefficients[i] = 0
+
+			if width == 0 {
+				continue
+			}
+
+			if value := r.readBits(width); value != 0 {
+				coefficients[i] = q * float64(r.readSign(value))
+			}
+		}
+	}
+}
+
+// readAudioFloat reads a float with a 5 bit exponent, a 23 bit mantissa and a sign bit
+//nolint:gomnd // bit sizes
+func readAudioFloat(r *bitReader) float64 {
+	power := r.readBits(5)
+	value := math.Ldexp(float64(r.readBits(audioFloatBits)), power-audioFloatBits)
+
+	if r.readBit() == 1 {
+		value = -value
+	}
+
+	return value
+}
+
+// appendSamples appends the samples of the last block that do not overlap the next one
+func (d *audioDecoder) appendSamples(samples []int16) []int16 {
+	length := d.frameLength - d.overlap
+
+	for i := 0; i < length; i++ {
+		for _, out := range d.coefficients {
+			samples = append(samples, toSample(out[i]))
+		}
+	}
+
+	return samples
+}
+
+func toSample(value float64) int16 {
+	sample := math.Round(value * -audioMinSample)
+
+	switch {
+	case sample > audioMaxSample:
+		return audioMaxSample
+	case sample < audioMinSample:
+		return audioMinSample
+	}
+
+	return int16(sample)
+}
+
+// inverseDCT computes the DCT-III of the data in place, scaled by 2/N,
+// y[k] = 2/N * (x[0]/2 + sum x[n] * cos(pi*n*(k+1/2)/N)), with a complex FFT of twice the size
+func inverseDCT(data []float64) {
+	n := len(data)
+	buffer := make([]complex128, n*2)
+
+	for i, value := range data {
+		scale := 2 / float64(n)
+		if i == 0 {
+			scale /= 2
+		}
+
+		buffer[i] = cmplx.Rect(value*scale, math.Pi*float64(i)/float64(2*n))
+	}
+
+	fft(buffer)
+
+	for i := range data {
+		data[i] = real(buffer[i])
+	}
+}
+
+// inverseRDFT computes the real signal of the packed spectrum in place. data[0] and data[1] are the real
+// parts of the DC and nyquist coefficients, the other pairs are the real and imaginary parts of the
+// others, y[n] = X0/2 + XN/2*(-1)^n + sum re(Xk)*cos(2*pi*k*n/N) + im(Xk)*sin(2*pi*k*n/N)
+func inverseRDFT(data []float64) {
+	n := len(data)
+	buffer := make([]complex128, n)
+
+	buffer[0] = complex(data[0]/2, 0)
+	buffer[n/2] = complex(data[1]/2, 0)
+
+	for k := 1; k < n/2; k++ {
+		buffer[k] = complex(data[2*k], -data[2*k+1]) / 2
+		buffer[n-k] = cmplx.Conj(buffer[k])
+	}
+
+	fft(buffer)
+
+	for i := range data {
+		data[i] = real(buffer[i])
+	}
+}
+
+// fft computes sum x[n] * e^(2*pi*i*n*k/N) in place, the length must be a power of two
+func fft(data []complex128) {
+	n := len(data)
+
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+
+		j |= bit
+
+		if i < j {
+			data[i], data[j] = data[j], data[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Rect(1, 2*math.Pi/float64(size))
+
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+
+			for k := 0; k < size/2; k++ {
+				even, odd := data[start+k], data[start+k+size/2]*w
+				data[start+k] = even + odd
+				data[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+}
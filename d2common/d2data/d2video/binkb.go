@@ -0,0 +1,249 @@
+package d2video
+
+// bundle sources of revision b files, which code each value with a fixed number of bits
+const (
+	oldSourceBlockTypes = iota
+	oldSourceColors
+	oldSourcePattern
+	oldSourceXOffset
+	oldSourceYOffset
+	oldSourceIntraDC
+	oldSourceInterDC
+	oldSourceIntraQuantizer
+	oldSourceInterQuantizer
+	oldSourceInterCoefficients
+
+	numOldSources
+)
+
+const (
+	oldLengthBits = 13
+	oldKeyYBias   = -15 // keyframes reference the rows above them
+)
+
+// block types of revision b files
+const (
+	oldBlockSkip = iota
+	oldBlockRun
+	oldBlockIntra
+	oldBlockResidue
+	oldBlockInter
+	oldBlockFill
+	oldBlockPattern
+	oldBlockMotion
+	oldBlockRaw
+)
+
+//nolint:gochecknoglobals // bink data
+var (
+	oldBundleBits   = [numOldSources]int{4, 8, 8, 5, 5, 11, 11, 4, 4, 7}
+	oldBundleSigned = [numOldSources]bool{false, false, false, true, true, false, true, false, false, false}
+)
+
+// readOldBundle reads the values of a bundle, signed values are stored with a bias of half their range
+func readOldBundle(r *bitReader, b *bundle, source int) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	bits := oldBundleBits[source]
+	bias := 0
+
+	if oldBundleSigned[source] {
+		bias = 1 << uint(bits-1)
+	}
+
+	for i := 0; i < count; i++ {
+		b.push(r.readBits(bits) - bias)
+	}
+
+	return nil
+}
+
+// oldRunBits returns the size of the run length at the given position, it fits the remaining pixels
+func oldRunBits(position int) int {
+	bits := 0
+
+	for remaining := blockPixels - 1 - position; remaining > 0; remaining >>= 1 {
+		bits++
+	}
+
+	return bits
+}
+
+// decodeOldPlane decodes a plane of a revision b file. The planes are decoded in place, motion vectors
+// reference the current frame.
+func (d *videoDecoder) decodeOldPlane(r *bitReader, plane int, _, keyframe bool) error {
+	dst := d.current[plane]
+
+	for _, b := range d.bundles {
+		b.lengthBits = oldLengthBits
+		b.reset()
+	}
+
+	yBias := 0
+	if keyframe {
+		yBias = oldKeyYBias
+	}
+
+	for by := 0; by < dst.blocksHigh; by++ {
+		for source, b := range d.bundles {
+			if err := readOldBundle(r, b, source); err != nil {
+				return err
+			}
+		}
+
+		for bx := 0; bx < dst.blocksWide; bx++ {
+			block := &blockContext{r: r, dst: dst, prev: dst, x: bx * blockSize, y: by * blockSize}
+
+			if err := d.decodeOldBlock(block, yBias); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.err()
+}
+
+func (d *videoDecoder) decodeOldBlock(b *blockContext, yBias int) error {
+	var pixels [blockPixels]byte
+
+	switch blockType := d.bundles[oldSourceBlockTypes].next(); blockType {
+	case oldBlockSkip:
+	case oldBlockRun:
+		if err := d.oldRunBlock(b.r, &pixels); err != nil {
+			return err
+		}
+
+		putBlock(b.dst, b.offset(), &pixels)
+	case oldBlockIntra:
+		return d.oldDCTBlock(b, oldSourceIntraDC, oldSourceIntraQuantizer, &d.intraQuant)
+	case oldBlockResidue:
+		d.oldMotionBlock(b, yBias)
+
+		var residue [blockPixels]int32
+
+		readResidue(b.r, &residue, d.bundles[oldSourceInterCoefficients].next())
+		addBlock(b.dst, b.offset(), &residue)
+	case oldBlockInter:
+		d.oldMotionBlock(b, yBias)
+
+		return d.oldDCTBlock(b, oldSourceInterDC, oldSourceInterQuantizer, &d.interQuant)
+	case oldBlockFill:
+		fillBlock(b.dst, b.offset(), byte(d.bundles[oldSourceColors].next()), blockSize)
+	case oldBlockPattern:
+		colors := [2]byte{byte(d.bundles[oldSourceColors].next()), byte(d.bundles[oldSourceColors].next())}
+
+		for y := 0; y < blockSize; y++ {
+			pattern := d.bundles[oldSourcePattern].next()
+
+			for x := 0; x < blockSize; x++ {
+				pixels[y*blockSize+x] = colors[pattern&1]
+				pattern >>= 1
+			}
+		}
+
+		putBlock(b.dst, b.offset(), &pixels)
+	case oldBlockMotion:
+		d.oldMotionBlock(b, yBias)
+	case oldBlockRaw:
+		for i := range pixels {
+			pixels[i] = byte(d.bundles[oldSourceColors].next())
+		}
+
+		putBlock(b.dst, b.offset(), &pixels)
+	default:
+		return errBlockType
+	}
+
+	return nil
+}
+
+// oldRunBlock is like runBlock, but the run lengths are read from the block
+func (d *videoDecoder) oldRunBlock(r *bitReader, pixels *[blockPixels]byte) error {
+	scan := binkPatterns[r.readBits(4)][:] //nolint:gomnd // 16 patterns
+	colors := d.bundles[oldSourceColors]
+	i := 0
+
+	for i < blockPixels-1 {
+		same := r.readBit() == 1
+		run := r.readBits(oldRunBits(i)) + 1
+
+		if i+run > blockPixels {
+			return errRunOverflow
+		}
+
+		if same {
+			color := byte(colors.next())
+
+			for j := 0; j < run; j++ {
+				pixels[scan[i+j]] = color
+			}
+		} else {
+			for j := 0; j < run; j++ {
+				pixels[scan[i+j]] = byte(colors.next())
+			}
+		}
+
+		i += run
+	}
+
+	if i == blockPixels-1 {
+		pixels[scan[i]] = byte(colors.next())
+	}
+
+	return nil
+}
+
+// oldMotionBlock copies a block of the current frame. Like the reference decoder, vectors that point
+// outside the plane are ignored.
+func (d *videoDecoder) oldMotionBlock(b *blockContext, yBias int) {
+	x := d.bundles[oldSourceXOffset].next()
+	y := d.bundles[oldSourceYOffset].next() + yBias
+	offset := b.offset() + y*b.dst.stride + x
+	end := (b.dst.blocksHigh*b.dst.stride + b.dst.blocksWide) * blockSize
+
+	if offset < 0 || offset+blockSize*b.dst.stride > end {
+		return
+	}
+
+	// the source may overlap the block, so it is copied before it is drawn
+	var pixels [blockPixels]byte
+
+	for row := 0; row < blockSize; row++ {
+		copy(pixels[row*blockSize:(row+1)*blockSize], b.dst.pixels[offset+row*b.dst.stride:])
+	}
+
+	putBlock(b.dst, b.offset(), &pixels)
+}
+
+func (d *videoDecoder) oldDCTBlock(b *blockContext, dcSource, quantizerSource int,
+	quant *[16][blockPixels]int32) error {
+	var coefficients [blockPixels]int32
+
+	coefficients[0] = int32(d.bundles[dcSource].next())
+
+	quantizer, err := readDCTCoefficients(b.r, &coefficients, d.bundles[quantizerSource].next())
+	if err != nil {
+		return err
+	}
+
+	unquantize(&coefficients, &quant[quantizer])
+	idct(&coefficients)
+
+	if dcSource == oldSourceInterDC {
+		addBlock(b.dst, b.offset(), &coefficients)
+		return nil
+	}
+
+	var pixels [blockPixels]byte
+
+	for i, value := range coefficients {
+		pixels[i] = clampByte(value)
+	}
+
+	putBlock(b.dst, b.offset(), &pixels)
+
+	return nil
+}
@@ -0,0 +1,352 @@
+package d2video
+
+import "errors"
+
+var (
+	errBundleOverflow = errors.New("bink: too many values in bundle")
+	errDCOutOfRange   = errors.New("bink: DC value out of range")
+)
+
+// bundle sources, each kind of value of a plane is coded in its own bundle
+const (
+	sourceBlockTypes = iota
+	sourceSubBlockTypes
+	sourceColors
+	sourcePattern
+	sourceXOffset
+	sourceYOffset
+	sourceIntraDC
+	sourceInterDC
+	sourceRun
+
+	numSources
+)
+
+const (
+	dcStartBits       = 11
+	doneReading       = -1
+	blockTypeRunStart = 12
+)
+
+// bundle holds the values of one source. The values of a row of blocks are read before the blocks of
+// the row are decoded, a bundle is refilled when all its values are used.
+type bundle struct {
+	lengthBits int // the size of the count of values that are read at once
+	tree       symbolTree
+	values     []int
+	decoded    int // the number of values read, or doneReading when there are no more in this plane
+	used       int
+}
+
+func newBundle(capacity int) *bundle {
+	return &bundle{values: make([]int, capacity)}
+}
+
+func (b *bundle) reset() {
+	b.decoded = 0
+	b.used = 0
+}
+
+// next returns the next value of the bundle
+func (b *bundle) next() int {
+	if b.used >= len(b.values) {
+		return 0
+	}
+
+	value := b.values[b.used]
+	b.used++
+
+	return value
+}
+
+// readCount reads the number of values to decode, it returns 0 when the bundle still has values to use
+// or when the bundle is done
+func (b *bundle) readCount(r *bitReader) (int, error) {
+	if b.decoded == doneReading || b.decoded > b.used {
+		return 0, nil
+	}
+
+	count := r.readBits(b.lengthBits)
+	if count == 0 {
+		b.decoded = doneReading
+		return 0, nil
+	}
+
+	if b.decoded+count > len(b.values) {
+		return 0, errBundleOverflow
+	}
+
+	return count, nil
+}
+
+func (b *bundle) fill(count, value int) {
+	for i := 0; i < count; i++ {
+		b.values[b.decoded+i] = value
+	}
+
+	b.decoded += count
+}
+
+func (b *bundle) push(value int) {
+	b.values[b.decoded] = value
+	b.decoded++
+}
+
+// lengthBits returns the size of the value counts of a bundle that holds up to the given number of values
+func lengthBits(values int) int {
+	bits := 0
+
+	for v := values + 511; v > 1; v >>= 1 { //nolint:gomnd // at least 9 bits
+		bits++
+	}
+
+	return bits + 1
+}
+
+// initLengths sets the size of the value counts of the bundles of a plane
+//nolint:gomnd // blocks per row, and values per block
+func (d *videoDecoder) initLengths(width, blocksWide int) {
+	width = (width + 7) &^ 7
+
+	d.bundles[sourceBlockTypes].lengthBits = lengthBits(width >> 3)
+	d.bundles[sourceSubBlockTypes].lengthBits = lengthBits(width >> 4)
+	d.bundles[sourceColors].lengthBits = lengthBits(blocksWide * 64)
+	d.bundles[sourceIntraDC].lengthBits = lengthBits(width >> 3)
+	d.bundles[sourceInterDC].lengthBits = lengthBits(width >> 3)
+	d.bundles[sourceXOffset].lengthBits = lengthBits(width >> 3)
+	d.bundles[sourceYOffset].lengthBits = lengthBits(width >> 3)
+	d.bundles[sourcePattern].lengthBits = lengthBits(blocksWide << 3)
+	d.bundles[sourceRun].lengthBits = lengthBits(blocksWide * 48)
+}
+
+// readBundleTrees reads the trees of all bundles at the start of a plane
+func (d *videoDecoder) readBundleTrees(r *bitReader) {
+	for source, b := range d.bundles[:numSources] {
+		if source == sourceColors {
+			for i := range d.colorHigh {
+				d.colorHigh[i] = readSymbolTree(r)
+			}
+
+			d.colorLast = 0
+		}
+
+		if source != sourceIntraDC && source != sourceInterDC {
+			b.tree = readSymbolTree(r)
+		}
+
+		b.reset()
+	}
+}
+
+// bundleReaders returns the functions that read the values of each source
+func (d *videoDecoder) bundleReaders() []func(*bitReader, *bundle) error {
+	return []func(*bitReader, *bundle) error{
+		d.readBlockTypes,
+		d.readBlockTypes,
+		d.readColors,
+		readPatterns,
+		readMotionValues,
+		readMotionValues,
+		func(r *bitReader, b *bundle) error { return readDCs(r, b, dcStartBits, false) },
+		func(r *bitReader, b *bundle) error { return readDCs(r, b, dcStartBits, true) },
+		readRuns,
+	}
+}
+
+// readBundles refills the bundles for the next row of blocks
+func (d *videoDecoder) readBundles(r *bitReader) error {
+	for source, read := range d.readers {
+		if err := read(r, d.bundles[source]); err != nil {
+			return err
+		}
+	}
+
+	return r.err()
+}
+
+func readRuns(r *bitReader, b *bundle) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	if r.readBit() == 1 {
+		b.fill(count, r.readBits(4)) //nolint:gomnd // 4 bit value
+
+		return nil
+	}
+
+	for i := 0; i < count; i++ {
+		b.push(b.tree.decode(r))
+	}
+
+	return nil
+}
+
+func readMotionValues(r *bitReader, b *bundle) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	if r.readBit() == 1 {
+		b.fill(count, r.readSign(r.readBits(4))) //nolint:gomnd // 4 bit value
+
+		return nil
+	}
+
+	for i := 0; i < count; i++ {
+		b.push(r.readSign(b.tree.decode(r)))
+	}
+
+	return nil
+}
+
+func (d *videoDecoder) readBlockTypes(r *bitReader, b *bundle) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	if d.revision == 'k' {
+		count ^= 0xBB
+
+		if count == 0 {
+			b.decoded = doneReading
+			return nil
+		}
+
+		if b.decoded+count > len(b.values) {
+			return errBundleOverflow
+		}
+	}
+
+	if r.readBit() == 1 {
+		b.fill(count, r.readBits(4)) //nolint:gomnd // 4 bit value
+
+		return nil
+	}
+
+	end := b.decoded + count
+	last := 0
+
+	for b.decoded < end {
+		value := b.tree.decode(r)
+
+		if value < blockTypeRunStart {
+			last = value
+			b.push(value)
+
+			continue
+		}
+
+		run := blockTypeRuns[value-blockTypeRunStart]
+		if end-b.decoded < run {
+			return errBundleOverflow
+		}
+
+		b.fill(run, last)
+	}
+
+	return nil
+}
+
+func readPatterns(r *bitReader, b *bundle) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	for i := 0; i < count; i++ {
+		low := b.tree.decode(r)
+		b.push(low | b.tree.decode(r)<<4) //nolint:gomnd // two nibbles
+	}
+
+	return nil
+}
+
+func (d *videoDecoder) readColors(r *bitReader, b *bundle) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	if r.readBit() == 1 {
+		b.fill(count, d.readColor(r, b))
+
+		return nil
+	}
+
+	for i := 0; i < count; i++ {
+		b.push(d.readColor(r, b))
+	}
+
+	return nil
+}
+
+// readColor reads a color as its high nibble, coded with a tree that depends on the previous high
+// nibble, and its low nibble. Revisions before i code colors as a sign and magnitude around 128.
+//nolint:gomnd // nibbles and bytes
+func (d *videoDecoder) readColor(r *bitReader, b *bundle) int {
+	d.colorLast = d.colorHigh[d.colorLast].decode(r)
+	value := d.colorLast<<4 | b.tree.decode(r)
+
+	if d.revision < 'i' {
+		magnitude := value & 0x7F
+
+		if value&0x80 != 0 {
+			magnitude = -magnitude
+		}
+
+		value = (magnitude + 0x80) & 0xFF
+	}
+
+	return value
+}
+
+// readDCs reads the DC values of DCT blocks, they are coded as the differences to the previous value in
+// groups of 8 that share a bit size
+//nolint:gomnd // bit sizes
+func readDCs(r *bitReader, b *bundle, startBits int, signed bool) error {
+	count, err := b.readCount(r)
+	if count == 0 {
+		return err
+	}
+
+	bits := startBits
+	if signed {
+		bits--
+	}
+
+	value := r.readBits(bits)
+	if signed {
+		value = r.readSign(value)
+	}
+
+	b.push(value)
+
+	count--
+
+	for i := 0; i < count; i += 8 {
+		group := count - i
+		if group > 8 {
+			group = 8
+		}
+
+		size := r.readBits(4)
+
+		for j := 0; j < group; j++ {
+			if size != 0 {
+				value += r.readSign(r.readBits(size))
+			}
+
+			if value < -32768 || value > 32767 {
+				return errDCOutOfRange
+			}
+
+			b.push(value)
+		}
+	}
+
+	return nil
+}
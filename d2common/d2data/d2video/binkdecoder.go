@@ -1,11 +1,29 @@
 package d2video
 
 import (
-	"log"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
 )
 
+var (
+	errInvalidHeader = errors.New("bink: invalid header")
+	errFrameIndex    = errors.New("bink: invalid frame index")
+	errAudioPacket   = errors.New("bink: audio packet exceeds the frame")
+)
+
+const (
+	binkHeaderSize      = 44
+	binkAudioHeaderSize = 12 // per track, the maximum decoded size, sample rate, flags and track id
+	binkFrameIndexSize  = 4
+	binkKeyframeFlag    = 1
+	binkAudioSizeBytes  = 4
+)
+
 // BinkVideoMode is the video mode type
 type BinkVideoMode uint32
 
@@ -67,41 +85,142 @@ type BinkDecoder struct {
 	HasAlphaPlane         bool
 	Grayscale             bool
 
-	// Mask bit 0, as this is defined as a keyframe
+	fpsDividend uint32
+	fpsDivider  uint32
+	video       *videoDecoder
+	audio       []*audioDecoder
+}
+
+// BinkFrame is a decoded frame of a bink video, with the audio that starts with it
+type BinkFrame struct {
+	Index    int
+	Time     time.Duration
+	Keyframe bool
+	Image    *image.RGBA
+
+	// Audio holds the interleaved 16 bit samples of each audio track
+	Audio [][]int16
+}
 
+// WritePNG encodes the image of the frame as a PNG
+func (f *BinkFrame) WritePNG(w io.Writer) error {
+	return png.Encode(w, f.Image)
 }
 
 // CreateBinkDecoder returns a new instance of the bink decoder
-func CreateBinkDecoder(source []byte) *BinkDecoder {
+func CreateBinkDecoder(source []byte) (*BinkDecoder, error) {
 	result := &BinkDecoder{
 		streamReader: d2datautils.CreateStreamReader(source),
 	}
 
-	result.loadHeaderInformation()
+	if err := result.loadHeaderInformation(); err != nil {
+		return nil, err
+	}
+
+	video, err := newVideoDecoder(result.videoCodecRevision, int(result.VideoWidth), int(result.VideoHeight),
+		result.HasAlphaPlane)
+	if err != nil {
+		return nil, err
+	}
 
-	return result
+	result.video = video
+	result.Rewind()
+
+	return result, nil
+}
+
+// FrameCount returns the number of frames of the video
+func (v *BinkDecoder) FrameCount() int {
+	return int(v.numberOfFrames)
 }
 
-// GetNextFrame gets the next frame
-func (v *BinkDecoder) GetNextFrame() {
-	//nolint:gocritic // v.streamReader.SetPosition(uint64(v.FrameIndexTable[i] & 0xFFFFFFFE))
-	lengthOfAudioPackets := v.streamReader.GetUInt32() - 4 //nolint:gomnd // decode magic
-	samplesInPacket := v.streamReader.GetUInt32()
+// FrameDuration returns how long each frame is shown
+func (v *BinkDecoder) FrameDuration() time.Duration {
+	return time.Duration(uint64(time.Second) * uint64(v.fpsDivider) / uint64(v.fpsDividend))
+}
+
+// Rewind restarts decoding at the first frame
+func (v *BinkDecoder) Rewind() {
+	v.frameIndex = 0
+	v.audio = make([]*audioDecoder, len(v.AudioTracks))
+
+	for i := range v.AudioTracks {
+		v.audio[i] = newAudioDecoder(v.videoCodecRevision, &v.AudioTracks[i])
+	}
+}
+
+// DecodeFrame decodes the next frame, it returns io.EOF after the last frame
+func (v *BinkDecoder) DecodeFrame() (*BinkFrame, error) {
+	if v.frameIndex >= v.numberOfFrames {
+		return nil, io.EOF
+	}
+
+	start := v.FrameIndexTable[v.frameIndex] &^ binkKeyframeFlag
+	end := v.FrameIndexTable[v.frameIndex+1] &^ binkKeyframeFlag
 
-	v.streamReader.SkipBytes(int(lengthOfAudioPackets))
+	if end < start || uint64(end) > v.streamReader.GetSize() {
+		return nil, errFrameIndex
+	}
+
+	frame := &BinkFrame{
+		Index:    int(v.frameIndex),
+		Time:     time.Duration(v.frameIndex) * v.FrameDuration(),
+		Keyframe: v.FrameIndexTable[v.frameIndex]&binkKeyframeFlag != 0,
+		Audio:    make([][]int16, len(v.audio)),
+	}
+
+	v.streamReader.SetPosition(uint64(start))
+	remaining := end - start
+
+	for track, decoder := range v.audio {
+		if remaining < binkAudioSizeBytes {
+			return nil, errAudioPacket
+		}
+
+		size := v.streamReader.GetUInt32()
+		remaining -= binkAudioSizeBytes
 
-	log.Printf("Frame %d:\tSamp: %d", v.frameIndex, samplesInPacket)
+		if size > remaining {
+			return nil, errAudioPacket
+		}
 
+		remaining -= size
+		packet := v.streamReader.ReadBytes(int(size))
+
+		// packets that are too small for the sample count are empty
+		if size < binkAudioSizeBytes {
+			continue
+		}
+
+		samples, err := decoder.decode(packet)
+		if err != nil {
+			return nil, err
+		}
+
+		frame.Audio[track] = samples
+	}
+
+	if err := v.video.decode(v.streamReader.ReadBytes(int(remaining)), frame.Keyframe); err != nil {
+		return nil, err
+	}
+
+	frame.Image = v.video.image(v.Grayscale)
 	v.frameIndex++
+
+	return frame, nil
 }
 
 //nolint:gomnd // Decoder magic
-func (v *BinkDecoder) loadHeaderInformation() {
+func (v *BinkDecoder) loadHeaderInformation() error {
+	if v.streamReader.GetSize() < binkHeaderSize {
+		return errInvalidHeader
+	}
+
 	v.streamReader.SetPosition(0)
 	headerBytes := v.streamReader.ReadBytes(3)
 
 	if string(headerBytes) != "BIK" {
-		log.Fatal("Invalid header for bink video")
+		return errInvalidHeader
 	}
 
 	v.videoCodecRevision = v.streamReader.GetByte()
@@ -111,15 +230,26 @@ func (v *BinkDecoder) loadHeaderInformation() {
 	v.streamReader.SkipBytes(4) // Number of frames again?
 	v.VideoWidth = v.streamReader.GetUInt32()
 	v.VideoHeight = v.streamReader.GetUInt32()
-	fpsDividend := v.streamReader.GetUInt32()
-	fpsDivider := v.streamReader.GetUInt32()
-	v.FPS = uint32(float32(fpsDividend) / float32(fpsDivider))
+	v.fpsDividend = v.streamReader.GetUInt32()
+	v.fpsDivider = v.streamReader.GetUInt32()
+
+	if v.fpsDividend == 0 || v.fpsDivider == 0 || v.fpsDividend < v.fpsDivider {
+		return errInvalidHeader
+	}
+
+	v.FPS = uint32(float32(v.fpsDividend) / float32(v.fpsDivider))
 	v.FrameTimeMS = 1000 / v.FPS
 	videoFlags := v.streamReader.GetUInt32()
 	v.VideoMode = BinkVideoMode((videoFlags >> 28) & 0x0F)
 	v.HasAlphaPlane = ((videoFlags >> 20) & 0x1) == 1
 	v.Grayscale = ((videoFlags >> 17) & 0x1) == 1
 	numberOfAudioTracks := v.streamReader.GetUInt32()
+
+	tableSize := uint64(numberOfAudioTracks)*binkAudioHeaderSize + uint64(v.numberOfFrames+1)*binkFrameIndexSize
+	if v.streamReader.GetSize()-binkHeaderSize < tableSize {
+		return errInvalidHeader
+	}
+
 	v.AudioTracks = make([]BinkAudioTrack, numberOfAudioTracks)
 
 	for i := 0; i < int(numberOfAudioTracks); i++ {
@@ -143,4 +273,6 @@ func (v *BinkDecoder) loadHeaderInformation() {
 	for i := 0; i < int(v.numberOfFrames+1); i++ {
 		v.FrameIndexTable[i] = v.streamReader.GetUInt32()
 	}
+
+	return nil
 }
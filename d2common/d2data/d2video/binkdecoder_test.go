@@ -0,0 +1,540 @@
+package d2video
+
+import (
+	"image/color"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+func alignTestStream(w *d2datautils.BitWriter) {
+	for w.BitsWritten()%32 != 0 {
+		w.PushBit(0)
+	}
+}
+
+// testRow is a row of blocks of a plane, with the values each bundle has for the row and the bits of the
+// blocks that are not in a bundle
+type testRow struct {
+	values [numSources][]int
+	bits   func(w *d2datautils.BitWriter)
+}
+
+// writePlane writes a plane with the identity trees. The values of each bundle are read row by row, so a
+// bundle that has no values for a row is done and can not have values in the rows below.
+func writePlane(w *d2datautils.BitWriter, width, blocksWide int, rows ...testRow) {
+	for i := 0; i < numSources-2+treeSymbols; i++ {
+		w.PushBits(0, 4)
+	}
+
+	if width < blockSize {
+		width = blockSize
+	}
+
+	width = (width + 7) &^ 7
+
+	lengths := [numSources]int{
+		lengthBits(width >> 3), lengthBits(width >> 4), lengthBits(blocksWide * 64),
+		lengthBits(blocksWide << 3), lengthBits(width >> 3), lengthBits(width >> 3),
+		lengthBits(width >> 3), lengthBits(width >> 3), lengthBits(blocksWide * 48),
+	}
+
+	var done [numSources]bool
+
+	for _, row := range rows {
+		for source, values := range row.values {
+			if done[source] {
+				if len(values) > 0 {
+					panic("the bundle is done")
+				}
+
+				continue
+			}
+
+			w.PushBits(uint32(len(values)), lengths[source])
+			done[source] = len(values) == 0
+
+			writeBundleValues(w, source, values)
+		}
+
+		if row.bits != nil {
+			row.bits(w)
+		}
+	}
+
+	alignTestStream(w)
+}
+
+// writeBundleValues writes the values of a bundle one by one, with the identity trees all symbols are 4 bits
+func writeBundleValues(w *d2datautils.BitWriter, source int, values []int) {
+	if len(values) == 0 {
+		return
+	}
+
+	switch source {
+	case sourceBlockTypes, sourceSubBlockTypes, sourceRun:
+		w.PushBit(0)
+
+		for _, value := range values {
+			w.PushBits(uint32(value), 4)
+		}
+	case sourceColors:
+		w.PushBit(0)
+
+		for _, value := range values {
+			w.PushBits(uint32(value>>4), 4)
+			w.PushBits(uint32(value&0xF), 4)
+		}
+	case sourceXOffset, sourceYOffset:
+		w.PushBit(0)
+
+		for _, value := range values {
+			sign := uint32(0)
+			if value < 0 {
+				value, sign = -value, 1
+			}
+
+			w.PushBits(uint32(value), 4)
+
+			if value != 0 {
+				w.PushBit(sign)
+			}
+		}
+	default:
+		panic("the test streams do not code this bundle")
+	}
+}
+
+// fillRows returns the rows of a plane of fill blocks of one color
+func fillRows(blocksWide, blocksHigh int, fill byte) []testRow {
+	rows := make([]testRow, blocksHigh)
+
+	for by := range rows {
+		rows[by].values[sourceBlockTypes] = repeatValue(blockFill, blocksWide)
+		rows[by].values[sourceColors] = repeatValue(int(fill), blocksWide)
+	}
+
+	return rows
+}
+
+func repeatValue(value, count int) []int {
+	values := make([]int, count)
+	for i := range values {
+		values[i] = value
+	}
+
+	return values
+}
+
+// testFrame is a frame of a test file, with a packet for each audio track
+type testFrame struct {
+	keyframe bool
+	audio    [][]byte
+	video    []byte
+}
+
+// createTestBink creates a revision i file at 25 frames per second
+func createTestBink(width, height int, tracks []BinkAudioTrack, frames ...testFrame) []byte {
+	start := binkHeaderSize + len(tracks)*binkAudioHeaderSize + (len(frames)+1)*binkFrameIndexSize
+	index := make([]uint32, 0, len(frames)+1)
+	data := d2datautils.CreateStreamWriter()
+	largest := 0
+
+	for _, frame := range frames {
+		offset := uint32(start + len(data.GetBytes()))
+		if frame.keyframe {
+			offset |= binkKeyframeFlag
+		}
+
+		index = append(index, offset)
+		size := len(data.GetBytes())
+
+		for _, packet := range frame.audio {
+			data.PushUint32(uint32(len(packet)))
+			data.PushBytes(packet...)
+		}
+
+		data.PushBytes(frame.video...)
+
+		if size = len(data.GetBytes()) - size; size > largest {
+			largest = size
+		}
+	}
+
+	index = append(index, uint32(start+len(data.GetBytes())))
+
+	sw := d2datautils.CreateStreamWriter()
+	sw.PushBytes('B', 'I', 'K', 'i')
+	sw.PushUint32(uint32(start + len(data.GetBytes())))
+	sw.PushUint32(uint32(len(frames)))
+	sw.PushUint32(uint32(largest))
+	sw.PushUint32(uint32(len(frames)))
+	sw.PushUint32(uint32(width))
+	sw.PushUint32(uint32(height))
+	sw.PushUint32(25)
+	sw.PushUint32(1)
+	sw.PushUint32(0)
+	sw.PushUint32(uint32(len(tracks)))
+
+	for _, track := range tracks {
+		sw.PushUint16(0)
+		sw.PushUint16(track.AudioChannels)
+	}
+
+	for _, track := range tracks {
+		flags := uint16(track.Algorithm) << 12
+		if track.Stereo {
+			flags |= 1 << 13
+		}
+
+		sw.PushUint16(track.AudioSampleRateHz)
+		sw.PushUint16(flags)
+	}
+
+	for _, track := range tracks {
+		sw.PushUint32(track.AudioTrackID)
+	}
+
+	for _, offset := range index {
+		sw.PushUint32(offset)
+	}
+
+	sw.PushBytes(data.GetBytes()...)
+
+	return sw.GetBytes()
+}
+
+// createVideoPacket creates the packet of a frame of the planes, the chroma planes are red difference first
+func createVideoPacket(width int, luma, cr, cb []testRow) []byte {
+	packet := d2datautils.CreateBitWriter()
+	packet.PushBits(0, 32)
+
+	writePlane(packet, width, (width+7)>>3, luma...)
+	writePlane(packet, width>>1, (width+15)>>4, cr...)
+	writePlane(packet, width>>1, (width+15)>>4, cb...)
+
+	return packet.GetBytes()
+}
+
+// createTestVideo creates a file with one frame of a single color
+func createTestVideo(width, height int, y, cb, cr byte) []byte {
+	blocksWide, blocksHigh := (width+7)>>3, (height+7)>>3
+	chromaWide, chromaHigh := (width+15)>>4, (height+15)>>4
+
+	video := createVideoPacket(width, fillRows(blocksWide, blocksHigh, y), fillRows(chromaWide, chromaHigh, cr),
+		fillRows(chromaWide, chromaHigh, cb))
+
+	return createTestBink(width, height, nil, testFrame{keyframe: true, video: video})
+}
+
+func TestBinkDecodeFrame(t *testing.T) {
+	const y, cb, cr = 120, 90, 200
+
+	decoder, err := CreateBinkDecoder(createTestVideo(24, 16, y, cb, cr))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoder.FrameCount() != 1 || decoder.FrameDuration() != 40*time.Millisecond {
+		t.Fatalf("unexpected frame count %d or duration %v", decoder.FrameCount(), decoder.FrameDuration())
+	}
+
+	frame, err := decoder.DecodeFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !frame.Keyframe || frame.Image.Bounds().Dx() != 24 || frame.Image.Bounds().Dy() != 16 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+
+	r, g, b := color.YCbCrToRGB(y, cb, cr)
+	expected := color.RGBA{R: r, G: g, B: b, A: 0xFF}
+
+	for py := 0; py < 16; py++ {
+		for px := 0; px < 24; px++ {
+			if got := frame.Image.RGBAAt(px, py); got != expected {
+				t.Fatalf("pixel %d,%d is %v, expected %v", px, py, got, expected)
+			}
+		}
+	}
+
+	if _, err := decoder.DecodeFrame(); err != io.EOF {
+		t.Fatalf("expected io.EOF after the last frame, got %v", err)
+	}
+
+	decoder.Rewind()
+
+	if _, err := decoder.DecodeFrame(); err != nil {
+		t.Fatalf("failed to decode after rewinding: %v", err)
+	}
+}
+
+// testLuma is the luma of the key frame of the motion test
+func testLuma(x, y int) byte {
+	return byte(16 + x*8 + y*4)
+}
+
+// createMotionVideo creates a 16x16 file with a key frame of raw luma blocks, and a frame that moves them
+// around with motion, residue and skip blocks
+func createMotionVideo(tracks []BinkAudioTrack, audio ...[]byte) []byte {
+	var keyframe [2]testRow
+
+	for by := range keyframe {
+		keyframe[by].values[sourceBlockTypes] = []int{blockRaw, blockRaw}
+
+		for bx := 0; bx < 2; bx++ {
+			for y := 0; y < blockSize; y++ {
+				for x := 0; x < blockSize; x++ {
+					keyframe[by].values[sourceColors] = append(keyframe[by].values[sourceColors],
+						int(testLuma(bx*blockSize+x, by*blockSize+y)))
+				}
+			}
+		}
+	}
+
+	var moved [2]testRow
+
+	// the top left block is the top right one, the top right one is the bottom right one with a residue
+	moved[0].values[sourceBlockTypes] = []int{blockMotion, blockResidue}
+	moved[0].values[sourceXOffset] = []int{8, 0}
+	moved[0].values[sourceYOffset] = []int{0, 8}
+	moved[0].bits = func(w *d2datautils.BitWriter) {
+		w.PushBits(1, 7) // two values
+		w.PushBits(2, 3) // of 4
+		w.PushBits(0, 3) // not in the groups of coefficients 4, 24 and 44
+		w.PushBit(1)     // in the group of 0
+		w.PushBits(0, 2) // +4 for coefficient 0
+		w.PushBits(2, 2) // -4 for coefficient 1
+	}
+
+	// the bottom left block is skipped, the bottom right one is the top left one
+	moved[1].values[sourceBlockTypes] = []int{blockSkip, blockMotion}
+	moved[1].values[sourceXOffset] = []int{-8}
+	moved[1].values[sourceYOffset] = []int{-8}
+
+	chroma := fillRows(1, 1, 128)
+	skipped := []testRow{{values: [numSources][]int{sourceBlockTypes: {blockSkip}}}}
+
+	frames := []testFrame{
+		{keyframe: true, video: createVideoPacket(16, keyframe[:], chroma, chroma)},
+		{video: createVideoPacket(16, moved[:], skipped, skipped)},
+	}
+
+	for i := range frames {
+		if len(audio) > 0 {
+			frames[i].audio = [][]byte{audio[i]}
+		}
+	}
+
+	return createTestBink(16, 16, tracks, frames...)
+}
+
+func expectLuma(t *testing.T, frame *BinkFrame, luma func(x, y int) byte) {
+	t.Helper()
+
+	for y := 0; y < 16; y++ {
+		for x := 0; x < 16; x++ {
+			r, g, b := color.YCbCrToRGB(luma(x, y), 128, 128)
+			expected := color.RGBA{R: r, G: g, B: b, A: 0xFF}
+
+			if got := frame.Image.RGBAAt(x, y); got != expected {
+				t.Fatalf("frame %d: pixel %d,%d is %v, expected %v", frame.Index, x, y, got, expected)
+			}
+		}
+	}
+}
+
+func TestBinkDecodeMotion(t *testing.T) {
+	decoder, err := CreateBinkDecoder(createMotionVideo(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyframe, err := decoder.DecodeFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectLuma(t, keyframe, testLuma)
+
+	frame, err := decoder.DecodeFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if frame.Keyframe || frame.Index != 1 || frame.Time != 40*time.Millisecond {
+		t.Fatalf("unexpected frame %d at %v, keyframe %v", frame.Index, frame.Time, frame.Keyframe)
+	}
+
+	expectLuma(t, frame, func(x, y int) byte {
+		switch {
+		case x < 8 && y < 8:
+			return testLuma(x+8, y)
+		case y < 8:
+			residue := map[int]int{int(binkScan[0]): 4, int(binkScan[1]): -4}[y*blockSize+x-8]
+			return byte(int(testLuma(x, y+8)) + residue)
+		case x < 8:
+			return testLuma(x, y)
+		}
+
+		return testLuma(x-8, y-8)
+	})
+}
+
+// createAudioPacket creates an audio packet of a block of a 22050 Hz mono RDFT track. The DC and nyquist
+// coefficients are floats, the first coefficient after them is quantized with the first band.
+func createAudioPacket(dc, nyquist, first int) []byte {
+	w := d2datautils.CreateBitWriter()
+	w.PushBits(0, 32)
+
+	for _, value := range []int{dc, nyquist} {
+		w.PushBits(23, 5) // the exponent of a whole number
+		w.PushBits(uint32(value), 23)
+		w.PushBit(0)
+	}
+
+	// the quantizers of the 23 bands
+	for i := 0; i < 23; i++ {
+		w.PushBits(0, 8)
+	}
+
+	// the first run of 8 coefficients is 8 bits wide, the 127 other runs are zero
+	w.PushBit(0)
+	w.PushBits(8, 4)
+	w.PushBits(uint32(first), 8)
+	w.PushBit(0)
+
+	for i := 1; i < 8; i++ {
+		w.PushBits(0, 8)
+	}
+
+	for i := 1; i < 128; i++ {
+		w.PushBit(0)
+		w.PushBits(0, 4)
+	}
+
+	alignTestStream(w)
+
+	return w.GetBytes()
+}
+
+func TestBinkDecodeAudio(t *testing.T) {
+	tracks := []BinkAudioTrack{{AudioChannels: 1, AudioSampleRateHz: 22050, Algorithm: BinkAudioAlgorithmFFT}}
+	packet := createAudioPacket(3200, 640, 160)
+
+	decoder, err := CreateBinkDecoder(createMotionVideo(tracks, packet, packet))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(decoder.AudioTracks) != 1 || decoder.AudioTracks[0] != tracks[0] {
+		t.Fatalf("unexpected audio tracks %+v", decoder.AudioTracks)
+	}
+
+	// the samples of a block are scaled by 1/32, the coefficients give 100 + 20*(-1)^n + 10*cos(2*pi*n/1024)
+	const length, overlap = 1024, 64
+
+	block := func(n int) float64 {
+		return 100 + 20*math.Pow(-1, float64(n)) + 10*math.Cos(2*math.Pi*float64(n)/length)
+	}
+
+	// the second block fades in over the end of the first
+	expected := [2]func(n int) float64{block, func(n int) float64 {
+		if n >= overlap {
+			return block(n)
+		}
+
+		return (block(length-overlap+n)*float64(overlap-n) + block(n)*float64(n)) / overlap
+	}}
+
+	for i := range expected {
+		frame, err := decoder.DecodeFrame()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(frame.Audio) != 1 || len(frame.Audio[0]) != length-overlap {
+			t.Fatalf("frame %d: expected %d samples, got %d tracks", i, length-overlap, len(frame.Audio))
+		}
+
+		for n, sample := range frame.Audio[0] {
+			if math.Abs(float64(sample)-expected[i](n)) > 1 {
+				t.Fatalf("frame %d: sample %d is %d, expected %.2f", i, n, sample, expected[i](n))
+			}
+		}
+
+		// the video after the audio packets is decoded as well
+		if frame.Keyframe {
+			expectLuma(t, frame, testLuma)
+		}
+	}
+}
+
+func TestBinkFrameWritePNG(t *testing.T) {
+	decoder, err := CreateBinkDecoder(createTestVideo(16, 16, 200, 128, 128))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frame, err := decoder.DecodeFrame()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "d2video")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "frame.png")
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := frame.WritePNG(file); err != nil {
+		t.Fatal(err)
+	}
+
+	_ = file.Close()
+
+	file, err = os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r, g, b, _ := img.At(8, 8).RGBA(); r>>8 != 200 || g>>8 != 200 || b>>8 != 200 {
+		t.Fatalf("unexpected color %d %d %d", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestBinkInvalidHeader(t *testing.T) {
+	data := createTestVideo(16, 16, 0, 0, 0)
+	data[0] = 'X'
+
+	if _, err := CreateBinkDecoder(data); err == nil {
+		t.Fatal("expected an error for an invalid signature")
+	}
+
+	if _, err := CreateBinkDecoder(data[:20]); err == nil {
+		t.Fatal("expected an error for a truncated header")
+	}
+}
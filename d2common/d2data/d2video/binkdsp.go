@@ -0,0 +1,338 @@
+package d2video
+
+import "math"
+
+// copyBlock copies an 8x8 block of pixels
+func copyBlock(dst *videoPlane, dstOffset int, src *videoPlane, srcOffset int) {
+	for y := 0; y < blockSize; y++ {
+		copy(dst.pixels[dstOffset+y*dst.stride:dstOffset+y*dst.stride+blockSize],
+			src.pixels[srcOffset+y*src.stride:srcOffset+y*src.stride+blockSize])
+	}
+}
+
+// putBlock draws an 8x8 block of pixels
+func putBlock(dst *videoPlane, offset int, pixels *[blockPixels]byte) {
+	for y := 0; y < blockSize; y++ {
+		copy(dst.pixels[offset+y*dst.stride:], pixels[y*blockSize:(y+1)*blockSize])
+	}
+}
+
+// scaleBlock draws an 8x8 block of pixels at twice its size
+func scaleBlock(dst *videoPlane, offset int, pixels *[blockPixels]byte) {
+	for y := 0; y < blockSize*2; y++ {
+		row := dst.pixels[offset+y*dst.stride:]
+
+		for x := 0; x < blockSize*2; x++ {
+			row[x] = pixels[(y>>1)*blockSize+(x>>1)]
+		}
+	}
+}
+
+// fillBlock fills a square block of the given size with one color
+func fillBlock(dst *videoPlane, offset int, color byte, size int) {
+	for y := 0; y < size; y++ {
+		row := dst.pixels[offset+y*dst.stride : offset+y*dst.stride+size]
+
+		for x := range row {
+			row[x] = color
+		}
+	}
+}
+
+// addBlock adds the values of an 8x8 block to the pixels
+func addBlock(dst *videoPlane, offset int, values *[blockPixels]int32) {
+	for y := 0; y < blockSize; y++ {
+		row := dst.pixels[offset+y*dst.stride:]
+
+		for x := 0; x < blockSize; x++ {
+			row[x] = clampByte(int32(row[x]) + values[y*blockSize+x])
+		}
+	}
+}
+
+func clampByte(value int32) byte {
+	switch {
+	case value < 0:
+		return 0
+	case value > math.MaxUint8:
+		return math.MaxUint8
+	}
+
+	return byte(value)
+}
+
+// coefficient list modes of readDCTCoefficients and readResidue
+const (
+	modeGroupStart = iota // a group of 4 coefficients, that splits off 3 more groups of 4
+	modeGroupSplit        // the split off groups of a group that was started
+	modeGroup             // a group of 4 coefficients
+	modeSingle            // a single coefficient
+)
+
+const coefficientListSize = 128
+
+// coefficientList is the list of groups of coefficients that are not read yet. Groups that contain
+// coefficients above the current bit size are read, groups of single coefficients are pushed to the front.
+type coefficientList struct {
+	coefficient [coefficientListSize]int
+	mode        [coefficientListSize]int
+	start, end  int
+}
+
+func newCoefficientList(entries ...[2]int) *coefficientList {
+	list := &coefficientList{start: blockPixels, end: blockPixels}
+
+	for _, entry := range entries {
+		list.pushBack(entry[0], entry[1])
+	}
+
+	return list
+}
+
+func (l *coefficientList) pushBack(coefficient, mode int) {
+	l.coefficient[l.end] = coefficient
+	l.mode[l.end] = mode
+	l.end++
+}
+
+func (l *coefficientList) pushFront(coefficient, mode int) {
+	l.start--
+	l.coefficient[l.start] = coefficient
+	l.mode[l.start] = mode
+}
+
+func (l *coefficientList) clear(pos int) {
+	l.coefficient[pos] = 0
+	l.mode[pos] = 0
+}
+
+// coefficientReader is called for each coefficient that is read, it returns false to stop reading
+type coefficientReader func(coefficient int) bool
+
+// walk visits the groups of the list once for the current bit size, each group is read when the next
+// bit is set
+//nolint:gomnd // groups of 4 coefficients
+func (l *coefficientList) walk(r *bitReader, read coefficientReader) bool {
+	for pos := l.start; pos < l.end; {
+		if l.coefficient[pos]|l.mode[pos] == 0 || r.readBit() == 0 {
+			pos++
+			continue
+		}
+
+		coefficient, mode := l.coefficient[pos], l.mode[pos]
+
+		switch mode {
+		case modeGroupStart, modeGroup:
+			if mode == modeGroupStart {
+				l.coefficient[pos] = coefficient + 4
+				l.mode[pos] = modeGroupSplit
+			} else {
+				l.clear(pos)
+				pos++
+			}
+
+			for i := 0; i < 4; i, coefficient = i+1, coefficient+1 {
+				if r.readBit() == 1 {
+					l.pushFront(coefficient, modeSingle)
+				} else if !read(coefficient) {
+					return false
+				}
+			}
+		case modeGroupSplit:
+			l.mode[pos] = modeGroup
+
+			for i := 0; i < 3; i++ {
+				coefficient += 4
+				l.pushBack(coefficient, modeGroup)
+			}
+		case modeSingle:
+			l.clear(pos)
+			pos++
+
+			if !read(coefficient) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// readDCTCoefficients reads the AC coefficients of a DCT block, from the highest bit size down, and
+// returns the quantizer. A quantizer of -1 means that it is read after the coefficients.
+//nolint:gomnd // bit sizes
+func readDCTCoefficients(r *bitReader, block *[blockPixels]int32, quantizer int) (int, error) {
+	list := newCoefficientList([2]int{4, modeGroupStart}, [2]int{24, modeGroupStart}, [2]int{44, modeGroupStart},
+		[2]int{1, modeSingle}, [2]int{2, modeSingle}, [2]int{3, modeSingle})
+
+	for bits := r.readBits(4) - 1; bits >= 0; bits-- {
+		list.walk(r, func(coefficient int) bool {
+			value := 1 - r.readBit()*2
+
+			if bits > 0 {
+				value = r.readSign(r.readBits(bits) | 1<<uint(bits))
+			}
+
+			block[binkScan[coefficient]] = int32(value)
+
+			return true
+		})
+	}
+
+	if quantizer == -1 {
+		quantizer = r.readBits(4)
+	}
+
+	if quantizer < 0 || quantizer > 15 {
+		return 0, errQuantizer
+	}
+
+	return quantizer, r.err()
+}
+
+// readResidue reads the difference to the motion compensated block, bit plane by bit plane from the
+// highest. At most the given number of bits are set.
+//nolint:gomnd // bit sizes
+func readResidue(r *bitReader, block *[blockPixels]int32, masks int) {
+	list := newCoefficientList([2]int{4, modeGroupStart}, [2]int{24, modeGroupStart}, [2]int{44, modeGroupStart},
+		[2]int{0, modeGroup})
+	nonZero := make([]int, 0, blockPixels)
+
+	for mask := int32(1) << uint(r.readBits(3)); mask != 0; mask >>= 1 {
+		for _, index := range nonZero {
+			if r.readBit() == 0 {
+				continue
+			}
+
+			if block[index] < 0 {
+				block[index] -= mask
+			} else {
+				block[index] += mask
+			}
+
+			if masks--; masks < 0 {
+				return
+			}
+		}
+
+		complete := list.walk(r, func(coefficient int) bool {
+			index := int(binkScan[coefficient])
+			nonZero = append(nonZero, index)
+
+			block[index] = mask
+			if r.readBit() == 1 {
+				block[index] = -mask
+			}
+
+			masks--
+
+			return masks >= 0
+		})
+
+		if !complete {
+			return
+		}
+	}
+}
+
+// unquantize scales the coefficients with the quantizer matrix, which is in scan order
+func unquantize(block *[blockPixels]int32, quant *[blockPixels]int32) {
+	for i, index := range binkScan {
+		block[index] = int32((int64(block[index]) * int64(quant[i])) >> 11) //nolint:gomnd // fixed point
+	}
+}
+
+// quantTables calculates the quantizer matrices. They include the scale factors of the IDCT, the factor
+// of each coefficient is the product of those of its row and column, sqrt(2)*cos(k*pi/16) for k > 0.
+//nolint:gomnd // fixed point
+func quantTables() (intra, inter [16][blockPixels]int32) {
+	var factors [blockSize]float64
+
+	for k := range factors {
+		factors[k] = 1
+
+		if k > 0 {
+			factors[k] = math.Sqrt2 * math.Cos(float64(k)*math.Pi/16)
+		}
+	}
+
+	for i, index := range binkScan {
+		scale := int64(math.Round(factors[index>>3] * factors[index&7] * (1 << 30)))
+
+		for q, ratio := range quantScale {
+			intra[q][i] = int32(intraQuantSeed[index] * scale * ratio[0] / (ratio[1] << 18))
+			inter[q][i] = int32(interQuantSeed[index] * scale * ratio[0] / (ratio[1] << 18))
+		}
+	}
+
+	return intra, inter
+}
+
+// IDCT constants, in 1.11 fixed point
+const (
+	idctA1 = 2896 // 1/sqrt(2)
+	idctA2 = 2217
+	idctA3 = 3784
+	idctA4 = -5352
+)
+
+func idctMul(x, y int32) int32 {
+	return int32(uint32(x)*uint32(y)) >> 11 //nolint:gomnd // fixed point
+}
+
+// idctTransform transforms 8 values that are step apart
+func idctTransform(dst, src []int32, step int, round func(int32) int32) {
+	a0 := src[0] + src[4*step]
+	a1 := src[0] - src[4*step]
+	a2 := src[2*step] + src[6*step]
+	a3 := idctMul(idctA1, src[2*step]-src[6*step])
+	a4 := src[5*step] + src[3*step]
+	a5 := src[5*step] - src[3*step]
+	a6 := src[1*step] + src[7*step]
+	a7 := src[1*step] - src[7*step]
+	b0 := a4 + a6
+	b1 := idctMul(idctA3, a5+a7)
+	b2 := idctMul(idctA4, a5) - b0 + b1
+	b3 := idctMul(idctA1, a6-a4) - b2
+	b4 := idctMul(idctA2, a7) + b3 - b1
+
+	dst[0] = round(a0 + a2 + b0)
+	dst[1*step] = round(a1 + a3 - a2 + b2)
+	dst[2*step] = round(a1 - a3 + a2 + b3)
+	dst[3*step] = round(a0 - a2 - b4)
+	dst[4*step] = round(a0 - a2 + b4)
+	dst[5*step] = round(a1 - a3 + a2 - b3)
+	dst[6*step] = round(a1 + a3 - a2 - b2)
+	dst[7*step] = round(a0 + a2 - b0)
+}
+
+func idctNoRounding(value int32) int32 {
+	return value
+}
+
+func idctRowRounding(value int32) int32 {
+	return (value + 0x7F) >> 8 //nolint:gomnd // fixed point
+}
+
+// idct transforms a block of coefficients to pixel values, the columns first and then the rows
+func idct(block *[blockPixels]int32) {
+	var temp [blockPixels]int32
+
+	for x := 0; x < blockSize; x++ {
+		column := block[x:]
+
+		if column[8]|column[16]|column[24]|column[32]|column[40]|column[48]|column[56] == 0 {
+			for y := 0; y < blockSize; y++ {
+				temp[y*blockSize+x] = column[0]
+			}
+
+			continue
+		}
+
+		idctTransform(temp[x:], column, blockSize, idctNoRounding)
+	}
+
+	for y := 0; y < blockSize; y++ {
+		idctTransform(block[y*blockSize:], temp[y*blockSize:], 1, idctRowRounding)
+	}
+}
@@ -0,0 +1,169 @@
+package d2video
+
+import (
+	"math"
+	"testing"
+)
+
+func isPermutation(values []uint8) bool {
+	var seen [blockPixels]bool
+
+	for _, value := range values {
+		if int(value) >= blockPixels || seen[value] {
+			return false
+		}
+
+		seen[value] = true
+	}
+
+	return len(values) == blockPixels
+}
+
+func TestBinkScanOrders(t *testing.T) {
+	if !isPermutation(binkScan[:]) {
+		t.Error("the coefficient scan is not a permutation")
+	}
+
+	for i := range binkPatterns {
+		if !isPermutation(binkPatterns[i][:]) {
+			t.Errorf("pattern %d is not a permutation", i)
+		}
+	}
+}
+
+func TestHuffmanTreesAreComplete(t *testing.T) {
+	for i, lengths := range treeLengths {
+		sum := 0.0
+
+		for _, length := range lengths {
+			sum += math.Ldexp(1, -int(length))
+		}
+
+		if sum != 1 {
+			t.Errorf("the code lengths of tree %d are not complete: %v", i, sum)
+		}
+	}
+}
+
+func TestBitReader(t *testing.T) {
+	r := newBitReader([]byte{0xA5, 0x3C, 0xFF})
+
+	if value := r.readBits(4); value != 0x5 {
+		t.Errorf("expected 0x5, got %#x", value)
+	}
+
+	if value := r.readBits(12); value != 0x3CA {
+		t.Errorf("expected 0x3CA, got %#x", value)
+	}
+
+	if value := r.readSign(3); value != -3 {
+		t.Errorf("expected -3, got %d", value)
+	}
+
+	r.align32()
+
+	if r.bitsLeft() != 0 || r.err() != nil {
+		t.Error("aligning at the end of the data should not overrun")
+	}
+
+	if r.readBit(); r.err() == nil {
+		t.Error("expected an error after reading past the end")
+	}
+}
+
+func TestIDCTOfDC(t *testing.T) {
+	var block [blockPixels]int32
+
+	block[0] = 100 << 8
+
+	idct(&block)
+
+	for i, value := range block {
+		if value != 100 {
+			t.Fatalf("pixel %d is %d, expected 100", i, value)
+		}
+	}
+}
+
+func TestInverseDCT(t *testing.T) {
+	const n = 64
+
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = math.Sin(float64(i*i)) * 10
+	}
+
+	expected := make([]float64, n)
+
+	for k := range expected {
+		sum := data[0] / 2
+
+		for i := 1; i < n; i++ {
+			sum += data[i] * math.Cos(math.Pi*float64(i)*(float64(k)+0.5)/n)
+		}
+
+		expected[k] = sum * 2 / n
+	}
+
+	inverseDCT(data)
+
+	for k := range data {
+		if math.Abs(data[k]-expected[k]) > 1e-9 {
+			t.Fatalf("sample %d is %v, expected %v", k, data[k], expected[k])
+		}
+	}
+}
+
+func TestInverseRDFT(t *testing.T) {
+	const n = 64
+
+	data := make([]float64, n)
+	for i := range data {
+		data[i] = math.Cos(float64(i*i)) * 10
+	}
+
+	expected := make([]float64, n)
+
+	for i := range expected {
+		sum := data[0]/2 + data[1]/2*math.Pow(-1, float64(i))
+
+		for k := 1; k < n/2; k++ {
+			angle := 2 * math.Pi * float64(k*i) / n
+			sum += data[2*k]*math.Cos(angle) + data[2*k+1]*math.Sin(angle)
+		}
+
+		expected[i] = sum
+	}
+
+	inverseRDFT(data)
+
+	for i := range data {
+		if math.Abs(data[i]-expected[i]) > 1e-9 {
+			t.Fatalf("sample %d is %v, expected %v", i, data[i], expected[i])
+		}
+	}
+}
+
+func TestDecodeSilentAudioPacket(t *testing.T) {
+	track := &BinkAudioTrack{AudioSampleRateHz: 22050, Algorithm: BinkAudioAlgorithmFFT}
+	decoder := newAudioDecoder('i', track)
+
+	// the sample count, the two floats, the quantizers of 23 bands and 128 runs of zero width coefficients,
+	// padded to 32 bits
+	packet := make([]byte, 4+112)
+
+	samples, err := decoder.decode(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(samples) != decoder.frameLength-decoder.overlap {
+		t.Fatalf("expected %d samples, got %d", decoder.frameLength-decoder.overlap, len(samples))
+	}
+
+	for i, sample := range samples {
+		if sample != 0 {
+			t.Fatalf("sample %d is %d, expected silence", i, sample)
+		}
+	}
+}
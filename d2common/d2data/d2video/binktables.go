@@ -0,0 +1,259 @@
+package d2video
+
+// Huffman tree lengths, scan orders and quantizer seeds of the bink video and audio codecs.
+
+const (
+	numTrees      = 16
+	treeSymbols   = 16
+	blockSize     = 8
+	blockPixels   = blockSize * blockSize
+	maxTreeLength = 8
+)
+
+// treeLengths are the code lengths of the symbols of the 16 predefined huffman trees. The codes are
+// canonical, tree 0 is special cased as 4 raw bits.
+//nolint:gochecknoglobals // constant lookup table
+var treeLengths = [numTrees][treeSymbols]uint8{
+	{4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4},
+	{1, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{2, 2, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{2, 3, 3, 4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5, 5, 5},
+	{3, 3, 3, 3, 4, 4, 4, 4, 5, 5, 5, 5, 5, 5, 5, 5},
+	{3, 3, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5},
+	{2, 4, 4, 4, 4, 4, 4, 4, 4, 4, 5, 5, 5, 5, 5, 5},
+	{1, 3, 3, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6},
+	{1, 2, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6, 6},
+	{1, 3, 4, 4, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6, 6, 6},
+	{2, 2, 3, 4, 4, 5, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6},
+	{1, 2, 5, 5, 5, 5, 6, 6, 6, 6, 6, 6, 7, 7, 7, 7},
+	{2, 2, 3, 3, 4, 5, 5, 5, 6, 6, 6, 6, 7, 7, 7, 7},
+	{1, 2, 4, 4, 6, 6, 6, 6, 7, 7, 7, 7, 7, 7, 7, 7},
+	{1, 2, 3, 5, 5, 6, 7, 7, 8, 8, 8, 8, 8, 8, 8, 8},
+	{1, 2, 3, 4, 6, 7, 8, 8, 8, 8, 8, 8, 8, 8, 8, 8},
+}
+
+// binkScan is the order of the coefficients of DCT blocks
+//nolint:gochecknoglobals // constant lookup table
+var binkScan = [blockPixels]uint8{
+	0, 1, 8, 9, 2, 3, 10, 11,
+	4, 5, 12, 13, 6, 7, 14, 15,
+	20, 21, 28, 29, 22, 23, 30, 31,
+	16, 17, 24, 25, 32, 33, 40, 41,
+	34, 35, 42, 43, 48, 49, 56, 57,
+	50, 51, 58, 59, 18, 19, 26, 27,
+	36, 37, 44, 45, 38, 39, 46, 47,
+	52, 53, 60, 61, 54, 55, 62, 63,
+}
+
+// binkPatterns are the orders in which run blocks fill their pixels, as y*8+x
+//nolint:gochecknoglobals // constant lookup table
+var binkPatterns = [16][blockPixels]uint8{
+	{
+		0x00, 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38,
+		0x39, 0x31, 0x29, 0x21, 0x19, 0x11, 0x09, 0x01,
+		0x02, 0x0A, 0x12, 0x1A, 0x22, 0x2A, 0x32, 0x3A,
+		0x3B, 0x33, 0x2B, 0x23, 0x1B, 0x13, 0x0B, 0x03,
+		0x04, 0x0C, 0x14, 0x1C, 0x24, 0x2C, 0x34, 0x3C,
+		0x3D, 0x35, 0x2D, 0x25, 0x1D, 0x15, 0x0D, 0x05,
+		0x06, 0x0E, 0x16, 0x1E, 0x26, 0x2E, 0x36, 0x3E,
+		0x3F, 0x37, 0x2F, 0x27, 0x1F, 0x17, 0x0F, 0x07,
+	},
+	{
+		0x3B, 0x3A, 0x39, 0x38, 0x30, 0x31, 0x32, 0x33,
+		0x2B, 0x2A, 0x29, 0x28, 0x20, 0x21, 0x22, 0x23,
+		0x1B, 0x1A, 0x19, 0x18, 0x10, 0x11, 0x12, 0x13,
+		0x0B, 0x0A, 0x09, 0x08, 0x00, 0x01, 0x02, 0x03,
+		0x04, 0x05, 0x06, 0x07, 0x0F, 0x0E, 0x0D, 0x0C,
+		0x14, 0x15, 0x16, 0x17, 0x1F, 0x1E, 0x1D, 0x1C,
+		0x24, 0x25, 0x26, 0x27, 0x2F, 0x2E, 0x2D, 0x2C,
+		0x34, 0x35, 0x36, 0x37, 0x3F, 0x3E, 0x3D, 0x3C,
+	},
+	{
+		0x19, 0x11, 0x12, 0x1A, 0x1B, 0x13, 0x0B, 0x03,
+		0x02, 0x0A, 0x09, 0x01, 0x00, 0x08, 0x10, 0x18,
+		0x20, 0x28, 0x30, 0x38, 0x39, 0x31, 0x29, 0x2A,
+		0x32, 0x3A, 0x3B, 0x33, 0x2B, 0x23, 0x22, 0x21,
+		0x1D, 0x15, 0x16, 0x1E, 0x1F, 0x17, 0x0F, 0x07,
+		0x06, 0x0E, 0x0D, 0x05, 0x04, 0x0C, 0x14, 0x1C,
+		0x24, 0x2C, 0x34, 0x3C, 0x3D, 0x35, 0x2D, 0x2E,
+		0x36, 0x3E, 0x3F, 0x37, 0x2F, 0x27, 0x26, 0x25,
+	},
+	{
+		0x03, 0x0B, 0x02, 0x0A, 0x01, 0x09, 0x00, 0x08,
+		0x10, 0x18, 0x11, 0x19, 0x12, 0x1A, 0x13, 0x1B,
+		0x23, 0x2B, 0x22, 0x2A, 0x21, 0x29, 0x20, 0x28,
+		0x30, 0x38, 0x31, 0x39, 0x32, 0x3A, 0x33, 0x3B,
+		0x3C, 0x34, 0x3D, 0x35, 0x3E, 0x36, 0x3F, 0x37,
+		0x2F, 0x27, 0x2E, 0x26, 0x2D, 0x25, 0x2C, 0x24,
+		0x1C, 0x14, 0x1D, 0x15, 0x1E, 0x16, 0x1F, 0x17,
+		0x0F, 0x07, 0x0E, 0x06, 0x0D, 0x05, 0x0C, 0x04,
+	},
+	{
+		0x18, 0x19, 0x10, 0x11, 0x08, 0x09, 0x00, 0x01,
+		0x02, 0x03, 0x0A, 0x0B, 0x12, 0x13, 0x1A, 0x1B,
+		0x1C, 0x1D, 0x14, 0x15, 0x0C, 0x0D, 0x04, 0x05,
+		0x06, 0x07, 0x0E, 0x0F, 0x16, 0x17, 0x1E, 0x1F,
+		0x27, 0x26, 0x2F, 0x2E, 0x37, 0x36, 0x3F, 0x3E,
+		0x3D, 0x3C, 0x35, 0x34, 0x2D, 0x2C, 0x25, 0x24,
+		0x23, 0x22, 0x2B, 0x2A, 0x33, 0x32, 0x3B, 0x3A,
+		0x39, 0x38, 0x31, 0x30, 0x29, 0x28, 0x21, 0x20,
+	},
+	{
+		0x00, 0x01, 0x02, 0x03, 0x08, 0x09, 0x0A, 0x0B,
+		0x10, 0x11, 0x12, 0x13, 0x18, 0x19, 0x1A, 0x1B,
+		0x20, 0x21, 0x22, 0x23, 0x28, 0x29, 0x2A, 0x2B,
+		0x30, 0x31, 0x32, 0x33, 0x38, 0x39, 0x3A, 0x3B,
+		0x04, 0x05, 0x06, 0x07, 0x0C, 0x0D, 0x0E, 0x0F,
+		0x14, 0x15, 0x16, 0x17, 0x1C, 0x1D, 0x1E, 0x1F,
+		0x24, 0x25, 0x26, 0x27, 0x2C, 0x2D, 0x2E, 0x2F,
+		0x34, 0x35, 0x36, 0x37, 0x3C, 0x3D, 0x3E, 0x3F,
+	},
+	{
+		0x06, 0x07, 0x0F, 0x0E, 0x0D, 0x05, 0x0C, 0x04,
+		0x03, 0x0B, 0x02, 0x0A, 0x09, 0x01, 0x00, 0x08,
+		0x10, 0x18, 0x11, 0x19, 0x12, 0x1A, 0x13, 0x1B,
+		0x14, 0x1C, 0x15, 0x1D, 0x16, 0x1E, 0x17, 0x1F,
+		0x27, 0x2F, 0x26, 0x2E, 0x25, 0x2D, 0x24, 0x2C,
+		0x23, 0x2B, 0x22, 0x2A, 0x21, 0x29, 0x20, 0x28,
+		0x31, 0x30, 0x38, 0x39, 0x3A, 0x32, 0x3B, 0x33,
+		0x3C, 0x34, 0x3D, 0x35, 0x36, 0x37, 0x3F, 0x3E,
+	},
+	{
+		0x00, 0x08, 0x09, 0x01, 0x02, 0x03, 0x0B, 0x0A,
+		0x12, 0x13, 0x1B, 0x1A, 0x19, 0x11, 0x10, 0x18,
+		0x20, 0x28, 0x29, 0x21, 0x22, 0x23, 0x2B, 0x2A,
+		0x32, 0x31, 0x30, 0x38, 0x39, 0x3A, 0x3B, 0x33,
+		0x34, 0x3C, 0x3D, 0x3E, 0x3F, 0x37, 0x36, 0x35,
+		0x2D, 0x2C, 0x24, 0x25, 0x26, 0x2E, 0x2F, 0x27,
+		0x1F, 0x17, 0x16, 0x1E, 0x1D, 0x1C, 0x14, 0x15,
+		0x0D, 0x0C, 0x04, 0x05, 0x06, 0x0E, 0x0F, 0x07,
+	},
+	{
+		0x18, 0x19, 0x10, 0x11, 0x08, 0x09, 0x00, 0x01,
+		0x02, 0x03, 0x0A, 0x0B, 0x12, 0x13, 0x1A, 0x1B,
+		0x1C, 0x1D, 0x14, 0x15, 0x0C, 0x0D, 0x04, 0x05,
+		0x06, 0x07, 0x0E, 0x0F, 0x16, 0x17, 0x1E, 0x1F,
+		0x26, 0x27, 0x2E, 0x2F, 0x36, 0x37, 0x3E, 0x3F,
+		0x3C, 0x3D, 0x34, 0x35, 0x2C, 0x2D, 0x24, 0x25,
+		0x22, 0x23, 0x2A, 0x2B, 0x32, 0x33, 0x3A, 0x3B,
+		0x38, 0x39, 0x30, 0x31, 0x28, 0x29, 0x20, 0x21,
+	},
+	{
+		0x00, 0x08, 0x01, 0x09, 0x02, 0x0A, 0x03, 0x0B,
+		0x13, 0x1B, 0x12, 0x1A, 0x11, 0x19, 0x10, 0x18,
+		0x20, 0x28, 0x21, 0x29, 0x22, 0x2A, 0x23, 0x2B,
+		0x33, 0x3B, 0x32, 0x3A, 0x31, 0x39, 0x30, 0x38,
+		0x3C, 0x34, 0x3D, 0x35, 0x3E, 0x36, 0x3F, 0x37,
+		0x2F, 0x27, 0x2E, 0x26, 0x2D, 0x25, 0x2C, 0x24,
+		0x1F, 0x17, 0x1E, 0x16, 0x1D, 0x15, 0x1C, 0x14,
+		0x0C, 0x04, 0x0D, 0x05, 0x0E, 0x06, 0x0F, 0x07,
+	},
+	{
+		0x00, 0x08, 0x10, 0x18, 0x19, 0x1A, 0x1B, 0x13,
+		0x0B, 0x03, 0x02, 0x01, 0x09, 0x11, 0x12, 0x0A,
+		0x04, 0x0C, 0x14, 0x1C, 0x1D, 0x1E, 0x1F, 0x17,
+		0x0F, 0x07, 0x06, 0x05, 0x0D, 0x15, 0x16, 0x0E,
+		0x24, 0x2C, 0x34, 0x3C, 0x3D, 0x3E, 0x3F, 0x37,
+		0x2F, 0x27, 0x26, 0x25, 0x2D, 0x35, 0x36, 0x2E,
+		0x20, 0x28, 0x30, 0x38, 0x39, 0x3A, 0x3B, 0x33,
+		0x2B, 0x23, 0x22, 0x21, 0x29, 0x31, 0x32, 0x2A,
+	},
+	{
+		0x00, 0x08, 0x09, 0x01, 0x02, 0x03, 0x0B, 0x0A,
+		0x13, 0x1B, 0x1A, 0x12, 0x11, 0x10, 0x18, 0x19,
+		0x21, 0x20, 0x28, 0x29, 0x2A, 0x22, 0x23, 0x2B,
+		0x33, 0x3B, 0x3A, 0x32, 0x31, 0x39, 0x38, 0x30,
+		0x34, 0x3C, 0x3D, 0x35, 0x36, 0x3E, 0x3F, 0x37,
+		0x2F, 0x27, 0x26, 0x2E, 0x2D, 0x2C, 0x24, 0x25,
+		0x1D, 0x1C, 0x14, 0x15, 0x16, 0x1E, 0x1F, 0x17,
+		0x0E, 0x0F, 0x07, 0x06, 0x05, 0x0D, 0x0C, 0x04,
+	},
+	{
+		0x18, 0x10, 0x08, 0x00, 0x01, 0x02, 0x03, 0x0B,
+		0x13, 0x1B, 0x1A, 0x19, 0x11, 0x0A, 0x09, 0x12,
+		0x1C, 0x14, 0x0C, 0x04, 0x05, 0x06, 0x07, 0x0F,
+		0x17, 0x1F, 0x1E, 0x1D, 0x15, 0x0E, 0x0D, 0x16,
+		0x3C, 0x34, 0x2C, 0x24, 0x25, 0x26, 0x27, 0x2F,
+		0x37, 0x3F, 0x3E, 0x3D, 0x35, 0x2E, 0x2D, 0x36,
+		0x38, 0x30, 0x28, 0x20, 0x21, 0x22, 0x23, 0x2B,
+		0x33, 0x3B, 0x3A, 0x39, 0x31, 0x2A, 0x29, 0x32,
+	},
+	{
+		0x00, 0x08, 0x09, 0x01, 0x02, 0x0A, 0x12, 0x11,
+		0x10, 0x18, 0x19, 0x1A, 0x1B, 0x13, 0x0B, 0x03,
+		0x07, 0x06, 0x0E, 0x0F, 0x17, 0x16, 0x15, 0x0D,
+		0x05, 0x04, 0x0C, 0x14, 0x1C, 0x1D, 0x1E, 0x1F,
+		0x3F, 0x3E, 0x36, 0x37, 0x2F, 0x2E, 0x2D, 0x35,
+		0x3D, 0x3C, 0x34, 0x2C, 0x24, 0x25, 0x26, 0x27,
+		0x38, 0x30, 0x31, 0x39, 0x3A, 0x32, 0x2A, 0x29,
+		0x28, 0x20, 0x21, 0x22, 0x23, 0x2B, 0x33, 0x3B,
+	},
+	{
+		0x00, 0x01, 0x08, 0x09, 0x10, 0x11, 0x18, 0x19,
+		0x20, 0x21, 0x28, 0x29, 0x30, 0x31, 0x38, 0x39,
+		0x3A, 0x3B, 0x32, 0x33, 0x2A, 0x2B, 0x22, 0x23,
+		0x1A, 0x1B, 0x12, 0x13, 0x0A, 0x0B, 0x02, 0x03,
+		0x04, 0x05, 0x0C, 0x0D, 0x14, 0x15, 0x1C, 0x1D,
+		0x24, 0x25, 0x2C, 0x2D, 0x34, 0x35, 0x3C, 0x3D,
+		0x3E, 0x3F, 0x36, 0x37, 0x2E, 0x2F, 0x26, 0x27,
+		0x1E, 0x1F, 0x16, 0x17, 0x0E, 0x0F, 0x06, 0x07,
+	},
+	{
+		0x00, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07,
+		0x0F, 0x0E, 0x0D, 0x0C, 0x0B, 0x0A, 0x09, 0x08,
+		0x10, 0x11, 0x12, 0x13, 0x14, 0x15, 0x16, 0x17,
+		0x1F, 0x1E, 0x1D, 0x1C, 0x1B, 0x1A, 0x19, 0x18,
+		0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27,
+		0x2F, 0x2E, 0x2D, 0x2C, 0x2B, 0x2A, 0x29, 0x28,
+		0x30, 0x31, 0x32, 0x33, 0x34, 0x35, 0x36, 0x37,
+		0x3F, 0x3E, 0x3D, 0x3C, 0x3B, 0x3A, 0x39, 0x38,
+	},
+}
+
+// blockTypeRuns are the lengths of the runs of block types coded with the symbols 12 to 15
+//nolint:gochecknoglobals // constant lookup table
+var blockTypeRuns = [4]int{4, 8, 12, 32}
+
+// intraQuantSeed and interQuantSeed are the quantizer matrices, before scaling by the quantizer and the
+// factors of the IDCT
+//nolint:gochecknoglobals // constant lookup table
+var intraQuantSeed = [blockPixels]int64{
+	16, 16, 16, 19, 16, 19, 22, 22,
+	22, 22, 26, 24, 26, 22, 22, 27,
+	27, 27, 26, 26, 26, 29, 29, 29,
+	27, 27, 27, 26, 34, 34, 34, 29,
+	29, 29, 27, 27, 37, 34, 34, 32,
+	32, 29, 29, 38, 37, 35, 35, 34,
+	35, 40, 40, 40, 38, 38, 48, 48,
+	46, 46, 56, 56, 58, 69, 69, 83,
+}
+
+//nolint:gochecknoglobals // constant lookup table
+var interQuantSeed = [blockPixels]int64{
+	16, 17, 17, 18, 18, 18, 19, 19,
+	19, 19, 20, 20, 20, 20, 20, 21,
+	21, 21, 21, 21, 21, 22, 22, 22,
+	22, 22, 22, 22, 23, 23, 23, 23,
+	23, 23, 23, 23, 24, 24, 24, 25,
+	24, 24, 24, 25, 26, 26, 26, 26,
+	25, 27, 27, 27, 27, 27, 28, 28,
+	28, 28, 30, 30, 30, 31, 31, 33,
+}
+
+// quantScale is the scale of each of the 16 quantizers, as numerator and denominator
+//nolint:gochecknoglobals // constant lookup table
+var quantScale = [16][2]int64{
+	{1, 1}, {4, 3}, {5, 3}, {2, 1}, {7, 3}, {8, 3}, {3, 1}, {7, 2},
+	{4, 1}, {9, 2}, {5, 1}, {6, 1}, {7, 1}, {8, 1}, {9, 1}, {10, 1},
+}
+
+// criticalFrequencies are the upper bounds in Hz of the bands that share a quantizer in audio blocks
+//nolint:gochecknoglobals // constant lookup table
+var criticalFrequencies = [25]int{
+	100, 200, 300, 400, 510, 630, 770, 920, 1080, 1270, 1480, 1720, 2000, 2320, 2700, 3150, 3700, 4400,
+	5300, 6400, 7700, 9500, 12000, 15500, 24500,
+}
+
+// audioRunLengths are the lengths in groups of 8 of the runs of audio coefficients that share a width
+//nolint:gochecknoglobals // constant lookup table
+var audioRunLengths = [16]int{2, 3, 4, 5, 6, 8, 9, 10, 11, 12, 13, 14, 15, 16, 32, 64}
@@ -0,0 +1,143 @@
+package d2video
+
+// huffmanTree decodes the canonical codes of one of the predefined trees
+type huffmanTree struct {
+	raw       bool // tree 0 is not a tree, its symbols are 4 raw bits
+	firstCode [maxTreeLength + 1]int
+	count     [maxTreeLength + 1]int
+	offset    [maxTreeLength + 1]int
+}
+
+//nolint:gochecknoglobals // built once from treeLengths
+var huffmanTrees = buildHuffmanTrees()
+
+func buildHuffmanTrees() [numTrees]*huffmanTree {
+	var trees [numTrees]*huffmanTree
+
+	for idx := range treeLengths {
+		tree := &huffmanTree{raw: idx == 0}
+
+		for _, length := range treeLengths[idx] {
+			tree.count[length]++
+		}
+
+		code, offset := 0, 0
+
+		for length := 1; length <= maxTreeLength; length++ {
+			tree.firstCode[length] = code
+			tree.offset[length] = offset
+			code = (code + tree.count[length]) << 1
+			offset += tree.count[length]
+		}
+
+		trees[idx] = tree
+	}
+
+	return trees
+}
+
+// decode reads the index of the next leaf, the lengths are sorted so the leaves are in code order
+func (t *huffmanTree) decode(r *bitReader) int {
+	if t.raw {
+		return r.readBits(4) //nolint:gomnd // 16 symbols
+	}
+
+	code := 0
+
+	for length := 1; length <= maxTreeLength; length++ {
+		code = code<<1 | r.readBit()
+
+		if index := code - t.firstCode[length]; index < t.count[length] {
+			return t.offset[length] + index
+		}
+	}
+
+	return 0
+}
+
+// symbolTree maps the leaves of one of the predefined trees to the symbols of a bundle
+type symbolTree struct {
+	tree    *huffmanTree
+	symbols [treeSymbols]uint8
+}
+
+func (t *symbolTree) decode(r *bitReader) int {
+	return int(t.symbols[t.tree.decode(r)])
+}
+
+// readSymbolTree reads which tree a bundle uses and how its leaves map to symbols. The mapping is either
+// a list of the first symbols followed by the unused ones in order, or a merge sort of the symbols in
+// up to four passes.
+//nolint:gomnd // bit lengths of the tree header
+func readSymbolTree(r *bitReader) symbolTree {
+	var result symbolTree
+
+	treeIndex := r.readBits(4)
+	result.tree = huffmanTrees[treeIndex]
+
+	if treeIndex == 0 {
+		for i := range result.symbols {
+			result.symbols[i] = uint8(i)
+		}
+
+		return result
+	}
+
+	if r.readBit() == 1 {
+		var used [treeSymbols]bool
+
+		count := r.readBits(3)
+
+		for i := 0; i <= count; i++ {
+			result.symbols[i] = uint8(r.readBits(4))
+			used[result.symbols[i]] = true
+		}
+
+		for symbol := 0; symbol < treeSymbols && count < treeSymbols-1; symbol++ {
+			if !used[symbol] {
+				count++
+				result.symbols[count] = uint8(symbol)
+			}
+		}
+
+		return result
+	}
+
+	passes := r.readBits(2)
+
+	var in, out [treeSymbols]uint8
+
+	for i := range in {
+		in[i] = uint8(i)
+	}
+
+	for pass := 0; pass <= passes; pass++ {
+		size := 1 << uint(pass)
+
+		for start := 0; start < treeSymbols; start += size * 2 {
+			mergeSymbols(r, out[start:start+size*2], in[start:start+size], in[start+size:start+size*2])
+		}
+
+		in, out = out, in
+	}
+
+	result.symbols = in
+
+	return result
+}
+
+// mergeSymbols merges two lists of symbols, a set bit takes the next symbol from the second list
+func mergeSymbols(r *bitReader, dst, first, second []uint8) {
+	for len(first) > 0 && len(second) > 0 {
+		if r.readBit() == 0 {
+			dst[0], first = first[0], first[1:]
+		} else {
+			dst[0], second = second[0], second[1:]
+		}
+
+		dst = dst[1:]
+	}
+
+	copy(dst, first)
+	copy(dst[len(first):], second)
+}
@@ -0,0 +1,499 @@
+package d2video
+
+import (
+	"errors"
+	"image"
+	"image/color"
+)
+
+var (
+	errBlockType     = errors.New("bink: invalid block type")
+	errRunOverflow   = errors.New("bink: run goes past the end of the block")
+	errMotionBounds  = errors.New("bink: motion vector points outside the frame")
+	errQuantizer     = errors.New("bink: quantizer out of range")
+	errVideoRevision = errors.New("bink: unsupported video revision")
+)
+
+// block types
+const (
+	blockSkip = iota
+	blockScaled
+	blockMotion
+	blockRun
+	blockResidue
+	blockIntra
+	blockFill
+	blockInter
+	blockPattern
+	blockRaw
+)
+
+const (
+	planeY = iota
+	planeU
+	planeV
+	planeAlpha
+
+	numPlanes
+)
+
+// videoPlane holds the pixels of one plane. The pixels are padded by a block to the right and bottom, so
+// that scaled blocks at the edges fit.
+type videoPlane struct {
+	width, height          int // the visible size
+	blocksWide, blocksHigh int
+	stride                 int
+	pixels                 []byte
+}
+
+func newVideoPlane(width, height, blocksWide, blocksHigh int) *videoPlane {
+	stride := (blocksWide + 1) * blockSize
+	rows := (blocksHigh + 1) * blockSize
+
+	return &videoPlane{
+		width:      width,
+		height:     height,
+		blocksWide: blocksWide,
+		blocksHigh: blocksHigh,
+		stride:     stride,
+		pixels:     make([]byte, stride*rows),
+	}
+}
+
+// maxOffset returns the offset of the last block of the plane
+func (p *videoPlane) maxOffset() int {
+	return (p.blocksHigh-1)*blockSize*p.stride + (p.blocksWide-1)*blockSize
+}
+
+// videoDecoder decodes the video packets of bink 1 files into planes of Y, U, V and alpha
+type videoDecoder struct {
+	revision   byte
+	width      int
+	height     int
+	hasAlpha   bool
+	swapPlanes bool
+
+	current [numPlanes]*videoPlane
+	last    [numPlanes]*videoPlane
+
+	bundles   [numOldSources]*bundle
+	readers   []func(*bitReader, *bundle) error
+	colorHigh [treeSymbols]symbolTree
+	colorLast int
+
+	intraQuant [16][blockPixels]int32
+	interQuant [16][blockPixels]int32
+}
+
+func newVideoDecoder(revision byte, width, height int, hasAlpha bool) (*videoDecoder, error) {
+	if revision < 'b' || revision > 'k' || revision == 'c' {
+		return nil, errVideoRevision
+	}
+
+	d := &videoDecoder{
+		revision:   revision,
+		width:      width,
+		height:     height,
+		hasAlpha:   hasAlpha,
+		swapPlanes: revision >= 'h',
+	}
+
+	blocks := ((width + 7) >> 3) * ((height + 7) >> 3)
+
+	for i := range d.bundles {
+		d.bundles[i] = newBundle(blocks * blockPixels)
+	}
+
+	d.readers = d.bundleReaders()
+	d.intraQuant, d.interQuant = quantTables()
+
+	for plane := range d.current {
+		d.current[plane] = d.newPlane(plane == planeU || plane == planeV)
+		d.last[plane] = d.newPlane(plane == planeU || plane == planeV)
+	}
+
+	return d, nil
+}
+
+// newPlane creates a plane, chroma planes have half the width and height
+func (d *videoDecoder) newPlane(chroma bool) *videoPlane {
+	if chroma {
+		return newVideoPlane(d.width>>1, d.height>>1, (d.width+15)>>4, (d.height+15)>>4)
+	}
+
+	return newVideoPlane(d.width, d.height, (d.width+7)>>3, (d.height+7)>>3)
+}
+
+// decode decodes a video packet. Each plane starts at a 32 bit boundary, revisions from i on have the
+// size of the plane data before the alpha and luma planes.
+func (d *videoDecoder) decode(packet []byte, keyframe bool) error {
+	r := newBitReader(packet)
+
+	d.current, d.last = d.last, d.current
+
+	if d.revision == 'b' {
+		// old files decode in place, so blocks that are not coded keep the previous frame
+		for plane := range d.current {
+			copy(d.current[plane].pixels, d.last[plane].pixels)
+		}
+	}
+
+	if d.hasAlpha {
+		if d.revision >= 'i' {
+			r.skip(32) //nolint:gomnd // plane size
+		}
+
+		if err := d.decodePlane(r, planeAlpha, false, keyframe); err != nil {
+			return err
+		}
+	}
+
+	if d.revision >= 'i' {
+		r.skip(32) //nolint:gomnd // plane size
+	}
+
+	for plane := planeY; plane <= planeV; plane++ {
+		index := plane
+		if plane != planeY && d.swapPlanes {
+			index = plane ^ 3 //nolint:gomnd // swaps U and V
+		}
+
+		if err := d.decodePlane(r, index, plane != planeY, keyframe); err != nil {
+			return err
+		}
+
+		if r.bitsLeft() <= 0 {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (d *videoDecoder) decodePlane(r *bitReader, plane int, chroma, keyframe bool) error {
+	var err error
+
+	if d.revision == 'b' {
+		err = d.decodeOldPlane(r, plane, chroma, keyframe)
+	} else {
+		err = d.decodeNewPlane(r, plane, chroma)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r.align32()
+
+	return nil
+}
+
+func (d *videoDecoder) decodeNewPlane(r *bitReader, plane int, chroma bool) error {
+	dst, prev := d.current[plane], d.last[plane]
+
+	if d.revision == 'k' && r.readBit() == 1 {
+		fill := byte(r.readBits(8)) //nolint:gomnd // 8 bit color
+
+		for i := range dst.pixels {
+			dst.pixels[i] = fill
+		}
+
+		return nil
+	}
+
+	blocksWide, blocksHigh := dst.blocksWide, dst.blocksHigh
+
+	width := dst.width
+	if width < blockSize {
+		width = blockSize
+	}
+
+	d.initLengths(width, blocksWide)
+	d.readBundleTrees(r)
+
+	for by := 0; by < blocksHigh; by++ {
+		if err := d.readBundles(r); err != nil {
+			return err
+		}
+
+		for bx := 0; bx < blocksWide; bx++ {
+			blockType := d.bundles[sourceBlockTypes].next()
+
+			// the lower halves of scaled blocks were drawn with the row above
+			if blockType == blockScaled && by&1 == 1 {
+				bx++
+				continue
+			}
+
+			block := &blockContext{r: r, dst: dst, prev: prev, x: bx * blockSize, y: by * blockSize}
+
+			if err := d.decodeBlock(block, blockType); err != nil {
+				return err
+			}
+
+			if blockType == blockScaled {
+				bx++
+			}
+		}
+	}
+
+	return r.err()
+}
+
+// blockContext is the position of the block that is decoded
+type blockContext struct {
+	r         *bitReader
+	dst, prev *videoPlane
+	x, y      int
+}
+
+func (b *blockContext) offset() int {
+	return b.y*b.dst.stride + b.x
+}
+
+func (d *videoDecoder) decodeBlock(b *blockContext, blockType int) error {
+	switch blockType {
+	case blockSkip:
+		copyBlock(b.dst, b.offset(), b.prev, b.offset())
+	case blockScaled:
+		return d.decodeScaledBlock(b)
+	case blockMotion:
+		return d.motionBlock(b)
+	case blockRun:
+		var pixels [blockPixels]byte
+
+		if err := d.runBlock(b.r, &pixels); err != nil {
+			return err
+		}
+
+		putBlock(b.dst, b.offset(), &pixels)
+	case blockResidue:
+		return d.residueBlock(b)
+	case blockIntra:
+		var pixels [blockPixels]byte
+
+		if err := d.intraBlock(b.r, &pixels); err != nil {
+			return err
+		}
+
+		putBlock(b.dst, b.offset(), &pixels)
+	case blockFill:
+		fillBlock(b.dst, b.offset(), byte(d.bundles[sourceColors].next()), blockSize)
+	case blockInter:
+		return d.interBlock(b)
+	case blockPattern:
+		var pixels [blockPixels]byte
+
+		d.patternBlock(&pixels)
+		putBlock(b.dst, b.offset(), &pixels)
+	case blockRaw:
+		var pixels [blockPixels]byte
+
+		d.rawBlock(&pixels)
+		putBlock(b.dst, b.offset(), &pixels)
+	default:
+		return errBlockType
+	}
+
+	return nil
+}
+
+// decodeScaledBlock decodes a 16x16 block, it is coded as an 8x8 block that is scaled up
+func (d *videoDecoder) decodeScaledBlock(b *blockContext) error {
+	var pixels [blockPixels]byte
+
+	switch subType := d.bundles[sourceSubBlockTypes].next(); subType {
+	case blockRun:
+		if err := d.runBlock(b.r, &pixels); err != nil {
+			return err
+		}
+	case blockIntra:
+		if err := d.intraBlock(b.r, &pixels); err != nil {
+			return err
+		}
+	case blockFill:
+		fillBlock(b.dst, b.offset(), byte(d.bundles[sourceColors].next()), blockSize*2)
+		return nil
+	case blockPattern:
+		d.patternBlock(&pixels)
+	case blockRaw:
+		d.rawBlock(&pixels)
+	default:
+		return errBlockType
+	}
+
+	scaleBlock(b.dst, b.offset(), &pixels)
+
+	return nil
+}
+
+// runBlock fills the pixels in the order of one of the patterns, with runs of either the same color or
+// of individual colors
+func (d *videoDecoder) runBlock(r *bitReader, pixels *[blockPixels]byte) error {
+	scan := binkPatterns[r.readBits(4)][:] //nolint:gomnd // 16 patterns
+	colors := d.bundles[sourceColors]
+	i := 0
+
+	for i < blockPixels-1 {
+		run := d.bundles[sourceRun].next() + 1
+
+		if i+run > blockPixels {
+			return errRunOverflow
+		}
+
+		if r.readBit() == 1 {
+			color := byte(colors.next())
+
+			for j := 0; j < run; j++ {
+				pixels[scan[i+j]] = color
+			}
+		} else {
+			for j := 0; j < run; j++ {
+				pixels[scan[i+j]] = byte(colors.next())
+			}
+		}
+
+		i += run
+	}
+
+	if i == blockPixels-1 {
+		pixels[scan[i]] = byte(colors.next())
+	}
+
+	return nil
+}
+
+func (d *videoDecoder) intraBlock(r *bitReader, pixels *[blockPixels]byte) error {
+	var coefficients [blockPixels]int32
+
+	coefficients[0] = int32(d.bundles[sourceIntraDC].next())
+
+	quantizer, err := readDCTCoefficients(r, &coefficients, -1)
+	if err != nil {
+		return err
+	}
+
+	unquantize(&coefficients, &d.intraQuant[quantizer])
+	idct(&coefficients)
+
+	for i, value := range coefficients {
+		pixels[i] = clampByte(value)
+	}
+
+	return nil
+}
+
+func (d *videoDecoder) patternBlock(pixels *[blockPixels]byte) {
+	colors := [2]byte{byte(d.bundles[sourceColors].next()), byte(d.bundles[sourceColors].next())}
+
+	for y := 0; y < blockSize; y++ {
+		pattern := d.bundles[sourcePattern].next()
+
+		for x := 0; x < blockSize; x++ {
+			pixels[y*blockSize+x] = colors[pattern&1]
+			pattern >>= 1
+		}
+	}
+}
+
+func (d *videoDecoder) rawBlock(pixels *[blockPixels]byte) {
+	for i := range pixels {
+		pixels[i] = byte(d.bundles[sourceColors].next())
+	}
+}
+
+// motionOffset returns the offset in the previous frame that the next motion vector points to
+func (d *videoDecoder) motionOffset(b *blockContext) (int, error) {
+	x := b.x + d.bundles[sourceXOffset].next()
+	y := b.y + d.bundles[sourceYOffset].next()
+	offset := y*b.prev.stride + x
+
+	// like the reference decoder, only the start of the block is checked, so vectors that point past the
+	// right edge read from the start of the next rows
+	if offset < 0 || offset > b.prev.maxOffset() {
+		return 0, errMotionBounds
+	}
+
+	return offset, nil
+}
+
+func (d *videoDecoder) motionBlock(b *blockContext) error {
+	offset, err := d.motionOffset(b)
+	if err != nil {
+		return err
+	}
+
+	copyBlock(b.dst, b.offset(), b.prev, offset)
+
+	return nil
+}
+
+func (d *videoDecoder) residueBlock(b *blockContext) error {
+	if err := d.motionBlock(b); err != nil {
+		return err
+	}
+
+	var residue [blockPixels]int32
+
+	readResidue(b.r, &residue, b.r.readBits(7)) //nolint:gomnd // 7 bit count
+	addBlock(b.dst, b.offset(), &residue)
+
+	return nil
+}
+
+func (d *videoDecoder) interBlock(b *blockContext) error {
+	if err := d.motionBlock(b); err != nil {
+		return err
+	}
+
+	var coefficients [blockPixels]int32
+
+	coefficients[0] = int32(d.bundles[sourceInterDC].next())
+
+	quantizer, err := readDCTCoefficients(b.r, &coefficients, -1)
+	if err != nil {
+		return err
+	}
+
+	unquantize(&coefficients, &d.interQuant[quantizer])
+	idct(&coefficients)
+	addBlock(b.dst, b.offset(), &coefficients)
+
+	return nil
+}
+
+// image converts the current planes to an RGBA image
+func (d *videoDecoder) image(grayscale bool) *image.RGBA {
+	result := image.NewRGBA(image.Rect(0, 0, d.width, d.height))
+	luma, cb, cr, alpha := d.current[planeY], d.current[planeU], d.current[planeV], d.current[planeAlpha]
+
+	for y := 0; y < d.height; y++ {
+		row := result.Pix[y*result.Stride:]
+
+		for x := 0; x < d.width; x++ {
+			pixel := row[x*4 : x*4+4]
+			lumaValue := luma.pixels[y*luma.stride+x]
+
+			if grayscale {
+				pixel[0], pixel[1], pixel[2] = lumaValue, lumaValue, lumaValue
+			} else {
+				chroma := (y>>1)*cb.stride + x>>1
+				pixel[0], pixel[1], pixel[2] = color.YCbCrToRGB(lumaValue, cb.pixels[chroma], cr.pixels[chroma])
+			}
+
+			pixel[3] = 0xFF
+
+			if d.hasAlpha {
+				a := uint16(alpha.pixels[y*alpha.stride+x])
+
+				for i := 0; i < 3; i++ {
+					pixel[i] = byte(uint16(pixel[i]) * a / 0xFF)
+				}
+
+				pixel[3] = byte(a)
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+package d2video
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const bitsPerByte = 8
+
+var errEndOfData = errors.New("bink: unexpected end of data")
+
+// bitReader reads the bitstreams of bink packets, least significant bit first. Reading past the end of
+// the data gives zero bits and marks the reader as overrun, so the decoders check once per block
+// instead of on every read.
+type bitReader struct {
+	muncher *d2datautils.BitMuncher
+	size    int // in bits
+	overrun bool
+}
+
+func newBitReader(data []byte) *bitReader {
+	return &bitReader{
+		muncher: d2datautils.CreateBitMuncher(data, 0),
+		size:    len(data) * bitsPerByte,
+	}
+}
+
+func (r *bitReader) readBit() int {
+	return r.readBits(1)
+}
+
+// readBits reads an unsigned value of up to 32 bits
+func (r *bitReader) readBits(bits int) int {
+	if left := r.bitsLeft(); bits > left {
+		r.overrun = true
+
+		if left <= 0 {
+			return 0
+		}
+
+		// the bits that are left are the low bits of the value
+		return int(r.muncher.GetBits(left))
+	}
+
+	return int(r.muncher.GetBits(bits))
+}
+
+// readSign negates the value when the next bit is set, zero values have no sign bit
+func (r *bitReader) readSign(value int) int {
+	if value != 0 && r.readBit() == 1 {
+		return -value
+	}
+
+	return value
+}
+
+func (r *bitReader) skip(bits int) {
+	r.muncher.SkipBits(bits)
+
+	if r.bitsLeft() < 0 {
+		r.overrun = true
+	}
+}
+
+// align32 skips to the next 32 bit boundary, packets do not need to be padded to one at their end
+func (r *bitReader) align32() {
+	const alignment = 32
+
+	offset := r.muncher.Offset()
+
+	if rest := offset % alignment; rest != 0 {
+		offset += alignment - rest
+	}
+
+	if offset > r.size {
+		offset = r.size
+	}
+
+	r.muncher.SetOffset(offset)
+}
+
+func (r *bitReader) bitsLeft() int {
+	return r.size - r.muncher.Offset()
+}
+
+func (r *bitReader) err() error {
+	if r.overrun {
+		return errEndOfData
+	}
+
+	return nil
+}
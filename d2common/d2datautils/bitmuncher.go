@@ -90,15 +90,23 @@ func (v *BitMuncher) GetUInt32() uint32 {
 }
 
 // GetBits given a number of bits to read, reads that number of
-// bits and retruns as a uint32
+// bits and retruns as a uint32. The bits are read up to a byte at a time.
 func (v *BitMuncher) GetBits(bits int) uint32 {
-	if bits == 0 {
-		return 0
-	}
-
 	result := uint32(0)
-	for i := 0; i < bits; i++ {
-		result |= v.GetBit() << uint(i)
+
+	for read := 0; read < bits; {
+		shift := v.offset % byteLen
+		take := byteLen - shift
+
+		if take > bits-read {
+			take = bits - read
+		}
+
+		chunk := uint32(v.data[v.offset/byteLen]>>uint(shift)) & (1<<uint(take) - 1)
+		result |= chunk << uint(read)
+		read += take
+		v.offset += take
+		v.bitsRead += take
 	}
 
 	return result
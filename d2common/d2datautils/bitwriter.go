@@ -0,0 +1,52 @@
+package d2datautils
+
+// BitWriter is the counterpart of BitMuncher, it writes values that are not byte-aligned, least significant
+// bit first, such as the bitstreams of DCC files.
+type BitWriter struct {
+	data        []byte
+	bitsWritten int
+}
+
+// CreateBitWriter creates a new BitWriter
+func CreateBitWriter() *BitWriter {
+	return &BitWriter{}
+}
+
+// BitsWritten returns the number of bits written to the BitWriter
+func (v *BitWriter) BitsWritten() int {
+	return v.bitsWritten
+}
+
+// PushBit writes the lowest bit of the given value
+func (v *BitWriter) PushBit(bit uint32) {
+	if v.bitsWritten%byteLen == 0 {
+		v.data = append(v.data, 0)
+	}
+
+	v.data[v.bitsWritten/byteLen] |= byte(bit&oneBit) << uint(v.bitsWritten%byteLen)
+	v.bitsWritten++
+}
+
+// PushBits writes the given number of low bits of the value, it is read back with BitMuncher.GetBits
+func (v *BitWriter) PushBits(value uint32, bits int) {
+	for i := 0; i < bits; i++ {
+		v.PushBit(value >> uint(i))
+	}
+}
+
+// PushSignedBits writes a two's complement value, it is read back with BitMuncher.GetSignedBits
+func (v *BitWriter) PushSignedBits(value, bits int) {
+	v.PushBits(uint32(value), bits)
+}
+
+// PushBitWriter appends all bits written to another BitWriter, without any padding in between
+func (v *BitWriter) PushBitWriter(other *BitWriter) {
+	for i := 0; i < other.bitsWritten; i++ {
+		v.PushBit(uint32(other.data[i/byteLen] >> uint(i%byteLen)))
+	}
+}
+
+// GetBytes returns the written data, the last byte is padded with zero bits
+func (v *BitWriter) GetBytes() []byte {
+	return v.data
+}
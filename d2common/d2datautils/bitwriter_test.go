@@ -0,0 +1,44 @@
+package d2datautils
+
+import (
+	"testing"
+)
+
+func TestBitWriterRoundTrip(t *testing.T) {
+	bw := CreateBitWriter()
+
+	bw.PushBit(1)
+	bw.PushBits(0x2A, 6)
+	bw.PushSignedBits(-3, 5)
+	bw.PushBits(0xDEADBEEF, 32)
+
+	tail := CreateBitWriter()
+	tail.PushBits(5, 3)
+	bw.PushBitWriter(tail)
+
+	if bw.BitsWritten() != 47 {
+		t.Fatalf("expected 47 bits written, got %d", bw.BitsWritten())
+	}
+
+	bm := CreateBitMuncher(bw.GetBytes(), 0)
+
+	if v := bm.GetBit(); v != 1 {
+		t.Fatalf("expected bit 1, got %d", v)
+	}
+
+	if v := bm.GetBits(6); v != 0x2A {
+		t.Fatalf("expected 0x2A, got %X", v)
+	}
+
+	if v := bm.GetSignedBits(5); v != -3 {
+		t.Fatalf("expected -3, got %d", v)
+	}
+
+	if v := bm.GetUInt32(); v != 0xDEADBEEF {
+		t.Fatalf("expected 0xDEADBEEF, got %X", v)
+	}
+
+	if v := bm.GetBits(3); v != 5 {
+		t.Fatalf("expected 5, got %d", v)
+	}
+}
@@ -49,6 +49,16 @@ func (v *StreamWriter) PushUint32(val uint32) {
 	}
 }
 
+// PushInt32 writes a int32 dword to the stream
+func (v *StreamWriter) PushInt32(val int32) {
+	v.PushUint32(uint32(val))
+}
+
+// PushBytes writes a byte slice to the stream
+func (v *StreamWriter) PushBytes(b ...byte) {
+	v.data.Write(b)
+}
+
 // PushUint64 writes a uint64 qword to the stream
 func (v *StreamWriter) PushUint64(val uint64) {
 	for count := 0; count < bytesPerInt64; count++ {
@@ -48,3 +48,18 @@ func TestStreamWriterDword(t *testing.T) {
 		}
 	}
 }
+
+func TestStreamWriterInt32AndBytes(t *testing.T) {
+	sr := CreateStreamWriter()
+	data := []byte{0xFE, 0xFF, 0xFF, 0xFF, 0x12, 0x34}
+
+	sr.PushInt32(-2)
+	sr.PushBytes(0x12, 0x34)
+
+	output := sr.GetBytes()
+	for i, d := range data {
+		if output[i] != d {
+			t.Fatalf("sr.PushInt32() pushed byte %X to %d, but %X was expected instead", output[i], i, d)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package d2enum
+
+// DifficultyType is the difficulty of a game, it selects the normal, nightmare or hell columns of the records
+type DifficultyType int
+
+// Difficulties
+const (
+	DifficultyNormal DifficultyType = iota
+	DifficultyNightmare
+	DifficultyHell
+)
+
+func (d DifficultyType) String() string {
+	switch d {
+	case DifficultyNormal:
+		return "Normal"
+	case DifficultyNightmare:
+		return "Nightmare"
+	case DifficultyHell:
+		return "Hell"
+	}
+
+	return "Unknown"
+}
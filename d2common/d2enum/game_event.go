@@ -1,5 +1,7 @@
 package d2enum
 
+import "strings"
+
 // GameEvent represents an envent in the game engine
 type GameEvent int
 
@@ -68,3 +70,74 @@ const (
 
 	ClearScreen // closes all active menus/panels
 )
+
+// String returns the name of the game event
+func (e GameEvent) String() string {
+	if name, ok := gameEventNames[e]; ok {
+		return name
+	}
+
+	return "Unknown"
+}
+
+// GameEventFromString returns the game event with the given name, the match is case insensitive
+func GameEventFromString(name string) (GameEvent, bool) {
+	for event, eventName := range gameEventNames {
+		if strings.EqualFold(name, eventName) {
+			return event, true
+		}
+	}
+
+	return 0, false
+}
+
+//nolint:gochecknoglobals // lookup table
+var gameEventNames = map[GameEvent]string{
+	ToggleGameMenu:           "ToggleGameMenu",
+	ToggleCharacterPanel:     "ToggleCharacterPanel",
+	ToggleInventoryPanel:     "ToggleInventoryPanel",
+	TogglePartyPanel:         "TogglePartyPanel",
+	ToggleSkillTreePanel:     "ToggleSkillTreePanel",
+	ToggleHirelingPanel:      "ToggleHirelingPanel",
+	ToggleQuestLog:           "ToggleQuestLog",
+	ToggleHelpScreen:         "ToggleHelpScreen",
+	ToggleChatOverlay:        "ToggleChatOverlay",
+	ToggleMessageLog:         "ToggleMessageLog",
+	ToggleRightSkillSelector: "ToggleRightSkillSelector",
+	ToggleLeftSkillSelector:  "ToggleLeftSkillSelector",
+	ToggleAutomap:            "ToggleAutomap",
+	CenterAutomap:            "CenterAutomap",
+	FadeAutomap:              "FadeAutomap",
+	TogglePartyOnAutomap:     "TogglePartyOnAutomap",
+	ToggleNamesOnAutomap:     "ToggleNamesOnAutomap",
+	UseSkill1:                "UseSkill1",
+	UseSkill2:                "UseSkill2",
+	UseSkill3:                "UseSkill3",
+	UseSkill4:                "UseSkill4",
+	UseSkill5:                "UseSkill5",
+	UseSkill6:                "UseSkill6",
+	UseSkill7:                "UseSkill7",
+	UseSkill8:                "UseSkill8",
+	UseSkill9:                "UseSkill9",
+	UseSkill10:               "UseSkill10",
+	UseSkill11:               "UseSkill11",
+	UseSkill12:               "UseSkill12",
+	UseSkill13:               "UseSkill13",
+	UseSkill14:               "UseSkill14",
+	UseSkill15:               "UseSkill15",
+	UseSkill16:               "UseSkill16",
+	SelectPreviousSkill:      "SelectPreviousSkill",
+	SelectNextSkill:          "SelectNextSkill",
+	ToggleBelts:              "ToggleBelts",
+	UseBeltSlot1:             "UseBeltSlot1",
+	UseBeltSlot2:             "UseBeltSlot2",
+	UseBeltSlot3:             "UseBeltSlot3",
+	UseBeltSlot4:             "UseBeltSlot4",
+	SwapWeapons:              "SwapWeapons",
+	ToggleRunWalk:            "ToggleRunWalk",
+	HoldRun:                  "HoldRun",
+	HoldStandStill:           "HoldStandStill",
+	HoldShowGroundItems:      "HoldShowGroundItems",
+	HoldShowPortraits:        "HoldShowPortraits",
+	ClearScreen:              "ClearScreen",
+}
@@ -15,3 +15,23 @@ const (
 	Crafted
 	Tempered
 )
+
+func (q ItemQuality) String() string {
+	names := map[ItemQuality]string{
+		LowQuality: "Low Quality",
+		Normal:     "Normal",
+		Superior:   "Superior",
+		Magic:      "Magic",
+		Set:        "Set",
+		Rare:       "Rare",
+		Unique:     "Unique",
+		Crafted:    "Crafted",
+		Tempered:   "Tempered",
+	}
+
+	if name, found := names[q]; found {
+		return name
+	}
+
+	return "Unknown"
+}
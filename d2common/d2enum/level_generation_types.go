@@ -9,9 +9,10 @@ package d2enum
 // 3 Wilderness level
 type LevelGenerationType int
 
-// Level generation types
+// Level generation types, the values are the ones used in levels.txt
 const (
-	LevelTypeRandomMaze LevelGenerationType = iota
+	LevelTypeNone LevelGenerationType = iota
+	LevelTypeRandomMaze
 	LevelTypePreset
 	LevelTypeWilderness
 )
@@ -42,3 +42,13 @@ const (
 	RegionAct5Baal
 	RegionAct5Lava
 )
+
+// IsTown returns true for the regions of the towns, where monsters do not attack
+func (r RegionIdType) IsTown() bool {
+	switch r {
+	case RegionAct1Town, RegionAct2Town, RegionAct3Town, RegionAct4Town, RegonAct5Town:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,85 @@
+package d2dat
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+)
+
+// transparentIndex is the palette index sprites use for transparent pixels
+const transparentIndex = 0
+
+// alphaThreshold is the 16 bit alpha value below which a pixel is treated as transparent
+const alphaThreshold = 0x8000
+
+// ColorPalette converts the palette to a color.Palette for use with the image packages. Index 0 is the
+// transparent color of sprites, so it is converted to a fully transparent color.
+func ColorPalette(p d2interface.Palette) color.Palette {
+	colors := p.GetColors()
+	result := make(color.Palette, p.NumColors())
+
+	for idx := range result {
+		if idx == transparentIndex || colors[idx] == nil {
+			result[idx] = color.RGBA{}
+			continue
+		}
+
+		result[idx] = color.RGBA{R: colors[idx].R(), G: colors[idx].G(), B: colors[idx].B(), A: mask}
+	}
+
+	return result
+}
+
+// Quantize converts the image to palette indices, keeping the bounds of the image. Transparent pixels become
+// index 0, all other pixels become the closest opaque color of the palette.
+func Quantize(img image.Image, palette color.Palette) *image.Paletted {
+	bounds := img.Bounds()
+	result := image.NewPaletted(bounds, palette)
+	cache := make(map[color.RGBA]uint8)
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a < alphaThreshold {
+				result.SetColorIndex(x, y, transparentIndex)
+				continue
+			}
+
+			c := color.RGBA{R: uint8(r >> colorBits), G: uint8(g >> colorBits), B: uint8(b >> colorBits), A: mask}
+
+			idx, ok := cache[c]
+			if !ok {
+				idx = closestOpaqueIndex(c, palette)
+				cache[c] = idx
+			}
+
+			result.SetColorIndex(x, y, idx)
+		}
+	}
+
+	return result
+}
+
+// closestOpaqueIndex returns the palette index with the smallest squared RGB distance, never index 0
+func closestOpaqueIndex(c color.RGBA, palette color.Palette) uint8 {
+	best, bestDistance := 0, -1
+
+	for idx := transparentIndex + 1; idx < len(palette) && idx < numColors; idx++ {
+		r, g, b, _ := palette[idx].RGBA()
+		dr := int(c.R) - int(r>>colorBits)
+		dg := int(c.G) - int(g>>colorBits)
+		db := int(c.B) - int(b>>colorBits)
+
+		distance := dr*dr + dg*dg + db*db
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = idx, distance
+		}
+
+		if distance == 0 {
+			break
+		}
+	}
+
+	return uint8(best)
+}
@@ -0,0 +1,173 @@
+package d2dc6
+
+import (
+	"errors"
+	"image"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	dc6Version      = 6
+	dc6Flags        = 1 // celfile_serialised
+	terminationByte = 0xee
+	headerSize      = 24
+	frameHeaderSize = 32
+	terminationSize = 4
+	terminatorSize  = 3
+)
+
+var (
+	errNoFrames   = errors.New("a DC6 needs at least one direction with one frame")
+	errFrameCount = errors.New("all directions of a DC6 need the same number of frames")
+)
+
+// Encode creates a DC6 from images indexed by direction and frame. The pixels are palette indices, where
+// index 0 is transparent, and the bounds of each image are the offsets of the frame. An empty image is
+// stored as a single transparent pixel, because frames without scanlines cannot be decoded.
+func Encode(directions [][]*image.Paletted) (*DC6, error) {
+	if len(directions) == 0 || len(directions[0]) == 0 {
+		return nil, errNoFrames
+	}
+
+	dc := &DC6{
+		Version:            dc6Version,
+		Flags:              dc6Flags,
+		Termination:        []byte{terminationByte, terminationByte, terminationByte, terminationByte},
+		Directions:         uint32(len(directions)),
+		FramesPerDirection: uint32(len(directions[0])),
+	}
+
+	for _, frames := range directions {
+		if len(frames) != len(directions[0]) {
+			return nil, errFrameCount
+		}
+
+		for _, img := range frames {
+			dc.Frames = append(dc.Frames, encodeFrameImage(img))
+		}
+	}
+
+	dc.updateFramePointers()
+
+	return dc, nil
+}
+
+func encodeFrameImage(img *image.Paletted) *DC6Frame {
+	bounds := img.Bounds()
+	indexData := make([]byte, bounds.Dx()*bounds.Dy())
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		copy(indexData[(y-bounds.Min.Y)*bounds.Dx():], img.Pix[img.PixOffset(bounds.Min.X, y):img.PixOffset(bounds.Max.X, y)])
+	}
+
+	if bounds.Empty() {
+		bounds.Max = bounds.Min.Add(image.Pt(1, 1))
+		indexData = []byte{0}
+	}
+
+	frameData := EncodeFrame(indexData, bounds.Dx(), bounds.Dy())
+
+	return &DC6Frame{
+		Width:      uint32(bounds.Dx()),
+		Height:     uint32(bounds.Dy()),
+		OffsetX:    int32(bounds.Min.X),
+		OffsetY:    int32(bounds.Min.Y),
+		Length:     uint32(len(frameData)),
+		FrameData:  frameData,
+		Terminator: []byte{terminationByte, terminationByte, terminationByte},
+	}
+}
+
+// updateFramePointers sets the frame pointers and the next block of each frame to the frame positions in
+// the marshaled file
+func (d *DC6) updateFramePointers() {
+	d.FramePointers = make([]uint32, len(d.Frames))
+	offset := uint32(headerSize + len(d.Frames)*4) //nolint:gomnd // size of a frame pointer
+
+	for idx, frame := range d.Frames {
+		d.FramePointers[idx] = offset
+		offset += frameHeaderSize + uint32(len(frame.FrameData)) + terminatorSize
+		frame.NextBlock = offset
+	}
+}
+
+// EncodeFrame encodes the palette indices of a frame to RLE scanlines, it is the inverse of DecodeFrame.
+// Scanlines are stored bottom up, transparent pixels at the end of a scanline are not stored.
+func EncodeFrame(indexData []byte, width, height int) []byte {
+	data := make([]byte, 0, len(indexData)+height)
+
+	for y := height - 1; y >= 0; y-- {
+		row := indexData[y*width : (y+1)*width]
+
+		for x := 0; x < width; {
+			transparent := 0
+			for x+transparent < width && row[x+transparent] == 0 {
+				transparent++
+			}
+
+			if x+transparent == width {
+				break
+			}
+
+			x += transparent
+
+			for ; transparent > 0; transparent -= maxRunLength {
+				data = append(data, endOfScanLine|byte(d2math.MinInt(transparent, maxRunLength)))
+			}
+
+			opaque := 0
+			for x+opaque < width && row[x+opaque] != 0 && opaque < maxRunLength {
+				opaque++
+			}
+
+			data = append(data, byte(opaque))
+			data = append(data, row[x:x+opaque]...)
+			x += opaque
+		}
+
+		data = append(data, endOfScanLine)
+	}
+
+	return data
+}
+
+// Marshal encodes the DC6 back to the file format read by Load
+func (d *DC6) Marshal() []byte {
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushInt32(d.Version)
+	sw.PushUint32(d.Flags)
+	sw.PushUint32(d.Encoding)
+	sw.PushBytes(fixedSize(d.Termination, terminationSize)...)
+	sw.PushUint32(d.Directions)
+	sw.PushUint32(d.FramesPerDirection)
+
+	for _, pointer := range d.FramePointers {
+		sw.PushUint32(pointer)
+	}
+
+	for _, frame := range d.Frames {
+		sw.PushUint32(frame.Flipped)
+		sw.PushUint32(frame.Width)
+		sw.PushUint32(frame.Height)
+		sw.PushInt32(frame.OffsetX)
+		sw.PushInt32(frame.OffsetY)
+		sw.PushUint32(frame.Unknown)
+		sw.PushUint32(frame.NextBlock)
+		sw.PushUint32(uint32(len(frame.FrameData)))
+		sw.PushBytes(frame.FrameData...)
+		sw.PushBytes(fixedSize(frame.Terminator, terminatorSize)...)
+	}
+
+	return sw.GetBytes()
+}
+
+// fixedSize pads or truncates the data to the given size, for fields that have to be an exact size
+func fixedSize(data []byte, size int) []byte {
+	result := make([]byte, size)
+	copy(result, data)
+
+	return result
+}
@@ -0,0 +1,91 @@
+package d2dc6
+
+import (
+	"bytes"
+	"image"
+	"math/rand"
+	"testing"
+)
+
+func testFrame(rng *rand.Rand, bounds image.Rectangle) *image.Paletted {
+	img := image.NewPaletted(bounds, nil)
+
+	for idx := range img.Pix {
+		if rng.Intn(3) > 0 { //nolint:gomnd // a third of the pixels are transparent
+			img.Pix[idx] = byte(rng.Intn(256))
+		}
+	}
+
+	// long runs of both kinds
+	for x := bounds.Min.X; x < bounds.Max.X && bounds.Dy() > 2; x++ {
+		img.SetColorIndex(x, bounds.Min.Y, 0)
+		img.SetColorIndex(x, bounds.Min.Y+1, 17)
+	}
+
+	return img
+}
+
+func TestDC6_EncodeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	directions := [][]*image.Paletted{
+		{testFrame(rng, image.Rect(-20, -90, 280, 5)), testFrame(rng, image.Rect(0, 0, 1, 1))},
+		{testFrame(rng, image.Rect(3, 4, 10, 300)), image.NewPaletted(image.Rect(5, 5, 5, 5), nil)},
+	}
+
+	encoded, err := Encode(directions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dc, err := Load(encoded.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(dc.Marshal(), encoded.Marshal()) {
+		t.Error("marshaling a loaded DC6 changed the data")
+	}
+
+	for dirIdx, frames := range directions {
+		for frameIdx, want := range frames {
+			got := dc.FrameImage(dirIdx*len(frames)+frameIdx, nil)
+
+			if want.Rect.Empty() {
+				if got.Rect.Dx() != 1 || got.Rect.Dy() != 1 || got.Pix[0] != 0 {
+					t.Errorf("direction %d frame %d: expected a single transparent pixel", dirIdx, frameIdx)
+				}
+
+				continue
+			}
+
+			if got.Rect != want.Rect {
+				t.Errorf("direction %d frame %d: expected bounds %v, got %v", dirIdx, frameIdx, want.Rect, got.Rect)
+			}
+
+			if !bytes.Equal(got.Pix, want.Pix) {
+				t.Errorf("direction %d frame %d: pixels differ", dirIdx, frameIdx)
+			}
+		}
+	}
+
+	sheet := dc.DirectionImage(0, nil)
+	if sheet.Rect != image.Rect(0, 0, 600, 95) {
+		t.Fatalf("unexpected sheet bounds %v", sheet.Rect)
+	}
+
+	if got, want := sheet.ColorIndexAt(300+20, 90), directions[0][1].Pix[0]; got != want {
+		t.Errorf("expected the second frame at its offset in the sheet, got color %d instead of %d", got, want)
+	}
+}
+
+func TestDC6_EncodeFrameCount(t *testing.T) {
+	frame := image.NewPaletted(image.Rect(0, 0, 1, 1), nil)
+
+	if _, err := Encode([][]*image.Paletted{{frame, frame}, {frame}}); err == nil {
+		t.Error("expected an error for directions with different frame counts")
+	}
+
+	if _, err := Encode(nil); err == nil {
+		t.Error("expected an error without frames")
+	}
+}
@@ -0,0 +1,47 @@
+package d2dc6
+
+import (
+	"image"
+	"image/color"
+)
+
+// FrameImage decodes the given frame to a paletted image, the bounds of the image are the offsets of the
+// frame. Pass the image to png.Encode to export the frame.
+func (d *DC6) FrameImage(frameIndex int, palette color.Palette) *image.Paletted {
+	frame := d.Frames[frameIndex]
+	bounds := image.Rect(0, 0, int(frame.Width), int(frame.Height)).
+		Add(image.Pt(int(frame.OffsetX), int(frame.OffsetY)))
+
+	return &image.Paletted{
+		Pix:     d.DecodeFrame(frameIndex),
+		Stride:  int(frame.Width),
+		Rect:    bounds,
+		Palette: palette,
+	}
+}
+
+// DirectionImage decodes all frames of the given direction into a single sheet, with the frames side by
+// side from left to right. Every frame gets a cell the size of the union of the frame bounds, so the
+// frames stay aligned to each other.
+func (d *DC6) DirectionImage(direction int, palette color.Palette) *image.Paletted {
+	frames := make([]*image.Paletted, d.FramesPerDirection)
+	union := image.Rectangle{}
+
+	for idx := range frames {
+		frames[idx] = d.FrameImage(direction*int(d.FramesPerDirection)+idx, palette)
+		union = union.Union(frames[idx].Rect)
+	}
+
+	sheet := image.NewPaletted(image.Rect(0, 0, union.Dx()*len(frames), union.Dy()), palette)
+
+	for idx, frame := range frames {
+		cell := image.Pt(idx*union.Dx()-union.Min.X, -union.Min.Y)
+
+		for y := frame.Rect.Min.Y; y < frame.Rect.Max.Y; y++ {
+			copy(sheet.Pix[sheet.PixOffset(frame.Rect.Min.X+cell.X, y+cell.Y):],
+				frame.Pix[frame.PixOffset(frame.Rect.Min.X, y):frame.PixOffset(frame.Rect.Max.X, y)])
+		}
+	}
+
+	return sheet
+}
@@ -11,6 +11,9 @@ import (
 
 const cellsPerRow = 4
 
+// crazyBitTable maps the 4 bit codes of the direction header to field sizes in bits
+var crazyBitTable = []byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32} //nolint:gochecknoglobals // const table
+
 // DCCDirection represents a DCCDirection file.
 type DCCDirection struct {
 	OutSizeCoded               int
@@ -38,8 +41,6 @@ type DCCDirection struct {
 
 // CreateDCCDirection creates an instance of a DCCDirection.
 func CreateDCCDirection(bm *d2datautils.BitMuncher, file *DCC) *DCCDirection {
-	var crazyBitTable = []byte{0, 1, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 26, 28, 30, 32}
-
 	result := &DCCDirection{
 		OutSizeCoded:     int(bm.GetUInt32()),
 		CompressionFlags: int(bm.GetBits(2)),                //nolint:gomnd // binary data
@@ -0,0 +1,442 @@
+package d2dcc
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/bits"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	dccVersion        = 6
+	fileHeaderSize    = 15 // signature, version, direction count and three int32 fields
+	bitstreamSizeBits = 20
+	fieldSizeCodeBits = 4
+	pixelMaskBits     = 4
+	displacementBits  = 4
+	maxDisplacement   = 15
+	maxCellColors     = 4
+	fullPixelMask     = 0x0f
+	maxDirections     = 255
+	colorShift        = 8 // color.Color components are 16 bit
+)
+
+var (
+	errNoFrames          = errors.New("a DCC needs at least one direction with one frame")
+	errFrameCount        = errors.New("all directions of a DCC need the same number of frames")
+	errTooManyDirections = errors.New("a DCC has at most 255 directions")
+	errBitstreamTooLarge = errors.New("a DCC direction bitstream exceeds the maximum size")
+)
+
+// Encode creates a DCC from images indexed by direction and frame. The pixels are palette indices, where
+// index 0 is transparent, and the bounds of each image are the box of the frame.
+//
+// A DCC stores at most four colors in each 4x4 cell of a direction. Frames that use more colors in a cell
+// lose the least used ones, those pixels get the closest kept color of the image palette. All other frames
+// decode to exactly the encoded pixels.
+func Encode(directions [][]*image.Paletted) (*DCC, error) {
+	if len(directions) == 0 || len(directions[0]) == 0 {
+		return nil, errNoFrames
+	}
+
+	if len(directions) > maxDirections {
+		return nil, errTooManyDirections
+	}
+
+	encoded := make([][]byte, len(directions))
+	totalSize := 0
+
+	for idx, frames := range directions {
+		if len(frames) != len(directions[0]) {
+			return nil, errFrameCount
+		}
+
+		data, err := encodeDirection(frames)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded[idx] = data
+		totalSize += len(data)
+	}
+
+	sw := d2datautils.CreateStreamWriter()
+	sw.PushByte(dccFileSignature)
+	sw.PushByte(dccVersion)
+	sw.PushByte(byte(len(directions)))
+	sw.PushInt32(int32(len(directions[0])))
+	sw.PushInt32(1)
+	sw.PushInt32(int32(totalSize))
+
+	offset := fileHeaderSize + len(directions)*4 //nolint:gomnd // size of a direction offset
+
+	for _, data := range encoded {
+		sw.PushInt32(int32(offset))
+		offset += len(data)
+	}
+
+	for _, data := range encoded {
+		sw.PushBytes(data...)
+	}
+
+	return Load(sw.GetBytes())
+}
+
+// Marshal returns the DCC in the file format read by Load
+func (d *DCC) Marshal() []byte {
+	return d.fileData
+}
+
+// encodeDirection encodes the frames of a direction without the equal cell and raw pixel bitstreams, every
+// cell of every frame is coded in full.
+func encodeDirection(images []*image.Paletted) ([]byte, error) {
+	dir := &DCCDirection{Frames: make([]*DCCDirectionFrame, len(images))}
+
+	for idx, img := range images {
+		bounds := img.Bounds()
+		if bounds.Empty() {
+			bounds.Max = bounds.Min.Add(image.Pt(1, 1))
+		}
+
+		dir.Frames[idx] = &DCCDirectionFrame{
+			Box:     d2geom.Rectangle{Left: bounds.Min.X, Top: bounds.Min.Y, Width: bounds.Dx(), Height: bounds.Dy()},
+			Width:   bounds.Dx(),
+			Height:  bounds.Dy(),
+			XOffset: bounds.Min.X,
+			YOffset: bounds.Max.Y - 1,
+		}
+
+		if idx == 0 {
+			dir.Box = dir.Frames[idx].Box
+		} else {
+			dir.Box = unionBox(dir.Box, dir.Frames[idx].Box)
+		}
+	}
+
+	widthCode, heightCode, xOffsetCode, yOffsetCode := dir.fieldSizeCodes()
+
+	dir.calculateCells()
+
+	for _, frame := range dir.Frames {
+		frame.recalculateCells(dir)
+	}
+
+	palette := dir.usedColors(images)
+	pixelMask, pixelCodes := dir.encodeCells(images, palette)
+
+	if pixelMask.BitsWritten() >= 1<<bitstreamSizeBits {
+		return nil, errBitstreamTooLarge
+	}
+
+	bw := d2datautils.CreateBitWriter()
+	bw.PushBits(0, 2) //nolint:gomnd // compression flags, no equal cells and no raw pixel codes
+	bw.PushBits(0, fieldSizeCodeBits)
+	bw.PushBits(widthCode, fieldSizeCodeBits)
+	bw.PushBits(heightCode, fieldSizeCodeBits)
+	bw.PushBits(xOffsetCode, fieldSizeCodeBits)
+	bw.PushBits(yOffsetCode, fieldSizeCodeBits)
+	bw.PushBits(0, fieldSizeCodeBits) // optional data
+	bw.PushBits(0, fieldSizeCodeBits) // coded bytes, they are not needed to decode the direction
+
+	for _, frame := range dir.Frames {
+		bw.PushBits(uint32(frame.Width), dir.WidthBits)
+		bw.PushBits(uint32(frame.Height), dir.HeightBits)
+		bw.PushSignedBits(frame.XOffset, dir.XOffsetBits)
+		bw.PushSignedBits(frame.YOffset, dir.YOffsetBits)
+		bw.PushBit(0) // top down
+	}
+
+	bw.PushBits(uint32(pixelMask.BitsWritten()), bitstreamSizeBits)
+
+	for _, used := range palette.used {
+		if used {
+			bw.PushBit(1)
+		} else {
+			bw.PushBit(0)
+		}
+	}
+
+	bw.PushBitWriter(pixelMask)
+	bw.PushBitWriter(pixelCodes)
+
+	body := bw.GetBytes()
+
+	sw := d2datautils.CreateStreamWriter()
+	sw.PushUint32(uint32(4 + len(body))) //nolint:gomnd // size of the size field
+	sw.PushBytes(body...)
+
+	return sw.GetBytes(), nil
+}
+
+func unionBox(a, b d2geom.Rectangle) d2geom.Rectangle {
+	left, top := d2math.MinInt(a.Left, b.Left), d2math.MinInt(a.Top, b.Top)
+	right, bottom := d2math.MaxInt(a.Right(), b.Right()), d2math.MaxInt(a.Bottom(), b.Bottom())
+
+	return d2geom.Rectangle{Left: left, Top: top, Width: right - left, Height: bottom - top}
+}
+
+// fieldSizeCodes picks the smallest field sizes for the frame headers and returns their header codes
+func (v *DCCDirection) fieldSizeCodes() (width, height, xOffset, yOffset uint32) {
+	var widthBits, heightBits, xOffsetBits, yOffsetBits int
+
+	for _, frame := range v.Frames {
+		widthBits = d2math.MaxInt(widthBits, bits.Len(uint(frame.Width)))
+		heightBits = d2math.MaxInt(heightBits, bits.Len(uint(frame.Height)))
+		xOffsetBits = d2math.MaxInt(xOffsetBits, signedBitsLen(frame.XOffset))
+		yOffsetBits = d2math.MaxInt(yOffsetBits, signedBitsLen(frame.YOffset))
+	}
+
+	width, v.WidthBits = fieldSizeCode(widthBits)
+	height, v.HeightBits = fieldSizeCode(heightBits)
+	xOffset, v.XOffsetBits = fieldSizeCode(xOffsetBits)
+	yOffset, v.YOffsetBits = fieldSizeCode(yOffsetBits)
+
+	return width, height, xOffset, yOffset
+}
+
+// fieldSizeCode returns the code of the smallest field size in the crazy bit table that holds the given bits
+func fieldSizeCode(minBits int) (code uint32, size int) {
+	for idx, fieldBits := range crazyBitTable {
+		if int(fieldBits) >= minBits {
+			return uint32(idx), int(fieldBits)
+		}
+	}
+
+	return uint32(len(crazyBitTable) - 1), int(crazyBitTable[len(crazyBitTable)-1])
+}
+
+func signedBitsLen(value int) int {
+	if value == 0 {
+		return 0
+	}
+
+	if value < 0 {
+		value = ^value
+	}
+
+	return bits.Len(uint(value)) + 1
+}
+
+// directionPalette holds the palette entries of a direction, the cells are coded with entry indices
+type directionPalette struct {
+	used    [256]bool
+	entries [256]byte
+}
+
+// usedColors collects the colors used by the frames. The transparent color is always an entry, because
+// the decoder fills cells with entry 0 when it runs out of colors.
+func (v *DCCDirection) usedColors(images []*image.Paletted) *directionPalette {
+	palette := &directionPalette{}
+	palette.used[0] = true
+
+	for idx, frame := range v.Frames {
+		for y := frame.Box.Top; y < frame.Box.Bottom(); y++ {
+			for x := frame.Box.Left; x < frame.Box.Right(); x++ {
+				palette.used[pixelAt(images[idx], x, y)] = true
+			}
+		}
+	}
+
+	entry := byte(0)
+
+	for colorIndex, used := range palette.used {
+		if used {
+			palette.entries[colorIndex] = entry
+			entry++
+		}
+	}
+
+	return palette
+}
+
+func pixelAt(img *image.Paletted, x, y int) byte {
+	if !image.Pt(x, y).In(img.Rect) {
+		return 0
+	}
+
+	return img.Pix[img.PixOffset(x, y)]
+}
+
+// encodeCells writes the cells of all frames in the order they are decoded. The pixel mask bitstream gets
+// a full mask for every cell that was used by an earlier frame. The pixel code and displacement bitstream
+// gets the colors of all cells, followed by the pixels of all cells.
+func (v *DCCDirection) encodeCells(images []*image.Paletted, palette *directionPalette) (pixelMask,
+	pixelCodes *d2datautils.BitWriter) {
+	pixelMask = d2datautils.CreateBitWriter()
+	displacements := d2datautils.CreateBitWriter()
+	pixels := d2datautils.CreateBitWriter()
+	cellBuffer := make([]bool, v.HorizontalCellCount*v.VerticalCellCount)
+
+	for idx, frame := range v.Frames {
+		originCellX := (frame.Box.Left - v.Box.Left) / cellsPerRow
+		originCellY := (frame.Box.Top - v.Box.Top) / cellsPerRow
+
+		for cellY := 0; cellY < frame.VerticalCellCount; cellY++ {
+			for cellX := 0; cellX < frame.HorizontalCellCount; cellX++ {
+				currentCell := originCellX + cellX + ((originCellY + cellY) * v.HorizontalCellCount)
+
+				if cellBuffer[currentCell] {
+					pixelMask.PushBits(fullPixelMask, pixelMaskBits)
+				}
+
+				cellBuffer[currentCell] = true
+
+				cell := frame.Cells[cellX+(cellY*frame.HorizontalCellCount)]
+				cellPixels := make([]byte, 0, cell.Width*cell.Height)
+
+				for y := 0; y < cell.Height; y++ {
+					for x := 0; x < cell.Width; x++ {
+						cellPixels = append(cellPixels, pixelAt(images[idx], v.Box.Left+cell.XOffset+x, v.Box.Top+cell.YOffset+y))
+					}
+				}
+
+				encodeCell(cellPixels, images[idx].Palette, palette, displacements, pixels)
+			}
+		}
+	}
+
+	displacements.PushBitWriter(pixels)
+
+	return pixelMask, displacements
+}
+
+// encodeCell writes the colors of a cell as a stack of displacements between increasing palette entries.
+// The decoder assigns the colors to the pixel codes starting with the last color, any codes left over get
+// entry 0, which is transparent.
+func encodeCell(cellPixels []byte, imagePalette color.Palette, palette *directionPalette,
+	displacements, pixels *d2datautils.BitWriter) {
+	cellPixels = reduceCellColors(cellPixels, imagePalette)
+
+	var colors []int
+
+	for _, colorIndex := range cellPixels {
+		entry := int(palette.entries[colorIndex])
+
+		if entry != 0 && !containsInt(colors, entry) {
+			colors = append(colors, entry)
+		}
+	}
+
+	sort.Ints(colors)
+
+	lastEntry := 0
+
+	for _, entry := range colors {
+		displacement := entry - lastEntry
+		for ; displacement >= maxDisplacement; displacement -= maxDisplacement {
+			displacements.PushBits(maxDisplacement, displacementBits)
+		}
+
+		displacements.PushBits(uint32(displacement), displacementBits)
+		lastEntry = entry
+	}
+
+	if len(colors) < maxCellColors {
+		displacements.PushBits(0, displacementBits) // repeating the last color ends the stack
+	}
+
+	var codes [maxCellColors]int
+
+	for idx := range colors {
+		codes[idx] = colors[len(colors)-1-idx]
+	}
+
+	if codes[0] == codes[1] {
+		// a cell without colors is filled with entry 0
+		return
+	}
+
+	codeBits := 2
+	if codes[1] == codes[2] {
+		codeBits = 1
+	}
+
+	for _, colorIndex := range cellPixels {
+		entry := int(palette.entries[colorIndex])
+
+		for code := range codes {
+			if codes[code] == entry {
+				pixels.PushBits(uint32(code), codeBits)
+				break
+			}
+		}
+	}
+}
+
+// reduceCellColors keeps the four most used colors of a cell, other pixels get the closest kept color
+func reduceCellColors(cellPixels []byte, imagePalette color.Palette) []byte {
+	counts := make(map[byte]int)
+
+	for _, colorIndex := range cellPixels {
+		counts[colorIndex]++
+	}
+
+	if len(counts) <= maxCellColors {
+		return cellPixels
+	}
+
+	kept := make([]byte, 0, len(counts))
+	for colorIndex := range counts {
+		kept = append(kept, colorIndex)
+	}
+
+	sort.Slice(kept, func(i, j int) bool {
+		if counts[kept[i]] != counts[kept[j]] {
+			return counts[kept[i]] > counts[kept[j]]
+		}
+
+		return kept[i] < kept[j]
+	})
+
+	kept = kept[:maxCellColors]
+	result := make([]byte, len(cellPixels))
+
+	for idx, colorIndex := range cellPixels {
+		result[idx] = closestColor(colorIndex, kept, imagePalette)
+	}
+
+	return result
+}
+
+func closestColor(colorIndex byte, candidates []byte, imagePalette color.Palette) byte {
+	best, bestDistance := candidates[0], -1
+
+	for _, candidate := range candidates {
+		if candidate == colorIndex {
+			return candidate
+		}
+
+		// opaque pixels do not become transparent
+		if (candidate == 0 && colorIndex != 0) || int(colorIndex) >= len(imagePalette) ||
+			int(candidate) >= len(imagePalette) {
+			continue
+		}
+
+		r1, g1, b1, _ := imagePalette[colorIndex].RGBA()
+		r2, g2, b2, _ := imagePalette[candidate].RGBA()
+		dr := int(r1>>colorShift) - int(r2>>colorShift)
+		dg := int(g1>>colorShift) - int(g2>>colorShift)
+		db := int(b1>>colorShift) - int(b2>>colorShift)
+
+		if distance := dr*dr + dg*dg + db*db; bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	return best
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
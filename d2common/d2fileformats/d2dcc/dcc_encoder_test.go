@@ -0,0 +1,123 @@
+package d2dcc
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func testPalette() color.Palette {
+	palette := make(color.Palette, 256)
+	for idx := range palette {
+		palette[idx] = color.RGBA{R: uint8(idx), G: uint8(idx), B: uint8(idx), A: 0xff}
+	}
+
+	return palette
+}
+
+// testFrame returns a frame with the given colors, the frame holds no more than four colors in any cell when
+// colors has at most three entries
+func testFrame(rng *rand.Rand, bounds image.Rectangle, colors []byte) *image.Paletted {
+	img := image.NewPaletted(bounds, testPalette())
+
+	for idx := range img.Pix {
+		if rng.Intn(4) > 0 { //nolint:gomnd // a quarter of the pixels are transparent
+			img.Pix[idx] = colors[rng.Intn(len(colors))]
+		}
+	}
+
+	return img
+}
+
+func TestDCC_EncodeRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // deterministic test data
+	colors := []byte{7, 40, 255}
+	directions := [][]*image.Paletted{
+		{
+			testFrame(rng, image.Rect(-30, -70, 25, 3), colors),
+			testFrame(rng, image.Rect(-27, -69, 21, -5), colors),
+			testFrame(rng, image.Rect(-3, -2, 2, 2), []byte{1}),
+		},
+		{
+			testFrame(rng, image.Rect(0, 0, 1, 1), colors),
+			testFrame(rng, image.Rect(1, 1, 6, 6), colors[:1]),
+			image.NewPaletted(image.Rect(2, 2, 2, 2), nil),
+		},
+	}
+
+	dcc, err := Encode(directions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dcc, err = Load(dcc.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for dirIdx, frames := range directions {
+		for frameIdx, want := range frames {
+			got := dcc.FrameImage(dirIdx, frameIdx, nil)
+
+			for y := got.Rect.Min.Y; y < got.Rect.Max.Y; y++ {
+				for x := got.Rect.Min.X; x < got.Rect.Max.X; x++ {
+					wantColor := uint8(0)
+					if image.Pt(x, y).In(want.Rect) {
+						wantColor = want.ColorIndexAt(x, y)
+					}
+
+					if gotColor := got.ColorIndexAt(x, y); gotColor != wantColor {
+						t.Fatalf("direction %d frame %d: expected color %d at %d,%d, got %d",
+							dirIdx, frameIdx, wantColor, x, y, gotColor)
+					}
+				}
+			}
+		}
+	}
+
+	if box := dcc.Directions[0].Box; box.Left != -30 || box.Top != -70 || box.Width != 55 || box.Height != 73 {
+		t.Errorf("unexpected direction box %+v", box)
+	}
+
+	sheet := dcc.DirectionImage(0, nil)
+	if sheet.Rect != image.Rect(0, 0, 55*3, 73) {
+		t.Errorf("unexpected sheet bounds %v", sheet.Rect)
+	}
+}
+
+func TestDCC_EncodeManyColors(t *testing.T) {
+	rng := rand.New(rand.NewSource(2)) //nolint:gosec // deterministic test data
+	frame := image.NewPaletted(image.Rect(0, 0, 16, 16), testPalette())
+
+	for idx := range frame.Pix {
+		frame.Pix[idx] = byte(1 + rng.Intn(255))
+	}
+
+	dcc, err := Encode([][]*image.Paletted{{frame}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := dcc.FrameImage(0, 0, nil)
+
+	for cellY := 0; cellY < 16; cellY += cellsPerRow {
+		for cellX := 0; cellX < 16; cellX += cellsPerRow {
+			colors := make(map[uint8]bool)
+
+			for y := cellY; y < cellY+cellsPerRow; y++ {
+				for x := cellX; x < cellX+cellsPerRow; x++ {
+					colors[got.ColorIndexAt(x, y)] = true
+
+					if got.ColorIndexAt(x, y) == 0 {
+						t.Fatalf("opaque pixel at %d,%d became transparent", x, y)
+					}
+				}
+			}
+
+			if len(colors) > maxCellColors {
+				t.Errorf("cell %d,%d has %d colors", cellX, cellY, len(colors))
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package d2dcc
+
+import (
+	"image"
+	"image/color"
+)
+
+// FrameImage returns the given frame as a paletted image. All frames of a direction share the box of the
+// direction as their bounds. Pass the image to png.Encode to export the frame.
+func (d *DCC) FrameImage(direction, frame int, palette color.Palette) *image.Paletted {
+	dir := d.Directions[direction]
+
+	return &image.Paletted{
+		Pix:     dir.Frames[frame].PixelData,
+		Stride:  dir.Box.Width,
+		Rect:    image.Rect(dir.Box.Left, dir.Box.Top, dir.Box.Right(), dir.Box.Bottom()),
+		Palette: palette,
+	}
+}
+
+// DirectionImage returns all frames of the given direction in a single sheet, with the frames side by side
+// from left to right.
+func (d *DCC) DirectionImage(direction int, palette color.Palette) *image.Paletted {
+	dir := d.Directions[direction]
+	sheet := image.NewPaletted(image.Rect(0, 0, dir.Box.Width*len(dir.Frames), dir.Box.Height), palette)
+
+	for idx, frame := range dir.Frames {
+		for y := 0; y < dir.Box.Height; y++ {
+			copy(sheet.Pix[sheet.PixOffset(idx*dir.Box.Width, y):], frame.PixelData[y*dir.Box.Width:(y+1)*dir.Box.Width])
+		}
+	}
+
+	return sheet
+}
@@ -8,7 +8,17 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
 )
 
-const maxActNumber = 5
+const (
+	maxActNumber  = 5
+	bytesPerInt32 = 4
+)
+
+// dirLookup maps the wall orientations of versions before 7 to tile types
+var dirLookup = []int32{ //nolint:gochecknoglobals // const table
+	0x00, 0x01, 0x02, 0x01, 0x02, 0x03, 0x03, 0x05, 0x05, 0x06,
+	0x06, 0x07, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E,
+	0x0F, 0x10, 0x11, 0x12, 0x14,
+}
 
 // DS1 represents the "stamp" data that is used to build up maps.
 type DS1 struct {
@@ -26,6 +36,8 @@ type DS1 struct {
 	NumberOfShadowLayers       int32               // ShadowNum number of shadow layer used
 	NumberOfSubstitutionLayers int32               // SubstitutionNum number of substitution layer used
 	SubstitutionGroupsNum      int32               // SubstitutionGroupsNum number of substitution groups, datas between objects & NPC paths
+	unknown1                   []byte              // two dwords of versions 9 to 13
+	unknown2                   uint32              // dword in front of the substitution groups of version 18 and up
 }
 
 // LoadDS1 loads the specified DS1 file
@@ -73,8 +85,8 @@ func LoadDS1(fileData []byte) (*DS1, error) {
 	}
 
 	if ds1.Version >= 9 && ds1.Version <= 13 {
-		// Skipping two dwords because they are "meaningless"?
-		br.SkipBytes(8) //nolint:gomnd // We don't know what's here
+		// Two dwords that are "meaningless"? They are kept to write the file back out
+		ds1.unknown1 = br.ReadBytes(8) //nolint:gomnd // We don't know what's here
 	}
 
 	if ds1.Version >= 4 { //nolint:gomnd // Version number
@@ -84,6 +96,11 @@ func LoadDS1(fileData []byte) (*DS1, error) {
 		} else {
 			ds1.NumberOfFloors = 1
 		}
+	} else {
+		// versions before 4 have a fixed set of layers, see setupStreamLayerTypes
+		ds1.NumberOfWalls = 1
+		ds1.NumberOfFloors = 1
+		ds1.NumberOfSubstitutionLayers = 1
 	}
 
 	layerStream := ds1.setupStreamLayerTypes()
@@ -131,7 +148,7 @@ func (ds1 *DS1) loadObjects(br *d2datautils.StreamReader) {
 func (ds1 *DS1) loadSubstitutions(br *d2datautils.StreamReader) {
 	if ds1.Version >= 12 && (ds1.SubstitutionType == 1 || ds1.SubstitutionType == 2) {
 		if ds1.Version >= 18 { //nolint:gomnd // Version number
-			br.GetUInt32()
+			ds1.unknown2 = br.GetUInt32()
 		}
 
 		numberOfSubGroups := br.GetInt32()
@@ -209,9 +226,9 @@ func (ds1 *DS1) loadNPCs(br *d2datautils.StreamReader) {
 				ds1.loadNpcPaths(br, objIdx, int(numPaths))
 			} else {
 				if ds1.Version >= 15 { //nolint:gomnd // Version number
-					br.SkipBytes(int(numPaths) * 3) //nolint:gomnd // Unknown data
+					br.SkipBytes(int(numPaths) * 3 * bytesPerInt32) //nolint:gomnd // Unknown data
 				} else {
-					br.SkipBytes(int(numPaths) * 2) //nolint:gomnd // Unknown data
+					br.SkipBytes(int(numPaths) * 2 * bytesPerInt32) //nolint:gomnd // Unknown data
 				}
 			}
 		}
@@ -238,12 +255,6 @@ func (ds1 *DS1) loadNpcPaths(br *d2datautils.StreamReader, objIdx, numPaths int)
 }
 
 func (ds1 *DS1) loadLayerStreams(br *d2datautils.StreamReader, layerStream []d2enum.LayerStreamType) {
-	var dirLookup = []int32{
-		0x00, 0x01, 0x02, 0x01, 0x02, 0x03, 0x03, 0x05, 0x05, 0x06,
-		0x06, 0x07, 0x07, 0x08, 0x09, 0x0A, 0x0B, 0x0C, 0x0D, 0x0E,
-		0x0F, 0x10, 0x11, 0x12, 0x14,
-	}
-
 	for lIdx := range layerStream {
 		layerStreamType := layerStream[lIdx]
 
@@ -266,6 +277,8 @@ func (ds1 *DS1) loadLayerStreams(br *d2datautils.StreamReader, layerStream []d2e
 					c := int32(dw & 0x000000FF) //nolint:gomnd // Bitmask
 
 					if ds1.Version < 7 { //nolint:gomnd // Version number
+						ds1.Tiles[y][x].Walls[wallIndex].legacyOrientation = byte(c)
+
 						if c < int32(len(dirLookup)) {
 							c = dirLookup[c]
 						}
@@ -0,0 +1,157 @@
+package d2ds1
+
+import (
+	"errors"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
+)
+
+const (
+	maxWallLayers  = 4
+	maxFloorLayers = 2
+)
+
+var (
+	errFixedLayers = errors.New("the layers of this DS1 version cannot be changed")
+	errLayerLimit  = errors.New("the DS1 already has the maximum number of layers of this kind")
+	errLayerIndex  = errors.New("DS1 layer index out of range")
+	errObjectIndex = errors.New("DS1 object index out of range")
+	errPathIndex   = errors.New("DS1 path index out of range")
+	errNoObjects   = errors.New("this DS1 version does not support objects")
+	errNoPaths     = errors.New("this DS1 version does not support NPC paths")
+)
+
+func (ds1 *DS1) forEachTile(fn func(tile *TileRecord)) {
+	for y := range ds1.Tiles {
+		for x := range ds1.Tiles[y] {
+			fn(&ds1.Tiles[y][x])
+		}
+	}
+}
+
+// AddWallLayer adds an empty wall layer above the existing wall layers
+func (ds1 *DS1) AddWallLayer() error {
+	if ds1.Version < 4 { //nolint:gomnd // Version number
+		return errFixedLayers
+	}
+
+	if ds1.NumberOfWalls >= maxWallLayers {
+		return errLayerLimit
+	}
+
+	ds1.NumberOfWalls++
+	ds1.forEachTile(func(tile *TileRecord) {
+		tile.Walls = append(tile.Walls, WallRecord{})
+	})
+
+	return nil
+}
+
+// RemoveWallLayer removes the wall layer with the given index, the layers above it move down
+func (ds1 *DS1) RemoveWallLayer(index int) error {
+	if ds1.Version < 4 { //nolint:gomnd // Version number
+		return errFixedLayers
+	}
+
+	if index < 0 || index >= int(ds1.NumberOfWalls) {
+		return errLayerIndex
+	}
+
+	ds1.NumberOfWalls--
+	ds1.forEachTile(func(tile *TileRecord) {
+		tile.Walls = append(tile.Walls[:index], tile.Walls[index+1:]...)
+	})
+
+	return nil
+}
+
+// AddFloorLayer adds an empty floor layer above the existing floor layers
+func (ds1 *DS1) AddFloorLayer() error {
+	if ds1.Version < 16 { //nolint:gomnd // Version number
+		return errFixedLayers
+	}
+
+	if ds1.NumberOfFloors >= maxFloorLayers {
+		return errLayerLimit
+	}
+
+	ds1.NumberOfFloors++
+	ds1.forEachTile(func(tile *TileRecord) {
+		tile.Floors = append(tile.Floors, FloorShadowRecord{})
+	})
+
+	return nil
+}
+
+// RemoveFloorLayer removes the floor layer with the given index, the layers above it move down
+func (ds1 *DS1) RemoveFloorLayer(index int) error {
+	if ds1.Version < 16 { //nolint:gomnd // Version number
+		return errFixedLayers
+	}
+
+	if index < 0 || index >= int(ds1.NumberOfFloors) {
+		return errLayerIndex
+	}
+
+	ds1.NumberOfFloors--
+	ds1.forEachTile(func(tile *TileRecord) {
+		tile.Floors = append(tile.Floors[:index], tile.Floors[index+1:]...)
+	})
+
+	return nil
+}
+
+// AddObject adds an object and returns its index
+func (ds1 *DS1) AddObject(object Object) (int, error) {
+	if ds1.Version < 2 { //nolint:gomnd // Version number
+		return 0, errNoObjects
+	}
+
+	ds1.Objects = append(ds1.Objects, object)
+
+	return len(ds1.Objects) - 1, nil
+}
+
+// RemoveObject removes the object with the given index, along with its paths
+func (ds1 *DS1) RemoveObject(index int) error {
+	if index < 0 || index >= len(ds1.Objects) {
+		return errObjectIndex
+	}
+
+	ds1.Objects = append(ds1.Objects[:index], ds1.Objects[index+1:]...)
+
+	return nil
+}
+
+// AddPath adds a path point to the object with the given index. The paths of an object are stored with the
+// position of the object, so objects with paths need a position of their own.
+func (ds1 *DS1) AddPath(objectIndex int, path d2path.Path) error {
+	if ds1.Version < 14 { //nolint:gomnd // Version number
+		return errNoPaths
+	}
+
+	if objectIndex < 0 || objectIndex >= len(ds1.Objects) {
+		return errObjectIndex
+	}
+
+	ds1.Objects[objectIndex].Paths = append(ds1.Objects[objectIndex].Paths, path)
+
+	return nil
+}
+
+// RemovePath removes a path point from the object with the given index
+func (ds1 *DS1) RemovePath(objectIndex, pathIndex int) error {
+	if objectIndex < 0 || objectIndex >= len(ds1.Objects) {
+		return errObjectIndex
+	}
+
+	object := &ds1.Objects[objectIndex]
+
+	if pathIndex < 0 || pathIndex >= len(object.Paths) {
+		return errPathIndex
+	}
+
+	object.Paths = append(object.Paths[:pathIndex], object.Paths[pathIndex+1:]...)
+
+	return nil
+}
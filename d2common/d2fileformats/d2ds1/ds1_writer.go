@@ -0,0 +1,195 @@
+package d2ds1
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const unknown1Size = 8
+
+// Marshal encodes the DS1 in the file format of its version, the result is read back by LoadDS1. Files that
+// were loaded with LoadDS1 are written back unchanged, except for NPC paths that do not belong to an object,
+// which LoadDS1 drops.
+func (ds1 *DS1) Marshal() []byte {
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushInt32(ds1.Version)
+	sw.PushInt32(ds1.Width - 1)
+	sw.PushInt32(ds1.Height - 1)
+
+	if ds1.Version >= 8 { //nolint:gomnd // Version number
+		sw.PushInt32(ds1.Act - 1)
+	}
+
+	if ds1.Version >= 10 { //nolint:gomnd // Version number
+		sw.PushInt32(ds1.SubstitutionType)
+	}
+
+	if ds1.Version >= 3 { //nolint:gomnd // Version number
+		sw.PushInt32(int32(len(ds1.Files)))
+
+		for _, file := range ds1.Files {
+			sw.PushBytes([]byte(file)...)
+			sw.PushByte(0)
+		}
+	}
+
+	if ds1.Version >= 9 && ds1.Version <= 13 {
+		unknown := make([]byte, unknown1Size)
+		copy(unknown, ds1.unknown1)
+		sw.PushBytes(unknown...)
+	}
+
+	if ds1.Version >= 4 { //nolint:gomnd // Version number
+		sw.PushInt32(ds1.NumberOfWalls)
+
+		if ds1.Version >= 16 { //nolint:gomnd // Version number
+			sw.PushInt32(ds1.NumberOfFloors)
+		}
+	}
+
+	ds1.saveLayerStreams(sw, ds1.setupStreamLayerTypes())
+	ds1.saveObjects(sw)
+	ds1.saveSubstitutions(sw)
+	ds1.saveNPCs(sw)
+
+	return sw.GetBytes()
+}
+
+func (ds1 *DS1) saveLayerStreams(sw *d2datautils.StreamWriter, layerStream []d2enum.LayerStreamType) {
+	for _, layerStreamType := range layerStream {
+		for y := 0; y < int(ds1.Height); y++ {
+			for x := 0; x < int(ds1.Width); x++ {
+				tile := &ds1.Tiles[y][x]
+
+				switch layerStreamType {
+				case d2enum.LayerStreamWall1, d2enum.LayerStreamWall2, d2enum.LayerStreamWall3, d2enum.LayerStreamWall4:
+					wall := &tile.Walls[int(layerStreamType)-int(d2enum.LayerStreamWall1)]
+					sw.PushUint32(encodeLayerRecord(wall.Prop1, wall.Sequence, wall.Unknown1, wall.Style, wall.Unknown2,
+						wall.Hidden))
+				case d2enum.LayerStreamOrientation1, d2enum.LayerStreamOrientation2,
+					d2enum.LayerStreamOrientation3, d2enum.LayerStreamOrientation4:
+					wall := &tile.Walls[int(layerStreamType)-int(d2enum.LayerStreamOrientation1)]
+					sw.PushUint32(uint32(ds1.orientation(wall)) | uint32(wall.Zero)<<8) //nolint:gomnd // Bitmask
+				case d2enum.LayerStreamFloor1, d2enum.LayerStreamFloor2:
+					floor := &tile.Floors[int(layerStreamType)-int(d2enum.LayerStreamFloor1)]
+					sw.PushUint32(encodeLayerRecord(floor.Prop1, floor.Sequence, floor.Unknown1, floor.Style, floor.Unknown2,
+						floor.Hidden))
+				case d2enum.LayerStreamShadow:
+					shadow := &tile.Shadows[0]
+					sw.PushUint32(encodeLayerRecord(shadow.Prop1, shadow.Sequence, shadow.Unknown1, shadow.Style,
+						shadow.Unknown2, shadow.Hidden))
+				case d2enum.LayerStreamSubstitute:
+					sw.PushUint32(tile.Substitutions[0].Unknown)
+				}
+			}
+		}
+	}
+}
+
+//nolint:gomnd // Bitmask
+func encodeLayerRecord(prop1, sequence, unknown1, style, unknown2 byte, hidden bool) uint32 {
+	dw := uint32(prop1)
+	dw |= uint32(sequence&0x3F) << 8
+	dw |= uint32(unknown1&0x3F) << 14
+	dw |= uint32(style&0x3F) << 20
+	dw |= uint32(unknown2&0x1F) << 26
+
+	if hidden {
+		dw |= 0x80000000
+	}
+
+	return dw
+}
+
+// orientation returns the orientation of a wall as stored in the file. Versions before 7 store a code that
+// is mapped to the tile type, the loaded code is kept if it still maps to the tile type of the wall.
+func (ds1 *DS1) orientation(wall *WallRecord) byte {
+	if ds1.Version >= 7 { //nolint:gomnd // Version number
+		return byte(wall.Type)
+	}
+
+	if int(wall.legacyOrientation) < len(dirLookup) && dirLookup[wall.legacyOrientation] == int32(wall.Type) {
+		return wall.legacyOrientation
+	}
+
+	for code, tileType := range dirLookup {
+		if tileType == int32(wall.Type) {
+			return byte(code)
+		}
+	}
+
+	return byte(wall.Type)
+}
+
+func (ds1 *DS1) saveObjects(sw *d2datautils.StreamWriter) {
+	if ds1.Version < 2 { //nolint:gomnd // Version number
+		return
+	}
+
+	sw.PushInt32(int32(len(ds1.Objects)))
+
+	for _, object := range ds1.Objects {
+		sw.PushInt32(int32(object.Type))
+		sw.PushInt32(int32(object.ID))
+		sw.PushInt32(int32(object.X))
+		sw.PushInt32(int32(object.Y))
+		sw.PushInt32(int32(object.Flags))
+	}
+}
+
+func (ds1 *DS1) saveSubstitutions(sw *d2datautils.StreamWriter) {
+	if ds1.Version < 12 || (ds1.SubstitutionType != 1 && ds1.SubstitutionType != 2) {
+		return
+	}
+
+	if ds1.Version >= 18 { //nolint:gomnd // Version number
+		sw.PushUint32(ds1.unknown2)
+	}
+
+	sw.PushInt32(int32(len(ds1.SubstitutionGroups)))
+
+	for _, group := range ds1.SubstitutionGroups {
+		sw.PushInt32(group.TileX)
+		sw.PushInt32(group.TileY)
+		sw.PushInt32(group.WidthInTiles)
+		sw.PushInt32(group.HeightInTiles)
+		sw.PushInt32(group.Unknown)
+	}
+}
+
+// saveNPCs writes the paths of the objects, the NPC of a path is found by the position of its object
+func (ds1 *DS1) saveNPCs(sw *d2datautils.StreamWriter) {
+	if ds1.Version < 14 { //nolint:gomnd // Version number
+		return
+	}
+
+	numberOfNpcs := 0
+
+	for _, object := range ds1.Objects {
+		if len(object.Paths) > 0 {
+			numberOfNpcs++
+		}
+	}
+
+	sw.PushInt32(int32(numberOfNpcs))
+
+	for _, object := range ds1.Objects {
+		if len(object.Paths) == 0 {
+			continue
+		}
+
+		sw.PushInt32(int32(len(object.Paths)))
+		sw.PushInt32(int32(object.X))
+		sw.PushInt32(int32(object.Y))
+
+		for _, path := range object.Paths {
+			sw.PushInt32(int32(path.Position.X()))
+			sw.PushInt32(int32(path.Position.Y()))
+
+			if ds1.Version >= 15 { //nolint:gomnd // Version number
+				sw.PushInt32(int32(path.Action))
+			}
+		}
+	}
+}
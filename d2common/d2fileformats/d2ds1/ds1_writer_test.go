@@ -0,0 +1,209 @@
+package d2ds1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
+)
+
+// testDS1File writes a DS1 file of the given version with random content, following the file layout
+func testDS1File(version int32, seed int64) []byte { //nolint:funlen,gocyclo // the layout of each version
+	const (
+		width, height = 5, 3
+		walls, floors = 2, 2
+		objects       = 3
+	)
+
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic test data
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushInt32(version)
+	sw.PushInt32(width - 1)
+	sw.PushInt32(height - 1)
+
+	if version >= 8 {
+		sw.PushInt32(rng.Int31n(5))
+	}
+
+	if version >= 10 {
+		sw.PushInt32(2)
+	}
+
+	if version >= 3 {
+		sw.PushInt32(2)
+		sw.PushBytes([]byte("data\\global\\tiles\\act1\\town\\floor.tg1\x00")...)
+		sw.PushBytes([]byte("data\\global\\tiles\\act1\\town\\wall.tg1\x00")...)
+	}
+
+	if version >= 9 && version <= 13 {
+		sw.PushUint64(rng.Uint64())
+	}
+
+	layers := 5
+
+	if version >= 4 {
+		layers = walls*2 + 1 // the walls with their orientations and the shadow
+
+		sw.PushInt32(walls)
+
+		if version >= 16 {
+			sw.PushInt32(floors)
+			layers += floors
+		} else {
+			layers++
+		}
+
+		if version >= 10 {
+			layers++
+		}
+	}
+
+	orientationLayer := func(layer int) bool {
+		if version < 4 {
+			return layer == 2
+		}
+
+		return layer < walls*2 && layer%2 == 1
+	}
+
+	for layer := 0; layer < layers; layer++ {
+		for tile := 0; tile < width*height; tile++ {
+			if orientationLayer(layer) {
+				sw.PushUint32(uint32(rng.Intn(1 << 16)))
+			} else {
+				sw.PushUint32(rng.Uint32())
+			}
+		}
+	}
+
+	if version >= 2 {
+		sw.PushInt32(objects)
+
+		for idx := 0; idx < objects; idx++ {
+			sw.PushInt32(rng.Int31n(3))
+			sw.PushInt32(rng.Int31n(600))
+			sw.PushInt32(int32(idx * 10))
+			sw.PushInt32(int32(idx * 7))
+			sw.PushInt32(rng.Int31n(8))
+		}
+	}
+
+	if version >= 12 {
+		if version >= 18 {
+			sw.PushUint32(rng.Uint32())
+		}
+
+		sw.PushInt32(1)
+
+		for i := 0; i < 5; i++ {
+			sw.PushInt32(rng.Int31n(100))
+		}
+	}
+
+	if version >= 14 {
+		sw.PushInt32(2)
+
+		for _, idx := range []int32{0, 2} {
+			sw.PushInt32(idx + 1)
+			sw.PushInt32(idx * 10)
+			sw.PushInt32(idx * 7)
+
+			for i := int32(0); i <= idx; i++ {
+				sw.PushInt32(rng.Int31n(50))
+				sw.PushInt32(rng.Int31n(50))
+
+				if version >= 15 {
+					sw.PushInt32(rng.Int31n(4))
+				}
+			}
+		}
+	}
+
+	return sw.GetBytes()
+}
+
+func TestDS1_MarshalRoundTrip(t *testing.T) {
+	for _, version := range []int32{3, 6, 7, 8, 9, 12, 13, 14, 15, 16, 17, 18} {
+		data := testDS1File(version, int64(version))
+
+		ds1, err := LoadDS1(data)
+		if err != nil {
+			t.Fatalf("version %d: %s", version, err)
+		}
+
+		if got := ds1.Marshal(); !bytes.Equal(got, data) {
+			t.Errorf("version %d: marshaled %d bytes that differ from the %d loaded bytes", version, len(got), len(data))
+		}
+	}
+}
+
+func TestDS1_Edit(t *testing.T) {
+	ds1, err := LoadDS1(testDS1File(18, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.AddWallLayer(); err != nil {
+		t.Fatal(err)
+	}
+
+	ds1.Tiles[1][2].Walls[2].Style = 9
+
+	if err = ds1.RemoveWallLayer(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.AddFloorLayer(); err == nil {
+		t.Error("expected an error for a third floor layer")
+	}
+
+	if err = ds1.RemoveFloorLayer(1); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ds1.AddObject(Object{Type: 1, ID: 2, X: 100, Y: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.AddPath(idx, d2path.Path{Position: d2vector.NewPosition(3, 4), Action: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.RemoveObject(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.RemovePath(1, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = ds1.RemovePath(0, 0); err == nil {
+		t.Error("expected an error for a missing path")
+	}
+
+	loaded, err := LoadDS1(ds1.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.NumberOfWalls != 2 || loaded.NumberOfFloors != 1 || len(loaded.Tiles[0][0].Walls) != 2 {
+		t.Errorf("unexpected layers, %d walls and %d floors", loaded.NumberOfWalls, loaded.NumberOfFloors)
+	}
+
+	if loaded.Tiles[1][2].Walls[1].Style != 9 {
+		t.Error("wall layers did not move down")
+	}
+
+	if len(loaded.Objects) != 3 || len(loaded.Objects[1].Paths) != 2 || len(loaded.Objects[2].Paths) != 1 {
+		t.Fatalf("unexpected objects %+v", loaded.Objects)
+	}
+
+	if path := loaded.Objects[2].Paths[0]; path.Position.X() != 3 || path.Position.Y() != 4 || path.Action != 1 {
+		t.Errorf("unexpected path %+v", path)
+	}
+}
@@ -14,4 +14,6 @@ type WallRecord struct {
 	Hidden      bool
 	RandomIndex byte
 	YAdjust     int
+
+	legacyOrientation byte // orientation as stored by DS1 versions before 7
 }
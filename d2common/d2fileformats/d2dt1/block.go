@@ -10,4 +10,7 @@ type Block struct {
 	EncodedData []byte
 	Length      int32
 	FileOffset  int32
+	formatValue int16
+	unknown1    []byte
+	unknown2    []byte
 }
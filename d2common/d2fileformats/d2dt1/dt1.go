@@ -8,7 +8,8 @@ import (
 
 // DT1 represents a DT1 file.
 type DT1 struct {
-	Tiles []Tile
+	Tiles   []Tile
+	unknown []byte
 }
 
 // BlockDataFormat represents the format of the block data
@@ -30,11 +31,11 @@ func LoadDT1(fileData []byte) (*DT1, error) {
 	ver1 := br.GetInt32()
 	ver2 := br.GetInt32()
 
-	if ver1 != 7 || ver2 != 6 {
+	if ver1 != dt1Version1 || ver2 != dt1Version2 {
 		return nil, fmt.Errorf("expected to have a version of 7.6, but got %d.%d instead", ver1, ver2)
 	}
 
-	br.SkipBytes(260) //nolint:gomnd // Unknown data
+	result.unknown = br.ReadBytes(unknownHeaderSize)
 
 	numberOfTiles := br.GetInt32()
 	br.SetPosition(uint64(br.GetInt32()))
@@ -45,30 +46,30 @@ func LoadDT1(fileData []byte) (*DT1, error) {
 		newTile := Tile{}
 		newTile.Direction = br.GetInt32()
 		newTile.RoofHeight = br.GetInt16()
-		newTile.MaterialFlags = NewMaterialFlags(br.GetUInt16())
+		newTile.materialFlags = br.GetUInt16()
+		newTile.MaterialFlags = NewMaterialFlags(newTile.materialFlags)
 		newTile.Height = br.GetInt32()
 		newTile.Width = br.GetInt32()
 
-		br.SkipBytes(4) //nolint:gomnd // Unknown data
-
+		newTile.unknown1 = br.ReadBytes(tileUnknown1Size)
 		newTile.Type = br.GetInt32()
 		newTile.Style = br.GetInt32()
 		newTile.Sequence = br.GetInt32()
 		newTile.RarityFrameIndex = br.GetInt32()
 
-		br.SkipBytes(4) //nolint:gomnd // Unknown data
+		newTile.unknown2 = br.ReadBytes(tileUnknown2Size)
 
 		for i := range newTile.SubTileFlags {
 			newTile.SubTileFlags[i] = NewSubTileFlags(br.GetByte())
 		}
 
-		br.SkipBytes(7) //nolint:gomnd // Unknown data
+		newTile.unknown3 = br.ReadBytes(tileUnknown3Size)
 
 		newTile.blockHeaderPointer = br.GetInt32()
 		newTile.blockHeaderSize = br.GetInt32()
 		newTile.Blocks = make([]Block, br.GetInt32())
 
-		br.SkipBytes(12) //nolint:gomnd // Unknown data
+		newTile.unknown4 = br.ReadBytes(tileUnknown4Size)
 
 		result.Tiles[tileIdx] = newTile
 	}
@@ -81,11 +82,11 @@ func LoadDT1(fileData []byte) (*DT1, error) {
 			result.Tiles[tileIdx].Blocks[blockIdx].X = br.GetInt16()
 			result.Tiles[tileIdx].Blocks[blockIdx].Y = br.GetInt16()
 
-			br.SkipBytes(2) //nolint:gomnd // Unknown data
-
+			result.Tiles[tileIdx].Blocks[blockIdx].unknown1 = br.ReadBytes(blockUnknown1Size)
 			result.Tiles[tileIdx].Blocks[blockIdx].GridX = br.GetByte()
 			result.Tiles[tileIdx].Blocks[blockIdx].GridY = br.GetByte()
 			formatValue := br.GetInt16()
+			result.Tiles[tileIdx].Blocks[blockIdx].formatValue = formatValue
 
 			if formatValue == 1 {
 				result.Tiles[tileIdx].Blocks[blockIdx].Format = BlockFormatIsometric
@@ -95,8 +96,7 @@ func LoadDT1(fileData []byte) (*DT1, error) {
 
 			result.Tiles[tileIdx].Blocks[blockIdx].Length = br.GetInt32()
 
-			br.SkipBytes(2) //nolint:gomnd // Unknown data
-
+			result.Tiles[tileIdx].Blocks[blockIdx].unknown2 = br.ReadBytes(blockUnknown2Size)
 			result.Tiles[tileIdx].Blocks[blockIdx].FileOffset = br.GetInt32()
 		}
 
@@ -0,0 +1,128 @@
+package d2dt1
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const (
+	dt1Version1       = 7
+	dt1Version2       = 6
+	unknownHeaderSize = 260
+	fileHeaderSize    = 276 // versions, unknown data, tile count and tile header pointer
+	tileHeaderSize    = 96
+	blockHeaderSize   = 20
+	tileUnknown1Size  = 4
+	tileUnknown2Size  = 4
+	tileUnknown3Size  = 7
+	tileUnknown4Size  = 12
+	blockUnknown1Size = 2
+	blockUnknown2Size = 2
+)
+
+// Marshal encodes the DT1 in the file format read by LoadDT1. The tile headers follow the file header, then
+// the block headers of each tile are followed by the block data of the tile, the layout of the original files.
+// The block pointers, offsets and lengths are recalculated, so blocks can be added, removed and changed.
+func (dt1 *DT1) Marshal() []byte {
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushInt32(dt1Version1)
+	sw.PushInt32(dt1Version2)
+	sw.PushBytes(fixedSize(dt1.unknown, unknownHeaderSize)...)
+	sw.PushInt32(int32(len(dt1.Tiles)))
+	sw.PushInt32(fileHeaderSize)
+
+	blockHeaderPointer := int32(fileHeaderSize + len(dt1.Tiles)*tileHeaderSize)
+
+	for idx := range dt1.Tiles {
+		tile := &dt1.Tiles[idx]
+		tile.updateBlockOffsets(blockHeaderPointer)
+		blockHeaderPointer += tile.blockHeaderSize
+
+		tile.marshalHeader(sw)
+	}
+
+	for idx := range dt1.Tiles {
+		dt1.Tiles[idx].marshalBlocks(sw)
+	}
+
+	return sw.GetBytes()
+}
+
+// updateBlockOffsets sets the block pointers and offsets of the tile for the given block header position
+func (t *Tile) updateBlockOffsets(blockHeaderPointer int32) {
+	t.blockHeaderPointer = blockHeaderPointer
+	offset := int32(len(t.Blocks) * blockHeaderSize)
+
+	for idx := range t.Blocks {
+		t.Blocks[idx].Length = int32(len(t.Blocks[idx].EncodedData))
+		t.Blocks[idx].FileOffset = offset
+		offset += t.Blocks[idx].Length
+	}
+
+	t.blockHeaderSize = offset
+}
+
+func (t *Tile) marshalHeader(sw *d2datautils.StreamWriter) {
+	sw.PushInt32(t.Direction)
+	sw.PushInt16(t.RoofHeight)
+	sw.PushUint16(t.materialFlags&^knownMaterialFlags | t.MaterialFlags.Encode())
+	sw.PushInt32(t.Height)
+	sw.PushInt32(t.Width)
+	sw.PushBytes(fixedSize(t.unknown1, tileUnknown1Size)...)
+	sw.PushInt32(t.Type)
+	sw.PushInt32(t.Style)
+	sw.PushInt32(t.Sequence)
+	sw.PushInt32(t.RarityFrameIndex)
+	sw.PushBytes(fixedSize(t.unknown2, tileUnknown2Size)...)
+
+	for i := range t.SubTileFlags {
+		sw.PushByte(t.SubTileFlags[i].Encode())
+	}
+
+	sw.PushBytes(fixedSize(t.unknown3, tileUnknown3Size)...)
+	sw.PushInt32(t.blockHeaderPointer)
+	sw.PushInt32(t.blockHeaderSize)
+	sw.PushInt32(int32(len(t.Blocks)))
+	sw.PushBytes(fixedSize(t.unknown4, tileUnknown4Size)...)
+}
+
+func (t *Tile) marshalBlocks(sw *d2datautils.StreamWriter) {
+	for idx := range t.Blocks {
+		block := &t.Blocks[idx]
+
+		sw.PushInt16(block.X)
+		sw.PushInt16(block.Y)
+		sw.PushBytes(fixedSize(block.unknown1, blockUnknown1Size)...)
+		sw.PushByte(block.GridX)
+		sw.PushByte(block.GridY)
+		sw.PushInt16(block.format())
+		sw.PushInt32(block.Length)
+		sw.PushBytes(fixedSize(block.unknown2, blockUnknown2Size)...)
+		sw.PushInt32(block.FileOffset)
+	}
+
+	for idx := range t.Blocks {
+		sw.PushBytes(t.Blocks[idx].EncodedData...)
+	}
+}
+
+// format returns the format as stored in the file, LoadDT1 reads any value other than 1 as RLE
+func (b *Block) format() int16 {
+	if b.Format == BlockFormatIsometric {
+		return int16(BlockFormatIsometric)
+	}
+
+	if b.formatValue != int16(BlockFormatIsometric) {
+		return b.formatValue
+	}
+
+	return int16(BlockFormatRLE)
+}
+
+// fixedSize pads or truncates the data to the given size, for unknown data of tiles created from scratch
+func fixedSize(data []byte, size int) []byte {
+	result := make([]byte, size)
+	copy(result, data)
+
+	return result
+}
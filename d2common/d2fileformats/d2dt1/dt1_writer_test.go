@@ -0,0 +1,101 @@
+package d2dt1
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// testDT1File writes a DT1 file with random content in the layout of the original files
+func testDT1File(seed int64) []byte {
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic test data
+	randomBytes := func(n int) []byte {
+		b := make([]byte, n)
+		rng.Read(b)
+
+		return b
+	}
+
+	blockCounts := []int{2, 0, 3}
+	blockLengths := make([][]int, len(blockCounts))
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushInt32(dt1Version1)
+	sw.PushInt32(dt1Version2)
+	sw.PushBytes(randomBytes(unknownHeaderSize)...)
+	sw.PushInt32(int32(len(blockCounts)))
+	sw.PushInt32(fileHeaderSize)
+
+	blockHeaderPointer := fileHeaderSize + len(blockCounts)*tileHeaderSize
+
+	for tileIdx, count := range blockCounts {
+		size := count * blockHeaderSize
+
+		for i := 0; i < count; i++ {
+			blockLengths[tileIdx] = append(blockLengths[tileIdx], 2+rng.Intn(300))
+			size += blockLengths[tileIdx][i]
+		}
+
+		sw.PushBytes(randomBytes(65)...) // direction up to the sub tile flags
+		sw.PushBytes(randomBytes(7)...)
+		sw.PushInt32(int32(blockHeaderPointer))
+		sw.PushInt32(int32(size))
+		sw.PushInt32(int32(count))
+		sw.PushBytes(randomBytes(12)...)
+
+		blockHeaderPointer += size
+	}
+
+	for tileIdx, count := range blockCounts {
+		offset := count * blockHeaderSize
+
+		for i := 0; i < count; i++ {
+			sw.PushBytes(randomBytes(8)...)
+			sw.PushInt16(int16(rng.Intn(3))) // rle, isometric and an unknown format
+			sw.PushInt32(int32(blockLengths[tileIdx][i]))
+			sw.PushBytes(randomBytes(2)...)
+			sw.PushInt32(int32(offset))
+
+			offset += blockLengths[tileIdx][i]
+		}
+
+		for _, length := range blockLengths[tileIdx] {
+			sw.PushBytes(randomBytes(length)...)
+		}
+	}
+
+	return sw.GetBytes()
+}
+
+func TestDT1_MarshalRoundTrip(t *testing.T) {
+	data := testDT1File(1)
+
+	dt1, err := LoadDT1(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := dt1.Marshal(); !bytes.Equal(got, data) {
+		t.Fatalf("marshaled %d bytes that differ from the %d loaded bytes", len(got), len(data))
+	}
+
+	dt1.Tiles[0].Blocks = dt1.Tiles[0].Blocks[1:]
+	dt1.Tiles[1].MaterialFlags.Lava = !dt1.Tiles[1].MaterialFlags.Lava
+	dt1.Tiles[2].Blocks[0].EncodedData = []byte{1, 2, 3}
+
+	loaded, err := LoadDT1(dt1.Marshal())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(loaded.Tiles[0].Blocks) != 1 || loaded.Tiles[1].MaterialFlags != dt1.Tiles[1].MaterialFlags ||
+		!bytes.Equal(loaded.Tiles[2].Blocks[0].EncodedData, []byte{1, 2, 3}) {
+		t.Error("changes were lost")
+	}
+
+	if !bytes.Equal(loaded.Tiles[2].Blocks[1].EncodedData, dt1.Tiles[2].Blocks[1].EncodedData) {
+		t.Error("block data moved")
+	}
+}
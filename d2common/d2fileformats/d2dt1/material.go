@@ -30,3 +30,29 @@ func NewMaterialFlags(data uint16) MaterialFlags {
 		Snow:         data&0x0400 == 0x0400,
 	}
 }
+
+// knownMaterialFlags are the bits of the material flags that are decoded by NewMaterialFlags
+const knownMaterialFlags = 0x05ff
+
+// Encode returns the material flags as stored in a DT1 file
+// nolint:gomnd // Binary values
+func (m *MaterialFlags) Encode() uint16 {
+	flags := [...]struct {
+		set  bool
+		mask uint16
+	}{
+		{m.Other, 0x0001}, {m.Water, 0x0002}, {m.WoodObject, 0x0004}, {m.InsideStone, 0x0008},
+		{m.OutsideStone, 0x0010}, {m.Dirt, 0x0020}, {m.Sand, 0x0040}, {m.Wood, 0x0080},
+		{m.Lava, 0x0100}, {m.Snow, 0x0400},
+	}
+
+	var data uint16
+
+	for _, flag := range flags {
+		if flag.set {
+			data |= flag.mask
+		}
+	}
+
+	return data
+}
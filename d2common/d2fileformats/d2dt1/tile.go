@@ -15,4 +15,9 @@ type Tile struct {
 	blockHeaderPointer int32
 	blockHeaderSize    int32
 	Blocks             []Block
+	materialFlags      uint16
+	unknown1           []byte
+	unknown2           []byte
+	unknown3           []byte
+	unknown4           []byte
 }
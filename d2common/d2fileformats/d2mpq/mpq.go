@@ -262,6 +262,31 @@ func decryptBytes(data []byte, seed uint32) {
 	}
 }
 
+func encrypt(data []uint32, seed uint32) {
+	seed2 := uint32(0xeeeeeeee) //nolint:gomnd // Encryption magic
+
+	for i := 0; i < len(data); i++ {
+		seed2 += cryptoLookup(0x400 + (seed & 0xff)) //nolint:gomnd // Encryption magic
+		plain := data[i]
+		data[i] = plain ^ (seed + seed2)
+
+		seed = ((^seed << 21) + 0x11111111) | (seed >> 11)
+		seed2 = plain + seed2 + (seed2 << 5) + 3 //nolint:gomnd // Encryption magic
+	}
+}
+
+// encryptBytes encrypts the data in place, a trailing partial uint32 is left as is, like decryptBytes does
+func encryptBytes(data []byte, seed uint32) {
+	seed2 := uint32(0xEEEEEEEE) //nolint:gomnd // Encryption magic
+	for i := 0; i < len(data)-3; i += 4 {
+		seed2 += cryptoLookup(0x400 + (seed & 0xFF)) //nolint:gomnd // Encryption magic
+		plain := binary.LittleEndian.Uint32(data[i : i+4])
+		binary.LittleEndian.PutUint32(data[i:i+4], plain^(seed+seed2))
+		seed = ((^seed << 21) + 0x11111111) | (seed >> 11)
+		seed2 = plain + seed2 + (seed2 << 5) + 3 //nolint:gomnd // Encryption magic
+	}
+}
+
 func hashString(key string, hashType uint32) uint32 {
 	seed1 := uint32(0x7FED7FED) //nolint:gomnd // Decryption magic
 	seed2 := uint32(0xEEEEEEEE) //nolint:gomnd // Decryption magic
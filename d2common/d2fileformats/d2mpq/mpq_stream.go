@@ -60,6 +60,11 @@ func CreateStream(mpq *MPQ, blockTableEntry BlockTableEntry, fileName string) (*
 
 func (v *Stream) loadBlockOffsets() error {
 	blockPositionCount := ((v.BlockTableEntry.UncompressedFileSize + v.BlockSize - 1) / v.BlockSize) + 1
+
+	// the sector checksums are stored after the last sector, with an offset of their own
+	if v.BlockTableEntry.HasFlag(FileSectorCrc) {
+		blockPositionCount++
+	}
 	v.BlockPositions = make([]uint32, blockPositionCount)
 
 	_, err := v.MPQData.file.Seek(int64(v.BlockTableEntry.FilePosition), 0)
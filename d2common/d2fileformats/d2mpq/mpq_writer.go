@@ -0,0 +1,521 @@
+package d2mpq
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	mpqHeaderSize       = 32
+	defaultSectorShift  = 3 // 4096 byte sectors, like the original game archives
+	minHashTableEntries = 16
+
+	hashEntryEmpty = 0xFFFFFFFF
+
+	archivePermissions = 0644
+
+	listFileName       = "(listfile)"
+	attributesFileName = "(attributes)"
+)
+
+// Compression is the compression method of files written by Writer.
+type Compression byte
+
+// Compression methods supported by Writer, the values are the compression type bytes of MPQ sectors
+const (
+	CompressionNone   Compression = 0x00
+	CompressionZlib   Compression = 0x02
+	CompressionPKWare Compression = 0x08
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionZlib:
+		return "zlib"
+	case CompressionPKWare:
+		return "pkware"
+	}
+
+	return fmt.Sprintf("unknown compression %#x", byte(c))
+}
+
+// FileOptions controls how a file is stored by Writer
+type FileOptions struct {
+	Compression Compression
+	Encrypted   bool
+	FixKey      bool // alters the encryption key by the file position and size, only used with Encrypted
+	SectorCRC   bool // stores an Adler-32 checksum of each sector, only used with compression
+}
+
+// DefaultFileOptions returns the options used by Writer.AddFile unless changed.
+func DefaultFileOptions() FileOptions {
+	return FileOptions{Compression: CompressionZlib, SectorCRC: true}
+}
+
+type writerFile struct {
+	name    string
+	data    []byte
+	options FileOptions
+}
+
+// Writer creates MPQ version 1 archives. The files are kept in memory until the archive is written with
+// WriteTo or Save. Writer does not keep a reference to any archive, so an archive can be rewritten in place.
+type Writer struct {
+	// Options are used for files added with AddFile and for the (listfile)
+	Options     FileOptions
+	files       []*writerFile
+	sectorShift uint16
+}
+
+// NewWriter creates a Writer for an empty archive
+func NewWriter() *Writer {
+	return &Writer{
+		Options:     DefaultFileOptions(),
+		sectorShift: defaultSectorShift,
+	}
+}
+
+// NewWriterFromArchive creates a Writer with the files of an existing archive, to append or replace files.
+// Only the files named in the (listfile) of the archive can be carried over. The (attributes) file is
+// dropped, as it would not match the rewritten files.
+func NewWriterFromArchive(fileName string) (*Writer, error) {
+	archive, err := Load(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	defer archive.Close()
+
+	list, err := archive.GetFileList()
+	if err != nil {
+		return nil, fmt.Errorf("archive %s has no usable listfile: %w", fileName, err)
+	}
+
+	w := NewWriter()
+
+	for _, name := range list {
+		if name == "" || strings.EqualFold(name, listFileName) || strings.EqualFold(name, attributesFileName) {
+			continue
+		}
+
+		if !archive.Contains(name) {
+			continue
+		}
+
+		data, err := readArchiveFile(archive.(*MPQ), name)
+		if err != nil {
+			return nil, err
+		}
+
+		w.AddFile(name, data)
+	}
+
+	return w, nil
+}
+
+// readArchiveFile reads a file, turning a panic for unsupported compression methods into an error
+func readArchiveFile(archive *MPQ, name string) (data []byte, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("can not read %s: %v", name, r)
+		}
+	}()
+
+	return archive.ReadFile(name)
+}
+
+// AddFile adds a file with the Writer's Options, replacing a file with the same name.
+func (w *Writer) AddFile(name string, data []byte) {
+	w.AddFileWithOptions(name, data, w.Options)
+}
+
+// AddFileWithOptions adds a file with the given options, replacing a file with the same name. Forward slashes
+// in the name are stored as backslashes.
+func (w *Writer) AddFileWithOptions(name string, data []byte, options FileOptions) {
+	name = strings.ReplaceAll(name, "/", `\`)
+	file := &writerFile{name: name, data: data, options: options}
+
+	if idx := w.indexOf(name); idx >= 0 {
+		w.files[idx] = file
+		return
+	}
+
+	w.files = append(w.files, file)
+}
+
+// RemoveFile removes a file, it returns false if there is no such file.
+func (w *Writer) RemoveFile(name string) bool {
+	idx := w.indexOf(name)
+	if idx < 0 {
+		return false
+	}
+
+	w.files = append(w.files[:idx], w.files[idx+1:]...)
+
+	return true
+}
+
+// Contains returns true if the Writer has a file with the given name
+func (w *Writer) Contains(name string) bool {
+	return w.indexOf(name) >= 0
+}
+
+// FileNames returns the names of all files, in the order they are written
+func (w *Writer) FileNames() []string {
+	names := make([]string, len(w.files))
+
+	for idx := range w.files {
+		names[idx] = w.files[idx].name
+	}
+
+	return names
+}
+
+func (w *Writer) indexOf(name string) int {
+	name = strings.ReplaceAll(name, "/", `\`)
+
+	for idx := range w.files {
+		if strings.EqualFold(w.files[idx].name, name) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// Save writes the archive to a file. The archive is written to a temporary file first, which replaces the
+// given file when it is complete.
+func (w *Writer) Save(fileName string) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(fileName), filepath.Base(fileName)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if err = tmp.Chmod(archivePermissions); err != nil {
+		log.Print(err)
+	}
+
+	if _, err = w.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err = tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), fileName)
+}
+
+// WriteTo writes the archive with a (listfile), followed by the hash table and the block table.
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	files := append(w.files[:len(w.files):len(w.files)], w.listFile())
+	blocks := make([]BlockTableEntry, len(files))
+	body := &bytes.Buffer{}
+
+	for idx, file := range files {
+		position := uint32(mpqHeaderSize + body.Len())
+
+		entry, data, err := w.encodeFile(file, position)
+		if err != nil {
+			return 0, err
+		}
+
+		blocks[idx] = entry
+		body.Write(data)
+	}
+
+	hashTable, err := buildHashTable(files)
+	if err != nil {
+		return 0, err
+	}
+
+	hashTableOffset := uint32(mpqHeaderSize + body.Len())
+	blockTableOffset := hashTableOffset + uint32(len(hashTable))*16 //nolint:gomnd // hash entry size
+	archiveSize := blockTableOffset + uint32(len(blocks))*16        //nolint:gomnd // block entry size
+
+	header := Data{
+		HeaderSize:        mpqHeaderSize,
+		ArchiveSize:       archiveSize,
+		FormatVersion:     0,
+		BlockSize:         w.sectorShift,
+		HashTableOffset:   hashTableOffset,
+		BlockTableOffset:  blockTableOffset,
+		HashTableEntries:  uint32(len(hashTable)),
+		BlockTableEntries: uint32(len(blocks)),
+	}
+	copy(header.Magic[:], "MPQ\x1A")
+
+	result := &bytes.Buffer{}
+	result.Grow(int(archiveSize))
+
+	if err := binary.Write(result, binary.LittleEndian, &header); err != nil {
+		return 0, err
+	}
+
+	result.Write(body.Bytes())
+	writeTable(result, encodeHashTable(hashTable), "(hash table)")
+	writeTable(result, encodeBlockTable(blocks), "(block table)")
+
+	return result.WriteTo(out)
+}
+
+func (w *Writer) listFile() *writerFile {
+	var list bytes.Buffer
+
+	for _, file := range w.files {
+		list.WriteString(file.name)
+		list.WriteString("\r\n")
+	}
+
+	return &writerFile{name: listFileName, data: list.Bytes(), options: w.Options}
+}
+
+// encodeFile compresses and encrypts a file stored at the given position of the archive
+func (w *Writer) encodeFile(file *writerFile, position uint32) (BlockTableEntry, []byte, error) {
+	entry := BlockTableEntry{
+		FilePosition:         position,
+		UncompressedFileSize: uint32(len(file.data)),
+		Flags:                FileExists,
+	}
+
+	if len(file.data) == 0 {
+		return entry, nil, nil
+	}
+
+	options := file.options
+	sectorSize := 0x200 << w.sectorShift //nolint:gomnd // MPQ magic
+
+	var seed uint32
+
+	if options.Encrypted {
+		entry.Flags |= FileEncrypted
+		seed = encryptionSeed(file.name, &entry, options.FixKey)
+	}
+
+	sectors := splitSectors(file.data, sectorSize)
+
+	if options.Compression == CompressionNone {
+		data := make([]byte, 0, len(file.data))
+
+		for idx, sector := range sectors {
+			sector = append([]byte(nil), sector...)
+
+			if options.Encrypted {
+				encryptBytes(sector, seed+uint32(idx))
+			}
+
+			data = append(data, sector...)
+		}
+
+		entry.CompressedFileSize = uint32(len(data))
+
+		return entry, data, nil
+	}
+
+	entry.Flags |= FileCompress
+
+	offsetCount := len(sectors) + 1
+	if options.SectorCRC {
+		entry.Flags |= FileSectorCrc
+		offsetCount++
+	}
+
+	offsets := make([]uint32, offsetCount)
+	checksums := make([]uint32, 0, len(sectors))
+	body := &bytes.Buffer{}
+	tableSize := offsetCount * 4 //nolint:gomnd // uint32 size
+
+	for idx, sector := range sectors {
+		compressed, err := compressSector(sector, options.Compression)
+		if err != nil {
+			return entry, nil, err
+		}
+
+		checksums = append(checksums, adler32.Checksum(compressed))
+
+		if options.Encrypted {
+			encryptBytes(compressed, seed+uint32(idx))
+		}
+
+		offsets[idx] = uint32(tableSize + body.Len())
+		body.Write(compressed)
+	}
+
+	offsets[len(sectors)] = uint32(tableSize + body.Len())
+
+	if options.SectorCRC {
+		crc := make([]byte, len(checksums)*4) //nolint:gomnd // uint32 size
+
+		for idx := range checksums {
+			binary.LittleEndian.PutUint32(crc[idx*4:], checksums[idx])
+		}
+
+		if options.Encrypted {
+			encryptBytes(crc, seed+uint32(len(sectors)))
+		}
+
+		body.Write(crc)
+		offsets[len(sectors)+1] = uint32(tableSize + body.Len())
+	}
+
+	if options.Encrypted {
+		encrypt(offsets, seed-1)
+	}
+
+	data := make([]byte, tableSize, tableSize+body.Len())
+
+	for idx := range offsets {
+		binary.LittleEndian.PutUint32(data[idx*4:], offsets[idx])
+	}
+
+	data = append(data, body.Bytes()...)
+	entry.CompressedFileSize = uint32(len(data))
+
+	return entry, data, nil
+}
+
+// encryptionSeed returns the encryption key of a file, which is based on the file name without its path
+func encryptionSeed(name string, entry *BlockTableEntry, fixKey bool) uint32 {
+	segments := strings.Split(name, `\`)
+	seed := hashString(segments[len(segments)-1], 3) //nolint:gomnd // hash type of the file key
+
+	if fixKey {
+		entry.Flags |= FileFixKey
+		seed = (seed + entry.FilePosition) ^ entry.UncompressedFileSize
+	}
+
+	return seed
+}
+
+func splitSectors(data []byte, sectorSize int) [][]byte {
+	sectors := make([][]byte, 0, (len(data)+sectorSize-1)/sectorSize)
+
+	for start := 0; start < len(data); start += sectorSize {
+		end := start + sectorSize
+		if end > len(data) {
+			end = len(data)
+		}
+
+		sectors = append(sectors, data[start:end])
+	}
+
+	return sectors
+}
+
+// compressSector compresses a sector and prefixes it with the compression type. Sectors which do not get smaller
+// are stored as is, which readers detect by the sector size.
+func compressSector(sector []byte, compression Compression) ([]byte, error) {
+	var compressed []byte
+
+	switch compression {
+	case CompressionZlib:
+		var buf bytes.Buffer
+
+		buf.WriteByte(byte(CompressionZlib))
+
+		zw := zlib.NewWriter(&buf)
+
+		if _, err := zw.Write(sector); err != nil {
+			return nil, err
+		}
+
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+
+		compressed = buf.Bytes()
+	case CompressionPKWare:
+		compressed = append([]byte{byte(CompressionPKWare)}, pkCompress(sector)...)
+	default:
+		return nil, fmt.Errorf("unsupported compression: %s", compression)
+	}
+
+	if len(compressed) >= len(sector) {
+		return append([]byte(nil), sector...), nil
+	}
+
+	return compressed, nil
+}
+
+// buildHashTable places the files in a hash table, resolving collisions with linear probing. The table size is a
+// power of two with some free entries, which end the lookups of missing files.
+func buildHashTable(files []*writerFile) ([]HashTableEntry, error) {
+	size := minHashTableEntries
+	for size < len(files)*4/3+1 { //nolint:gomnd // keep a quarter of the table free
+		size *= 2
+	}
+
+	table := make([]HashTableEntry, size)
+
+	for idx := range table {
+		table[idx] = HashTableEntry{
+			NamePartA:  hashEntryEmpty,
+			NamePartB:  hashEntryEmpty,
+			Locale:     0xFFFF, //nolint:gomnd // empty entry
+			Platform:   0xFFFF, //nolint:gomnd // empty entry
+			BlockIndex: hashEntryEmpty,
+		}
+	}
+
+	for blockIndex, file := range files {
+		nameA := hashString(file.name, 1)
+		nameB := hashString(file.name, 2) //nolint:gomnd // hash type of the name check
+		idx := int(hashString(file.name, 0)) & (size - 1)
+
+		for table[idx].BlockIndex != hashEntryEmpty {
+			if table[idx].NamePartA == nameA && table[idx].NamePartB == nameB {
+				return nil, errors.New("hash collision for " + file.name)
+			}
+
+			idx = (idx + 1) & (size - 1)
+		}
+
+		table[idx] = HashTableEntry{NamePartA: nameA, NamePartB: nameB, BlockIndex: uint32(blockIndex)}
+	}
+
+	return table, nil
+}
+
+func encodeHashTable(table []HashTableEntry) []uint32 {
+	data := make([]uint32, 0, len(table)*4) //nolint:gomnd // uint32s per entry
+
+	for _, e := range table {
+		data = append(data, e.NamePartA, e.NamePartB, uint32(e.Platform)<<16|uint32(e.Locale), e.BlockIndex)
+	}
+
+	return data
+}
+
+func encodeBlockTable(blocks []BlockTableEntry) []uint32 {
+	data := make([]uint32, 0, len(blocks)*4) //nolint:gomnd // uint32s per entry
+
+	for _, e := range blocks {
+		data = append(data, e.FilePosition, e.CompressedFileSize, e.UncompressedFileSize, uint32(e.Flags))
+	}
+
+	return data
+}
+
+func writeTable(out *bytes.Buffer, data []uint32, key string) {
+	encrypt(data, hashString(key, 3)) //nolint:gomnd // hash type of the table key
+
+	for _, v := range data {
+		_ = binary.Write(out, binary.LittleEndian, v)
+	}
+}
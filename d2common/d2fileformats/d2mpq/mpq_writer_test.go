@@ -0,0 +1,191 @@
+package d2mpq
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testData(size int, seed int64, compressible bool) []byte {
+	rng := rand.New(rand.NewSource(seed)) //nolint:gosec // deterministic test data
+	data := make([]byte, size)
+
+	for idx := range data {
+		if compressible {
+			data[idx] = "ACT1 tristram cow level "[rng.Intn(24)]
+		} else {
+			data[idx] = byte(rng.Intn(256))
+		}
+	}
+
+	return data
+}
+
+func testArchivePath(t *testing.T) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "d2mpq")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "test.mpq")
+}
+
+func TestPkCompress(t *testing.T) {
+	inputs := [][]byte{
+		{},
+		{42},
+		[]byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		bytes.Repeat([]byte{0}, 4096),
+		testData(4096, 1, true),
+		testData(4096, 2, false),
+		testData(20000, 3, true),
+	}
+
+	for idx, input := range inputs {
+		compressed := pkCompress(input)
+
+		if got := pkDecompress(compressed); !bytes.Equal(got, input) {
+			t.Errorf("input %d: round trip mismatch, %d bytes in, %d bytes out", idx, len(input), len(got))
+		}
+	}
+
+	if compressed := pkCompress(inputs[4]); len(compressed) >= len(inputs[4]) {
+		t.Errorf("expected compressible data to get smaller, got %d bytes", len(compressed))
+	}
+}
+
+func TestEncrypt(t *testing.T) {
+	data := []uint32{1, 2, 3, 0xFFFFFFFF}
+	encrypted := append([]uint32(nil), data...)
+
+	encrypt(encrypted, 0x1234)
+	decrypt(encrypted, 0x1234)
+
+	for idx := range data {
+		if encrypted[idx] != data[idx] {
+			t.Fatalf("round trip mismatch at %d: %v", idx, encrypted)
+		}
+	}
+}
+
+func TestWriter_RoundTrip(t *testing.T) {
+	files := []struct {
+		name    string
+		data    []byte
+		options FileOptions
+	}{
+		{`data\global\excel\armor.txt`, testData(10000, 1, true), DefaultFileOptions()},
+		{`data\global\random.bin`, testData(9000, 2, false), DefaultFileOptions()},
+		{`data\global\empty.txt`, nil, DefaultFileOptions()},
+		{`data\global\tiny.txt`, []byte("ab"), FileOptions{Compression: CompressionZlib, Encrypted: true}},
+		{`data\pk.txt`, testData(12345, 3, true), FileOptions{Compression: CompressionPKWare, SectorCRC: true}},
+		{`data\plain.txt`, testData(5000, 4, true), FileOptions{Compression: CompressionNone, Encrypted: true}},
+		{`data\secret.txt`, testData(7000, 5, true), FileOptions{
+			Compression: CompressionZlib, Encrypted: true, FixKey: true, SectorCRC: true,
+		}},
+		{`data\secret_pk.txt`, testData(4096, 6, true), FileOptions{
+			Compression: CompressionPKWare, Encrypted: true, FixKey: true,
+		}},
+	}
+
+	w := NewWriter()
+
+	for _, f := range files {
+		w.AddFileWithOptions(f.name, f.data, f.options)
+	}
+
+	archivePath := testArchivePath(t)
+
+	if err := w.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Load(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer archive.Close()
+
+	for _, f := range files {
+		if !archive.Contains(f.name) {
+			t.Errorf("%s: missing from the archive", f.name)
+			continue
+		}
+
+		data, err := archive.ReadFile(f.name)
+		if err != nil {
+			t.Errorf("%s: %s", f.name, err)
+			continue
+		}
+
+		if !bytes.Equal(data, f.data) {
+			t.Errorf("%s: round trip mismatch", f.name)
+		}
+	}
+
+	list, err := archive.GetFileList()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != len(files) {
+		t.Errorf("expected %d files in the listfile, got %d: %v", len(files), len(list), list)
+	}
+}
+
+func TestWriter_FromArchive(t *testing.T) {
+	archivePath := testArchivePath(t)
+
+	w := NewWriter()
+	w.AddFile("keep.txt", []byte("keep me"))
+	w.AddFile("data/replace.txt", []byte("old"))
+	w.AddFile("remove.txt", []byte("remove me"))
+
+	if err := w.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWriterFromArchive(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.AddFile(`data\REPLACE.txt`, []byte("new"))
+	w.AddFile("added.txt", []byte("added"))
+
+	if !w.RemoveFile("remove.txt") {
+		t.Error("expected remove.txt to be removed")
+	}
+
+	if err = w.Save(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := Load(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer archive.Close()
+
+	want := map[string]string{"keep.txt": "keep me", `data\replace.txt`: "new", "added.txt": "added"}
+
+	for name, content := range want {
+		data, err := archive.ReadFile(name)
+		if err != nil || string(data) != content {
+			t.Errorf("%s: expected %q, got %q (%v)", name, content, data, err)
+		}
+	}
+
+	if archive.Contains("remove.txt") {
+		t.Error("removed file is still in the archive")
+	}
+}
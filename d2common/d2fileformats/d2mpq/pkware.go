@@ -0,0 +1,223 @@
+package d2mpq
+
+// This is a compressor for the PKWARE Data Compression Library format ("implode"), which is decompressed by
+// pkDecompress. Only the binary literal mode is written, the ASCII mode has no benefit for game data.
+// See blast.c in the zlib contrib directory for a description of the format.
+
+const (
+	pkLiteralBinary  = 0
+	pkDictionaryBits = 6 // 4096 byte dictionary
+	pkDictionarySize = 64 << pkDictionaryBits
+
+	pkMinMatch    = 3 // two byte matches exist, but rarely save anything
+	pkMaxMatch    = 518
+	pkEndOfStream = 519
+
+	pkHashBits     = 12
+	pkMaxChainSize = 64
+)
+
+// length codes: symbol i encodes lengths pkLengthBase[i] to pkLengthBase[i] + 1<<pkLengthExtra[i] - 1
+var pkLengthBase = [...]int{3, 2, 4, 5, 6, 7, 8, 9, 10, 12, 16, 24, 40, 72, 136, 264} //nolint:gochecknoglobals // const table
+
+var pkLengthExtra = [...]uint{0, 0, 0, 0, 0, 0, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8} //nolint:gochecknoglobals // const table
+
+// code lengths of the length and distance codes, each byte is (symbol count - 1) << 4 | code length
+var pkLengthCodeLengths = [...]byte{2, 35, 36, 53, 38, 23} //nolint:gochecknoglobals // const table
+
+var pkDistanceCodeLengths = [...]byte{2, 20, 53, 230, 247, 151, 248} //nolint:gochecknoglobals // const table
+
+// pkCode is a huffman code ready to be written to the bit stream
+type pkCode struct {
+	bits   uint32
+	length uint
+}
+
+//nolint:gochecknoglobals // built once from the const tables above
+var (
+	pkLengthCodes   = buildPkCodes(pkLengthCodeLengths[:])
+	pkDistanceCodes = buildPkCodes(pkDistanceCodeLengths[:])
+)
+
+// buildPkCodes assigns canonical huffman codes to the symbols. The decoder reads the codes most significant bit
+// first and inverted, so the codes are stored reversed and inverted for the least significant bit first writer.
+func buildPkCodes(compact []byte) []pkCode {
+	var lengths []uint
+
+	for _, b := range compact {
+		for n := int(b>>4) + 1; n > 0; n-- { //nolint:gomnd // see the table description
+			lengths = append(lengths, uint(b&0xf)) //nolint:gomnd // see the table description
+		}
+	}
+
+	codes := make([]pkCode, len(lengths))
+	code := uint32(0)
+
+	for length := uint(1); length <= 16; length++ { //nolint:gomnd // max code length
+		for symbol := range lengths {
+			if lengths[symbol] != length {
+				continue
+			}
+
+			codes[symbol] = pkCode{bits: reverseBits(^code, length), length: length}
+			code++
+		}
+
+		code <<= 1
+	}
+
+	return codes
+}
+
+func reverseBits(v uint32, length uint) uint32 {
+	result := uint32(0)
+
+	for i := uint(0); i < length; i++ {
+		result = result<<1 | (v>>i)&1
+	}
+
+	return result
+}
+
+// pkBitWriter writes bits least significant bit first
+type pkBitWriter struct {
+	data    []byte
+	buffer  uint32
+	bitSize uint
+}
+
+func (w *pkBitWriter) writeBits(v uint32, length uint) {
+	w.buffer |= (v & (1<<length - 1)) << w.bitSize
+	w.bitSize += length
+
+	for w.bitSize >= 8 { //nolint:gomnd // bits per byte
+		w.data = append(w.data, byte(w.buffer))
+		w.buffer >>= 8
+		w.bitSize -= 8
+	}
+}
+
+func (w *pkBitWriter) writeCode(c pkCode) {
+	w.writeBits(c.bits, c.length)
+}
+
+func (w *pkBitWriter) flush() []byte {
+	if w.bitSize > 0 {
+		w.data = append(w.data, byte(w.buffer))
+		w.buffer, w.bitSize = 0, 0
+	}
+
+	return w.data
+}
+
+func (w *pkBitWriter) writeLength(length int) {
+	symbol := len(pkLengthBase) - 1
+
+	for symbol > 0 && pkLengthBase[symbol] > length {
+		symbol--
+	}
+
+	// symbol 0 is length 3, which is above the base of symbol 1
+	if length == pkLengthBase[0] {
+		symbol = 0
+	}
+
+	w.writeCode(pkLengthCodes[symbol])
+	w.writeBits(uint32(length-pkLengthBase[symbol]), pkLengthExtra[symbol])
+}
+
+func (w *pkBitWriter) writeDistance(distance int) {
+	d := uint32(distance - 1)
+
+	w.writeCode(pkDistanceCodes[d>>pkDictionaryBits])
+	w.writeBits(d, pkDictionaryBits)
+}
+
+// pkCompress compresses the data with the PKWARE Data Compression Library format, using greedy matching
+// with hash chains.
+func pkCompress(data []byte) []byte {
+	w := &pkBitWriter{data: make([]byte, 0, len(data)/2)} //nolint:gomnd // initial guess
+	w.writeBits(pkLiteralBinary, 8)                       //nolint:gomnd // header byte
+	w.writeBits(pkDictionaryBits, 8)                      //nolint:gomnd // header byte
+
+	var head [1 << pkHashBits]int
+
+	for i := range head {
+		head[i] = -1
+	}
+
+	prev := make([]int, len(data))
+
+	insert := func(pos int) {
+		if pos+pkMinMatch > len(data) {
+			return
+		}
+
+		h := pkHash(data[pos:])
+		prev[pos] = head[h]
+		head[h] = pos
+	}
+
+	for pos := 0; pos < len(data); {
+		length, distance := pkFindMatch(data, pos, head[:], prev)
+
+		if length < pkMinMatch {
+			w.writeBits(0, 1)
+			w.writeBits(uint32(data[pos]), 8) //nolint:gomnd // literal byte
+			insert(pos)
+			pos++
+
+			continue
+		}
+
+		w.writeBits(1, 1)
+		w.writeLength(length)
+		w.writeDistance(distance)
+
+		for end := pos + length; pos < end; pos++ {
+			insert(pos)
+		}
+	}
+
+	w.writeBits(1, 1)
+	w.writeLength(pkEndOfStream)
+
+	return w.flush()
+}
+
+func pkHash(b []byte) int {
+	return (int(b[0])<<8 ^ int(b[1])<<4 ^ int(b[2])) & (1<<pkHashBits - 1) //nolint:gomnd // hash mixing
+}
+
+// pkFindMatch returns the longest match for the data at pos within the dictionary
+func pkFindMatch(data []byte, pos int, head, prev []int) (length, distance int) {
+	if pos+pkMinMatch > len(data) {
+		return 0, 0
+	}
+
+	maxLength := len(data) - pos
+	if maxLength > pkMaxMatch {
+		maxLength = pkMaxMatch
+	}
+
+	candidate := head[pkHash(data[pos:])]
+
+	for chain := 0; candidate >= 0 && pos-candidate <= pkDictionarySize && chain < pkMaxChainSize; chain++ {
+		n := 0
+		for n < maxLength && data[candidate+n] == data[pos+n] {
+			n++
+		}
+
+		if n > length {
+			length, distance = n, pos-candidate
+
+			if n == maxLength {
+				break
+			}
+		}
+
+		candidate = prev[candidate]
+	}
+
+	return length, distance
+}
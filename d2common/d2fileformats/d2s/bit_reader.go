@@ -0,0 +1,74 @@
+package d2s
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const bitsPerByte = 8
+
+// bitReader reads the bit packed sections, it remembers running out of data instead of panicking
+type bitReader struct {
+	muncher *d2datautils.BitMuncher
+	data    []byte
+	size    int
+	err     error
+}
+
+func newBitReader(data []byte, offset int) *bitReader {
+	return &bitReader{
+		muncher: d2datautils.CreateBitMuncher(data, offset*bitsPerByte),
+		data:    data,
+		size:    len(data) * bitsPerByte,
+	}
+}
+
+func (r *bitReader) bits(count int) uint32 {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.muncher.Offset()+count > r.size {
+		r.err = errTruncated
+		return 0
+	}
+
+	return r.muncher.GetBits(count)
+}
+
+func (r *bitReader) int(count int) int {
+	return int(r.bits(count))
+}
+
+func (r *bitReader) bool() bool {
+	return r.bits(1) == 1
+}
+
+// align moves to the start of the next byte and returns its offset
+func (r *bitReader) align() int {
+	offset := (r.muncher.Offset() + bitsPerByte - 1) / bitsPerByte
+	r.muncher.SetOffset(offset * bitsPerByte)
+
+	return offset
+}
+
+// bitWriter writes the bit packed sections
+type bitWriter struct {
+	*d2datautils.BitWriter
+}
+
+func newBitWriter() bitWriter {
+	return bitWriter{d2datautils.CreateBitWriter()}
+}
+
+func (w bitWriter) int(value, count int) {
+	w.PushBits(uint32(value), count)
+}
+
+func (w bitWriter) bool(value bool) {
+	if value {
+		w.PushBit(1)
+		return
+	}
+
+	w.PushBit(0)
+}
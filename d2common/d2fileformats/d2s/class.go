@@ -0,0 +1,65 @@
+package d2s
+
+import "github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+
+// noSkill marks a skill hotkey that is not assigned
+const noSkill = 0xFFFF
+
+// Class is the class of a character, as it is stored in character files
+type Class byte
+
+// Classes
+const (
+	ClassAmazon Class = iota
+	ClassSorceress
+	ClassNecromancer
+	ClassPaladin
+	ClassBarbarian
+	ClassDruid
+	ClassAssassin
+)
+
+//nolint:gochecknoglobals // constant tables
+var (
+	classHeroes = map[Class]d2enum.Hero{
+		ClassAmazon:      d2enum.HeroAmazon,
+		ClassSorceress:   d2enum.HeroSorceress,
+		ClassNecromancer: d2enum.HeroNecromancer,
+		ClassPaladin:     d2enum.HeroPaladin,
+		ClassBarbarian:   d2enum.HeroBarbarian,
+		ClassDruid:       d2enum.HeroDruid,
+		ClassAssassin:    d2enum.HeroAssassin,
+	}
+
+	// the ID of the first skill of each class, the skills of a class have consecutive IDs
+	classFirstSkills = map[Class]int{
+		ClassAmazon:      6,
+		ClassSorceress:   36,
+		ClassNecromancer: 66,
+		ClassPaladin:     96,
+		ClassBarbarian:   126,
+		ClassDruid:       221,
+		ClassAssassin:    251,
+	}
+)
+
+// ClassFromHero returns the class of a hero type
+func ClassFromHero(hero d2enum.Hero) (Class, bool) {
+	for class, classHero := range classHeroes {
+		if classHero == hero {
+			return class, true
+		}
+	}
+
+	return 0, false
+}
+
+// Hero returns the hero type of the class, or HeroNone when the class is unknown
+func (c Class) Hero() d2enum.Hero {
+	return classHeroes[c]
+}
+
+// SkillID returns the skill ID of the n-th skill of the class, in the order of the skills section
+func (c Class) SkillID(n int) int {
+	return classFirstSkills[c] + n
+}
@@ -0,0 +1,361 @@
+package d2s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+// Version is the version of the character files written by Diablo II 1.10 to 1.14d, the only version
+// that is supported
+const Version = 96
+
+const (
+	signature = 0xAA55AA55
+
+	nameLength       = 16
+	skillHotkeyCount = 16
+	appearanceSize   = 32
+	difficultyCount  = 3
+	headerSize       = 335
+
+	sizeOffset     = 8
+	checksumOffset = 12
+
+	unknownHeader1Size = 2
+	unknownHeader2Size = 2
+	unknownHeader3Size = 4
+	unknownHeader4Size = 4
+	unknownHeader5Size = 2
+	unknownHeader6Size = 144
+
+	// the act of a difficulty is stored in the low bits, the high bit marks the active difficulty
+	difficultyActMask   = 0x07
+	difficultyActiveBit = 0x80
+)
+
+// Character status flags
+const (
+	StatusHardcore  = 1 << 2
+	StatusDied      = 1 << 3
+	StatusExpansion = 1 << 5
+	StatusLadder    = 1 << 6
+)
+
+// Difficulties
+const (
+	DifficultyNormal = iota
+	DifficultyNightmare
+	DifficultyHell
+)
+
+var (
+	errSignature   = errors.New("not a character file")
+	errVersion     = errors.New("unsupported character file version")
+	errTruncated   = errors.New("character file is truncated")
+	errSectionID   = errors.New("unexpected section")
+	errNameTooLong = errors.New("character name is too long")
+)
+
+// D2S is a character file
+type D2S struct {
+	Version        uint32
+	ActiveWeapon   uint32
+	Name           string
+	Status         byte
+	Progression    byte
+	Class          Class
+	Level          byte
+	Timestamp      uint32
+	SkillHotkeys   [skillHotkeyCount]uint32
+	LeftSkill      uint32
+	RightSkill     uint32
+	LeftSwapSkill  uint32
+	RightSwapSkill uint32
+	Appearance     [appearanceSize]byte
+	Difficulty     [difficultyCount]byte
+	MapID          uint32
+	MercDead       uint16
+	MercID         uint32
+	MercNameID     uint16
+	MercType       uint16
+	MercExperience uint32
+
+	Progress [difficultyCount]Progress
+	Stats    CharacterStats
+	Skills   [ClassSkillCount]byte
+
+	Items     []*Item
+	Corpse    *Corpse
+	MercItems []*Item
+	Golem     *Item
+
+	unknownHeader [][]byte
+	npcData       []byte
+
+	// the item sections as they were loaded, when they were not decoded
+	itemData []byte
+}
+
+// Corpse is the corpse of the character, with the items that are still on it
+type Corpse struct {
+	Items []*Item
+
+	unknown []byte
+}
+
+// New creates the file of a new character, on the normal difficulty in act 1 of the expansion
+func New(name string, class Class) *D2S {
+	d2s := &D2S{
+		Version:    Version,
+		Name:       name,
+		Status:     StatusExpansion,
+		Class:      class,
+		Level:      1,
+		Difficulty: [difficultyCount]byte{difficultyActiveBit},
+		Stats:      CharacterStats{Level: 1},
+		Items:      []*Item{},
+		MercItems:  []*Item{},
+	}
+
+	for i := range d2s.SkillHotkeys {
+		d2s.SkillHotkeys[i] = noSkill
+	}
+
+	d2s.Progress[DifficultyNormal].SetWaypoint(0, true)
+
+	return d2s
+}
+
+// Load loads a character file. The items are decoded with the given records, when there are no records the
+// items are kept as they are and written back unchanged.
+func Load(data []byte, records ItemRecords) (*D2S, error) {
+	if len(data) < headerSize+questSectionSize+waypointSectionSize+npcSectionSize {
+		return nil, errTruncated
+	}
+
+	sr := d2datautils.CreateStreamReader(data)
+
+	if sr.GetUInt32() != signature {
+		return nil, errSignature
+	}
+
+	d2s := &D2S{Version: sr.GetUInt32()}
+	if d2s.Version != Version {
+		return nil, fmt.Errorf("%w: %d", errVersion, d2s.Version)
+	}
+
+	sr.SkipBytes(8) //nolint:gomnd // the file size and checksum
+
+	d2s.loadHeader(sr)
+
+	if err := d2s.loadProgress(sr); err != nil {
+		return nil, err
+	}
+
+	offset, err := d2s.Stats.load(data, int(sr.GetPosition()))
+	if err != nil {
+		return nil, err
+	}
+
+	if offset, err = d2s.loadSkills(data, offset); err != nil {
+		return nil, err
+	}
+
+	if records == nil {
+		d2s.itemData = append([]byte{}, data[offset:]...)
+		return d2s, nil
+	}
+
+	if err := d2s.loadItems(newBitReader(data, offset), records); err != nil {
+		return nil, err
+	}
+
+	return d2s, nil
+}
+
+func (d2s *D2S) loadHeader(sr *d2datautils.StreamReader) {
+	d2s.ActiveWeapon = sr.GetUInt32()
+	d2s.Name = strings.TrimRight(string(sr.ReadBytes(nameLength)), "\x00")
+	d2s.Status = sr.GetByte()
+	d2s.Progression = sr.GetByte()
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader1Size))
+	d2s.Class = Class(sr.GetByte())
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader2Size))
+	d2s.Level = sr.GetByte()
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader3Size))
+	d2s.Timestamp = sr.GetUInt32()
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader4Size))
+
+	for i := range d2s.SkillHotkeys {
+		d2s.SkillHotkeys[i] = sr.GetUInt32()
+	}
+
+	d2s.LeftSkill = sr.GetUInt32()
+	d2s.RightSkill = sr.GetUInt32()
+	d2s.LeftSwapSkill = sr.GetUInt32()
+	d2s.RightSwapSkill = sr.GetUInt32()
+	copy(d2s.Appearance[:], sr.ReadBytes(appearanceSize))
+	copy(d2s.Difficulty[:], sr.ReadBytes(difficultyCount))
+	d2s.MapID = sr.GetUInt32()
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader5Size))
+	d2s.MercDead = sr.GetUInt16()
+	d2s.MercID = sr.GetUInt32()
+	d2s.MercNameID = sr.GetUInt16()
+	d2s.MercType = sr.GetUInt16()
+	d2s.MercExperience = sr.GetUInt32()
+	d2s.unknownHeader = append(d2s.unknownHeader, readBytes(sr, unknownHeader6Size))
+}
+
+// Marshal encodes the character file and updates its size and checksum. Items are encoded with the given
+// records, which may be nil when the file was loaded without records and the items were not changed.
+func (d2s *D2S) Marshal(records ItemRecords) ([]byte, error) {
+	if len(d2s.Name) >= nameLength {
+		return nil, fmt.Errorf("%w: %s", errNameTooLong, d2s.Name)
+	}
+
+	sw := d2datautils.CreateStreamWriter()
+
+	sw.PushUint32(signature)
+	sw.PushUint32(d2s.Version)
+	sw.PushUint32(0)
+	sw.PushUint32(0)
+
+	d2s.saveHeader(sw)
+	d2s.saveProgress(sw)
+	d2s.Stats.save(sw)
+
+	sw.PushBytes([]byte(skillsSectionID)...)
+	sw.PushBytes(d2s.Skills[:]...)
+
+	if d2s.itemData != nil {
+		sw.PushBytes(d2s.itemData...)
+	} else if err := d2s.saveItems(sw, records); err != nil {
+		return nil, err
+	}
+
+	data := sw.GetBytes()
+	setUint32(data, sizeOffset, uint32(len(data)))
+	setUint32(data, checksumOffset, Checksum(data))
+
+	return data, nil
+}
+
+func (d2s *D2S) saveHeader(sw *d2datautils.StreamWriter) {
+	unknown := d2s.unknownHeader
+	if len(unknown) == 0 {
+		unknown = defaultUnknownHeader()
+	}
+
+	name := make([]byte, nameLength)
+	copy(name, d2s.Name)
+
+	sw.PushUint32(d2s.ActiveWeapon)
+	sw.PushBytes(name...)
+	sw.PushByte(d2s.Status)
+	sw.PushByte(d2s.Progression)
+	sw.PushBytes(unknown[0]...)
+	sw.PushByte(byte(d2s.Class))
+	sw.PushBytes(unknown[1]...)
+	sw.PushByte(d2s.Level)
+	sw.PushBytes(unknown[2]...)
+	sw.PushUint32(d2s.Timestamp)
+	sw.PushBytes(unknown[3]...)
+
+	for _, hotkey := range d2s.SkillHotkeys {
+		sw.PushUint32(hotkey)
+	}
+
+	sw.PushUint32(d2s.LeftSkill)
+	sw.PushUint32(d2s.RightSkill)
+	sw.PushUint32(d2s.LeftSwapSkill)
+	sw.PushUint32(d2s.RightSwapSkill)
+	sw.PushBytes(d2s.Appearance[:]...)
+	sw.PushBytes(d2s.Difficulty[:]...)
+	sw.PushUint32(d2s.MapID)
+	sw.PushBytes(unknown[4]...)
+	sw.PushUint16(d2s.MercDead)
+	sw.PushUint32(d2s.MercID)
+	sw.PushUint16(d2s.MercNameID)
+	sw.PushUint16(d2s.MercType)
+	sw.PushUint32(d2s.MercExperience)
+	sw.PushBytes(unknown[5]...)
+}
+
+// defaultUnknownHeader returns the unknown header fields of a new character
+func defaultUnknownHeader() [][]byte {
+	return [][]byte{
+		make([]byte, unknownHeader1Size),
+		{0x10, 0x1E}, //nolint:gomnd // written by the game
+		make([]byte, unknownHeader3Size),
+		{0xFF, 0xFF, 0xFF, 0xFF}, //nolint:gomnd // written by the game
+		make([]byte, unknownHeader5Size),
+		make([]byte, unknownHeader6Size),
+	}
+}
+
+// Expansion returns true for characters of the expansion
+func (d2s *D2S) Expansion() bool {
+	return d2s.Status&StatusExpansion != 0
+}
+
+// CurrentDifficulty returns the active difficulty and the act the character is in, starting at 1
+func (d2s *D2S) CurrentDifficulty() (difficulty, act int) {
+	for i, value := range d2s.Difficulty {
+		if value&difficultyActiveBit != 0 {
+			return i, int(value&difficultyActMask) + 1
+		}
+	}
+
+	return DifficultyNormal, 1
+}
+
+// SetCurrentDifficulty makes the difficulty active and sets the act the character is in, starting at 1
+func (d2s *D2S) SetCurrentDifficulty(difficulty, act int) {
+	for i := range d2s.Difficulty {
+		d2s.Difficulty[i] &^= difficultyActiveBit
+	}
+
+	d2s.Difficulty[difficulty] = difficultyActiveBit | byte(act-1)&difficultyActMask
+}
+
+// Checksum returns the checksum of a character file, the checksum field itself is skipped
+func Checksum(data []byte) uint32 {
+	var sum uint32
+
+	for i, value := range data {
+		if i >= checksumOffset && i < checksumOffset+4 {
+			value = 0
+		}
+
+		sum = sum<<1 | sum>>31
+		sum += uint32(value)
+	}
+
+	return sum
+}
+
+// readBytes copies bytes from the stream, so the loaded file does not keep the data alive
+func readBytes(sr *d2datautils.StreamReader, count int) []byte {
+	return append([]byte{}, sr.ReadBytes(count)...)
+}
+
+func setUint32(data []byte, offset int, value uint32) {
+	for i := 0; i < 4; i++ {
+		data[offset+i] = byte(value >> (bitsPerByte * i))
+	}
+}
+
+func readSectionID(data []byte, offset int, id string) error {
+	if offset+len(id) > len(data) {
+		return errTruncated
+	}
+
+	if found := string(data[offset : offset+len(id)]); found != id {
+		return fmt.Errorf("%w: %q instead of %q", errSectionID, found, id)
+	}
+
+	return nil
+}
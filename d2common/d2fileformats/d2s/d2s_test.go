@@ -0,0 +1,248 @@
+package d2s
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// levelOffset is the offset of the level of the character in the header
+const levelOffset = 43
+
+// testFile is testCharacter as a character file, it guards the layout of the written files against changes
+const testFile = "testdata/tester.d2s"
+
+type testRecords struct{}
+
+func (testRecords) ItemStat(id int) (StatLayout, bool) {
+	layout, found := map[int]StatLayout{
+		0:   {Bits: 8, Add: 32},
+		17:  {Bits: 9},
+		18:  {Bits: 9},
+		31:  {Bits: 11, Add: 10},
+		54:  {Bits: 8},
+		55:  {Bits: 9},
+		56:  {Bits: 8},
+		72:  {Bits: 9},
+		73:  {Bits: 8},
+		97:  {Bits: 6, ParamBits: 9},
+		127: {Bits: 3},
+	}[id]
+
+	return layout, found
+}
+
+func (testRecords) ItemKind(code string) ItemKind {
+	switch code {
+	case "cap":
+		return ItemKindArmor
+	case "lsd", "jav":
+		return ItemKindWeapon
+	default:
+		return ItemKindMisc
+	}
+}
+
+func (testRecords) Stackable(code string) bool {
+	return code == "jav" || code == "tbk"
+}
+
+func testCharacter() *D2S {
+	character := New("Tester", ClassSorceress)
+	character.Level = 42
+	character.Stats = CharacterStats{
+		Strength: 60, Energy: 150, Dexterity: 45, Vitality: 110, StatPoints: 5,
+		Life: 400 << 8, MaxLife: 420 << 8, Mana: 300 << 8, MaxMana: 320 << 8, MaxStamina: 180 << 8,
+		Level: 42, Experience: 123456789, Gold: 5000,
+	}
+	character.Skills[3] = 20
+	character.SetCurrentDifficulty(DifficultyNightmare, 3)
+	character.Progress[DifficultyNormal].SetQuestCompleted(1, 1, true)
+	character.Progress[DifficultyNormal].SetWaypoint(38, true)
+	character.MercID = 7
+
+	rune := &Item{Simple: true, Location: LocationSocketed, Code: "r01", Identified: true}
+	character.Items = []*Item{
+		{Simple: true, Location: LocationBelt, X: 2, Code: "hp1", Identified: true},
+		{
+			Location: LocationEquipped, Slot: SlotHead, Code: "cap", ID: 0xDEADBEEF, Level: 30,
+			Quality: d2enum.Magic, MagicPrefix: 100, MagicSuffix: 200, Defense: 12, MaxDurability: 24,
+			Durability: 20, Identified: true, Stats: []Stat{{ID: 0, Value: -5}, {ID: 97, Param: 54, Value: 1}},
+		},
+		{
+			Location: LocationEquipped, Slot: SlotRightHand, Code: "lsd", Level: 60, Quality: d2enum.Normal,
+			Socketed: true, Sockets: 2, Runeword: true, RunewordID: 27, MaxDurability: 44, Durability: 44,
+			Stats: []Stat{}, RunewordStats: []Stat{{ID: 17, Value: 200}, {ID: 18, Value: 200}},
+			SocketedItems: []*Item{rune, rune},
+		},
+		{
+			Storage: StorageInventory, X: 3, Y: 1, Code: "jav", Quality: d2enum.Set, SetID: 11, Quantity: 140,
+			MaxDurability: 0, Stats: []Stat{{ID: 54, Value: 3}, {ID: 55, Value: 7}, {ID: 56, Value: 50}},
+			SetBonusStats: [setBonusLists][]Stat{1: {{ID: 127, Value: 2}}}, Identified: true,
+		},
+		{
+			Storage: StorageStash, Code: "tbk", Quality: d2enum.Rare, RareNames: [2]int{12, 34},
+			RareAffixes: [rareAffixCount]int{5, 0, 7, 0, 0, 9}, TomeData: 3, Quantity: 20, Personalized: true,
+			PersonalizedName: "Tester", Stats: []Stat{},
+		},
+		{Ear: true, Storage: StorageInventory, EarClass: ClassBarbarian, EarLevel: 99, EarName: "Victim"},
+	}
+	character.Corpse = &Corpse{Items: []*Item{{Simple: true, Code: "hp1"}}}
+	character.MercItems = []*Item{{Simple: true, Location: LocationEquipped, Slot: SlotHead, Code: "hp1"}}
+	character.Golem = &Item{Simple: true, Code: "r01"}
+
+	return character
+}
+
+func TestD2SRoundTrip(t *testing.T) {
+	character := testCharacter()
+
+	data, err := character.Marshal(testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if Checksum(data) != binary.LittleEndian.Uint32(data[checksumOffset:]) {
+		t.Error("the checksum does not match")
+	}
+
+	loaded, err := Load(data, testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded.Name != "Tester" || loaded.Class.Hero() != d2enum.HeroSorceress || loaded.Stats != character.Stats {
+		t.Fatalf("unexpected character %s %v %+v", loaded.Name, loaded.Class, loaded.Stats)
+	}
+
+	if difficulty, act := loaded.CurrentDifficulty(); difficulty != DifficultyNightmare || act != 3 {
+		t.Errorf("expected act 3 of nightmare, got act %d of %d", act, difficulty)
+	}
+
+	progress := loaded.Progress[DifficultyNormal]
+	if !progress.QuestCompleted(1, 1) || progress.QuestCompleted(1, 2) || !progress.HasWaypoint(38) {
+		t.Error("the quests or waypoints were not loaded")
+	}
+
+	for i, item := range character.Items {
+		if !reflect.DeepEqual(item, loaded.Items[i]) {
+			t.Errorf("item %d was loaded as %+v, expected %+v", i, loaded.Items[i], item)
+		}
+	}
+
+	if !reflect.DeepEqual(loaded.MercItems, character.MercItems) || !reflect.DeepEqual(loaded.Golem, character.Golem) {
+		t.Error("the mercenary or golem items were not loaded")
+	}
+
+	if len(loaded.Corpse.Items) != 1 {
+		t.Error("the corpse was not loaded")
+	}
+
+	again, err := loaded.Marshal(testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, again) {
+		t.Error("the loaded file was not written back unchanged")
+	}
+}
+
+func TestD2SWithoutRecords(t *testing.T) {
+	data, err := testCharacter().Marshal(testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded.Level++
+
+	again, err := loaded.Marshal(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := len(data) - len(loaded.itemData)
+
+	if len(again) != len(data) || again[levelOffset] != data[levelOffset]+1 || !bytes.Equal(again[items:], data[items:]) {
+		t.Error("the items were not written back unchanged")
+	}
+}
+
+func TestD2SInvalid(t *testing.T) {
+	data, err := testCharacter().Marshal(testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(data[:len(data)-4], testRecords{}); err == nil {
+		t.Error("expected an error for a truncated file")
+	}
+
+	data[0] = 0
+
+	if _, err := Load(data, testRecords{}); err == nil {
+		t.Error("expected an error for an invalid signature")
+	}
+
+	if _, err := New("AVeryLongCharacterName", ClassDruid).Marshal(nil); err == nil {
+		t.Error("expected an error for a long name")
+	}
+}
+
+// TestD2SFile checks that testCharacter is still written as the character file in testdata
+func TestD2SFile(t *testing.T) {
+	expected, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := testCharacter().Marshal(testRecords{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(data, expected) {
+		t.Errorf("the character is not written as %s", testFile)
+	}
+}
+
+// TestD2SFiles writes back the character files in testdata, with and without decoding their items
+func TestD2SFiles(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.d2s"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, records := range []ItemRecords{nil, testRecords{}} {
+			character, err := Load(data, records)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+
+			again, err := character.Marshal(records)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+
+			if !bytes.Equal(data, again) {
+				t.Errorf("%s was not written back unchanged", path)
+			}
+		}
+	}
+}
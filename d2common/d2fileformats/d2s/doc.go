@@ -0,0 +1,2 @@
+// Package d2s provides functionality for loading and saving the character files (.d2s) of Diablo II
+package d2s
@@ -0,0 +1,702 @@
+package d2s
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+// ItemVersion is the version of the items in the supported character files
+const ItemVersion = 101
+
+const (
+	itemID     = "JM"
+	itemIDBits = 16
+
+	flagBits         = 32
+	flagIdentified   = 1 << 4
+	flagSocketed     = 1 << 11
+	flagNew          = 1 << 13
+	flagEar          = 1 << 16
+	flagStarter      = 1 << 17
+	flagSimple       = 1 << 21
+	flagEthereal     = 1 << 22
+	flagPersonalized = 1 << 24
+	flagRuneword     = 1 << 26
+	knownFlags       = flagIdentified | flagSocketed | flagNew | flagEar | flagStarter | flagSimple | flagEthereal |
+		flagPersonalized | flagRuneword
+
+	versionBits       = 10
+	locationBits      = 3
+	slotBits          = 4
+	positionBits      = 4
+	storageBits       = 3
+	codeLength        = 4
+	filledSocketBits  = 3
+	earClassBits      = 3
+	earLevelBits      = 7
+	nameCharBits      = 7
+	maxNameLength     = 15
+	uniqueIDBits      = 32
+	levelBits         = 7
+	qualityBits       = 4
+	pictureBits       = 3
+	autoAffixBits     = 11
+	qualityIDBits     = 3
+	affixBits         = 11
+	setUniqueBits     = 12
+	rareNameBits      = 8
+	rareAffixCount    = 6
+	runewordBits      = 12
+	runewordExtraBits = 4
+	tomeBits          = 5
+	quantityBits      = 9
+	socketBits        = 4
+	setBonusLists     = 5
+
+	statArmorClass    = 31
+	statDurability    = 72
+	statMaxDurability = 73
+)
+
+var (
+	errItemID    = errors.New("item does not start with JM")
+	errNoRecords = errors.New("item records are needed to encode items")
+)
+
+//nolint:gochecknoglobals // constant tables
+var (
+	// stats that are saved together with the stats that follow them, without their IDs
+	statGroups = map[int]int{17: 2, 48: 2, 50: 2, 52: 2, 54: 3, 57: 3}
+
+	tomeCodes = map[string]bool{"tbk": true, "ibk": true}
+)
+
+// ItemKind is the kind of base item, it decides which attributes are saved
+type ItemKind int
+
+// Item kinds
+const (
+	ItemKindMisc ItemKind = iota
+	ItemKindArmor
+	ItemKindWeapon
+)
+
+// ItemLocation is where an item is
+type ItemLocation int
+
+// Item locations
+const (
+	LocationStored   ItemLocation = 0
+	LocationEquipped ItemLocation = 1
+	LocationBelt     ItemLocation = 2
+	LocationCursor   ItemLocation = 4
+	LocationSocketed ItemLocation = 6
+)
+
+// EquipSlot is the body location of an equipped item
+type EquipSlot int
+
+// Equip slots
+const (
+	SlotNone EquipSlot = iota
+	SlotHead
+	SlotNeck
+	SlotTorso
+	SlotRightHand
+	SlotLeftHand
+	SlotRightRing
+	SlotLeftRing
+	SlotBelt
+	SlotFeet
+	SlotGloves
+	SlotSwapRightHand
+	SlotSwapLeftHand
+)
+
+// ItemStorage is the panel of a stored item
+type ItemStorage int
+
+// Item storages
+const (
+	StorageNone      ItemStorage = 0
+	StorageInventory ItemStorage = 1
+	StorageCube      ItemStorage = 4
+	StorageStash     ItemStorage = 5
+)
+
+// StatLayout describes how an item stat is saved, it comes from ItemStatCost.txt
+type StatLayout struct {
+	Bits      int
+	Add       int
+	ParamBits int
+}
+
+// ItemRecords answers what is not stored in character files, but needed to decode their items
+type ItemRecords interface {
+	// ItemStat returns how the item stat with the given ID is saved
+	ItemStat(id int) (StatLayout, bool)
+	// ItemKind returns the kind of the base item with the given code
+	ItemKind(code string) ItemKind
+	// Stackable returns true when the base item with the given code has a quantity
+	Stackable(code string) bool
+}
+
+// Stat is a stat of an item
+type Stat struct {
+	ID    int
+	Param int
+	Value int
+}
+
+// Item is an item in a character file
+type Item struct {
+	Identified   bool
+	Socketed     bool
+	New          bool
+	Ear          bool
+	Starter      bool
+	Simple       bool
+	Ethereal     bool
+	Personalized bool
+	Runeword     bool
+
+	Version  int
+	Location ItemLocation
+	Slot     EquipSlot
+	X        int
+	Y        int
+	Storage  ItemStorage
+
+	EarClass Class
+	EarLevel int
+	EarName  string
+
+	Code string
+
+	ID               uint32
+	Level            int
+	Quality          d2enum.ItemQuality
+	HasPicture       bool
+	PictureID        int
+	ClassSpecific    bool
+	AutoAffixID      int
+	QualityID        int // the low quality prefix or the superior type
+	MagicPrefix      int
+	MagicSuffix      int
+	SetID            int
+	UniqueID         int
+	RareNames        [2]int
+	RareAffixes      [rareAffixCount]int // the prefixes and suffixes of rare items, 0 for none
+	RunewordID       int
+	PersonalizedName string
+	TomeData         int
+	Defense          int
+	MaxDurability    int
+	Durability       int
+	Quantity         int
+	Sockets          int
+
+	Stats         []Stat
+	SetBonusStats [setBonusLists][]Stat // the lists that are nil are not saved
+	RunewordStats []Stat
+	SocketedItems []*Item
+
+	unknownFlags    uint32
+	unknownBit      bool
+	runewordUnknown int
+}
+
+// NewItem creates an identified item of normal quality, with the version of the items of Diablo II 1.10 to
+// 1.14d
+func NewItem(code string) *Item {
+	return &Item{
+		Identified: true,
+		Version:    ItemVersion,
+		Code:       code,
+		Level:      1,
+		Quality:    d2enum.Normal,
+		Stats:      []Stat{},
+	}
+}
+
+// readItem reads an item that starts at a byte boundary, without the items in its sockets
+func readItem(r *bitReader, records ItemRecords) (*Item, int, error) {
+	if id := r.bits(itemIDBits); r.err == nil && id != uint32(itemID[0])|uint32(itemID[1])<<bitsPerByte {
+		return nil, 0, errItemID
+	}
+
+	item := &Item{}
+	filledSockets := item.readSimple(r)
+
+	if !item.Simple && !item.Ear {
+		if err := item.readExtended(r, records); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if r.err != nil {
+		return nil, 0, r.err
+	}
+
+	r.align()
+
+	return item, filledSockets, nil
+}
+
+// readSimple reads the attributes of all items, and returns the number of items in the sockets
+func (i *Item) readSimple(r *bitReader) int {
+	flags := r.bits(flagBits)
+	i.Identified = flags&flagIdentified != 0
+	i.Socketed = flags&flagSocketed != 0
+	i.New = flags&flagNew != 0
+	i.Ear = flags&flagEar != 0
+	i.Starter = flags&flagStarter != 0
+	i.Simple = flags&flagSimple != 0
+	i.Ethereal = flags&flagEthereal != 0
+	i.Personalized = flags&flagPersonalized != 0
+	i.Runeword = flags&flagRuneword != 0
+	i.unknownFlags = flags &^ knownFlags
+
+	i.Version = r.int(versionBits)
+	i.Location = ItemLocation(r.int(locationBits))
+	i.Slot = EquipSlot(r.int(slotBits))
+	i.X = r.int(positionBits)
+	i.Y = r.int(positionBits)
+	i.Storage = ItemStorage(r.int(storageBits))
+
+	if i.Ear {
+		i.EarClass = Class(r.int(earClassBits))
+		i.EarLevel = r.int(earLevelBits)
+		i.EarName = readName(r)
+
+		return 0
+	}
+
+	code := make([]byte, codeLength)
+	for n := range code {
+		code[n] = byte(r.bits(bitsPerByte))
+	}
+
+	i.Code = strings.TrimRight(string(code), " ")
+
+	return r.int(filledSocketBits)
+}
+
+func (i *Item) readExtended(r *bitReader, records ItemRecords) error {
+	i.ID = r.bits(uniqueIDBits)
+	i.Level = r.int(levelBits)
+	i.Quality = d2enum.ItemQuality(r.int(qualityBits))
+
+	if i.HasPicture = r.bool(); i.HasPicture {
+		i.PictureID = r.int(pictureBits)
+	}
+
+	if i.ClassSpecific = r.bool(); i.ClassSpecific {
+		i.AutoAffixID = r.int(autoAffixBits)
+	}
+
+	i.readQuality(r)
+
+	if i.Runeword {
+		i.RunewordID = r.int(runewordBits)
+		i.runewordUnknown = r.int(runewordExtraBits)
+	}
+
+	if i.Personalized {
+		i.PersonalizedName = readName(r)
+	}
+
+	if tomeCodes[i.Code] {
+		i.TomeData = r.int(tomeBits)
+	}
+
+	i.unknownBit = r.bool()
+
+	if err := i.readDurability(r, records); err != nil {
+		return err
+	}
+
+	if records.Stackable(i.Code) {
+		i.Quantity = r.int(quantityBits)
+	}
+
+	if i.Socketed {
+		i.Sockets = r.int(socketBits)
+	}
+
+	return i.readStatLists(r, records)
+}
+
+func (i *Item) readQuality(r *bitReader) {
+	switch i.Quality {
+	case d2enum.LowQuality, d2enum.Superior:
+		i.QualityID = r.int(qualityIDBits)
+	case d2enum.Magic:
+		i.MagicPrefix = r.int(affixBits)
+		i.MagicSuffix = r.int(affixBits)
+	case d2enum.Set:
+		i.SetID = r.int(setUniqueBits)
+	case d2enum.Unique:
+		i.UniqueID = r.int(setUniqueBits)
+	case d2enum.Rare, d2enum.Crafted:
+		i.RareNames[0] = r.int(rareNameBits)
+		i.RareNames[1] = r.int(rareNameBits)
+
+		for n := range i.RareAffixes {
+			if r.bool() {
+				i.RareAffixes[n] = r.int(affixBits)
+			}
+		}
+	}
+}
+
+func (i *Item) readDurability(r *bitReader, records ItemRecords) error {
+	kind := records.ItemKind(i.Code)
+
+	if kind == ItemKindArmor {
+		layout, err := itemStat(records, statArmorClass)
+		if err != nil {
+			return err
+		}
+
+		i.Defense = r.int(layout.Bits) - layout.Add
+	}
+
+	if kind == ItemKindMisc {
+		return nil
+	}
+
+	layout, err := itemStat(records, statMaxDurability)
+	if err != nil {
+		return err
+	}
+
+	if i.MaxDurability = r.int(layout.Bits) - layout.Add; i.MaxDurability == 0 {
+		return nil
+	}
+
+	if layout, err = itemStat(records, statDurability); err != nil {
+		return err
+	}
+
+	i.Durability = r.int(layout.Bits) - layout.Add
+
+	return nil
+}
+
+func (i *Item) readStatLists(r *bitReader, records ItemRecords) error {
+	setLists := 0
+	if i.Quality == d2enum.Set {
+		setLists = r.int(setBonusLists)
+	}
+
+	var err error
+
+	if i.Stats, err = readStats(r, records); err != nil {
+		return err
+	}
+
+	for n := range i.SetBonusStats {
+		if setLists&(1<<uint(n)) == 0 {
+			continue
+		}
+
+		if i.SetBonusStats[n], err = readStats(r, records); err != nil {
+			return err
+		}
+	}
+
+	if i.Runeword {
+		i.RunewordStats, err = readStats(r, records)
+	}
+
+	return err
+}
+
+func readStats(r *bitReader, records ItemRecords) ([]Stat, error) {
+	stats := make([]Stat, 0)
+
+	for id := r.int(statIDBits); id != statListEnd && r.err == nil; id = r.int(statIDBits) {
+		for n := 0; n < statGroupSize(id); n++ {
+			layout, err := itemStat(records, id+n)
+			if err != nil {
+				return nil, err
+			}
+
+			stat := Stat{ID: id + n}
+			stat.Param = r.int(layout.ParamBits)
+			stat.Value = r.int(layout.Bits) - layout.Add
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats, r.err
+}
+
+func statGroupSize(id int) int {
+	if size, found := statGroups[id]; found {
+		return size
+	}
+
+	return 1
+}
+
+func itemStat(records ItemRecords, id int) (StatLayout, error) {
+	layout, found := records.ItemStat(id)
+	if !found {
+		return layout, fmt.Errorf("%w: %d", errUnknownStat, id)
+	}
+
+	return layout, nil
+}
+
+func readName(r *bitReader) string {
+	name := make([]byte, 0, maxNameLength)
+
+	for n := 0; n <= maxNameLength; n++ {
+		char := byte(r.int(nameCharBits))
+		if char == 0 {
+			break
+		}
+
+		name = append(name, char)
+	}
+
+	return string(name)
+}
+
+// marshal encodes the item, without the items in its sockets
+func (i *Item) marshal(records ItemRecords) ([]byte, error) {
+	w := newBitWriter()
+
+	w.int(int(itemID[0])|int(itemID[1])<<bitsPerByte, itemIDBits)
+	i.writeSimple(w)
+
+	if !i.Simple && !i.Ear {
+		if records == nil {
+			return nil, errNoRecords
+		}
+
+		if err := i.writeExtended(w, records); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.GetBytes(), nil
+}
+
+func (i *Item) writeSimple(w bitWriter) {
+	flags := i.unknownFlags
+
+	for flag, set := range map[uint32]bool{
+		flagIdentified: i.Identified, flagSocketed: i.Socketed, flagNew: i.New, flagEar: i.Ear,
+		flagStarter: i.Starter, flagSimple: i.Simple, flagEthereal: i.Ethereal,
+		flagPersonalized: i.Personalized, flagRuneword: i.Runeword,
+	} {
+		if set {
+			flags |= flag
+		}
+	}
+
+	w.PushBits(flags, flagBits)
+	w.int(i.Version, versionBits)
+	w.int(int(i.Location), locationBits)
+	w.int(int(i.Slot), slotBits)
+	w.int(i.X, positionBits)
+	w.int(i.Y, positionBits)
+	w.int(int(i.Storage), storageBits)
+
+	if i.Ear {
+		w.int(int(i.EarClass), earClassBits)
+		w.int(i.EarLevel, earLevelBits)
+		writeName(w, i.EarName)
+
+		return
+	}
+
+	code := []byte(i.Code + strings.Repeat(" ", codeLength))
+	for _, char := range code[:codeLength] {
+		w.int(int(char), bitsPerByte)
+	}
+
+	w.int(len(i.SocketedItems), filledSocketBits)
+}
+
+func (i *Item) writeExtended(w bitWriter, records ItemRecords) error {
+	w.PushBits(i.ID, uniqueIDBits)
+	w.int(i.Level, levelBits)
+	w.int(int(i.Quality), qualityBits)
+
+	if w.bool(i.HasPicture); i.HasPicture {
+		w.int(i.PictureID, pictureBits)
+	}
+
+	if w.bool(i.ClassSpecific); i.ClassSpecific {
+		w.int(i.AutoAffixID, autoAffixBits)
+	}
+
+	i.writeQuality(w)
+
+	if i.Runeword {
+		w.int(i.RunewordID, runewordBits)
+		w.int(i.runewordUnknown, runewordExtraBits)
+	}
+
+	if i.Personalized {
+		writeName(w, i.PersonalizedName)
+	}
+
+	if tomeCodes[i.Code] {
+		w.int(i.TomeData, tomeBits)
+	}
+
+	w.bool(i.unknownBit)
+
+	if err := i.writeDurability(w, records); err != nil {
+		return err
+	}
+
+	if records.Stackable(i.Code) {
+		w.int(i.Quantity, quantityBits)
+	}
+
+	if i.Socketed {
+		w.int(i.Sockets, socketBits)
+	}
+
+	return i.writeStatLists(w, records)
+}
+
+func (i *Item) writeQuality(w bitWriter) {
+	switch i.Quality {
+	case d2enum.LowQuality, d2enum.Superior:
+		w.int(i.QualityID, qualityIDBits)
+	case d2enum.Magic:
+		w.int(i.MagicPrefix, affixBits)
+		w.int(i.MagicSuffix, affixBits)
+	case d2enum.Set:
+		w.int(i.SetID, setUniqueBits)
+	case d2enum.Unique:
+		w.int(i.UniqueID, setUniqueBits)
+	case d2enum.Rare, d2enum.Crafted:
+		w.int(i.RareNames[0], rareNameBits)
+		w.int(i.RareNames[1], rareNameBits)
+
+		for _, affix := range i.RareAffixes {
+			if w.bool(affix != 0); affix != 0 {
+				w.int(affix, affixBits)
+			}
+		}
+	}
+}
+
+func (i *Item) writeDurability(w bitWriter, records ItemRecords) error {
+	kind := records.ItemKind(i.Code)
+
+	if kind == ItemKindArmor {
+		layout, err := itemStat(records, statArmorClass)
+		if err != nil {
+			return err
+		}
+
+		w.int(i.Defense+layout.Add, layout.Bits)
+	}
+
+	if kind == ItemKindMisc {
+		return nil
+	}
+
+	layout, err := itemStat(records, statMaxDurability)
+	if err != nil {
+		return err
+	}
+
+	if w.int(i.MaxDurability+layout.Add, layout.Bits); i.MaxDurability == 0 {
+		return nil
+	}
+
+	if layout, err = itemStat(records, statDurability); err != nil {
+		return err
+	}
+
+	w.int(i.Durability+layout.Add, layout.Bits)
+
+	return nil
+}
+
+func (i *Item) writeStatLists(w bitWriter, records ItemRecords) error {
+	if i.Quality == d2enum.Set {
+		setLists := 0
+
+		for n := range i.SetBonusStats {
+			if i.SetBonusStats[n] != nil {
+				setLists |= 1 << uint(n)
+			}
+		}
+
+		w.int(setLists, setBonusLists)
+	}
+
+	if err := writeStats(w, records, i.Stats); err != nil {
+		return err
+	}
+
+	for _, stats := range i.SetBonusStats {
+		if stats == nil {
+			continue
+		}
+
+		if err := writeStats(w, records, stats); err != nil {
+			return err
+		}
+	}
+
+	if i.Runeword {
+		return writeStats(w, records, i.RunewordStats)
+	}
+
+	return nil
+}
+
+// writeStats writes a stat list, the stats that are saved in groups must follow each other
+func writeStats(w bitWriter, records ItemRecords, stats []Stat) error {
+	for idx := 0; idx < len(stats); {
+		id := stats[idx].ID
+		w.int(id, statIDBits)
+
+		for n := 0; n < statGroupSize(id); n++ {
+			layout, err := itemStat(records, id+n)
+			if err != nil {
+				return err
+			}
+
+			stat := Stat{ID: id + n}
+			if idx < len(stats) && stats[idx].ID == id+n {
+				stat = stats[idx]
+				idx++
+			}
+
+			w.int(stat.Param, layout.ParamBits)
+			w.int(stat.Value+layout.Add, layout.Bits)
+		}
+	}
+
+	w.int(statListEnd, statIDBits)
+
+	return nil
+}
+
+func writeName(w bitWriter, name string) {
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+
+	for _, char := range []byte(name) {
+		w.int(int(char), nameCharBits)
+	}
+
+	w.int(0, nameCharBits)
+}
@@ -0,0 +1,184 @@
+package d2s
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const (
+	mercSectionID     = "jf"
+	golemSectionID    = "kf"
+	corpseUnknownSize = 12
+	countBits         = 16
+)
+
+func (d2s *D2S) loadItems(r *bitReader, records ItemRecords) error {
+	var err error
+
+	if d2s.Items, err = readItemList(r, records); err != nil {
+		return err
+	}
+
+	if err := readListID(r, itemID); err != nil {
+		return err
+	}
+
+	if r.int(countBits) > 0 {
+		d2s.Corpse = &Corpse{unknown: make([]byte, corpseUnknownSize)}
+
+		for n := range d2s.Corpse.unknown {
+			d2s.Corpse.unknown[n] = byte(r.bits(bitsPerByte))
+		}
+
+		if d2s.Corpse.Items, err = readItemList(r, records); err != nil {
+			return err
+		}
+	}
+
+	if !d2s.Expansion() {
+		return r.err
+	}
+
+	if err := readListID(r, mercSectionID); err != nil {
+		return err
+	}
+
+	if d2s.MercID != 0 {
+		if d2s.MercItems, err = readItemList(r, records); err != nil {
+			return err
+		}
+	}
+
+	if err := readListID(r, golemSectionID); err != nil {
+		return err
+	}
+
+	if r.int(bitsPerByte) != 0 {
+		if d2s.Golem, err = readItemWithSockets(r, records); err != nil {
+			return err
+		}
+	}
+
+	return r.err
+}
+
+func readListID(r *bitReader, id string) error {
+	offset := r.align()
+
+	if err := readSectionID(r.data, offset, id); err != nil {
+		return err
+	}
+
+	r.bits(len(id) * bitsPerByte)
+
+	return r.err
+}
+
+func readItemList(r *bitReader, records ItemRecords) ([]*Item, error) {
+	if err := readListID(r, itemID); err != nil {
+		return nil, err
+	}
+
+	count := r.int(countBits)
+	items := make([]*Item, 0, count)
+
+	for n := 0; n < count; n++ {
+		item, err := readItemWithSockets(r, records)
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, item)
+	}
+
+	return items, r.err
+}
+
+// readItemWithSockets reads an item and the items in its sockets, which follow it
+func readItemWithSockets(r *bitReader, records ItemRecords) (*Item, error) {
+	item, filledSockets, err := readItem(r, records)
+	if err != nil {
+		return nil, err
+	}
+
+	for n := 0; n < filledSockets; n++ {
+		socketed, _, err := readItem(r, records)
+		if err != nil {
+			return nil, err
+		}
+
+		item.SocketedItems = append(item.SocketedItems, socketed)
+	}
+
+	return item, nil
+}
+
+func (d2s *D2S) saveItems(sw *d2datautils.StreamWriter, records ItemRecords) error {
+	if err := writeItemList(sw, records, d2s.Items); err != nil {
+		return err
+	}
+
+	sw.PushBytes([]byte(itemID)...)
+
+	if d2s.Corpse == nil {
+		sw.PushUint16(0)
+	} else {
+		unknown := make([]byte, corpseUnknownSize)
+		copy(unknown, d2s.Corpse.unknown)
+
+		sw.PushUint16(1)
+		sw.PushBytes(unknown...)
+
+		if err := writeItemList(sw, records, d2s.Corpse.Items); err != nil {
+			return err
+		}
+	}
+
+	if !d2s.Expansion() {
+		return nil
+	}
+
+	sw.PushBytes([]byte(mercSectionID)...)
+
+	if d2s.MercID != 0 {
+		if err := writeItemList(sw, records, d2s.MercItems); err != nil {
+			return err
+		}
+	}
+
+	sw.PushBytes([]byte(golemSectionID)...)
+
+	if d2s.Golem == nil {
+		sw.PushByte(0)
+		return nil
+	}
+
+	sw.PushByte(1)
+
+	return writeItemWithSockets(sw, records, d2s.Golem)
+}
+
+func writeItemList(sw *d2datautils.StreamWriter, records ItemRecords, items []*Item) error {
+	sw.PushBytes([]byte(itemID)...)
+	sw.PushUint16(uint16(len(items)))
+
+	for _, item := range items {
+		if err := writeItemWithSockets(sw, records, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeItemWithSockets(sw *d2datautils.StreamWriter, records ItemRecords, item *Item) error {
+	for _, item := range append([]*Item{item}, item.SocketedItems...) {
+		data, err := item.marshal(records)
+		if err != nil {
+			return err
+		}
+
+		sw.PushBytes(data...)
+	}
+
+	return nil
+}
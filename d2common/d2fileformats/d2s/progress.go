@@ -0,0 +1,169 @@
+package d2s
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const (
+	questSectionID      = "Woo!"
+	questVersion        = 6
+	questWords          = 48
+	questDataSize       = questWords * 2
+	questSectionSize    = 10 + difficultyCount*questDataSize
+	questCompletedBit   = 1
+	waypointSectionID   = "WS"
+	waypointVersion     = 1
+	waypointDataSize    = 24
+	waypointSectionSize = 8 + difficultyCount*waypointDataSize
+	waypointBytes       = 5
+	waypointHeaderSize  = 2
+	npcSectionID        = "\x01\x77"
+	npcDataSize         = 48
+	npcSectionSize      = 4 + npcDataSize
+
+	// WaypointCount is the number of waypoints in the five acts
+	WaypointCount = 39
+)
+
+//nolint:gochecknoglobals // constant tables
+var (
+	// the first quest of each act, and the number of quests in it
+	actQuests = [...]struct{ first, count int }{
+		{1, 6}, {9, 6}, {17, 6}, {25, 3}, {35, 6},
+	}
+
+	waypointHeader = []byte{0x02, 0x01}
+)
+
+// Progress holds the quests and waypoints of one difficulty
+type Progress struct {
+	// Quests holds a word of flags for each quest, including the act introductions and completions
+	Quests [questWords]uint16
+	// Waypoints has one bit for each waypoint that was activated
+	Waypoints uint64
+
+	waypointUnknown []byte
+}
+
+// QuestCompleted returns true when the quest of an act was completed, the act and quest start at 1
+func (p *Progress) QuestCompleted(act, quest int) bool {
+	word, ok := questWord(act, quest)
+
+	return ok && p.Quests[word]&questCompletedBit != 0
+}
+
+// SetQuestCompleted marks the quest of an act as completed, the act and quest start at 1
+func (p *Progress) SetQuestCompleted(act, quest int, completed bool) {
+	word, ok := questWord(act, quest)
+	if !ok {
+		return
+	}
+
+	if completed {
+		p.Quests[word] |= questCompletedBit
+	} else {
+		p.Quests[word] &^= questCompletedBit
+	}
+}
+
+func questWord(act, quest int) (int, bool) {
+	if act < 1 || act > len(actQuests) || quest < 1 || quest > actQuests[act-1].count {
+		return 0, false
+	}
+
+	return actQuests[act-1].first + quest - 1, true
+}
+
+// HasWaypoint returns true when the waypoint was activated, the waypoints of all acts are counted from 0
+func (p *Progress) HasWaypoint(index int) bool {
+	return index >= 0 && index < WaypointCount && p.Waypoints&(1<<uint(index)) != 0
+}
+
+// SetWaypoint activates or deactivates a waypoint
+func (p *Progress) SetWaypoint(index int, active bool) {
+	if index < 0 || index >= WaypointCount {
+		return
+	}
+
+	if active {
+		p.Waypoints |= 1 << uint(index)
+	} else {
+		p.Waypoints &^= 1 << uint(index)
+	}
+}
+
+func (d2s *D2S) loadProgress(sr *d2datautils.StreamReader) error {
+	data := sr.ReadBytes(questSectionSize + waypointSectionSize + npcSectionSize)
+
+	if err := readSectionID(data, 0, questSectionID); err != nil {
+		return err
+	}
+
+	if err := readSectionID(data, questSectionSize, waypointSectionID); err != nil {
+		return err
+	}
+
+	if err := readSectionID(data, questSectionSize+waypointSectionSize, npcSectionID); err != nil {
+		return err
+	}
+
+	quests := d2datautils.CreateStreamReader(data[questSectionSize-difficultyCount*questDataSize : questSectionSize])
+	waypoints := d2datautils.CreateStreamReader(data[questSectionSize+waypointSectionSize-difficultyCount*waypointDataSize:])
+
+	for i := range d2s.Progress {
+		progress := &d2s.Progress[i]
+
+		for word := range progress.Quests {
+			progress.Quests[word] = quests.GetUInt16()
+		}
+
+		waypoints.SkipBytes(waypointHeaderSize)
+
+		for b := 0; b < waypointBytes; b++ {
+			progress.Waypoints |= uint64(waypoints.GetByte()) << uint(b*bitsPerByte)
+		}
+
+		progress.waypointUnknown = readBytes(waypoints, waypointDataSize-waypointHeaderSize-waypointBytes)
+	}
+
+	d2s.npcData = append([]byte{}, data[len(data)-npcDataSize:]...)
+
+	return nil
+}
+
+func (d2s *D2S) saveProgress(sw *d2datautils.StreamWriter) {
+	sw.PushBytes([]byte(questSectionID)...)
+	sw.PushUint32(questVersion)
+	sw.PushUint16(questSectionSize)
+
+	for i := range d2s.Progress {
+		for _, word := range d2s.Progress[i].Quests {
+			sw.PushUint16(word)
+		}
+	}
+
+	sw.PushBytes([]byte(waypointSectionID)...)
+	sw.PushUint32(waypointVersion)
+	sw.PushUint16(waypointSectionSize)
+
+	for i := range d2s.Progress {
+		progress := &d2s.Progress[i]
+
+		sw.PushBytes(waypointHeader...)
+
+		for b := 0; b < waypointBytes; b++ {
+			sw.PushByte(byte(progress.Waypoints >> uint(b*bitsPerByte)))
+		}
+
+		unknown := make([]byte, waypointDataSize-waypointHeaderSize-waypointBytes)
+		copy(unknown, progress.waypointUnknown)
+		sw.PushBytes(unknown...)
+	}
+
+	npcData := make([]byte, npcDataSize)
+	copy(npcData, d2s.npcData)
+
+	sw.PushBytes([]byte(npcSectionID)...)
+	sw.PushUint16(npcSectionSize)
+	sw.PushBytes(npcData...)
+}
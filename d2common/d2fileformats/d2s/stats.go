@@ -0,0 +1,113 @@
+package d2s
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2datautils"
+)
+
+const (
+	statsSectionID  = "gf"
+	skillsSectionID = "if"
+	statIDBits      = 9
+	statListEnd     = 0x1FF
+
+	// ClassSkillCount is the number of skills of a class
+	ClassSkillCount = 30
+)
+
+var errUnknownStat = errors.New("unknown stat")
+
+// characterStatBits are the bits of the character stats, in the order of their IDs
+//nolint:gochecknoglobals // constant table
+var characterStatBits = [...]int{10, 10, 10, 10, 10, 8, 21, 21, 21, 21, 21, 21, 7, 32, 25, 25}
+
+// CharacterStats are the stats of the character. Life, mana and stamina are fixed point numbers with 8
+// fraction bits.
+type CharacterStats struct {
+	Strength    int
+	Energy      int
+	Dexterity   int
+	Vitality    int
+	StatPoints  int
+	SkillPoints int
+	Life        int
+	MaxLife     int
+	Mana        int
+	MaxMana     int
+	Stamina     int
+	MaxStamina  int
+	Level       int
+	Experience  int
+	Gold        int
+	StashGold   int
+}
+
+// fields returns the stats in the order of their IDs
+func (s *CharacterStats) fields() []*int {
+	return []*int{
+		&s.Strength, &s.Energy, &s.Dexterity, &s.Vitality, &s.StatPoints, &s.SkillPoints,
+		&s.Life, &s.MaxLife, &s.Mana, &s.MaxMana, &s.Stamina, &s.MaxStamina,
+		&s.Level, &s.Experience, &s.Gold, &s.StashGold,
+	}
+}
+
+// load reads the stats section at the offset and returns the offset of the next section
+func (s *CharacterStats) load(data []byte, offset int) (int, error) {
+	if err := readSectionID(data, offset, statsSectionID); err != nil {
+		return 0, err
+	}
+
+	fields := s.fields()
+	r := newBitReader(data, offset+len(statsSectionID))
+
+	for id := r.int(statIDBits); id != statListEnd && r.err == nil; id = r.int(statIDBits) {
+		if id >= len(fields) {
+			return 0, fmt.Errorf("%w: %d", errUnknownStat, id)
+		}
+
+		*fields[id] = r.int(characterStatBits[id])
+	}
+
+	if r.err != nil {
+		return 0, r.err
+	}
+
+	return r.align(), nil
+}
+
+// save writes the stats that are not zero
+func (s *CharacterStats) save(sw *d2datautils.StreamWriter) {
+	w := newBitWriter()
+
+	for id, field := range s.fields() {
+		if *field == 0 {
+			continue
+		}
+
+		w.int(id, statIDBits)
+		w.int(*field, characterStatBits[id])
+	}
+
+	w.int(statListEnd, statIDBits)
+
+	sw.PushBytes([]byte(statsSectionID)...)
+	sw.PushBytes(w.GetBytes()...)
+}
+
+func (d2s *D2S) loadSkills(data []byte, offset int) (int, error) {
+	if err := readSectionID(data, offset, skillsSectionID); err != nil {
+		return 0, err
+	}
+
+	offset += len(skillsSectionID)
+
+	if offset+ClassSkillCount > len(data) {
+		return 0, errTruncated
+	}
+
+	copy(d2s.Skills[:], data[offset:])
+
+	return offset + ClassSkillCount, nil
+}
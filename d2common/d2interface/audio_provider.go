@@ -6,4 +6,5 @@ type AudioProvider interface {
 	PlayBGM(song string)
 	LoadSound(sfx string, loop bool, bgm bool) (SoundEffect, error)
 	SetVolumes(bgmVolume, sfxVolume float64)
+	CreatePCMStream(sampleRate, channels int) (PCMStream, error)
 }
@@ -0,0 +1,15 @@
+package d2interface
+
+import "time"
+
+// PCMStream plays 16 bit samples that are written to it while it plays, such as the audio tracks of
+// videos. It plays silence when it runs out of samples.
+type PCMStream interface {
+	// Write queues interleaved samples
+	Write(samples []int16)
+	Play()
+	Stop()
+	Close() error
+	// Position returns how much of the written audio has been played
+	Position() time.Duration
+}
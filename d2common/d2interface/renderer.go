@@ -1,7 +1,5 @@
 package d2interface
 
-import "github.com/hajimehoshi/ebiten/v2"
-
 type renderCallback = func(Surface) error
 
 type updateCallback = func() error
@@ -21,6 +19,4 @@ type Renderer interface {
 	GetCursorPos() (int, int)
 	CurrentFPS() float64
 	ShowPanicScreen(message string)
-	Print(target *ebiten.Image, str string) error
-	PrintAt(target *ebiten.Image, str string, x, y int)
 }
@@ -145,6 +145,27 @@ func AbsInt32(a int32) int32 {
 	return a
 }
 
+// AbsInt returns the absolute of the given int
+func AbsInt(a int) int {
+	if a < 0 {
+		return -a
+	}
+
+	return a
+}
+
+// SignInt returns the sign of the given int
+func SignInt(a int) int {
+	switch {
+	case a < 0:
+		return -1
+	case a > 0:
+		return +1
+	}
+
+	return 0
+}
+
 // MinInt32 returns the higher of two values
 func MinInt32(a, b int32) int32 {
 	if a < b {
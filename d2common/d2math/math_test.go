@@ -103,6 +103,14 @@ func BenchmarkAbs(b *testing.B) {
 	}
 }
 
+func TestAbsInt(t *testing.T) {
+	for x, want := range map[int]int{-3: 3, 0: 0, 2: 2} {
+		if got := AbsInt(x); got != want {
+			t.Errorf("absolute value of %d: want %d: got %d", x, want, got)
+		}
+	}
+}
+
 func TestClamp(t *testing.T) {
 	want := 0.5
 	a := 0.5
@@ -171,6 +179,14 @@ func BenchmarkSign(b *testing.B) {
 	}
 }
 
+func TestSignInt(t *testing.T) {
+	for a, want := range map[int]int{-3: -1, 0: 0, 2: 1} {
+		if got := SignInt(a); got != want {
+			t.Errorf("sign of %d: wanted %d: got %d", a, want, got)
+		}
+	}
+}
+
 func TestLerp(t *testing.T) {
 	want := 3.0
 	x := 0.3
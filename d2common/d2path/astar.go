@@ -0,0 +1,243 @@
+package d2path
+
+import (
+	"container/heap"
+	"math"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+const (
+	straightCost = 1.0
+	diagonalCost = math.Sqrt2
+)
+
+// DefaultSearchBudget is the default maximum number of nodes expanded by FindPath.
+const DefaultSearchBudget = 10000
+
+// WalkableFunc reports whether the cell at the given grid coordinates can be walked on.
+// It must return false for coordinates outside of the grid.
+type WalkableFunc func(x, y int) bool
+
+// neighbors are the eight grid directions, straight directions first
+var neighbors = [8]d2geom.Point{ //nolint:gochecknoglobals // lookup table
+	{X: 1, Y: 0}, {X: -1, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: -1},
+	{X: 1, Y: 1}, {X: -1, Y: 1}, {X: 1, Y: -1}, {X: -1, Y: -1},
+}
+
+type searchNode struct {
+	point  d2geom.Point
+	parent *searchNode
+	cost   float64 // cost from the start
+	score  float64 // cost + heuristic
+	index  int     // index in the open set, -1 when closed
+}
+
+type openSet []*searchNode
+
+func (o openSet) Len() int { return len(o) }
+
+func (o openSet) Less(i, j int) bool {
+	if o[i].score == o[j].score {
+		// prefer the node closest to the goal on ties
+		return o[i].cost > o[j].cost
+	}
+
+	return o[i].score < o[j].score
+}
+
+func (o openSet) Swap(i, j int) {
+	o[i], o[j] = o[j], o[i]
+	o[i].index = i
+	o[j].index = j
+}
+
+func (o *openSet) Push(x interface{}) {
+	n := x.(*searchNode)
+	n.index = len(*o)
+	*o = append(*o, n)
+}
+
+func (o *openSet) Pop() interface{} {
+	old := *o
+	last := len(old) - 1
+	n := old[last]
+	old[last] = nil
+	n.index = -1
+	*o = old[:last]
+
+	return n
+}
+
+// octile is the distance between two cells when moving in eight directions
+func octile(a, b d2geom.Point) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+
+	return straightCost*math.Max(dx, dy) + (diagonalCost-straightCost)*math.Min(dx, dy)
+}
+
+// canStep returns true if it is possible to move from the given cell in the given direction.
+// Diagonal moves are not allowed to cut the corner of a blocked cell.
+func canStep(from, dir d2geom.Point, walkable WalkableFunc) bool {
+	if !walkable(from.X+dir.X, from.Y+dir.Y) {
+		return false
+	}
+
+	if dir.X != 0 && dir.Y != 0 {
+		return walkable(from.X+dir.X, from.Y) && walkable(from.X, from.Y+dir.Y)
+	}
+
+	return true
+}
+
+// FindPath searches the grid for the shortest path between start and dest using A*. The returned
+// path excludes start and ends with dest. The start cell is always considered walkable.
+//
+// At most budget nodes are expanded. When the budget runs out, or when dest can not be reached, the
+// path leads to the explored cell closest to dest instead. Nil is returned when no progress towards
+// dest can be made.
+func FindPath(start, dest d2geom.Point, walkable WalkableFunc, budget int) []d2geom.Point {
+	if start == dest {
+		return nil
+	}
+
+	if budget <= 0 {
+		budget = DefaultSearchBudget
+	}
+
+	first := &searchNode{point: start, score: octile(start, dest)}
+	nodes := map[d2geom.Point]*searchNode{start: first}
+	open := &openSet{}
+	heap.Push(open, first)
+
+	closest := first
+	closestDistance := first.score
+
+	for expanded := 0; open.Len() > 0 && expanded < budget; expanded++ {
+		current := heap.Pop(open).(*searchNode)
+
+		if current.point == dest {
+			return tracePath(current)
+		}
+
+		if h := current.score - current.cost; h < closestDistance {
+			closest, closestDistance = current, h
+		}
+
+		for idx := range neighbors {
+			dir := neighbors[idx]
+			if !canStep(current.point, dir, walkable) {
+				continue
+			}
+
+			point := d2geom.Point{X: current.point.X + dir.X, Y: current.point.Y + dir.Y}
+
+			cost := current.cost + straightCost
+			if dir.X != 0 && dir.Y != 0 {
+				cost = current.cost + diagonalCost
+			}
+
+			node, seen := nodes[point]
+
+			switch {
+			case !seen:
+				node = &searchNode{point: point}
+				nodes[point] = node
+			case node.index < 0 || cost >= node.cost:
+				// already closed, or no improvement
+				continue
+			}
+
+			node.parent = current
+			node.cost = cost
+			node.score = cost + octile(point, dest)
+
+			if seen {
+				heap.Fix(open, node.index)
+			} else {
+				heap.Push(open, node)
+			}
+		}
+	}
+
+	if closest == first {
+		return nil
+	}
+
+	return tracePath(closest)
+}
+
+// tracePath walks back from the given node to the start, returning the cells in walking order without the start cell
+func tracePath(last *searchNode) []d2geom.Point {
+	length := 0
+	for n := last; n.parent != nil; n = n.parent {
+		length++
+	}
+
+	path := make([]d2geom.Point, length)
+
+	for n := last; n.parent != nil; n = n.parent {
+		length--
+		path[length] = n.point
+	}
+
+	return path
+}
+
+// LineOfSight returns true if the straight line between the centers of the given cells only crosses walkable
+// cells. A line passing exactly through a corner requires both cells touching that corner to be walkable, which
+// matches the corner cutting rule of FindPath.
+func LineOfSight(from, to d2geom.Point, walkable WalkableFunc) bool {
+	dx, dy := d2math.AbsInt(to.X-from.X), d2math.AbsInt(to.Y-from.Y)
+	sx, sy := d2math.SignInt(to.X-from.X), d2math.SignInt(to.Y-from.Y)
+	x, y := from.X, from.Y
+
+	for ix, iy := 0, 0; ix < dx || iy < dy; {
+		decision := (1+2*ix)*dy - (1+2*iy)*dx
+
+		switch {
+		case decision == 0:
+			if !walkable(x+sx, y) || !walkable(x, y+sy) {
+				return false
+			}
+
+			x, y = x+sx, y+sy
+			ix++
+			iy++
+		case decision < 0:
+			x += sx
+			ix++
+		default:
+			y += sy
+			iy++
+		}
+
+		if !walkable(x, y) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Smooth removes the cells of the path which can be skipped by walking in a straight line. The start cell is
+// the cell the path is walked from, it is not part of the path.
+func Smooth(start d2geom.Point, path []d2geom.Point, walkable WalkableFunc) []d2geom.Point {
+	if len(path) < 2 { //nolint:gomnd // nothing to smooth
+		return path
+	}
+
+	result := make([]d2geom.Point, 0, len(path))
+	anchor := start
+
+	for idx := 0; idx < len(path)-1; idx++ {
+		if !LineOfSight(anchor, path[idx+1], walkable) {
+			result = append(result, path[idx])
+			anchor = path[idx]
+		}
+	}
+
+	return append(result, path[len(path)-1])
+}
@@ -0,0 +1,231 @@
+package d2path
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+)
+
+// testGrid is a grid described by rows of text, where '#' is a blocked cell
+type testGrid []string
+
+func newTestGrid(rows ...string) testGrid {
+	return rows
+}
+
+func (g testGrid) walkable(x, y int) bool {
+	if y < 0 || y >= len(g) || x < 0 || x >= len(g[y]) {
+		return false
+	}
+
+	return g[y][x] != '#'
+}
+
+func (g testGrid) find(c byte) d2geom.Point {
+	for y := range g {
+		if x := strings.IndexByte(g[y], c); x >= 0 {
+			return d2geom.Point{X: x, Y: y}
+		}
+	}
+
+	panic("cell not found")
+}
+
+func checkWalkable(t *testing.T, g testGrid, start d2geom.Point, path []d2geom.Point) {
+	t.Helper()
+
+	prev := start
+
+	for _, p := range path {
+		if !g.walkable(p.X, p.Y) {
+			t.Fatalf("path goes through blocked cell %v: %v", p, path)
+		}
+
+		dir := d2geom.Point{X: p.X - prev.X, Y: p.Y - prev.Y}
+		if d2math.AbsInt(dir.X) > 1 || d2math.AbsInt(dir.Y) > 1 {
+			t.Fatalf("path jumps from %v to %v", prev, p)
+		}
+
+		if !canStep(prev, dir, g.walkable) {
+			t.Fatalf("path cuts a corner from %v to %v", prev, p)
+		}
+
+		prev = p
+	}
+}
+
+func TestFindPath_Straight(t *testing.T) {
+	g := newTestGrid(
+		"S....D",
+	)
+
+	start, dest := g.find('S'), g.find('D')
+	path := FindPath(start, dest, g.walkable, 0)
+
+	if len(path) != 5 {
+		t.Fatalf("expected a path of 5 cells, got %v", path)
+	}
+
+	if path[len(path)-1] != dest {
+		t.Errorf("path should end at %v, got %v", dest, path[len(path)-1])
+	}
+
+	checkWalkable(t, g, start, path)
+}
+
+func TestFindPath_AroundWall(t *testing.T) {
+	g := newTestGrid(
+		"......",
+		"..#...",
+		"S.#..D",
+		"..#...",
+		"..#...",
+	)
+
+	start, dest := g.find('S'), g.find('D')
+	path := FindPath(start, dest, g.walkable, 0)
+
+	if len(path) == 0 || path[len(path)-1] != dest {
+		t.Fatalf("expected a path to %v, got %v", dest, path)
+	}
+
+	checkWalkable(t, g, start, path)
+
+	// two diagonal steps over the top of the wall and three straight steps
+	if len(path) != 6 {
+		t.Errorf("expected the shortest path of 6 cells, got %d: %v", len(path), path)
+	}
+}
+
+func TestFindPath_NoCornerCutting(t *testing.T) {
+	g := newTestGrid(
+		"S#",
+		"#D",
+	)
+
+	path := FindPath(g.find('S'), g.find('D'), g.walkable, 0)
+
+	if path != nil {
+		t.Errorf("expected no path through the corner, got %v", path)
+	}
+}
+
+func TestFindPath_Unreachable(t *testing.T) {
+	g := newTestGrid(
+		"S...#...",
+		"....#.D.",
+		"....#...",
+	)
+
+	start, dest := g.find('S'), g.find('D')
+	path := FindPath(start, dest, g.walkable, 0)
+
+	if len(path) == 0 {
+		t.Fatal("expected a path towards the destination")
+	}
+
+	checkWalkable(t, g, start, path)
+
+	want := d2geom.Point{X: 3, Y: 1}
+	if last := path[len(path)-1]; last != want {
+		t.Errorf("expected the path to stop at the closest cell %v, got %v", want, last)
+	}
+}
+
+func TestFindPath_Budget(t *testing.T) {
+	const size = 200
+
+	walkable := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < size && y < size && !(x == size/2 && y > 0)
+	}
+
+	start, dest := d2geom.Point{X: 0, Y: size - 1}, d2geom.Point{X: size - 1, Y: size - 1}
+
+	full := FindPath(start, dest, walkable, size*size)
+	if len(full) == 0 || full[len(full)-1] != dest {
+		t.Fatalf("expected an unbounded search to reach %v", dest)
+	}
+
+	partial := FindPath(start, dest, walkable, 100)
+	if len(partial) == 0 {
+		t.Fatal("expected a partial path when running out of budget")
+	}
+
+	if partial[len(partial)-1] == dest {
+		t.Error("expected the budget to stop the search before reaching the destination")
+	}
+}
+
+func TestLineOfSight(t *testing.T) {
+	g := newTestGrid(
+		".....",
+		".#...",
+		".....",
+		"...#.",
+	)
+
+	tests := []struct {
+		from, to d2geom.Point
+		want     bool
+	}{
+		{d2geom.Point{X: 0, Y: 0}, d2geom.Point{X: 4, Y: 0}, true},
+		{d2geom.Point{X: 0, Y: 0}, d2geom.Point{X: 2, Y: 2}, false},
+		{d2geom.Point{X: 0, Y: 2}, d2geom.Point{X: 4, Y: 2}, true},
+		{d2geom.Point{X: 2, Y: 0}, d2geom.Point{X: 2, Y: 3}, true},
+		{d2geom.Point{X: 2, Y: 2}, d2geom.Point{X: 4, Y: 4}, false},
+		{d2geom.Point{X: 0, Y: 3}, d2geom.Point{X: 1, Y: 0}, false},
+	}
+
+	for _, test := range tests {
+		if got := LineOfSight(test.from, test.to, g.walkable); got != test.want {
+			t.Errorf("line of sight from %v to %v: want %t, got %t", test.from, test.to, test.want, got)
+		}
+	}
+}
+
+func TestSmooth(t *testing.T) {
+	g := newTestGrid(
+		"......",
+		"..#...",
+		"S.#..D",
+		"..#...",
+		"..#...",
+	)
+
+	start, dest := g.find('S'), g.find('D')
+	path := FindPath(start, dest, g.walkable, 0)
+	smooth := Smooth(start, path, g.walkable)
+
+	if len(smooth) >= len(path) {
+		t.Errorf("expected smoothing to remove cells, got %v from %v", smooth, path)
+	}
+
+	if smooth[len(smooth)-1] != dest {
+		t.Errorf("smoothed path should end at %v, got %v", dest, smooth)
+	}
+
+	prev := start
+	for _, p := range smooth {
+		if !LineOfSight(prev, p, g.walkable) {
+			t.Errorf("no line of sight between smoothed nodes %v and %v", prev, p)
+		}
+
+		prev = p
+	}
+}
+
+func BenchmarkFindPath(b *testing.B) {
+	const size = 500
+
+	walkable := func(x, y int) bool {
+		return x >= 0 && y >= 0 && x < size && y < size && (x%50 != 25 || y%100 == 0)
+	}
+
+	start, dest := d2geom.Point{X: 0, Y: 0}, d2geom.Point{X: size - 1, Y: 60}
+
+	for n := 0; n < b.N; n++ {
+		FindPath(start, dest, walkable, DefaultSearchBudget)
+	}
+}
@@ -0,0 +1,42 @@
+package d2util
+
+import "fmt"
+
+// ColorToken is a string which is used inside of label strings to set font color.
+type ColorToken string
+
+const colorTokenFmt = `%s%s`
+
+// Color tokens for colored labels
+const (
+	ColorTokenGrey   ColorToken = "[grey]"
+	ColorTokenRed    ColorToken = "[red]"
+	ColorTokenWhite  ColorToken = "[white]"
+	ColorTokenBlue   ColorToken = "[blue]"
+	ColorTokenYellow ColorToken = "[yellow]"
+	ColorTokenGreen  ColorToken = "[green]"
+	ColorTokenGold   ColorToken = "[gold]"
+	ColorTokenOrange ColorToken = "[orange]"
+	ColorTokenBlack  ColorToken = "[black]"
+)
+
+// Color tokens for specific use-cases
+const (
+	ColorTokenSocketedItem  = ColorTokenGrey
+	ColorTokenNormalItem    = ColorTokenWhite
+	ColorTokenMagicItem     = ColorTokenBlue
+	ColorTokenRareItem      = ColorTokenYellow
+	ColorTokenSetItem       = ColorTokenGreen
+	ColorTokenUniqueItem    = ColorTokenGold
+	ColorTokenCraftedItem   = ColorTokenOrange
+	ColorTokenServer        = ColorTokenRed
+	ColorTokenButton        = ColorTokenBlack
+	ColorTokenCharacterName = ColorTokenGold
+	ColorTokenCharacterDesc = ColorTokenWhite
+	ColorTokenCharacterType = ColorTokenGreen
+)
+
+// ColorTokenize formats the string with the given color token
+func ColorTokenize(s string, t ColorToken) string {
+	return fmt.Sprintf(colorTokenFmt, t, s)
+}
@@ -0,0 +1,85 @@
+package d2asset
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+)
+
+const (
+	fmtLoadAnimData = "loading animation data from: %s"
+)
+
+// recordPaths are the data dictionaries that are loaded into the record manager
+//nolint:gochecknoglobals // the list is only read
+var recordPaths = []string{
+	d2resource.LevelType, d2resource.LevelPreset, d2resource.LevelWarp,
+	d2resource.ObjectType, d2resource.ObjectDetails, d2resource.Weapons,
+	d2resource.Armor, d2resource.Misc, d2resource.Books, d2resource.ItemTypes,
+	d2resource.UniqueItems, d2resource.Missiles, d2resource.SoundSettings,
+	d2resource.MonStats, d2resource.MonStats2, d2resource.MonPreset,
+	d2resource.MonProp, d2resource.MonType, d2resource.MonMode,
+	d2resource.MagicPrefix, d2resource.MagicSuffix, d2resource.ItemStatCost,
+	d2resource.ItemRatio, d2resource.StorePage, d2resource.Overlays,
+	d2resource.CharStats, d2resource.Hireling, d2resource.Experience,
+	d2resource.Gems, d2resource.QualityItems, d2resource.Runes,
+	d2resource.DifficultyLevels, d2resource.AutoMap, d2resource.LevelDetails,
+	d2resource.LevelMaze, d2resource.LevelSubstitutions, d2resource.CubeRecipes,
+	d2resource.SuperUniques, d2resource.Inventory, d2resource.Skills,
+	d2resource.SkillCalc, d2resource.MissileCalc, d2resource.Properties,
+	d2resource.SkillDesc, d2resource.BodyLocations, d2resource.Sets,
+	d2resource.SetItems, d2resource.AutoMagic, d2resource.TreasureClass,
+	d2resource.TreasureClassEx, d2resource.States, d2resource.SoundEnvirons,
+	d2resource.Shrines, d2resource.ElemType, d2resource.PlrMode,
+	d2resource.PetType, d2resource.NPC, d2resource.MonsterUniqueModifier,
+	d2resource.MonsterEquipment, d2resource.UniqueAppellation, d2resource.MonsterLevel,
+	d2resource.MonsterSound, d2resource.MonsterSequence, d2resource.PlayerClass,
+	d2resource.MonsterPlacement, d2resource.ObjectGroup, d2resource.CompCode,
+	d2resource.MonsterAI, d2resource.RarePrefix, d2resource.RareSuffix,
+	d2resource.Events, d2resource.Colors, d2resource.ArmorType,
+	d2resource.WeaponClass, d2resource.PlayerType, d2resource.Composite,
+	d2resource.HitClass, d2resource.UniquePrefix, d2resource.UniqueSuffix,
+	d2resource.CubeModifier, d2resource.CubeType, d2resource.HirelingDescription,
+	d2resource.LowQualityItems,
+}
+
+// stringTablePaths are the string tables, in the order they are searched for translations
+//nolint:gochecknoglobals // the list is only read
+var stringTablePaths = []string{
+	d2resource.PatchStringTable,
+	d2resource.ExpansionStringTable,
+	d2resource.StringTable,
+}
+
+// LoadRecordTables loads all data dictionaries into the record manager, as well as the animation data.
+// The sources with the game data must have been added first.
+func (am *AssetManager) LoadRecordTables() error {
+	for _, path := range recordPaths {
+		if err := am.LoadRecords(path); err != nil {
+			return err
+		}
+	}
+
+	animDataBytes, err := am.LoadFile(d2resource.AnimationData)
+	if err != nil {
+		return err
+	}
+
+	am.Debugf(fmtLoadAnimData, d2resource.AnimationData)
+
+	am.Records.Animation.Data = d2data.LoadAnimationData(animDataBytes)
+
+	am.Infof("Loaded %d animation data records", len(am.Records.Animation.Data))
+
+	return nil
+}
+
+// LoadStringTables loads the string tables that are used to translate strings
+func (am *AssetManager) LoadStringTables() error {
+	for _, path := range stringTablePaths {
+		if _, err := am.LoadStringTable(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package ebiten
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+const (
+	outputChannels = 2
+	bytesPerSample = 2
+	bytesPerFrame  = outputChannels * bytesPerSample
+)
+
+var errPCMFormat = errors.New("unsupported PCM stream format")
+
+var _ d2interface.PCMStream = &PCMStream{} // Static check to confirm struct conforms to interface
+
+// pcmBuffer holds the samples that are not played yet, converted to the format of the audio context
+type pcmBuffer struct {
+	mutex    sync.Mutex
+	data     []byte
+	channels int
+	step     float64 // the source frames per output frame
+	phase    float64 // the position in the source frames of the next output frame
+	written  int64   // output bytes of real samples
+}
+
+// Read reads the queued samples, or silence when there are none, so the player does not stop
+func (b *pcmBuffer) Read(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	n := copy(p, b.data)
+	b.data = b.data[n:]
+
+	if n == 0 {
+		n = len(p) - len(p)%bytesPerFrame
+
+		for i := 0; i < n; i++ {
+			p[i] = 0
+		}
+	}
+
+	return n, nil
+}
+
+// write resamples the frames to the rate of the audio context, taking the nearest source frame
+func (b *pcmBuffer) write(samples []int16) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	frames := len(samples) / b.channels
+
+	for ; b.phase < float64(frames); b.phase += b.step {
+		frame := samples[int(b.phase)*b.channels:]
+		left, right := frame[0], frame[0]
+
+		if b.channels > 1 {
+			right = frame[1]
+		}
+
+		b.data = append(b.data, byte(left), byte(left>>bitsPerByte), byte(right), byte(right>>bitsPerByte))
+		b.written += bytesPerFrame
+	}
+
+	b.phase -= float64(frames)
+}
+
+// PCMStream is ebiten's implementation of a stream of samples
+type PCMStream struct {
+	player *audio.Player
+	buffer *pcmBuffer
+}
+
+// CreatePCMStream creates a stream of samples with the given rate and number of channels
+func (eap *AudioProvider) CreatePCMStream(rate, channels int) (d2interface.PCMStream, error) {
+	if rate <= 0 || channels < 1 || channels > outputChannels {
+		return nil, errPCMFormat
+	}
+
+	buffer := &pcmBuffer{
+		channels: channels,
+		step:     float64(rate) / sampleRate,
+	}
+
+	player, err := audio.NewPlayer(eap.audioContext, buffer)
+	if err != nil {
+		return nil, err
+	}
+
+	player.SetVolume(eap.bgmVolume)
+
+	return &PCMStream{player: player, buffer: buffer}, nil
+}
+
+// Write queues interleaved samples
+func (s *PCMStream) Write(samples []int16) {
+	s.buffer.write(samples)
+}
+
+// Play starts or resumes playing
+func (s *PCMStream) Play() {
+	s.player.Play()
+}
+
+// Stop pauses playing
+func (s *PCMStream) Stop() {
+	s.player.Pause()
+}
+
+// Close stops playing and releases the player
+func (s *PCMStream) Close() error {
+	return s.player.Close()
+}
+
+// Position returns how much of the written audio has been played, the silence that is played when the
+// stream runs out of samples is not counted
+func (s *PCMStream) Position() time.Duration {
+	s.buffer.mutex.Lock()
+	written := time.Duration(s.buffer.written) * time.Second / (sampleRate * bytesPerFrame)
+	s.buffer.mutex.Unlock()
+
+	if current := s.player.Current(); current < written {
+		return current
+	}
+
+	return written
+}
@@ -0,0 +1,252 @@
+package d2combat
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+// Stats of itemstatcost.txt used in combat
+const (
+	StatAttackRating        = "tohit"
+	StatAttackRatingPercent = "item_tohit_percent"
+	StatDefense             = "armorclass"
+	StatDefensePercent      = "item_armor_percent"
+	StatMinDamage           = "mindamage"
+	StatMaxDamage           = "maxdamage"
+	StatDamagePercent       = "item_maxdamage_percent"
+	StatDamageResist        = "damageresist"
+	StatDamageReduction     = "normal_damage_reduction"
+	StatMagicReduction      = "magic_damage_reduction"
+	StatFireMinDamage       = "firemindam"
+	StatFireMaxDamage       = "firemaxdam"
+	StatFireResist          = "fireresist"
+	StatLightMinDamage      = "lightmindam"
+	StatLightMaxDamage      = "lightmaxdam"
+	StatLightResist         = "lightresist"
+	StatColdMinDamage       = "coldmindam"
+	StatColdMaxDamage       = "coldmaxdam"
+	StatColdResist          = "coldresist"
+	StatPoisonMinDamage     = "poisonmindam"
+	StatPoisonMaxDamage     = "poisonmaxdam"
+	StatPoisonResist        = "poisonresist"
+	StatMagicMinDamage      = "magicmindam"
+	StatMagicMaxDamage      = "magicmaxdam"
+	StatMagicResist         = "magicresist"
+)
+
+const (
+	// HeroMaxResist is the highest resistance a hero can have
+	HeroMaxResist = 75
+
+	// MonsterMaxResist is the highest resistance a monster can have, monsters with it are immune
+	MonsterMaxResist = 100
+)
+
+// Combatant is an entity that attacks or is attacked
+type Combatant struct {
+	ID        string
+	Level     int
+	Life      int
+	MaxLife   int
+	MaxResist int // resistances above this are not taken into account
+	Stats     d2stats.StatList
+}
+
+// NewCombatant creates a combatant with the given stats, at full life
+func NewCombatant(id string, level, life int, stats d2stats.StatList) *Combatant {
+	return &Combatant{
+		ID:        id,
+		Level:     level,
+		Life:      life,
+		MaxLife:   life,
+		MaxResist: MonsterMaxResist,
+		Stats:     stats,
+	}
+}
+
+// IsDead returns true when the combatant has no life left
+func (c *Combatant) IsDead() bool {
+	return c.Life <= 0
+}
+
+// Stat returns the sum of the first values of the stats with the given name
+func (c *Combatant) Stat(name string) int {
+	return statValue(c.Stats, name)
+}
+
+func statValue(list d2stats.StatList, name string) int {
+	if list == nil {
+		return 0
+	}
+
+	total := 0
+
+	for _, stat := range list.Stats() {
+		if stat == nil || stat.Name() != name || len(stat.Values()) == 0 {
+			continue
+		}
+
+		total += stat.Values()[0].Int()
+	}
+
+	return total
+}
+
+// NewMonster creates a combatant for a monster of the given type. The elemental damage of all the elements
+// of the monster is added to its attacks.
+func NewMonster(factory *diablo2stats.StatFactory, id string, record *d2records.MonStatsRecord,
+	difficulty d2enum.DifficultyType, life int) *Combatant {
+	m := monsterValues(record, difficulty)
+
+	values := map[string]int{
+		StatAttackRating: m.attackRating,
+		StatDefense:      m.defense,
+		StatMinDamage:    m.minDamage,
+		StatMaxDamage:    m.maxDamage,
+		StatDamageResist: m.resist[DamagePhysical],
+		StatFireResist:   m.resist[DamageFire],
+		StatLightResist:  m.resist[DamageLightning],
+		StatColdResist:   m.resist[DamageCold],
+		StatPoisonResist: m.resist[DamagePoison],
+		StatMagicResist:  m.resist[DamageMagic],
+	}
+
+	for _, element := range m.elements {
+		damageType, found := elementTypes[element.code]
+		if !found {
+			continue
+		}
+
+		values[damageTypes[damageType].minStat] += element.min
+		values[damageTypes[damageType].maxStat] += element.max
+	}
+
+	return NewCombatant(id, m.level, life, NewStatList(factory, values))
+}
+
+// NewStatList creates a stat list with a stat for each of the non zero values, stats that are not in
+// itemstatcost.txt are left out
+func NewStatList(factory *diablo2stats.StatFactory, values map[string]int) d2stats.StatList {
+	list := factory.NewStatList()
+
+	for _, name := range statNames {
+		value := values[name]
+		if value == 0 {
+			continue
+		}
+
+		if stat := factory.NewStat(name, float64(value)); stat != nil {
+			list.Push(stat)
+		}
+	}
+
+	return list
+}
+
+//nolint:gochecknoglobals // the stats in a fixed order, so that stat lists are built the same every time
+var statNames = []string{
+	StatAttackRating, StatAttackRatingPercent, StatDefense, StatDefensePercent,
+	StatMinDamage, StatMaxDamage, StatDamagePercent, StatDamageResist, StatDamageReduction, StatMagicReduction,
+	StatFireMinDamage, StatFireMaxDamage, StatFireResist,
+	StatLightMinDamage, StatLightMaxDamage, StatLightResist,
+	StatColdMinDamage, StatColdMaxDamage, StatColdResist,
+	StatPoisonMinDamage, StatPoisonMaxDamage, StatPoisonResist,
+	StatMagicMinDamage, StatMagicMaxDamage, StatMagicResist,
+}
+
+//nolint:gochecknoglobals // lookup table of the element codes of monstats.txt
+var elementTypes = map[string]DamageType{
+	"fire": DamageFire,
+	"ltng": DamageLightning,
+	"cold": DamageCold,
+	"pois": DamagePoison,
+	"mag":  DamageMagic,
+}
+
+type monsterElement struct {
+	code     string
+	min, max int
+}
+
+type monsterCombatValues struct {
+	level, attackRating, defense, minDamage, maxDamage int
+	resist                                             [NumDamageTypes]int
+	elements                                           []monsterElement
+}
+
+func monsterValues(r *d2records.MonStatsRecord, difficulty d2enum.DifficultyType) *monsterCombatValues {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return &monsterCombatValues{
+			level: r.LevelNightmare, attackRating: r.AttackRatingA1Nightmare, defense: r.ArmorClassNightmare,
+			minDamage: r.DamageMinA1Nightmare, maxDamage: r.DamageMaxA1Nightmare,
+			resist: [NumDamageTypes]int{
+				r.ResistancePhysicalNightmare, r.ResistanceFireNightmare, r.ResistanceLightningNightmare,
+				r.ResistanceColdNightmare, r.ResistancePoisonNightmare, r.ResistanceMagicNightmare,
+			},
+			elements: []monsterElement{
+				{r.ElementType1, r.ElementDamageMin1Nightmare, r.ElementDamageMax1Nightmare},
+				{r.ElementType2, r.ElementDamageMin2Nightmare, r.ElementDamageMax2Nightmare},
+				{r.ElementType3, r.ElementDamageMin3Nightmare, r.ElementDamageMax3Nightmare},
+			},
+		}
+	case d2enum.DifficultyHell:
+		return &monsterCombatValues{
+			level: r.LevelHell, attackRating: r.AttackRatingA1Hell, defense: r.ArmorClassHell,
+			minDamage: r.DamageMinA1Hell, maxDamage: r.DamageMaxA1Hell,
+			resist: [NumDamageTypes]int{
+				r.ResistancePhysicalHell, r.ResistanceFireHell, r.ResistanceLightningHell,
+				r.ResistanceColdHell, r.ResistancePoisonHell, r.ResistanceMagicHell,
+			},
+			elements: []monsterElement{
+				{r.ElementType1, r.ElementDamageMin1Hell, r.ElementDamageMax1Hell},
+				{r.ElementType2, r.ElementDamageMin2Hell, r.ElementDamageMax2Hell},
+				{r.ElementType3, r.ElementDamageMin3Hell, r.ElementDamageMax3Hell},
+			},
+		}
+	}
+
+	return &monsterCombatValues{
+		level: r.LevelNormal, attackRating: r.AttackRatingA1Normal, defense: r.ArmorClassNormal,
+		minDamage: r.DamageMinA1Normal, maxDamage: r.DamageMaxA1Normal,
+		resist: [NumDamageTypes]int{
+			r.ResistancePhysicalNormal, r.ResistanceFireNormal, r.ResistanceLightningNormal,
+			r.ResistanceColdNormal, r.ResistancePoisonNormal, r.ResistanceMagicNormal,
+		},
+		elements: []monsterElement{
+			{r.ElementType1, r.ElementDamageMin1Normal, r.ElementDamageMax1Normal},
+			{r.ElementType2, r.ElementDamageMin2Normal, r.ElementDamageMax2Normal},
+			{r.ElementType3, r.ElementDamageMin3Normal, r.ElementDamageMax3Normal},
+		},
+	}
+}
+
+// TreasureClass returns the name of the treasure class a monster drops for the given difficulty
+func TreasureClass(record *d2records.MonStatsRecord, difficulty d2enum.DifficultyType) string {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return record.TreasureClassNightmare
+	case d2enum.DifficultyHell:
+		return record.TreasureClassHell
+	}
+
+	return record.TreasureClassNormal
+}
+
+// SkillStats returns the attack rating and damage that the given skill adds to an attack, at skill level 1
+func SkillStats(factory *diablo2stats.StatFactory, skill *d2records.SkillRecord) d2stats.StatList {
+	values := map[string]int{
+		StatAttackRating: skill.ToHit,
+		StatMinDamage:    skill.MinDam,
+		StatMaxDamage:    skill.MaxDam,
+	}
+
+	if damageType, found := elementTypes[skill.EType]; found {
+		values[damageTypes[damageType].minStat] += skill.EMin
+		values[damageTypes[damageType].maxStat] += skill.EMax
+	}
+
+	return NewStatList(factory, values)
+}
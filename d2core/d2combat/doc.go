@@ -0,0 +1,2 @@
+// Package d2combat resolves attacks between players and monsters: hit chance, damage and death
+package d2combat
@@ -0,0 +1,194 @@
+package d2combat
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+)
+
+// DamageType is a kind of damage
+type DamageType int
+
+// Damage types
+const (
+	DamagePhysical DamageType = iota
+	DamageFire
+	DamageLightning
+	DamageCold
+	DamagePoison
+	DamageMagic
+
+	NumDamageTypes
+)
+
+type damageStats struct {
+	minStat, maxStat, resistStat string
+}
+
+//nolint:gochecknoglobals // the stats of each damage type
+var damageTypes = [NumDamageTypes]damageStats{
+	DamagePhysical:  {StatMinDamage, StatMaxDamage, StatDamageResist},
+	DamageFire:      {StatFireMinDamage, StatFireMaxDamage, StatFireResist},
+	DamageLightning: {StatLightMinDamage, StatLightMaxDamage, StatLightResist},
+	DamageCold:      {StatColdMinDamage, StatColdMaxDamage, StatColdResist},
+	DamagePoison:    {StatPoisonMinDamage, StatPoisonMaxDamage, StatPoisonResist},
+	DamageMagic:     {StatMagicMinDamage, StatMagicMaxDamage, StatMagicResist},
+}
+
+const (
+	minHitChance = 5
+	maxHitChance = 95
+	percent      = 100
+)
+
+// Attack is an attack of one combatant on another
+type Attack struct {
+	Attacker *Combatant
+	Defender *Combatant
+	Skill    d2stats.StatList          // stats the skill used adds to the attacker, can be nil
+	HitClass *d2records.HitClassRecord // the hit effect of the attack, can be nil
+}
+
+// Result is the outcome of an attack
+type Result struct {
+	AttackerID string
+	DefenderID string
+	Hit        bool
+	Damage     [NumDamageTypes]int
+	Total      int    // the total damage dealt
+	Life       int    // the life the defender has left
+	HitClass   string // the token of the hit class, which selects the hit effect that clients show
+	Killed     bool
+}
+
+// Resolver resolves attacks. All random rolls are taken from its own random source, so the same seed
+// gives the same results for the same attacks.
+type Resolver struct {
+	rng *rand.Rand
+}
+
+// NewResolver creates an attack resolver with the given seed
+func NewResolver(seed int64) *Resolver {
+	return &Resolver{
+		rng: rand.New(rand.NewSource(seed)), //nolint:gosec // reproducible by design
+	}
+}
+
+// HitChance returns the chance in percent that the attacker hits the defender
+func HitChance(attackRating, defense, attackerLevel, defenderLevel int) int {
+	if attackRating+defense <= 0 || attackerLevel+defenderLevel <= 0 {
+		return maxHitChance
+	}
+
+	// 200% * AR / (AR + DR) * Alvl / (Alvl + Dlvl)
+	chance := 2 * percent * attackRating * attackerLevel / ((attackRating + defense) * (attackerLevel + defenderLevel))
+
+	switch {
+	case chance < minHitChance:
+		return minHitChance
+	case chance > maxHitChance:
+		return maxHitChance
+	}
+
+	return chance
+}
+
+// Resolve rolls whether the attack hits and how much damage it deals, and takes the damage from the life
+// of the defender. Poison damage is dealt at once, there is no damage over time yet.
+func (r *Resolver) Resolve(attack *Attack) *Result {
+	attacker, defender := attack.Attacker, attack.Defender
+
+	result := &Result{
+		AttackerID: attacker.ID,
+		DefenderID: defender.ID,
+		Life:       defender.Life,
+	}
+
+	if attack.HitClass != nil {
+		result.HitClass = attack.HitClass.Token
+	}
+
+	if defender.IsDead() {
+		return result
+	}
+
+	attackerStat := func(name string) int {
+		return attacker.Stat(name) + statValue(attack.Skill, name)
+	}
+
+	attackRating := applyPercent(attackerStat(StatAttackRating), attackerStat(StatAttackRatingPercent))
+	defense := applyPercent(defender.Stat(StatDefense), defender.Stat(StatDefensePercent))
+	chance := HitChance(attackRating, defense, attacker.Level, defender.Level)
+
+	if r.rng.Intn(percent) >= chance {
+		return result
+	}
+
+	result.Hit = true
+
+	for damageType := range damageTypes {
+		stats := &damageTypes[damageType]
+		damage := r.roll(attackerStat(stats.minStat), attackerStat(stats.maxStat))
+
+		if DamageType(damageType) == DamagePhysical {
+			damage = applyPercent(damage, attackerStat(StatDamagePercent))
+		}
+
+		resist := defender.Stat(stats.resistStat)
+		if resist > defender.MaxResist {
+			resist = defender.MaxResist
+		}
+
+		damage = damage * (percent - resist) / percent
+
+		switch DamageType(damageType) {
+		case DamagePhysical:
+			damage -= defender.Stat(StatDamageReduction)
+		case DamageMagic:
+			damage -= defender.Stat(StatMagicReduction)
+		}
+
+		if damage < 0 {
+			damage = 0
+		}
+
+		result.Damage[damageType] = damage
+		result.Total += damage
+	}
+
+	defender.Life -= result.Total
+	if defender.Life <= 0 {
+		defender.Life = 0
+		result.Killed = true
+	}
+
+	result.Life = defender.Life
+
+	return result
+}
+
+// roll returns a random number from min to max
+func (r *Resolver) roll(min, max int) int {
+	if max <= min {
+		return min
+	}
+
+	return min + r.rng.Intn(max-min+1)
+}
+
+func applyPercent(value, bonus int) int {
+	return value * (percent + bonus) / percent
+}
+
+// FindHitClass returns the hit class with the given token, like the HitClass column of weapons.txt, or nil
+func FindHitClass(hitClasses d2records.HitClasses, token string) *d2records.HitClassRecord {
+	for _, record := range hitClasses {
+		if strings.EqualFold(record.Token, token) {
+			return record
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package d2combat
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+func testStatFactory(t *testing.T) *diablo2stats.StatFactory {
+	asset := &d2asset.AssetManager{Records: &d2records.RecordManager{}}
+	asset.Records.Item.Stats = make(map[string]*d2records.ItemStatCostRecord)
+
+	for _, name := range statNames {
+		asset.Records.Item.Stats[name] = &d2records.ItemStatCostRecord{Name: name, DescFnID: 1}
+	}
+
+	factory, err := diablo2stats.NewStatFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return factory
+}
+
+func TestHitChance(t *testing.T) {
+	tests := []struct {
+		attackRating, defense, attackerLevel, defenderLevel int
+		expected                                            int
+	}{
+		{100, 100, 10, 10, 50},
+		{300, 100, 10, 10, 75},
+		{100, 0, 1, 1, maxHitChance},
+		{1, 1000, 1, 50, minHitChance},
+		{0, 0, 0, 0, maxHitChance},
+	}
+
+	for _, test := range tests {
+		got := HitChance(test.attackRating, test.defense, test.attackerLevel, test.defenderLevel)
+		if got != test.expected {
+			t.Errorf("HitChance(%d, %d, %d, %d): expected %d, got %d", test.attackRating, test.defense,
+				test.attackerLevel, test.defenderLevel, test.expected, got)
+		}
+	}
+}
+
+func TestResolver_Damage(t *testing.T) {
+	factory := testStatFactory(t)
+
+	attacker := NewCombatant("attacker", 10, 100, NewStatList(factory, map[string]int{
+		StatAttackRating:   100000,
+		StatMinDamage:      10,
+		StatMaxDamage:      10,
+		StatDamagePercent:  50,
+		StatFireMinDamage:  20,
+		StatFireMaxDamage:  20,
+		StatColdMinDamage:  8,
+		StatColdMaxDamage:  8,
+		StatMagicMinDamage: 4,
+		StatMagicMaxDamage: 4,
+	}))
+
+	defender := NewCombatant("defender", 1, 1000, NewStatList(factory, map[string]int{
+		StatDamageResist:    20,
+		StatDamageReduction: 2,
+		StatFireResist:      50,
+		StatColdResist:      200,
+		StatMagicReduction:  10,
+	}))
+
+	result := NewResolver(1).Resolve(&Attack{Attacker: attacker, Defender: defender})
+
+	if !result.Hit {
+		t.Fatal("expected a hit")
+	}
+
+	expected := [NumDamageTypes]int{
+		DamagePhysical: 10, // 10 +50% -20% -2
+		DamageFire:     10,
+		DamageCold:     0, // immune
+		DamageMagic:    0, // reduced to nothing
+	}
+
+	if result.Damage != expected {
+		t.Errorf("expected damage %v, got %v", expected, result.Damage)
+	}
+
+	if result.Total != 20 || defender.Life != 980 || result.Life != 980 {
+		t.Errorf("expected 20 damage leaving 980 life, got %d damage and %d life", result.Total, defender.Life)
+	}
+}
+
+func TestResolver_HeroResistCap(t *testing.T) {
+	factory := testStatFactory(t)
+
+	attacker := NewCombatant("attacker", 10, 100, NewStatList(factory, map[string]int{
+		StatAttackRating:  100000,
+		StatFireMinDamage: 100,
+		StatFireMaxDamage: 100,
+	}))
+
+	defender := NewCombatant("hero", 1, 1000, NewStatList(factory, map[string]int{StatFireResist: 90}))
+	defender.MaxResist = HeroMaxResist
+
+	result := NewResolver(1).Resolve(&Attack{Attacker: attacker, Defender: defender})
+
+	if result.Damage[DamageFire] != 25 {
+		t.Errorf("expected the resistance to be capped at %d, got %d fire damage", HeroMaxResist,
+			result.Damage[DamageFire])
+	}
+}
+
+func TestResolver_Kill(t *testing.T) {
+	factory := testStatFactory(t)
+
+	attacker := NewCombatant("attacker", 10, 100, NewStatList(factory, map[string]int{
+		StatAttackRating: 100000,
+		StatMinDamage:    5,
+		StatMaxDamage:    5,
+	}))
+	defender := NewCombatant("defender", 1, 12, nil)
+	resolver := NewResolver(7)
+
+	var results []*Result
+
+	for i := 0; i < 100 && !defender.IsDead(); i++ {
+		results = append(results, resolver.Resolve(&Attack{
+			Attacker: attacker,
+			Defender: defender,
+			HitClass: &d2records.HitClassRecord{Name: "Hand To Hand", Token: "HTH"},
+		}))
+	}
+
+	last := results[len(results)-1]
+	if !last.Killed || last.Life != 0 || defender.Life != 0 {
+		t.Fatalf("expected the defender to be killed, got %+v", last)
+	}
+
+	if last.HitClass != "HTH" {
+		t.Errorf("expected hit class HTH, got %q", last.HitClass)
+	}
+
+	if again := resolver.Resolve(&Attack{Attacker: attacker, Defender: defender}); again.Hit || again.Killed {
+		t.Errorf("expected dead defenders not to be hit, got %+v", again)
+	}
+}
+
+func TestResolver_Deterministic(t *testing.T) {
+	factory := testStatFactory(t)
+
+	fight := func(seed int64) []*Result {
+		attacker := NewCombatant("attacker", 5, 100, NewStatList(factory, map[string]int{
+			StatAttackRating:  50,
+			StatMinDamage:     1,
+			StatMaxDamage:     8,
+			StatFireMinDamage: 1,
+			StatFireMaxDamage: 6,
+		}))
+		defender := NewCombatant("defender", 5, 10000, NewStatList(factory, map[string]int{StatDefense: 50}))
+		resolver := NewResolver(seed)
+		results := make([]*Result, 0)
+
+		for i := 0; i < 200; i++ {
+			results = append(results, resolver.Resolve(&Attack{Attacker: attacker, Defender: defender}))
+		}
+
+		return results
+	}
+
+	first := fight(1234)
+	if !reflect.DeepEqual(first, fight(1234)) {
+		t.Error("the same seed gave different results")
+	}
+
+	hits := 0
+
+	for _, result := range first {
+		if result.Hit {
+			hits++
+		}
+	}
+
+	// the hit chance is 50%
+	if hits < 70 || hits > 130 {
+		t.Errorf("expected about 100 hits out of 200, got %d", hits)
+	}
+}
+
+func TestNewMonster(t *testing.T) {
+	factory := testStatFactory(t)
+
+	record := &d2records.MonStatsRecord{
+		LevelNightmare:             40,
+		AttackRatingA1Nightmare:    300,
+		ArmorClassNightmare:        120,
+		DamageMinA1Nightmare:       10,
+		DamageMaxA1Nightmare:       20,
+		ResistanceFireNightmare:    100,
+		ElementType1:               "cold",
+		ElementDamageMin1Nightmare: 3,
+		ElementDamageMax1Nightmare: 6,
+	}
+
+	monster := NewMonster(factory, "monster", record, d2enum.DifficultyNightmare, 50)
+
+	expected := map[string]int{
+		StatAttackRating:  300,
+		StatDefense:       120,
+		StatMinDamage:     10,
+		StatMaxDamage:     20,
+		StatFireResist:    100,
+		StatColdMinDamage: 3,
+		StatColdMaxDamage: 6,
+		StatColdResist:    0,
+	}
+
+	for name, value := range expected {
+		if got := monster.Stat(name); got != value {
+			t.Errorf("%s: expected %d, got %d", name, value, got)
+		}
+	}
+
+	if monster.Level != 40 || monster.Life != 50 || monster.MaxLife != 50 {
+		t.Errorf("unexpected level %d and life %d/%d", monster.Level, monster.Life, monster.MaxLife)
+	}
+}
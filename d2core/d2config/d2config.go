@@ -65,6 +65,11 @@ func (c *Configuration) Path() string {
 	return c.path
 }
 
+// KeyMapPath returns the path of the key bindings file, next to the config file
+func (c *Configuration) KeyMapPath() string {
+	return filepath.Join(c.Dir(), od2KeyMapFileName)
+}
+
 // SetPath sets where the config file is saved to (a full path)
 func (c *Configuration) SetPath(p string) {
 	c.path = p
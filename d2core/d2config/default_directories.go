@@ -11,6 +11,7 @@ const (
 
 const (
 	od2ConfigFileName = "config.json"
+	od2KeyMapFileName = "keymap.json"
 )
 
 // DefaultConfigPath returns the absolute path for the default config file location
@@ -44,3 +44,21 @@ func (hs *HeroSkill) UnmarshalJSON(data []byte) error {
 
 	return nil
 }
+
+// SetSkillPoints sets the points spent on the skill
+func (hs *HeroSkill) SetSkillPoints(points int) {
+	hs.SkillPoints = points
+
+	if hs.shallow != nil {
+		hs.shallow.SkillPoints = points
+	}
+}
+
+// NewShallowHeroSkill creates a HeroSkill which only knows its skill ID and points, as if it was deserialized.
+// The records are loaded with HydrateSkills.
+func NewShallowHeroSkill(skillID, skillPoints int) *HeroSkill {
+	return &HeroSkill{
+		SkillPoints: skillPoints,
+		shallow:     &shallowHeroSkill{SkillID: skillID, SkillPoints: skillPoints},
+	}
+}
@@ -19,4 +19,8 @@ type HeroState struct {
 	Y          float64                        `json:"y"`
 	LeftSkill  int                            `json:"leftSkill"`
 	RightSkill int                            `json:"rightSkill"`
+
+	// Character is the character file the hero was imported from. It keeps what the hero state does not hold,
+	// like the gold, the quests, the waypoints and the items that are not equipped, for the export.
+	Character []byte `json:"character,omitempty"`
 }
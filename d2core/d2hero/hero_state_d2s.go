@@ -0,0 +1,333 @@
+package d2hero
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2s"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// life, mana and stamina are fixed point numbers with 8 fraction bits in character files
+	d2sFractionBits = 8
+
+	d2sExtension = ".d2s"
+)
+
+var errUnknownClass = errors.New("unknown character class")
+
+// d2sRecords provides the item records that are needed to decode the items of character files
+type d2sRecords struct {
+	records *d2records.RecordManager
+	stats   map[int]*d2records.ItemStatCostRecord
+}
+
+func newD2SRecords(records *d2records.RecordManager) *d2sRecords {
+	stats := make(map[int]*d2records.ItemStatCostRecord, len(records.Item.Stats))
+
+	for _, record := range records.Item.Stats {
+		stats[record.Index] = record
+	}
+
+	return &d2sRecords{records: records, stats: stats}
+}
+
+// ItemStat returns how an item stat is saved, from its ItemStatCost record
+func (r *d2sRecords) ItemStat(id int) (d2s.StatLayout, bool) {
+	record, found := r.stats[id]
+	if !found {
+		return d2s.StatLayout{}, false
+	}
+
+	return d2s.StatLayout{Bits: record.SaveBits, Add: record.SaveAdd, ParamBits: record.SaveParamBits}, true
+}
+
+// ItemKind returns whether the base item is an armor, a weapon or another item
+func (r *d2sRecords) ItemKind(code string) d2s.ItemKind {
+	switch {
+	case r.records.Item.Armors[code] != nil:
+		return d2s.ItemKindArmor
+	case r.records.Item.Weapons[code] != nil:
+		return d2s.ItemKindWeapon
+	default:
+		return d2s.ItemKindMisc
+	}
+}
+
+// Stackable returns true when the base item can be stacked
+func (r *d2sRecords) Stackable(code string) bool {
+	record := r.records.Item.All[code]
+
+	return record != nil && record.Stackable
+}
+
+// ImportD2S loads a character file of Diablo II 1.10 to 1.14d. Saving the hero state writes the character file
+// back.
+func (f *HeroStateFactory) ImportD2S(filePath string) (*HeroState, error) {
+	data, err := ioutil.ReadFile(filepath.Clean(filePath))
+	if err != nil {
+		return nil, err
+	}
+
+	character, err := d2s.Load(data, newD2SRecords(f.asset.Records))
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := f.HeroStateFromD2S(character)
+	if err != nil {
+		return nil, err
+	}
+
+	state.FilePath = filePath
+
+	return state, nil
+}
+
+// HeroStateFromD2S converts a character file to a hero state. The hero state holds the stats, the skills and the
+// equipped armor and weapons, the rest of the character file is kept in the hero state for the export.
+func (f *HeroStateFactory) HeroStateFromD2S(character *d2s.D2S) (*HeroState, error) {
+	hero := character.Class.Hero()
+	if hero == d2enum.HeroNone {
+		return nil, fmt.Errorf("%w: %d", errUnknownClass, character.Class)
+	}
+
+	classStats := f.asset.Records.Character.Stats[hero]
+
+	skills, err := f.CreateHeroSkillsState(classStats, hero)
+	if err != nil {
+		return nil, err
+	}
+
+	for n, points := range character.Skills {
+		if skill, found := skills[character.Class.SkillID(n)]; found {
+			skill.SetSkillPoints(int(points))
+		}
+	}
+
+	data, err := character.Marshal(newD2SRecords(f.asset.Records))
+	if err != nil {
+		return nil, err
+	}
+
+	_, act := character.CurrentDifficulty()
+	stats := &character.Stats
+
+	state := &HeroState{
+		HeroName:   character.Name,
+		HeroType:   hero,
+		HeroLevel:  int(character.Level),
+		Act:        act,
+		Equipment:  f.equipmentFromD2S(character.Items),
+		Skills:     skills,
+		LeftSkill:  int(character.LeftSkill),
+		RightSkill: int(character.RightSkill),
+		Character:  data,
+		Stats: &HeroStatsState{
+			Level:        stats.Level,
+			Experience:   stats.Experience,
+			NextLevelExp: f.asset.Records.GetExperienceBreakpoint(hero, stats.Level),
+			Strength:     stats.Strength,
+			Dexterity:    stats.Dexterity,
+			Vitality:     stats.Vitality,
+			Energy:       stats.Energy,
+			StatPoints:   stats.StatPoints,
+			SkillPoints:  stats.SkillPoints,
+			Health:       stats.Life >> d2sFractionBits,
+			MaxHealth:    stats.MaxLife >> d2sFractionBits,
+			Mana:         stats.Mana >> d2sFractionBits,
+			MaxMana:      stats.MaxMana >> d2sFractionBits,
+			MaxStamina:   stats.MaxStamina >> d2sFractionBits,
+			Stamina:      float64(stats.Stamina >> d2sFractionBits),
+		},
+	}
+
+	return state, nil
+}
+
+func (f *HeroStateFactory) equipmentFromD2S(items []*d2s.Item) d2inventory.CharacterEquipment {
+	equipment := d2inventory.CharacterEquipment{}
+
+	for _, item := range items {
+		if item.Location != d2s.LocationEquipped {
+			continue
+		}
+
+		armor := f.asset.Records.Item.Armors[item.Code] != nil
+		weapon := f.asset.Records.Item.Weapons[item.Code] != nil
+
+		switch {
+		case item.Slot == d2s.SlotHead && armor:
+			equipment.Head = f.GetArmorItemByCode(item.Code)
+		case item.Slot == d2s.SlotTorso && armor:
+			equipment.Torso = f.GetArmorItemByCode(item.Code)
+		case item.Slot == d2s.SlotRightHand && weapon:
+			equipment.RightHand = f.GetWeaponItemByCode(item.Code)
+		case item.Slot == d2s.SlotLeftHand && weapon:
+			equipment.LeftHand = f.GetWeaponItemByCode(item.Code)
+		case item.Slot == d2s.SlotLeftHand && armor:
+			equipment.Shield = f.GetArmorItemByCode(item.Code)
+		}
+	}
+
+	return equipment
+}
+
+// ExportD2S saves the hero state as a character file of Diablo II 1.10 to 1.14d
+func (f *HeroStateFactory) ExportD2S(state *HeroState, filePath string) error {
+	character, err := f.D2SFromHeroState(state)
+	if err != nil {
+		return err
+	}
+
+	data, err := character.Marshal(newD2SRecords(f.asset.Records))
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(filePath, data, writefilePermission)
+}
+
+// D2SFromHeroState converts a hero state to a character file. A hero that was imported keeps the rest of its
+// character file, a new hero gets a new character file on the normal difficulty.
+func (f *HeroStateFactory) D2SFromHeroState(state *HeroState) (*d2s.D2S, error) {
+	class, found := d2s.ClassFromHero(state.HeroType)
+	if !found {
+		return nil, fmt.Errorf("%w: %s", errUnknownClass, state.HeroType)
+	}
+
+	character := d2s.New(state.HeroName, class)
+
+	if state.Character != nil {
+		imported, err := d2s.Load(state.Character, newD2SRecords(f.asset.Records))
+		if err != nil {
+			return nil, err
+		}
+
+		character = imported
+		character.Name = state.HeroName
+		character.Class = class
+	}
+
+	character.Level = byte(state.HeroLevel)
+	character.LeftSkill = uint32(state.LeftSkill)
+	character.RightSkill = uint32(state.RightSkill)
+
+	if state.Act > 0 {
+		difficulty, _ := character.CurrentDifficulty()
+		character.SetCurrentDifficulty(difficulty, state.Act)
+	}
+
+	if stats := state.Stats; stats != nil {
+		character.Level = byte(stats.Level)
+		d2sStatsFromHeroStats(&character.Stats, stats)
+	}
+
+	for n := range character.Skills {
+		if skill, found := state.Skills[class.SkillID(n)]; found {
+			character.Skills[n] = byte(skill.SkillPoints)
+		}
+	}
+
+	character.Items = f.equipmentToD2S(&state.Equipment, character.Items)
+
+	return character, nil
+}
+
+// d2sStatsFromHeroStats sets the character stats that the hero stats hold, the gold is kept
+func d2sStatsFromHeroStats(character *d2s.CharacterStats, stats *HeroStatsState) {
+	character.Strength = stats.Strength
+	character.Energy = stats.Energy
+	character.Dexterity = stats.Dexterity
+	character.Vitality = stats.Vitality
+	character.StatPoints = stats.StatPoints
+	character.SkillPoints = stats.SkillPoints
+	character.Life = stats.Health << d2sFractionBits
+	character.MaxLife = stats.MaxHealth << d2sFractionBits
+	character.Mana = stats.Mana << d2sFractionBits
+	character.MaxMana = stats.MaxMana << d2sFractionBits
+	character.Stamina = int(stats.Stamina) << d2sFractionBits
+	character.MaxStamina = stats.MaxStamina << d2sFractionBits
+	character.Level = stats.Level
+	character.Experience = stats.Experience
+}
+
+// equipmentToD2S returns the items of the character with the equipment of the hero state. The items of the
+// character that the hero state does not hold are kept, and so are the equipped items that did not change, with
+// their sockets and affixes.
+func (f *HeroStateFactory) equipmentToD2S(equipment *d2inventory.CharacterEquipment, items []*d2s.Item) []*d2s.Item {
+	result := make([]*d2s.Item, 0, len(items))
+	equippedBefore := make(map[d2s.EquipSlot]*d2s.Item)
+
+	for _, item := range items {
+		if item.Location == d2s.LocationEquipped && heldByHeroState(item.Slot) {
+			equippedBefore[item.Slot] = item
+			continue
+		}
+
+		result = append(result, item)
+	}
+
+	equipped := []struct {
+		slot d2s.EquipSlot
+		code string
+	}{
+		{d2s.SlotHead, equipment.Head.GetItemCode()},
+		{d2s.SlotTorso, equipment.Torso.GetItemCode()},
+		{d2s.SlotRightHand, equipment.RightHand.GetItemCode()},
+		{d2s.SlotLeftHand, equipment.LeftHand.GetItemCode()},
+		{d2s.SlotLeftHand, equipment.Shield.GetItemCode()},
+	}
+
+	for _, item := range equipped {
+		if item.code == "" {
+			continue
+		}
+
+		if before := equippedBefore[item.slot]; before != nil && before.Code == item.code {
+			result = append(result, before)
+			continue
+		}
+
+		result = append(result, f.newD2SItem(item.code, item.slot))
+	}
+
+	return result
+}
+
+// heldByHeroState returns true for the equipment slots that the hero state holds
+func heldByHeroState(slot d2s.EquipSlot) bool {
+	switch slot {
+	case d2s.SlotHead, d2s.SlotTorso, d2s.SlotRightHand, d2s.SlotLeftHand:
+		return true
+	default:
+		return false
+	}
+}
+
+// newD2SItem creates an equipped item with the defense and durability of its base item
+func (f *HeroStateFactory) newD2SItem(code string, slot d2s.EquipSlot) *d2s.Item {
+	item := d2s.NewItem(code)
+	item.Location = d2s.LocationEquipped
+	item.Slot = slot
+
+	if record := f.asset.Records.Item.All[code]; record != nil {
+		item.Defense = record.MinAC
+
+		if !record.NoDurability {
+			item.MaxDurability = record.Durability
+			item.Durability = record.Durability
+		}
+
+		if record.Stackable {
+			item.Quantity = record.MaxStack
+		}
+	}
+
+	return item
+}
@@ -0,0 +1,326 @@
+package d2hero
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2s"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testD2SFactory(t *testing.T) *HeroStateFactory {
+	records := &d2records.RecordManager{}
+
+	records.Character.Stats = d2records.CharStats{d2enum.HeroSorceress: {Class: d2enum.HeroSorceress}}
+	records.Character.Experience = d2records.ExperienceBreakpoints{
+		1:  {Level: 1, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 500}},
+		30: {Level: 30, HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroSorceress: 2000000}},
+	}
+
+	records.Skill.Details = d2records.SkillDetails{
+		0:  {ID: 0, Skill: "Attack", Skilldesc: "attack"},
+		36: {ID: 36, Skill: "Fire Bolt", Charclass: "sor", Skilldesc: "fire bolt"},
+	}
+	records.Skill.Descriptions = d2records.SkillDescriptions{"attack": {}, "fire bolt": {}}
+
+	records.Item.Stats = d2records.ItemStatCosts{
+		"strength":      {Index: 0, SaveBits: 8, SaveAdd: 32},
+		"armorclass":    {Index: 31, SaveBits: 11, SaveAdd: 10},
+		"durability":    {Index: 72, SaveBits: 9},
+		"maxdurability": {Index: 73, SaveBits: 8},
+	}
+	records.Item.Weapons = d2records.CommonItems{}
+	records.Item.Armors = d2records.CommonItems{}
+	records.Item.Misc = d2records.CommonItems{}
+	records.Item.All = d2records.CommonItems{}
+
+	add := func(items d2records.CommonItems, codes ...string) {
+		for _, code := range codes {
+			record := &d2records.ItemCommonRecord{Code: code, Name: code, Durability: 20, MinAC: 3}
+			items[code] = record
+			records.Item.All[code] = record
+		}
+	}
+
+	add(records.Item.Weapons, "hax", "wnd", "ssd", "ktr", "sst", "jav", "clb", "lsd")
+	add(records.Item.Armors, "buc", "cap", "skp")
+	add(records.Item.Misc, "r01", "hp1")
+
+	factory, err := NewHeroStateFactory(&d2asset.AssetManager{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return factory
+}
+
+func testD2SCharacter() *d2s.D2S {
+	character := d2s.New("Tester", d2s.ClassSorceress)
+	character.Level = 30
+	character.Stats = d2s.CharacterStats{
+		Strength: 40, Energy: 90, Dexterity: 30, Vitality: 60, StatPoints: 2, SkillPoints: 1,
+		Life: 200 << 8, MaxLife: 250 << 8, Mana: 150 << 8, MaxMana: 180 << 8, Stamina: 70 << 8, MaxStamina: 120 << 8,
+		Level: 30, Experience: 1500000, Gold: 2500, StashGold: 40000,
+	}
+	character.Skills[0] = 20
+	character.SetCurrentDifficulty(d2s.DifficultyNightmare, 2)
+	character.Progress[d2s.DifficultyNormal].SetQuestCompleted(1, 1, true)
+	character.Progress[d2s.DifficultyNormal].SetWaypoint(38, true)
+
+	rune := &d2s.Item{Simple: true, Location: d2s.LocationSocketed, Code: "r01", Identified: true}
+	character.Items = []*d2s.Item{
+		{
+			Location: d2s.LocationEquipped, Slot: d2s.SlotHead, Code: "cap", Level: 30, Quality: d2enum.Unique,
+			UniqueID: 5, Defense: 10, MaxDurability: 12, Durability: 12, Identified: true,
+			Stats: []d2s.Stat{{ID: 0, Value: 10}},
+		},
+		{
+			Location: d2s.LocationEquipped, Slot: d2s.SlotRightHand, Code: "lsd", Level: 30, Quality: d2enum.Normal,
+			Socketed: true, Sockets: 1, Runeword: true, RunewordID: 27, MaxDurability: 20, Durability: 20,
+			Identified: true, Stats: []d2s.Stat{}, RunewordStats: []d2s.Stat{{ID: 0, Value: 5}},
+			SocketedItems: []*d2s.Item{rune},
+		},
+		{Simple: true, Location: d2s.LocationBelt, X: 1, Code: "hp1", Identified: true},
+		{
+			Storage: d2s.StorageStash, X: 2, Y: 3, Code: "buc", Level: 12, Quality: d2enum.Set, SetID: 3,
+			Defense: 5, MaxDurability: 10, Durability: 10, Identified: true, Stats: []d2s.Stat{},
+		},
+	}
+
+	return character
+}
+
+// writeD2S writes the character file to the directory and returns its path
+func writeD2S(t *testing.T, factory *HeroStateFactory, dir string, character *d2s.D2S) string {
+	data, err := character.Marshal(newD2SRecords(factory.asset.Records))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(dir, character.Name+d2sExtension)
+
+	if err := ioutil.WriteFile(filePath, data, writefilePermission); err != nil {
+		t.Fatal(err)
+	}
+
+	return filePath
+}
+
+// readD2S loads the character file at the path
+func readD2S(t *testing.T, factory *HeroStateFactory, filePath string) *d2s.D2S {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	character, err := d2s.Load(data, newD2SRecords(factory.asset.Records))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return character
+}
+
+// findD2SItem returns the item of the character that equals the given item
+func findD2SItem(character *d2s.D2S, item *d2s.Item) *d2s.Item {
+	for _, found := range character.Items {
+		if reflect.DeepEqual(found, item) {
+			return found
+		}
+	}
+
+	return nil
+}
+
+func TestImportD2S(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	factory := testD2SFactory(t)
+	filePath := writeD2S(t, factory, dir, testD2SCharacter())
+
+	state, err := factory.ImportD2S(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.HeroName != "Tester" || state.HeroType != d2enum.HeroSorceress || state.Act != 2 ||
+		state.FilePath != filePath {
+		t.Fatalf("unexpected hero %s %s in act %d at %s", state.HeroName, state.HeroType, state.Act, state.FilePath)
+	}
+
+	expected := HeroStatsState{
+		Level: 30, Experience: 1500000, NextLevelExp: 2000000, Strength: 40, Dexterity: 30, Vitality: 60, Energy: 90,
+		StatPoints: 2, SkillPoints: 1, Health: 200, MaxHealth: 250, Mana: 150, MaxMana: 180, Stamina: 70,
+		MaxStamina: 120,
+	}
+
+	if *state.Stats != expected {
+		t.Errorf("expected the stats %+v, got %+v", expected, *state.Stats)
+	}
+
+	if skill := state.Skills[36]; skill == nil || skill.SkillPoints != 20 {
+		t.Error("the skill points of Fire Bolt were not imported")
+	}
+
+	if state.Equipment.Head.GetItemCode() != "cap" || state.Equipment.RightHand.GetItemCode() != "lsd" {
+		t.Errorf("the equipment was not imported: %+v", state.Equipment)
+	}
+}
+
+func TestExportD2SKeepsTheCharacter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	factory := testD2SFactory(t)
+	filePath := writeD2S(t, factory, dir, testD2SCharacter())
+	original := readD2S(t, factory, filePath)
+
+	state, err := factory.ImportD2S(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state.Stats.Strength++
+	state.Act = 3
+	state.Equipment.Head = factory.GetArmorItemByCode("skp")
+
+	if err := factory.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := readD2S(t, factory, state.FilePath)
+
+	expectedStats := original.Stats
+	expectedStats.Strength++
+
+	if saved.Stats != expectedStats {
+		t.Errorf("expected the stats %+v, got %+v", expectedStats, saved.Stats)
+	}
+
+	if difficulty, act := saved.CurrentDifficulty(); difficulty != d2s.DifficultyNightmare || act != 3 {
+		t.Errorf("expected act 3 of nightmare, got act %d of %d", act, difficulty)
+	}
+
+	if !reflect.DeepEqual(saved.Progress, original.Progress) || saved.Skills != original.Skills {
+		t.Error("the quests, waypoints or skills were not kept")
+	}
+
+	if len(saved.Items) != len(original.Items) {
+		t.Fatalf("expected %d items, got %d", len(original.Items), len(saved.Items))
+	}
+
+	// the head changed, the runeword, the belt and the stash are kept with their sockets and affixes
+	for _, item := range original.Items[1:] {
+		if findD2SItem(saved, item) == nil {
+			t.Errorf("the %s was not kept", item.Code)
+		}
+	}
+
+	head := factory.newD2SItem("skp", d2s.SlotHead)
+	head.Defense, head.MaxDurability, head.Durability = 3, 20, 20
+
+	if findD2SItem(saved, head) == nil {
+		t.Error("the new head was not saved")
+	}
+}
+
+func TestSaveKeepsTheImportedCharacter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2hero")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	factory := testD2SFactory(t)
+	filePath := writeD2S(t, factory, dir, testD2SCharacter())
+	original := readD2S(t, factory, filePath)
+
+	state, err := factory.ImportD2S(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the hero is saved in the format of OpenDiablo2, and exported again after it was loaded
+	state.FilePath = filepath.Join(dir, "0.od2")
+
+	if err := factory.Save(state); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := factory.LoadHeroState(state.FilePath)
+	if loaded == nil {
+		t.Fatal("the hero was not loaded")
+	}
+
+	// the stamina is not saved, it is reset on entering the world
+	loaded.Stats.Stamina = state.Stats.Stamina
+	exported := filepath.Join(dir, "Exported"+d2sExtension)
+
+	if err := factory.ExportD2S(loaded, exported); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := readD2S(t, factory, exported)
+
+	if saved.Stats != original.Stats || !reflect.DeepEqual(saved.Progress, original.Progress) {
+		t.Errorf("the character was not kept, got the stats %+v", saved.Stats)
+	}
+
+	for _, item := range original.Items {
+		if findD2SItem(saved, item) == nil {
+			t.Errorf("the %s was not kept", item.Code)
+		}
+	}
+}
+
+func TestExportNewHero(t *testing.T) {
+	factory := testD2SFactory(t)
+
+	state, err := factory.CreateHeroState("Newbie", d2enum.HeroSorceress, &HeroStatsState{
+		Level: 1, Strength: 10, Dexterity: 25, Vitality: 10, Energy: 35, Health: 40, MaxHealth: 40, Mana: 35,
+		MaxMana: 35, Stamina: 74, MaxStamina: 74, NextLevelExp: 500,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state.HeroLevel = 1
+	state.Skills[36].SkillPoints = 1
+
+	character, err := factory.D2SFromHeroState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	imported, err := factory.HeroStateFromD2S(character)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *imported.Stats != *state.Stats {
+		t.Errorf("expected the stats %+v, got %+v", *state.Stats, *imported.Stats)
+	}
+
+	if imported.Equipment.RightHand.GetItemCode() != "sst" || imported.Skills[36].SkillPoints != 1 {
+		t.Error("the equipment or skills were not exported")
+	}
+
+	if difficulty, act := character.CurrentDifficulty(); difficulty != d2s.DifficultyNormal || act != 1 {
+		t.Errorf("expected act 1 of normal, got act %d of %d", act, difficulty)
+	}
+}
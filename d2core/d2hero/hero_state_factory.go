@@ -78,6 +78,19 @@ func (f *HeroStateFactory) GetAllHeroStates() ([]*HeroState, error) {
 
 	for _, file := range files {
 		fileName := file.Name()
+		if !file.IsDir() && strings.EqualFold(path.Ext(fileName), d2sExtension) {
+			// characters of Diablo II that were copied to the saves
+			gameState, err := f.ImportD2S(path.Join(basePath, fileName))
+			if err != nil {
+				fmt.Printf("failed to import %s, err: %v\n", fileName, err)
+				continue
+			}
+
+			result = append(result, gameState)
+
+			continue
+		}
+
 		if file.IsDir() || len(fileName) < 5 || !strings.EqualFold(fileName[len(fileName)-4:], ".od2") {
 			continue
 		}
@@ -240,7 +253,8 @@ func (f *HeroStateFactory) getFirstFreeFileName() string {
 	}
 }
 
-// Save saves the player state to a file
+// Save saves the player state to a file, the heroes that were imported from character files are saved as
+// character files
 func (f *HeroStateFactory) Save(state *HeroState) error {
 	if state.FilePath == "" {
 		state.FilePath = f.getFirstFreeFileName()
@@ -250,6 +264,10 @@ func (f *HeroStateFactory) Save(state *HeroState) error {
 		return err
 	}
 
+	if strings.EqualFold(path.Ext(state.FilePath), d2sExtension) {
+		return f.ExportD2S(state, state.FilePath)
+	}
+
 	fileJSON, _ := json.MarshalIndent(state, "", "   ")
 	if err := ioutil.WriteFile(state.FilePath, fileJSON, writefilePermission); err != nil {
 		return err
@@ -15,6 +15,9 @@ type HeroStatsState struct {
 	Strength  int `json:"strength"`
 	Dexterity int `json:"dexterity"`
 
+	StatPoints  int `json:"statPoints"`  // unspent stat points
+	SkillPoints int `json:"skillPoints"` // unspent skill points
+
 	AttackRating  int `json:"attackRating"`
 	DefenseRating int `json:"defenseRating"`
 
@@ -0,0 +1,311 @@
+package d2hero
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// Attribute is one of the attributes that stat points are spent on
+type Attribute byte
+
+// Attributes
+const (
+	AttributeStrength Attribute = iota
+	AttributeDexterity
+	AttributeVitality
+	AttributeEnergy
+)
+
+func (a Attribute) String() string {
+	switch a {
+	case AttributeStrength:
+		return "strength"
+	case AttributeDexterity:
+		return "dexterity"
+	case AttributeVitality:
+		return "vitality"
+	case AttributeEnergy:
+		return "energy"
+	}
+
+	return fmt.Sprintf("attribute %d", a)
+}
+
+const (
+	skillPointsPerLevel = 1
+	maxHardSkillPoints  = 20 // skills can not be raised further with skill points
+
+	// life, mana and stamina gains of the CharStats records are in fourths
+	charStatsFractions = 4
+
+	expRatioDivisor   = 1024 // the ExpRatio of the Experience records is in 1024ths
+	percent           = 100
+	noPenaltyLevels   = 5  // a hero gets the full experience of monsters up to this many levels below
+	partyBonusPercent = 35 // each further party member adds this much experience to a kill
+)
+
+// levelPenalty is the percentage of the experience a hero gets for monsters 6, 7, 8, 9 and 10 or more levels
+// below the hero
+//
+//nolint:gochecknoglobals // the list is only read
+var levelPenalty = []int{81, 62, 43, 24, 5}
+
+var (
+	errNoStatPoints      = errors.New("no stat points to spend")
+	errUnknownAttribute  = errors.New("unknown attribute")
+	errNoSkillPoints     = errors.New("no skill points to spend")
+	errUnknownSkill      = errors.New("the hero does not have the skill")
+	errSkillLevel        = errors.New("the level of the hero is too low for the skill")
+	errSkillPrerequisite = errors.New("a prerequisite skill has not been learned")
+	errSkillMaxed        = errors.New("the skill can not be raised further")
+)
+
+// Progression grants heroes experience for slain monsters, levels them up and spends their stat and skill
+// points, from the Experience, MonLvl, MonStats, CharStats and Skills records
+type Progression struct {
+	records *d2records.RecordManager
+}
+
+// NewProgression creates a Progression from the records
+func NewProgression(records *d2records.RecordManager) *Progression {
+	return &Progression{records: records}
+}
+
+// MonsterExperience returns the level of a monster on the difficulty and the experience it is worth, before
+// the level difference and party are taken into account
+func (p *Progression) MonsterExperience(monStats *d2records.MonStatsRecord,
+	difficulty d2enum.DifficultyType) (level, experience int) {
+	if monStats == nil {
+		return 0, 0
+	}
+
+	level, percentage := monStats.LevelNormal, monStats.ExperienceNormal
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		level, percentage = monStats.LevelNightmare, monStats.ExperienceNightmare
+	case d2enum.DifficultyHell:
+		level, percentage = monStats.LevelHell, monStats.ExperienceHell
+	}
+
+	record := p.records.Monster.Levels[level]
+	if record == nil {
+		return level, 0
+	}
+
+	base := record.Ladder.Normal.Experience
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		base = record.Ladder.Nightmare.Experience
+	case d2enum.DifficultyHell:
+		base = record.Ladder.Hell.Experience
+	}
+
+	return level, base * percentage / percent
+}
+
+// PartyShares splits the experience of a kill among the party members of the given levels. Each member
+// besides the first adds to the experience, which is shared in proportion to the levels of the members.
+func PartyShares(experience int, levels []int) []int {
+	shares := make([]int, len(levels))
+	if len(levels) == 0 {
+		return shares
+	}
+
+	total := experience * (percent + partyBonusPercent*(len(levels)-1)) / percent
+	levelSum := 0
+
+	for _, level := range levels {
+		levelSum += level
+	}
+
+	for i, level := range levels {
+		if levelSum == 0 {
+			shares[i] = total / len(levels)
+			continue
+		}
+
+		shares[i] = total * level / levelSum
+	}
+
+	return shares
+}
+
+// KillExperience returns the experience a hero gets of the experience of a monster of the given level. Heroes
+// far above the monster get a fraction of the experience, as do heroes far below it, and the ExpRatio of the
+// level of the hero reduces the experience on the highest levels.
+func (p *Progression) KillExperience(heroLevel, monsterLevel, experience int) int {
+	switch difference := heroLevel - monsterLevel; {
+	case difference > noPenaltyLevels:
+		index := difference - noPenaltyLevels - 1
+		if index >= len(levelPenalty) {
+			index = len(levelPenalty) - 1
+		}
+
+		experience = experience * levelPenalty[index] / percent
+	case -difference > noPenaltyLevels && monsterLevel > 0:
+		experience = experience * heroLevel / monsterLevel
+	}
+
+	if record := p.records.Character.Experience[heroLevel]; record != nil && record.Ratio > 0 {
+		experience = experience * record.Ratio / expRatioDivisor
+	}
+
+	return experience
+}
+
+// maxExperience returns the experience of a hero on the highest level
+func (p *Progression) maxExperience(hero d2enum.Hero) int {
+	return p.breakpoint(hero, p.records.Character.MaxLevel[hero]-1)
+}
+
+// breakpoint returns the experience that is needed to reach the level after the given level
+func (p *Progression) breakpoint(hero d2enum.Hero, level int) int {
+	if record := p.records.Character.Experience[level]; record != nil {
+		return record.HeroBreakpoints[hero]
+	}
+
+	return 0
+}
+
+// AddExperience adds experience to the hero and levels it up, it returns the number of levels gained
+func (p *Progression) AddExperience(state *HeroState, experience int) int {
+	stats := state.Stats
+	if stats == nil || experience <= 0 {
+		return 0
+	}
+
+	stats.Experience += experience
+
+	if maxExperience := p.maxExperience(state.HeroType); stats.Experience > maxExperience {
+		stats.Experience = maxExperience
+	}
+
+	levels := 0
+	maxLevel := p.records.Character.MaxLevel[state.HeroType]
+
+	for stats.Level < maxLevel && stats.Experience >= p.breakpoint(state.HeroType, stats.Level) {
+		p.levelUp(state)
+		levels++
+	}
+
+	stats.NextLevelExp = p.breakpoint(state.HeroType, stats.Level)
+
+	return levels
+}
+
+// levelUp raises the level of the hero. The hero gets stat points and skill points, more life, mana and
+// stamina, and is healed.
+func (p *Progression) levelUp(state *HeroState) {
+	stats := state.Stats
+	stats.Level++
+	state.HeroLevel = stats.Level
+
+	if classStats := p.records.Character.Stats[state.HeroType]; classStats != nil {
+		stats.StatPoints += classStats.StatPerLevel
+		stats.MaxHealth += fractionGain(stats.Level-1, classStats.LifePerLevel)
+		stats.MaxMana += fractionGain(stats.Level-1, classStats.ManaPerLevel)
+		stats.MaxStamina += fractionGain(stats.Level-1, classStats.StaminaPerLevel)
+	}
+
+	stats.SkillPoints += skillPointsPerLevel
+	stats.Health = stats.MaxHealth
+	stats.Mana = stats.MaxMana
+	stats.Stamina = float64(stats.MaxStamina)
+}
+
+// fractionGain returns what the count-th gain of a value in fourths adds, the fractions of the gains add up
+// to whole points
+func fractionGain(count, fourths int) int {
+	return count*fourths/charStatsFractions - (count-1)*fourths/charStatsFractions
+}
+
+// SpendStatPoint spends a stat point of the hero on an attribute. Vitality adds life and stamina, energy
+// adds mana.
+func (p *Progression) SpendStatPoint(state *HeroState, attribute Attribute) error {
+	stats := state.Stats
+	if stats == nil || stats.StatPoints <= 0 {
+		return errNoStatPoints
+	}
+
+	classStats := p.records.Character.Stats[state.HeroType]
+	if classStats == nil {
+		return fmt.Errorf("%w: %s", errUnknownClass, state.HeroType)
+	}
+
+	switch attribute {
+	case AttributeStrength:
+		stats.Strength++
+	case AttributeDexterity:
+		stats.Dexterity++
+	case AttributeVitality:
+		stats.Vitality++
+		count := stats.Vitality - classStats.InitVit
+		life := fractionGain(count, classStats.LifePerVit)
+		stamina := fractionGain(count, classStats.StaminaPerVit)
+		stats.MaxHealth += life
+		stats.Health += life
+		stats.MaxStamina += stamina
+		stats.Stamina += float64(stamina)
+	case AttributeEnergy:
+		stats.Energy++
+		mana := fractionGain(stats.Energy-classStats.InitEne, classStats.ManaPerEne)
+		stats.MaxMana += mana
+		stats.Mana += mana
+	default:
+		return fmt.Errorf("%w: %s", errUnknownAttribute, attribute)
+	}
+
+	stats.StatPoints--
+
+	return nil
+}
+
+// LearnSkill spends a skill point of the hero on a skill of its class. The hero has to have the required
+// level of the skill and to have learned the skills it requires.
+func (p *Progression) LearnSkill(state *HeroState, skillID int) error {
+	if state.Stats == nil || state.Stats.SkillPoints <= 0 {
+		return errNoSkillPoints
+	}
+
+	skill := state.Skills[skillID]
+	record := p.records.Skill.Details[skillID]
+
+	if skill == nil || record == nil || !strings.EqualFold(record.Charclass, state.HeroType.GetToken3()) {
+		return fmt.Errorf("%w: %d", errUnknownSkill, skillID)
+	}
+
+	if state.Stats.Level < record.Reqlevel {
+		return fmt.Errorf("%w: %s requires level %d", errSkillLevel, record.Skill, record.Reqlevel)
+	}
+
+	for _, name := range []string{record.Reqskill1, record.Reqskill2, record.Reqskill3} {
+		if name == "" {
+			continue
+		}
+
+		required := p.records.GetSkillByName(name)
+		if required == nil || state.Skills[required.ID] == nil || state.Skills[required.ID].SkillPoints == 0 {
+			return fmt.Errorf("%w: %s requires %s", errSkillPrerequisite, record.Skill, name)
+		}
+	}
+
+	maxPoints := maxHardSkillPoints
+	if record.Maxlvl > 0 && record.Maxlvl < maxPoints {
+		maxPoints = record.Maxlvl
+	}
+
+	if skill.SkillPoints >= maxPoints {
+		return fmt.Errorf("%w: %s", errSkillMaxed, record.Skill)
+	}
+
+	skill.SetSkillPoints(skill.SkillPoints + 1)
+	state.Stats.SkillPoints--
+
+	return nil
+}
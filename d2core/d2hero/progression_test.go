@@ -0,0 +1,237 @@
+package d2hero
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testProgression() *Progression {
+	records := &d2records.RecordManager{}
+
+	records.Character.MaxLevel = d2records.ExperienceMaxLevels{d2enum.HeroAmazon: 5}
+	records.Character.Experience = d2records.ExperienceBreakpoints{}
+
+	for level, breakpoint := range []int{0, 500, 1500, 3750, 7875, 7875} {
+		records.Character.Experience[level] = &d2records.ExperienceBreakpointsRecord{
+			Level:           level,
+			HeroBreakpoints: map[d2enum.Hero]int{d2enum.HeroAmazon: breakpoint},
+			Ratio:           1024,
+		}
+	}
+
+	records.Character.Experience[4].Ratio = 512
+
+	records.Character.Stats = d2records.CharStats{
+		d2enum.HeroAmazon: {
+			Class: d2enum.HeroAmazon, InitVit: 20, InitEne: 15, StatPerLevel: 5,
+			LifePerLevel: 8, ManaPerLevel: 6, StaminaPerLevel: 4, LifePerVit: 12, ManaPerEne: 6, StaminaPerVit: 4,
+		},
+	}
+
+	monsterLevel := &d2records.MonsterLevelRecord{Level: 3}
+	monsterLevel.Ladder.Normal.Experience = 200
+	monsterLevel.Ladder.Hell.Experience = 1000
+	records.Monster.Levels = d2records.MonsterLevels{3: monsterLevel}
+
+	records.Skill.Details = d2records.SkillDetails{
+		6:  {ID: 6, Skill: "Magic Arrow", Charclass: "ama", Reqlevel: 1, Maxlvl: 20},
+		7:  {ID: 7, Skill: "Fire Arrow", Charclass: "ama", Reqlevel: 1, Maxlvl: 20, Reqskill1: "Magic Arrow"},
+		10: {ID: 10, Skill: "Jab", Charclass: "ama", Reqlevel: 3, Maxlvl: 20},
+		36: {ID: 36, Skill: "Fire Bolt", Charclass: "sor", Reqlevel: 1, Maxlvl: 20},
+	}
+
+	return NewProgression(records)
+}
+
+func testAmazon() *HeroState {
+	return &HeroState{
+		HeroType:  d2enum.HeroAmazon,
+		HeroLevel: 1,
+		Stats: &HeroStatsState{
+			Level: 1, Vitality: 20, Energy: 15, MaxHealth: 50, Health: 10, MaxMana: 15, Mana: 15, MaxStamina: 84,
+		},
+		Skills: map[int]*HeroSkill{
+			6:  NewShallowHeroSkill(6, 0),
+			7:  NewShallowHeroSkill(7, 0),
+			10: NewShallowHeroSkill(10, 0),
+			36: NewShallowHeroSkill(36, 0),
+		},
+	}
+}
+
+func TestProgressionMonsterExperience(t *testing.T) {
+	p := testProgression()
+	monStats := &d2records.MonStatsRecord{}
+	monStats.LevelNormal, monStats.ExperienceNormal = 3, 150
+	monStats.LevelHell, monStats.ExperienceHell = 3, 50
+
+	if level, experience := p.MonsterExperience(monStats, d2enum.DifficultyNormal); level != 3 || experience != 300 {
+		t.Errorf("expected 300 experience of a level 3 monster, got %d of level %d", experience, level)
+	}
+
+	if _, experience := p.MonsterExperience(monStats, d2enum.DifficultyHell); experience != 500 {
+		t.Errorf("expected 500 experience on hell, got %d", experience)
+	}
+
+	if _, experience := p.MonsterExperience(monStats, d2enum.DifficultyNightmare); experience != 0 {
+		t.Errorf("expected no experience without a MonLvl record, got %d", experience)
+	}
+}
+
+func TestProgressionKillExperience(t *testing.T) {
+	p := testProgression()
+
+	tests := []struct {
+		heroLevel, monsterLevel, expected int
+	}{
+		{1, 1, 1000},
+		{2, 7, 1000}, // five levels below the monster
+		{2, 10, 200}, // far below the monster
+		{12, 6, 810}, // six levels above the monster
+		{20, 10, 50}, // ten or more levels above the monster
+		{4, 4, 500},  // the ExpRatio of level 4
+		{40, 1, 50},  // the penalty does not go below 5%
+		{3, 8, 1000}, // still within five levels
+		{1, 20, 50},  // one twentieth
+		{30, 30, 1000},
+	}
+
+	for _, test := range tests {
+		if got := p.KillExperience(test.heroLevel, test.monsterLevel, 1000); got != test.expected {
+			t.Errorf("level %d hero, level %d monster: expected %d, got %d", test.heroLevel, test.monsterLevel,
+				test.expected, got)
+		}
+	}
+}
+
+func TestPartyShares(t *testing.T) {
+	if got := PartyShares(1000, []int{10}); !reflect.DeepEqual(got, []int{1000}) {
+		t.Errorf("expected the full experience alone, got %v", got)
+	}
+
+	// two players get 135% of the experience, split by their levels
+	if got := PartyShares(1000, []int{30, 15}); !reflect.DeepEqual(got, []int{900, 450}) {
+		t.Errorf("expected shares of 900 and 450, got %v", got)
+	}
+
+	if got := PartyShares(1000, nil); len(got) != 0 {
+		t.Errorf("expected no shares, got %v", got)
+	}
+}
+
+func TestProgressionLevelUp(t *testing.T) {
+	p := testProgression()
+	state := testAmazon()
+
+	if levels := p.AddExperience(state, 499); levels != 0 || state.Stats.NextLevelExp != 500 {
+		t.Fatalf("expected no level up, got %d levels, next level at %d", levels, state.Stats.NextLevelExp)
+	}
+
+	if levels := p.AddExperience(state, 1001); levels != 2 {
+		t.Fatalf("expected two levels, got %d", levels)
+	}
+
+	stats := state.Stats
+	if stats.Level != 3 || state.HeroLevel != 3 || stats.NextLevelExp != 3750 {
+		t.Errorf("expected level 3 with the next level at 3750, got %d (%d), %d", stats.Level, state.HeroLevel,
+			stats.NextLevelExp)
+	}
+
+	if stats.StatPoints != 10 || stats.SkillPoints != 2 {
+		t.Errorf("expected 10 stat and 2 skill points, got %d and %d", stats.StatPoints, stats.SkillPoints)
+	}
+
+	// two levels of 2 life, 1.5 mana and 1 stamina each
+	if stats.MaxHealth != 54 || stats.MaxMana != 18 || stats.MaxStamina != 86 {
+		t.Errorf("unexpected life %d, mana %d, stamina %d", stats.MaxHealth, stats.MaxMana, stats.MaxStamina)
+	}
+
+	if stats.Health != stats.MaxHealth || stats.Mana != stats.MaxMana {
+		t.Error("the hero was not healed on the level up")
+	}
+
+	p.AddExperience(state, 1000000)
+
+	if stats.Level != 5 || stats.Experience != 7875 {
+		t.Errorf("expected the highest level and experience, got level %d with %d", stats.Level, stats.Experience)
+	}
+}
+
+func TestProgressionSpendStatPoint(t *testing.T) {
+	p := testProgression()
+	state := testAmazon()
+
+	if err := p.SpendStatPoint(state, AttributeStrength); !errors.Is(err, errNoStatPoints) {
+		t.Errorf("expected %v, got %v", errNoStatPoints, err)
+	}
+
+	state.Stats.StatPoints = 4
+
+	for i := 0; i < 2; i++ {
+		if err := p.SpendStatPoint(state, AttributeEnergy); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := p.SpendStatPoint(state, AttributeVitality); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.SpendStatPoint(state, Attribute(9)); !errors.Is(err, errUnknownAttribute) {
+		t.Errorf("expected %v, got %v", errUnknownAttribute, err)
+	}
+
+	stats := state.Stats
+	if stats.StatPoints != 1 || stats.Energy != 17 || stats.Vitality != 21 {
+		t.Errorf("unexpected points %d, energy %d, vitality %d", stats.StatPoints, stats.Energy, stats.Vitality)
+	}
+
+	// 1.5 mana per energy, 3 life and 1 stamina per vitality
+	if stats.MaxMana != 18 || stats.MaxHealth != 53 || stats.Health != 13 || stats.MaxStamina != 85 {
+		t.Errorf("unexpected mana %d, life %d/%d, stamina %d", stats.MaxMana, stats.Health, stats.MaxHealth,
+			stats.MaxStamina)
+	}
+}
+
+func TestProgressionLearnSkill(t *testing.T) {
+	p := testProgression()
+	state := testAmazon()
+
+	if err := p.LearnSkill(state, 6); !errors.Is(err, errNoSkillPoints) {
+		t.Errorf("expected %v, got %v", errNoSkillPoints, err)
+	}
+
+	state.Stats.SkillPoints = 3
+
+	tests := []struct {
+		skillID  int
+		expected error
+	}{
+		{7, errSkillPrerequisite},
+		{10, errSkillLevel},
+		{36, errUnknownSkill},
+		{99, errUnknownSkill},
+		{6, nil},
+		{7, nil},
+	}
+
+	for _, test := range tests {
+		if err := p.LearnSkill(state, test.skillID); !errors.Is(err, test.expected) {
+			t.Errorf("skill %d: expected %v, got %v", test.skillID, test.expected, err)
+		}
+	}
+
+	if state.Stats.SkillPoints != 1 || state.Skills[6].SkillPoints != 1 || state.Skills[7].shallow.SkillPoints != 1 {
+		t.Errorf("unexpected skill points %d, %+v", state.Stats.SkillPoints, state.Skills[7].shallow)
+	}
+
+	state.Skills[6].SetSkillPoints(20)
+
+	if err := p.LearnSkill(state, 6); !errors.Is(err, errSkillMaxed) {
+		t.Errorf("expected %v, got %v", errSkillMaxed, err)
+	}
+}
@@ -0,0 +1,91 @@
+package d2hero
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// qualifiers of the skill calculations
+const (
+	calcLevel        = "lvl"
+	calcBaseLevel    = "blvl"
+	calcParam        = "par"
+	calcLinear       = "ln"
+	calcDiminishing  = "dm"
+	calcParamPairLen = 4 // "ln12" and "dm34" name the two params they use
+)
+
+// the diminishing returns of dmXY calculations approach the second param with this factor of the level
+const (
+	diminishingFactor = 110
+	diminishingLevels = 6
+	diminishingRange  = 100
+)
+
+// evalSkillCalc evaluates a calculation of the Skills records for a skill of the given level. The params and
+// level the calculation refers to are taken from the record of the skill.
+func evalSkillCalc(calc d2calculation.Calculation, record *d2records.SkillRecord, level int) int {
+	switch node := calc.(type) {
+	case nil:
+		return 0
+	case *d2calculation.BinaryCalculation:
+		return node.Op(evalSkillCalc(node.Left, record, level), evalSkillCalc(node.Right, record, level))
+	case *d2calculation.UnaryCalculation:
+		return node.Op(evalSkillCalc(node.Child, record, level))
+	case *d2calculation.TernaryCalculation:
+		return node.Op(evalSkillCalc(node.Left, record, level), evalSkillCalc(node.Middle, record, level),
+			evalSkillCalc(node.Right, record, level))
+	case *d2calculation.PropertyReferenceCalculation:
+		return evalSkillQualifier(node.Qualifier, record, level)
+	}
+
+	return calc.Eval()
+}
+
+// evalSkillQualifier returns the value of a qualifier like "lvl", "par3", "ln12" or "dm34". Linear
+// calculations add the second param for each level above the first, diminishing calculations approach the
+// second param from the first one.
+func evalSkillQualifier(qualifier string, record *d2records.SkillRecord, level int) int {
+	switch {
+	case qualifier == calcLevel || qualifier == calcBaseLevel:
+		return level
+	case strings.HasPrefix(qualifier, calcParam):
+		index, err := strconv.Atoi(strings.TrimPrefix(qualifier, calcParam))
+		if err != nil {
+			return 0
+		}
+
+		return skillParam(record, index)
+	case len(qualifier) != calcParamPairLen:
+		return 0
+	}
+
+	first := skillParam(record, int(qualifier[2]-'0'))
+	second := skillParam(record, int(qualifier[3]-'0'))
+
+	switch qualifier[:2] {
+	case calcLinear:
+		return first + (level-1)*second
+	case calcDiminishing:
+		return first + (second-first)*(diminishingFactor*level/(level+diminishingLevels))/diminishingRange
+	}
+
+	return 0
+}
+
+// skillParam returns one of the params Param1 to Param8 of the record
+func skillParam(record *d2records.SkillRecord, index int) int {
+	params := []int{
+		record.Param1, record.Param2, record.Param3, record.Param4,
+		record.Param5, record.Param6, record.Param7, record.Param8,
+	}
+
+	if index < 1 || index > len(params) {
+		return 0
+	}
+
+	return params[index-1]
+}
@@ -0,0 +1,298 @@
+package d2hero
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+)
+
+// names of the ItemStatCost records the stat sheet is computed from
+const (
+	statStrength          = "strength"
+	statDexterity         = "dexterity"
+	statVitality          = "vitality"
+	statEnergy            = "energy"
+	statStrengthPerLevel  = "item_strength_perlevel"
+	statDexterityPerLevel = "item_dexterity_perlevel"
+	statVitalityPerLevel  = "item_vitality_perlevel"
+	statEnergyPerLevel    = "item_energy_perlevel"
+	statLife              = "maxhp"
+	statLifePerLevel      = "item_hp_perlevel"
+	statLifePercent       = "item_maxhp_percent"
+	statMana              = "maxmana"
+	statManaPerLevel      = "item_mana_perlevel"
+	statManaPercent       = "item_maxmana_percent"
+	statStamina           = "maxstamina"
+	statStaminaPerLevel   = "item_stamina_perlevel"
+	statDefense           = "armorclass"
+	statDefensePerLevel   = "item_armor_perlevel"
+	statDefensePercent    = "item_armor_percent"
+	statAttackRating      = "tohit"
+	statAttackRatingLevel = "item_tohit_perlevel"
+	statAttackRatingPct   = "item_tohit_percent"
+	statFireResist        = "fireresist"
+	statColdResist        = "coldresist"
+	statLightningResist   = "lightresist"
+	statPoisonResist      = "poisonresist"
+	statMaxFireResist     = "maxfireresist"
+	statMaxColdResist     = "maxcoldresist"
+	statMaxLightResist    = "maxlightresist"
+	statMaxPoisonResist   = "maxpoisonresist"
+)
+
+const (
+	perLevelFractions     = 8 // the per level stats of items are in eighths per level of the hero
+	dexterityPerDefense   = 4 // each fourth point of dexterity adds a point of defense
+	attackRatingPerDex    = 5 // each point of dexterity adds to the attack rating
+	attackRatingBase      = -35
+	maxResistance         = 75 // the highest resistance without bonuses to the maximum resistance
+	maxResistanceBonusCap = 95 // bonuses can not raise the maximum resistance further
+	minResistance         = -100
+)
+
+// StatSheet holds the effective stats of a hero. They combine the stats of its HeroStatsState with the stats
+// of its equipment, the bonuses of the sets it wears and its passive skills.
+type StatSheet struct {
+	Strength  int
+	Dexterity int
+	Vitality  int
+	Energy    int
+
+	AttackRating  int
+	DefenseRating int
+
+	MaxHealth  int
+	MaxMana    int
+	MaxStamina int
+
+	FireResistance      int
+	ColdResistance      int
+	LightningResistance int
+	PoisonResistance    int
+
+	// Stats are the reduced stats of the equipment, set bonuses and passive skills
+	Stats d2stats.StatList
+}
+
+// StatAggregator computes the StatSheet of a hero from the CharStats, Skills, DifficultyLevels and
+// ItemTypes records
+type StatAggregator struct {
+	records *d2records.RecordManager
+	stat    *diablo2stats.StatFactory
+	item    *diablo2item.ItemFactory
+}
+
+// NewStatAggregator creates a StatAggregator
+func NewStatAggregator(asset *d2asset.AssetManager) (*StatAggregator, error) {
+	statFactory, err := diablo2stats.NewStatFactory(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	itemFactory, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatAggregator{records: asset.Records, stat: statFactory, item: itemFactory}, nil
+}
+
+// Aggregate computes the stat sheet of the hero with the equipped items on the difficulty.
+//
+// Enhanced defense of an item only enhances the defense of that item, while the enhanced defense of set
+// bonuses and passive skills enhances the whole defense. Increased life and mana only increase the life and
+// mana the hero has without its equipment. Resistances are lowered by the penalty of the difficulty and
+// capped at 75, bonuses to the maximum resistance raise the cap up to 95.
+func (a *StatAggregator) Aggregate(state *HeroState, equipment []*diablo2item.Item,
+	difficulty d2enum.DifficultyType) *StatSheet {
+	base := state.Stats
+	if base == nil {
+		base = &HeroStatsState{}
+	}
+
+	itemStats := make([]d2stats.Stat, 0)
+
+	for _, item := range equipment {
+		if item != nil && item.StatList() != nil {
+			itemStats = append(itemStats, item.StatList().Stats()...)
+		}
+	}
+
+	bonusStats := append(a.item.SetBonuses(equipment).Stats(), a.passiveStats(state, equipment)...)
+
+	sheet := &StatSheet{Stats: a.stat.NewStatList(append(itemStats, bonusStats...)...).ReduceStats()}
+	totals := statTotals(sheet.Stats)
+	bonusTotals := statTotals(a.stat.NewStatList(bonusStats...).ReduceStats())
+
+	perLevel := func(name string) int {
+		return totals[name] * base.Level / perLevelFractions
+	}
+
+	sheet.Strength = base.Strength + totals[statStrength] + perLevel(statStrengthPerLevel)
+	sheet.Dexterity = base.Dexterity + totals[statDexterity] + perLevel(statDexterityPerLevel)
+	sheet.Vitality = base.Vitality + totals[statVitality] + perLevel(statVitalityPerLevel)
+	sheet.Energy = base.Energy + totals[statEnergy] + perLevel(statEnergyPerLevel)
+
+	sheet.MaxHealth = base.MaxHealth + base.MaxHealth*totals[statLifePercent]/percent + totals[statLife] +
+		perLevel(statLifePerLevel)
+	sheet.MaxMana = base.MaxMana + base.MaxMana*totals[statManaPercent]/percent + totals[statMana] +
+		perLevel(statManaPerLevel)
+	sheet.MaxStamina = base.MaxStamina + totals[statStamina] + perLevel(statStaminaPerLevel)
+
+	if classStats := a.records.Character.Stats[state.HeroType]; classStats != nil {
+		sheet.MaxHealth += (sheet.Vitality - base.Vitality) * classStats.LifePerVit / charStatsFractions
+		sheet.MaxMana += (sheet.Energy - base.Energy) * classStats.ManaPerEne / charStatsFractions
+		sheet.MaxStamina += (sheet.Vitality - base.Vitality) * classStats.StaminaPerVit / charStatsFractions
+		sheet.AttackRating = classStats.ToHitFactor
+	}
+
+	sheet.AttackRating += base.AttackRating + attackRatingBase + sheet.Dexterity*attackRatingPerDex +
+		totals[statAttackRating] + perLevel(statAttackRatingLevel)
+	sheet.AttackRating = sheet.AttackRating * (percent + totals[statAttackRatingPct]) / percent
+
+	sheet.DefenseRating = base.DefenseRating + equipmentDefense(equipment) + totals[statDefense] +
+		perLevel(statDefensePerLevel) + sheet.Dexterity/dexterityPerDefense
+	sheet.DefenseRating = sheet.DefenseRating * (percent + bonusTotals[statDefensePercent]) / percent
+
+	penalty := a.resistancePenalty(difficulty)
+	sheet.FireResistance = resistance(base.FireResistance, penalty, totals[statFireResist], totals[statMaxFireResist])
+	sheet.ColdResistance = resistance(base.ColdResistance, penalty, totals[statColdResist], totals[statMaxColdResist])
+	sheet.LightningResistance = resistance(base.LightningResistance, penalty, totals[statLightningResist],
+		totals[statMaxLightResist])
+	sheet.PoisonResistance = resistance(base.PoisonResistance, penalty, totals[statPoisonResist],
+		totals[statMaxPoisonResist])
+
+	return sheet
+}
+
+// statTotals returns the value of each stat of the list which has a single value, by the name of the stat
+func statTotals(list d2stats.StatList) map[string]int {
+	totals := make(map[string]int)
+
+	for _, stat := range list.Stats() {
+		if values := stat.Values(); len(values) == 1 {
+			totals[stat.Name()] += values[0].Int()
+		}
+	}
+
+	return totals
+}
+
+// equipmentDefense returns the defense of the items, each enhanced by the enhanced defense of the item
+func equipmentDefense(equipment []*diablo2item.Item) int {
+	defense := 0
+
+	for _, item := range equipment {
+		if item == nil {
+			continue
+		}
+
+		enhanced := 0
+		if item.StatList() != nil {
+			enhanced = statTotals(item.StatList())[statDefensePercent]
+		}
+
+		defense += item.Defense() * (percent + enhanced) / percent
+	}
+
+	return defense
+}
+
+// resistance returns a resistance lowered by the penalty of the difficulty and capped at the maximum
+func resistance(base, penalty, bonus, maxBonus int) int {
+	maximum := maxResistance + maxBonus
+	if maximum > maxResistanceBonusCap {
+		maximum = maxResistanceBonusCap
+	}
+
+	value := base + penalty + bonus
+
+	switch {
+	case value > maximum:
+		return maximum
+	case value < minResistance:
+		return minResistance
+	}
+
+	return value
+}
+
+// resistancePenalty returns the (negative) resistance penalty of the difficulty
+func (a *StatAggregator) resistancePenalty(difficulty d2enum.DifficultyType) int {
+	if record := a.records.DifficultyLevels[difficulty.String()]; record != nil {
+		return record.ResistancePenalty
+	}
+
+	return 0
+}
+
+// passiveStats returns the stats of the passive skills the hero has learned. Passive skills which require an
+// item type, like the bow skills of the amazon, only grant their stats when such an item is equipped.
+func (a *StatAggregator) passiveStats(state *HeroState, equipment []*diablo2item.Item) []d2stats.Stat {
+	stats := make([]d2stats.Stat, 0)
+
+	for id, skill := range state.Skills {
+		record := a.records.Skill.Details[id]
+		if skill == nil || skill.SkillPoints <= 0 || record == nil {
+			continue
+		}
+
+		if record.Passiveitype != "" && !a.equipsType(equipment, record.Passiveitype) {
+			continue
+		}
+
+		passives := []struct {
+			stat string
+			calc d2calculation.Calculation
+		}{
+			{record.Passivestat1, record.Passivecalc1},
+			{record.Passivestat2, record.Passivecalc2},
+			{record.Passivestat3, record.Passivecalc3},
+			{record.Passivestat4, record.Passivecalc4},
+			{record.Passivestat5, record.Passivecalc5},
+		}
+
+		for _, passive := range passives {
+			if passive.stat == "" {
+				continue
+			}
+
+			value := evalSkillCalc(passive.calc, record, skill.SkillPoints)
+			if stat := a.stat.NewStat(passive.stat, float64(value)); stat != nil {
+				stats = append(stats, stat)
+			}
+		}
+	}
+
+	return stats
+}
+
+// equipsType returns true when one of the items is of the item type, or of a type equivalent to it
+func (a *StatAggregator) equipsType(equipment []*diablo2item.Item, itemType string) bool {
+	for _, item := range equipment {
+		if item != nil && a.isOfType(item.ItemType(), itemType, len(a.records.Item.Types)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOfType follows the equivalences of the item type, at most depth of them
+func (a *StatAggregator) isOfType(code, itemType string, depth int) bool {
+	if code == itemType {
+		return true
+	}
+
+	record := a.records.Item.Types[code]
+	if record == nil || depth <= 0 {
+		return false
+	}
+
+	return (record.Equiv1 != "" && a.isOfType(record.Equiv1, itemType, depth-1)) ||
+		(record.Equiv2 != "" && a.isOfType(record.Equiv2, itemType, depth-1))
+}
@@ -0,0 +1,264 @@
+package d2hero
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2calculation/d2parser"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func testStatRecords() *d2records.RecordManager {
+	records := &d2records.RecordManager{}
+
+	records.Item.Stats = d2records.ItemStatCosts{}
+	for name, descFn := range map[string]int{
+		statStrength: 1, statDexterity: 1, statVitality: 1, statEnergy: 1, statStrengthPerLevel: 6,
+		statLife: 1, statLifePercent: 2, statDefense: 1, statDefensePercent: 4, statAttackRating: 1,
+		statFireResist: 4, statMaxFireResist: 4, statColdResist: 4,
+	} {
+		records.Item.Stats[name] = &d2records.ItemStatCostRecord{Name: name, DescFnID: descFn}
+	}
+
+	records.Properties = d2records.Properties{}
+	for code, stat := range map[string]string{
+		"str": statStrength, "str/lvl": statStrengthPerLevel, "vit": statVitality, "hp": statLife,
+		"hp%": statLifePercent, "ac": statDefense, "ac%": statDefensePercent, "res-fire": statFireResist,
+		"res-fire-max": statMaxFireResist, "res-cold": statColdResist,
+	} {
+		records.Properties[code] = &d2records.PropertyRecord{
+			Code:  code,
+			Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: stat}},
+		}
+	}
+
+	records.Item.All = d2records.CommonItems{
+		"cap": {Code: "cap", Type: "helm", MinAC: 3, MaxAC: 3},
+		"buc": {Code: "buc", Type: "shie", MinAC: 4, MaxAC: 4},
+		"sbw": {Code: "sbw", Type: "abow"},
+	}
+
+	records.Item.Types = d2records.ItemTypes{
+		"abow": {Code: "abow", Equiv1: "bow"},
+		"bow":  {Code: "bow", Equiv1: "miss"},
+	}
+
+	records.Character.Stats = d2records.CharStats{
+		d2enum.HeroAmazon: {Class: d2enum.HeroAmazon, LifePerVit: 12, ManaPerEne: 6, StaminaPerVit: 4, ToHitFactor: 5},
+	}
+
+	records.DifficultyLevels = d2records.DifficultyLevels{
+		"Normal": {Name: "Normal"},
+		"Hell":   {Name: "Hell", ResistancePenalty: -100},
+	}
+
+	return records
+}
+
+func testProperty(code string, value int) *d2records.PropertyDescriptor {
+	return &d2records.PropertyDescriptor{Code: code, Min: value, Max: value}
+}
+
+func testStatAggregator(t *testing.T, records *d2records.RecordManager) (*StatAggregator,
+	*diablo2item.ItemFactory) {
+	asset := &d2asset.AssetManager{Records: records}
+
+	aggregator, err := NewStatAggregator(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return aggregator, items
+}
+
+func testItem(t *testing.T, items *diablo2item.ItemFactory, codes ...string) *diablo2item.Item {
+	item, err := items.NewItem(codes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return item
+}
+
+func testStatHero() *HeroState {
+	return &HeroState{
+		HeroType: d2enum.HeroAmazon,
+		Stats: &HeroStatsState{
+			Level: 8, Strength: 20, Dexterity: 25, Vitality: 20, Energy: 15,
+			MaxHealth: 50, MaxMana: 15, MaxStamina: 84,
+		},
+		Skills: map[int]*HeroSkill{},
+	}
+}
+
+func TestStatAggregatorEquipment(t *testing.T) {
+	records := testStatRecords()
+	records.Item.Unique = d2records.UniqueItems{
+		"Helm": {Name: "Helm", Code: "cap", Properties: [12]*d2records.UniqueItemProperty{
+			testProperty("str", 5), testProperty("vit", 4), testProperty("hp%", 10), testProperty("hp", 20),
+			testProperty("ac%", 50),
+		}},
+		"Shield": {Name: "Shield", Code: "buc", Properties: [12]*d2records.UniqueItemProperty{
+			testProperty("str", 3), testProperty("str/lvl", 8),
+		}},
+	}
+
+	aggregator, items := testStatAggregator(t, records)
+	equipment := []*diablo2item.Item{testItem(t, items, "cap", "Helm"), testItem(t, items, "buc", "Shield")}
+
+	sheet := aggregator.Aggregate(testStatHero(), equipment, d2enum.DifficultyNormal)
+
+	// the strength of both items stacks, the per level strength adds 8/8 per level
+	if sheet.Strength != 36 || sheet.Vitality != 24 || sheet.Dexterity != 25 || sheet.Energy != 15 {
+		t.Errorf("unexpected attributes %d, %d, %d, %d", sheet.Strength, sheet.Dexterity, sheet.Vitality,
+			sheet.Energy)
+	}
+
+	// 50 base life, 10% of it, 20 life and 3 life for each of the 4 vitality
+	if sheet.MaxHealth != 87 || sheet.MaxMana != 15 || sheet.MaxStamina != 88 {
+		t.Errorf("unexpected life %d, mana %d, stamina %d", sheet.MaxHealth, sheet.MaxMana, sheet.MaxStamina)
+	}
+
+	// the enhanced defense of the helm does not enhance the shield: 3*150% + 4 + 25/4
+	if sheet.DefenseRating != 14 {
+		t.Errorf("expected a defense of 14, got %d", sheet.DefenseRating)
+	}
+
+	if sheet.AttackRating != 95 {
+		t.Errorf("expected an attack rating of 95, got %d", sheet.AttackRating)
+	}
+
+	if len(sheet.Stats.Stats()) != 6 {
+		t.Errorf("expected 6 reduced stats, got %d", len(sheet.Stats.Stats()))
+	}
+}
+
+func TestStatAggregatorResistances(t *testing.T) {
+	records := testStatRecords()
+	records.Item.Unique = d2records.UniqueItems{
+		"Helm": {Name: "Helm", Code: "cap", Properties: [12]*d2records.UniqueItemProperty{
+			testProperty("res-fire", 50), testProperty("res-cold", -20),
+		}},
+		"Shield": {Name: "Shield", Code: "buc", Properties: [12]*d2records.UniqueItemProperty{
+			testProperty("res-fire", 40),
+		}},
+		"Max": {Name: "Max", Code: "buc", Properties: [12]*d2records.UniqueItemProperty{
+			testProperty("res-fire-max", 30),
+		}},
+	}
+
+	aggregator, items := testStatAggregator(t, records)
+	hero := testStatHero()
+	hero.Stats.PoisonResistance = 10
+
+	equipment := []*diablo2item.Item{testItem(t, items, "cap", "Helm"), testItem(t, items, "buc", "Shield")}
+
+	tests := []struct {
+		difficulty              d2enum.DifficultyType
+		equipment               []*diablo2item.Item
+		fire, cold, light, pois int
+	}{
+		{d2enum.DifficultyNormal, equipment, 75, -20, 0, 10},
+		{d2enum.DifficultyHell, equipment, -10, -100, -100, -90},
+		{d2enum.DifficultyNormal, append(equipment, testItem(t, items, "buc", "Max")), 90, -20, 0, 10},
+		{d2enum.DifficultyNightmare, nil, 0, 0, 0, 10}, // without a DifficultyLevels record
+	}
+
+	for _, test := range tests {
+		sheet := aggregator.Aggregate(hero, test.equipment, test.difficulty)
+
+		if sheet.FireResistance != test.fire || sheet.ColdResistance != test.cold ||
+			sheet.LightningResistance != test.light || sheet.PoisonResistance != test.pois {
+			t.Errorf("%s: expected %d, %d, %d, %d, got %d, %d, %d, %d", test.difficulty, test.fire, test.cold,
+				test.light, test.pois, sheet.FireResistance, sheet.ColdResistance, sheet.LightningResistance,
+				sheet.PoisonResistance)
+		}
+	}
+}
+
+func TestStatAggregatorSetBonuses(t *testing.T) {
+	records := testStatRecords()
+
+	helm := &d2records.SetItemRecord{SetItemKey: "Helm", SetKey: "Set", ItemCode: "cap", AddFn: 2}
+	helm.SetPropertiesLevel1[0] = testProperty("ac", 10)
+
+	shield := &d2records.SetItemRecord{SetItemKey: "Shield", SetKey: "Set", ItemCode: "buc", AddFn: 2}
+	shield.SetPropertiesLevel2[0] = testProperty("ac%", 100)
+	shield.SetPropertiesLevel1[1] = testProperty("str", 100)
+
+	bow := &d2records.SetItemRecord{SetItemKey: "Bow", SetKey: "Set", ItemCode: "sbw"}
+	records.Item.SetItems = d2records.SetItems{"Helm": helm, "Shield": shield, "Bow": bow}
+
+	set := &d2records.SetRecord{Key: "Set"}
+	set.Properties.PartialA = []*d2records.SetProperty{testProperty("res-cold", 10), testProperty("str", 200)}
+	set.Properties.PartialB = []*d2records.SetProperty{testProperty("str", 2), nil}
+	set.Properties.Full = []*d2records.SetProperty{testProperty("vit", 50)}
+	records.Item.Sets = d2records.Sets{"Set": set}
+
+	aggregator, items := testStatAggregator(t, records)
+
+	// the second helm does not count as another item of the set
+	equipment := []*diablo2item.Item{
+		testItem(t, items, "cap", "Helm"), testItem(t, items, "cap", "Helm"), testItem(t, items, "buc", "Shield"),
+	}
+
+	sheet := aggregator.Aggregate(testStatHero(), equipment, d2enum.DifficultyNormal)
+
+	if sheet.Strength != 22 || sheet.Vitality != 20 || sheet.ColdResistance != 10 {
+		t.Errorf("unexpected partial set bonuses: strength %d, vitality %d, cold resistance %d", sheet.Strength,
+			sheet.Vitality, sheet.ColdResistance)
+	}
+
+	// the enhanced defense of the set bonus enhances the whole defense: (3 + 3 + 4 + 10 + 25/4) * 200%
+	if sheet.DefenseRating != 52 {
+		t.Errorf("expected a defense of 52, got %d", sheet.DefenseRating)
+	}
+
+	sheet = aggregator.Aggregate(testStatHero(), append(equipment, testItem(t, items, "sbw", "Bow")),
+		d2enum.DifficultyNormal)
+
+	if sheet.Strength != 322 || sheet.Vitality != 70 {
+		t.Errorf("unexpected full set bonuses: strength %d, vitality %d", sheet.Strength, sheet.Vitality)
+	}
+}
+
+func TestStatAggregatorPassiveSkills(t *testing.T) {
+	records := testStatRecords()
+	parser := d2parser.New()
+
+	records.Skill.Details = d2records.SkillDetails{
+		1: {ID: 1, Passivestat1: statDefensePercent, Passivecalc1: parser.Parse("ln12"), Param1: 30, Param2: 10},
+		2: {ID: 2, Passivestat1: statAttackRating, Passivecalc1: parser.Parse("par1*lvl"), Param1: 10,
+			Passiveitype: "bow"},
+		3: {ID: 3, Passivestat1: statFireResist, Passivecalc1: parser.Parse("dm12"), Param1: 0, Param2: 100},
+	}
+
+	aggregator, items := testStatAggregator(t, records)
+	hero := testStatHero()
+	hero.Skills = map[int]*HeroSkill{1: NewShallowHeroSkill(1, 3), 2: NewShallowHeroSkill(2, 2),
+		3: NewShallowHeroSkill(3, 0)}
+
+	sheet := aggregator.Aggregate(hero, nil, d2enum.DifficultyNormal)
+
+	// 30% enhanced defense and 10% for each further level, of the defense of the dexterity
+	if sheet.DefenseRating != 9 || sheet.AttackRating != 95 || sheet.FireResistance != 0 {
+		t.Errorf("unexpected defense %d, attack rating %d, fire resistance %d", sheet.DefenseRating,
+			sheet.AttackRating, sheet.FireResistance)
+	}
+
+	hero.Skills[3].SetSkillPoints(1)
+
+	sheet = aggregator.Aggregate(hero, []*diablo2item.Item{testItem(t, items, "sbw")}, d2enum.DifficultyNormal)
+
+	// the bow skill needs a bow, 10 attack rating per level, and the diminishing resistance
+	if sheet.AttackRating != 115 || sheet.FireResistance != 15 {
+		t.Errorf("unexpected attack rating %d, fire resistance %d", sheet.AttackRating, sheet.FireResistance)
+	}
+}
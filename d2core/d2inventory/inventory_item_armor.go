@@ -4,6 +4,9 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 )
 
+// static check to ensure InventoryItemArmor implements InventoryItem
+var _ InventoryItem = &InventoryItemArmor{}
+
 // InventoryItemArmor stores the info of an armor item in the inventory
 type InventoryItemArmor struct {
 	InventorySizeX int    `json:"inventorySizeX"`
@@ -53,11 +56,6 @@ func (v *InventoryItemArmor) SetInventoryGridSlot(x, y int) {
 	v.InventorySlotX, v.InventorySlotY = x, y
 }
 
-// Serialize returns the armor object as a byte array
-func (v *InventoryItemArmor) Serialize() []byte {
-	return []byte{}
-}
-
 // GetItemCode returns the item code of the armor
 func (v *InventoryItemArmor) GetItemCode() string {
 	if v == nil {
@@ -4,6 +4,9 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 )
 
+// static check to ensure InventoryItemMisc implements InventoryItem
+var _ InventoryItem = &InventoryItemMisc{}
+
 // InventoryItemMisc stores the info of an miscellaneous item in the inventory
 type InventoryItemMisc struct {
 	InventorySizeX int    `json:"inventorySizeX"`
@@ -43,11 +46,6 @@ func (v *InventoryItemMisc) SetInventoryGridSlot(x, y int) {
 	v.InventorySlotX, v.InventorySlotY = x, y
 }
 
-// Serialize returns the miscellaneous item object as a byte array
-func (v *InventoryItemMisc) Serialize() []byte {
-	return []byte{}
-}
-
 // GetItemCode returns the item code of the miscellaneous item
 func (v *InventoryItemMisc) GetItemCode() string {
 	if v == nil {
@@ -4,6 +4,9 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 )
 
+// static check to ensure InventoryItemWeapon implements InventoryItem
+var _ InventoryItem = &InventoryItemWeapon{}
+
 // InventoryItemWeapon stores the info of an weapon item in the inventory
 type InventoryItemWeapon struct {
 	InventorySizeX     int    `json:"inventorySizeX"`
@@ -63,11 +66,6 @@ func (v *InventoryItemWeapon) SetInventoryGridSlot(x, y int) {
 	v.InventorySlotX, v.InventorySlotY = x, y
 }
 
-// Serialize returns the weapon object as a byte array
-func (v *InventoryItemWeapon) Serialize() []byte {
-	return []byte{}
-}
-
 // GetItemCode returns the item code of the weapon
 func (v *InventoryItemWeapon) GetItemCode() string {
 	if v == nil {
@@ -0,0 +1,487 @@
+package diablo2item
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+var (
+	errNoMatchingRecipe  = errors.New("no cube recipe matches the items")
+	errUnknownCubeOutput = errors.New("unknown cube recipe output")
+)
+
+// the item codes of cube inputs and outputs which do not name an item or an item type
+const (
+	cubeAnyItem  = "any"
+	cubeUseItem  = "useitem" // the first input item, modified by the recipe
+	cubeUseType  = "usetype" // a new item of the base item of the first input item
+	cubePortal   = "Portal"  // Cow Portal, Pandemonium Portal, ... open a portal instead of creating an item
+	cubeParamSep = "="
+)
+
+// the params of cube inputs and outputs
+const (
+	cubeNoSocket    = "nos"
+	cubeSocketed    = "sock"
+	cubeEthereal    = "eth"
+	cubeNotEthereal = "noe"
+	cubeBasic       = "bas"
+	cubeExceptional = "exc"
+	cubeElite       = "eli"
+	cubeUpgraded    = "upg"
+	cubeNoRuneword  = "nru"
+	cubeRemove      = "rem" // remove the items in the sockets and keep them
+	cubeUnsocket    = "uns" // remove the items in the sockets and destroy them
+)
+
+const (
+	cubeModChancePercent = 100
+	cubeLevelPercent     = 100 // plvl and ilvl are percentages of the levels of the player and the first input
+)
+
+// cubeQualities are the quality params of cube inputs and outputs
+// nolint:gochecknoglobals // a constant map
+var cubeQualities = map[string]d2enum.ItemQuality{
+	"low": d2enum.LowQuality,
+	"nor": d2enum.Normal,
+	"hiq": d2enum.Superior,
+	"mag": d2enum.Magic,
+	"set": d2enum.Set,
+	"rar": d2enum.Rare,
+	"uni": d2enum.Unique,
+	"crf": d2enum.Crafted,
+	"tmp": d2enum.Tempered,
+}
+
+// HoradricCube transmutes items with the recipes of the CubeMain records. It does not depend on a cube
+// panel, so it can transmute the items of tests and scripts as well.
+type HoradricCube struct {
+	factory     *ItemFactory
+	difficulty  d2enum.DifficultyType
+	hero        d2enum.Hero
+	playerLevel int
+}
+
+// Transmutation is the result of transmuting items in the cube. The input items are consumed, the outputs
+// replace them in the cube. An output of the recipe which modifies the first input item holds that item.
+type Transmutation struct {
+	Recipe  *d2records.CubeRecipeRecord
+	Outputs []*Item
+	Portal  string // the portal the recipe opens, like "Cow Portal"
+}
+
+// NewHoradricCube creates a HoradricCube which creates the outputs of the recipes with the item factory
+func NewHoradricCube(factory *ItemFactory) *HoradricCube {
+	return &HoradricCube{factory: factory}
+}
+
+// Factory returns the item factory the cube creates the outputs with
+func (c *HoradricCube) Factory() *ItemFactory {
+	return c.factory
+}
+
+// SetDifficulty sets the difficulty, recipes with a higher minimum difficulty do not match
+func (c *HoradricCube) SetDifficulty(difficulty d2enum.DifficultyType) {
+	c.difficulty = difficulty
+}
+
+// SetHero sets the class of the hero using the cube, for recipes which are class specific
+func (c *HoradricCube) SetHero(hero d2enum.Hero) {
+	c.hero = hero
+}
+
+// SetPlayerLevel sets the level of the hero using the cube, for outputs whose item level depends on it
+func (c *HoradricCube) SetPlayerLevel(level int) {
+	c.playerLevel = level
+}
+
+// FindRecipe returns the first enabled recipe the items match, or nil. All of the items have to be used by the
+// inputs of the recipe. The stat requirements of the recipes are not checked yet.
+func (c *HoradricCube) FindRecipe(items []*Item) *d2records.CubeRecipeRecord {
+	record, _ := c.findRecipe(items)
+
+	return record
+}
+
+// Transmute transmutes the items with the first recipe they match
+func (c *HoradricCube) Transmute(items []*Item) (*Transmutation, error) {
+	record, first := c.findRecipe(items)
+	if record == nil {
+		return nil, errNoMatchingRecipe
+	}
+
+	result := &Transmutation{Recipe: record, Outputs: make([]*Item, 0)}
+
+	for idx := range record.Outputs {
+		output := &record.Outputs[idx]
+
+		switch code := output.Item.Code; {
+		case code == "":
+			continue
+		case strings.HasSuffix(code, cubePortal):
+			result.Portal = code
+			continue
+		}
+
+		outputs, err := c.createOutputs(output, first)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Outputs = append(result.Outputs, outputs...)
+	}
+
+	return result, nil
+}
+
+// findRecipe returns the first recipe the items match and the item which matched its first input
+func (c *HoradricCube) findRecipe(items []*Item) (record *d2records.CubeRecipeRecord, first *Item) {
+	for _, recipe := range c.factory.asset.Records.Item.Cube.Recipes {
+		if !c.isAvailable(recipe) {
+			continue
+		}
+
+		inputs := cubeInputs(recipe)
+
+		assigned := make([]int, len(items))
+		if !c.assignInputs(items, inputs, assigned, make([]int, len(inputs)), 0) {
+			continue
+		}
+
+		for idx := range items {
+			if assigned[idx] == 0 {
+				return recipe, items[idx]
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// isAvailable returns true when the recipe can be used on the difficulty by the hero
+func (c *HoradricCube) isAvailable(recipe *d2records.CubeRecipeRecord) bool {
+	if !recipe.Enabled || recipe.Ladder || recipe.MinDiff > int(c.difficulty) {
+		return false
+	}
+
+	restricted := false
+
+	for _, class := range recipe.Class {
+		if class == d2enum.HeroNone {
+			continue
+		}
+
+		if class == c.hero {
+			return true
+		}
+
+		restricted = true
+	}
+
+	return !restricted
+}
+
+// cubeInputs returns the inputs of the recipe which name an item
+func cubeInputs(recipe *d2records.CubeRecipeRecord) []d2records.CubeRecipeItem {
+	inputs := make([]d2records.CubeRecipeItem, 0, len(recipe.Inputs))
+
+	for _, input := range recipe.Inputs {
+		if input.Code != "" {
+			inputs = append(inputs, input)
+		}
+	}
+
+	return inputs
+}
+
+// assignInputs assigns each of the items, from the one at the index on, to an input it matches, as long as
+// the input needs more items. The same item may match more than one input, like a ring matches "rin" and
+// "any", so other assignments are tried when one does not use up all of the inputs.
+func (c *HoradricCube) assignInputs(items []*Item, inputs []d2records.CubeRecipeItem, assigned, counts []int,
+	index int) bool {
+	if index == len(items) {
+		for idx := range inputs {
+			if counts[idx] != inputs[idx].Count {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	for idx := range inputs {
+		if counts[idx] >= inputs[idx].Count || !c.matchesInput(items[index], &inputs[idx]) {
+			continue
+		}
+
+		counts[idx]++
+		assigned[index] = idx
+
+		if c.assignInputs(items, inputs, assigned, counts, index+1) {
+			return true
+		}
+
+		counts[idx]--
+	}
+
+	return false
+}
+
+// matchesInput returns true when the item is of the item or item type of the input and has its params
+func (c *HoradricCube) matchesInput(item *Item, input *d2records.CubeRecipeItem) bool {
+	if item == nil || item.CommonRecord() == nil {
+		return false
+	}
+
+	if input.Code != cubeAnyItem && input.Code != item.CommonCode {
+		itemTypes := c.factory.asset.Records.FindEquivalentTypesByItemCommonRecord(item.CommonRecord())
+		if !containsAnyType([]string{input.Code}, itemTypes) {
+			return false
+		}
+	}
+
+	for _, param := range input.Params {
+		if !hasCubeParam(item, param) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasCubeParam returns true when the item has the quality, sockets or other property the param of an input
+// asks for. Params which are not known are never matched.
+func hasCubeParam(item *Item, param string) bool {
+	if quality, found := cubeQualities[param]; found {
+		return item.Quality() == quality
+	}
+
+	name, value := cubeParam(param)
+	common := item.CommonRecord()
+
+	switch name {
+	case cubeNoSocket:
+		return item.Sockets() == 0
+	case cubeSocketed:
+		return item.Sockets() > 0 && (value == 0 || item.Sockets() == value)
+	case cubeEthereal:
+		return item.attributes != nil && item.attributes.ethereal
+	case cubeNotEthereal:
+		return item.attributes == nil || !item.attributes.ethereal
+	case cubeBasic:
+		return common.NormalCode == "" || common.Code == common.NormalCode
+	case cubeExceptional:
+		return common.Code == common.UberCode
+	case cubeElite:
+		return common.Code == common.UltraCode
+	case cubeUpgraded:
+		return common.Code == common.UberCode || common.Code == common.UltraCode
+	case cubeNoRuneword:
+		return item.RunewordRecord() == nil
+	}
+
+	return false
+}
+
+// cubeParam splits a param like "sock=4" into its name and value
+func cubeParam(param string) (name string, value int) {
+	parts := strings.SplitN(param, cubeParamSep, 2) //nolint:gomnd // the name and the value
+
+	if len(parts) > 1 {
+		value, _ = strconv.Atoi(parts[1])
+	}
+
+	return parts[0], value
+}
+
+// createOutputs creates the items of an output of a recipe, the first input is modified when the output uses it
+func (c *HoradricCube) createOutputs(output *d2records.CubeRecipeResult, first *Item) ([]*Item, error) {
+	level := c.outputLevel(output, first)
+
+	if output.Item.Code == cubeUseItem {
+		if level > 0 {
+			first.level = level
+		}
+
+		return c.modifyOutput(first, output, true), nil
+	}
+
+	result := make([]*Item, 0, output.Item.Count)
+
+	for count := 0; count < output.Item.Count; count++ {
+		code := output.Item.Code
+		if code == cubeUseType {
+			code = first.CommonCode
+		}
+
+		item := c.newOutputItem(code)
+		if item == nil {
+			return nil, fmt.Errorf("%w: %s", errUnknownCubeOutput, output.Item.Code)
+		}
+
+		item.level = level
+
+		result = append(result, c.modifyOutput(item, output, false)...)
+	}
+
+	return result, nil
+}
+
+// outputLevel returns the item level of the items of the output, the items are rolled with it. It is the lvl of
+// the output plus the plvl percent of the level of the player and the ilvl percent of the item level of the first
+// input, or 0 when the output sets none of them and the items keep their level.
+func (c *HoradricCube) outputLevel(output *d2records.CubeRecipeResult, first *Item) int {
+	level := output.Level + output.PLevel*c.playerLevel/cubeLevelPercent
+
+	if first != nil {
+		level += output.ILevel * first.ItemLevel() / cubeLevelPercent
+	}
+
+	return level
+}
+
+// newOutputItem creates an item of the item code, or a random item of the item type
+func (c *HoradricCube) newOutputItem(code string) *Item {
+	f := c.factory
+
+	if f.asset.Records.Item.All[code] == nil {
+		equivalents := f.asset.Records.Item.Equivalency[code]
+		if len(equivalents) == 0 {
+			return nil
+		}
+
+		code = equivalents[f.rand.Intn(len(equivalents))].Code
+	}
+
+	item := &Item{factory: f, CommonCode: code}
+	item.SetSeed(f.rand.Int63())
+
+	return item
+}
+
+// modifyOutput applies the params and mods of the output to the item and returns it, along with the items
+// removed from its sockets. A new item gets the quality of the output, an item the output uses only when the
+// output asks for one.
+func (c *HoradricCube) modifyOutput(item *Item, output *d2records.CubeRecipeResult, used bool) []*Item {
+	result := []*Item{item}
+	quality := d2enum.ItemQuality(0)
+
+	for _, param := range output.Item.Params {
+		if q, found := cubeQualities[param]; found {
+			quality = q
+			continue
+		}
+
+		name, value := cubeParam(param)
+
+		switch name {
+		case cubeExceptional, cubeElite, cubeUpgraded:
+			item.upgrade(name)
+		case cubeRemove:
+			result = append(result, item.unsocket()...)
+		case cubeUnsocket:
+			item.unsocket()
+		case cubeSocketed:
+			_ = item.SetSockets(d2math.MinInt(value, item.MaxSockets()))
+		}
+	}
+
+	if quality != 0 || !used {
+		item.reroll(quality)
+	}
+
+	c.applyMods(item, output.Properties)
+
+	item.init()
+	item.Identify()
+
+	return result
+}
+
+// applyMods rolls for the mods of the output, the "sock" mod adds sockets instead of a property
+func (c *HoradricCube) applyMods(item *Item, mods []d2records.CubeRecipeItemProperty) {
+	for _, mod := range mods {
+		if mod.Code == "" || (mod.Chance > 0 && c.factory.rand.Intn(cubeModChancePercent) >= mod.Chance) {
+			continue
+		}
+
+		if mod.Code == cubeSocketed {
+			sockets := mod.Min
+			if mod.Max > mod.Min {
+				sockets += c.factory.rand.Intn(mod.Max - mod.Min + 1)
+			}
+
+			_ = item.SetSockets(d2math.MaxInt(d2math.MinInt(sockets, item.MaxSockets()), len(item.sockets)))
+
+			continue
+		}
+
+		param := ""
+		if mod.Param != 0 {
+			param = strconv.Itoa(mod.Param)
+		}
+
+		item.craftedMods = append(item.craftedMods, &d2records.PropertyDescriptor{
+			Code:      mod.Code,
+			Parameter: param,
+			Min:       mod.Min,
+			Max:       mod.Max,
+		})
+	}
+}
+
+// reroll rolls the item again with the quality. Crafted and tempered items are rolled like rare items.
+func (i *Item) reroll(quality d2enum.ItemQuality) {
+	i.UniqueCode, i.SetCode, i.SetItemCode, i.RunewordCode = "", "", "", ""
+	i.PrefixCodes, i.SuffixCodes = nil, nil
+	i.craftedMods = nil
+
+	modifiers := map[d2enum.ItemQuality]dropModifier{
+		d2enum.Magic:    dropModifierMagic,
+		d2enum.Set:      dropModifierSet,
+		d2enum.Rare:     dropModifierRare,
+		d2enum.Unique:   dropModifierUnique,
+		d2enum.Crafted:  dropModifierRare,
+		d2enum.Tempered: dropModifierRare,
+	}
+
+	i.applyDropModifier(modifiers[quality])
+
+	if quality == d2enum.Crafted || quality == d2enum.Tempered {
+		i.quality = quality
+	}
+}
+
+// upgrade changes the base item to its exceptional or elite version, "upg" upgrades it by one step. Items are
+// never downgraded, an elite item stays as it is.
+func (i *Item) upgrade(to string) {
+	common := i.CommonRecord()
+	if common.Code == common.UltraCode {
+		return
+	}
+
+	code := common.UberCode
+	if to == cubeElite || (to == cubeUpgraded && common.Code == common.UberCode) {
+		code = common.UltraCode
+	}
+
+	if i.factory.asset.Records.Item.All[code] != nil {
+		i.CommonCode, i.TypeCode = code, ""
+	}
+}
+
+// unsocket removes the items from the sockets of the item, the sockets stay empty
+func (i *Item) unsocket() []*Item {
+	removed := i.sockets
+
+	i.sockets = nil
+	i.RunewordCode = ""
+
+	return removed
+}
@@ -0,0 +1,326 @@
+package diablo2item
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// testCube loads the cube recipes in testdata along with the item, gem and runeword records
+func testCube(t *testing.T) (*HoradricCube, *ItemFactory) {
+	factory := testSocketFactory(t)
+	factory.SetSeed(1)
+
+	loadTestRecords(t, factory.asset.Records, d2resource.CubeRecipes)
+
+	return NewHoradricCube(factory), factory
+}
+
+func testCubeItems(t *testing.T, factory *ItemFactory, codes ...string) []*Item {
+	items := make([]*Item, len(codes))
+
+	for idx, code := range codes {
+		item, err := factory.NewItem(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		items[idx] = item
+	}
+
+	return items
+}
+
+func TestCubeRecipes(t *testing.T) {
+	cube, factory := testCube(t)
+
+	tests := []struct {
+		items      []string
+		difficulty d2enum.DifficultyType
+		hero       d2enum.Hero
+		recipe     string
+	}{
+		{[]string{"r01", "r01", "r01"}, d2enum.DifficultyNormal, d2enum.HeroNone, "3 El Runes -> Eld Rune"},
+		{[]string{"r01", "r01"}, d2enum.DifficultyNormal, d2enum.HeroNone, ""},
+		{[]string{"r01", "r01", "r01", "r01"}, d2enum.DifficultyNormal, d2enum.HeroNone, ""},
+		{[]string{"r02", "r02"}, d2enum.DifficultyNormal, d2enum.HeroNone, ""},
+		{[]string{"r02", "r02"}, d2enum.DifficultyNightmare, d2enum.HeroNone, "2 Eld Runes -> Tir Rune"},
+		{[]string{"r03", "r03"}, d2enum.DifficultyHell, d2enum.HeroNone, ""},   // disabled
+		{[]string{"r04", "r04"}, d2enum.DifficultyNormal, d2enum.HeroNone, ""}, // ladder only
+		{[]string{"r04", "r04", "r04"}, d2enum.DifficultyNormal, d2enum.HeroPaladin, ""},
+		{[]string{"r04", "r04", "r04"}, d2enum.DifficultyNormal, d2enum.HeroAmazon, "3 Nef Runes -> El Rune"},
+		{[]string{"gcr", "qui", "r03"}, d2enum.DifficultyNormal, d2enum.HeroNone, "Socket Body Armor"},
+		{[]string{"gcr", "cap", "r03"}, d2enum.DifficultyNormal, d2enum.HeroNone, ""},
+		{[]string{"rin", "cap"}, d2enum.DifficultyNormal, d2enum.HeroNone, "Remove Socketed Items"},
+	}
+
+	for _, test := range tests {
+		cube.SetDifficulty(test.difficulty)
+		cube.SetHero(test.hero)
+
+		recipe := cube.FindRecipe(testCubeItems(t, factory, test.items...))
+
+		switch {
+		case recipe == nil && test.recipe != "":
+			t.Errorf("%v: expected the recipe %q, no recipe matched", test.items, test.recipe)
+		case recipe != nil && recipe.Description != test.recipe:
+			t.Errorf("%v: expected the recipe %q, got %q", test.items, test.recipe, recipe.Description)
+		}
+	}
+
+	if _, err := cube.Transmute(testCubeItems(t, factory, "r01")); !errors.Is(err, errNoMatchingRecipe) {
+		t.Errorf("expected %q, got %v", errNoMatchingRecipe, err)
+	}
+}
+
+func TestCubeRuneUpgrade(t *testing.T) {
+	cube, factory := testCube(t)
+
+	result, err := cube.Transmute(testCubeItems(t, factory, "r01", "r01", "r01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Outputs) != 1 || result.Outputs[0].CommonCode != "r02" {
+		t.Errorf("expected an Eld Rune, got %+v", result.Outputs)
+	}
+
+	portal, err := cube.Transmute(testCubeItems(t, factory, "r02", "r04"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if portal.Portal != "Cow Portal" || len(portal.Outputs) != 0 {
+		t.Errorf("expected the Cow Portal, got %+v", portal)
+	}
+}
+
+func TestCubeSocketPunching(t *testing.T) {
+	cube, factory := testCube(t)
+
+	items := testCubeItems(t, factory, "gcr", "qui", "r03")
+
+	result, err := cube.Transmute(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Outputs) != 1 || result.Outputs[0] != items[1] {
+		t.Fatalf("expected the armor, got %+v", result.Outputs)
+	}
+
+	if sockets := items[1].Sockets(); sockets < 1 || sockets > items[1].MaxSockets() {
+		t.Errorf("expected 1 to %d sockets, got %d", items[1].MaxSockets(), sockets)
+	}
+
+	// the armor has sockets now, so it does not match the recipe again
+	if recipe := cube.FindRecipe(items); recipe != nil {
+		t.Errorf("a socketed armor matched %q", recipe.Description)
+	}
+}
+
+func TestCubeRerollAndUpgrade(t *testing.T) {
+	cube, factory := testCube(t)
+
+	magic, err := factory.NewItem("hax", "Jagged")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rerolled, err := cube.Transmute(append(testCubeItems(t, factory, "gcr", "gcr", "gcr"), magic))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rerolled.Outputs) != 1 || rerolled.Outputs[0] == magic {
+		t.Fatalf("expected a new item, got %+v", rerolled.Outputs)
+	}
+
+	if item := rerolled.Outputs[0]; item.CommonCode != "hax" || item.Quality() != d2enum.Magic {
+		t.Errorf("expected a magic Hand Axe, got a %s %s", item.Quality(), item.CommonCode)
+	}
+
+	unique, err := factory.NewItem("hax", "The Gnasher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upgraded, err := cube.Transmute(append(testCubeItems(t, factory, "r04", "gcr"), unique))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(upgraded.Outputs) != 1 || upgraded.Outputs[0] != unique {
+		t.Fatalf("expected the unique, got %+v", upgraded.Outputs)
+	}
+
+	if unique.CommonCode != "9ha" || unique.UniqueCode != "The Gnasher" {
+		t.Errorf("expected an exceptional The Gnasher, got %s %s", unique.UniqueCode, unique.CommonCode)
+	}
+
+	// exceptional items are no longer basic
+	if recipe := cube.FindRecipe(append(testCubeItems(t, factory, "r04", "gcr"), unique)); recipe != nil {
+		t.Errorf("an exceptional unique matched %q", recipe.Description)
+	}
+}
+
+func TestCubeUpgradeTiers(t *testing.T) {
+	_, factory := testCube(t)
+
+	tests := []struct {
+		code, to, expected string
+	}{
+		{"hax", cubeExceptional, "9ha"},
+		{"hax", cubeElite, "7ha"},
+		{"hax", cubeUpgraded, "9ha"},
+		{"9ha", cubeExceptional, "9ha"},
+		{"9ha", cubeElite, "7ha"},
+		{"9ha", cubeUpgraded, "7ha"},
+		{"7ha", cubeExceptional, "7ha"},
+		{"7ha", cubeElite, "7ha"},
+		{"7ha", cubeUpgraded, "7ha"}, // an elite item is not downgraded
+	}
+
+	for _, test := range tests {
+		item := testCubeItems(t, factory, test.code)[0]
+		item.upgrade(test.to)
+
+		if item.CommonCode != test.expected {
+			t.Errorf("%s %s: expected %s, got %s", test.to, test.code, test.expected, item.CommonCode)
+		}
+	}
+}
+
+func TestCubeOutputLevel(t *testing.T) {
+	cube, factory := testCube(t)
+	cube.SetPlayerLevel(40)
+
+	first := testCubeItems(t, factory, "hax")[0]
+	first.level = 30
+
+	tests := []struct {
+		level, playerLevel, itemLevel int
+		expected                      int
+	}{
+		{0, 0, 0, 3}, // the base level of the Hand Axe
+		{10, 0, 0, 10},
+		{0, 50, 0, 20},
+		{0, 0, 50, 15},
+		{1, 50, 10, 24},
+	}
+
+	for _, test := range tests {
+		output := &d2records.CubeRecipeResult{
+			Item:   d2records.CubeRecipeItem{Code: "hax", Count: 1},
+			Level:  test.level,
+			PLevel: test.playerLevel,
+			ILevel: test.itemLevel,
+		}
+
+		items, err := cube.createOutputs(output, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if level := items[0].ItemLevel(); level != test.expected {
+			t.Errorf("lvl=%d plvl=%d ilvl=%d: expected the item level %d, got %d", test.level, test.playerLevel,
+				test.itemLevel, test.expected, level)
+		}
+	}
+
+	// the affixes are rolled at the item level, Fine needs the level 4
+	prefixes := factory.asset.Records.Item.Magic.Prefix
+	record := first.CommonRecord()
+
+	if containsAffix(factory.FindMatchingAffixes(record, 3, prefixes), "Fine") {
+		t.Error("Fine matched an item of the level 3")
+	}
+
+	if !containsAffix(factory.FindMatchingAffixes(record, 4, prefixes), "Fine") {
+		t.Error("Fine did not match an item of the level 4")
+	}
+}
+
+func containsAffix(affixes []*d2records.ItemAffixCommonRecord, name string) bool {
+	for _, affix := range affixes {
+		if affix.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestCubeCrafting(t *testing.T) {
+	cube, factory := testCube(t)
+
+	helm, err := factory.NewItem("cap", "Sturdy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := cube.Transmute(append(testCubeItems(t, factory, "jew", "r01"), helm))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Outputs) != 1 {
+		t.Fatalf("expected a crafted helm, got %+v", result.Outputs)
+	}
+
+	crafted := result.Outputs[0]
+
+	if crafted.Quality() != d2enum.Crafted || crafted.CommonCode != "cap" {
+		t.Errorf("expected a crafted Cap, got a %s %s", crafted.Quality(), crafted.CommonCode)
+	}
+
+	if !containsString(crafted.GetStatStrings(), "+5 to Strength") {
+		t.Errorf("the crafted helm does not have the mod of the recipe: %q", crafted.GetStatStrings())
+	}
+
+	if label := crafted.Label(); label != d2util.ColorTokenize(crafted.name, d2util.ColorTokenCraftedItem) {
+		t.Errorf("unexpected crafted label %q", label)
+	}
+
+	deserialized, err := factory.Deserialize(crafted.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deserialized.Quality() != d2enum.Crafted || !containsString(deserialized.GetStatStrings(), "+5 to Strength") {
+		t.Errorf("the crafted helm did not survive serialization: %q", deserialized.GetStatStrings())
+	}
+}
+
+func TestCubeRemoveSocketedItems(t *testing.T) {
+	cube, factory := testCube(t)
+
+	helm := testSocketedItem(t, factory, "cap", 2, "gcr", "r01")
+
+	result, err := cube.Transmute(append(testCubeItems(t, factory, "rin"), helm))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Outputs) != 3 || result.Outputs[0] != helm {
+		t.Fatalf("expected the helm, its gem and its rune, got %+v", result.Outputs)
+	}
+
+	if result.Outputs[1].CommonCode != "gcr" || result.Outputs[2].CommonCode != "r01" {
+		t.Errorf("expected the Chipped Ruby and the El Rune, got %s and %s", result.Outputs[1].CommonCode,
+			result.Outputs[2].CommonCode)
+	}
+
+	if len(helm.SocketedItems()) != 0 || helm.Sockets() != 2 {
+		t.Errorf("expected 2 empty sockets, got %d sockets with %d items", helm.Sockets(),
+			len(helm.SocketedItems()))
+	}
+
+	if len(helm.GetStatStrings()) != 0 {
+		t.Errorf("the helm kept the stats of its gem and rune: %q", helm.GetStatStrings())
+	}
+}
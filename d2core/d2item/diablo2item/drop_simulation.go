@@ -0,0 +1,242 @@
+package diablo2item
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+var (
+	errUnknownMonster       = errors.New("unknown monster")
+	errUnknownTreasureClass = errors.New("unknown treasure class")
+	errNoDropSource         = errors.New("a monster or a treasure class is required")
+)
+
+// the categories of the rows of a csv drop report
+const (
+	reportCategorySummary = "summary"
+	reportCategoryQuality = "quality"
+	reportCategoryItem    = "item"
+	reportCategoryPrefix  = "prefix"
+	reportCategorySuffix  = "suffix"
+	reportCategoryUnique  = "unique"
+	reportCategorySetItem = "set item"
+)
+
+const reportRatePrecision = 6
+
+// DropSimulation describes the drops to roll in a simulation
+type DropSimulation struct {
+	Monster       string // the monster which drops the items, its treasure class depends on the difficulty
+	TreasureClass string // the treasure class to roll, when no monster is given
+	MagicFind     int
+	ItemLevel     int // the level the items drop at, 0 drops them at the level of their base items
+	Difficulty    d2enum.DifficultyType
+	Drops         int // the number of times the treasure class is rolled
+	Seed          int64
+}
+
+// DropReport holds how often each quality, base item, affix, unique and set item dropped in a simulation
+type DropReport struct {
+	TreasureClass string         `json:"treasureClass"`
+	Seed          int64          `json:"seed"`
+	MagicFind     int            `json:"magicFind"`
+	Difficulty    string         `json:"difficulty"`
+	Drops         int            `json:"drops"`
+	NoDrops       int            `json:"noDrops"`
+	Items         int            `json:"items"`
+	Qualities     map[string]int `json:"qualities"`
+	BaseItems     map[string]int `json:"baseItems"`
+	Prefixes      map[string]int `json:"prefixes"`
+	Suffixes      map[string]int `json:"suffixes"`
+	Uniques       map[string]int `json:"uniques"`
+	SetItems      map[string]int `json:"setItems"`
+}
+
+// SimulateDrops rolls the treasure class of the simulation for the number of drops and counts the items. The
+// factory is seeded with the seed of the simulation, so a simulation on the same records always reports the
+// same drops.
+func (f *ItemFactory) SimulateDrops(simulation *DropSimulation) (*DropReport, error) {
+	code, err := f.simulationTreasureClass(simulation)
+	if err != nil {
+		return nil, err
+	}
+
+	tcr := f.TreasureClass(code)
+	if tcr == nil {
+		return nil, fmt.Errorf("%w: %s", errUnknownTreasureClass, code)
+	}
+
+	f.SetSeed(simulation.Seed)
+	f.SetMagicFind(simulation.MagicFind)
+
+	report := &DropReport{
+		TreasureClass: code,
+		Seed:          simulation.Seed,
+		MagicFind:     simulation.MagicFind,
+		Difficulty:    simulation.Difficulty.String(),
+		Qualities:     make(map[string]int),
+		BaseItems:     make(map[string]int),
+		Prefixes:      make(map[string]int),
+		Suffixes:      make(map[string]int),
+		Uniques:       make(map[string]int),
+		SetItems:      make(map[string]int),
+	}
+
+	for drop := 0; drop < simulation.Drops; drop++ {
+		items := f.ItemsFromTreasureClass(tcr, simulation.ItemLevel)
+		if len(items) == 0 {
+			report.NoDrops++
+		}
+
+		for _, item := range items {
+			report.add(item)
+		}
+
+		report.Drops++
+	}
+
+	return report, nil
+}
+
+// simulationTreasureClass returns the treasure class of the monster on the difficulty, or the treasure class of
+// the simulation
+func (f *ItemFactory) simulationTreasureClass(simulation *DropSimulation) (string, error) {
+	if simulation.Monster == "" {
+		if simulation.TreasureClass == "" {
+			return "", errNoDropSource
+		}
+
+		return simulation.TreasureClass, nil
+	}
+
+	monster := f.asset.Records.Monster.Stats[simulation.Monster]
+	if monster == nil {
+		return "", fmt.Errorf("%w: %s", errUnknownMonster, simulation.Monster)
+	}
+
+	return monsterTreasureClass(monster, simulation.Difficulty), nil
+}
+
+// monsterTreasureClass returns the treasure class of a regular monster on the difficulty
+func monsterTreasureClass(monster *d2records.MonStatsRecord, difficulty d2enum.DifficultyType) string {
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		return monster.TreasureClassNightmare
+	case d2enum.DifficultyHell:
+		return monster.TreasureClassHell
+	}
+
+	return monster.TreasureClassNormal
+}
+
+func (r *DropReport) add(item *Item) {
+	r.Items++
+	r.Qualities[item.Quality().String()]++
+	r.BaseItems[item.CommonCode]++
+
+	for _, code := range item.PrefixCodes {
+		r.Prefixes[code]++
+	}
+
+	for _, code := range item.SuffixCodes {
+		r.Suffixes[code]++
+	}
+
+	if item.UniqueRecord() != nil {
+		r.Uniques[item.UniqueCode]++
+	}
+
+	if item.SetItemRecord() != nil {
+		r.SetItems[item.SetItemCode]++
+	}
+}
+
+// WriteJSON writes the report as indented json
+func (r *DropReport) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(append(data, '\n'))
+
+	return err
+}
+
+// WriteCSV writes the report as csv, with a row for each count. The rate of the summary rows is relative to the
+// number of drops, the rate of the other rows to the number of items.
+func (r *DropReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write([]string{"category", "name", "count", "rate"}); err != nil {
+		return err
+	}
+
+	summary := []struct {
+		name  string
+		count int
+	}{
+		{"drops", r.Drops},
+		{"no drops", r.NoDrops},
+		{"items", r.Items},
+	}
+
+	for _, row := range summary {
+		if err := writer.Write(reportRow(reportCategorySummary, row.name, row.count, r.Drops)); err != nil {
+			return err
+		}
+	}
+
+	categories := []struct {
+		name   string
+		counts map[string]int
+	}{
+		{reportCategoryQuality, r.Qualities},
+		{reportCategoryItem, r.BaseItems},
+		{reportCategoryPrefix, r.Prefixes},
+		{reportCategorySuffix, r.Suffixes},
+		{reportCategoryUnique, r.Uniques},
+		{reportCategorySetItem, r.SetItems},
+	}
+
+	for _, category := range categories {
+		for _, name := range sortedKeys(category.counts) {
+			if err := writer.Write(reportRow(category.name, name, category.counts[name], r.Items)); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+func reportRow(category, name string, count, total int) []string {
+	rate := 0.0
+	if total > 0 {
+		rate = float64(count) / float64(total)
+	}
+
+	return []string{category, name, strconv.Itoa(count), strconv.FormatFloat(rate, 'f', reportRatePrecision, 64)}
+}
+
+func sortedKeys(counts map[string]int) []string {
+	keys := make([]string, 0, len(counts))
+
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,230 @@
+package diablo2item
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2txt"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// nolint:gochecknoglobals // test flag
+var updateGolden = flag.Bool("update", false, "update the golden files of the drop simulations")
+
+// dropRecordPaths are the records the drop simulations are rolled with, in the order they are loaded
+// nolint:gochecknoglobals // just a test
+var dropRecordPaths = []string{
+	d2resource.Weapons, d2resource.Armor, d2resource.Misc, d2resource.ItemTypes,
+	d2resource.MagicPrefix, d2resource.MagicSuffix, d2resource.RarePrefix, d2resource.RareSuffix,
+	d2resource.UniqueItems, d2resource.Sets, d2resource.SetItems, d2resource.TreasureClassEx,
+	d2resource.MonStats,
+}
+
+// testDropFactory loads the records in testdata with the record loaders, so the golden files change when
+// the loaders do
+func testDropFactory(t *testing.T) *ItemFactory {
+	records, err := d2records.NewRecordManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loadTestRecords(t, records, dropRecordPaths...)
+
+	factory, err := NewItemFactory(&d2asset.AssetManager{Records: records})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return factory
+}
+
+// loadTestRecords loads the records of the paths from the files in testdata
+func loadTestRecords(t *testing.T, records *d2records.RecordManager, recordPaths ...string) {
+	for _, recordPath := range recordPaths {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", path.Base(recordPath)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := records.Load(recordPath, d2txt.LoadDataDictionary(data)); err != nil {
+			t.Fatalf("loading %s: %s", recordPath, err)
+		}
+	}
+}
+
+func TestSimulateDropsGolden(t *testing.T) {
+	factory := testDropFactory(t)
+
+	tests := []struct {
+		golden     string
+		simulation DropSimulation
+	}{
+		{"monster_normal", DropSimulation{Monster: "zombie1", Drops: 20000, Seed: 1}},
+		{"monster_nightmare", DropSimulation{Monster: "zombie1", Difficulty: d2enum.DifficultyNightmare,
+			Drops: 20000, Seed: 2}},
+		{"treasure_class_magic_find", DropSimulation{TreasureClass: "Act 1 Champ A", MagicFind: 300,
+			Drops: 20000, Seed: 3}},
+	}
+
+	for _, test := range tests {
+		simulation := test.simulation
+
+		report, err := factory.SimulateDrops(&simulation)
+		if err != nil {
+			t.Fatalf("%s: %s", test.golden, err)
+		}
+
+		buf := &bytes.Buffer{}
+		if err := report.WriteJSON(buf); err != nil {
+			t.Fatal(err)
+		}
+
+		goldenPath := filepath.Join("testdata", test.golden+".golden.json")
+
+		if *updateGolden {
+			if err := ioutil.WriteFile(goldenPath, buf.Bytes(), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		golden, err := ioutil.ReadFile(goldenPath)
+		if err != nil {
+			t.Fatalf("%s: %s, run the tests with -update to create it", test.golden, err)
+		}
+
+		if !bytes.Equal(buf.Bytes(), golden) {
+			t.Errorf("%s: the drops differ from %s, run the tests with -update if the change is intended:\n%s",
+				test.golden, goldenPath, buf.String())
+		}
+	}
+}
+
+func TestSimulateDropsDeterministic(t *testing.T) {
+	simulation := &DropSimulation{TreasureClass: "Act 1 H2H A", Drops: 2000, Seed: 42}
+
+	first, err := testDropFactory(t).SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := testDropFactory(t).SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("the same simulation reported different drops:\n%+v\n%+v", first, second)
+	}
+
+	simulation.Seed = 43
+
+	other, err := testDropFactory(t).SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if reflect.DeepEqual(first, other) {
+		t.Error("simulations with different seeds reported the same drops")
+	}
+}
+
+func TestSimulateDropsMagicFind(t *testing.T) {
+	factory := testDropFactory(t)
+	simulation := &DropSimulation{TreasureClass: "Act 1 Equip A", Drops: 5000, Seed: 7}
+
+	without, err := factory.SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simulation.TreasureClass = "Act 1 H2H A"
+
+	base, err := factory.SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	simulation.MagicFind = 500
+
+	found, err := factory.SimulateDrops(simulation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if without.Qualities[d2enum.Normal.String()] != without.Items {
+		t.Errorf("a treasure class without drop modifiers dropped %v", without.Qualities)
+	}
+
+	for _, quality := range []d2enum.ItemQuality{d2enum.Unique, d2enum.Set, d2enum.Magic} {
+		if found.Qualities[quality.String()] <= base.Qualities[quality.String()] {
+			t.Errorf("magic find did not raise the %s drops: %d, without %d", quality,
+				found.Qualities[quality.String()], base.Qualities[quality.String()])
+		}
+	}
+
+	if found.Uniques["Deathspade"] > 0 || found.Prefixes["Bronze"] > 0 {
+		t.Error("disabled uniques or affixes which can not spawn dropped")
+	}
+}
+
+func TestSimulateDropsErrors(t *testing.T) {
+	factory := testDropFactory(t)
+
+	tests := []struct {
+		simulation DropSimulation
+		err        error
+	}{
+		{DropSimulation{Monster: "diablo"}, errUnknownMonster},
+		{DropSimulation{TreasureClass: "Act 6 Equip A"}, errUnknownTreasureClass},
+		{DropSimulation{}, errNoDropSource},
+	}
+
+	for _, test := range tests {
+		simulation := test.simulation
+
+		if _, err := factory.SimulateDrops(&simulation); !errors.Is(err, test.err) {
+			t.Errorf("%+v: expected %q, got %v", simulation, test.err, err)
+		}
+	}
+}
+
+func TestDropReportCSV(t *testing.T) {
+	report := &DropReport{
+		Drops:     4,
+		NoDrops:   2,
+		Items:     2,
+		Qualities: map[string]int{"Unique": 1, "Magic": 1},
+		BaseItems: map[string]int{"cap": 2},
+		Prefixes:  map[string]int{"Sturdy": 1},
+		Uniques:   map[string]int{"Biggin's Bonnet": 1},
+	}
+
+	expected := "category,name,count,rate\n" +
+		"summary,drops,4,1.000000\n" +
+		"summary,no drops,2,0.500000\n" +
+		"summary,items,2,0.500000\n" +
+		"quality,Magic,1,0.500000\n" +
+		"quality,Unique,1,0.500000\n" +
+		"item,cap,2,1.000000\n" +
+		"prefix,Sturdy,1,0.500000\n" +
+		"unique,Biggin's Bonnet,1,0.500000\n"
+
+	buf := &bytes.Buffer{}
+	if err := report.WriteCSV(buf); err != nil {
+		t.Fatal(err)
+	}
+
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
@@ -10,9 +10,10 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 )
 
 // PropertyPool is used for separating properties by their source
@@ -25,8 +26,24 @@ const (
 	PropertyPoolUnique
 	PropertyPoolSetItem
 	PropertyPoolSet
+	PropertyPoolSocket
+	PropertyPoolRuneword
+	PropertyPoolCrafted
 )
 
+// propertyPools are all property pools, in the order their properties are generated
+// nolint:gochecknoglobals // a constant list
+var propertyPools = []PropertyPool{
+	PropertyPoolPrefix,
+	PropertyPoolSuffix,
+	PropertyPoolUnique,
+	PropertyPoolSetItem,
+	PropertyPoolSet,
+	PropertyPoolSocket,
+	PropertyPoolRuneword,
+	PropertyPoolCrafted,
+}
+
 // for handling special cases
 const (
 	jewelItemCode          = "jew"
@@ -52,6 +69,9 @@ const (
 // static check to ensure Item implements Item
 var _ d2item.Item = &Item{}
 
+// static check to ensure Item implements the inventory item
+var _ d2inventory.InventoryItem = &Item{}
+
 // Item is a representation of a diablo2 item
 // nolint:structcheck,unused // WIP
 type Item struct {
@@ -61,14 +81,17 @@ type Item struct {
 	rand    *rand.Rand // non-global rand instance for re-generating the item
 
 	slotType d2enum.EquippedSlot
-
-	TypeCode    string
-	CommonCode  string
-	UniqueCode  string
-	SetCode     string
-	SetItemCode string
-	PrefixCodes []string
-	SuffixCodes []string
+	quality  d2enum.ItemQuality // the quality of the drop modifier the item was rolled with
+	level    int                // the item level, 0 for the level of the base item, see ItemLevel
+
+	TypeCode     string
+	CommonCode   string
+	UniqueCode   string
+	SetCode      string
+	SetItemCode  string
+	PrefixCodes  []string
+	SuffixCodes  []string
+	RunewordCode string
 
 	properties      map[PropertyPool][]*Property
 	statContext     d2item.StatContext
@@ -81,14 +104,14 @@ type Item struct {
 	GridX int
 	GridY int
 
-	sockets []*d2item.Item // there will be checks for handling the craziness this might entail
+	craftedMods []*d2records.PropertyDescriptor // the mods of the cube recipe which crafted the item
+
+	numSockets int
+	sockets    []*Item // the gems, runes and jewels in the sockets, in the order they were inserted
 }
 
 // nolint:structcheck,unused // WIP
 type itemAttributes struct {
-	worldSprite     *d2ui.Sprite
-	inventorySprite *d2ui.Sprite
-
 	damageOneHand minMaxEnhanceable
 	damageTwoHand minMaxEnhanceable
 	damageMissile minMaxEnhanceable
@@ -132,34 +155,55 @@ func (i *Item) Label() string {
 	}
 
 	if i.attributes.crafted {
-		return d2ui.ColorTokenize(str, d2ui.ColorTokenCraftedItem)
+		return d2util.ColorTokenize(str, d2util.ColorTokenCraftedItem)
 	}
 
 	if i.SetItemRecord() != nil {
-		return d2ui.ColorTokenize(str, d2ui.ColorTokenSetItem)
+		return d2util.ColorTokenize(str, d2util.ColorTokenSetItem)
 	}
 
-	if i.UniqueRecord() != nil {
-		return d2ui.ColorTokenize(str, d2ui.ColorTokenUniqueItem)
+	if i.UniqueRecord() != nil || i.RunewordRecord() != nil {
+		return d2util.ColorTokenize(str, d2util.ColorTokenUniqueItem)
 	}
 
 	numAffixes := len(i.PrefixRecords()) + len(i.SuffixRecords())
 
 	if numAffixes > 0 && numAffixes <= maxAffixesOnMagicItem {
-		return d2ui.ColorTokenize(str, d2ui.ColorTokenMagicItem)
+		return d2util.ColorTokenize(str, d2util.ColorTokenMagicItem)
 	}
 
 	if numAffixes > maxAffixesOnMagicItem {
-		return d2ui.ColorTokenize(str, d2ui.ColorTokenRareItem)
+		return d2util.ColorTokenize(str, d2util.ColorTokenRareItem)
 	}
 
-	if i.sockets != nil {
-		if len(i.sockets) > 0 {
-			return d2ui.ColorTokenize(str, d2ui.ColorTokenSocketedItem)
-		}
+	if i.numSockets > 0 {
+		return d2util.ColorTokenize(str, d2util.ColorTokenSocketedItem)
+	}
+
+	return d2util.ColorTokenize(str, d2util.ColorTokenNormalItem)
+}
+
+// Quality returns the quality of the item, items which were not rolled from a treasure class
+// get it from their records and affixes
+func (i *Item) Quality() d2enum.ItemQuality {
+	if i.quality != 0 {
+		return i.quality
 	}
 
-	return d2ui.ColorTokenize(str, d2ui.ColorTokenNormalItem)
+	numAffixes := len(i.PrefixCodes) + len(i.SuffixCodes)
+
+	switch {
+	case i.SetItemRecord() != nil:
+		return d2enum.Set
+	case i.UniqueRecord() != nil:
+		return d2enum.Unique
+	case numAffixes > maxAffixesOnMagicItem:
+		return d2enum.Rare
+	case numAffixes > 0:
+		return d2enum.Magic
+	}
+
+	return d2enum.Normal
 }
 
 // Context returns the statContext that is being used to evaluate stats. for example,
@@ -180,9 +224,14 @@ func (i *Item) ItemType() string {
 	return i.TypeCode
 }
 
-// ItemLevel returns the level of item
+// ItemLevel returns the item level, which is the level of the monster or area that dropped the item. Items that
+// were not dropped have the level of their base item.
 func (i *Item) ItemLevel() int {
-	return i.attributes.baseItemLevel
+	if common := i.CommonRecord(); i.level == 0 && common != nil {
+		return common.Level
+	}
+
+	return i.level
 }
 
 // TypeRecord returns the ItemTypeRecord of the item
@@ -248,6 +297,15 @@ func (i *Item) StatList() d2stats.StatList {
 	return i.statList
 }
 
+// Defense returns the base defense of the item, before the enhanced defense of its stats
+func (i *Item) Defense() int {
+	if i.attributes == nil {
+		return 0
+	}
+
+	return i.attributes.defense
+}
+
 // Description returns the full description string for the item
 func (i *Item) Description() string {
 	return ""
@@ -268,6 +326,8 @@ func (i *Item) applyDropModifier(modifier dropModifier) {
 			i.applyDropModifier(dropModifierRare)
 			return
 		}
+
+		i.quality = d2enum.Unique
 	case dropModifierSet:
 		i.pickSetRecords()
 
@@ -275,12 +335,19 @@ func (i *Item) applyDropModifier(modifier dropModifier) {
 			i.applyDropModifier(dropModifierRare)
 			return
 		}
-	case dropModifierRare, dropModifierMagic:
+
+		i.quality = d2enum.Set
+	case dropModifierRare:
 		// the method of picking stays the same for magic/rare
 		// but magic gets to pick more, and jewels have a special
 		// way of picking affixes
 		i.pickMagicAffixes(modifier)
+		i.quality = d2enum.Rare
+	case dropModifierMagic:
+		i.pickMagicAffixes(modifier)
+		i.quality = d2enum.Magic
 	case dropModifierNone:
+		i.quality = d2enum.Normal
 	default:
 		return
 	}
@@ -313,7 +380,7 @@ func (i *Item) pickUniqueRecord() {
 	matches := i.findMatchingUniqueRecords(i.CommonRecord())
 	if len(matches) > 0 {
 		match := matches[i.rand.Intn(len(matches))]
-		i.UniqueCode = match.Code
+		i.UniqueCode = match.Name
 	}
 }
 
@@ -354,7 +421,7 @@ func (i *Item) pickMagicAffixes(mod dropModifier) {
 	}
 
 	prefixes := i.factory.asset.Records.Item.Magic.Prefix
-	suffixes := i.factory.asset.Records.Item.Magic.Prefix
+	suffixes := i.factory.asset.Records.Item.Magic.Suffix
 
 	i.PrefixCodes = i.pickRandomAffixes(numPrefixes, totalAffixes, prefixes)
 	i.SuffixCodes = i.pickRandomAffixes(numSuffixes, totalAffixes, suffixes)
@@ -365,7 +432,7 @@ func (i *Item) pickRandomAffixes(max, totalMax int,
 	pickedCodes := make([]string, 0)
 
 	for numPicks := 0; numPicks < max; numPicks++ {
-		matches := i.factory.FindMatchingAffixes(i.CommonRecord(), affixMap)
+		matches := i.factory.FindMatchingAffixes(i.CommonRecord(), i.ItemLevel(), affixMap)
 
 		// flip a coin for whether to get an affix on this pick
 		if coinToss := i.rand.Intn(sidesOnACoin) > 0; coinToss {
@@ -399,30 +466,56 @@ func (i *Item) init() *Item {
 		i.SetSeed(0)
 	}
 
+	if common := i.CommonRecord(); common != nil && i.TypeCode == "" {
+		i.TypeCode = common.Type
+	}
+
+	if set := i.SetItemRecord(); set != nil {
+		i.SetCode = set.SetKey
+	}
+
 	i.generateAllProperties()
 	i.updateItemAttributes()
+	i.updateStatList()
 
 	return i
 }
 
+// updateStatList combines the stats of all properties of the item into its stat list
+func (i *Item) updateStatList() {
+	i.statList = i.factory.stat.NewStatList(i.propertyStats()...).ReduceStats()
+}
+
+// propertyStats returns the stats of all properties of the item
+func (i *Item) propertyStats() []d2stats.Stat {
+	stats := make([]d2stats.Stat, 0)
+
+	for pool := range i.properties {
+		for _, prop := range i.properties[pool] {
+			if prop == nil {
+				continue
+			}
+
+			stats = append(stats, prop.stats...)
+		}
+	}
+
+	return stats
+}
+
 func (i *Item) generateAllProperties() {
 	if i.attributes == nil {
 		i.attributes = &itemAttributes{}
 	}
 
+	// the properties of codes the item no longer has are not kept
+	i.properties = nil
+
 	// these will get updated by any generated properties
 	i.attributes.ethereal = false
 	i.attributes.indestructable = false
 
-	pools := []PropertyPool{
-		PropertyPoolPrefix,
-		PropertyPoolSuffix,
-		PropertyPoolUnique,
-		PropertyPoolSetItem,
-		PropertyPoolSet,
-	}
-
-	for _, pool := range pools {
+	for _, pool := range propertyPools {
 		i.generateProperties(pool)
 	}
 }
@@ -443,6 +536,18 @@ func (i *Item) generateProperties(pool PropertyPool) {
 		if generated := i.generateSetItemProperties(); generated != nil {
 			props = generated
 		}
+	case PropertyPoolSocket:
+		if generated := i.generateSocketProperties(); generated != nil {
+			props = generated
+		}
+	case PropertyPoolRuneword:
+		if generated := i.generateRunewordProperties(); generated != nil {
+			props = generated
+		}
+	case PropertyPoolCrafted:
+		if len(i.craftedMods) > 0 {
+			props = i.generateItemProperties(i.craftedMods)
+		}
 	case PropertyPoolSet: // https://github.com/OpenDiablo2/OpenDiablo2/issues/817
 	}
 
@@ -502,10 +607,15 @@ func (i *Item) updateItemAttributes() {
 		requiredLevel:     r.RequiredLevel,
 		requiredStrength:  r.RequiredStrength,
 		requiredDexterity: r.RequiredDexterity,
+		numSockets:        i.numSockets,
+		crafted:           i.quality == d2enum.Crafted,
 		durable:           !r.NoDurability,
 		throwable:         r.Throwable,
 	}
 
+	// the base attributes are rolled from the seed, so they are the same when the item is generated again
+	i.rand.Seed(i.Seed)
+
 	def, minDef, maxDef := 0, r.MinAC, r.MaxAC
 
 	if maxDef < minDef {
@@ -578,36 +688,19 @@ func (i *Item) generateSetItemProperties() []*Property {
 }
 
 func (i *Item) generateItemProperties(properties []*d2records.PropertyDescriptor) []*Property {
-	result := make([]*Property, 0)
-
-	for propIdx := range properties {
-		setProp := properties[propIdx]
-
-		// like with unique records, the property param is sometimes a skill name
-		// as a string, not an integer index
-		paramStr := getStringComponent(setProp.Parameter)
-		paramInt := getNumericComponent(setProp.Parameter)
-
-		if paramStr != "" {
-			for skillID := range i.factory.asset.Records.Skill.Details {
-				if i.factory.asset.Records.Skill.Details[skillID].Skill == paramStr {
-					paramInt = skillID
-				}
-			}
-		}
+	return i.factory.propertiesFromDescriptors(properties)
+}
 
-		prop := i.factory.NewProperty(setProp.Code, paramInt, setProp.Min, setProp.Max)
-		if prop == nil {
-			continue
+func (i *Item) generateName() {
+	if record := i.RunewordRecord(); record != nil {
+		// the name of a runeword is in the string tables, the rune name of the record is more of a note
+		if i.name = i.factory.asset.TranslateString(record.Name); i.name == record.Name {
+			i.name = record.RuneName
 		}
 
-		result = append(result, prop)
+		return
 	}
 
-	return result
-}
-
-func (i *Item) generateName() {
 	if i.SetItemRecord() != nil {
 		i.name = i.factory.asset.TranslateString(i.SetItemRecord().SetItemKey)
 		return
@@ -668,26 +761,7 @@ func (i *Item) generateName() {
 // GetStatStrings is a test function for getting all stat strings
 func (i *Item) GetStatStrings() []string {
 	result := make([]string, 0)
-	stats := make([]d2stats.Stat, 0)
-
-	for pool := range i.properties {
-		propPool := i.properties[pool]
-		if propPool == nil {
-			continue
-		}
-
-		for propIdx := range propPool {
-			if propPool[propIdx] == nil {
-				continue
-			}
-
-			prop := propPool[propIdx]
-
-			for statIdx := range prop.stats {
-				stats = append(stats, prop.stats[statIdx])
-			}
-		}
-	}
+	stats := i.propertyStats()
 
 	if len(stats) > 0 {
 		stats = i.factory.stat.NewStatList(stats...).ReduceStats().Stats()
@@ -705,48 +779,45 @@ func (i *Item) GetStatStrings() []string {
 	return result
 }
 
+// find possible UniqueItemRecords that the given ItemCommonRecord can have, sorted by their name
 func (i *Item) findMatchingUniqueRecords(icr *d2records.ItemCommonRecord) []*d2records.UniqueItemRecord {
 	result := make([]*d2records.UniqueItemRecord, 0)
 
-	c1, c2, c3, c4 := icr.Code, icr.NormalCode, icr.UberCode, icr.UltraCode
-
-	for uCode := range i.factory.asset.Records.Item.Unique {
-		uRec := i.factory.asset.Records.Item.Unique[uCode]
-
-		switch uCode {
-		case c1, c2, c3, c4:
+	for _, uRec := range i.factory.asset.Records.Item.Unique {
+		if uRec.Enabled && uRec.Code == icr.Code {
 			result = append(result, uRec)
 		}
 	}
 
+	sort.Slice(result, func(a, b int) bool { return result[a].Name < result[b].Name })
+
 	return result
 }
 
-// find possible SetItemRecords that the given ItemCommonRecord can have
+// find possible SetItemRecords that the given ItemCommonRecord can have, sorted by their key
 func (i *Item) findMatchingSetItemRecords(icr *d2records.ItemCommonRecord) []*d2records.SetItemRecord {
 	result := make([]*d2records.SetItemRecord, 0)
 
-	c1, c2, c3, c4 := icr.Code, icr.NormalCode, icr.UberCode, icr.UltraCode
-
-	for setItemIdx := range i.factory.asset.Records.Item.SetItems {
-		switch i.factory.asset.Records.Item.SetItems[setItemIdx].ItemCode {
-		case c1, c2, c3, c4:
-			result = append(result, i.factory.asset.Records.Item.SetItems[setItemIdx])
+	for _, setItem := range i.factory.asset.Records.Item.SetItems {
+		if setItem.ItemCode == icr.Code {
+			result = append(result, setItem)
 		}
 	}
 
+	sort.Slice(result, func(a, b int) bool { return result[a].SetItemKey < result[b].SetItemKey })
+
 	return result
 }
 
 // these functions are to satisfy the inventory grid item interface
 
-// GetInventoryItemName returns the item name
-func (i *Item) GetInventoryItemName() string {
+// InventoryItemName returns the item name
+func (i *Item) InventoryItemName() string {
 	return i.Label()
 }
 
-// GetInventoryItemType returns whether the item is a weapon, armor, or misc item
-func (i *Item) GetInventoryItemType() d2enum.InventoryItemType {
+// InventoryItemType returns whether the item is a weapon, armor, or misc item
+func (i *Item) InventoryItemType() d2enum.InventoryItemType {
 	typeCode := i.TypeRecord().Code
 
 	armorEquiv := i.factory.asset.Records.Item.Equivalency["armo"]
@@ -778,11 +849,6 @@ func (i *Item) GetItemCode() string {
 	return i.CommonRecord().Code
 }
 
-// Serialize the item to a byte slize
-func (i *Item) Serialize() []byte {
-	panic("item serialization not yet implemented")
-}
-
 // InventoryGridSlot returns the inventory grid slot x and y
 func (i *Item) InventoryGridSlot() (x, y int) {
 	return i.GridX, i.GridY
@@ -793,11 +859,6 @@ func (i *Item) SetInventoryGridSlot(x, y int) {
 	i.GridX, i.GridY = x, y
 }
 
-// GetInventoryGridSize returns the inventory grid size in grid units
-func (i *Item) GetInventoryGridSize() (x, y int) {
-	return i.GridX, i.GridY
-}
-
 // Identify sets the identified attribute of the item
 func (i *Item) Identify() *Item {
 	i.attributes.identitified = true
@@ -823,6 +884,7 @@ const (
 	damageThrow  = "ItemStats1n" // "Throw Damage:",
 	damageSmite  = "ItemStats1o" // "Smite Damage:",
 	reqLevel     = "ItemStats1p" // "Required Level:",
+	socketed     = "Socketable"  // "Socketed (%i)",
 )
 
 // GetItemDescription gets the complete item description as a slice of strings.
@@ -836,11 +898,16 @@ func (i *Item) GetItemDescription() []string {
 
 	str := ""
 
+	if i.RunewordRecord() != nil {
+		str = d2util.ColorTokenize(fmt.Sprintf("'%s'", i.runeLetters()), d2util.ColorTokenUniqueItem)
+		lines = append(lines, str)
+	}
+
 	if common.MinAC > 0 {
 		min, max := common.MinAC, common.MaxAC
 		str = fmt.Sprintf("%s %v %s %v", i.factory.asset.TranslateString(defense), min,
 			i.factory.asset.TranslateString(to), max)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
@@ -848,7 +915,7 @@ func (i *Item) GetItemDescription() []string {
 		min, max := common.MinDamage, common.MaxDamage
 		str = fmt.Sprintf("%s %v %s %v", i.factory.asset.TranslateString(damage1h), min,
 			i.factory.asset.TranslateString(to), max)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
@@ -856,7 +923,7 @@ func (i *Item) GetItemDescription() []string {
 		min, max := common.Min2HandDamage, common.Max2HandDamage
 		str = fmt.Sprintf("%s %v %s %v", i.factory.asset.TranslateString(damage2h), min,
 			i.factory.asset.TranslateString(to), max)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
@@ -864,34 +931,40 @@ func (i *Item) GetItemDescription() []string {
 		min, max := common.MinMissileDamage, common.MaxMissileDamage
 		str = fmt.Sprintf("%s %v %s %v", i.factory.asset.TranslateString(damageThrow), min,
 			i.factory.asset.TranslateString(to), max)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
 	if common.RequiredStrength > 1 {
 		str = fmt.Sprintf("%s %v", i.factory.asset.TranslateString(reqStrength),
 			common.RequiredStrength)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
 	if common.RequiredDexterity > 1 {
 		str = fmt.Sprintf("%s %v", i.factory.asset.TranslateString(reqDexterity),
 			common.RequiredDexterity)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
 	if common.RequiredLevel > 1 {
 		str = fmt.Sprintf("%s %v", i.factory.asset.TranslateString(reqLevel), common.RequiredLevel)
-		str = d2ui.ColorTokenize(str, d2ui.ColorTokenWhite)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenWhite)
 		lines = append(lines, str)
 	}
 
 	statStrings := i.GetStatStrings()
 
 	for _, statStr := range statStrings {
-		str = d2ui.ColorTokenize(statStr, d2ui.ColorTokenBlue)
+		str = d2util.ColorTokenize(statStr, d2util.ColorTokenBlue)
+		lines = append(lines, str)
+	}
+
+	if i.numSockets > 0 {
+		str = strings.Replace(i.factory.asset.TranslateString(socketed), "%i", strconv.Itoa(i.numSockets), 1)
+		str = d2util.ColorTokenize(str, d2util.ColorTokenBlue)
 		lines = append(lines, str)
 	}
 
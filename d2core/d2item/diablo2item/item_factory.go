@@ -4,8 +4,10 @@ import (
 	"errors"
 	"math/rand"
 	"regexp"
+	"sort"
 	"strconv"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
@@ -21,6 +23,14 @@ const (
 	dropModifierBaseProbability = 1024 // base dropModifier probability total
 )
 
+// magic find has diminishing returns for uniques, sets and rares, the effective magic find approaches these
+const (
+	magicFindUniqueFactor = 250
+	magicFindSetFactor    = 500
+	magicFindRareFactor   = 600
+	magicFindPercent      = 100
+)
+
 type dropModifier int
 
 const (
@@ -42,6 +52,12 @@ const (
 	goldItemCode         = "gld"
 )
 
+//nolint:gochecknoglobals // the patterns are only compiled once
+var (
+	numericPattern    = regexp.MustCompile(`\d+`)
+	nonNumericPattern = regexp.MustCompile(`\D`)
+)
+
 // NewItemFactory creates a new ItemFactory instance
 func NewItemFactory(asset *d2asset.AssetManager) (*ItemFactory, error) {
 	itemFactory := &ItemFactory{
@@ -63,11 +79,12 @@ func NewItemFactory(asset *d2asset.AssetManager) (*ItemFactory, error) {
 
 // ItemFactory is a diablo 2 implementation of an item generator
 type ItemFactory struct {
-	asset  *d2asset.AssetManager
-	stat   *diablo2stats.StatFactory
-	rand   *rand.Rand
-	source rand.Source
-	Seed   int64
+	asset     *d2asset.AssetManager
+	stat      *diablo2stats.StatFactory
+	rand      *rand.Rand
+	source    rand.Source
+	Seed      int64
+	magicFind int
 }
 
 // SetSeed sets the item generator seed
@@ -80,6 +97,11 @@ func (f *ItemFactory) SetSeed(seed int64) {
 	f.Seed = seed
 }
 
+// SetMagicFind sets the magic find, in percent, the drop modifiers of items are rolled with
+func (f *ItemFactory) SetMagicFind(magicFind int) {
+	f.magicFind = magicFind
+}
+
 // NewItem creates a new item instance from the given codes
 func (f *ItemFactory) NewItem(codes ...string) (*Item, error) {
 	var common, set, unique string
@@ -160,37 +182,95 @@ func (f *ItemFactory) NewProperty(code string, values ...int) *Property {
 	return result.init()
 }
 
-func (f *ItemFactory) rollDropModifier(tcr *d2records.TreasureClassRecord) dropModifier {
-	modMap := map[int]dropModifier{
-		0: dropModifierNone,
-		1: dropModifierUnique,
-		2: dropModifierSet,
-		3: dropModifierRare,
-		4: dropModifierMagic,
-	}
+// propertiesFromDescriptors creates the properties of the property descriptors of unique, set item and set
+// records, descriptors without a known property are skipped
+func (f *ItemFactory) propertiesFromDescriptors(descriptors []*d2records.PropertyDescriptor) []*Property {
+	result := make([]*Property, 0)
 
-	dropModifiers := []int{
-		dropModifierBaseProbability,
-		tcr.FreqUnique,
-		tcr.FreqSet,
-		tcr.FreqRare,
-		tcr.FreqMagic,
-	}
+	for _, descriptor := range descriptors {
+		if descriptor == nil || descriptor.Code == "" {
+			continue
+		}
 
-	for idx := range dropModifiers {
-		if idx == 0 {
+		// like with unique records, the property param is sometimes a skill name
+		// as a string, not an integer index
+		paramStr := getStringComponent(descriptor.Parameter)
+		paramInt := getNumericComponent(descriptor.Parameter)
+
+		if paramStr != "" {
+			for skillID := range f.asset.Records.Skill.Details {
+				if f.asset.Records.Skill.Details[skillID].Skill == paramStr {
+					paramInt = skillID
+				}
+			}
+		}
+
+		prop := f.NewProperty(descriptor.Code, paramInt, descriptor.Min, descriptor.Max)
+		if prop == nil {
 			continue
 		}
 
-		dropModifiers[idx] += dropModifiers[idx-1]
+		result = append(result, prop)
 	}
 
-	roll := f.rand.Intn(dropModifiers[len(dropModifiers)-1])
+	return result
+}
+
+// dropRatios are the frequencies of the drop modifiers of a chain of treasure classes. The items of nested
+// treasure classes are rolled with the highest frequency of each modifier along the chain.
+type dropRatios struct {
+	unique, set, rare, magic int
+}
 
-	for idx := range dropModifiers {
-		if roll < dropModifiers[idx] {
-			return modMap[idx]
+func (r dropRatios) merge(tcr *d2records.TreasureClassRecord) dropRatios {
+	return dropRatios{
+		unique: d2math.MaxInt(r.unique, tcr.FreqUnique),
+		set:    d2math.MaxInt(r.set, tcr.FreqSet),
+		rare:   d2math.MaxInt(r.rare, tcr.FreqRare),
+		magic:  d2math.MaxInt(r.magic, tcr.FreqMagic),
+	}
+}
+
+// effectiveMagicFind returns the magic find with the diminishing returns of the factor
+func effectiveMagicFind(magicFind, factor int) int {
+	if magicFind <= 0 {
+		return magicFind
+	}
+
+	return magicFind * factor / (magicFind + factor)
+}
+
+// withMagicFind scales the frequency of a drop modifier with the magic find
+func withMagicFind(frequency, magicFind int) int {
+	return frequency * (magicFindPercent + magicFind) / magicFindPercent
+}
+
+// rollDropModifier rolls for the modifiers one after another, each against the total frequency of itself, the
+// modifiers after it and the base probability of no modifier. Without magic find this is the same as a
+// weighted pick among them, magic find raises the chance of each roll.
+func (f *ItemFactory) rollDropModifier(ratios dropRatios) dropModifier {
+	modifiers := []struct {
+		modifier  dropModifier
+		frequency int
+		magicFind int
+	}{
+		{dropModifierUnique, ratios.unique, effectiveMagicFind(f.magicFind, magicFindUniqueFactor)},
+		{dropModifierSet, ratios.set, effectiveMagicFind(f.magicFind, magicFindSetFactor)},
+		{dropModifierRare, ratios.rare, effectiveMagicFind(f.magicFind, magicFindRareFactor)},
+		{dropModifierMagic, ratios.magic, f.magicFind},
+	}
+
+	remaining := dropModifierBaseProbability
+	for _, mod := range modifiers {
+		remaining += mod.frequency
+	}
+
+	for _, mod := range modifiers {
+		if mod.frequency > 0 && f.rand.Intn(remaining) < withMagicFind(mod.frequency, mod.magicFind) {
+			return mod.modifier
 		}
+
+		remaining -= mod.frequency
 	}
 
 	return dropModifierNone
@@ -222,10 +302,54 @@ func (f *ItemFactory) rollTreasurePick(tcr *d2records.TreasureClassRecord) *d2re
 	return nil
 }
 
-// ItemsFromTreasureClass rolls for and creates items using a treasure class record
-func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord) []*Item {
+// ItemsFromTreasureClass rolls for and creates items using a treasure class record. The items drop at the item
+// level, the level of the monster or area that drops them, an item level of 0 drops them at the level of their
+// base items.
+func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord, itemLevel int) []*Item {
+	return f.itemsFromTreasureClass(tcr, dropRatios{}, itemLevel)
+}
+
+// TreasureClass returns the treasure class record of the code, from the classic or the expansion treasure
+// classes
+func (f *ItemFactory) TreasureClass(code string) *d2records.TreasureClassRecord {
+	if record, found := f.asset.Records.Item.Treasure.Normal[code]; found {
+		return record
+	}
+
+	return f.asset.Records.Item.Treasure.Expansion[code]
+}
+
+func (f *ItemFactory) itemsFromTreasureClass(tcr *d2records.TreasureClassRecord, ratios dropRatios,
+	itemLevel int) []*Item {
 	result := make([]*Item, 0)
+	ratios = ratios.merge(tcr)
 
+	// for each of our picked/rolled treasures, we will attempt to generate an item.
+	// The treasure may actually be a reference to another treasure class, in which
+	// case we will roll that treasure class, eventually getting a slice of items
+	for _, picked := range f.pickTreasures(tcr) {
+		if record := f.TreasureClass(picked.Code); record != nil {
+			// the code is for a treasure class, we roll again using that TC
+			result = append(result, f.itemsFromTreasureClass(record, ratios, itemLevel)...)
+			continue
+		}
+
+		// the code is not for a treasure class, but for an item
+		item := f.ItemFromTreasure(picked)
+		if item != nil {
+			item.level = itemLevel
+			item.applyDropModifier(f.rollDropModifier(ratios))
+			item.rollSockets()
+			item.init()
+			result = append(result, item)
+		}
+	}
+
+	return result
+}
+
+// pickTreasures picks the treasures of the treasure class, without the picks which do not drop anything
+func (f *ItemFactory) pickTreasures(tcr *d2records.TreasureClassRecord) []*d2records.Treasure {
 	treasurePicks := make([]*d2records.Treasure, 0)
 
 	// if tcr.NumPicks is negative, each item probability is instead a count for how many
@@ -242,52 +366,29 @@ func (f *ItemFactory) ItemsFromTreasureClass(tcr *d2records.TreasureClassRecord)
 				picksLeft++
 			}
 		}
-	} else {
-		// for N picks, we roll for a treasure and append to our treasures if it isn't a NoDrop
-		for picksLeft := tcr.NumPicks; picksLeft > 0; picksLeft-- {
-			rolledTreasure := f.rollTreasurePick(tcr)
 
-			if rolledTreasure == nil {
-				continue
-			}
-
-			treasurePicks = append(treasurePicks, rolledTreasure)
-		}
+		return treasurePicks
 	}
 
-	// for each of our picked/rolled treasures, we will attempt to generate an item.
-	// The treasure may actually be a reference to another treasure class, in which
-	// case we will roll that treasure class, eventually getting a slice of items
-	for idx := range treasurePicks {
-		picked := treasurePicks[idx]
-		if record, found := f.asset.Records.Item.Treasure.Normal[picked.Code]; found {
-			// the code is for a treasure class, we roll again using that TC
-			itemSlice := f.ItemsFromTreasureClass(record)
-			for itemIdx := range itemSlice {
-				itemSlice[itemIdx].applyDropModifier(f.rollDropModifier(tcr))
-				itemSlice[itemIdx].init()
-				result = append(result, itemSlice[itemIdx])
-			}
-		} else {
-			// the code is not for a treasure class, but for an item
-			item := f.ItemFromTreasure(picked)
-			if item != nil {
-				item.applyDropModifier(f.rollDropModifier(tcr))
-				item.init()
-				result = append(result, item)
-			}
+	// for N picks, we roll for a treasure and append to our treasures if it isn't a NoDrop
+	for picksLeft := tcr.NumPicks; picksLeft > 0; picksLeft-- {
+		rolledTreasure := f.rollTreasurePick(tcr)
+
+		if rolledTreasure == nil {
+			continue
 		}
+
+		treasurePicks = append(treasurePicks, rolledTreasure)
 	}
 
-	return result
+	return treasurePicks
 }
 
-// ItemFromTreasure rolls for a f.rand.m item using the Treasure struct (from d2datadict)
+// ItemFromTreasure rolls for a f.rand.m item using the Treasure struct (from d2datadict).
+// The seed of the item is rolled as well, so the items of a seeded factory are repeatable.
 func (f *ItemFactory) ItemFromTreasure(treasure *d2records.Treasure) *Item {
-	result := &Item{
-		// nolint:gosec // we're not concerned with crypto-strong randomness
-		rand: rand.New(rand.NewSource(f.Seed)),
-	}
+	result := &Item{factory: f}
+	result.SetSeed(f.rand.Int63())
 
 	// in this case, the treasure code is a code used by an ItemCommonRecord
 	commonRecord := f.asset.Records.Item.All[treasure.Code]
@@ -320,53 +421,45 @@ func (f *ItemFactory) ItemFromTreasure(treasure *d2records.Treasure) *Item {
 	return nil
 }
 
-// FindMatchingAffixes for a given ItemCommonRecord, find all possible affixes that can spawn
+// FindMatchingAffixes for a given ItemCommonRecord and item level, find all possible affixes that can spawn.
+// The affixes are sorted by their name, so that picking one of them by a seeded roll is repeatable.
 func (f *ItemFactory) FindMatchingAffixes(
 	icr *d2records.ItemCommonRecord,
+	itemLevel int,
 	fromAffixes map[string]*d2records.ItemAffixCommonRecord,
 ) []*d2records.ItemAffixCommonRecord {
 	result := make([]*d2records.ItemAffixCommonRecord, 0)
 
 	equivItemTypes := f.asset.Records.FindEquivalentTypesByItemCommonRecord(icr)
 
-	for prefixIdx := range fromAffixes {
-		include, exclude := false, false
-		affix := fromAffixes[prefixIdx]
-
-		for itemTypeIdx := range equivItemTypes {
-			itemType := equivItemTypes[itemTypeIdx]
+	for _, affix := range fromAffixes {
+		if !affix.Spawnable || itemLevel < affix.Level {
+			continue
+		}
 
-			for _, excludedType := range affix.ItemExclude {
-				if itemType == excludedType {
-					exclude = true
-					break
-				}
-			}
+		if containsAnyType(affix.ItemExclude, equivItemTypes) || !containsAnyType(affix.ItemInclude, equivItemTypes) {
+			continue
+		}
 
-			if exclude {
-				break
-			}
+		result = append(result, affix)
+	}
 
-			for _, includedType := range affix.ItemInclude {
-				if itemType == includedType {
-					include = true
-					break
-				}
-			}
+	sort.Slice(result, func(a, b int) bool { return result[a].Name < result[b].Name })
 
-			if !include {
-				continue
-			}
+	return result
+}
 
-			if icr.Level < affix.Level {
-				continue
+// containsAnyType returns true when one of the item types is in the list
+func containsAnyType(list, itemTypes []string) bool {
+	for _, listed := range list {
+		for _, itemType := range itemTypes {
+			if listed == itemType {
+				return true
 			}
-
-			result = append(result, affix)
 		}
 	}
 
-	return result
+	return false
 }
 
 func (f *ItemFactory) resolveDynamicTreasureCode(code string) []*d2records.ItemCommonRecord {
@@ -395,15 +488,13 @@ func (f *ItemFactory) resolveDynamicTreasureCode(code string) []*d2records.ItemC
 }
 
 func getStringComponent(code string) string {
-	re := regexp.MustCompile(`\d+`)
-	return string(re.ReplaceAll([]byte(code), []byte("")))
+	return string(numericPattern.ReplaceAll([]byte(code), []byte("")))
 }
 
 func getNumericComponent(code string) int {
 	result := 0
 
-	re := regexp.MustCompile(`\D`)
-	numStr := string(re.ReplaceAll([]byte(code), []byte("")))
+	numStr := string(nonNumericPattern.ReplaceAll([]byte(code), []byte("")))
 
 	if number, err := strconv.ParseInt(numStr, 10, 32); err == nil {
 		result = int(number)
@@ -0,0 +1,119 @@
+package diablo2item
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+var errUnknownBaseItem = errors.New("unknown base item")
+
+// itemData is the serialized form of an item. It holds the seed and the codes of the item, the properties
+// are generated again from them when the item is deserialized.
+type itemData struct {
+	Seed       int64                           `json:"seed"`
+	Quality    int                             `json:"quality,omitempty"`
+	Level      int                             `json:"level,omitempty"`
+	Common     string                          `json:"common"`
+	Unique     string                          `json:"unique,omitempty"`
+	SetItem    string                          `json:"setItem,omitempty"`
+	Prefixes   []string                        `json:"prefixes,omitempty"`
+	Suffixes   []string                        `json:"suffixes,omitempty"`
+	Mods       []*d2records.PropertyDescriptor `json:"mods,omitempty"`
+	Identified bool                            `json:"identified,omitempty"`
+	Sockets    int                             `json:"sockets,omitempty"`
+	Socketed   []*itemData                     `json:"socketed,omitempty"`
+	GridX      int                             `json:"gridX"`
+	GridY      int                             `json:"gridY"`
+}
+
+// Serialize the item, with the items in its sockets, to json
+func (i *Item) Serialize() []byte {
+	data, err := json.Marshal(i.data())
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+func (i *Item) data() *itemData {
+	data := &itemData{
+		Seed:     i.Seed,
+		Quality:  int(i.quality),
+		Level:    i.level,
+		Common:   i.CommonCode,
+		Unique:   i.UniqueCode,
+		SetItem:  i.SetItemCode,
+		Prefixes: i.PrefixCodes,
+		Suffixes: i.SuffixCodes,
+		Mods:     i.craftedMods,
+		Sockets:  i.numSockets,
+		GridX:    i.GridX,
+		GridY:    i.GridY,
+	}
+
+	if i.attributes != nil {
+		data.Identified = i.attributes.identitified
+	}
+
+	for _, socketed := range i.sockets {
+		data.Socketed = append(data.Socketed, socketed.data())
+	}
+
+	return data
+}
+
+// Deserialize creates an item from its serialized form. The items in its sockets are inserted again, so an
+// item which was a runeword becomes that runeword again.
+func (f *ItemFactory) Deserialize(serialized []byte) (*Item, error) {
+	data := &itemData{}
+
+	if err := json.Unmarshal(serialized, data); err != nil {
+		return nil, err
+	}
+
+	return f.itemFromData(data)
+}
+
+func (f *ItemFactory) itemFromData(data *itemData) (*Item, error) {
+	if f.asset.Records.Item.All[data.Common] == nil {
+		return nil, fmt.Errorf("%w: %s", errUnknownBaseItem, data.Common)
+	}
+
+	item := &Item{
+		factory:     f,
+		quality:     d2enum.ItemQuality(data.Quality),
+		level:       data.Level,
+		CommonCode:  data.Common,
+		UniqueCode:  data.Unique,
+		SetItemCode: data.SetItem,
+		PrefixCodes: data.Prefixes,
+		SuffixCodes: data.Suffixes,
+		craftedMods: data.Mods,
+		numSockets:  data.Sockets,
+		GridX:       data.GridX,
+		GridY:       data.GridY,
+	}
+
+	item.SetSeed(data.Seed)
+	item.init()
+
+	item.attributes.identitified = data.Identified
+
+	for _, socketedData := range data.Socketed {
+		socketed, err := f.itemFromData(socketedData)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := item.Insert(socketed); err != nil {
+			return nil, err
+		}
+	}
+
+	return item, nil
+}
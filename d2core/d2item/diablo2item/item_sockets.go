@@ -0,0 +1,288 @@
+package diablo2item
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+var (
+	errNotSocketable  = errors.New("item can not be inserted into a socket")
+	errNoFreeSocket   = errors.New("item has no free socket")
+	errTooManySockets = errors.New("item can not have that many sockets")
+)
+
+// the gemapplytype of the base item decides which mods of a gem or rune it gets
+const (
+	gemApplyTypeWeapon = iota
+	gemApplyTypeArmor
+	gemApplyTypeShield
+)
+
+// the item levels from which the MaxSock25 and MaxSock40 columns of an item type apply
+const (
+	socketItemLevel25 = 25
+	socketItemLevel40 = 40
+)
+
+// normal and magic items which can have sockets drop socketed once in this many drops
+const socketedDropChance = 10
+
+// MaxSockets returns the most sockets the item can have. It is limited by the sockets of the base item, the
+// sockets of its item type at the item level and the size of the item.
+func (i *Item) MaxSockets() int {
+	common := i.CommonRecord()
+	if common == nil || !common.HasInventory {
+		return 0
+	}
+
+	max := d2math.MinInt(common.GemSockets, common.InventoryWidth*common.InventoryHeight)
+
+	if itemType := i.factory.asset.Records.Item.Types[common.Type]; itemType != nil {
+		switch {
+		case i.ItemLevel() >= socketItemLevel40:
+			max = d2math.MinInt(max, itemType.MaxSock40)
+		case i.ItemLevel() >= socketItemLevel25:
+			max = d2math.MinInt(max, itemType.MaxSock25)
+		default:
+			max = d2math.MinInt(max, itemType.MaxSock1)
+		}
+	}
+
+	return max
+}
+
+// Sockets returns the number of sockets of the item
+func (i *Item) Sockets() int {
+	return i.numSockets
+}
+
+// SetSockets sets the number of sockets of the item, it can not remove the sockets of socketed items
+func (i *Item) SetSockets(count int) error {
+	if count > i.MaxSockets() || count < len(i.sockets) {
+		return fmt.Errorf("%w: %d sockets on %s", errTooManySockets, count, i.CommonCode)
+	}
+
+	i.numSockets = count
+
+	if i.attributes != nil {
+		i.attributes.numSockets = count
+	}
+
+	return nil
+}
+
+// SocketedItems returns the gems, runes and jewels in the sockets of the item, in the order they were inserted
+func (i *Item) SocketedItems() []*Item {
+	return i.sockets
+}
+
+// RunewordRecord returns the RunesRecord of the runeword the runes in the sockets of the item spell
+func (i *Item) RunewordRecord() *d2records.RunesRecord {
+	return i.factory.asset.Records.Item.Runewords[i.RunewordCode]
+}
+
+// IsSocketable returns true for gems, runes and jewels
+func (i *Item) IsSocketable() bool {
+	return i.CommonCode == jewelItemCode || i.factory.gemRecord(i.CommonCode) != nil
+}
+
+// Insert puts a gem, rune or jewel into the next free socket of the item. Gems and runes add their mods for
+// the kind of the item, jewels add their properties. When the runes of a normal item spell a runeword, the
+// item becomes that runeword.
+func (i *Item) Insert(socketable *Item) error {
+	if socketable == nil || socketable == i || !socketable.IsSocketable() {
+		return errNotSocketable
+	}
+
+	if len(i.sockets) >= i.numSockets {
+		return fmt.Errorf("%w: %s", errNoFreeSocket, i.CommonCode)
+	}
+
+	i.sockets = append(i.sockets, socketable)
+
+	i.generateProperties(PropertyPoolSocket)
+
+	if record := i.findRuneword(); record != nil {
+		i.RunewordCode = record.Name
+		i.attributes.identitified = true // the runes of a runeword are plain to see
+		i.generateProperties(PropertyPoolRuneword)
+		i.generateName()
+	}
+
+	i.updateStatList()
+
+	return nil
+}
+
+// rollSockets gives a dropped normal or magic item a chance to have sockets
+func (i *Item) rollSockets() {
+	if quality := i.Quality(); quality != d2enum.Normal && quality != d2enum.Magic {
+		return
+	}
+
+	max := i.MaxSockets()
+	if max < 1 || i.rand.Intn(socketedDropChance) != 0 {
+		return
+	}
+
+	i.numSockets = i.rand.Intn(max) + 1
+}
+
+// generateSocketProperties returns the mods of the gems and runes and the properties of the jewels in the
+// sockets of the item
+func (i *Item) generateSocketProperties() []*Property {
+	if len(i.sockets) < 1 {
+		return nil
+	}
+
+	result := make([]*Property, 0)
+	applyType := i.CommonRecord().GemApplyType
+
+	for _, socketed := range i.sockets {
+		if gem := i.factory.gemRecord(socketed.CommonCode); gem != nil {
+			result = append(result, i.factory.propertiesFromDescriptors(gemModifiers(gem, applyType))...)
+			continue
+		}
+
+		for _, pool := range propertyPools {
+			result = append(result, socketed.properties[pool]...)
+		}
+	}
+
+	return result
+}
+
+func (i *Item) generateRunewordProperties() []*Property {
+	if record := i.RunewordRecord(); record != nil {
+		return i.generateItemProperties(record.Properties)
+	}
+
+	return nil
+}
+
+// findRuneword returns the complete runeword the runes in the sockets of the item spell, when all of its
+// sockets are filled. Only normal items, and no magic, rare, set or unique ones, can become runewords.
+func (i *Item) findRuneword() *d2records.RunesRecord {
+	if i.Quality() != d2enum.Normal || len(i.sockets) != i.numSockets || i.numSockets < 1 {
+		return nil
+	}
+
+	itemTypes := i.factory.asset.Records.FindEquivalentTypesByItemCommonRecord(i.CommonRecord())
+	runewords := i.factory.asset.Records.Item.Runewords
+	names := make([]string, 0, len(runewords))
+
+	for name := range runewords {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		record := runewords[name]
+
+		if !record.Complete || !i.spellsRunes(record.Runes) {
+			continue
+		}
+
+		if containsAnyType(record.ItemTypes.Exclude, itemTypes) || !containsAnyType(record.ItemTypes.Include, itemTypes) {
+			continue
+		}
+
+		return record
+	}
+
+	return nil
+}
+
+// spellsRunes returns true when the sockets of the item hold exactly the runes, in their order
+func (i *Item) spellsRunes(runes []string) bool {
+	if len(runes) != len(i.sockets) {
+		return false
+	}
+
+	for idx := range runes {
+		if i.sockets[idx].CommonCode != runes[idx] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// runeLetters returns the letters of the runes in the sockets of the item, like "TirEl"
+func (i *Item) runeLetters() string {
+	letters := ""
+
+	for _, socketed := range i.sockets {
+		if gem := i.factory.gemRecord(socketed.CommonCode); gem != nil {
+			letters += gem.Letter
+		}
+	}
+
+	return letters
+}
+
+// gemRecord returns the GemsRecord of a gem or rune by its item code
+func (f *ItemFactory) gemRecord(code string) *d2records.GemsRecord {
+	for _, gem := range f.asset.Records.Item.Gems {
+		if gem.Code == code {
+			return gem
+		}
+	}
+
+	return nil
+}
+
+// gemModifiers returns the mods a gem or rune adds to an item of the gemapplytype
+func gemModifiers(gem *d2records.GemsRecord, applyType int) []*d2records.PropertyDescriptor {
+	type mod struct {
+		code          string
+		param, lo, hi int
+	}
+
+	var mods []mod
+
+	switch applyType {
+	case gemApplyTypeWeapon:
+		mods = []mod{
+			{gem.WeaponMod1Code, gem.WeaponMod1Param, gem.WeaponMod1Min, gem.WeaponMod1Max},
+			{gem.WeaponMod2Code, gem.WeaponMod2Param, gem.WeaponMod2Min, gem.WeaponMod2Max},
+			{gem.WeaponMod3Code, gem.WeaponMod3Param, gem.WeaponMod3Min, gem.WeaponMod3Max},
+		}
+	case gemApplyTypeArmor:
+		mods = []mod{
+			{gem.HelmMod1Code, gem.HelmMod1Param, gem.HelmMod1Min, gem.HelmMod1Max},
+			{gem.HelmMod2Code, gem.HelmMod2Param, gem.HelmMod2Min, gem.HelmMod2Max},
+			{gem.HelmMod3Code, gem.HelmMod3Param, gem.HelmMod3Min, gem.HelmMod3Max},
+		}
+	case gemApplyTypeShield:
+		mods = []mod{
+			{gem.ShieldMod1Code, gem.ShieldMod1Param, gem.ShieldMod1Min, gem.ShieldMod1Max},
+			{gem.ShieldMod2Code, gem.ShieldMod2Param, gem.ShieldMod2Min, gem.ShieldMod2Max},
+			{gem.ShieldMod3Code, gem.ShieldMod3Param, gem.ShieldMod3Min, gem.ShieldMod3Max},
+		}
+	}
+
+	result := make([]*d2records.PropertyDescriptor, 0, len(mods))
+
+	for _, m := range mods {
+		if m.code == "" {
+			continue
+		}
+
+		param := ""
+		if m.param != 0 {
+			param = strconv.Itoa(m.param)
+		}
+
+		result = append(result, &d2records.PropertyDescriptor{Code: m.code, Parameter: param, Min: m.lo, Max: m.hi})
+	}
+
+	return result
+}
@@ -0,0 +1,323 @@
+package diablo2item
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// nolint:gochecknoglobals // just a test
+var socketProperties = map[string]*d2records.PropertyRecord{
+	"str": {Code: "str", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "strength"}}},
+	"dex": {Code: "dex", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "dexterity"}}},
+	"vit": {Code: "vit", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "vitality"}}},
+	"ene": {Code: "ene", Stats: [7]*d2records.PropertyStatRecord{{FunctionID: 1, StatCode: "energy"}}},
+}
+
+// testSocketFactory loads the gems and runewords in testdata along with the item records
+func testSocketFactory(t *testing.T) *ItemFactory {
+	factory := testDropFactory(t)
+	records := factory.asset.Records
+
+	loadTestRecords(t, records, d2resource.Gems, d2resource.Runes)
+
+	records.Properties = socketProperties
+	records.Item.Stats = itemStatCosts
+
+	return factory
+}
+
+func testSocketedItem(t *testing.T, factory *ItemFactory, code string, sockets int, socketables ...string) *Item {
+	item, err := factory.NewItem(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.SetSockets(sockets); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, socketableCode := range socketables {
+		socketable, err := factory.NewItem(socketableCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := item.Insert(socketable); err != nil {
+			t.Fatalf("inserting %s into %s: %s", socketableCode, code, err)
+		}
+	}
+
+	return item
+}
+
+func TestMaxSockets(t *testing.T) {
+	factory := testSocketFactory(t)
+
+	tests := []struct {
+		code     string
+		expected int
+	}{
+		{"hax", 2}, // limited by the base item
+		{"axe", 2}, // limited by the item type at item level 7
+		{"gax", 5}, // limited by the item type at item level 41
+		{"buc", 1},
+		{"rin", 0}, // rings have no sockets
+	}
+
+	for _, test := range tests {
+		item, err := factory.NewItem(test.code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if max := item.MaxSockets(); max != test.expected {
+			t.Errorf("%s: expected %d sockets at most, got %d", test.code, test.expected, max)
+		}
+	}
+
+	// an axe dropped at item level 30 has the sockets of its item type at level 25
+	axes := 0
+
+	for drop := 0; drop < 500; drop++ {
+		for _, item := range factory.ItemsFromTreasureClass(factory.TreasureClass("Act 1 Equip A"), 30) {
+			if item.ItemLevel() != 30 {
+				t.Fatalf("%s dropped at item level %d", item.CommonCode, item.ItemLevel())
+			}
+
+			if item.CommonCode != "axe" {
+				continue
+			}
+
+			axes++
+
+			if item.MaxSockets() != 4 {
+				t.Errorf("expected an axe of item level 30 to have 4 sockets at most, got %d", item.MaxSockets())
+			}
+		}
+	}
+
+	if axes == 0 {
+		t.Error("no axe dropped")
+	}
+}
+
+func TestInsertGems(t *testing.T) {
+	factory := testSocketFactory(t)
+
+	tests := []struct {
+		code     string
+		expected string
+	}{
+		{"hax", "+3 to Strength"},  // weapon mod
+		{"cap", "+4 to Vitality"},  // helm mod
+		{"buc", "+5 to Dexterity"}, // shield mod
+	}
+
+	for _, test := range tests {
+		item := testSocketedItem(t, factory, test.code, 1, "gcr")
+
+		if stats := item.GetStatStrings(); !reflect.DeepEqual(stats, []string{test.expected}) {
+			t.Errorf("%s: expected the stats %q, got %q", test.code, test.expected, stats)
+		}
+
+		if len(item.SocketedItems()) != 1 || item.SocketedItems()[0].CommonCode != "gcr" {
+			t.Errorf("%s: the gem is not in its socket", test.code)
+		}
+	}
+
+	jewel, err := factory.NewItem("jew")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jewel.properties = map[PropertyPool][]*Property{PropertyPoolPrefix: {factory.NewProperty("ene", 7, 7)}}
+
+	item := testSocketedItem(t, factory, "cap", 2, "gcr")
+	if err := item.Insert(jewel); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := item.GetStatStrings(); len(stats) != 2 || !containsString(stats, "+7 to Energy") {
+		t.Errorf("the jewel did not add its properties: %q", stats)
+	}
+}
+
+func TestInsertErrors(t *testing.T) {
+	factory := testSocketFactory(t)
+
+	item := testSocketedItem(t, factory, "cap", 1, "gcr")
+
+	ruby, err := factory.NewItem("gcr")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := item.Insert(ruby); !errors.Is(err, errNoFreeSocket) {
+		t.Errorf("expected %q, got %v", errNoFreeSocket, err)
+	}
+
+	helm, err := factory.NewItem("cap")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := helm.SetSockets(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := helm.Insert(item); !errors.Is(err, errNotSocketable) {
+		t.Errorf("expected %q, got %v", errNotSocketable, err)
+	}
+
+	if err := helm.SetSockets(3); !errors.Is(err, errTooManySockets) {
+		t.Errorf("expected %q, got %v", errTooManySockets, err)
+	}
+
+	if err := item.SetSockets(0); !errors.Is(err, errTooManySockets) {
+		t.Errorf("removing the socket of a gem: expected %q, got %v", errTooManySockets, err)
+	}
+}
+
+func TestRunewords(t *testing.T) {
+	factory := testSocketFactory(t)
+
+	tests := []struct {
+		code     string
+		runes    []string
+		runeword string
+	}{
+		{"hax", []string{"r03", "r01"}, "Runeword1"},
+		{"hax", []string{"r01", "r03"}, ""},          // the runes are in the wrong order
+		{"hax", []string{"r01", "r01"}, ""},          // the runeword is not complete
+		{"cap", []string{"r03", "r01"}, ""},          // the runeword is for axes
+		{"cap", []string{"r04", "r03"}, "Runeword2"}, // helms are armor
+		{"qui", []string{"r01", "r02"}, "Runeword4"},
+		{"axe", []string{"r03"}, ""}, // a socket is left free
+	}
+
+	for _, test := range tests {
+		item := testSocketedItem(t, factory, test.code, 2, test.runes...)
+
+		if item.RunewordCode != test.runeword {
+			t.Errorf("%s %v: expected the runeword %q, got %q", test.code, test.runes, test.runeword,
+				item.RunewordCode)
+		}
+	}
+
+	steel := testSocketedItem(t, factory, "hax", 2, "r03", "r01")
+
+	if steel.Label() != d2util.ColorTokenize("Steel", d2util.ColorTokenUniqueItem) {
+		t.Errorf("unexpected runeword label %q", steel.Label())
+	}
+
+	expectedStats := []string{"+21 to Strength", "+20 to Dexterity", "+2 to Energy"}
+	if stats := steel.GetStatStrings(); len(stats) != len(expectedStats) {
+		t.Errorf("expected the runeword stats %q, got %q", expectedStats, stats)
+	} else {
+		for _, stat := range expectedStats {
+			if !containsString(stats, stat) {
+				t.Errorf("expected the runeword stats %q, got %q", expectedStats, stats)
+			}
+		}
+	}
+
+	if description := steel.GetItemDescription(); description[1] != d2util.ColorTokenize("'TirEl'",
+		d2util.ColorTokenUniqueItem) {
+		t.Errorf("the description does not spell the runes: %q", description)
+	}
+
+	magic, err := factory.NewItem("hax", "Sturdy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := magic.SetSockets(2); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{"r03", "r01"} {
+		rune, _ := factory.NewItem(code)
+		if err := magic.Insert(rune); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if magic.RunewordRecord() != nil {
+		t.Error("a magic item became a runeword")
+	}
+}
+
+func TestSocketedDrops(t *testing.T) {
+	factory := testSocketFactory(t)
+	factory.SetSeed(5)
+
+	socketed := 0
+
+	for drop := 0; drop < 2000; drop++ {
+		for _, item := range factory.ItemsFromTreasureClass(factory.TreasureClass("Act 1 Equip A"), 0) {
+			if item.Sockets() > item.MaxSockets() {
+				t.Fatalf("%s dropped with %d sockets, it can have %d", item.CommonCode, item.Sockets(),
+					item.MaxSockets())
+			}
+
+			if item.Sockets() > 0 {
+				socketed++
+			}
+		}
+	}
+
+	if socketed == 0 {
+		t.Error("no item dropped with sockets")
+	}
+}
+
+func TestSerializeSocketedItem(t *testing.T) {
+	factory := testSocketFactory(t)
+
+	item := testSocketedItem(t, factory, "cap", 2, "r04", "r03")
+	item.SetInventoryGridSlot(3, 1)
+	item.level = 12
+	item.Identify()
+
+	deserialized, err := factory.Deserialize(item.Serialize())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if deserialized.RunewordCode != "Runeword2" || deserialized.Sockets() != 2 || len(deserialized.SocketedItems()) != 2 {
+		t.Errorf("the runeword did not survive serialization: %+v", deserialized)
+	}
+
+	if deserialized.Defense() != item.Defense() || deserialized.Label() != item.Label() {
+		t.Errorf("expected %q with %d defense, got %q with %d", item.Label(), item.Defense(),
+			deserialized.Label(), deserialized.Defense())
+	}
+
+	if deserialized.ItemLevel() != item.ItemLevel() {
+		t.Errorf("expected item level %d, got %d", item.ItemLevel(), deserialized.ItemLevel())
+	}
+
+	if x, y := deserialized.InventoryGridSlot(); x != 3 || y != 1 {
+		t.Errorf("expected the grid slot 3,1, got %d,%d", x, y)
+	}
+
+	if _, err := factory.Deserialize([]byte(`{"common":"xyz"}`)); !errors.Is(err, errUnknownBaseItem) {
+		t.Errorf("expected %q, got %v", errUnknownBaseItem, err)
+	}
+}
+
+func containsString(list []string, str string) bool {
+	for _, listed := range list {
+		if strings.EqualFold(listed, str) {
+			return true
+		}
+	}
+
+	return false
+}
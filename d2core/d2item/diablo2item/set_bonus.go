@@ -0,0 +1,119 @@
+package diablo2item
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats"
+)
+
+const (
+	// the first bonus of a set is granted when this many of its items are equipped
+	setBonusItemOffset = 2
+
+	// set items with this add func do not grant bonus properties
+	setItemAddFnNone = 0
+)
+
+// SetBonuses returns the stats of the set bonuses of the equipped items. A set item grants its bonus
+// properties for the number of equipped items of its set, a set grants its partial properties for that
+// number and its full properties once all of its items are equipped. Each set item counts once, even when
+// it is equipped twice.
+func (f *ItemFactory) SetBonuses(equipped []*Item) d2stats.StatList {
+	counts := make(map[string]int) // the number of equipped items of each set
+	setItems := make([]*Item, 0)
+	sets := make([]string, 0)
+
+	for _, item := range equipped {
+		if item == nil || item.SetItemRecord() == nil || containsSetItem(setItems, item.SetItemCode) {
+			continue
+		}
+
+		if counts[item.SetCode] == 0 {
+			sets = append(sets, item.SetCode)
+		}
+
+		counts[item.SetCode]++
+		setItems = append(setItems, item)
+	}
+
+	properties := make([]*Property, 0)
+
+	for _, item := range setItems {
+		if record := item.SetItemRecord(); record.AddFn != setItemAddFnNone {
+			properties = append(properties, f.propertiesFromDescriptors(setItemBonuses(record, counts[item.SetCode]))...)
+		}
+	}
+
+	for _, set := range sets {
+		if record := f.asset.Records.Item.Sets[set]; record != nil {
+			properties = append(properties, f.propertiesFromDescriptors(f.setBonuses(record, counts[set]))...)
+		}
+	}
+
+	stats := make([]d2stats.Stat, 0)
+	for _, prop := range properties {
+		stats = append(stats, prop.stats...)
+	}
+
+	return f.stat.NewStatList(stats...)
+}
+
+func containsSetItem(items []*Item, setItemCode string) bool {
+	for _, item := range items {
+		if item.SetItemCode == setItemCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setItemBonuses returns the bonus properties a set item grants with the given number of items of its set
+func setItemBonuses(record *d2records.SetItemRecord, count int) []*d2records.PropertyDescriptor {
+	result := make([]*d2records.PropertyDescriptor, 0)
+
+	for idx := range record.SetPropertiesLevel1 {
+		if idx+setBonusItemOffset > count {
+			break
+		}
+
+		result = append(result, record.SetPropertiesLevel1[idx], record.SetPropertiesLevel2[idx])
+	}
+
+	return result
+}
+
+// setBonuses returns the partial and full properties a set grants with the given number of its items
+func (f *ItemFactory) setBonuses(record *d2records.SetRecord, count int) []*d2records.PropertyDescriptor {
+	result := make([]*d2records.PropertyDescriptor, 0)
+
+	for idx := range record.Properties.PartialA {
+		if idx+setBonusItemOffset > count {
+			break
+		}
+
+		result = append(result, record.Properties.PartialA[idx])
+
+		if idx < len(record.Properties.PartialB) {
+			result = append(result, record.Properties.PartialB[idx])
+		}
+	}
+
+	if count >= f.setSize(record.Key) {
+		result = append(result, record.Properties.Full...)
+	}
+
+	return result
+}
+
+// setSize returns the number of items of a set
+func (f *ItemFactory) setSize(set string) int {
+	size := 0
+
+	for _, record := range f.asset.Records.Item.SetItems {
+		if record.SetKey == set {
+			size++
+		}
+	}
+
+	return size
+}
@@ -34,6 +34,8 @@ type MapEngine struct {
 
 	// https://github.com/OpenDiablo2/OpenDiablo2/issues/789
 	IsLoading bool // (temp) Whether we have processed the GenerateMapPacket(only for remote client)
+
+	Replicated bool // Whether the entities are created by a server, then stamps place no entities
 }
 
 const (
@@ -189,6 +191,10 @@ func (m *MapEngine) PlaceStamp(stamp *d2mapstamp.Stamp, tileOffsetX, tileOffsetY
 		}
 	}
 
+	if m.Replicated {
+		return
+	}
+
 	// Copy over the entities
 	stampEntities := stamp.Entities(tileOffsetX, tileOffsetY)
 	for idx := range stampEntities {
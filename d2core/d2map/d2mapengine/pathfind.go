@@ -3,46 +3,81 @@ package d2mapengine
 import (
 	"math"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
 )
 
-// PathFind finds a path between given start and dest positions and returns the positions of the path
+const (
+	subTileCenter = 0.5
+)
+
+// PathFind finds a path between given start and dest positions and returns the positions of the path.
+// The path walks around obstacles and is smoothed so that it only contains the corners to walk around.
+// If dest can not be reached, the path leads as close to it as possible. An empty path is returned when
+// there is nowhere to go.
 func (m *MapEngine) PathFind(start, dest d2vector.Position) []d2vector.Position {
-	points := make([]d2vector.Position, 0)
-	_, point := m.checkLos(start, dest)
-	points = append(points, point)
+	startCell := subTileCell(start)
+	destCell := subTileCell(dest)
+
+	goal := m.walkableCellTowards(destCell, startCell)
+
+	if goal == startCell {
+		if goal != destCell {
+			return nil
+		}
+
+		return []d2vector.Position{dest}
+	}
+
+	cells := d2path.FindPath(startCell, goal, m.walkable, d2path.DefaultSearchBudget)
+	cells = d2path.Smooth(startCell, cells, m.walkable)
+
+	points := make([]d2vector.Position, len(cells))
+
+	for idx := range cells {
+		points[idx] = d2vector.NewPosition(float64(cells[idx].X)+subTileCenter, float64(cells[idx].Y)+subTileCenter)
+	}
+
+	if len(cells) > 0 && cells[len(cells)-1] == destCell {
+		points[len(points)-1] = dest
+	}
 
 	return points
 }
 
-// checkLos finds out if there is a clear line of sight between two points
-func (m *MapEngine) checkLos(start, end d2vector.Position) (bool, d2vector.Position) {
-	dv := d2vector.Position{Vector: *end.Clone()}
-	dv.Subtract(&start.Vector)
-	dx := dv.X()
-	dy := dv.Y()
-	N := math.Max(math.Abs(dx), math.Abs(dy))
-
-	var divN float64
-	if N == 0 {
-		divN = 0.0
-	} else {
-		divN = 1.0 / N // nolint:gomnd // we're just taking inverse...
+// walkable returns true if the given sub tile is inside the map and not blocked
+func (m *MapEngine) walkable(subX, subY int) bool {
+	if subX < 0 || subY < 0 || subX >= m.size.Width*subtilesPerTile || subY >= m.size.Height*subtilesPerTile {
+		return false
 	}
 
-	xstep := dx * divN
-	ystep := dy * divN
-	x := start.X()
-	y := start.Y()
+	return !m.SubTileAt(subX, subY).BlockWalk
+}
 
-	for i := 0; i <= int(N); i++ {
-		x += xstep
-		y += ystep
+// walkableCellTowards walks the straight line from the given cell towards the target cell and returns the first
+// walkable cell, or the target cell if there is none. This is used to retarget clicks on walls and other blocked
+// areas.
+func (m *MapEngine) walkableCellTowards(from, to d2geom.Point) d2geom.Point {
+	dx := float64(to.X - from.X)
+	dy := float64(to.Y - from.Y)
+	steps := int(math.Max(math.Abs(dx), math.Abs(dy)))
 
-		if m.SubTileAt(int(math.Floor(x)), int(math.Floor(y))).BlockWalk {
-			return false, d2vector.NewPosition(x-xstep, y-ystep)
+	for i := 0; i < steps; i++ {
+		t := float64(i) / float64(steps)
+		cell := d2geom.Point{
+			X: from.X + int(math.Round(dx*t)),
+			Y: from.Y + int(math.Round(dy*t)),
+		}
+
+		if m.walkable(cell.X, cell.Y) {
+			return cell
 		}
 	}
 
-	return true, end
+	return to
+}
+
+func subTileCell(p d2vector.Position) d2geom.Point {
+	return d2geom.Point{X: int(math.Floor(p.X())), Y: int(math.Floor(p.Y()))}
 }
@@ -0,0 +1,144 @@
+package d2mapengine
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2path"
+)
+
+// testMapEngine creates a map engine of the given size in tiles, without any assets.
+func testMapEngine(width, height int) *MapEngine {
+	return &MapEngine{
+		size:  d2geom.Size{Width: width, Height: height},
+		tiles: make([]MapTile, width*height),
+	}
+}
+
+// blockSubTiles blocks walking on all sub tiles in the given rectangle, in sub tiles.
+func blockSubTiles(m *MapEngine, x, y, w, h int) {
+	for subY := y; subY < y+h; subY++ {
+		for subX := x; subX < x+w; subX++ {
+			m.SubTileAt(subX, subY).BlockWalk = true
+		}
+	}
+}
+
+func checkPath(t *testing.T, m *MapEngine, start d2vector.Position, path []d2vector.Position) {
+	t.Helper()
+
+	prev := subTileCell(start)
+
+	for idx := range path {
+		cell := subTileCell(path[idx])
+
+		if !m.walkable(cell.X, cell.Y) {
+			t.Fatalf("path node %d at %s is not walkable", idx, path[idx].Vector)
+		}
+
+		if !d2path.LineOfSight(prev, cell, m.walkable) {
+			t.Fatalf("path segment %d from %v to %v crosses a blocked sub tile", idx, prev, cell)
+		}
+
+		prev = cell
+	}
+}
+
+func TestMapEngine_PathFind_Open(t *testing.T) {
+	m := testMapEngine(4, 4)
+	start := d2vector.NewPosition(1.5, 1.5)
+	dest := d2vector.NewPosition(17.2, 12.8)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) != 1 {
+		t.Fatalf("expected a single straight segment on an open map, got %d nodes", len(path))
+	}
+
+	if !path[0].Equals(&dest.Vector) {
+		t.Errorf("expected the path to end at %s, got %s", dest.Vector, path[0].Vector)
+	}
+}
+
+func TestMapEngine_PathFind_AroundWall(t *testing.T) {
+	m := testMapEngine(6, 6)
+
+	// a wall from the top of the map down to sub tile 24, leaving a gap at the bottom
+	blockSubTiles(m, 15, 0, 1, 25)
+
+	start := d2vector.NewPosition(5.5, 5.5)
+	dest := d2vector.NewPosition(25.5, 5.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) < 2 {
+		t.Fatalf("expected the path to walk around the wall, got %d nodes", len(path))
+	}
+
+	last := path[len(path)-1]
+	if !last.Equals(&dest.Vector) {
+		t.Errorf("expected the path to end at %s, got %s", dest.Vector, last.Vector)
+	}
+
+	checkPath(t, m, start, path)
+}
+
+func TestMapEngine_PathFind_BlockedDestination(t *testing.T) {
+	m := testMapEngine(4, 4)
+	blockSubTiles(m, 10, 0, 10, 20)
+
+	start := d2vector.NewPosition(2.5, 2.5)
+	dest := d2vector.NewPosition(14.5, 2.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) == 0 {
+		t.Fatal("expected a path towards the blocked destination")
+	}
+
+	last := subTileCell(path[len(path)-1])
+	if want := (d2geom.Point{X: 9, Y: 2}); last != want {
+		t.Errorf("expected the path to stop in front of the wall at %v, got %v", want, last)
+	}
+
+	checkPath(t, m, start, path)
+}
+
+func TestMapEngine_PathFind_Enclosed(t *testing.T) {
+	m := testMapEngine(4, 4)
+
+	// a closed box around the destination
+	blockSubTiles(m, 8, 8, 5, 1)
+	blockSubTiles(m, 8, 12, 5, 1)
+	blockSubTiles(m, 8, 8, 1, 5)
+	blockSubTiles(m, 12, 8, 1, 5)
+
+	start := d2vector.NewPosition(2.5, 2.5)
+	dest := d2vector.NewPosition(10.5, 10.5)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) == 0 {
+		t.Fatal("expected a path towards the enclosed destination")
+	}
+
+	checkPath(t, m, start, path)
+
+	last := subTileCell(path[len(path)-1])
+	if last.X >= 8 && last.X <= 12 && last.Y >= 8 && last.Y <= 12 {
+		t.Errorf("path should not enter the box, ends at %v", last)
+	}
+}
+
+func TestMapEngine_PathFind_SameSubTile(t *testing.T) {
+	m := testMapEngine(2, 2)
+	start := d2vector.NewPosition(3.2, 3.2)
+	dest := d2vector.NewPosition(3.7, 3.4)
+
+	path := m.PathFind(start, dest)
+
+	if len(path) != 1 || !path[0].Equals(&dest.Vector) {
+		t.Errorf("expected a path straight to %s, got %v", dest.Vector, path)
+	}
+}
@@ -87,6 +87,12 @@ func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroT
 
 	heroState, _ := f.CreateHeroState(name, heroType, stats)
 
+	for id, skill := range skills {
+		if known := heroState.Skills[id]; known != nil && skill != nil {
+			known.SetSkillPoints(skill.SkillPoints)
+		}
+	}
+
 	result := &Player{
 		mapEntity:  newMapEntity(x, y),
 		composite:  composite,
@@ -104,7 +110,7 @@ func (f *MapEntityFactory) NewPlayer(id, name string, x, y, direction int, heroT
 	}
 
 	result.mapEntity.uuid = id
-	result.SetSpeed(baseWalkSpeed)
+	result.SetSpeed(BaseWalkSpeed)
 	result.mapEntity.directioner = result.rotate
 	err = composite.SetMode(d2enum.PlayerAnimationModeTownNeutral, equipment.RightHand.GetWeaponClass())
 
@@ -157,6 +163,21 @@ func (f *MapEntityFactory) NewItem(x, y int, codes ...string) (*Item, error) {
 		return nil, err
 	}
 
+	return f.NewItemEntity(x, y, item)
+}
+
+// NewSerializedItem creates the map entity of an item serialized with Item.Serialize
+func (f *MapEntityFactory) NewSerializedItem(x, y int, data []byte) (*Item, error) {
+	item, err := f.item.Deserialize(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.NewItemEntity(x, y, item)
+}
+
+// NewItemEntity creates the map entity of an item that was already rolled, like the loot of a monster
+func (f *MapEntityFactory) NewItemEntity(x, y int, item *diablo2item.Item) (*Item, error) {
 	filename := item.CommonRecord().FlippyFile
 	filepath := fmt.Sprintf("%s/%s.DC6", d2resource.ItemGraphics, filename)
 	animation, err := f.asset.LoadAnimation(filepath, d2resource.PaletteUnits)
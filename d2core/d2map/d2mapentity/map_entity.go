@@ -23,6 +23,7 @@ type mapEntity struct {
 
 	done        func()
 	directioner func(direction int)
+	facing      int // the direction of the last target
 
 	highlight bool
 }
@@ -63,6 +64,45 @@ func (m *mapEntity) StopMoving() {
 	m.setTarget(m.Position, nil)
 }
 
+// SetPosition places the entity at the given position, discarding its current path and target.
+func (m *mapEntity) SetPosition(p d2vector.Position) {
+	m.ClearPath()
+	m.velocity.Set(0, 0)
+	m.Position.Copy(&p.Vector)
+	m.setTarget(p, nil)
+}
+
+// SetID replaces the uuid of the entity with the ID of the server that replicates it. It has to be called before the
+// entity is added to the map engine.
+func (m *mapEntity) SetID(id string) {
+	m.uuid = id
+}
+
+// Glide places the entity at the given position on its way to the given destination, discarding its path. It is
+// used for entities whose position is interpolated from the snapshots of a server, the entity faces the destination
+// and stops once it is there.
+func (m *mapEntity) Glide(position, destination d2vector.Position) {
+	moving := !m.atTarget()
+
+	m.ClearPath()
+	m.velocity.Set(0, 0)
+	m.Position.Copy(&position.Vector)
+
+	if m.Position.EqualsApprox(&destination.Vector) {
+		m.Target.Copy(&destination.Vector)
+
+		if moving && m.directioner != nil {
+			m.directioner(m.facing)
+		}
+
+		return
+	}
+
+	if !moving || !m.Target.EqualsApprox(&destination.Vector) {
+		m.setTarget(destination, nil)
+	}
+}
+
 // SetSpeed sets the entity movement speed.
 func (m *mapEntity) SetSpeed(speed float64) {
 	m.Speed = speed
@@ -183,9 +223,9 @@ func (m *mapEntity) setTarget(p d2vector.Position, done func()) {
 
 	// Update the direction
 	if m.directioner != nil {
-		d := m.Position.DirectionTo(m.Target.Vector)
+		m.facing = m.Position.DirectionTo(m.Target.Vector)
 
-		m.directioner(d)
+		m.directioner(m.facing)
 	}
 
 	// Update the velocity direction
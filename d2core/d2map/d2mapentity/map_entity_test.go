@@ -72,6 +72,34 @@ func TestMapEntity_Step(t *testing.T) {
 	}
 }
 
+func TestMapEntity_Glide(t *testing.T) {
+	e := entity()
+	directions := make([]int, 0)
+	e.directioner = func(direction int) {
+		directions = append(directions, direction)
+	}
+
+	destination := d2vector.NewPosition(20, 10)
+
+	e.Glide(d2vector.NewPosition(15, 10), destination)
+
+	if e.Position.X() != 15 || e.atTarget() || len(directions) != 1 {
+		t.Fatalf("expected the entity to glide towards the destination, at %s facing %v", e.Position, directions)
+	}
+
+	e.Glide(d2vector.NewPosition(18, 10), destination)
+
+	if len(directions) != 1 {
+		t.Error("the entity turned without a new destination")
+	}
+
+	e.Glide(destination, destination)
+
+	if !e.atTarget() || len(directions) != 2 || directions[1] != directions[0] {
+		t.Errorf("expected the entity to stop facing the same direction, got %v", directions)
+	}
+}
+
 func BenchmarkMapEntity_Step(b *testing.B) {
 	stepEntity := movingEntity()
 
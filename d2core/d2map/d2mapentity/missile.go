@@ -29,6 +29,11 @@ func (m *Missile) GetVelocity() d2vector.Vector {
 	return m.AnimatedEntity.velocity
 }
 
+// MissileRecord returns the missiles.txt record of the missile
+func (m *Missile) MissileRecord() *d2records.MissileRecord {
+	return m.record
+}
+
 // SetRadians adjusts the entity target based on it's range, rotating it's
 // current destination by the value of angle in radians.
 func (m *Missile) SetRadians(angle float64, done func()) {
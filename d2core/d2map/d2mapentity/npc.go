@@ -26,6 +26,8 @@ type NPC struct {
 	monstatEx     *d2records.MonStats2Record
 	HasPaths      bool
 	isDone        bool
+	isGettingHit  bool
+	isDead        bool
 }
 
 const (
@@ -98,6 +100,18 @@ func (v *NPC) Advance(tickTime float64) {
 		return
 	}
 
+	if v.composite.GetPlayedCount() >= 1 {
+		switch {
+		case v.isDead:
+			if err := v.composite.SetMode(d2enum.MonsterAnimationModeDead, v.composite.GetWeaponClass()); err != nil {
+				return
+			}
+		case v.isGettingHit:
+			v.isGettingHit = false
+			v.rotate(v.composite.GetDirection())
+		}
+	}
+
 	if v.HasPaths && v.wait() {
 		// If at the target, set target to the next path.
 		v.isDone = false
@@ -144,8 +158,41 @@ func (v *NPC) next() {
 	}
 }
 
+// GetHit plays the animation of the NPC being hit
+func (v *NPC) GetHit() {
+	if v.isDead {
+		return
+	}
+
+	v.isGettingHit = true
+
+	if err := v.composite.SetMode(d2enum.MonsterAnimationModeGetHit, v.composite.GetWeaponClass()); err != nil {
+		v.isGettingHit = false
+	}
+}
+
+// Kill stops the NPC and plays its death animation, after which it stays on the map as a corpse
+func (v *NPC) Kill() {
+	v.isDead = true
+	v.HasPaths = false
+	v.StopMoving()
+
+	if err := v.composite.SetMode(d2enum.MonsterAnimationModeDeath, v.composite.GetWeaponClass()); err != nil {
+		return
+	}
+}
+
+// IsDead returns true if the NPC was killed
+func (v *NPC) IsDead() bool {
+	return v.isDead
+}
+
 // rotate sets direction and changes animation
 func (v *NPC) rotate(direction int) {
+	if v.isDead || v.isGettingHit {
+		return
+	}
+
 	var newMode d2enum.MonsterAnimationMode
 	if !v.atTarget() {
 		newMode = d2enum.MonsterAnimationModeWalk
@@ -167,7 +214,7 @@ func (v *NPC) rotate(direction int) {
 // Selectable returns true if the object can be highlighted/selected.
 func (v *NPC) Selectable() bool {
 	// is there something handy that determines selectable npc's?
-	return v.name != ""
+	return v.name != "" && !v.isDead
 }
 
 // Label returns the NPC's in-game name (e.g. "Deckard Cain") or an empty string if it does not have a name.
@@ -189,3 +236,8 @@ func (v *NPC) GetVelocity() d2vector.Vector {
 func (v *NPC) GetSize() (width, height int) {
 	return v.composite.GetSize()
 }
+
+// MonStats returns the monstats.txt record of the NPC
+func (v *NPC) MonStats() *d2records.MonStatsRecord {
+	return v.monstatRecord
+}
@@ -66,6 +66,17 @@ func (ob *Object) ID() string {
 	return ob.uuid
 }
 
+// SetID replaces the uuid of the object with the ID of the server that replicates it. It has to be called before
+// the object is added to the map engine.
+func (ob *Object) SetID(id string) {
+	ob.uuid = id
+}
+
+// ObjectRecord returns the objects.txt record of the object
+func (ob *Object) ObjectRecord() *d2records.ObjectDetailsRecord {
+	return ob.objectRecord
+}
+
 // Highlight sets the entity highlighted flag to true.
 func (ob *Object) Highlight() {
 	ob.highlight = true
@@ -28,12 +28,16 @@ type Player struct {
 	isRunToggled      bool
 	isRunning         bool
 	isCasting         bool
+	isGettingHit      bool
 	onFinishedCasting func()
 }
 
+// Player movement speeds, in sub tiles per second.
 // run speed should be walkspeed * 1.5, since in the original game it is 6 yards walk and 9 yards run.
-const baseWalkSpeed = 6.0
-const baseRunSpeed = 9.0
+const (
+	BaseWalkSpeed = 6.0
+	BaseRunSpeed  = 9.0
+)
 
 // ID returns the Player uuid
 func (p *Player) ID() string {
@@ -67,9 +71,9 @@ func (p *Player) SetIsRunning(isRunning bool) {
 	p.isRunning = isRunning
 
 	if isRunning {
-		p.SetSpeed(baseRunSpeed)
+		p.SetSpeed(BaseRunSpeed)
 	} else {
-		p.SetSpeed(baseWalkSpeed)
+		p.SetSpeed(BaseWalkSpeed)
 	}
 }
 
@@ -91,6 +95,10 @@ func (p *Player) Advance(tickTime float64) {
 		fmt.Printf("failed to set animationMode to: %d, err: %v\n", p.GetAnimationMode(), err)
 	}
 
+	if p.isGettingHit && p.composite.GetPlayedCount() >= 1 {
+		p.isGettingHit = false
+	}
+
 	if p.IsCasting() {
 		if p.composite.GetPlayedCount() >= 1 {
 			p.isCasting = false
@@ -131,13 +139,13 @@ func (p *Player) Advance(tickTime float64) {
 	if p.IsRunning() && !p.atTarget() && !p.IsInTown() {
 		p.Stats.Stamina -= staminaDrain * tickTime / magicStaminaDrainDivisor
 		if p.Stats.Stamina <= 0 {
-			p.SetSpeed(baseWalkSpeed)
+			p.SetSpeed(BaseWalkSpeed)
 			p.Stats.Stamina = 0
 		}
 	} else if p.Stats.Stamina < float64(p.Stats.MaxStamina) {
 		p.Stats.Stamina += staminaDrain * tickTime / magicStaminaDrainDivisor
 		if p.IsRunning() {
-			p.SetSpeed(baseRunSpeed)
+			p.SetSpeed(BaseRunSpeed)
 		}
 	}
 }
@@ -179,6 +187,10 @@ func (p *Player) GetAnimationMode() d2enum.PlayerAnimationMode {
 		return d2enum.PlayerAnimationModeCast
 	}
 
+	if p.isGettingHit {
+		return d2enum.PlayerAnimationModeGetHit
+	}
+
 	return d2enum.PlayerAnimationModeNeutral
 }
 
@@ -217,6 +229,11 @@ func (p *Player) IsCasting() bool {
 	return p.isCasting
 }
 
+// GetHit plays the animation of the player being hit, unless the player is busy moving or casting
+func (p *Player) GetHit() {
+	p.isGettingHit = true
+}
+
 // StartCasting sets a flag indicating the player is casting a skill and
 // sets the animation mode to the casting animation.
 // This handles all types of skills - melee, ranged, kick, summon, etc.
@@ -45,6 +45,9 @@ func (g *MapGenerator) GenerateAct1Overworld() {
 	mapWidth := g.engine.Size().Width
 	mapHeight := g.engine.Size().Height
 
+	g.level = &Level{ID: act1TownLevelID}
+	g.level.addArea(wildernessDetailsRecordID, d2geom.Rectangle{Width: mapWidth, Height: mapHeight})
+
 	townStamp := g.engine.LoadStamp(d2enum.RegionAct1Town, presetB, autoFileIndex)
 	townStamp.RegionPath()
 	townSize := townStamp.Size()
@@ -53,10 +56,10 @@ func (g *MapGenerator) GenerateAct1Overworld() {
 
 	switch {
 	case strings.Contains(townStamp.RegionPath(), "E1"):
-		g.engine.PlaceStamp(townStamp, 0, 0)
+		g.placeTown(townStamp, 0, 0)
 		g.generateWilderness1TownEast(townSize.Width, 0)
 	case strings.Contains(townStamp.RegionPath(), "S1"):
-		g.engine.PlaceStamp(townStamp, mapWidth-townSize.Width, 0)
+		g.placeTown(townStamp, mapWidth-townSize.Width, 0)
 		rightWaterBorderStamp := g.loadPreset(d2wilderness.WaterBorderEast, 0)
 		rightWaterBorderStamp2 := g.loadPreset(d2wilderness.WaterBorderWest, 0)
 
@@ -74,15 +77,23 @@ func (g *MapGenerator) GenerateAct1Overworld() {
 
 		g.generateWilderness1TownSouth(startX, startY)
 	case strings.Contains(townStamp.RegionPath(), "W1"):
-		g.engine.PlaceStamp(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
+		g.placeTown(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
 		startX := mapWidth - townSize.Width - wilderness1Details.SizeXNormal
 		startY := mapHeight - wilderness1Details.SizeYNormal
 		g.generateWilderness1TownWest(startX, startY)
 	default:
-		g.engine.PlaceStamp(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
+		g.placeTown(townStamp, mapWidth-townSize.Width, mapHeight-townSize.Height)
 	}
 }
 
+// placeTown places the town stamp, the rest of the map belongs to the Blood Moor
+func (g *MapGenerator) placeTown(townStamp *d2mapstamp.Stamp, x, y int) {
+	townSize := townStamp.Size()
+
+	g.engine.PlaceStamp(townStamp, x, y)
+	g.level.addArea(act1TownLevelID, d2geom.Rectangle{Left: x, Top: y, Width: townSize.Width, Height: townSize.Height})
+}
+
 // nolint:gosec,gomnd // we dont need crypto-strong randomness, mapgen will get a refactor soon
 func (g *MapGenerator) generateWilderness1TownEast(startX, startY int) {
 	levelDetails := g.asset.Records.GetLevelDetails(wildernessDetailsRecordID)
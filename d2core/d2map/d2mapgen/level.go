@@ -0,0 +1,425 @@
+package d2mapgen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// Level IDs of the towns, from levels.txt
+const (
+	act1TownLevelID = 1
+	act2TownLevelID = 40
+	act3TownLevelID = 75
+	act4TownLevelID = 103
+	act5TownLevelID = 109
+)
+
+const (
+	numLevelLinks     = 8
+	visTileStyle      = 30            // style of the special tiles that link to another level, the sequence is the link index
+	warpReach         = 2             // distance in tiles from a warp that takes a player through it
+	arrivalDistance   = warpReach + 1 // distance in tiles from a warp where players arrive
+	subtilesPerTile   = 5
+	placementAttempts = 100
+)
+
+var (
+	errUnknownLevel = errors.New("unknown level")
+	errNoPresets    = errors.New("no level presets for level")
+	errNoMaze       = errors.New("no maze details for level")
+	errNoMazeRoom   = errors.New("no maze room preset")
+)
+
+// TownLevelID returns the level ID of the town of the given act, starting at 1
+func TownLevelID(act int) int {
+	switch act {
+	case 2: //nolint:gomnd // act number
+		return act2TownLevelID
+	case 3: //nolint:gomnd // act number
+		return act3TownLevelID
+	case 4: //nolint:gomnd // act number
+		return act4TownLevelID
+	case 5: //nolint:gomnd // act number
+		return act5TownLevelID
+	default:
+		return act1TownLevelID
+	}
+}
+
+// Level is a level generated by MapGenerator.GenerateLevel. A generated map can hold several levels, like the
+// Rogue Encampment and the Blood Moor, in which case the ID is the level the map was generated for.
+type Level struct {
+	ID    int
+	Warps []Warp
+	areas []levelArea
+}
+
+// Warp is a tile of a generated level that takes players to another level
+type Warp struct {
+	Record      *d2records.LevelWarpRecord // nil for the edges between outdoor levels
+	LevelID     int                        // the level the warp is in
+	Destination int                        // the level the warp leads to
+	TileX       int
+	TileY       int
+}
+
+// levelArea is the part of a generated map that belongs to a level, areas added later are placed over the
+// areas added before them
+type levelArea struct {
+	levelID int
+	rect    d2geom.Rectangle
+	exits   map[int]d2geom.Point // warp positions by link index, for links without a vis tile
+}
+
+func (l *Level) addArea(levelID int, rect d2geom.Rectangle) *levelArea {
+	l.areas = append(l.areas, levelArea{levelID: levelID, rect: rect, exits: make(map[int]d2geom.Point)})
+
+	return &l.areas[len(l.areas)-1]
+}
+
+func (l *Level) areaAt(tileX, tileY int) *levelArea {
+	for idx := len(l.areas) - 1; idx >= 0; idx-- {
+		if l.areas[idx].rect.IsInRect(tileX, tileY) {
+			return &l.areas[idx]
+		}
+	}
+
+	return nil
+}
+
+// Contains returns true if the given level is part of the generated map
+func (l *Level) Contains(levelID int) bool {
+	for idx := range l.areas {
+		if l.areas[idx].levelID == levelID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LevelAt returns the level of the given tile, or 0 when the tile is outside of all levels
+func (l *Level) LevelAt(tileX, tileY int) int {
+	if area := l.areaAt(tileX, tileY); area != nil {
+		return area.levelID
+	}
+
+	return 0
+}
+
+// WarpAt returns the warp that a player at the given tile position goes through, or nil
+func (l *Level) WarpAt(tileX, tileY int) *Warp {
+	for idx := range l.Warps {
+		warp := &l.Warps[idx]
+
+		if d2math.AbsInt(warp.TileX-tileX) <= warpReach && d2math.AbsInt(warp.TileY-tileY) <= warpReach {
+			return warp
+		}
+	}
+
+	return nil
+}
+
+// Arrival returns the tile position where players arrive that come from the given level, out of reach of the
+// warp they arrive by. It returns false when no warp of the level leads back to that level.
+func (l *Level) Arrival(fromLevelID int) (x, y float64, ok bool) {
+	for idx := range l.Warps {
+		warp := &l.Warps[idx]
+		if warp.Destination != fromLevelID {
+			continue
+		}
+
+		x, y = float64(warp.TileX)+0.5, float64(warp.TileY)+0.5 //nolint:gomnd // middle of the tile
+
+		if warp.Record != nil {
+			exitX := x + float64(warp.Record.ExitWalkX)/subtilesPerTile
+			exitY := y + float64(warp.Record.ExitWalkY)/subtilesPerTile
+
+			if d2math.AbsInt(int(exitX)-warp.TileX) > warpReach || d2math.AbsInt(int(exitY)-warp.TileY) > warpReach {
+				return exitX, exitY, true
+			}
+		}
+
+		// step away from the warp, towards the middle of the level
+		if area := l.areaAt(warp.TileX, warp.TileY); area != nil {
+			x += float64(d2math.SignInt(area.rect.Left+area.rect.Width/2-warp.TileX) * arrivalDistance)
+			y += float64(d2math.SignInt(area.rect.Top+area.rect.Height/2-warp.TileY) * arrivalDistance)
+		}
+
+		return x, y, true
+	}
+
+	return 0, 0, false
+}
+
+// GenerateLevel generates the map of the level with the given ID from levels.txt, replacing the map of the
+// engine. Dungeons and caves are laid out as mazes, outdoor levels are filled with the presets of their level
+// type, and the warps to the linked levels are looked up afterwards. The map only depends on the engine seed
+// and the level, so all generators with the same seed generate the same map.
+func (g *MapGenerator) GenerateLevel(levelID int) (*Level, error) {
+	details := g.asset.Records.GetLevelDetails(levelID)
+	if details == nil || details.LevelType <= 0 || details.LevelType >= len(g.asset.Records.Level.Types) {
+		return nil, fmt.Errorf("%w: %d", errUnknownLevel, levelID)
+	}
+
+	if levelID == wildernessDetailsRecordID {
+		// the Blood Moor is generated along with the Rogue Encampment
+		levelID = act1TownLevelID
+	}
+
+	if levelID == act1TownLevelID {
+		g.GenerateAct1Overworld()
+		g.linkWarps()
+
+		return g.level, nil
+	}
+
+	g.level = &Level{ID: levelID}
+
+	// each level has its own random source, so the order levels are generated in does not matter
+	rng := rand.New(rand.NewSource(g.engine.Seed() + int64(levelID))) //nolint:gosec // reproducible by design
+
+	var err error
+
+	switch details.LevelGenerationType {
+	case d2enum.LevelTypeRandomMaze:
+		err = g.generateMaze(rng, details)
+	case d2enum.LevelTypePreset:
+		err = g.generatePresetLevel(rng, details)
+	default:
+		err = g.generateOutdoors(rng, details)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.linkWarps()
+
+	return g.level, nil
+}
+
+// findPresets returns the level presets that match the filter, sorted by definition ID
+func (g *MapGenerator) findPresets(filter func(preset *d2records.LevelPresetRecord) bool) []d2records.LevelPresetRecord {
+	presets := make([]d2records.LevelPresetRecord, 0)
+
+	for id := range g.asset.Records.Level.Presets {
+		preset := g.asset.Records.Level.Presets[id]
+		if filter(&preset) {
+			presets = append(presets, preset)
+		}
+	}
+
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].DefinitionID < presets[j].DefinitionID
+	})
+
+	return presets
+}
+
+// loadStamp loads one of the files of the preset, picked with the given random source
+func (g *MapGenerator) loadStamp(rng *rand.Rand, regionType d2enum.RegionIdType,
+	preset *d2records.LevelPresetRecord) *d2mapstamp.Stamp {
+	numFiles := 0
+
+	for _, file := range preset.Files {
+		if file != "" && file != "0" {
+			numFiles++
+		}
+	}
+
+	if numFiles == 0 {
+		return nil
+	}
+
+	return g.engine.LoadStamp(regionType, preset.DefinitionID, rng.Intn(numFiles))
+}
+
+// addStampTiles adds the tiles of the stamps to the engine, which has to be reset beforehand
+func (g *MapGenerator) addStampTiles(stamps ...*d2mapstamp.Stamp) {
+	for _, stamp := range stamps {
+		g.engine.AddDS1(stamp.RegionPath())
+	}
+}
+
+func (g *MapGenerator) generatePresetLevel(rng *rand.Rand, details *d2records.LevelDetailsRecord) error {
+	regionType := d2enum.RegionIdType(details.LevelType)
+
+	presets := g.findPresets(func(preset *d2records.LevelPresetRecord) bool {
+		return preset.LevelID == details.ID
+	})
+
+	if len(presets) == 0 {
+		return fmt.Errorf("%w: %d", errNoPresets, details.ID)
+	}
+
+	stamp := g.loadStamp(rng, regionType, &presets[0])
+	if stamp == nil {
+		return fmt.Errorf("%w: %d", errNoPresets, details.ID)
+	}
+
+	size := stamp.Size()
+
+	g.engine.ResetMap(regionType, size.Width, size.Height)
+	g.addStampTiles(stamp)
+	g.engine.PlaceStamp(stamp, 0, 0)
+	g.level.addArea(details.ID, d2geom.Rectangle{Width: size.Width, Height: size.Height})
+
+	return nil
+}
+
+// levelLink is a level that can be reached from another level, with the graphics of the warp
+type levelLink struct {
+	index   int
+	levelID int
+	warpID  int
+}
+
+func levelLinks(details *d2records.LevelDetailsRecord) []levelLink {
+	levels := [numLevelLinks]int{
+		details.LevelLinkID0, details.LevelLinkID1, details.LevelLinkID2, details.LevelLinkID3,
+		details.LevelLinkID4, details.LevelLinkID5, details.LevelLinkID6, details.LevelLinkID7,
+	}
+
+	warps := [numLevelLinks]int{
+		details.WarpGraphicsID0, details.WarpGraphicsID1, details.WarpGraphicsID2, details.WarpGraphicsID3,
+		details.WarpGraphicsID4, details.WarpGraphicsID5, details.WarpGraphicsID6, details.WarpGraphicsID7,
+	}
+
+	links := make([]levelLink, 0, numLevelLinks)
+
+	for idx := range levels {
+		if levels[idx] > 0 {
+			links = append(links, levelLink{index: idx, levelID: levels[idx], warpID: warps[idx]})
+		}
+	}
+
+	return links
+}
+
+// linkWarps adds a warp for each link of the levels on the map. Links are placed on the vis tiles of the
+// level presets, then on the exits the generator picked, and outdoor levels without either are linked on the
+// edge that faces the linked level.
+func (g *MapGenerator) linkWarps() {
+	level := g.level
+
+	for areaIdx := range level.areas {
+		area := &level.areas[areaIdx]
+		details := g.asset.Records.GetLevelDetails(area.levelID)
+		links := levelLinks(details)
+		visTiles := g.findVisTiles(area)
+
+		for _, link := range links {
+			position, found := visTiles[link.index]
+
+			if !found {
+				position, found = area.exits[link.index]
+			}
+
+			if !found {
+				position = g.edgeTile(area, details, g.asset.Records.GetLevelDetails(link.levelID))
+			}
+
+			level.Warps = append(level.Warps, Warp{
+				Record:      g.asset.Records.Level.Warp[link.warpID],
+				LevelID:     area.levelID,
+				Destination: link.levelID,
+				TileX:       position.X,
+				TileY:       position.Y,
+			})
+		}
+	}
+}
+
+// findVisTiles returns the first vis tile of each link index in the area
+func (g *MapGenerator) findVisTiles(area *levelArea) map[int]d2geom.Point {
+	visTiles := make(map[int]d2geom.Point)
+
+	for y := area.rect.Top; y < area.rect.Bottom(); y++ {
+		for x := area.rect.Left; x < area.rect.Right(); x++ {
+			if g.level.areaAt(x, y) != area {
+				continue
+			}
+
+			walls := g.engine.Tile(x, y).Components.Walls
+
+			for idx := range walls {
+				if !walls[idx].Type.Special() || walls[idx].Style != visTileStyle {
+					continue
+				}
+
+				if _, found := visTiles[int(walls[idx].Sequence)]; !found {
+					visTiles[int(walls[idx].Sequence)] = d2geom.Point{X: x, Y: y}
+				}
+			}
+		}
+	}
+
+	return visTiles
+}
+
+// edgeTile returns a walkable tile on the edge of the area that faces the linked level, looking further
+// inwards when the edge is blocked. The world offsets of the levels tell where they are.
+func (g *MapGenerator) edgeTile(area *levelArea, from, to *d2records.LevelDetailsRecord) d2geom.Point {
+	rect := area.rect
+	center := d2geom.Point{X: rect.Left + rect.Width/2, Y: rect.Top + rect.Height/2}
+
+	if to == nil {
+		return center
+	}
+
+	dx := (to.WorldOffsetX + to.SizeXNormal/2) - (from.WorldOffsetX + from.SizeXNormal/2)
+	dy := (to.WorldOffsetY + to.SizeYNormal/2) - (from.WorldOffsetY + from.SizeYNormal/2)
+
+	if dx == 0 && dy == 0 {
+		return center
+	}
+
+	horizontal := d2math.AbsInt(dx) >= d2math.AbsInt(dy)
+	depth, length := rect.Height/2, rect.Width
+
+	if horizontal {
+		depth, length = rect.Width/2, rect.Height
+	}
+
+	for inset := 0; inset < depth; inset++ {
+		for step := 0; step < length; step++ {
+			// start in the middle of the edge and alternate between both sides of it
+			offset := (step + 1) / 2 * (1 - 2*(step%2)) //nolint:gomnd // alternating sign
+
+			var x, y int
+
+			if horizontal {
+				x, y = rect.Left+inset, center.Y+offset
+				if dx > 0 {
+					x = rect.Right() - 1 - inset
+				}
+			} else {
+				x, y = center.X+offset, rect.Top+inset
+				if dy > 0 {
+					y = rect.Bottom() - 1 - inset
+				}
+			}
+
+			if rect.IsInRect(x, y) && g.level.areaAt(x, y) == area && g.walkable(x, y) {
+				return d2geom.Point{X: x, Y: y}
+			}
+		}
+	}
+
+	return center
+}
+
+func (g *MapGenerator) walkable(tileX, tileY int) bool {
+	tile := g.engine.Tile(tileX, tileY)
+
+	return len(tile.Components.Floors) > 0 && !tile.GetSubTileFlags(2, 2).BlockWalk
+}
@@ -23,6 +23,12 @@ func NewMapGenerator(a *d2asset.AssetManager, e *d2mapengine.MapEngine) (*MapGen
 type MapGenerator struct {
 	asset  *d2asset.AssetManager
 	engine *d2mapengine.MapEngine
+	level  *Level // the level that was generated last
+}
+
+// Level returns the level that was generated last, or nil
+func (g *MapGenerator) Level() *Level {
+	return g.level
 }
 
 func (g *MapGenerator) loadPreset(id, index int) *d2mapstamp.Stamp {
@@ -0,0 +1,113 @@
+package d2mapgen
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// mazeRoomKey groups the room presets of a level type that fit the same spot of a maze
+type mazeRoomKey struct {
+	kind  mazeRoomKind
+	doors mazeDoors
+}
+
+// generateMaze lays out the rooms of a dungeon or a cave, the number of rooms comes from the maze details of
+// the level and the rooms are the presets of its level type. The room to the previous level leads to the
+// first linked level, the room to the next level to the second one.
+func (g *MapGenerator) generateMaze(rng *rand.Rand, details *d2records.LevelDetailsRecord) error {
+	maze, found := g.asset.Records.Level.Maze[details.ID]
+	if !found || maze == nil {
+		return fmt.Errorf("%w: %d", errNoMaze, details.ID)
+	}
+
+	regionType := d2enum.RegionIdType(details.LevelType)
+	roomPresets := g.mazeRoomPresets(g.asset.Records.Level.Types[details.LevelType].Name)
+	links := levelLinks(details)
+
+	layout := newMazeLayout(rng, maze.NumRoomsNormal, len(links) > 1)
+	stamps := make([]*d2mapstamp.Stamp, len(layout))
+	cellSize := d2geom.Size{}
+
+	for idx, room := range layout {
+		presets := roomPresets[mazeRoomKey{room.kind, room.doors}]
+		if len(presets) == 0 {
+			// use a plain room with the same doors, the warp goes in its middle
+			presets = roomPresets[mazeRoomKey{roomFiller, room.doors}]
+		}
+
+		if len(presets) == 0 {
+			return fmt.Errorf("%w: level type %d, doors %04b", errNoMazeRoom, details.LevelType, room.doors)
+		}
+
+		stamps[idx] = g.loadStamp(rng, regionType, &presets[rng.Intn(len(presets))])
+		if stamps[idx] == nil {
+			return fmt.Errorf("%w: level type %d, doors %04b", errNoMazeRoom, details.LevelType, room.doors)
+		}
+
+		size := stamps[idx].Size()
+
+		if size.Width > cellSize.Width {
+			cellSize.Width = size.Width
+		}
+
+		if size.Height > cellSize.Height {
+			cellSize.Height = size.Height
+		}
+	}
+
+	gridWidth, gridHeight := mazeLayoutSize(layout)
+	width, height := gridWidth*cellSize.Width, gridHeight*cellSize.Height
+
+	g.engine.ResetMap(regionType, width, height)
+	g.addStampTiles(stamps...)
+
+	area := g.level.addArea(details.ID, d2geom.Rectangle{Width: width, Height: height})
+
+	for idx, room := range layout {
+		x, y := room.x*cellSize.Width, room.y*cellSize.Height
+		size := stamps[idx].Size()
+
+		g.engine.PlaceStamp(stamps[idx], x, y)
+
+		exit := d2geom.Point{X: x + size.Width/2, Y: y + size.Height/2}
+
+		switch {
+		case room.kind == roomPrevious && len(links) > 0:
+			area.exits[links[0].index] = exit
+		case room.kind == roomNext && len(links) > 1:
+			area.exits[links[1].index] = exit
+		}
+	}
+
+	log.Printf("Generated maze for level %d with %d rooms on a %dx%d grid", details.ID, len(layout),
+		gridWidth, gridHeight)
+
+	return nil
+}
+
+// mazeRoomPresets returns the room presets of a level type by their kind and doors
+func (g *MapGenerator) mazeRoomPresets(levelTypeName string) map[mazeRoomKey][]d2records.LevelPresetRecord {
+	rooms := make(map[mazeRoomKey][]d2records.LevelPresetRecord)
+
+	presets := g.findPresets(func(preset *d2records.LevelPresetRecord) bool {
+		return preset.LevelID == 0
+	})
+
+	for idx := range presets {
+		kind, doors, ok := parseMazeRoom(levelTypeName, presets[idx].Name)
+		if !ok {
+			continue
+		}
+
+		key := mazeRoomKey{kind, doors}
+		rooms[key] = append(rooms[key], presets[idx])
+	}
+
+	return rooms
+}
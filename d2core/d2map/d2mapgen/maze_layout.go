@@ -0,0 +1,223 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// mazeDoors is a bit set of the sides of a maze room that lead to a neighbouring room
+type mazeDoors byte
+
+// Sides of a maze room
+const (
+	doorNorth mazeDoors = 1 << iota
+	doorEast
+	doorSouth
+	doorWest
+)
+
+// mazeRoomKind tells the rooms that lead to other levels apart from the rooms in between
+type mazeRoomKind int
+
+// Kinds of maze rooms
+const (
+	roomFiller mazeRoomKind = iota
+	roomPrevious
+	roomNext
+)
+
+// mazeRoom is a room of a maze layout, at a position of the maze grid
+type mazeRoom struct {
+	x, y  int
+	doors mazeDoors
+	kind  mazeRoomKind
+}
+
+//nolint:gochecknoglobals // the neighbour offset of each side
+var mazeSides = []struct {
+	door     mazeDoors
+	opposite mazeDoors
+	dx, dy   int
+}{
+	{doorNorth, doorSouth, 0, -1},
+	{doorEast, doorWest, 1, 0},
+	{doorSouth, doorNorth, 0, 1},
+	{doorWest, doorEast, -1, 0},
+}
+
+// newMazeLayout lays out the given number of rooms as a tree on a grid. The first room is the room to the
+// previous level, it only has a single door. When withNext is set, the leaf farthest away from it becomes
+// the room to the next level. The grid positions start at 0 and the rooms are in the order they were added.
+func newMazeLayout(rng *rand.Rand, numRooms int, withNext bool) []*mazeRoom {
+	const minRooms = 2
+
+	if numRooms < minRooms {
+		numRooms = minRooms
+	}
+
+	rooms := []*mazeRoom{{kind: roomPrevious}}
+	occupied := map[[2]int]bool{{0, 0}: true}
+
+	for len(rooms) < numRooms {
+		from := rooms[rng.Intn(len(rooms))]
+		side := mazeSides[rng.Intn(len(mazeSides))]
+
+		if from.kind == roomPrevious && from.doors != 0 {
+			continue
+		}
+
+		x, y := from.x+side.dx, from.y+side.dy
+		if occupied[[2]int{x, y}] {
+			continue
+		}
+
+		occupied[[2]int{x, y}] = true
+		from.doors |= side.door
+		rooms = append(rooms, &mazeRoom{x: x, y: y, doors: side.opposite})
+	}
+
+	if withNext {
+		farthestLeaf(rooms).kind = roomNext
+	}
+
+	normalizeMazeLayout(rooms)
+
+	return rooms
+}
+
+// farthestLeaf returns the room with a single door that takes the most rooms to reach from the first room
+func farthestLeaf(rooms []*mazeRoom) *mazeRoom {
+	byPosition := make(map[[2]int]*mazeRoom, len(rooms))
+	for _, room := range rooms {
+		byPosition[[2]int{room.x, room.y}] = room
+	}
+
+	distance := map[*mazeRoom]int{rooms[0]: 0}
+	queue := []*mazeRoom{rooms[0]}
+	farthest := rooms[len(rooms)-1]
+
+	for len(queue) > 0 {
+		room := queue[0]
+		queue = queue[1:]
+
+		if room != rooms[0] && room.doors.count() == 1 && distance[room] > distance[farthest] {
+			farthest = room
+		}
+
+		for _, side := range mazeSides {
+			if room.doors&side.door == 0 {
+				continue
+			}
+
+			neighbour := byPosition[[2]int{room.x + side.dx, room.y + side.dy}]
+			if _, seen := distance[neighbour]; !seen {
+				distance[neighbour] = distance[room] + 1
+				queue = append(queue, neighbour)
+			}
+		}
+	}
+
+	return farthest
+}
+
+func normalizeMazeLayout(rooms []*mazeRoom) {
+	minX, minY := 0, 0
+
+	for _, room := range rooms {
+		if room.x < minX {
+			minX = room.x
+		}
+
+		if room.y < minY {
+			minY = room.y
+		}
+	}
+
+	for _, room := range rooms {
+		room.x -= minX
+		room.y -= minY
+	}
+}
+
+// mazeLayoutSize returns the size of the layout in rooms
+func mazeLayoutSize(rooms []*mazeRoom) (width, height int) {
+	for _, room := range rooms {
+		if room.x >= width {
+			width = room.x + 1
+		}
+
+		if room.y >= height {
+			height = room.y + 1
+		}
+	}
+
+	return width, height
+}
+
+func (d mazeDoors) count() int {
+	count := 0
+
+	for _, side := range mazeSides {
+		if d&side.door != 0 {
+			count++
+		}
+	}
+
+	return count
+}
+
+// parseMazeRoom reads the kind and the doors of a maze room from the name of its level preset. The room
+// presets of a level type are named after it, followed by the kind and the sides with a door, for example
+// "Act 1 - Cave Prev W", "Act 1 - Cave Next E" or "Act 1 - Cave 12 NSW".
+func parseMazeRoom(levelTypeName, presetName string) (kind mazeRoomKind, doors mazeDoors, ok bool) {
+	if !strings.HasPrefix(presetName, levelTypeName+" ") {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(strings.TrimPrefix(presetName, levelTypeName))
+
+	const numFields = 2
+
+	if len(fields) != numFields {
+		return 0, 0, false
+	}
+
+	switch fields[0] {
+	case "Prev":
+		kind = roomPrevious
+	case "Next":
+		kind = roomNext
+	default:
+		if _, err := strconv.Atoi(fields[0]); err != nil {
+			return 0, 0, false
+		}
+
+		kind = roomFiller
+	}
+
+	for _, side := range fields[1] {
+		var door mazeDoors
+
+		switch side {
+		case 'N':
+			door = doorNorth
+		case 'E':
+			door = doorEast
+		case 'S':
+			door = doorSouth
+		case 'W':
+			door = doorWest
+		default:
+			return 0, 0, false
+		}
+
+		if doors&door != 0 {
+			return 0, 0, false
+		}
+
+		doors |= door
+	}
+
+	return kind, doors, true
+}
@@ -0,0 +1,107 @@
+package d2mapgen
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestNewMazeLayout(t *testing.T) {
+	for seed := int64(0); seed < 50; seed++ {
+		numRooms := 2 + int(seed%15)
+		layout := newMazeLayout(rand.New(rand.NewSource(seed)), numRooms, true)
+
+		if len(layout) != numRooms {
+			t.Fatalf("seed %d: expected %d rooms, got %d", seed, numRooms, len(layout))
+		}
+
+		byPosition := make(map[[2]int]*mazeRoom)
+		numDoors, numPrevious, numNext := 0, 0, 0
+
+		for _, room := range layout {
+			position := [2]int{room.x, room.y}
+
+			if room.x < 0 || room.y < 0 || byPosition[position] != nil {
+				t.Fatalf("seed %d: bad room position %v", seed, position)
+			}
+
+			byPosition[position] = room
+			numDoors += room.doors.count()
+
+			switch room.kind {
+			case roomPrevious:
+				numPrevious++
+			case roomNext:
+				numNext++
+			}
+
+			if room.kind != roomFiller && room.doors.count() != 1 {
+				t.Errorf("seed %d: room of kind %d has doors %04b", seed, room.kind, room.doors)
+			}
+		}
+
+		if numPrevious != 1 || numNext != 1 {
+			t.Errorf("seed %d: %d previous and %d next rooms", seed, numPrevious, numNext)
+		}
+
+		// every door has a door on the other side, and the rooms form a tree
+		for _, room := range layout {
+			for _, side := range mazeSides {
+				if room.doors&side.door == 0 {
+					continue
+				}
+
+				neighbour := byPosition[[2]int{room.x + side.dx, room.y + side.dy}]
+				if neighbour == nil || neighbour.doors&side.opposite == 0 {
+					t.Errorf("seed %d: door %04b of room %d,%d leads nowhere", seed, side.door, room.x, room.y)
+				}
+			}
+		}
+
+		if numDoors != 2*(numRooms-1) {
+			t.Errorf("seed %d: expected %d doors, got %d", seed, 2*(numRooms-1), numDoors)
+		}
+	}
+}
+
+func TestNewMazeLayout_Deterministic(t *testing.T) {
+	first := newMazeLayout(rand.New(rand.NewSource(1234)), 12, true)
+	second := newMazeLayout(rand.New(rand.NewSource(1234)), 12, true)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed gave different layouts")
+	}
+}
+
+func TestNewMazeLayout_WithoutNext(t *testing.T) {
+	for _, room := range newMazeLayout(rand.New(rand.NewSource(5)), 8, false) {
+		if room.kind == roomNext {
+			t.Error("unexpected room to the next level")
+		}
+	}
+}
+
+func TestParseMazeRoom(t *testing.T) {
+	tests := []struct {
+		name  string
+		kind  mazeRoomKind
+		doors mazeDoors
+		ok    bool
+	}{
+		{"Act 1 - Cave Prev W", roomPrevious, doorWest, true},
+		{"Act 1 - Cave Next E", roomNext, doorEast, true},
+		{"Act 1 - Cave 12 NSW", roomFiller, doorNorth | doorSouth | doorWest, true},
+		{"Act 1 - Cave 3 NN", 0, 0, false},
+		{"Act 1 - Cave Den Of Evil", 0, 0, false},
+		{"Act 1 - Cavern 1 N", 0, 0, false},
+		{"Act 1 - Crypt 1 N", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		kind, doors, ok := parseMazeRoom("Act 1 - Cave", test.name)
+
+		if ok != test.ok || kind != test.kind || doors != test.doors {
+			t.Errorf("%s: got %d %04b %t", test.name, kind, doors, ok)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package d2mapgen
+
+import (
+	"log"
+	"math/rand"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2ds1"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2geom"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapstamp"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	fillArea     = 400 // number of tiles per fill preset in outdoor levels
+	fillFailures = 10  // number of fill presets that found no room before the level counts as full
+)
+
+// generateOutdoors generates the wilderness levels of Acts 2 to 5, and the ones of Act 1 that are not part
+// of the Act 1 overworld. The level is covered with the base floor of its level type, then the presets of
+// the level, like the entrances of dungeons, are placed, and the fill presets of the level type are scattered
+// over the rest of it.
+func (g *MapGenerator) generateOutdoors(rng *rand.Rand, details *d2records.LevelDetailsRecord) error {
+	regionType := d2enum.RegionIdType(details.LevelType)
+	levelTypeName := g.asset.Records.Level.Types[details.LevelType].Name
+
+	ownPresets := g.findPresets(func(preset *d2records.LevelPresetRecord) bool {
+		return preset.LevelID == details.ID
+	})
+
+	fillPresets := g.findPresets(func(preset *d2records.LevelPresetRecord) bool {
+		return preset.LevelID == 0 && preset.Outdoors && strings.HasPrefix(preset.Name, levelTypeName+" ") &&
+			strings.Contains(preset.Name, "Fill")
+	})
+
+	width, height := details.SizeXNormal, details.SizeYNormal
+
+	ownStamps := g.loadStamps(rng, regionType, ownPresets)
+	for _, stamp := range ownStamps {
+		// presets are placed inside the level, with a tile to spare
+		width = d2math.MaxInt(width, stamp.Size().Width+1)
+		height = d2math.MaxInt(height, stamp.Size().Height+1)
+	}
+
+	fillStamps := g.loadStamps(rng, regionType, fillPresets)
+
+	g.engine.ResetMap(regionType, width, height)
+	g.addStampTiles(ownStamps...)
+	g.addStampTiles(fillStamps...)
+
+	rect := d2geom.Rectangle{Width: width, Height: height}
+	g.level.addArea(details.ID, rect)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			tile := g.engine.Tile(x, y)
+			tile.RegionType = regionType
+			tile.Components.Floors = []d2ds1.FloorShadowRecord{{Prop1: 1, Style: 0, Sequence: 0}}
+			tile.PrepareTile(x, y, g.engine)
+		}
+	}
+
+	for _, stamp := range ownStamps {
+		if !g.placeRandomly(rng, stamp, rect) {
+			log.Printf("No room for preset %s in level %d", stamp.RegionPath(), details.ID)
+		}
+	}
+
+	if len(fillStamps) == 0 {
+		return nil
+	}
+
+	for placed, failed := 0, 0; placed < width*height/fillArea && failed < fillFailures; {
+		if g.placeRandomly(rng, fillStamps[rng.Intn(len(fillStamps))], rect) {
+			placed++
+		} else {
+			failed++
+		}
+	}
+
+	return nil
+}
+
+// loadStamps loads one stamp for each preset, presets without files are skipped
+func (g *MapGenerator) loadStamps(rng *rand.Rand, regionType d2enum.RegionIdType,
+	presets []d2records.LevelPresetRecord) []*d2mapstamp.Stamp {
+	stamps := make([]*d2mapstamp.Stamp, 0, len(presets))
+
+	for idx := range presets {
+		if stamp := g.loadStamp(rng, regionType, &presets[idx]); stamp != nil {
+			stamps = append(stamps, stamp)
+		}
+	}
+
+	return stamps
+}
+
+// placeRandomly places the stamp on an empty spot of the area, it returns false when no empty spot was found
+func (g *MapGenerator) placeRandomly(rng *rand.Rand, stamp *d2mapstamp.Stamp, area d2geom.Rectangle) bool {
+	size := stamp.Size()
+
+	if size.Width >= area.Width || size.Height >= area.Height {
+		return false
+	}
+
+	for attempt := 0; attempt < placementAttempts; attempt++ {
+		stampRect := d2geom.Rectangle{
+			Left:   area.Left + rng.Intn(area.Width-size.Width),
+			Top:    area.Top + rng.Intn(area.Height-size.Height),
+			Width:  size.Width,
+			Height: size.Height,
+		}
+
+		if areaEmpty(g.engine, stampRect) {
+			g.engine.PlaceStamp(stamp, stampRect.Left, stampRect.Top)
+			return true
+		}
+	}
+
+	return false
+}
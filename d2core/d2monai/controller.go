@@ -0,0 +1,330 @@
+package d2monai
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+const (
+	// TicksPerSecond is the rate at which monsters think, the same as the frame rate of the original game
+	TicksPerSecond = 25
+
+	tickLength     = 1.0 / TicksPerSecond
+	tickEpsilon    = 1e-6
+	attackTicks    = 15  // ticks an attack takes before the monster decides again
+	wanderChance   = 5   // percentage of decisions where an idle monster starts wandering
+	wanderRadius   = 10  // sub tiles from the spawn point that monsters wander
+	fleeDistance   = 10  // sub tiles a fleeing monster runs
+	leashFactor    = 1.5 // targets are followed up to this times the aggro distance
+	repathDistance = 1   // sub tiles a target moves before an approaching monster finds a new path
+	percent        = 100
+)
+
+// MonsterEntity is a map entity that can be controlled by the monster AI
+type MonsterEntity interface {
+	Body
+	MonStats() *d2records.MonStatsRecord
+}
+
+// Controller runs the monster AI at a fixed tick. All random decisions are taken from a single random
+// source and the monsters think in the order of their IDs, so the same seed gives the same game.
+type Controller struct {
+	world      World
+	records    *d2records.RecordManager
+	difficulty d2enum.DifficultyType
+	rng        *rand.Rand
+	monsters   map[string]*Monster
+	order      []string
+	elapsed    float64
+	tick       uint64
+	listener   func(change StateChange)
+	targets    func() []Target
+}
+
+// NewController creates a monster AI controller for the monsters of the given world
+func NewController(world World, records *d2records.RecordManager, difficulty d2enum.DifficultyType,
+	seed int64) *Controller {
+	return &Controller{
+		world:      world,
+		records:    records,
+		difficulty: difficulty,
+		rng:        rand.New(rand.NewSource(seed)), //nolint:gosec // reproducible by design
+		monsters:   make(map[string]*Monster),
+	}
+}
+
+// SetStateListener sets the function that is called with each state change of a monster, for example to
+// replicate the monsters to the clients
+func (c *Controller) SetStateListener(listener func(change StateChange)) {
+	c.listener = listener
+}
+
+// SetTargetSource sets the function that returns the targets of the monsters. By default the targets are
+// the entities of the world that implement Target.
+func (c *Controller) SetTargetSource(targets func() []Target) {
+	c.targets = targets
+}
+
+// Populate adds the monsters of the world that the controller does not control yet, it returns how many
+// monsters were added
+func (c *Controller) Populate() int {
+	entities := c.world.Entities()
+	ids := make([]string, 0, len(entities))
+
+	for id := range entities {
+		if _, found := c.monsters[id]; !found {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Strings(ids)
+
+	added := 0
+
+	for _, id := range ids {
+		entity, ok := entities[id].(MonsterEntity)
+		if !ok || entity.MonStats() == nil {
+			continue
+		}
+
+		c.Add(entity, entity.MonStats())
+		added++
+	}
+
+	return added
+}
+
+// Add starts controlling the given body with the AI of the given monster type
+func (c *Controller) Add(body Body, stats *d2records.MonStatsRecord) *Monster {
+	profile := NewProfile(stats, c.records.Monster.Stats2[stats.ExtraDataKey], c.records.Monster.AI[stats.AiKey],
+		c.difficulty)
+
+	return c.AddProfile(body, profile)
+}
+
+// AddProfile starts controlling the given body with the given AI profile
+func (c *Controller) AddProfile(body Body, profile *Profile) *Monster {
+	life := profile.MinLife
+	if profile.MaxLife > profile.MinLife {
+		life += c.rng.Intn(profile.MaxLife - profile.MinLife + 1)
+	}
+
+	monster := &Monster{
+		body:    body,
+		profile: profile,
+		home:    body.GetPosition(),
+		goal:    body.GetPosition(),
+		Life:    life,
+		MaxLife: life,
+	}
+
+	if _, found := c.monsters[body.ID()]; !found {
+		idx := sort.SearchStrings(c.order, body.ID())
+		c.order = append(c.order, "")
+		copy(c.order[idx+1:], c.order[idx:])
+		c.order[idx] = body.ID()
+	}
+
+	c.monsters[body.ID()] = monster
+
+	return monster
+}
+
+// Remove stops controlling the monster with the given ID
+func (c *Controller) Remove(id string) {
+	if _, found := c.monsters[id]; !found {
+		return
+	}
+
+	delete(c.monsters, id)
+
+	idx := sort.SearchStrings(c.order, id)
+	c.order = append(c.order[:idx], c.order[idx+1:]...)
+}
+
+// Monster returns the monster with the given ID, or nil
+func (c *Controller) Monster(id string) *Monster {
+	return c.monsters[id]
+}
+
+// Tick returns the number of ticks the controller has run
+func (c *Controller) Tick() uint64 {
+	return c.tick
+}
+
+// Advance runs as many ticks as fit in the elapsed time, the rest is kept for the next call
+func (c *Controller) Advance(elapsed float64) {
+	c.elapsed += elapsed
+
+	// the epsilon keeps rounding errors from dropping a tick when the elapsed time is a multiple of the tick
+	ticks := int(c.elapsed*TicksPerSecond + tickEpsilon)
+	c.elapsed = math.Max(0, c.elapsed-float64(ticks)*tickLength)
+
+	for i := 0; i < ticks; i++ {
+		c.Step()
+	}
+}
+
+// Step runs a single tick
+func (c *Controller) Step() {
+	c.tick++
+
+	var targets []Target
+	if c.targets != nil {
+		targets = c.targets()
+	} else {
+		targets = c.worldTargets()
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i].ID() < targets[j].ID()
+	})
+
+	for _, id := range c.order {
+		c.think(c.monsters[id], targets)
+	}
+}
+
+// worldTargets returns the entities of the world that monsters attack
+func (c *Controller) worldTargets() []Target {
+	targets := make([]Target, 0)
+
+	for _, entity := range c.world.Entities() {
+		if target, ok := entity.(Target); ok {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+func (c *Controller) think(m *Monster, targets []Target) {
+	if m.IsDead() || m.profile.Kind == KindPassive {
+		return
+	}
+
+	if m.cooldown > 0 {
+		m.cooldown--
+		return
+	}
+
+	m.cooldown = m.profile.Delay
+
+	target := m.acquireTarget(targets)
+	if target == nil {
+		c.idle(m)
+		return
+	}
+
+	position := m.body.GetPosition()
+	targetPosition := target.GetPosition()
+	distance := position.Distance(&targetPosition.Vector)
+
+	switch {
+	case m.profile.Kind == KindCowardly && m.Life*percent < m.MaxLife*m.profile.FleeLife:
+		away := position.Clone()
+		away.Subtract(&targetPosition.Vector).SetLength(fleeDistance).Add(&position.Vector)
+		c.moveTo(m, BehaviourFlee, target.ID(), d2vector.NewPosition(away.X(), away.Y()))
+	case distance <= m.profile.AttackRange:
+		behaviour := BehaviourMelee
+		if m.profile.Kind == KindRanged {
+			behaviour = BehaviourRanged
+		}
+
+		m.body.StopMoving()
+		m.cooldown += attackTicks
+		m.goal = position
+		m.state = behaviour
+		m.target = target.ID()
+		c.emit(m)
+	case m.state != BehaviourApproach || m.target != target.ID() ||
+		m.goal.Distance(&targetPosition.Vector) > repathDistance:
+		c.moveTo(m, BehaviourApproach, target.ID(), targetPosition)
+	}
+}
+
+// acquireTarget keeps the current target while it is in reach, otherwise it picks the closest target within
+// the aggro distance
+func (m *Monster) acquireTarget(targets []Target) Target {
+	position := m.body.GetPosition()
+
+	var closest Target
+
+	closestDistance := m.profile.AggroDistance
+
+	for _, target := range targets {
+		if target.IsInTown() {
+			continue
+		}
+
+		targetPosition := target.GetPosition()
+		distance := position.Distance(&targetPosition.Vector)
+
+		if target.ID() == m.target && distance <= m.profile.AggroDistance*leashFactor {
+			return target
+		}
+
+		if distance <= closestDistance {
+			closest, closestDistance = target, distance
+		}
+	}
+
+	return closest
+}
+
+// idle lets a monster without a target stand around, or wander around the spot it spawned at
+func (c *Controller) idle(m *Monster) {
+	position := m.body.GetPosition()
+
+	if m.state == BehaviourWander && !position.EqualsApprox(&m.goal.Vector) {
+		return
+	}
+
+	if c.rng.Intn(percent) >= wanderChance {
+		if m.state != BehaviourIdle || m.target != "" {
+			m.body.StopMoving()
+			m.state, m.target, m.goal = BehaviourIdle, "", position
+			c.emit(m)
+		}
+
+		return
+	}
+
+	dest := d2vector.NewPosition(
+		m.home.X()+(c.rng.Float64()*2-1)*wanderRadius,
+		m.home.Y()+(c.rng.Float64()*2-1)*wanderRadius,
+	)
+
+	c.moveTo(m, BehaviourWander, "", dest)
+}
+
+func (c *Controller) moveTo(m *Monster, behaviour Behaviour, targetID string, dest d2vector.Position) {
+	path := c.world.PathFind(m.body.GetPosition(), dest)
+	if len(path) == 0 {
+		return
+	}
+
+	m.body.SetPath(path, nil)
+	m.state, m.target, m.goal = behaviour, targetID, path[len(path)-1]
+	c.emit(m)
+}
+
+func (c *Controller) emit(m *Monster) {
+	if c.listener == nil {
+		return
+	}
+
+	c.listener(StateChange{
+		MonsterID: m.ID(),
+		Behaviour: m.state,
+		TargetID:  m.target,
+		X:         m.goal.X(),
+		Y:         m.goal.Y(),
+		Tick:      c.tick,
+	})
+}
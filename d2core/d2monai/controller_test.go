@@ -0,0 +1,251 @@
+package d2monai
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+// testWorld finds straight paths and has no entities, the targets are given to the controller directly
+type testWorld struct{}
+
+func (w *testWorld) Entities() map[string]d2interface.MapEntity {
+	return nil
+}
+
+func (w *testWorld) PathFind(_, dest d2vector.Position) []d2vector.Position {
+	return []d2vector.Position{dest}
+}
+
+// testBody moves one sub tile towards the end of its path on each step
+type testBody struct {
+	id       string
+	position d2vector.Position
+	path     []d2vector.Position
+}
+
+func (b *testBody) ID() string {
+	return b.id
+}
+
+func (b *testBody) GetPosition() d2vector.Position {
+	return b.position
+}
+
+func (b *testBody) SetPath(path []d2vector.Position, _ func()) {
+	b.path = path
+}
+
+func (b *testBody) StopMoving() {
+	b.path = nil
+}
+
+func (b *testBody) step() {
+	if len(b.path) == 0 {
+		return
+	}
+
+	dest := b.path[len(b.path)-1]
+	move := dest.Clone()
+	move.Subtract(&b.position.Vector)
+
+	if move.Length() <= 1 {
+		b.position, b.path = dest, nil
+		return
+	}
+
+	move.SetLength(1)
+	b.position.Add(move)
+}
+
+type testTarget struct {
+	id       string
+	position d2vector.Position
+	inTown   bool
+}
+
+func (t *testTarget) ID() string {
+	return t.id
+}
+
+func (t *testTarget) GetPosition() d2vector.Position {
+	return t.position
+}
+
+func (t *testTarget) IsInTown() bool {
+	return t.inTown
+}
+
+type testGame struct {
+	controller *Controller
+	bodies     []*testBody
+	changes    []StateChange
+}
+
+func newTestGame(seed int64, targets ...*testTarget) *testGame {
+	game := &testGame{controller: NewController(&testWorld{}, nil, 0, seed)}
+
+	game.controller.SetStateListener(func(change StateChange) {
+		game.changes = append(game.changes, change)
+	})
+
+	game.controller.SetTargetSource(func() []Target {
+		result := make([]Target, len(targets))
+		for i := range targets {
+			result[i] = targets[i]
+		}
+
+		return result
+	})
+
+	return game
+}
+
+func (g *testGame) add(id string, x, y float64, profile Profile) *Monster {
+	body := &testBody{id: id, position: d2vector.NewPosition(x, y)}
+	g.bodies = append(g.bodies, body)
+
+	return g.controller.AddProfile(body, &profile)
+}
+
+func (g *testGame) run(ticks int) {
+	for i := 0; i < ticks; i++ {
+		g.controller.Step()
+
+		for _, body := range g.bodies {
+			body.step()
+		}
+	}
+}
+
+func (g *testGame) behaviours(monsterID string) []Behaviour {
+	result := make([]Behaviour, 0)
+
+	for _, change := range g.changes {
+		if change.MonsterID == monsterID &&
+			(len(result) == 0 || result[len(result)-1] != change.Behaviour) {
+			result = append(result, change.Behaviour)
+		}
+	}
+
+	return result
+}
+
+func meleeProfile() Profile {
+	return Profile{Kind: KindMelee, AggroDistance: 20, MeleeRange: 2, AttackRange: 2, FleeLife: 50,
+		MinLife: 10, MaxLife: 10}
+}
+
+func TestController_ApproachAndMelee(t *testing.T) {
+	game := newTestGame(1, &testTarget{id: "player", position: d2vector.NewPosition(10, 0)})
+	monster := game.add("zombie", 0, 0, meleeProfile())
+
+	game.run(20)
+
+	expected := []Behaviour{BehaviourApproach, BehaviourMelee}
+	if got := game.behaviours("zombie"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected behaviours %v, got %v", expected, got)
+	}
+
+	if monster.Target() != "player" {
+		t.Errorf("expected the monster to target the player, got %q", monster.Target())
+	}
+}
+
+func TestController_Ranged(t *testing.T) {
+	game := newTestGame(1, &testTarget{id: "player", position: d2vector.NewPosition(10, 0)})
+
+	profile := meleeProfile()
+	profile.Kind, profile.AttackRange = KindRanged, rangedDistance
+	game.add("archer", 0, 0, profile)
+
+	game.run(1)
+
+	expected := []Behaviour{BehaviourRanged}
+	if got := game.behaviours("archer"); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected behaviours %v, got %v", expected, got)
+	}
+}
+
+func TestController_Flee(t *testing.T) {
+	game := newTestGame(1, &testTarget{id: "player", position: d2vector.NewPosition(3, 0)})
+
+	profile := meleeProfile()
+	profile.Kind = KindCowardly
+	monster := game.add("fallen", 0, 0, profile)
+	monster.Life = 2
+
+	game.run(1)
+
+	if monster.Behaviour() != BehaviourFlee {
+		t.Fatalf("expected the monster to flee, got %s", monster.Behaviour())
+	}
+
+	if x := game.changes[0].X; x >= 0 {
+		t.Errorf("expected the monster to flee away from the player, got destination x %f", x)
+	}
+}
+
+func TestController_IgnoresTargets(t *testing.T) {
+	game := newTestGame(1,
+		&testTarget{id: "far", position: d2vector.NewPosition(100, 0)},
+		&testTarget{id: "town", position: d2vector.NewPosition(1, 0), inTown: true},
+	)
+
+	game.add("zombie", 0, 0, meleeProfile())
+
+	passive := meleeProfile()
+	passive.Kind = KindPassive
+	game.add("cow", 5, 0, passive)
+
+	game.run(200)
+
+	for _, change := range game.changes {
+		if change.MonsterID == "cow" {
+			t.Fatalf("passive monster changed state: %+v", change)
+		}
+
+		if change.Behaviour != BehaviourIdle && change.Behaviour != BehaviourWander {
+			t.Fatalf("monster without a target changed state: %+v", change)
+		}
+	}
+}
+
+func TestController_Deterministic(t *testing.T) {
+	play := func() []StateChange {
+		target := &testTarget{id: "player", position: d2vector.NewPosition(60, 60)}
+		game := newTestGame(42, target)
+
+		for i := 0; i < 5; i++ {
+			game.add(string(rune('a'+i)), float64(i*10), 0, meleeProfile())
+		}
+
+		game.run(100)
+		target.position = d2vector.NewPosition(10, 10)
+		game.run(100)
+
+		return game.changes
+	}
+
+	first, second := play(), play()
+
+	if len(first) == 0 {
+		t.Fatal("expected state changes")
+	}
+
+	if !reflect.DeepEqual(first, second) {
+		t.Error("the same seed gave different state changes")
+	}
+}
+
+func TestController_Advance(t *testing.T) {
+	game := newTestGame(1)
+
+	game.controller.Advance(1)
+
+	if game.controller.Tick() != TicksPerSecond {
+		t.Errorf("expected %d ticks, got %d", TicksPerSecond, game.controller.Tick())
+	}
+}
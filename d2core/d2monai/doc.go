@@ -0,0 +1,2 @@
+// Package d2monai provides the server side monster AI, driven by the records of monai.txt and monstats.txt
+package d2monai
@@ -0,0 +1,108 @@
+package d2monai
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+// Behaviour is what a monster is doing
+type Behaviour int
+
+// Monster behaviours
+const (
+	BehaviourIdle Behaviour = iota
+	BehaviourWander
+	BehaviourApproach
+	BehaviourMelee
+	BehaviourRanged
+	BehaviourFlee
+)
+
+func (b Behaviour) String() string {
+	switch b {
+	case BehaviourIdle:
+		return "Idle"
+	case BehaviourWander:
+		return "Wander"
+	case BehaviourApproach:
+		return "Approach"
+	case BehaviourMelee:
+		return "Melee"
+	case BehaviourRanged:
+		return "Ranged"
+	case BehaviourFlee:
+		return "Flee"
+	}
+
+	return "Unknown"
+}
+
+// Body is the map entity a monster AI moves around, it is implemented by d2mapentity.NPC
+type Body interface {
+	ID() string
+	GetPosition() d2vector.Position
+	SetPath(path []d2vector.Position, done func())
+	StopMoving()
+}
+
+// Target is a map entity that monsters attack, it is implemented by d2mapentity.Player. Targets in town
+// are left alone.
+type Target interface {
+	ID() string
+	GetPosition() d2vector.Position
+	IsInTown() bool
+}
+
+// World is the part of the map engine that the monster AI uses to find targets and paths
+type World interface {
+	Entities() map[string]d2interface.MapEntity
+	PathFind(start, dest d2vector.Position) []d2vector.Position
+}
+
+// StateChange tells that a monster changed its behaviour, target or destination. It is also sent for each
+// attack, even when the monster keeps attacking the same target.
+type StateChange struct {
+	MonsterID string
+	Behaviour Behaviour
+	TargetID  string  // empty when the monster has no target
+	X, Y      float64 // destination in sub tiles, the position of the monster when it stands still
+	Tick      uint64
+}
+
+// Monster is the AI state of a monster on the map
+type Monster struct {
+	body     Body
+	profile  *Profile
+	home     d2vector.Position
+	goal     d2vector.Position
+	state    Behaviour
+	target   string
+	cooldown int
+	Life     int
+	MaxLife  int
+}
+
+// ID returns the ID of the map entity of the monster
+func (m *Monster) ID() string {
+	return m.body.ID()
+}
+
+// Profile returns the AI settings of the monster
+func (m *Monster) Profile() *Profile {
+	return m.profile
+}
+
+// Behaviour returns what the monster is doing
+func (m *Monster) Behaviour() Behaviour {
+	return m.state
+}
+
+// Target returns the ID of the entity the monster is after, or an empty string
+func (m *Monster) Target() string {
+	return m.target
+}
+
+// IsDead returns true when the monster has no life left
+func (m *Monster) IsDead() bool {
+	return m.Life <= 0
+}
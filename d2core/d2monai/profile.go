@@ -0,0 +1,141 @@
+package d2monai
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// Kind is the family of behaviours an AI code falls into
+type Kind int
+
+// AI kinds
+const (
+	// KindPassive monsters never attack, like the towners and critters
+	KindPassive Kind = iota
+
+	// KindMelee monsters approach their target and hit it
+	KindMelee
+
+	// KindRanged monsters stay at a distance and shoot at their target
+	KindRanged
+
+	// KindCowardly monsters fight in melee, but run away when they are hurt
+	KindCowardly
+)
+
+const (
+	numParameters        = 8
+	defaultAggroDistance = 35 // sub tiles, used when aidist is blank
+	defaultMeleeRange    = 2  // sub tiles, used when MeleeRng is blank
+	rangedDistance       = 15 // sub tiles a ranged monster keeps from its target
+	defaultFleeLife      = 50 // percentage of life below which cowardly monsters flee
+)
+
+//nolint:gochecknoglobals // lookup table of the AI codes in monai.txt that are not melee
+var aiKinds = map[string]Kind{
+	"Idle":          KindPassive,
+	"Npc":           KindPassive,
+	"NpcStationary": KindPassive,
+	"NpcOutOfTown":  KindPassive,
+	"Towner":        KindPassive,
+	"Vendor":        KindPassive,
+	"Navi":          KindPassive,
+	"Hireable":      KindPassive,
+	"Sarcophagus":   KindPassive,
+	"MaggotEgg":     KindPassive,
+	"FoulCrowNest":  KindPassive,
+	"SkeletonBow":   KindRanged,
+	"CorruptArcher": KindRanged,
+	"QuillRat":      KindRanged,
+	"Bighead":       KindRanged,
+	"FallenShaman":  KindRanged,
+	"SandMaggot":    KindRanged,
+	"Vulture":       KindRanged,
+	"ZakarumPriest": KindRanged,
+	"GoodNpcRanged": KindRanged,
+	"Fallen":        KindCowardly,
+	"Fetish":        KindCowardly,
+	"Scarab":        KindCowardly,
+	"FetishShaman":  KindRanged,
+}
+
+// Profile holds the AI settings of a monster type, taken from monstats.txt and monstats2.txt for a difficulty
+type Profile struct {
+	AI            string  // the AI code, one of the records in monai.txt
+	Kind          Kind    // behaviours of the AI code
+	AggroDistance float64 // distance in sub tiles at which targets are noticed
+	MeleeRange    float64 // distance in sub tiles from which the monster hits
+	AttackRange   float64 // distance in sub tiles from which the monster attacks
+	Delay         int     // ticks between the decisions of the monster
+	FleeLife      int     // percentage of life below which the monster flees
+	MinLife       int
+	MaxLife       int
+	Parameters    [numParameters]int // the aip columns, their meaning depends on the AI code
+}
+
+// NewProfile creates the AI profile of a monster type. The record of monai.txt is nil for AI codes that
+// are not in it, those monsters are passive.
+func NewProfile(stats *d2records.MonStatsRecord, stats2 *d2records.MonStats2Record,
+	ai *d2records.MonsterAIRecord, difficulty d2enum.DifficultyType) *Profile {
+	profile := &Profile{
+		AI:            stats.AiKey,
+		Kind:          KindMelee,
+		AggroDistance: defaultAggroDistance,
+		MeleeRange:    defaultMeleeRange,
+		FleeLife:      defaultFleeLife,
+	}
+
+	var aggroDistance int
+
+	switch difficulty {
+	case d2enum.DifficultyNightmare:
+		profile.Delay, aggroDistance = stats.AiDelayNightmare, stats.AiDistanceNightmare
+		profile.MinLife, profile.MaxLife = stats.MinHPNightmare, stats.MaxHPNightmare
+		profile.Parameters = [numParameters]int{
+			stats.AiParameterNightmare1, stats.AiParameterNightmare2, stats.AiParameterNightmare3,
+			stats.AiParameterNightmare4, stats.AiParameterNightmare5, stats.AiParameterNightmare6,
+			stats.AiParameterNightmare7, stats.AiParameterNightmare8,
+		}
+	case d2enum.DifficultyHell:
+		profile.Delay, aggroDistance = stats.AiDelayHell, stats.AiDistanceHell
+		profile.MinLife, profile.MaxLife = stats.MinHPHell, stats.MaxHPHell
+		profile.Parameters = [numParameters]int{
+			stats.AiParameterHell1, stats.AiParameterHell2, stats.AiParameterHell3, stats.AiParameterHell4,
+			stats.AiParameterHell5, stats.AiParameterHell6, stats.AiParameterHell7, stats.AiParameterHell8,
+		}
+	default:
+		profile.Delay, aggroDistance = stats.AiDelayNormal, stats.AiDistanceNormal
+		profile.MinLife, profile.MaxLife = stats.MinHPNormal, stats.MaxHPNormal
+		profile.Parameters = [numParameters]int{
+			stats.AiParameterNormal1, stats.AiParameterNormal2, stats.AiParameterNormal3,
+			stats.AiParameterNormal4, stats.AiParameterNormal5, stats.AiParameterNormal6,
+			stats.AiParameterNormal7, stats.AiParameterNormal8,
+		}
+	}
+
+	if aggroDistance > 0 {
+		profile.AggroDistance = float64(aggroDistance)
+	}
+
+	if stats2 != nil && stats2.MeleeRng > 0 {
+		profile.MeleeRange = float64(stats2.MeleeRng)
+	}
+
+	if kind, found := aiKinds[stats.AiKey]; found {
+		profile.Kind = kind
+	}
+
+	switch {
+	case ai == nil, stats.IsNpc, stats.Alignment != d2enum.MonsterEnemy:
+		profile.Kind = KindPassive
+	case profile.Kind == KindMelee && stats.IsRanged:
+		profile.Kind = KindRanged
+	}
+
+	profile.AttackRange = profile.MeleeRange
+	if profile.Kind == KindRanged {
+		profile.AttackRange = rangedDistance
+	}
+
+	return profile
+}
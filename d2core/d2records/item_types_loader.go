@@ -1,6 +1,8 @@
 package d2records
 
 import (
+	"sort"
+
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2txt"
 )
@@ -115,6 +117,11 @@ func LoadItemEquivalencies(allItems CommonItems, allTypes ItemTypes) ItemEquival
 		}
 	}
 
+	// the items are sorted by their code, so that picking one of them by a seeded roll is repeatable
+	for _, list := range equivMap {
+		sort.Slice(list, func(a, b int) bool { return list[a].Code < list[b].Code })
+	}
+
 	return equivMap
 }
 
@@ -3,6 +3,7 @@ package d2records
 import (
 	"fmt"
 	"log"
+	"sort"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 
@@ -379,6 +380,8 @@ func (r *RecordManager) FindEquivalentTypesByItemCommonRecord(
 				}
 			}
 		}
+
+		sort.Strings(r.Item.EquivalenceByRecord[icr])
 	}
 
 	return r.Item.EquivalenceByRecord[icr]
@@ -68,7 +68,7 @@ func runewordLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 
 		for idx := 0; idx < numRunewordProperties; idx++ {
 			codeColumn := fmt.Sprintf(fmtRunewordPropCode, idx+1)
-			if code := codeColumn; code != "" {
+			if code := d.String(codeColumn); code != "" {
 				prop := &RunewordProperty{
 					code,
 					d.String(fmt.Sprintf(fmtRunewordPropParam, idx+1)),
@@ -91,7 +91,8 @@ type SetItemRecord struct {
 	// Properties are a propert code, parameter, min, max for generating an item propert
 	Properties [numPropertiesOnSetItem]*SetItemProperty
 
-	// SetPropertiesLevel1 is the first version of bonus properties for the set
+	// SetPropertiesLevel1 is the first version of bonus properties for the set, the property at
+	// index n is granted when n+2 items of the set are equipped
 	SetPropertiesLevel1 [numBonusPropertiesOnSetItem]*SetItemProperty
 
 	// SetPropertiesLevel2 is the second version of bonus properties for the set
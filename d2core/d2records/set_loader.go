@@ -36,8 +36,8 @@ func setLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 				PartialB []*SetProperty
 				Full     []*SetProperty
 			}{
-				PartialA: make([]*SetProperty, 0),
-				PartialB: make([]*SetProperty, 0),
+				PartialA: make([]*SetProperty, numPartialSetProperties),
+				PartialB: make([]*SetProperty, numPartialSetProperties),
 				Full:     make([]*SetProperty, 0),
 			},
 		}
@@ -60,7 +60,7 @@ func setLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 					Max:       d.Number(maxColumn),
 				}
 
-				record.Properties.PartialA = append(record.Properties.PartialA, propA)
+				record.Properties.PartialA[idx] = propA
 			}
 
 			if codeB := d.String(columnB); codeB != "" {
@@ -75,7 +75,7 @@ func setLoader(r *RecordManager, d *d2txt.DataDictionary) error {
 					Max:       d.Number(maxColumn),
 				}
 
-				record.Properties.PartialB = append(record.Properties.PartialB, propB)
+				record.Properties.PartialB[idx] = propB
 			}
 		}
 
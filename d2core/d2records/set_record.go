@@ -22,7 +22,8 @@ type SetRecord struct {
 	// (reference only, not loaded into game).
 	Level int
 
-	// Properties contains the partial and full set bonus properties.
+	// Properties contains the partial and full set bonus properties. The partial properties at index
+	// 0, 1, 2 and 3 are granted with 2, 3, 4 and 5 items of the set, they are nil where the set grants none.
 	Properties struct {
 		PartialA []*SetProperty
 		PartialB []*SetProperty
@@ -4,8 +4,6 @@ import (
 	"errors"
 	"image"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
-
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 
@@ -36,7 +34,7 @@ var _ d2interface.Renderer = &Renderer{}
 type Renderer struct {
 	updateCallback
 	renderCallback
-	*d2util.GlyphPrinter
+	*GlyphPrinter
 	lastRenderError error
 }
 
@@ -71,7 +69,7 @@ func (r *Renderer) Layout(_, _ int) (width, height int) {
 // CreateRenderer creates an ebiten renderer instance
 func CreateRenderer(cfg *d2config.Configuration) (*Renderer, error) {
 	result := &Renderer{
-		GlyphPrinter: d2util.NewDebugPrinter(),
+		GlyphPrinter: NewDebugPrinter(),
 	}
 
 	if cfg != nil {
@@ -207,7 +207,7 @@ func (s *ebitenSurface) handleStateEffect(opts *ebiten.DrawImageOptions) {
 // DrawTextf renders the string to the surface with the given format string and a set of parameters
 func (s *ebitenSurface) DrawTextf(format string, params ...interface{}) {
 	str := fmt.Sprintf(format, params...)
-	s.Renderer().PrintAt(s.image, str, s.stateCurrent.x, s.stateCurrent.y)
+	s.renderer.PrintAt(s.image, str, s.stateCurrent.x, s.stateCurrent.y)
 }
 
 // DrawLine draws a line
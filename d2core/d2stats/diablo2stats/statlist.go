@@ -72,34 +72,35 @@ func (sl *Diablo2StatList) ReduceStats() d2stats.StatList {
 	clone := sl.Clone()
 	reduction := make([]d2stats.Stat, 0)
 
-	// for quick lookups
-	lookup := make(map[string]int)
+	// for quick lookups, stats with the same name which could not be combined
+	// (like skill levels of different skills) have several indices
+	lookup := make(map[string][]int)
 
 	for len(clone.Stats()) > 0 {
 		stat := clone.Pop()
-
-		// if we find it in the lookup, immediately try to combine
-		// if it doesn't combine, we append to the reduction
-		if idx, found := lookup[stat.Name()]; found {
-			if result, err := reduction[idx].Combine(stat); err == nil {
-				reduction[idx] = result
-				continue
-			}
+		if stat == nil {
+			continue
 		}
 
-		// we didnt find it in the lookup, so we will try to combine with other stats
-		for idx := range reduction {
-			if _, err := reduction[idx].Combine(stat); err == nil {
-				continue
-			}
+		if !reduceInto(reduction, lookup[stat.Name()], stat) {
+			lookup[stat.Name()] = append(lookup[stat.Name()], len(reduction))
+			reduction = append(reduction, stat)
 		}
+	}
 
-		lookup[stat.Name()] = len(lookup)
+	return clone.SetStats(reduction)
+}
 
-		reduction = append(reduction, stat)
+// reduceInto combines the stat with the first of the stats at the indices it can be combined with
+func reduceInto(reduction []d2stats.Stat, indices []int, stat d2stats.Stat) bool {
+	for _, idx := range indices {
+		if result, err := reduction[idx].Combine(stat); err == nil {
+			reduction[idx] = result
+			return true
+		}
 	}
 
-	return clone.SetStats(reduction)
+	return false
 }
 
 // RemoveStatAtIndex removes the stat from the stat list, returns the stat
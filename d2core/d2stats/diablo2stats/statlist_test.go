@@ -108,3 +108,40 @@ func TestStatList_Append(t *testing.T) {
 		t.Errorf("diablo2Stat append failed")
 	}
 }
+
+func TestStatList_ReduceDistinctStatic(t *testing.T) {
+	// the skill levels of different skills can not be combined, but each
+	// of them still has to be combined with the levels of the same skill
+	list := testStatFactory.NewStatList(
+		testStatFactory.NewStat("item_nonclassskill", 1, 64),
+		testStatFactory.NewStat("strength", 1),
+		testStatFactory.NewStat("item_nonclassskill", 2, 37),
+		testStatFactory.NewStat("energy", 1),
+		testStatFactory.NewStat("item_nonclassskill", 3, 64),
+		testStatFactory.NewStat("strength", 2),
+		testStatFactory.NewStat("item_nonclassskill", 4, 37),
+	)
+
+	reduction := list.ReduceStats()
+
+	expected := map[string]bool{
+		"+4 to Frozen Orb": true,
+		"+6 to Warmth":     true,
+		"+3 to Strength":   true,
+		"+1 to Energy":     true,
+	}
+
+	if len(reduction.Stats()) != len(expected) {
+		t.Fatalf("expected %d stats, got %d", len(expected), len(reduction.Stats()))
+	}
+
+	for _, stat := range reduction.Stats() {
+		if !expected[stat.String()] {
+			t.Errorf("unexpected stat %q", stat.String())
+		}
+	}
+
+	if len(list.Stats()) != 7 {
+		t.Error("the reduction altered the stat list")
+	}
+}
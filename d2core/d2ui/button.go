@@ -42,6 +42,7 @@ const (
 	ButtonTypeMinipanelMen       ButtonType = 19
 	ButtonTypeSquareClose        ButtonType = 20
 	ButtonTypeSkillTreeTab       ButtonType = 21
+	ButtonTypeAddSkill           ButtonType = 22
 
 	ButtonNoFixedWidth  int = -1
 	ButtonNoFixedHeight int = -1
@@ -119,6 +120,10 @@ const (
 	buttonRunSegmentsY     = 1
 	buttonRunDisabledFrame = -1
 
+	buttonAddSkillSegmentsX     = 1
+	buttonAddSkillSegmentsY     = 1
+	buttonAddSkillDisabledFrame = -1
+
 	pressedButtonOffset = 2
 )
 
@@ -235,6 +240,19 @@ func getButtonLayouts() map[ButtonType]ButtonLayout {
 			FixedHeight:      buttonSkillTreeTabFixedHeight,
 			LabelColor:       whiteAlpha100,
 		},
+		ButtonTypeAddSkill: {
+			XSegments:        buttonAddSkillSegmentsX,
+			YSegments:        buttonAddSkillSegmentsY,
+			DisabledFrame:    buttonAddSkillDisabledFrame,
+			ResourceName:     d2resource.AddSkillButton,
+			PaletteName:      d2resource.PaletteSky,
+			FontPath:         d2resource.Font16,
+			AllowFrameChange: true,
+			HasImage:         true,
+			FixedWidth:       ButtonNoFixedWidth,
+			FixedHeight:      ButtonNoFixedHeight,
+			LabelColor:       greyAlpha100,
+		},
 	}
 }
 
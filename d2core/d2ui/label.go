@@ -133,7 +133,7 @@ func (v *Label) processColorTokens(str string) string {
 	matches := tokenStrMatch.FindAll([]byte(str), -1)
 
 	if len(matches) == 0 {
-		v.Color[0] = getColor(ColorTokenWhite)
+		v.Color[0] = getColor(d2util.ColorTokenWhite)
 	}
 
 	// we find the index of each token and update the color map.
@@ -142,7 +142,7 @@ func (v *Label) processColorTokens(str string) string {
 		match := matches[idx]
 		matchToken := tokenMatch.Find(match)
 		matchStr := string(tokenMatch.ReplaceAll(match, empty))
-		token := ColorToken(matchToken)
+		token := d2util.ColorToken(matchToken)
 		theColor := getColor(token)
 
 		if v.Color == nil {
@@ -176,24 +176,24 @@ func (v *Label) Advance(elapsed float64) error {
 	return nil
 }
 
-func getColor(token ColorToken) color.Color {
+func getColor(token d2util.ColorToken) color.Color {
 	// https://github.com/OpenDiablo2/OpenDiablo2/issues/823
-	colors := map[ColorToken]color.Color{
-		ColorTokenGrey:   d2util.Color(colorGrey100Alpha),
-		ColorTokenWhite:  d2util.Color(colorWhite100Alpha),
-		ColorTokenBlue:   d2util.Color(colorBlue100Alpha),
-		ColorTokenYellow: d2util.Color(colorYellow100Alpha),
-		ColorTokenGreen:  d2util.Color(colorGreen100Alpha),
-		ColorTokenGold:   d2util.Color(colorGold100Alpha),
-		ColorTokenOrange: d2util.Color(colorOrange100Alpha),
-		ColorTokenRed:    d2util.Color(colorRed100Alpha),
-		ColorTokenBlack:  d2util.Color(colorBlack100Alpha),
+	colors := map[d2util.ColorToken]color.Color{
+		d2util.ColorTokenGrey:   d2util.Color(colorGrey100Alpha),
+		d2util.ColorTokenWhite:  d2util.Color(colorWhite100Alpha),
+		d2util.ColorTokenBlue:   d2util.Color(colorBlue100Alpha),
+		d2util.ColorTokenYellow: d2util.Color(colorYellow100Alpha),
+		d2util.ColorTokenGreen:  d2util.Color(colorGreen100Alpha),
+		d2util.ColorTokenGold:   d2util.Color(colorGold100Alpha),
+		d2util.ColorTokenOrange: d2util.Color(colorOrange100Alpha),
+		d2util.ColorTokenRed:    d2util.Color(colorRed100Alpha),
+		d2util.ColorTokenBlack:  d2util.Color(colorBlack100Alpha),
 	}
 
 	chosen := colors[token]
 
 	if chosen == nil {
-		return colors[ColorTokenWhite]
+		return colors[d2util.ColorTokenWhite]
 	}
 
 	return chosen
@@ -215,3 +215,8 @@ func (v *TextBox) OnActivated(_ func()) {
 func (v *TextBox) Activate() {
 	v.isFocused = true
 }
+
+// Deactivate deactivates the text box, it no longer receives the typed characters
+func (v *TextBox) Deactivate() {
+	v.isFocused = false
+}
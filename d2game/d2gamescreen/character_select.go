@@ -12,6 +12,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
@@ -340,9 +341,9 @@ func (v *CharacterSelect) updateCharacterBoxes() {
 		heroName := v.gameStates[idx].HeroName
 		heroInfo := "Level 1 " + v.gameStates[idx].HeroType.String()
 
-		v.characterNameLabel[i].SetText(d2ui.ColorTokenize(heroName, d2ui.ColorTokenGold))
-		v.characterStatsLabel[i].SetText(d2ui.ColorTokenize(heroInfo, d2ui.ColorTokenWhite))
-		v.characterExpLabel[i].SetText(d2ui.ColorTokenize(expText, d2ui.ColorTokenGreen))
+		v.characterNameLabel[i].SetText(d2util.ColorTokenize(heroName, d2util.ColorTokenGold))
+		v.characterStatsLabel[i].SetText(d2util.ColorTokenize(heroInfo, d2util.ColorTokenWhite))
+		v.characterExpLabel[i].SetText(d2util.ColorTokenize(expText, d2util.ColorTokenGreen))
 
 		heroType := v.gameStates[idx].HeroType
 		equipment := v.DefaultHeroItems[heroType]
@@ -1,7 +1,10 @@
 package d2gamescreen
 
 import (
+	"image/color"
+	"io"
 	"log"
+	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2video"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
@@ -25,6 +28,10 @@ const (
 	cinematicsExitBtnX, cinematicsExitBtnY = 340, 470
 )
 
+// videoDecodeAhead is how far frames are decoded ahead of the clock, so that the audio of the following
+// frames is queued before it is played
+const videoDecodeAhead = 500 * time.Millisecond
+
 // Cinematics represents the cinematics screen
 type Cinematics struct {
 	cinematicsBackground *d2ui.Sprite
@@ -45,6 +52,12 @@ type Cinematics struct {
 	uiManager     *d2ui.UIManager
 	videoDecoder  *d2video.BinkDecoder
 	audioProvider d2interface.AudioProvider
+
+	videoSurface d2interface.Surface
+	videoStream  d2interface.PCMStream
+	videoFrames  []*d2video.BinkFrame
+	videoClock   time.Duration
+	videoDecoded bool
 }
 
 // CreateCinematics creates an instance of the credits screen
@@ -140,6 +153,11 @@ func (v *Cinematics) createButtons() {
 }
 
 func (v *Cinematics) onCinematicsExitBtnClicked() {
+	if v.videoDecoder != nil {
+		v.stopVideo()
+		return
+	}
+
 	v.navigator.ToMainMenu()
 }
 
@@ -178,11 +196,143 @@ func (v *Cinematics) playVideo(path string) {
 		return
 	}
 
-	v.videoDecoder = d2video.CreateBinkDecoder(videoBytes)
+	v.videoDecoder, err = d2video.CreateBinkDecoder(videoBytes)
+	if err != nil {
+		log.Print(err)
+		v.videoDecoder = nil
+
+		return
+	}
+
+	v.videoSurface = v.renderer.NewSurface(int(v.videoDecoder.VideoWidth), int(v.videoDecoder.VideoHeight))
+	v.videoFrames = nil
+	v.videoClock = 0
+	v.videoDecoded = false
+
+	if len(v.videoDecoder.AudioTracks) > 0 {
+		track := v.videoDecoder.AudioTracks[0]
+
+		channels := 1
+		if track.Stereo {
+			channels = 2
+		}
+
+		v.videoStream, err = v.audioProvider.CreatePCMStream(int(track.AudioSampleRateHz), channels)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+
+	v.setButtonsVisible(false)
+
+	if err := v.decodeVideoFrames(v.videoClock + videoDecodeAhead); err != nil {
+		log.Print(err)
+		v.stopVideo()
+
+		return
+	}
+
+	if v.videoStream != nil {
+		v.videoStream.Play()
+	}
+}
+
+// decodeVideoFrames decodes the frames up to the given time, and queues their audio
+func (v *Cinematics) decodeVideoFrames(until time.Duration) error {
+	for !v.videoDecoded {
+		if count := len(v.videoFrames); count > 0 && v.videoFrames[count-1].Time >= until {
+			return nil
+		}
+
+		frame, err := v.videoDecoder.DecodeFrame()
+		if err == io.EOF {
+			v.videoDecoded = true
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if v.videoStream != nil && len(frame.Audio) > 0 {
+			v.videoStream.Write(frame.Audio[0])
+		}
+
+		v.videoFrames = append(v.videoFrames, frame)
+	}
+
+	return nil
+}
+
+func (v *Cinematics) stopVideo() {
+	if v.videoStream != nil {
+		if err := v.videoStream.Close(); err != nil {
+			log.Print(err)
+		}
+	}
+
+	v.videoDecoder = nil
+	v.videoStream = nil
+	v.videoSurface = nil
+	v.videoFrames = nil
+
+	v.setButtonsVisible(true)
+}
+
+// setButtonsVisible shows or hides the buttons that start videos, the cancel button stops a video
+func (v *Cinematics) setButtonsVisible(visible bool) {
+	for _, button := range []*d2ui.Button{v.a1Btn, v.a2Btn, v.a3Btn, v.a4Btn, v.a5Btn, v.endCreditClassBtn,
+		v.endCreditExpBtn} {
+		button.SetVisible(visible)
+	}
+}
+
+// Advance shows the frames of the playing video, in time with its audio when it has any
+func (v *Cinematics) Advance(elapsed float64) error {
+	if v.videoDecoder == nil {
+		return nil
+	}
+
+	v.videoClock += time.Duration(elapsed * float64(time.Second))
+
+	// once all audio is queued, the wall clock shows the frames that are left
+	if v.videoStream != nil && !v.videoDecoded {
+		v.videoClock = v.videoStream.Position()
+	}
+
+	if err := v.decodeVideoFrames(v.videoClock + videoDecodeAhead); err != nil {
+		log.Print(err)
+		v.stopVideo()
+
+		return nil
+	}
+
+	var current *d2video.BinkFrame
+
+	for len(v.videoFrames) > 0 && v.videoFrames[0].Time <= v.videoClock {
+		current, v.videoFrames = v.videoFrames[0], v.videoFrames[1:]
+	}
+
+	if current != nil {
+		v.videoSurface.ReplacePixels(current.Image.Pix)
+	}
+
+	length := time.Duration(v.videoDecoder.FrameCount()) * v.videoDecoder.FrameDuration()
+
+	if v.videoDecoded && len(v.videoFrames) == 0 && v.videoClock >= length {
+		v.stopVideo()
+	}
+
+	return nil
 }
 
 // Render renders the credits screen
 func (v *Cinematics) Render(screen d2interface.Surface) {
+	if v.videoSurface != nil {
+		v.renderVideo(screen)
+		return
+	}
+
 	err := v.background.RenderSegmented(screen, 4, 3, 0)
 
 	if err != nil {
@@ -201,3 +351,12 @@ func (v *Cinematics) Render(screen d2interface.Surface) {
 		return
 	}
 }
+
+func (v *Cinematics) renderVideo(screen d2interface.Surface) {
+	screen.Clear(color.Black)
+
+	width, height := v.videoSurface.GetSize()
+	screen.PushTranslation((screenWidth-width)/2, (screenHeight-height)/2) //nolint:gomnd // centered
+	screen.Render(v.videoSurface)
+	screen.Pop()
+}
@@ -3,6 +3,7 @@ package d2gamescreen
 import (
 	"fmt"
 	"image/color"
+	"sort"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
@@ -14,6 +15,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2audio"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2maprenderer"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2screen"
@@ -29,6 +31,16 @@ const (
 	bindControlsErrStr = "failed to add gameControls as input handler for player: %s\n"
 	castErrStr         = "failed to send CastSkill packet to the server, playerId: %s, skillId: %d, x: %g, x: %g\n"
 	spawnItemErrStr    = "failed to send SpawnItem packet to the server: (%d, %d) %+v"
+	chatErrStr         = "failed to send Chat packet to the server: %s\n"
+	spendStatErrStr    = "failed to send SpendStatPoint packet to the server: %s\n"
+	learnSkillErrStr   = "failed to send LearnSkill packet to the server: %s\n"
+)
+
+const (
+	chatColor        = 0xffffffff // rgba
+	chatPartyColor   = 0x18ff00ff
+	chatWhisperColor = 0xd8c480ff
+	chatSystemColor  = 0xffff6eff
 )
 
 const (
@@ -50,6 +62,7 @@ type Game struct {
 	soundEngine          *d2audio.SoundEngine
 	soundEnv             d2audio.SoundEnvironment
 	guiManager           *d2gui.GuiManager
+	keyMap               *d2player.KeyMap
 
 	renderer      d2interface.Renderer
 	inputManager  d2interface.InputManager
@@ -68,6 +81,7 @@ func CreateGame(
 	gameClient *d2client.GameClient,
 	term d2interface.Terminal,
 	guiManager *d2gui.GuiManager,
+	keyMap *d2player.KeyMap,
 ) *Game {
 	// find the local player and its initial location
 	var startX, startY float64
@@ -100,6 +114,7 @@ func CreateGame(
 		soundEngine:   d2audio.NewSoundEngine(audioProvider, asset, term),
 		uiManager:     ui,
 		guiManager:    guiManager,
+		keyMap:        keyMap,
 	}
 	result.soundEnv = d2audio.NewSoundEnvironment(result.soundEngine)
 
@@ -164,6 +179,30 @@ func (v *Game) OnLoad(_ d2screen.LoadingState) {
 	if err != nil {
 		fmt.Printf("failed to bind the '%s' action, err: %v\n", "spawnmon", err)
 	}
+
+	err = v.terminal.BindAction("latency", "shows the latency of each player to the server", v.printLatencies)
+	if err != nil {
+		fmt.Printf("failed to bind the '%s' action, err: %v\n", "latency", err)
+	}
+}
+
+func (v *Game) printLatencies() {
+	latencies := v.gameClient.Latencies()
+	if len(latencies) == 0 {
+		v.terminal.OutputWarningf("the server has not measured the latencies yet")
+		return
+	}
+
+	names := make([]string, 0, len(latencies))
+	for name := range latencies {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		v.terminal.OutputInfof("%s: %d ms", name, latencies[name].Milliseconds())
+	}
 }
 
 // OnUnload releases the resources of Gameplay screen
@@ -186,6 +225,10 @@ func (v *Game) OnUnload() error {
 		return err
 	}
 
+	if err := v.terminal.UnbindAction("latency"); err != nil {
+		return err
+	}
+
 	if err := v.OnPlayerSave(); err != nil {
 		return err
 	}
@@ -227,9 +270,15 @@ func (v *Game) Advance(elapsed float64) error {
 
 	if (v.escapeMenu != nil && !v.escapeMenu.IsOpen()) || len(v.gameClient.Players) != 1 {
 		v.gameClient.MapEngine.Advance(elapsed)
+		v.gameClient.Advance(elapsed)
 	}
 
 	if v.gameControls != nil {
+		messages := v.gameClient.ChatMessages()
+		for i := range messages {
+			v.showChatMessage(&messages[i])
+		}
+
 		if err := v.gameControls.Advance(elapsed); err != nil {
 			return err
 		}
@@ -290,13 +339,14 @@ func (v *Game) bindGameControls() error {
 
 		var err error
 		v.gameControls, err = d2player.NewGameControls(v.asset, v.renderer, player, v.gameClient.MapEngine,
-			v.escapeMenu, v.mapRenderer, v, v.terminal, v.uiManager, v.guiManager, v.gameClient.IsSinglePlayer())
+			v.escapeMenu, v.mapRenderer, v, v.terminal, v.uiManager, v.guiManager, v.keyMap, v.gameClient.IsSinglePlayer())
 
 		if err != nil {
 			return err
 		}
 
 		v.gameControls.Load()
+		v.gameControls.SetDifficulty(v.gameClient.Difficulty)
 
 		if err := v.inputManager.BindHandler(v.gameControls); err != nil {
 			fmt.Printf(bindControlsErrStr, player.ID())
@@ -308,15 +358,9 @@ func (v *Game) bindGameControls() error {
 	return nil
 }
 
-// OnPlayerMove sends the player move action to the server
+// OnPlayerMove moves the local player, the move is predicted by the game client and sent to the server
 func (v *Game) OnPlayerMove(targetX, targetY float64) {
-	worldPosition := v.localPlayer.Position.World()
-
-	playerID, worldX, worldY := v.gameClient.PlayerID, worldPosition.X(), worldPosition.Y()
-	createMovePlayerPacket := d2netpacket.CreateMovePlayerPacket(playerID, worldX, worldY, targetX, targetY)
-	err := v.gameClient.SendPacketToServer(createMovePlayerPacket)
-
-	if err != nil {
+	if err := v.gameClient.MovePlayer(targetX, targetY); err != nil {
 		fmt.Printf(moveErrStr, v.gameClient.PlayerID, targetX, targetY)
 	}
 }
@@ -341,6 +385,52 @@ func (v *Game) OnPlayerCast(skillID int, targetX, targetY float64) {
 	}
 }
 
+// OnPlayerChat sends the chat message of the local player to the server
+func (v *Game) OnPlayerChat(message string) {
+	if err := v.gameClient.SendChat(message); err != nil {
+		fmt.Printf(chatErrStr, err)
+	}
+}
+
+// OnPlayerSpendStatPoint asks the server to spend a stat point of the local player on the attribute
+func (v *Game) OnPlayerSpendStatPoint(attribute d2hero.Attribute) {
+	if err := v.gameClient.SpendStatPoint(attribute); err != nil {
+		fmt.Printf(spendStatErrStr, err)
+	}
+}
+
+// OnPlayerLearnSkill asks the server to spend a skill point of the local player on the skill
+func (v *Game) OnPlayerLearnSkill(skillID int) {
+	if err := v.gameClient.LearnSkill(skillID); err != nil {
+		fmt.Printf(learnSkillErrStr, err)
+	}
+}
+
+// showChatMessage adds a chat message to the HUD, in the color of its channel
+func (v *Game) showChatMessage(message *d2netpacket.ChatPacket) {
+	text := fmt.Sprintf("%s: %s", message.From, message.Message)
+	textColor := d2util.Color(chatColor)
+
+	switch message.Channel {
+	case d2netpacket.ChatParty:
+		text = "(party) " + text
+		textColor = d2util.Color(chatPartyColor)
+	case d2netpacket.ChatWhisper:
+		if v.localPlayer != nil && message.From == v.localPlayer.Name() {
+			text = fmt.Sprintf("You whisper to %s: %s", message.To, message.Message)
+		} else {
+			text = fmt.Sprintf("%s whispers: %s", message.From, message.Message)
+		}
+
+		textColor = d2util.Color(chatWhisperColor)
+	case d2netpacket.ChatSystem:
+		text = message.Message
+		textColor = d2util.Color(chatSystemColor)
+	}
+
+	v.gameControls.AddChatMessage(text, textColor)
+}
+
 func (v *Game) debugSpawnItemAtPlayer(codes ...string) {
 	if v.localPlayer == nil {
 		return
@@ -31,6 +31,7 @@ const (
 	ScreenModeMultiplayer
 	ScreenModeTCPIP
 	ScreenModeServerIP
+	ScreenModeGameList
 )
 
 const (
@@ -111,6 +112,7 @@ type MainMenu struct {
 	tcpJoinGameLabel    *d2ui.Label
 	errorLabel          *d2ui.Label
 	tcpJoinGameEntry    *d2ui.TextBox
+	gameList            *gameList
 	screenMode          mainMenuScreenMode
 	leftButtonHeld      bool
 
@@ -176,6 +178,7 @@ func (v *MainMenu) OnLoad(loading d2screen.LoadingState) {
 	v.tcpJoinGameEntry = v.uiManager.NewTextbox()
 	v.tcpJoinGameEntry.SetPosition(joinGameDialogX, joinGameDialogY)
 	v.tcpJoinGameEntry.SetFilter(joinGameCharacterFilter)
+	v.createGameList()
 	loading.Progress(ninetyPercent)
 
 	if v.screenMode == ScreenModeUnknown {
@@ -447,7 +450,7 @@ func (v *MainMenu) renderBackgrounds(screen d2interface.Surface) {
 		if err := v.serverIPBackground.RenderSegmented(screen, 2, 1, 0); err != nil {
 			return
 		}
-	case ScreenModeTCPIP:
+	case ScreenModeTCPIP, ScreenModeGameList:
 		if err := v.tcpIPBackground.RenderSegmented(screen, 4, 3, 0); err != nil {
 			return
 		}
@@ -475,6 +478,9 @@ func (v *MainMenu) renderLabels(screen d2interface.Surface) {
 		v.tcpJoinGameLabel.RenderNoError(screen)
 	case ScreenModeTCPIP:
 		v.tcpIPOptionsLabel.RenderNoError(screen)
+	case ScreenModeGameList:
+		v.tcpIPOptionsLabel.RenderNoError(screen)
+		v.renderGameList(screen)
 	case ScreenModeTrademark:
 		v.copyrightLabel.RenderNoError(screen)
 		v.copyrightLabel2.RenderNoError(screen)
@@ -508,6 +514,8 @@ func (v *MainMenu) Advance(tickTime float64) error {
 		if err := v.diabloLogoRight.Advance(tickTime); err != nil {
 			return err
 		}
+	case ScreenModeGameList:
+		v.advanceGameList()
 	}
 
 	return nil
@@ -520,6 +528,10 @@ func (v *MainMenu) OnMouseButtonDown(event d2interface.MouseEvent) bool {
 		return true
 	}
 
+	if v.screenMode == ScreenModeGameList {
+		return v.onGameListMouseDown(event)
+	}
+
 	return false
 }
 
@@ -556,6 +568,10 @@ func (v *MainMenu) OnKeyUp(event d2interface.KeyEvent) bool {
 	case ScreenModeServerIP: // back to previous menu
 		v.onEscapePressed(event, ScreenModeTCPIP)
 
+		preventKeyEventPropagation = true
+	case ScreenModeGameList: // back to previous menu
+		v.onEscapePressed(event, ScreenModeServerIP)
+
 		preventKeyEventPropagation = true
 	}
 
@@ -590,6 +606,7 @@ func (v *MainMenu) SetScreenMode(screenMode mainMenuScreenMode) {
 
 	v.btnServerIPOk.SetVisible(isServerIP)
 	v.btnServerIPCancel.SetVisible(isServerIP)
+	v.setGameListVisible(screenMode == ScreenModeGameList)
 }
 
 func (v *MainMenu) onNetworkCancelClicked() {
@@ -620,6 +637,9 @@ func (v *MainMenu) onBtnTCPIPCancelClicked() {
 	v.SetScreenMode(ScreenModeTCPIP)
 }
 
+// onBtnTCPIPOkClicked browses the games of the server
 func (v *MainMenu) onBtnTCPIPOkClicked() {
-	v.navigator.ToCharacterSelect(d2clientconnectiontype.LANClient, v.tcpJoinGameEntry.GetText())
+	v.gameList.host = v.tcpJoinGameEntry.GetText()
+	v.SetScreenMode(ScreenModeGameList)
+	v.refreshGameList()
 }
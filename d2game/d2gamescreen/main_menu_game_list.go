@@ -0,0 +1,250 @@
+package d2gamescreen
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2remoteclient"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	gameListX, gameListY           = 60, 110
+	gameListRowHeight              = 24
+	gameListRowWidth               = 380
+	gameListMaxRows                = 16
+	gameListStatusX, gameListStatY = 60, 110
+	gameNameLabelX, gameNameLabelY = 560, 105
+	gameNameEntryX, gameNameEntryY = 485, 130
+	gamePassLabelX, gamePassLabelY = 560, 185
+	gamePassEntryX, gamePassEntryY = 485, 210
+	gameCreateBtnX, gameCreateBtnY = 496, 290
+	gameJoinBtnX, gameJoinBtnY     = 496, 330
+	gameRefreshBtnX, gameRefreshY  = 496, 370
+)
+
+// gameListResult is the answer of a server to a game list request
+type gameListResult struct {
+	games []d2netpacket.GameInfo
+	err   error
+}
+
+// gameList is the part of the main menu that browses the games on a server
+type gameList struct {
+	host          string
+	games         []d2netpacket.GameInfo
+	selected      int
+	results       chan gameListResult
+	rows          []*d2ui.Label
+	statusLabel   *d2ui.Label
+	nameLabel     *d2ui.Label
+	passwordLabel *d2ui.Label
+	nameEntry     *d2ui.TextBox
+	passwordEntry *d2ui.TextBox
+	btnCreate     *d2ui.Button
+	btnJoin       *d2ui.Button
+	btnRefresh    *d2ui.Button
+	btnCancel     *d2ui.Button
+}
+
+func (v *MainMenu) createGameList() {
+	list := &gameList{
+		selected: -1,
+		results:  make(chan gameListResult, 1),
+		rows:     make([]*d2ui.Label, gameListMaxRows),
+	}
+
+	for i := range list.rows {
+		list.rows[i] = v.uiManager.NewLabel(d2resource.Font16, d2resource.PaletteUnits)
+		list.rows[i].SetPosition(gameListX, gameListY+i*gameListRowHeight)
+	}
+
+	list.statusLabel = v.uiManager.NewLabel(d2resource.Font16, d2resource.PaletteUnits)
+	list.statusLabel.SetPosition(gameListStatusX, gameListStatY)
+	list.statusLabel.Color[0] = rgbaColor(gold)
+
+	list.nameLabel = v.uiManager.NewLabel(d2resource.Font16, d2resource.PaletteUnits)
+	list.nameLabel.Alignment = d2gui.HorizontalAlignCenter
+	list.nameLabel.SetText("Game Name")
+	list.nameLabel.Color[0] = rgbaColor(gold)
+	list.nameLabel.SetPosition(gameNameLabelX, gameNameLabelY)
+
+	list.passwordLabel = v.uiManager.NewLabel(d2resource.Font16, d2resource.PaletteUnits)
+	list.passwordLabel.Alignment = d2gui.HorizontalAlignCenter
+	list.passwordLabel.SetText("Password")
+	list.passwordLabel.Color[0] = rgbaColor(gold)
+	list.passwordLabel.SetPosition(gamePassLabelX, gamePassLabelY)
+
+	list.nameEntry = v.uiManager.NewTextbox()
+	list.nameEntry.SetPosition(gameNameEntryX, gameNameEntryY)
+	list.nameEntry.SetFilter(joinGameCharacterFilter + " -")
+
+	list.passwordEntry = v.uiManager.NewTextbox()
+	list.passwordEntry.SetPosition(gamePassEntryX, gamePassEntryY)
+	list.passwordEntry.SetFilter(joinGameCharacterFilter)
+
+	list.btnCreate = v.uiManager.NewButton(d2ui.ButtonTypeMedium, "CREATE")
+	list.btnCreate.SetPosition(gameCreateBtnX, gameCreateBtnY)
+	list.btnCreate.OnActivated(func() { v.onGameListJoinClicked(true) })
+
+	list.btnJoin = v.uiManager.NewButton(d2ui.ButtonTypeMedium, "JOIN")
+	list.btnJoin.SetPosition(gameJoinBtnX, gameJoinBtnY)
+	list.btnJoin.OnActivated(func() { v.onGameListJoinClicked(false) })
+
+	list.btnRefresh = v.uiManager.NewButton(d2ui.ButtonTypeMedium, "REFRESH")
+	list.btnRefresh.SetPosition(gameRefreshBtnX, gameRefreshY)
+	list.btnRefresh.OnActivated(func() { v.refreshGameList() })
+
+	list.btnCancel = v.uiManager.NewButton(d2ui.ButtonTypeMedium, v.asset.TranslateString("cancel"))
+	list.btnCancel.SetPosition(tcpBtnX, tcpBtnY)
+	list.btnCancel.OnActivated(func() { v.SetScreenMode(ScreenModeServerIP) })
+
+	v.gameList = list
+}
+
+func (v *MainMenu) setGameListVisible(visible bool) {
+	list := v.gameList
+
+	list.nameEntry.SetVisible(visible)
+	list.passwordEntry.SetVisible(visible)
+	list.btnCreate.SetVisible(visible)
+	list.btnJoin.SetVisible(visible)
+	list.btnRefresh.SetVisible(visible)
+	list.btnCancel.SetVisible(visible)
+
+	if visible {
+		v.focusGameListEntry(list.nameEntry)
+	}
+}
+
+func (v *MainMenu) focusGameListEntry(entry *d2ui.TextBox) {
+	v.gameList.nameEntry.Deactivate()
+	v.gameList.passwordEntry.Deactivate()
+	entry.Activate()
+}
+
+// refreshGameList requests the games from the server, the answer is picked up by advanceGameList
+func (v *MainMenu) refreshGameList() {
+	list := v.gameList
+	list.games = nil
+	list.selected = -1
+	list.statusLabel.SetText("Loading games...")
+
+	go func(host string) {
+		games, err := d2remoteclient.ListGames(host)
+		list.results <- gameListResult{games: games, err: err}
+	}(list.host)
+}
+
+func (v *MainMenu) advanceGameList() {
+	list := v.gameList
+
+	select {
+	case result := <-list.results:
+		list.games = result.games
+
+		switch {
+		case result.err != nil:
+			list.statusLabel.SetText(fmt.Sprintf("Can not list the games of %s", list.host))
+		case len(result.games) == 0:
+			list.statusLabel.SetText("There are no games, create one")
+		default:
+			list.statusLabel.SetText("")
+		}
+
+		v.updateGameListRows()
+	default:
+	}
+}
+
+func (v *MainMenu) updateGameListRows() {
+	list := v.gameList
+
+	for i, row := range list.rows {
+		if i >= len(list.games) {
+			row.SetText("")
+			continue
+		}
+
+		game := list.games[i]
+		text := fmt.Sprintf("%s  %d/%d  %s", game.Name, game.Players, game.MaxPlayers, game.Difficulty)
+
+		if game.Password {
+			text += "  (password)"
+		}
+
+		row.SetText(text)
+		row.Color[0] = rgbaColor(white)
+
+		if i == list.selected {
+			row.Color[0] = rgbaColor(gold)
+		}
+	}
+}
+
+func (v *MainMenu) renderGameList(screen d2interface.Surface) {
+	list := v.gameList
+
+	list.statusLabel.RenderNoError(screen)
+	list.nameLabel.RenderNoError(screen)
+	list.passwordLabel.RenderNoError(screen)
+
+	for i := range list.games {
+		if i < len(list.rows) {
+			list.rows[i].RenderNoError(screen)
+		}
+	}
+}
+
+// onGameListMouseDown selects the game that was clicked, or focuses the text box that was clicked
+func (v *MainMenu) onGameListMouseDown(event d2interface.MouseEvent) bool {
+	if event.Button() != d2enum.MouseButtonLeft {
+		return false
+	}
+
+	list := v.gameList
+	x, y := event.X(), event.Y()
+
+	for _, entry := range []*d2ui.TextBox{list.nameEntry, list.passwordEntry} {
+		ex, ey := entry.GetPosition()
+		width, height := entry.GetSize()
+
+		if x >= ex && x < ex+width && y >= ey && y < ey+height {
+			v.focusGameListEntry(entry)
+			return true
+		}
+	}
+
+	if x < gameListX || x >= gameListX+gameListRowWidth || y < gameListY {
+		return false
+	}
+
+	row := (y - gameListY) / gameListRowHeight
+	if row >= len(list.games) || row >= len(list.rows) {
+		return false
+	}
+
+	list.selected = row
+	list.nameEntry.SetText(list.games[row].Name)
+	v.updateGameListRows()
+
+	return true
+}
+
+func (v *MainMenu) onGameListJoinClicked(create bool) {
+	list := v.gameList
+
+	name := list.nameEntry.GetText()
+	if name == "" {
+		list.statusLabel.SetText("Enter the name of the game")
+		return
+	}
+
+	address := d2remoteclient.JoinAddress(list.host, name, list.passwordEntry.GetText(), create)
+	v.navigator.ToCharacterSelect(d2clientconnectiontype.LANClient, address)
+}
@@ -0,0 +1,214 @@
+package d2player
+
+import (
+	"image/color"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
+)
+
+const (
+	chatX            = 20
+	chatBottomY      = 470 // the input line is below the messages
+	chatWidth        = 560
+	chatLineDuration = 10.0 // seconds a message is shown, while the history is closed
+	chatRecentLines  = 6    // the messages that are shown while the history is closed
+	chatHistoryLines = 24   // the messages that are shown while the history is open
+	chatHistorySize  = 200  // older messages are forgotten
+	chatInputLength  = 256  // the length of a message, in characters
+	chatPadding      = 4
+
+	// a held backspace deletes a character every chatRepeatInterval frames, after chatRepeatDelay frames
+	chatRepeatDelay    = 30
+	chatRepeatInterval = 3
+
+	blackAlpha60 = 0x00000099
+)
+
+// chatLine is a line of a chat message
+type chatLine struct {
+	label *d2ui.Label
+	age   float64 // seconds since the message was received
+}
+
+// chatOverlay shows the chat messages above the panel, and the input line while a message is typed. While
+// the history is open it shows more of the older messages.
+type chatOverlay struct {
+	ui          *d2ui.UIManager
+	lines       []*chatLine
+	input       *d2ui.Label
+	text        []rune
+	typing      bool
+	historyOpen bool
+	onSend      func(message string)
+}
+
+func newChatOverlay(ui *d2ui.UIManager, onSend func(message string)) *chatOverlay {
+	return &chatOverlay{
+		ui:     ui,
+		lines:  make([]*chatLine, 0),
+		input:  ui.NewLabel(d2resource.Font16, d2resource.PaletteStatic),
+		onSend: onSend,
+	}
+}
+
+// addMessage adds a message, which is wrapped to lines of the width of the chat
+func (c *chatOverlay) addMessage(text string, textColor color.Color) {
+	for _, line := range c.wrap(text) {
+		label := c.ui.NewLabel(d2resource.Font16, d2resource.PaletteStatic)
+		label.Color[0] = textColor
+		label.SetText(line)
+
+		c.lines = append(c.lines, &chatLine{label: label})
+	}
+
+	if len(c.lines) > chatHistorySize {
+		c.lines = c.lines[len(c.lines)-chatHistorySize:]
+	}
+}
+
+// wrap splits the text into lines that fit the width of the chat, at the spaces between the words
+func (c *chatOverlay) wrap(text string) []string {
+	lines := make([]string, 0, 1)
+	line := ""
+
+	for _, word := range strings.Fields(text) {
+		candidate := word
+		if line != "" {
+			candidate = line + " " + word
+		}
+
+		if width, _ := c.input.GetTextMetrics(candidate); width > chatWidth && line != "" {
+			lines = append(lines, line)
+			candidate = word
+		}
+
+		line = candidate
+	}
+
+	return append(lines, line)
+}
+
+func (c *chatOverlay) advance(elapsed float64) {
+	for _, line := range c.lines {
+		line.age += elapsed
+	}
+}
+
+// visibleLines returns the lines that are shown, the oldest first
+func (c *chatOverlay) visibleLines() []*chatLine {
+	count := chatRecentLines
+	if c.historyOpen {
+		count = chatHistoryLines
+	}
+
+	if count > len(c.lines) {
+		count = len(c.lines)
+	}
+
+	lines := c.lines[len(c.lines)-count:]
+
+	if c.historyOpen || c.typing {
+		return lines
+	}
+
+	for i, line := range lines {
+		if line.age < chatLineDuration {
+			return lines[i:]
+		}
+	}
+
+	return nil
+}
+
+func (c *chatOverlay) render(target d2interface.Surface) {
+	lines := c.visibleLines()
+	y := chatBottomY
+
+	if c.typing {
+		cursor := ""
+		if time.Now().UnixNano()/int64(time.Millisecond)&(1<<8) > 0 {
+			cursor = "_"
+		}
+
+		c.input.SetText("> " + string(c.text) + cursor)
+		c.input.SetPosition(chatX, y)
+		c.input.RenderNoError(target)
+	}
+
+	if c.historyOpen && len(lines) > 0 {
+		height := 0
+
+		for _, line := range lines {
+			_, lineHeight := line.label.GetSize()
+			height += lineHeight
+		}
+
+		target.PushTranslation(chatX-chatPadding, y-height-chatPadding)
+		target.DrawRect(chatWidth+chatPadding+chatPadding, height+chatPadding, d2util.Color(blackAlpha60))
+		target.Pop()
+	}
+
+	for i := len(lines) - 1; i >= 0; i-- {
+		_, lineHeight := lines[i].label.GetSize()
+		y -= lineHeight
+
+		lines[i].label.SetPosition(chatX, y)
+		lines[i].label.RenderNoError(target)
+	}
+}
+
+func (c *chatOverlay) startTyping() {
+	c.typing = true
+	c.text = c.text[:0]
+}
+
+func (c *chatOverlay) toggleHistory() {
+	c.historyOpen = !c.historyOpen
+}
+
+// onKeyDown handles the keys while a message is typed, it sends the message on enter
+func (c *chatOverlay) onKeyDown(event d2interface.KeyEvent) {
+	switch event.Key() {
+	case d2enum.KeyEnter, d2enum.KeyKPEnter:
+		c.typing = false
+
+		if message := strings.TrimSpace(string(c.text)); message != "" {
+			c.onSend(message)
+		}
+	case d2enum.KeyEscape:
+		c.typing = false
+	case d2enum.KeyBackspace:
+		c.deleteLastCharacter()
+	}
+}
+
+func (c *chatOverlay) onKeyRepeat(event d2interface.KeyEvent) {
+	if event.Key() != d2enum.KeyBackspace {
+		return
+	}
+
+	if frames := event.Duration(); frames >= chatRepeatDelay && (frames-chatRepeatDelay)%chatRepeatInterval == 0 {
+		c.deleteLastCharacter()
+	}
+}
+
+func (c *chatOverlay) onKeyChars(event d2interface.KeyCharsEvent) {
+	for _, character := range event.Chars() {
+		if unicode.IsPrint(character) && len(c.text) < chatInputLength {
+			c.text = append(c.text, character)
+		}
+	}
+}
+
+func (c *chatOverlay) deleteLastCharacter() {
+	if len(c.text) > 0 {
+		c.text = c.text[:len(c.text)-1]
+	}
+}
@@ -2,6 +2,7 @@ package d2player
 
 import (
 	"fmt"
+	"image/color"
 	"log"
 	"strings"
 	"time"
@@ -214,7 +215,7 @@ func NewGameControls(
 	term d2interface.Terminal,
 	ui *d2ui.UIManager,
 	guiManager *d2gui.GuiManager,
-
+	keyMap *KeyMap,
 	isSinglePlayer bool,
 ) (*GameControls, error) {
 	var inventoryRecordKey string
@@ -350,9 +351,9 @@ func NewGameControls(
 		return nil, err
 	}
 
-	keyMap := getDefaultKeyMap()
 	helpOverlay := NewHelpOverlay(asset, renderer, ui, guiManager, keyMap)
-	hud := NewHUD(asset, ui, hero, helpOverlay, newMiniPanel(asset, ui, isSinglePlayer), actionableRegions, mapEngine, mapRenderer)
+	hud := NewHUD(asset, ui, hero, helpOverlay, newMiniPanel(asset, ui, isSinglePlayer), actionableRegions, mapEngine,
+		mapRenderer, inputListener.OnPlayerChat)
 
 	const blackAlpha50percent = 0x0000007f
 
@@ -370,8 +371,8 @@ func NewGameControls(
 		inputListener:  inputListener,
 		mapRenderer:    mapRenderer,
 		inventory:      NewInventory(asset, ui, inventoryRecord),
-		skilltree:      newSkillTree(hero.Skills, hero.Class, asset, ui),
-		heroStatsPanel: NewHeroStatsPanel(asset, ui, hero.Name(), hero.Class, hero.Stats),
+		skilltree:      newSkillTree(hero.Skills, hero.Stats, hero.Class, asset, ui),
+		heroStatsPanel: NewHeroStatsPanel(asset, ui, hero.Name(), hero.Class, hero.Stats, hero.Skills),
 		HelpOverlay:    helpOverlay,
 		hud:            hud,
 		bottomMenuRect: &d2geom.Rectangle{
@@ -402,6 +403,9 @@ func NewGameControls(
 	gc.heroStatsPanel.SetOnCloseCb(closeCb)
 	gc.inventory.SetOnCloseCb(closeCb)
 	gc.skilltree.SetOnCloseCb(closeCb)
+	gc.heroStatsPanel.SetOnStatPointCb(inputListener.OnPlayerSpendStatPoint)
+	gc.inventory.SetOnEquipCb(gc.heroStatsPanel.SetEquipment)
+	gc.skilltree.SetOnLearnSkillCb(inputListener.OnPlayerLearnSkill)
 
 	err = gc.bindTerminalCommands(term)
 	if err != nil {
@@ -413,6 +417,11 @@ func NewGameControls(
 
 // OnKeyRepeat is called to handle repeated key presses
 func (g *GameControls) OnKeyRepeat(event d2interface.KeyEvent) bool {
+	if g.hud.chat.typing {
+		g.hud.chat.onKeyRepeat(event)
+		return true
+	}
+
 	if g.FreeCam {
 		var moveSpeed float64 = 8
 		if event.KeyMod() == d2enum.KeyModShift {
@@ -453,13 +462,22 @@ func (g *GameControls) OnKeyRepeat(event d2interface.KeyEvent) bool {
 
 // OnKeyDown handles key presses
 func (g *GameControls) OnKeyDown(event d2interface.KeyEvent) bool {
+	// the keys type the chat message until it is sent or canceled
+	if g.hud.chat.typing {
+		g.hud.chat.onKeyDown(event)
+		return true
+	}
+
 	if event.Key() == d2enum.KeyEscape {
 		g.onEscKey()
 		return true
 	}
 
-	gameEvent := g.keyMap.getGameEvent(event.Key())
+	return g.onGameEvent(g.keyMap.getGameEvent(NewKeyCombo(event.Key(), event.KeyMod())))
+}
 
+// onGameEvent handles the game events that are bound to keys or mouse buttons
+func (g *GameControls) onGameEvent(gameEvent d2enum.GameEvent) bool {
 	switch gameEvent {
 	case d2enum.ClearScreen:
 		g.inventory.Close()
@@ -483,6 +501,10 @@ func (g *GameControls) OnKeyDown(event d2interface.KeyEvent) bool {
 	case d2enum.ToggleHelpScreen:
 		g.HelpOverlay.Toggle()
 		g.updateLayout()
+	case d2enum.ToggleChatOverlay:
+		g.hud.chat.startTyping()
+	case d2enum.ToggleMessageLog:
+		g.hud.chat.toggleHistory()
 	default:
 		return false
 	}
@@ -490,9 +512,20 @@ func (g *GameControls) OnKeyDown(event d2interface.KeyEvent) bool {
 	return false
 }
 
+// OnKeyChars types the characters of a chat message
+func (g *GameControls) OnKeyChars(event d2interface.KeyCharsEvent) bool {
+	if !g.hud.chat.typing {
+		return false
+	}
+
+	g.hud.chat.onKeyChars(event)
+
+	return true
+}
+
 // OnKeyUp handles key release
 func (g *GameControls) OnKeyUp(event d2interface.KeyEvent) bool {
-	gameEvent := g.keyMap.getGameEvent(event.Key())
+	gameEvent := g.keyMap.getGameEvent(NewKeyCombo(event.Key(), event.KeyMod()))
 
 	switch gameEvent {
 	case d2enum.HoldRun:
@@ -643,6 +676,12 @@ func (g *GameControls) OnMouseMove(event d2interface.MouseMoveEvent) bool {
 func (g *GameControls) OnMouseButtonDown(event d2interface.MouseEvent) bool {
 	mx, my := event.X(), event.Y()
 
+	// mouse buttons that are bound to game events do not move or cast
+	if gameEvent := g.keyMap.getGameEvent(NewMouseCombo(event.Button(), event.KeyMod())); gameEvent != 0 {
+		g.onGameEvent(gameEvent)
+		return true
+	}
+
 	for i := range g.actionableRegions {
 		// If click is on a game control element
 		if g.actionableRegions[i].rect.IsInRect(mx, my) {
@@ -651,6 +690,10 @@ func (g *GameControls) OnMouseButtonDown(event d2interface.MouseEvent) bool {
 		}
 	}
 
+	if g.skilltree.IsOpen() && event.Button() == d2enum.MouseButtonLeft && g.skilltree.HandleClick(mx, my) {
+		return true
+	}
+
 	if g.hud.skillSelectMenu.IsOpen() && event.Button() == d2enum.MouseButtonLeft {
 		g.lastLeftBtnActionTime = d2util.Now()
 		g.hud.skillSelectMenu.HandleClick(mx, my)
@@ -695,9 +738,15 @@ func (g *GameControls) Load() {
 	g.HelpOverlay.Load()
 }
 
+// SetDifficulty sets the difficulty of the game, the stats of the hero depend on it
+func (g *GameControls) SetDifficulty(difficulty d2enum.DifficultyType) {
+	g.heroStatsPanel.SetDifficulty(difficulty)
+}
+
 // Advance advances the state of the GameControls
 func (g *GameControls) Advance(elapsed float64) error {
 	g.mapRenderer.Advance(elapsed)
+	g.hud.chat.advance(elapsed)
 	return nil
 }
 
@@ -776,6 +825,11 @@ func (g *GameControls) renderPanels(target d2interface.Surface) error {
 	return nil
 }
 
+// AddChatMessage shows a message in the chat, and in its history
+func (g *GameControls) AddChatMessage(text string, textColor color.Color) {
+	g.hud.chat.addMessage(text, textColor)
+}
+
 // SetZoneChangeText sets the zoneChangeText
 func (g *GameControls) SetZoneChangeText(text string) {
 	g.hud.zoneChangeText.SetText(text)
@@ -912,6 +966,10 @@ func (g *GameControls) onClickActionable(item actionableType) {
 			g.updateLayout()
 		},
 
+		miniPanelMessageLog: func() {
+			g.hud.chat.toggleHistory()
+		},
+
 		miniPanelGameMenu: func() {
 			g.hud.miniPanel.Close()
 			g.escapeMenu.open()
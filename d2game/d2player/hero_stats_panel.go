@@ -10,6 +10,7 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2gui"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2ui"
 )
 
@@ -38,10 +39,11 @@ const (
 	labelVitalityX, labelVitalityY   = 100, 300
 	labelEnergyX, labelEnergyY       = 100, 360
 
-	labelDefenseX, labelDefenseY = 280, 260
-	labelStaminaX, labelStaminaY = 280, 300
-	labelLifeX, labelLifeY       = 280, 322
-	labelManaX, labelManaY       = 280, 360
+	labelAttackRatingX, labelAttackRatingY = 280, 213
+	labelDefenseX, labelDefenseY           = 280, 260
+	labelStaminaX, labelStaminaY           = 280, 300
+	labelLifeX, labelLifeY                 = 280, 322
+	labelManaX, labelManaY                 = 280, 360
 
 	labelResFireLine1X, labelResFireLine1Y   = 310, 395
 	labelResFireLine2X, labelResFireLine2Y   = 310, 402
@@ -51,10 +53,28 @@ const (
 	labelResLightLine2X, labelResLightLine2Y = 310, 427
 	labelResPoisLine1X, labelResPoisLine1Y   = 310, 468
 	labelResPoisLine2X, labelResPoisLine2Y   = 310, 477
+
+	labelStatValueX = 370
+
+	labelAttackRatingValueY = 207
+	labelDefenseValueY      = 255
+	labelResFireValueY      = 395
+	labelResLightValueY     = 420
+	labelResColdValueY      = 445
+	labelResPoisValueY      = 470
+
+	labelStatPointsX, labelStatPointsY           = 100, 420
+	labelStatPointsValueX, labelStatPointsValueY = 175, 415
 )
 
 const (
 	heroStatsCloseButtonX, heroStatsCloseButtonY = 208, 453
+
+	statButtonX          = 206
+	statButtonStrengthY  = 140
+	statButtonDexterityY = 201
+	statButtonVitalityY  = 289
+	statButtonEnergyY    = 349
 )
 
 // PanelText represents text on the panel
@@ -68,19 +88,27 @@ type PanelText struct {
 
 // StatsPanelLabels represents the labels in the status panel
 type StatsPanelLabels struct {
-	Level        *d2ui.Label
-	Experience   *d2ui.Label
-	NextLevelExp *d2ui.Label
-	Strength     *d2ui.Label
-	Dexterity    *d2ui.Label
-	Vitality     *d2ui.Label
-	Energy       *d2ui.Label
-	Health       *d2ui.Label
-	MaxHealth    *d2ui.Label
-	Mana         *d2ui.Label
-	MaxMana      *d2ui.Label
-	MaxStamina   *d2ui.Label
-	Stamina      *d2ui.Label
+	Level          *d2ui.Label
+	Experience     *d2ui.Label
+	NextLevelExp   *d2ui.Label
+	Strength       *d2ui.Label
+	Dexterity      *d2ui.Label
+	Vitality       *d2ui.Label
+	Energy         *d2ui.Label
+	Health         *d2ui.Label
+	MaxHealth      *d2ui.Label
+	Mana           *d2ui.Label
+	MaxMana        *d2ui.Label
+	MaxStamina     *d2ui.Label
+	Stamina        *d2ui.Label
+	AttackRating   *d2ui.Label
+	Defense        *d2ui.Label
+	FireResist     *d2ui.Label
+	ColdResist     *d2ui.Label
+	LightResist    *d2ui.Label
+	PoisonResist   *d2ui.Label
+	StatPoints     *d2ui.Label
+	StatPointsText *d2ui.Label
 }
 
 // HeroStatsPanel represents the hero status panel
@@ -90,13 +118,23 @@ type HeroStatsPanel struct {
 	frame                *d2ui.UIFrame
 	panel                *d2ui.Sprite
 	heroState            *d2hero.HeroStatsState
+	heroSkills           map[int]*d2hero.HeroSkill
 	heroName             string
 	heroClass            d2enum.Hero
 	renderer             d2interface.Renderer
 	staticMenuImageCache *d2interface.Surface
 	labels               *StatsPanelLabels
 	closeButton          *d2ui.Button
+	statButtons          []*d2ui.Button
 	onCloseCb            func()
+	onStatPointCb        func(attribute d2hero.Attribute)
+
+	// the effective stats of the hero, computed from its stats, equipment and passive skills
+	aggregator *d2hero.StatAggregator
+	equipment  []*diablo2item.Item
+	difficulty d2enum.DifficultyType
+	sheet      *d2hero.StatSheet
+	sheetBase  d2hero.HeroStatsState // the stats of the hero the sheet was computed from
 
 	originX int
 	originY int
@@ -105,20 +143,27 @@ type HeroStatsPanel struct {
 
 // NewHeroStatsPanel creates a new hero status panel
 func NewHeroStatsPanel(asset *d2asset.AssetManager, ui *d2ui.UIManager, heroName string, heroClass d2enum.Hero,
-	heroState *d2hero.HeroStatsState) *HeroStatsPanel {
+	heroState *d2hero.HeroStatsState, heroSkills map[int]*d2hero.HeroSkill) *HeroStatsPanel {
 	originX := 0
 	originY := 0
 
+	aggregator, err := d2hero.NewStatAggregator(asset)
+	if err != nil {
+		log.Print(err)
+	}
+
 	return &HeroStatsPanel{
-		asset:     asset,
-		uiManager: ui,
-		renderer:  ui.Renderer(),
-		originX:   originX,
-		originY:   originY,
-		heroState: heroState,
-		heroName:  heroName,
-		heroClass: heroClass,
-		labels:    &StatsPanelLabels{},
+		asset:      asset,
+		uiManager:  ui,
+		renderer:   ui.Renderer(),
+		originX:    originX,
+		originY:    originY,
+		heroState:  heroState,
+		heroSkills: heroSkills,
+		heroName:   heroName,
+		heroClass:  heroClass,
+		labels:     &StatsPanelLabels{},
+		aggregator: aggregator,
 	}
 }
 
@@ -133,6 +178,8 @@ func (s *HeroStatsPanel) Load() {
 	s.closeButton.SetPosition(heroStatsCloseButtonX, heroStatsCloseButtonY)
 	s.closeButton.OnActivated(func() { s.Close() })
 
+	s.initStatButtons()
+
 	s.panel, err = s.uiManager.NewSprite(d2resource.InventoryCharacterPanel, d2resource.PaletteSky)
 	if err != nil {
 		log.Print(err)
@@ -141,6 +188,43 @@ func (s *HeroStatsPanel) Load() {
 	s.initStatValueLabels()
 }
 
+func (s *HeroStatsPanel) initStatButtons() {
+	statButtonConfigs := []struct {
+		attribute d2hero.Attribute
+		y         int
+	}{
+		{d2hero.AttributeStrength, statButtonStrengthY},
+		{d2hero.AttributeDexterity, statButtonDexterityY},
+		{d2hero.AttributeVitality, statButtonVitalityY},
+		{d2hero.AttributeEnergy, statButtonEnergyY},
+	}
+
+	for _, cfg := range statButtonConfigs {
+		attribute := cfg.attribute
+
+		button := s.uiManager.NewButton(d2ui.ButtonTypeAddSkill, "")
+		button.SetVisible(false)
+		button.SetPosition(statButtonX, cfg.y)
+		button.OnActivated(func() { s.spendStatPoint(attribute) })
+
+		s.statButtons = append(s.statButtons, button)
+	}
+}
+
+// spendStatPoint asks to spend a stat point, the stats are updated when the server has spent it
+func (s *HeroStatsPanel) spendStatPoint(attribute d2hero.Attribute) {
+	if s.heroState.StatPoints > 0 && s.onStatPointCb != nil {
+		s.onStatPointCb(attribute)
+	}
+}
+
+// setStatButtonsVisible shows the buttons which spend stat points
+func (s *HeroStatsPanel) setStatButtonsVisible(visible bool) {
+	for _, button := range s.statButtons {
+		button.SetVisible(visible)
+	}
+}
+
 // IsOpen returns true if the hero status panel is open
 func (s *HeroStatsPanel) IsOpen() bool {
 	return s.isOpen
@@ -159,12 +243,14 @@ func (s *HeroStatsPanel) Toggle() {
 func (s *HeroStatsPanel) Open() {
 	s.isOpen = true
 	s.closeButton.SetVisible(true)
+	s.setStatButtonsVisible(s.heroState.StatPoints > 0)
 }
 
 // Close closed the hero status panel
 func (s *HeroStatsPanel) Close() {
 	s.isOpen = false
 	s.closeButton.SetVisible(false)
+	s.setStatButtonsVisible(false)
 	s.onCloseCb()
 }
 
@@ -173,6 +259,44 @@ func (s *HeroStatsPanel) SetOnCloseCb(cb func()) {
 	s.onCloseCb = cb
 }
 
+// SetOnStatPointCb sets the callback run when a stat point is spent on an attribute
+func (s *HeroStatsPanel) SetOnStatPointCb(cb func(attribute d2hero.Attribute)) {
+	s.onStatPointCb = cb
+}
+
+// SetEquipment sets the equipped items the stats of the hero are computed with
+func (s *HeroStatsPanel) SetEquipment(items []*diablo2item.Item) {
+	s.equipment = items
+	s.updateStatSheet()
+}
+
+// SetDifficulty sets the difficulty the resistances of the hero are computed for
+func (s *HeroStatsPanel) SetDifficulty(difficulty d2enum.DifficultyType) {
+	s.difficulty = difficulty
+	s.updateStatSheet()
+}
+
+// updateStatSheet computes the effective stats of the hero
+func (s *HeroStatsPanel) updateStatSheet() {
+	if s.aggregator == nil || s.heroState == nil {
+		return
+	}
+
+	state := &d2hero.HeroState{HeroType: s.heroClass, Stats: s.heroState, Skills: s.heroSkills}
+
+	s.sheet = s.aggregator.Aggregate(state, s.equipment, s.difficulty)
+	s.sheetBase = statSheetBase(s.heroState)
+}
+
+// statSheetBase returns the stats of the hero without the ones which change while playing, like its life.
+// Learning a skill spends a skill point, so this changes when the passive skills do as well.
+func statSheetBase(stats *d2hero.HeroStatsState) d2hero.HeroStatsState {
+	base := *stats
+	base.Health, base.Mana, base.Stamina = 0, 0, 0
+
+	return base
+}
+
 // Render renders the hero status panel
 func (s *HeroStatsPanel) Render(target d2interface.Surface) {
 	if !s.isOpen {
@@ -194,6 +318,13 @@ func (s *HeroStatsPanel) Render(target d2interface.Surface) {
 
 	target.Render(*s.staticMenuImageCache)
 
+	// the stats of the hero change when it spends stat points or gains a level
+	if s.sheet == nil || s.sheetBase != statSheetBase(s.heroState) {
+		s.updateStatSheet()
+	}
+
+	// the stat points change when the server has spent them or the hero has gained a level
+	s.setStatButtonsVisible(s.heroState.StatPoints > 0)
 	s.renderStatValues(target)
 }
 
@@ -268,6 +399,7 @@ func (s *HeroStatsPanel) renderStaticLabels(target d2interface.Surface) {
 		{labelDexterityX, labelDexterityY, "Dexterity", d2resource.Font6, false},
 		{labelVitalityX, labelVitalityY, "Vitality", d2resource.Font6, false},
 		{labelEnergyX, labelEnergyY, "Energy", d2resource.Font6, false},
+		{labelAttackRatingX, labelAttackRatingY, "Attack Rating", d2resource.Font6, false},
 		{labelDefenseX, labelDefenseY, "Defense", d2resource.Font6, false},
 		{labelStaminaX, labelStaminaY, "Stamina", d2resource.Font6, true},
 		{labelLifeX, labelLifeY, "Life", d2resource.Font6, true},
@@ -318,11 +450,22 @@ func (s *HeroStatsPanel) initStatValueLabels() {
 		{&s.labels.Health, s.heroState.Health, 370, 320},
 		{&s.labels.MaxMana, s.heroState.MaxMana, 330, 355},
 		{&s.labels.Mana, s.heroState.Mana, 370, 355},
+		{&s.labels.AttackRating, s.heroState.AttackRating, labelStatValueX, labelAttackRatingValueY},
+		{&s.labels.Defense, s.heroState.DefenseRating, labelStatValueX, labelDefenseValueY},
+		{&s.labels.FireResist, s.heroState.FireResistance, labelStatValueX, labelResFireValueY},
+		{&s.labels.ColdResist, s.heroState.ColdResistance, labelStatValueX, labelResColdValueY},
+		{&s.labels.LightResist, s.heroState.LightningResistance, labelStatValueX, labelResLightValueY},
+		{&s.labels.PoisonResist, s.heroState.PoisonResistance, labelStatValueX, labelResPoisValueY},
+		{&s.labels.StatPoints, s.heroState.StatPoints, labelStatPointsValueX, labelStatPointsValueY},
 	}
 
 	for _, cfg := range valueLabelConfigs {
 		*cfg.assignTo = s.createStatValueLabel(cfg.value, cfg.x, cfg.y)
 	}
+
+	s.labels.StatPointsText = s.createTextLabel(PanelText{
+		X: labelStatPointsX, Y: labelStatPointsY, Text: "Stat Points", Font: d2resource.Font6,
+	})
 }
 
 func (s *HeroStatsPanel) renderStatValues(target d2interface.Surface) {
@@ -330,19 +473,44 @@ func (s *HeroStatsPanel) renderStatValues(target d2interface.Surface) {
 	s.renderStatValueNum(s.labels.Experience, s.heroState.Experience, target)
 	s.renderStatValueNum(s.labels.NextLevelExp, s.heroState.NextLevelExp, target)
 
-	s.renderStatValueNum(s.labels.Strength, s.heroState.Strength, target)
-	s.renderStatValueNum(s.labels.Dexterity, s.heroState.Dexterity, target)
-	s.renderStatValueNum(s.labels.Vitality, s.heroState.Vitality, target)
-	s.renderStatValueNum(s.labels.Energy, s.heroState.Energy, target)
+	sheet := s.sheet
+	if sheet == nil {
+		sheet = &d2hero.StatSheet{
+			Strength: s.heroState.Strength, Dexterity: s.heroState.Dexterity,
+			Vitality: s.heroState.Vitality, Energy: s.heroState.Energy,
+			AttackRating: s.heroState.AttackRating, DefenseRating: s.heroState.DefenseRating,
+			MaxHealth: s.heroState.MaxHealth, MaxMana: s.heroState.MaxMana, MaxStamina: s.heroState.MaxStamina,
+			FireResistance: s.heroState.FireResistance, ColdResistance: s.heroState.ColdResistance,
+			LightningResistance: s.heroState.LightningResistance, PoisonResistance: s.heroState.PoisonResistance,
+		}
+	}
+
+	s.renderStatValueNum(s.labels.Strength, sheet.Strength, target)
+	s.renderStatValueNum(s.labels.Dexterity, sheet.Dexterity, target)
+	s.renderStatValueNum(s.labels.Vitality, sheet.Vitality, target)
+	s.renderStatValueNum(s.labels.Energy, sheet.Energy, target)
+
+	s.renderStatValueNum(s.labels.AttackRating, sheet.AttackRating, target)
+	s.renderStatValueNum(s.labels.Defense, sheet.DefenseRating, target)
 
-	s.renderStatValueNum(s.labels.MaxHealth, s.heroState.MaxHealth, target)
+	s.renderStatValueNum(s.labels.MaxHealth, sheet.MaxHealth, target)
 	s.renderStatValueNum(s.labels.Health, s.heroState.Health, target)
 
-	s.renderStatValueNum(s.labels.MaxStamina, s.heroState.MaxStamina, target)
+	s.renderStatValueNum(s.labels.MaxStamina, sheet.MaxStamina, target)
 	s.renderStatValueNum(s.labels.Stamina, int(s.heroState.Stamina), target)
 
-	s.renderStatValueNum(s.labels.MaxMana, s.heroState.MaxMana, target)
+	s.renderStatValueNum(s.labels.MaxMana, sheet.MaxMana, target)
 	s.renderStatValueNum(s.labels.Mana, s.heroState.Mana, target)
+
+	s.renderStatValueNum(s.labels.FireResist, sheet.FireResistance, target)
+	s.renderStatValueNum(s.labels.ColdResist, sheet.ColdResistance, target)
+	s.renderStatValueNum(s.labels.LightResist, sheet.LightningResistance, target)
+	s.renderStatValueNum(s.labels.PoisonResist, sheet.PoisonResistance, target)
+
+	if s.heroState.StatPoints > 0 {
+		s.labels.StatPointsText.RenderNoError(target)
+		s.renderStatValueNum(s.labels.StatPoints, s.heroState.StatPoints, target)
+	}
 }
 
 func (s *HeroStatsPanel) renderStatValueNum(label *d2ui.Label, value int,
@@ -121,6 +121,7 @@ type HUD struct {
 	manaTooltip        *d2ui.Tooltip
 	miniPanelTooltip   *d2ui.Tooltip
 	nameLabel          *d2ui.Label
+	chat               *chatOverlay
 }
 
 // NewHUD creates a HUD object
@@ -133,10 +134,11 @@ func NewHUD(
 	actionableRegions []actionableRegion,
 	mapEngine *d2mapengine.MapEngine,
 	mapRenderer *d2maprenderer.MapRenderer,
+	onChat func(message string),
 ) *HUD {
 	nameLabel := ui.NewLabel(d2resource.Font16, d2resource.PaletteStatic)
 	nameLabel.Alignment = d2gui.HorizontalAlignCenter
-	nameLabel.SetText(d2ui.ColorTokenize("", d2ui.ColorTokenServer))
+	nameLabel.SetText(d2util.ColorTokenize("", d2util.ColorTokenServer))
 
 	zoneLabel := ui.NewLabel(d2resource.Font30, d2resource.PaletteUnits)
 	zoneLabel.Alignment = d2gui.HorizontalAlignCenter
@@ -153,6 +155,7 @@ func NewHUD(
 		nameLabel:         nameLabel,
 		skillSelectMenu:   NewSkillSelectMenu(asset, ui, hero),
 		zoneChangeText:    zoneLabel,
+		chat:              newChatOverlay(ui, onChat),
 	}
 }
 
@@ -797,6 +800,8 @@ func (h *HUD) Render(target d2interface.Surface) error {
 		h.zoneChangeText.RenderNoError(target)
 	}
 
+	h.chat.render(target)
+
 	h.renderHealthTooltip(target)
 	h.renderManaTooltip(target)
 	h.renderRunWalkTooltip(target)
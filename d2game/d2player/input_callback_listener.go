@@ -1,6 +1,11 @@
 package d2player
 
+import "github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+
 type inputCallbackListener interface {
 	OnPlayerMove(x, y float64)
 	OnPlayerCast(skillID int, x, y float64)
+	OnPlayerChat(message string)
+	OnPlayerSpendStatPoint(attribute d2hero.Attribute)
+	OnPlayerLearnSkill(skillID int)
 }
@@ -44,6 +44,7 @@ type Inventory struct {
 	hovering    bool
 	isOpen      bool
 	onCloseCb   func()
+	onEquipCb   func(items []*diablo2item.Item)
 }
 
 // NewInventory creates an inventory instance and returns a pointer to it
@@ -98,6 +99,28 @@ func (g *Inventory) SetOnCloseCb(cb func()) {
 	g.onCloseCb = cb
 }
 
+// SetOnEquipCb sets the callback run with the equipped items when the equipment changes
+func (g *Inventory) SetOnEquipCb(cb func(items []*diablo2item.Item)) {
+	g.onEquipCb = cb
+}
+
+// equipmentChanged runs the equip callback with the equipped items
+func (g *Inventory) equipmentChanged() {
+	if g.onEquipCb == nil {
+		return
+	}
+
+	items := make([]*diablo2item.Item, 0)
+
+	for _, equipped := range g.grid.EquippedItems() {
+		if item, ok := equipped.(*diablo2item.Item); ok {
+			items = append(items, item)
+		}
+	}
+
+	g.onEquipCb(items)
+}
+
 // Load the resources required by the inventory
 func (g *Inventory) Load() {
 	g.frame = d2ui.NewUIFrame(g.asset, g.uiManager, d2ui.FrameRight)
@@ -152,6 +175,8 @@ func (g *Inventory) Load() {
 		g.grid.ChangeEquippedSlot(slot, item)
 	}
 
+	g.equipmentChanged()
+
 	_, err := g.grid.Add(inventoryItems...)
 	if err != nil {
 		fmt.Printf("could not add items to the inventory, err: %v\n", err)
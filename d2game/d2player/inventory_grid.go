@@ -106,6 +106,19 @@ func (g *ItemGrid) ChangeEquippedSlot(slot d2enum.EquippedSlot, item InventoryIt
 	g.equipmentSlots[slot] = curItem
 }
 
+// EquippedItems returns the items of the equipment slots
+func (g *ItemGrid) EquippedItems() []InventoryItem {
+	items := make([]InventoryItem, 0)
+
+	for _, eq := range g.equipmentSlots {
+		if eq.item != nil {
+			items = append(items, eq.item)
+		}
+	}
+
+	return items
+}
+
 // Add places a given set of items into the first available slots.
 // Returns a count of the number of items which could be inserted.
 func (g *ItemGrid) Add(items ...InventoryItem) (int, error) {
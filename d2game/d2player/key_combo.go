@@ -0,0 +1,143 @@
+package d2player
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	noButton        d2enum.MouseButton = -1
+	keyComboJoiner                     = "+"
+	mouseNamePrefix                    = "Mouse"
+)
+
+var errUnknownKey = errors.New("unknown key")
+
+// NoKeyCombo is an empty binding
+//nolint:gochecknoglobals // constant value
+var NoKeyCombo = KeyCombo{Key: d2enum.KeyNone, Button: noButton}
+
+//nolint:gochecknoglobals // lookup tables
+var (
+	modifierNames = []struct {
+		mod  d2enum.KeyMod
+		key  d2enum.Key
+		name string
+	}{
+		{d2enum.KeyModControl, d2enum.KeyControl, "Ctrl"},
+		{d2enum.KeyModAlt, d2enum.KeyAlt, "Alt"},
+		{d2enum.KeyModShift, d2enum.KeyShift, "Shift"},
+	}
+
+	mouseButtonNames = map[d2enum.MouseButton]string{
+		d2enum.MouseButtonLeft:   mouseNamePrefix + "Left",
+		d2enum.MouseButtonMiddle: mouseNamePrefix + "Middle",
+		d2enum.MouseButtonRight:  mouseNamePrefix + "Right",
+	}
+)
+
+// KeyCombo is a key or a mouse button, together with the modifier keys that are held with it
+type KeyCombo struct {
+	Key       d2enum.Key
+	Button    d2enum.MouseButton
+	Modifiers d2enum.KeyMod
+}
+
+// NewKeyCombo returns the combination of a key and modifiers
+func NewKeyCombo(key d2enum.Key, modifiers d2enum.KeyMod) KeyCombo {
+	return KeyCombo{Key: key, Button: noButton, Modifiers: modifiers}
+}
+
+// NewMouseCombo returns the combination of a mouse button and modifiers
+func NewMouseCombo(button d2enum.MouseButton, modifiers d2enum.KeyMod) KeyCombo {
+	return KeyCombo{Key: d2enum.KeyNone, Button: button, Modifiers: modifiers}
+}
+
+// IsNone returns true when the combination has neither a key nor a mouse button
+func (c KeyCombo) IsNone() bool {
+	return c.Key == d2enum.KeyNone && c.Button == noButton
+}
+
+// normalized removes the modifier of the key itself, pressing Shift reports the Shift modifier
+func (c KeyCombo) normalized() KeyCombo {
+	if c.IsNone() {
+		return NoKeyCombo
+	}
+
+	for _, modifier := range modifierNames {
+		if c.Key == modifier.key {
+			c.Modifiers &^= modifier.mod
+		}
+	}
+
+	return c
+}
+
+// GetString returns the combination as it is written in key map files, such as Ctrl+Shift+F1
+func (c KeyCombo) GetString() string {
+	if c.IsNone() {
+		return d2enum.KeyNone.GetString()
+	}
+
+	parts := make([]string, 0, len(modifierNames)+1)
+
+	for _, modifier := range modifierNames {
+		if c.Modifiers&modifier.mod != 0 {
+			parts = append(parts, modifier.name)
+		}
+	}
+
+	if c.Key == d2enum.KeyNone {
+		parts = append(parts, mouseButtonNames[c.Button])
+	} else {
+		parts = append(parts, c.Key.GetString())
+	}
+
+	return strings.Join(parts, keyComboJoiner)
+}
+
+// ParseKeyCombo parses a combination written by GetString
+func ParseKeyCombo(text string) (KeyCombo, error) {
+	parts := strings.Split(strings.TrimSpace(text), keyComboJoiner)
+	combo := NoKeyCombo
+
+	for _, part := range parts[:len(parts)-1] {
+		found := false
+
+		for _, modifier := range modifierNames {
+			if strings.EqualFold(part, modifier.name) {
+				combo.Modifiers |= modifier.mod
+				found = true
+			}
+		}
+
+		if !found {
+			return NoKeyCombo, fmt.Errorf("%w: %s in %s", errUnknownKey, part, text)
+		}
+	}
+
+	last := parts[len(parts)-1]
+
+	for button, name := range mouseButtonNames {
+		if strings.EqualFold(last, name) {
+			combo.Button = button
+			return combo.normalized(), nil
+		}
+	}
+
+	key, found := d2enum.KeyFromString(last)
+	if !found {
+		return NoKeyCombo, fmt.Errorf("%w: %s", errUnknownKey, text)
+	}
+
+	if key == d2enum.KeyNone {
+		return NoKeyCombo, nil
+	}
+
+	combo.Key = key
+
+	return combo.normalized(), nil
+}
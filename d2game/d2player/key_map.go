@@ -7,70 +7,102 @@ import (
 )
 
 // KeyMap represents the key mappings of the game. Each game event
-// can be associated to 2 different keys. A key of -1 means none
+// can be associated to 2 different key combinations, which can also be mouse buttons
 type KeyMap struct {
 	mutex    sync.RWMutex
-	mapping  map[d2enum.Key]d2enum.GameEvent
+	mapping  map[KeyCombo]d2enum.GameEvent
 	controls map[d2enum.GameEvent]*KeyBinding
+	path     string
 }
 
 // NewKeyMap returns a new instance of a KeyMap
 func NewKeyMap() *KeyMap {
 	return &KeyMap{
-		mapping:  make(map[d2enum.Key]d2enum.GameEvent),
+		mapping:  make(map[KeyCombo]d2enum.GameEvent),
 		controls: make(map[d2enum.GameEvent]*KeyBinding),
 	}
 }
 
-// SetPrimaryBinding binds the first key for gameEvent
-func (km *KeyMap) SetPrimaryBinding(gameEvent d2enum.GameEvent, key d2enum.Key) {
-	if key == d2enum.KeyEscape {
+// SetPrimaryBinding binds the first key combination for gameEvent, the combination is unbound from any
+// other game event
+func (km *KeyMap) SetPrimaryBinding(gameEvent d2enum.GameEvent, combo KeyCombo) {
+	km.setBinding(gameEvent, combo, false)
+}
+
+// SetSecondaryBinding binds the second key combination for gameEvent, the combination is unbound from any
+// other game event
+func (km *KeyMap) SetSecondaryBinding(gameEvent d2enum.GameEvent, combo KeyCombo) {
+	km.setBinding(gameEvent, combo, true)
+}
+
+func (km *KeyMap) setBinding(gameEvent d2enum.GameEvent, combo KeyCombo, secondary bool) {
+	if combo.Key == d2enum.KeyEscape {
 		return
 	}
 
 	km.mutex.Lock()
 	defer km.mutex.Unlock()
 
+	km.bind(gameEvent, combo.normalized(), secondary)
+}
+
+func (km *KeyMap) bind(gameEvent d2enum.GameEvent, combo KeyCombo, secondary bool) {
 	if km.controls[gameEvent] == nil {
-		km.controls[gameEvent] = &KeyBinding{}
+		km.controls[gameEvent] = &KeyBinding{Primary: NoKeyCombo, Secondary: NoKeyCombo}
 	}
 
-	currentKey := km.controls[gameEvent].Primary
-	delete(km.mapping, currentKey)
-	km.mapping[key] = gameEvent
+	km.unbind(combo)
 
-	km.controls[gameEvent].Primary = key
-}
+	binding := km.controls[gameEvent]
 
-// SetSecondaryBinding binds the second key for gameEvent
-func (km *KeyMap) SetSecondaryBinding(gameEvent d2enum.GameEvent, key d2enum.Key) {
-	if key == d2enum.KeyEscape {
-		return
+	slot := &binding.Primary
+	if secondary {
+		slot = &binding.Secondary
 	}
 
-	km.mutex.Lock()
-	defer km.mutex.Unlock()
+	delete(km.mapping, *slot)
+	*slot = combo
 
-	if km.controls[gameEvent] == nil {
-		km.controls[gameEvent] = &KeyBinding{}
+	if !combo.IsNone() {
+		km.mapping[combo] = gameEvent
 	}
+}
 
-	currentKey := km.controls[gameEvent].Secondary
-	delete(km.mapping, currentKey)
-	km.mapping[key] = gameEvent
+// unbind removes the key combination from the game event that it is bound to
+func (km *KeyMap) unbind(combo KeyCombo) {
+	gameEvent, found := km.mapping[combo]
+	if !found {
+		return
+	}
 
-	if km.controls[gameEvent].Primary == key {
-		km.controls[gameEvent].Primary = d2enum.Key(-1)
+	delete(km.mapping, combo)
+
+	binding := km.controls[gameEvent]
+
+	if binding.Primary == combo {
+		binding.Primary = NoKeyCombo
 	}
 
-	km.controls[gameEvent].Secondary = key
+	if binding.Secondary == combo {
+		binding.Secondary = NoKeyCombo
+	}
 }
 
-func (km *KeyMap) getGameEvent(key d2enum.Key) d2enum.GameEvent {
+// getGameEvent returns the game event of the key combination. Combinations with modifiers that are not
+// bound fall back to the key or button alone, so that for example running does not block other keys.
+func (km *KeyMap) getGameEvent(combo KeyCombo) d2enum.GameEvent {
 	km.mutex.RLock()
 	defer km.mutex.RUnlock()
 
-	return km.mapping[key]
+	combo = combo.normalized()
+
+	if gameEvent, found := km.mapping[combo]; found {
+		return gameEvent
+	}
+
+	combo.Modifiers = 0
+
+	return km.mapping[combo]
 }
 
 // GetKeysForGameEvent returns the bindings for a givent game event
@@ -81,58 +113,70 @@ func (km *KeyMap) GetKeysForGameEvent(gameEvent d2enum.GameEvent) *KeyBinding {
 	return km.controls[gameEvent]
 }
 
-// KeyBinding holds the primary and secondary keys assigned to a GameEvent
+// Reset replaces all bindings with the default ones
+func (km *KeyMap) Reset() {
+	defaults := DefaultKeyMap()
+
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	km.mapping = defaults.mapping
+	km.controls = defaults.controls
+}
+
+// KeyBinding holds the primary and secondary key combinations assigned to a GameEvent
 type KeyBinding struct {
-	Primary   d2enum.Key
-	Secondary d2enum.Key
+	Primary   KeyCombo
+	Secondary KeyCombo
 }
 
-func getDefaultKeyMap() *KeyMap {
+// DefaultKeyMap returns a key map with the default bindings
+func DefaultKeyMap() *KeyMap {
 	keyMap := NewKeyMap()
 
-	defaultControls := map[d2enum.GameEvent]KeyBinding{
+	defaultControls := map[d2enum.GameEvent][2]d2enum.Key{
 		d2enum.ToggleCharacterPanel:     {d2enum.KeyA, d2enum.KeyC},
 		d2enum.ToggleInventoryPanel:     {d2enum.KeyB, d2enum.KeyI},
-		d2enum.ToggleHelpScreen:         {d2enum.KeyH, -1},
-		d2enum.TogglePartyPanel:         {d2enum.KeyP, -1},
-		d2enum.ToggleMessageLog:         {d2enum.KeyM, -1},
-		d2enum.ToggleQuestLog:           {d2enum.KeyQ, -1},
-		d2enum.ToggleChatOverlay:        {d2enum.KeyEnter, -1},
-		d2enum.ToggleAutomap:            {d2enum.KeyTab, -1},
-		d2enum.CenterAutomap:            {d2enum.KeyHome, -1},
-		d2enum.ToggleSkillTreePanel:     {d2enum.KeyT, -1},
-		d2enum.ToggleRightSkillSelector: {d2enum.KeyS, -1},
-		d2enum.UseSkill1:                {d2enum.KeyF1, -1},
-		d2enum.UseSkill2:                {d2enum.KeyF2, -1},
-		d2enum.UseSkill3:                {d2enum.KeyF3, -1},
-		d2enum.UseSkill4:                {d2enum.KeyF4, -1},
-		d2enum.UseSkill5:                {d2enum.KeyF5, -1},
-		d2enum.UseSkill6:                {d2enum.KeyF6, -1},
-		d2enum.UseSkill7:                {d2enum.KeyF7, -1},
-		d2enum.UseSkill8:                {d2enum.KeyF8, -1},
-		d2enum.UseSkill9:                {-1, -1},
-		d2enum.UseSkill10:               {-1, -1},
-		d2enum.UseSkill11:               {-1, -1},
-		d2enum.UseSkill12:               {-1, -1},
-		d2enum.UseSkill13:               {-1, -1},
-		d2enum.UseSkill14:               {-1, -1},
-		d2enum.UseSkill15:               {-1, -1},
-		d2enum.UseSkill16:               {-1, -1},
-		d2enum.ToggleBelts:              {d2enum.KeyTilde, -1},
-		d2enum.UseBeltSlot1:             {d2enum.Key1, -1},
-		d2enum.UseBeltSlot2:             {d2enum.Key2, -1},
-		d2enum.UseBeltSlot3:             {d2enum.Key3, -1},
-		d2enum.UseBeltSlot4:             {d2enum.Key4, -1},
-		d2enum.ToggleRunWalk:            {d2enum.KeyR, -1},
-		d2enum.HoldRun:                  {d2enum.KeyControl, -1},
-		d2enum.HoldShowGroundItems:      {d2enum.KeyAlt, -1},
-		d2enum.HoldShowPortraits:        {d2enum.KeyZ, -1},
-		d2enum.HoldStandStill:           {d2enum.KeyShift, -1},
-		d2enum.ClearScreen:              {d2enum.KeySpace, -1},
+		d2enum.ToggleHelpScreen:         {d2enum.KeyH, d2enum.KeyNone},
+		d2enum.TogglePartyPanel:         {d2enum.KeyP, d2enum.KeyNone},
+		d2enum.ToggleMessageLog:         {d2enum.KeyM, d2enum.KeyNone},
+		d2enum.ToggleQuestLog:           {d2enum.KeyQ, d2enum.KeyNone},
+		d2enum.ToggleChatOverlay:        {d2enum.KeyEnter, d2enum.KeyNone},
+		d2enum.ToggleAutomap:            {d2enum.KeyTab, d2enum.KeyNone},
+		d2enum.CenterAutomap:            {d2enum.KeyHome, d2enum.KeyNone},
+		d2enum.ToggleSkillTreePanel:     {d2enum.KeyT, d2enum.KeyNone},
+		d2enum.ToggleRightSkillSelector: {d2enum.KeyS, d2enum.KeyNone},
+		d2enum.UseSkill1:                {d2enum.KeyF1, d2enum.KeyNone},
+		d2enum.UseSkill2:                {d2enum.KeyF2, d2enum.KeyNone},
+		d2enum.UseSkill3:                {d2enum.KeyF3, d2enum.KeyNone},
+		d2enum.UseSkill4:                {d2enum.KeyF4, d2enum.KeyNone},
+		d2enum.UseSkill5:                {d2enum.KeyF5, d2enum.KeyNone},
+		d2enum.UseSkill6:                {d2enum.KeyF6, d2enum.KeyNone},
+		d2enum.UseSkill7:                {d2enum.KeyF7, d2enum.KeyNone},
+		d2enum.UseSkill8:                {d2enum.KeyF8, d2enum.KeyNone},
+		d2enum.UseSkill9:                {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill10:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill11:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill12:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill13:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill14:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill15:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.UseSkill16:               {d2enum.KeyNone, d2enum.KeyNone},
+		d2enum.ToggleBelts:              {d2enum.KeyTilde, d2enum.KeyNone},
+		d2enum.UseBeltSlot1:             {d2enum.Key1, d2enum.KeyNone},
+		d2enum.UseBeltSlot2:             {d2enum.Key2, d2enum.KeyNone},
+		d2enum.UseBeltSlot3:             {d2enum.Key3, d2enum.KeyNone},
+		d2enum.UseBeltSlot4:             {d2enum.Key4, d2enum.KeyNone},
+		d2enum.ToggleRunWalk:            {d2enum.KeyR, d2enum.KeyNone},
+		d2enum.HoldRun:                  {d2enum.KeyControl, d2enum.KeyNone},
+		d2enum.HoldShowGroundItems:      {d2enum.KeyAlt, d2enum.KeyNone},
+		d2enum.HoldShowPortraits:        {d2enum.KeyZ, d2enum.KeyNone},
+		d2enum.HoldStandStill:           {d2enum.KeyShift, d2enum.KeyNone},
+		d2enum.ClearScreen:              {d2enum.KeySpace, d2enum.KeyNone},
 	}
 	for gameEvent, keys := range defaultControls {
-		keyMap.SetPrimaryBinding(gameEvent, keys.Primary)
-		keyMap.SetSecondaryBinding(gameEvent, keys.Secondary)
+		keyMap.SetPrimaryBinding(gameEvent, NewKeyCombo(keys[0], 0))
+		keyMap.SetSecondaryBinding(gameEvent, NewKeyCombo(keys[1], 0))
 	}
 
 	return keyMap
@@ -0,0 +1,155 @@
+package d2player
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+const (
+	keyMapFileMode    = 0600
+	keyMapDirMode     = 0750
+	bindingsPerAction = 2
+)
+
+var (
+	errUnknownGameEvent = errors.New("unknown game event")
+	errTooManyBindings  = errors.New("too many bindings")
+	errKeyConflict      = errors.New("key bound to more than one game event")
+)
+
+// keyMapFile is the format of key map files, the names of game events mapped to their primary and
+// secondary key combinations
+type keyMapFile map[string][]string
+
+// LoadKeyMap loads the key bindings saved at the given path, game events that are not in the file keep
+// their default bindings. When the file does not exist it is created with the default bindings.
+// Bindings that can not be parsed or that conflict with another binding are skipped, and returned as an
+// error together with the key map.
+func LoadKeyMap(path string) (*KeyMap, error) {
+	keyMap := DefaultKeyMap()
+	keyMap.path = path
+
+	data, err := ioutil.ReadFile(filepath.Clean(path))
+	if os.IsNotExist(err) {
+		return keyMap, keyMap.Save()
+	}
+
+	if err != nil {
+		return keyMap, err
+	}
+
+	file := keyMapFile{}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return keyMap, err
+	}
+
+	return keyMap, keyMap.apply(file)
+}
+
+// apply replaces the bindings of the game events in the file, in the order of the game events
+func (km *KeyMap) apply(file keyMapFile) error {
+	km.mutex.Lock()
+	defer km.mutex.Unlock()
+
+	bindings := make(map[d2enum.GameEvent][]KeyCombo)
+	problems := make([]string, 0)
+
+	for name, texts := range file {
+		gameEvent, found := d2enum.GameEventFromString(name)
+		if !found {
+			problems = append(problems, fmt.Sprintf("%v: %s", errUnknownGameEvent, name))
+			continue
+		}
+
+		if len(texts) > bindingsPerAction {
+			problems = append(problems, fmt.Sprintf("%v: %s", errTooManyBindings, name))
+			texts = texts[:bindingsPerAction]
+		}
+
+		combos := []KeyCombo{NoKeyCombo, NoKeyCombo}
+
+		for i, text := range texts {
+			combo, err := ParseKeyCombo(text)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+
+			combos[i] = combo
+		}
+
+		bindings[gameEvent] = combos
+	}
+
+	gameEvents := make([]d2enum.GameEvent, 0, len(bindings))
+
+	for gameEvent := range bindings {
+		gameEvents = append(gameEvents, gameEvent)
+		km.bind(gameEvent, NoKeyCombo, false)
+		km.bind(gameEvent, NoKeyCombo, true)
+	}
+
+	sort.Slice(gameEvents, func(i, j int) bool { return gameEvents[i] < gameEvents[j] })
+
+	for _, gameEvent := range gameEvents {
+		for i, combo := range bindings[gameEvent] {
+			if other, found := km.mapping[combo]; found && other != gameEvent {
+				problems = append(problems, fmt.Sprintf("%v: %s is bound to %s and %s",
+					errKeyConflict, combo.GetString(), other, gameEvent))
+
+				continue
+			}
+
+			km.bind(gameEvent, combo, i == 1)
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// Save writes the bindings to the file they were loaded from
+func (km *KeyMap) Save() error {
+	if km.path == "" {
+		return nil
+	}
+
+	return km.Export(km.path)
+}
+
+// Export writes the bindings to a file
+func (km *KeyMap) Export(path string) error {
+	km.mutex.RLock()
+
+	file := make(keyMapFile, len(km.controls))
+
+	for gameEvent, binding := range km.controls {
+		file[gameEvent.String()] = []string{binding.Primary.GetString(), binding.Secondary.GetString()}
+	}
+
+	km.mutex.RUnlock()
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), keyMapDirMode); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, keyMapFileMode)
+}
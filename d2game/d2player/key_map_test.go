@@ -0,0 +1,148 @@
+package d2player
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+)
+
+func TestKeyComboRoundTrip(t *testing.T) {
+	combos := []KeyCombo{
+		NewKeyCombo(d2enum.KeyF1, d2enum.KeyModControl|d2enum.KeyModShift),
+		NewKeyCombo(d2enum.KeyA, 0),
+		NewMouseCombo(d2enum.MouseButtonMiddle, d2enum.KeyModAlt),
+		NoKeyCombo,
+	}
+
+	for _, combo := range combos {
+		parsed, err := ParseKeyCombo(combo.GetString())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed != combo {
+			t.Errorf("%s was parsed as %s", combo.GetString(), parsed.GetString())
+		}
+	}
+
+	if text := combos[0].GetString(); text != "Ctrl+Shift+F1" {
+		t.Errorf("expected Ctrl+Shift+F1, got %s", text)
+	}
+
+	if _, err := ParseKeyCombo("Hyper+A"); err == nil {
+		t.Error("expected an error for an unknown modifier")
+	}
+}
+
+func TestKeyComboIgnoresOwnModifier(t *testing.T) {
+	combo := NewKeyCombo(d2enum.KeyShift, d2enum.KeyModShift).normalized()
+
+	if combo != NewKeyCombo(d2enum.KeyShift, 0) {
+		t.Errorf("expected Shift, got %s", combo.GetString())
+	}
+}
+
+func TestKeyMapModifiers(t *testing.T) {
+	keyMap := DefaultKeyMap()
+	keyMap.SetSecondaryBinding(d2enum.ToggleAutomap, NewKeyCombo(d2enum.KeyA, d2enum.KeyModControl))
+
+	if event := keyMap.getGameEvent(NewKeyCombo(d2enum.KeyA, d2enum.KeyModControl)); event != d2enum.ToggleAutomap {
+		t.Errorf("expected Ctrl+A to toggle the automap, got %s", event)
+	}
+
+	if event := keyMap.getGameEvent(NewKeyCombo(d2enum.KeyA, d2enum.KeyModShift)); event != d2enum.ToggleCharacterPanel {
+		t.Errorf("expected Shift+A to fall back to A, got %s", event)
+	}
+
+	keyMap.SetPrimaryBinding(d2enum.ToggleQuestLog, NewKeyCombo(d2enum.KeyA, 0))
+
+	if binding := keyMap.GetKeysForGameEvent(d2enum.ToggleCharacterPanel); !binding.Primary.IsNone() {
+		t.Errorf("expected A to be unbound from the character panel, got %s", binding.Primary.GetString())
+	}
+}
+
+func TestLoadKeyMap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2player")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keymap.json")
+
+	if _, err := LoadKeyMap(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected the default key map to be saved: %v", err)
+	}
+
+	keyMap, err := LoadKeyMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyMap.SetPrimaryBinding(d2enum.ToggleAutomap, NewMouseCombo(d2enum.MouseButtonMiddle, d2enum.KeyModShift))
+
+	if err := keyMap.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyMap, err = LoadKeyMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binding := keyMap.GetKeysForGameEvent(d2enum.ToggleAutomap)
+	if binding.Primary != NewMouseCombo(d2enum.MouseButtonMiddle, d2enum.KeyModShift) {
+		t.Errorf("expected Shift+MouseMiddle, got %s", binding.Primary.GetString())
+	}
+}
+
+func TestLoadKeyMapConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2player")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "keymap.json")
+
+	data, err := json.Marshal(keyMapFile{
+		d2enum.ToggleCharacterPanel.String(): {"Ctrl+K"},
+		d2enum.ToggleInventoryPanel.String(): {"ctrl+k", "B"},
+		"NotAnEvent":                         {"X"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, data, keyMapFileMode); err != nil {
+		t.Fatal(err)
+	}
+
+	keyMap, err := LoadKeyMap(path)
+	if err == nil {
+		t.Fatal("expected the conflict and the unknown event to be reported")
+	}
+
+	if !strings.Contains(err.Error(), "Ctrl+K") || !strings.Contains(err.Error(), "NotAnEvent") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if event := keyMap.getGameEvent(NewKeyCombo(d2enum.KeyK, d2enum.KeyModControl)); event != d2enum.ToggleCharacterPanel {
+		t.Errorf("expected the first binding of Ctrl+K to be kept, got %s", event)
+	}
+
+	if binding := keyMap.GetKeysForGameEvent(d2enum.ToggleInventoryPanel); binding.Secondary.GetString() != "B" {
+		t.Errorf("expected B to be bound to the inventory, got %s", binding.Secondary.GetString())
+	}
+}
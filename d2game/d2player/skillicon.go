@@ -94,6 +94,19 @@ func (si *skillIcon) Render(target d2interface.Surface) error {
 	return si.renderSpriteLabel(target)
 }
 
+// contains returns true when the screen position is on the icon, sprites are positioned by their bottom left
+// corner
+func (si *skillIcon) contains(x, y int) bool {
+	width, height, err := si.sprite.GetFrameSize(si.skill.IconCel)
+	if err != nil {
+		return false
+	}
+
+	iconX, iconY := si.GetPosition()
+
+	return x >= iconX && x < iconX+width && y > iconY-height && y <= iconY
+}
+
 func (si *skillIcon) Advance(elapsed float64) error {
 	return nil
 }
@@ -3,6 +3,7 @@ package d2player
 import (
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
@@ -22,6 +23,9 @@ const (
 	availSPLabelX = 677
 	availSPLabelY = 72
 
+	skillPointsLabelX = 677
+	skillPointsLabelY = 125
+
 	skillCloseButtonXLeft   = 416
 	skillCloseButtonXMiddle = 501
 	skillCloseButtonXRight  = 572
@@ -89,10 +93,12 @@ type skillTree struct {
 	asset        *d2asset.AssetManager
 	uiManager    *d2ui.UIManager
 	skills       map[int]*d2hero.HeroSkill
+	stats        *d2hero.HeroStatsState
 	skillIcons   []*skillIcon
 	heroClass    d2enum.Hero
 	frame        *d2ui.UIFrame
 	availSPLabel *d2ui.Label
+	pointsLabel  *d2ui.Label
 	closeButton  *d2ui.Button
 	tab          [numTabs]*skillTreeTab
 	isOpen       bool
@@ -100,6 +106,7 @@ type skillTree struct {
 	originY      int
 	selectedTab  int
 	onCloseCb    func()
+	onLearnCb    func(skillID int)
 	panelGroup   *d2ui.WidgetGroup
 	iconGroup    *d2ui.WidgetGroup
 	panel        *d2ui.CustomWidget
@@ -107,12 +114,14 @@ type skillTree struct {
 
 func newSkillTree(
 	skills map[int]*d2hero.HeroSkill,
+	stats *d2hero.HeroStatsState,
 	heroClass d2enum.Hero,
 	asset *d2asset.AssetManager,
 	ui *d2ui.UIManager,
 ) *skillTree {
 	st := &skillTree{
 		skills:    skills,
+		stats:     stats,
 		heroClass: heroClass,
 		asset:     asset,
 		uiManager: ui,
@@ -189,6 +198,10 @@ func (s *skillTree) loadForHeroType() {
 	s.availSPLabel.Alignment = d2gui.HorizontalAlignCenter
 	s.availSPLabel.SetText(s.makeTabString("StrSklTree1", "StrSklTree2", "StrSklTree3"))
 	s.panelGroup.AddWidget(s.availSPLabel)
+
+	s.pointsLabel = s.uiManager.NewLabel(d2resource.Font16, d2resource.PaletteSky)
+	s.pointsLabel.SetPosition(skillPointsLabelX, skillPointsLabelY)
+	s.pointsLabel.Alignment = d2gui.HorizontalAlignCenter
 }
 
 type heroTabData struct {
@@ -372,6 +385,29 @@ func (s *skillTree) SetOnCloseCb(cb func()) {
 	s.onCloseCb = cb
 }
 
+// SetOnLearnSkillCb sets the callback run when a skill point is spent on a skill
+func (s *skillTree) SetOnLearnSkillCb(cb func(skillID int)) {
+	s.onLearnCb = cb
+}
+
+// HandleClick spends a skill point on the skill of the icon at the screen position, it returns true when
+// there is an icon. The skill points are updated when the server has spent the point.
+func (s *skillTree) HandleClick(x, y int) bool {
+	for _, si := range s.skillIcons {
+		if !si.GetVisible() || !si.contains(x, y) {
+			continue
+		}
+
+		if s.stats.SkillPoints > 0 && s.onLearnCb != nil {
+			s.onLearnCb(si.skill.ID)
+		}
+
+		return true
+	}
+
+	return false
+}
+
 func (s *skillTree) setTab(tab int) {
 	s.selectedTab = tab
 	s.closeButton.SetPosition(s.tab[tab].closeButtonPosX, skillCloseButtonY)
@@ -443,6 +479,8 @@ func (s *skillTree) renderTabCommon(target d2interface.Surface) error {
 
 	// available skill points label
 	s.availSPLabel.RenderNoError(target)
+	s.pointsLabel.SetText(strconv.Itoa(s.stats.SkillPoints))
+	s.pointsLabel.RenderNoError(target)
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package d2client
+
+import (
+	"sync"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// maxPendingChatMessages is how many received chat messages are kept until they are shown
+const maxPendingChatMessages = 100
+
+// chatMessages are the chat messages that were received but not shown yet
+type chatMessages struct {
+	sync.Mutex
+	pending []d2netpacket.ChatPacket
+}
+
+func (g *GameClient) handleChatPacket(packet d2netpacket.NetPacket) error {
+	chat, err := d2netpacket.UnmarshalChat(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	g.chat.Lock()
+	defer g.chat.Unlock()
+
+	g.chat.pending = append(g.chat.pending, chat)
+	if len(g.chat.pending) > maxPendingChatMessages {
+		g.chat.pending = g.chat.pending[len(g.chat.pending)-maxPendingChatMessages:]
+	}
+
+	return nil
+}
+
+// ChatMessages returns the chat messages that were received since the last call, the oldest first
+func (g *GameClient) ChatMessages() []d2netpacket.ChatPacket {
+	g.chat.Lock()
+	defer g.chat.Unlock()
+
+	messages := g.chat.pending
+	g.chat.pending = nil
+
+	return messages
+}
+
+// SendChat sends a chat message to all players of the game. The server runs the commands of messages
+// that start with a slash, such as /w to whisper or /p to message the party.
+func (g *GameClient) SendChat(message string) error {
+	return g.SendPacketToServer(d2netpacket.CreateChatPacket(d2netpacket.ChatAll, "", "", message))
+}
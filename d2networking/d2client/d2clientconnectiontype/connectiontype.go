@@ -6,7 +6,8 @@ type ClientConnectionType int
 
 //
 const (
-	Local     ClientConnectionType = iota // Local client
-	LANServer                             // Server
-	LANClient                             // Remote client
+	Local        ClientConnectionType = iota // Local client
+	LANServer                                // Server
+	LANClient                                // Remote client over TCP
+	LANClientUDP                             // Remote client over UDP
 )
@@ -3,6 +3,7 @@ package d2localclient
 import (
 	"github.com/google/uuid"
 
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 
@@ -12,6 +13,8 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server"
 )
 
+const maxGamePlayers = 8
+
 // LocalClientConnection is the implementation of ClientConnection
 // for a local client.
 type LocalClientConnection struct {
@@ -58,7 +61,8 @@ func Create(asset *d2asset.AssetManager, openNetworkServer bool) (*LocalClientCo
 	return result, nil
 }
 
-// Open creates a new GameServer, runs the server and connects this client to it.
+// Open creates a new GameServer with a game named after the hero, runs the server and connects this client
+// to the game. Remote clients join the game of the hero when they do not give a game name.
 func (l *LocalClientConnection) Open(_, saveFilePath string) error {
 	var err error
 
@@ -73,9 +77,16 @@ func (l *LocalClientConnection) Open(_, saveFilePath string) error {
 		return err
 	}
 
-	l.gameServer.OnClientConnected(l)
+	_, err = l.gameServer.CreateGame(&d2server.GameOptions{
+		Name:       l.playerState.HeroName,
+		Difficulty: d2enum.DifficultyNormal,
+		MaxPlayers: maxGamePlayers,
+	})
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return l.gameServer.OnClientConnected(l)
 }
 
 // Close disconnects from the server and destroys it.
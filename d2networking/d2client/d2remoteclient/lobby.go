@@ -0,0 +1,130 @@
+package d2remoteclient
+
+import (
+	"bufio"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+const (
+	defaultPort       = "6669"
+	newGameMaxPlayers = 8
+)
+
+// JoinAddress returns the connection string that joins a game on the host, or creates the game when create
+// is set. Connecting to the host alone joins its default game, which is the game of the host player when
+// the server runs in the game, and the game created at the start of a dedicated server.
+func JoinAddress(host, game, password string, create bool) string {
+	query := url.Values{}
+
+	if password != "" {
+		query.Set("password", password)
+	}
+
+	if create {
+		query.Set("create", "1")
+	}
+
+	address := host + "/" + url.PathEscape(game)
+
+	if len(query) > 0 {
+		address += "?" + query.Encode()
+	}
+
+	return address
+}
+
+// parseJoinAddress splits a connection string created by JoinAddress into the address of the server and
+// the JoinGamePacket for the game
+func parseJoinAddress(connectionString string) (string, d2netpacket.NetPacket, error) {
+	address := connectionString
+	game := ""
+
+	if i := strings.Index(connectionString, "/"); i >= 0 {
+		address = connectionString[:i]
+		game = connectionString[i+1:]
+	}
+
+	path := game
+	query := ""
+
+	if i := strings.Index(game, "?"); i >= 0 {
+		path, query = game[:i], game[i+1:]
+	}
+
+	name, err := url.PathUnescape(path)
+	if err != nil {
+		return "", d2netpacket.NetPacket{}, err
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return "", d2netpacket.NetPacket{}, err
+	}
+
+	if values.Get("create") != "" {
+		return address, d2netpacket.CreateNewGamePacket(name, values.Get("password"), d2enum.DifficultyNormal,
+			newGameMaxPlayers, 0), nil
+	}
+
+	return address, d2netpacket.CreateJoinGamePacket(name, values.Get("password")), nil
+}
+
+func dial(address string) (*net.TCPConn, error) {
+	if !strings.Contains(address, ":") {
+		address += ":" + defaultPort
+	}
+
+	tcpAddress, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialTCP("tcp", nil, tcpAddress)
+}
+
+// ListGames returns the games in the lobby of the server at the given address, without joining the server
+func ListGames(address string) ([]d2netpacket.GameInfo, error) {
+	conn, err := dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	if err := conn.SetDeadline(time.Now().Add(lobbyTimeout)); err != nil {
+		return nil, err
+	}
+
+	if err := d2netpacket.WriteFrame(conn, d2netpacket.CreateListGamesPacket(), d2netpacket.JSONEncoding); err != nil {
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+
+	for {
+		packet, err := d2netpacket.ReadFrame(reader)
+		if err != nil {
+			return nil, err
+		}
+
+		if packet.PacketType != d2netpackettype.GameList {
+			continue
+		}
+
+		gameList, err := d2netpacket.UnmarshalGameList(packet.PacketData)
+		if err != nil {
+			return nil, err
+		}
+
+		return gameList.Games, nil
+	}
+}
@@ -0,0 +1,57 @@
+package d2remoteclient
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+func TestJoinAddress(t *testing.T) {
+	tests := []struct {
+		host, game, password string
+		create               bool
+	}{
+		{"127.0.0.1", "", "", false},
+		{"example.com:7000", "baal runs", "", false},
+		{"10.0.0.2", "cows/2?", "p&ss=1", true},
+	}
+
+	for _, test := range tests {
+		address := JoinAddress(test.host, test.game, test.password, test.create)
+
+		host, packet, err := parseJoinAddress(address)
+		if err != nil {
+			t.Fatalf("%s: %s", address, err)
+		}
+
+		if host != test.host || packet.PacketType != d2netpackettype.JoinGame {
+			t.Fatalf("%s: unexpected host %s or packet %s", address, host, packet.PacketType)
+		}
+
+		join, err := d2netpacket.UnmarshalJoinGame(packet.PacketData)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if join.Name != test.game || join.Password != test.password || join.Create != test.create {
+			t.Errorf("%s: parsed as %+v", address, join)
+		}
+	}
+}
+
+func TestJoinAddressHostOnly(t *testing.T) {
+	host, packet, err := parseJoinAddress("192.168.1.10:6669")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	join, err := d2netpacket.UnmarshalJoinGame(packet.PacketData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if host != "192.168.1.10:6669" || join.Name != "" || join.Create {
+		t.Errorf("a host joins the default game, got %s %+v", host, join)
+	}
+}
@@ -1,11 +1,11 @@
 package d2remoteclient
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"net"
-	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -17,6 +17,15 @@ import (
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
+// lobbyTimeout is how long the server has to answer in the lobby
+const lobbyTimeout = 10 * time.Second
+
+var (
+	errServerFull = errors.New("server is full")
+	errJoinGame   = errors.New("could not join game")
+	errRefused    = errors.New("the server refused the account")
+)
+
 // RemoteClientConnection is the implementation of ClientConnection
 // for a remote client.
 type RemoteClientConnection struct {
@@ -24,13 +33,18 @@ type RemoteClientConnection struct {
 	heroState      *d2hero.HeroStateFactory
 	clientListener d2networking.ClientListener // The GameClient
 	uniqueID       string                      // Unique ID generated on construction
-	tcpConnection  *net.TCPConn                // UDP connection to the server
+	connection     transport                   // TCP or UDP connection to the server
+	udp            bool                        // The connection is over UDP
 	active         bool                        // The connection is currently open
+	preferred      d2netpacket.PacketEncoding  // The packet encoding requested from the server
+	encoding       d2netpacket.PacketEncoding  // The packet encoding used for sending packets
+	credentials    d2netpacket.Credentials     // The account on closed servers
+	encodingMutex  sync.Mutex
 }
 
-// Create constructs a new RemoteClientConnection
-// and returns a pointer to it.
-func Create(asset *d2asset.AssetManager) (*RemoteClientConnection, error) {
+// Create constructs a new RemoteClientConnection, which connects to
+// the server over UDP when udp is set and over TCP otherwise.
+func Create(asset *d2asset.AssetManager, udp bool) (*RemoteClientConnection, error) {
 	heroStateFactory, err := d2hero.NewHeroStateFactory(asset)
 	if err != nil {
 		return nil, err
@@ -40,36 +54,32 @@ func Create(asset *d2asset.AssetManager) (*RemoteClientConnection, error) {
 		asset:     asset,
 		heroState: heroStateFactory,
 		uniqueID:  uuid.New().String(),
+		udp:       udp,
+		preferred: d2netpacket.BinaryEncoding,
+		encoding:  d2netpacket.JSONEncoding,
 	}
 
 	return result, nil
 }
 
-// Open runs serverListener() in a goroutine to continuously read UDP packets.
-// It also sends a PlayerConnectionRequestPacket packet to the server (see d2netpacket).
+// Open connects to the server and sends a PlayerConnectionRequestPacket, followed by a JoinGamePacket for
+// the game of the connection string (see JoinAddress). It returns once the server has answered with a
+// JoinGameResultPacket, then runs serverListener() in a goroutine to continuously read packets.
 func (r *RemoteClientConnection) Open(connectionString, saveFilePath string) error {
-	if !strings.Contains(connectionString, ":") {
-		connectionString += ":6669"
-	}
-
-	tcpAddress, err := net.ResolveTCPAddr("tcp", connectionString)
-
+	address, joinPacket, err := parseJoinAddress(connectionString)
 	if err != nil {
 		return err
 	}
 
-	r.tcpConnection, err = net.DialTCP("tcp", nil, tcpAddress)
+	r.connection, err = dialTransport(address, r.udp)
 	if err != nil {
 		return err
 	}
 
-	r.active = true
-	go r.serverListener()
-
-	log.Printf("Connected to server at %s", r.tcpConnection.RemoteAddr().String())
+	log.Printf("Connected to server at %s", r.connection.RemoteAddr().String())
 
 	gameState := r.heroState.LoadHeroState(saveFilePath)
-	packet := d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), gameState)
+	packet := d2netpacket.CreatePlayerConnectionRequestPacket(r.GetUniqueID(), gameState, r.credentials, r.preferred)
 	err = r.SendPacketToServer(packet)
 
 	if err != nil {
@@ -77,11 +87,66 @@ func (r *RemoteClientConnection) Open(connectionString, saveFilePath string) err
 		return err
 	}
 
+	if err = r.SendPacketToServer(joinPacket); err != nil {
+		return err
+	}
+
+	if err = r.waitForJoinGameResult(); err != nil {
+		_ = r.connection.Close()
+		return err
+	}
+
+	r.active = true
+	go r.serverListener()
+
 	return nil
 }
 
-// Close informs the server that this client has disconnected and sets
-// RemoteClientConnection.active to false.
+// waitForJoinGameResult reads the packets of the lobby until the server tells whether the game was joined
+func (r *RemoteClientConnection) waitForJoinGameResult() error {
+	if err := r.connection.SetReadDeadline(time.Now().Add(lobbyTimeout)); err != nil {
+		return err
+	}
+
+	for {
+		packet, err := r.connection.readPacket()
+		if err != nil {
+			return err
+		}
+
+		switch packet.PacketType {
+		case d2netpackettype.ServerFull:
+			return errServerFull
+		case d2netpackettype.AuthenticationResult:
+			result, err := d2netpacket.UnmarshalAuthenticationResult(packet.PacketData)
+			if err != nil {
+				return err
+			}
+
+			if !result.Authenticated {
+				return fmt.Errorf("%w: %s", errRefused, result.Reason)
+			}
+
+			log.Printf("RemoteClientConnection: authenticated as %s", r.credentials.Account)
+		case d2netpackettype.JoinGameResult:
+			result, err := d2netpacket.UnmarshalJoinGameResult(packet.PacketData)
+			if err != nil {
+				return err
+			}
+
+			if !result.Joined {
+				return fmt.Errorf("%w %s: %s", errJoinGame, result.Name, result.Reason)
+			}
+
+			log.Printf("RemoteClientConnection: joined game %q", result.Name)
+
+			return r.connection.SetReadDeadline(time.Time{})
+		}
+	}
+}
+
+// Close informs the server that this client has disconnected, closes the
+// connection and sets RemoteClientConnection.active to false.
 func (r *RemoteClientConnection) Close() error {
 	r.active = false
 	err := r.SendPacketToServer(d2netpacket.CreatePlayerDisconnectRequestPacket(r.GetUniqueID()))
@@ -90,17 +155,21 @@ func (r *RemoteClientConnection) Close() error {
 		return err
 	}
 
-	return nil
+	return r.connection.Close()
 }
 
 // GetUniqueID returns RemoteClientConnection.uniqueID.
-func (r RemoteClientConnection) GetUniqueID() string {
+func (r *RemoteClientConnection) GetUniqueID() string {
 	return r.uniqueID
 }
 
 // GetConnectionType returns an enum representing the connection type.
 // See: d2clientconnectiontype
-func (r RemoteClientConnection) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
+func (r *RemoteClientConnection) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
+	if r.udp {
+		return d2clientconnectiontype.LANClientUDP
+	}
+
 	return d2clientconnectiontype.LANClient
 }
 
@@ -109,130 +178,59 @@ func (r *RemoteClientConnection) SetClientListener(listener d2networking.ClientL
 	r.clientListener = listener
 }
 
-// SendPacketToServer compresses the JSON encoding of a NetPacket and
-// sends it to the server.
+// SetPacketEncoding sets the packet encoding requested from the server when opening the connection.
+// Until the server has answered, packets are sent as JSON.
+func (r *RemoteClientConnection) SetPacketEncoding(encoding d2netpacket.PacketEncoding) {
+	r.preferred = encoding
+}
+
+// SetCredentials sets the account that is used on closed servers, it has to be called before Open.
+// The character of the save file is then played from the account, the server only uses its name and class.
+func (r *RemoteClientConnection) SetCredentials(credentials d2netpacket.Credentials) {
+	r.credentials = credentials
+}
+
+// SendPacketToServer sends a NetPacket to the server as a length prefixed frame over TCP
+// or as a message over UDP, using the packet encoding negotiated with the server.
 func (r *RemoteClientConnection) SendPacketToServer(packet d2netpacket.NetPacket) error {
-	data, err := json.Marshal(packet)
+	r.encodingMutex.Lock()
+	encoding := r.encoding
+	r.encodingMutex.Unlock()
+
+	return r.connection.writePacket(packet, encoding)
+}
+
+// onUpdateServerInfo switches to the packet encoding chosen by the server
+func (r *RemoteClientConnection) onUpdateServerInfo(packet d2netpacket.NetPacket) {
+	serverInfo, err := d2netpacket.UnmarshalUpdateServerInfo(packet.PacketData)
 	if err != nil {
-		return err
+		return
 	}
 
-	if _, err = r.tcpConnection.Write(data); err != nil {
-		return err
-	}
+	r.encodingMutex.Lock()
+	r.encoding = serverInfo.Encoding
+	r.encodingMutex.Unlock()
 
-	return nil
+	log.Printf("RemoteClientConnection: using %s packets", serverInfo.Encoding)
 }
 
-// serverListener runs a while loop, reading from the GameServer's TCP
+// serverListener runs a while loop, reading from the GameServer's TCP or UDP
 // connection.
 func (r *RemoteClientConnection) serverListener() {
-	var packet d2netpacket.NetPacket
-
-	decoder := json.NewDecoder(r.tcpConnection)
-
 	for {
-		err := decoder.Decode(&packet)
+		packet, err := r.connection.readPacket()
 		if err != nil {
 			log.Printf("failed to decode the packet, err: %v\n", err)
 			return
 		}
 
-		p, err := r.decodeToPacket(packet.PacketType, string(packet.PacketData))
-		if err != nil {
-			log.Println(packet.PacketType, err)
+		if packet.PacketType == d2netpackettype.UpdateServerInfo {
+			r.onUpdateServerInfo(packet)
 		}
 
-		err = r.clientListener.OnPacketReceived(p)
+		err = r.clientListener.OnPacketReceived(packet)
 		if err != nil {
 			log.Println(packet.PacketType, err)
 		}
 	}
 }
-
-// bytesToJSON reads the packet type, decompresses the packet and returns a JSON string.
-// nolint:unused // WIP
-func (r *RemoteClientConnection) bytesToJSON(buffer []byte) (string, d2netpackettype.NetPacketType, error) {
-	packet, err := d2netpacket.UnmarshalNetPacket(buffer)
-	if err != nil {
-		return "", 0, err
-	}
-
-	return string(packet.PacketData), packet.PacketType, nil
-}
-
-// decodeToPacket unmarshals the JSON string into the correct struct
-// and returns a NetPacket declaring that struct.
-// nolint:gocyclo // switch statement on packet type makes sense, no need to change
-func (r *RemoteClientConnection) decodeToPacket(t d2netpackettype.NetPacketType, data string) (d2netpacket.NetPacket, error) {
-	var np = d2netpacket.NetPacket{}
-
-	var err error
-
-	switch t {
-	case d2netpackettype.GenerateMap:
-		var p d2netpacket.GenerateMapPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.MovePlayer:
-		var p d2netpacket.MovePlayerPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.UpdateServerInfo:
-		var p d2netpacket.UpdateServerInfoPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.AddPlayer:
-		var p d2netpacket.AddPlayerPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.CastSkill:
-		var p d2netpacket.CastPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.Ping:
-		var p d2netpacket.PingPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	case d2netpackettype.PlayerDisconnectionNotification:
-		var p d2netpacket.PlayerDisconnectRequestPacket
-		if err = json.Unmarshal([]byte(data), &p); err != nil {
-			break
-		}
-
-		np = d2netpacket.NetPacket{PacketType: t, PacketData: d2netpacket.MarshalPacket(p)}
-
-	default:
-		err = fmt.Errorf("RemoteClientConnection: unrecognized packet type: %v", t)
-	}
-
-	if err != nil {
-		return np, err
-	}
-
-	return np, nil
-}
@@ -0,0 +1,79 @@
+package d2remoteclient
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2udp"
+)
+
+// transport sends and receives the packets of the connection to the server, over TCP or UDP
+type transport interface {
+	readPacket() (d2netpacket.NetPacket, error)
+	writePacket(packet d2netpacket.NetPacket, encoding d2netpacket.PacketEncoding) error
+	SetReadDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+func dialTransport(address string, udp bool) (transport, error) {
+	if !udp {
+		conn, err := dial(address)
+		if err != nil {
+			return nil, err
+		}
+
+		return &tcpTransport{TCPConn: conn, reader: bufio.NewReader(conn)}, nil
+	}
+
+	if !strings.Contains(address, ":") {
+		address += ":" + defaultPort
+	}
+
+	conn, err := d2udp.Dial(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return udpTransport{conn}, nil
+}
+
+// tcpTransport sends the packets as length prefixed frames
+type tcpTransport struct {
+	*net.TCPConn
+	reader *bufio.Reader
+}
+
+func (t *tcpTransport) readPacket() (d2netpacket.NetPacket, error) {
+	return d2netpacket.ReadFrame(t.reader)
+}
+
+func (t *tcpTransport) writePacket(packet d2netpacket.NetPacket, encoding d2netpacket.PacketEncoding) error {
+	return d2netpacket.WriteFrame(t.TCPConn, packet, encoding)
+}
+
+// udpTransport sends each packet as a message, lossy packets are sent unreliably
+type udpTransport struct {
+	*d2udp.Conn
+}
+
+func (t udpTransport) readPacket() (d2netpacket.NetPacket, error) {
+	message, err := t.ReadMessage()
+	if err != nil {
+		return d2netpacket.NetPacket{}, err
+	}
+
+	return d2netpacket.UnmarshalFrame(message)
+}
+
+func (t udpTransport) writePacket(packet d2netpacket.NetPacket, encoding d2netpacket.PacketEncoding) error {
+	message, err := d2netpacket.MarshalFrame(packet, encoding)
+	if err != nil {
+		return err
+	}
+
+	return t.WriteMessage(message, !packet.PacketType.Lossy())
+}
@@ -11,8 +11,6 @@ import (
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
@@ -42,7 +40,15 @@ type GameClient struct {
 	mapGen           *d2mapgen.MapGenerator         // map generator
 	PlayerID         string                         // ID of the local player
 	Players          map[string]*d2mapentity.Player // IDs of the other players
+	replicated       map[string]*replicatedEntity   // entities created from the snapshots of the server
+	snapshots        snapshotClock                  // tick of the server the replicated entities are shown at
+	moveSequence     uint32                         // sequence number of the last move of the local player
+	ackedSequence    uint32                         // sequence number of the last move the server acknowledged
+	predictedMoves   []predictedMove                // moves of the local player the server did not acknowledge
+	latencies        playerLatencies                // round trip times of the players to the server
+	chat             chatMessages                   // chat messages that were not shown yet
 	Seed             int64                          // Map seed
+	Difficulty       d2enum.DifficultyType          // Difficulty of the game
 	RegenMap         bool                           // Regenerate tile cache on render (map has changed)
 }
 
@@ -53,13 +59,22 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType,
 		asset:          asset,
 		MapEngine:      d2mapengine.CreateMapEngine(asset),
 		Players:        make(map[string]*d2mapentity.Player),
+		replicated:     make(map[string]*replicatedEntity),
 		connectionType: connectionType,
 		scriptEngine:   scriptEngine,
 	}
 
 	// for a remote client connection, set loading to true - wait until we process the GenerateMapPacket
 	// before we start updating map entites
-	result.MapEngine.IsLoading = connectionType == d2clientconnectiontype.LANClient
+	result.MapEngine.IsLoading = connectionType == d2clientconnectiontype.LANClient ||
+		connectionType == d2clientconnectiontype.LANClientUDP
+
+	// the server replicates the entities of the map, they are not placed with the map
+	result.MapEngine.Replicated = true
+
+	if scriptEngine != nil {
+		result.addLatencyScriptFunction()
+	}
 
 	mapGen, err := d2mapgen.NewMapGenerator(asset, result.MapEngine)
 	if err != nil {
@@ -70,7 +85,9 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType,
 
 	switch connectionType {
 	case d2clientconnectiontype.LANClient:
-		result.clientConnection, err = d2remoteclient.Create(asset)
+		result.clientConnection, err = d2remoteclient.Create(asset, false)
+	case d2clientconnectiontype.LANClientUDP:
+		result.clientConnection, err = d2remoteclient.Create(asset, true)
 	case d2clientconnectiontype.LANServer:
 		result.clientConnection, err = d2localclient.Create(asset, true)
 	case d2clientconnectiontype.Local:
@@ -88,6 +105,48 @@ func Create(connectionType d2clientconnectiontype.ClientConnectionType,
 	return result, nil
 }
 
+// SetPacketEncoding sets the packet encoding requested from a remote server,
+// it has no effect on local clients. It has to be called before Open.
+func (g *GameClient) SetPacketEncoding(encoding d2netpacket.PacketEncoding) {
+	if remote := g.remoteConnection(); remote != nil {
+		remote.SetPacketEncoding(encoding)
+	}
+}
+
+// SetCredentials sets the account that is used on closed remote servers,
+// it has no effect on local clients. It has to be called before Open.
+func (g *GameClient) SetCredentials(credentials d2netpacket.Credentials) {
+	if remote := g.remoteConnection(); remote != nil {
+		remote.SetCredentials(credentials)
+	}
+}
+
+// remoteConnection returns the connection to a remote server, or nil for local clients
+func (g *GameClient) remoteConnection() *d2remoteclient.RemoteClientConnection {
+	connection := g.clientConnection
+	if simulated, ok := connection.(*simulatedConnection); ok {
+		connection = simulated.ServerConnection
+	}
+
+	remote, _ := connection.(*d2remoteclient.RemoteClientConnection)
+
+	return remote
+}
+
+// SetNetworkConditions simulates a network with the given latency and packet loss between the client and the
+// server, for testing. It has to be called before Open.
+func (g *GameClient) SetNetworkConditions(conditions NetworkConditions) {
+	if conditions == (NetworkConditions{}) {
+		return
+	}
+
+	g.clientConnection = newSimulatedConnection(g.clientConnection, conditions)
+	g.clientConnection.SetClientListener(g)
+
+	// the map arrives late, like it does from a remote server
+	g.MapEngine.IsLoading = true
+}
+
 // Open creates the server and connects to it if the client is local.
 // If the client is remote it sends a PlayerConnectionRequestPacket to the
 // server (see d2netpacket).
@@ -137,6 +196,10 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 		if err := g.handleMovePlayerPacket(packet); err != nil {
 			return err
 		}
+	case d2netpackettype.SetPlayerPosition:
+		if err := g.handleSetPlayerPositionPacket(packet); err != nil {
+			return err
+		}
 	case d2netpackettype.CastSkill:
 		if err := g.handleCastSkillPacket(packet); err != nil {
 			return err
@@ -145,8 +208,36 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 		if err := g.handleSpawnItemPacket(packet); err != nil {
 			return err
 		}
+	case d2netpackettype.AttackResult:
+		if err := g.handleAttackResultPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.CreateEntities:
+		if err := g.handleCreateEntitiesPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.UpdateEntities:
+		if err := g.handleUpdateEntitiesPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.DestroyEntities:
+		if err := g.handleDestroyEntitiesPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.RemovePlayer:
+		if err := g.handleRemovePlayerPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.Chat:
+		if err := g.handleChatPacket(packet); err != nil {
+			return err
+		}
+	case d2netpackettype.PlayerStats:
+		if err := g.handlePlayerStatsPacket(packet); err != nil {
+			return err
+		}
 	case d2netpackettype.Ping:
-		if err := g.handlePingPacket(); err != nil {
+		if err := g.handlePingPacket(packet); err != nil {
 			log.Printf("GameClient: error responding to server ping: %s", err)
 		}
 	case d2netpackettype.PlayerDisconnectionNotification:
@@ -159,6 +250,8 @@ func (g *GameClient) OnPacketReceived(packet d2netpacket.NetPacket) error {
 	case d2netpackettype.ServerFull:
 		log.Println("Server is full")
 		os.Exit(0)
+	case d2netpackettype.JoinGameResult, d2netpackettype.GameList:
+		// the lobby is handled by the connection before the game starts
 	default:
 		log.Fatalf("Invalid packet type: %d", packet.PacketType)
 	}
@@ -178,8 +271,15 @@ func (g *GameClient) handleGenerateMapPacket(packet d2netpacket.NetPacket) error
 		return err
 	}
 
-	if mapData.RegionType == d2enum.RegionAct1Town {
-		g.mapGen.GenerateAct1Overworld()
+	if _, err := g.mapGen.GenerateLevel(mapData.LevelID); err != nil {
+		return err
+	}
+
+	// a new map starts without entities, the players move along to it
+	g.replicated = make(map[string]*replicatedEntity)
+
+	for _, player := range g.Players {
+		g.MapEngine.AddEntity(player)
 	}
 
 	g.RegenMap = true
@@ -196,6 +296,7 @@ func (g *GameClient) handleUpdateServerInfoPacket(packet d2netpacket.NetPacket)
 	g.MapEngine.SetSeed(serverInfo.Seed)
 	g.PlayerID = serverInfo.PlayerID
 	g.Seed = serverInfo.Seed
+	g.Difficulty = serverInfo.Difficulty
 	log.Printf("Player id set to %s", serverInfo.PlayerID)
 
 	return nil
@@ -218,6 +319,25 @@ func (g *GameClient) handleAddPlayerPacket(packet d2netpacket.NetPacket) error {
 	return nil
 }
 
+func (g *GameClient) handleRemovePlayerPacket(packet d2netpacket.NetPacket) error {
+	removePlayer, err := d2netpacket.UnmarshalRemovePlayer(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	player := g.Players[removePlayer.ID]
+	if player == nil {
+		return nil
+	}
+
+	log.Printf("Player %s left the game", player.Name())
+
+	delete(g.Players, removePlayer.ID)
+	g.MapEngine.RemoveEntity(player)
+
+	return nil
+}
+
 func (g *GameClient) handleSpawnItemPacket(packet d2netpacket.NetPacket) error {
 	item, err := d2netpacket.UnmarshalSpawnItem(packet.PacketData)
 	if err != nil {
@@ -233,169 +353,141 @@ func (g *GameClient) handleSpawnItemPacket(packet d2netpacket.NetPacket) error {
 	return err
 }
 
-func (g *GameClient) handleMovePlayerPacket(packet d2netpacket.NetPacket) error {
-	movePlayer, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+func (g *GameClient) handleAttackResultPacket(packet d2netpacket.NetPacket) error {
+	result, err := d2netpacket.UnmarshalAttackResult(packet.PacketData)
 	if err != nil {
 		return err
 	}
 
-	player := g.Players[movePlayer.PlayerID]
-	start := d2vector.NewPositionTile(movePlayer.StartX, movePlayer.StartY)
-	dest := d2vector.NewPositionTile(movePlayer.DestX, movePlayer.DestY)
-	path := g.MapEngine.PathFind(start, dest)
+	if player := g.Players[result.DefenderID]; player != nil {
+		player.Stats.Health = result.Life
 
-	if len(path) > 0 {
-		player.SetPath(path, func() {
-			tilePosition := player.Position.Tile()
-			tile := g.MapEngine.TileAt(int(tilePosition.X()), int(tilePosition.Y()))
-
-			if tile == nil {
-				return
-			}
+		switch {
+		case result.Killed:
+			// the server brings slain players back at full life, their new position follows
+			player.Stats.Health = player.Stats.MaxHealth
+		case result.Hit:
+			player.GetHit()
+		}
 
-			player.SetIsInTown(tile.RegionType == d2enum.RegionAct1Town)
+		return nil
+	}
 
-			err := player.SetAnimationMode(player.GetAnimationMode())
+	// monsters which the client does not know about are left alone
+	npc, ok := g.MapEngine.Entities()[result.DefenderID].(*d2mapentity.NPC)
+	if !ok {
+		return nil
+	}
 
-			if err != nil {
-				fmtStr := "GameClient: error setting animation mode for player %s: %s"
-				log.Printf(fmtStr, player.ID(), err)
-			}
-		})
+	switch {
+	case result.Killed:
+		npc.Kill()
+	case result.Hit:
+		npc.GetHit()
 	}
 
 	return nil
 }
 
-func (g *GameClient) handleCastSkillPacket(packet d2netpacket.NetPacket) error {
-	playerCast, err := d2netpacket.UnmarshalCast(packet.PacketData)
+func (g *GameClient) handleMovePlayerPacket(packet d2netpacket.NetPacket) error {
+	movePlayer, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
 	if err != nil {
 		return err
 	}
 
-	player := g.Players[playerCast.SourceEntityID]
-	player.StopMoving()
+	player := g.Players[movePlayer.PlayerID]
+	if player == nil {
+		return fmt.Errorf("cannot move unknown player %s", movePlayer.PlayerID)
+	}
 
-	castX := playerCast.TargetX * numSubtilesPerTile
-	castY := playerCast.TargetY * numSubtilesPerTile
+	if movePlayer.PlayerID == g.PlayerID && movePlayer.Sequence != 0 {
+		g.reconcileMove(player, &movePlayer)
+		return nil
+	}
 
-	direction := player.Position.DirectionTo(*d2vector.NewVector(castX, castY))
-	player.SetDirection(direction)
+	// the local player's run state is driven by the HUD
+	if movePlayer.PlayerID != g.PlayerID {
+		player.SetIsRunning(movePlayer.Running)
+	}
 
-	skillRecord := g.asset.Records.Skill.Details[playerCast.SkillID]
+	start := d2vector.NewPositionTile(movePlayer.StartX, movePlayer.StartY)
+	g.walkPlayer(player, start, d2vector.NewPositionTile(movePlayer.DestX, movePlayer.DestY))
 
-	missileEntities, err := g.createMissileEntities(skillRecord, player, castX, castY)
-	if err != nil {
-		return err
+	return nil
+}
+
+// walkPlayer sets the path of the player entity from the start to the destination, it returns where the path ends
+func (g *GameClient) walkPlayer(player *d2mapentity.Player, start, dest d2vector.Position) d2vector.Position {
+	path := g.MapEngine.PathFind(start, dest)
+	if len(path) == 0 {
+		return start
 	}
 
-	var summonedNpcEntity *d2mapentity.NPC
-	if skillRecord.Summon != "" {
-		summonedNpcEntity, err = g.createSummonedNpcEntity(skillRecord, int(castX), int(castY))
+	player.SetPath(path, func() {
+		tilePosition := player.Position.Tile()
+		tile := g.MapEngine.TileAt(int(tilePosition.X()), int(tilePosition.Y()))
 
-		if err != nil {
-			return err
+		if tile == nil {
+			return
 		}
-	}
 
-	player.StartCasting(skillRecord.Anim, func() {
-		if len(missileEntities) > 0 {
-			// shoot the missiles of the skill after the player has finished casting
-			for _, missileEntity := range missileEntities {
-				g.MapEngine.AddEntity(missileEntity)
-			}
-		}
+		player.SetIsInTown(tile.RegionType.IsTown())
+
+		err := player.SetAnimationMode(player.GetAnimationMode())
 
-		if summonedNpcEntity != nil {
-			// summon the referenced NPC after the player has finished casting
-			g.MapEngine.AddEntity(summonedNpcEntity)
+		if err != nil {
+			fmtStr := "GameClient: error setting animation mode for player %s: %s"
+			log.Printf(fmtStr, player.ID(), err)
 		}
 	})
 
-	overlayRecord := g.asset.Records.Layout.Overlays[skillRecord.Castoverlay]
-
-	return g.playCastOverlay(overlayRecord, int(player.Position.X()), int(player.Position.Y()))
+	return path[len(path)-1]
 }
 
-func (g *GameClient) createSummonedNpcEntity(skillRecord *d2records.SkillRecord, x, y int) (*d2mapentity.NPC, error) {
-	monsterStatsRecord := g.asset.Records.Monster.Stats[skillRecord.Summon]
-
-	if monsterStatsRecord == nil {
-		fmtErr := "cannot cast skill - No monstat entry for \"%s\""
-		return nil, fmt.Errorf(fmtErr, skillRecord.Summon)
-	}
-
-	// https://github.com/OpenDiablo2/OpenDiablo2/issues/803
-	summonedNpcEntity, err := g.MapEngine.NewNPC(x, y, monsterStatsRecord, 0)
+func (g *GameClient) handleSetPlayerPositionPacket(packet d2netpacket.NetPacket) error {
+	setPosition, err := d2netpacket.UnmarshalSetPlayerPosition(packet.PacketData)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return summonedNpcEntity, nil
-}
-
-func (g *GameClient) createMissileEntities(
-	skillRecord *d2records.SkillRecord,
-	player *d2mapentity.Player,
-	castX, castY float64,
-) ([]*d2mapentity.Missile, error) {
-	missileRecords := []*d2records.MissileRecord{
-		g.asset.Records.GetMissileByName(skillRecord.Cltmissile),
-		g.asset.Records.GetMissileByName(skillRecord.Cltmissilea),
-		g.asset.Records.GetMissileByName(skillRecord.Cltmissileb),
-		g.asset.Records.GetMissileByName(skillRecord.Cltmissilec),
-		g.asset.Records.GetMissileByName(skillRecord.Cltmissiled),
+	player := g.Players[setPosition.PlayerID]
+	if player == nil {
+		return fmt.Errorf("cannot set position of unknown player %s", setPosition.PlayerID)
 	}
 
-	missileEntities := make([]*d2mapentity.Missile, 0)
-
-	for _, missileRecord := range missileRecords {
-		if missileRecord == nil {
-			continue
-		}
+	player.SetPosition(d2vector.NewPositionTile(setPosition.X, setPosition.Y))
 
-		missileEntity, err := g.createMissileEntity(missileRecord, player, castX, castY)
-		if err != nil {
-			return nil, err
-		}
-
-		missileEntities = append(missileEntities, missileEntity)
+	if setPosition.PlayerID == g.PlayerID {
+		g.acknowledgeMoves(setPosition.Sequence)
+		g.replayMoves(player)
 	}
 
-	return missileEntities, nil
+	return nil
 }
 
-func (g *GameClient) createMissileEntity(
-	missileRecord *d2records.MissileRecord,
-	player *d2mapentity.Player,
-	castX, castY float64,
-) (*d2mapentity.Missile, error) {
-	if missileRecord == nil {
-		return nil, nil
+func (g *GameClient) handleCastSkillPacket(packet d2netpacket.NetPacket) error {
+	playerCast, err := d2netpacket.UnmarshalCast(packet.PacketData)
+	if err != nil {
+		return err
 	}
 
-	radians := d2math.GetRadiansBetween(
-		player.Position.X(),
-		player.Position.Y(),
-		castX,
-		castY,
-	)
+	player := g.Players[playerCast.SourceEntityID]
+	player.StopMoving()
 
-	missileEntity, err := g.MapEngine.NewMissile(
-		int(player.Position.X()),
-		int(player.Position.Y()),
-		g.asset.Records.Missiles[missileRecord.Id],
-	)
+	castX := playerCast.TargetX * numSubtilesPerTile
+	castY := playerCast.TargetY * numSubtilesPerTile
 
-	if err != nil {
-		return nil, err
-	}
+	direction := player.Position.DirectionTo(*d2vector.NewVector(castX, castY))
+	player.SetDirection(direction)
 
-	missileEntity.SetRadians(radians, func() {
-		g.MapEngine.RemoveEntity(missileEntity)
-	})
+	skillRecord := g.asset.Records.Skill.Details[playerCast.SkillID]
 
-	return missileEntity, nil
+	// the missiles and summons of the skill are replicated by the server
+	player.StartCasting(skillRecord.Anim, nil)
+
+	overlayRecord := g.asset.Records.Layout.Overlays[skillRecord.Castoverlay]
+
+	return g.playCastOverlay(overlayRecord, int(player.Position.X()), int(player.Position.Y()))
 }
 
 func (g *GameClient) playCastOverlay(overlayRecord *d2records.OverlayRecord, x, y int) error {
@@ -421,17 +513,6 @@ func (g *GameClient) playCastOverlay(overlayRecord *d2records.OverlayRecord, x,
 	return nil
 }
 
-func (g *GameClient) handlePingPacket() error {
-	pongPacket := d2netpacket.CreatePongPacket(g.PlayerID)
-	err := g.clientConnection.SendPacketToServer(pongPacket)
-
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // IsSinglePlayer returns a bool for whether the game is a single-player game
 func (g *GameClient) IsSinglePlayer() bool {
 	return g.connectionType == d2clientconnectiontype.Local
@@ -0,0 +1,69 @@
+package d2client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// playerLatencies are the latencies of the players in the game, measured by the server
+type playerLatencies struct {
+	sync.Mutex
+	byID map[string]time.Duration
+}
+
+func (g *GameClient) handlePingPacket(packet d2netpacket.NetPacket) error {
+	ping, err := d2netpacket.UnmarshalPing(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	latencies := make(map[string]time.Duration, len(ping.Latencies))
+	for _, latency := range ping.Latencies {
+		latencies[latency.ID] = latency.Latency
+	}
+
+	g.latencies.Lock()
+	g.latencies.byID = latencies
+	g.latencies.Unlock()
+
+	return g.clientConnection.SendPacketToServer(d2netpacket.CreatePongPacket(g.PlayerID, ping.TS))
+}
+
+// Latencies returns the round trip times between the server and the players in the game, by the names of
+// the players. The server measures them and sends them with each ping.
+func (g *GameClient) Latencies() map[string]time.Duration {
+	g.latencies.Lock()
+	defer g.latencies.Unlock()
+
+	latencies := make(map[string]time.Duration, len(g.latencies.byID))
+
+	for id, latency := range g.latencies.byID {
+		if player := g.Players[id]; player != nil {
+			latencies[player.Name()] = latency
+		}
+	}
+
+	return latencies
+}
+
+// addLatencyScriptFunction makes the latencies available to scripts, in milliseconds
+func (g *GameClient) addLatencyScriptFunction() {
+	g.scriptEngine.AddFunction("getLatencies", func(call otto.FunctionCall) otto.Value {
+		latencies := make(map[string]int64)
+		for name, latency := range g.Latencies() {
+			latencies[name] = latency.Milliseconds()
+		}
+
+		val, err := g.scriptEngine.ToValue(latencies)
+		if err != nil {
+			fmt.Print(err.Error())
+		}
+
+		return val
+	})
+}
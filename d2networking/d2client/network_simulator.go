@@ -0,0 +1,159 @@
+package d2client
+
+import (
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// delayQueueSize is the number of packets that can be on their way in each direction of a simulated network
+const delayQueueSize = 1024
+
+// NetworkConditions are the artificial latency and packet loss of a simulated network between the client and the
+// server, they are meant for testing the client on a loopback connection
+type NetworkConditions struct {
+	Latency    time.Duration // added to each packet in each direction
+	Jitter     time.Duration // the latency of each packet varies by up to this much
+	PacketLoss float64       // chance from 0 to 1 that a lossy packet is dropped, see NetPacketType.Lossy
+}
+
+type delayedPacket struct {
+	packet    d2netpacket.NetPacket
+	deliverAt time.Time
+}
+
+// packetDelayer delivers packets after the simulated latency, in the order they were sent
+type packetDelayer struct {
+	sync.Mutex
+	conditions NetworkConditions
+	rng        *rand.Rand
+	last       time.Time // when the last packet is delivered, no packet overtakes it
+	queue      chan delayedPacket
+	done       chan struct{}
+	stopOnce   sync.Once
+	deliver    func(packet d2netpacket.NetPacket) error
+}
+
+func newPacketDelayer(conditions NetworkConditions, deliver func(packet d2netpacket.NetPacket) error,
+	seed int64) *packetDelayer {
+	delayer := &packetDelayer{
+		conditions: conditions,
+		rng:        rand.New(rand.NewSource(seed)), //nolint:gosec // not concerned with crypto-strong randomness
+		queue:      make(chan delayedPacket, delayQueueSize),
+		done:       make(chan struct{}),
+		deliver:    deliver,
+	}
+
+	go delayer.run()
+
+	return delayer
+}
+
+// send queues the packet for delivery, or drops it
+func (d *packetDelayer) send(packet d2netpacket.NetPacket) {
+	d.Lock()
+	defer d.Unlock()
+
+	if packet.PacketType.Lossy() && d.rng.Float64() < d.conditions.PacketLoss {
+		return
+	}
+
+	delay := d.conditions.Latency
+	if d.conditions.Jitter > 0 {
+		delay += time.Duration(d.rng.Int63n(int64(d.conditions.Jitter)))
+	}
+
+	deliverAt := time.Now().Add(delay)
+	if deliverAt.Before(d.last) {
+		deliverAt = d.last
+	}
+
+	d.last = deliverAt
+
+	select {
+	case d.queue <- delayedPacket{packet: packet, deliverAt: deliverAt}:
+	case <-d.done:
+	}
+}
+
+func (d *packetDelayer) run() {
+	for {
+		select {
+		case <-d.done:
+			return
+		case delayed := <-d.queue:
+			timer := time.NewTimer(time.Until(delayed.deliverAt))
+
+			select {
+			case <-d.done:
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+
+			if err := d.deliver(delayed.packet); err != nil {
+				log.Printf("GameClient: error delivering simulated packet %s: %s", delayed.packet.PacketType, err)
+			}
+		}
+	}
+}
+
+func (d *packetDelayer) stop() {
+	d.stopOnce.Do(func() {
+		close(d.done)
+	})
+}
+
+// simulatedConnection is a server connection with the latency and packet loss of a simulated network in both
+// directions
+type simulatedConnection struct {
+	ServerConnection
+	listener d2networking.ClientListener
+	outgoing *packetDelayer
+	incoming *packetDelayer
+}
+
+func newSimulatedConnection(connection ServerConnection, conditions NetworkConditions) *simulatedConnection {
+	seed := time.Now().UnixNano()
+
+	simulated := &simulatedConnection{ServerConnection: connection}
+	simulated.outgoing = newPacketDelayer(conditions, connection.SendPacketToServer, seed)
+	simulated.incoming = newPacketDelayer(conditions, simulated.deliver, seed+1)
+
+	connection.SetClientListener(simulated)
+
+	return simulated
+}
+
+// SetClientListener sets the listener the delayed packets from the server are delivered to
+func (s *simulatedConnection) SetClientListener(listener d2networking.ClientListener) {
+	s.listener = listener
+}
+
+// OnPacketReceived delays a packet from the server
+func (s *simulatedConnection) OnPacketReceived(packet d2netpacket.NetPacket) error {
+	s.incoming.send(packet)
+	return nil
+}
+
+// SendPacketToServer delays a packet to the server
+func (s *simulatedConnection) SendPacketToServer(packet d2netpacket.NetPacket) error {
+	s.outgoing.send(packet)
+	return nil
+}
+
+// Close drops the packets on their way and closes the connection
+func (s *simulatedConnection) Close() error {
+	s.outgoing.stop()
+	s.incoming.stop()
+
+	return s.ServerConnection.Close()
+}
+
+func (s *simulatedConnection) deliver(packet d2netpacket.NetPacket) error {
+	return s.listener.OnPacketReceived(packet)
+}
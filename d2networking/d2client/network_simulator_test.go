@@ -0,0 +1,85 @@
+package d2client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+type recordingDeliverer struct {
+	sync.Mutex
+	packets []d2netpacket.NetPacket
+}
+
+func (r *recordingDeliverer) deliver(packet d2netpacket.NetPacket) error {
+	r.Lock()
+	defer r.Unlock()
+
+	r.packets = append(r.packets, packet)
+
+	return nil
+}
+
+func (r *recordingDeliverer) count() int {
+	r.Lock()
+	defer r.Unlock()
+
+	return len(r.packets)
+}
+
+func TestPacketDelayerOrder(t *testing.T) {
+	recorder := &recordingDeliverer{}
+	conditions := NetworkConditions{Latency: 20 * time.Millisecond, Jitter: 20 * time.Millisecond}
+	delayer := newPacketDelayer(conditions, recorder.deliver, 1)
+
+	defer delayer.stop()
+
+	start := time.Now()
+
+	const packets = 20
+
+	for i := 0; i < packets; i++ {
+		delayer.send(d2netpacket.NetPacket{PacketType: d2netpackettype.CastSkill, PacketData: []byte{byte(i)}})
+	}
+
+	for recorder.count() < packets {
+		if time.Since(start) > time.Second {
+			t.Fatalf("expected %d packets, got %d", packets, recorder.count())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if time.Since(start) < conditions.Latency {
+		t.Error("the packets were not delayed")
+	}
+
+	for i, packet := range recorder.packets {
+		if packet.PacketData[0] != byte(i) {
+			t.Fatalf("packet %d arrived as packet %d", packet.PacketData[0], i)
+		}
+	}
+}
+
+func TestPacketDelayerLoss(t *testing.T) {
+	recorder := &recordingDeliverer{}
+	delayer := newPacketDelayer(NetworkConditions{PacketLoss: 1}, recorder.deliver, 1)
+
+	defer delayer.stop()
+
+	delayer.send(d2netpacket.NetPacket{PacketType: d2netpackettype.MovePlayer})
+	delayer.send(d2netpacket.NetPacket{PacketType: d2netpackettype.CastSkill})
+
+	start := time.Now()
+
+	for recorder.count() < 1 && time.Since(start) < time.Second {
+		time.Sleep(time.Millisecond)
+	}
+
+	if recorder.count() != 1 || recorder.packets[0].PacketType != d2netpackettype.CastSkill {
+		t.Errorf("expected only the cast to arrive, got %d packets", recorder.count())
+	}
+}
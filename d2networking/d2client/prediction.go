@@ -0,0 +1,93 @@
+package d2client
+
+import (
+	"fmt"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// reconcileTolerance is the distance in sub tiles between a predicted and an acknowledged destination of the local
+// player that is not corrected
+const reconcileTolerance = 1
+
+// predictedMove is a move of the local player that was walked before the server acknowledged it
+type predictedMove struct {
+	sequence     uint32
+	destX, destY float64           // the world position that was clicked
+	end          d2vector.Position // where the predicted path ends
+}
+
+// MovePlayer walks the local player towards the given world position right away and sends the move to the
+// server. The server acknowledges the move with its sequence number, together with the destination it allowed.
+func (g *GameClient) MovePlayer(destX, destY float64) error {
+	player := g.Players[g.PlayerID]
+	if player == nil {
+		return fmt.Errorf("cannot move unknown player %s", g.PlayerID)
+	}
+
+	g.moveSequence++
+
+	start := player.Position.World()
+	move := predictedMove{sequence: g.moveSequence, destX: destX, destY: destY}
+	move.end = g.walkPlayer(player, player.Position, d2vector.NewPositionTile(destX, destY))
+	g.predictedMoves = append(g.predictedMoves, move)
+
+	return g.SendPacketToServer(d2netpacket.CreateMovePlayerPacket(g.PlayerID, move.sequence, start.X(), start.Y(),
+		destX, destY, player.IsRunning()))
+}
+
+// acknowledgeMoves forgets the predicted moves up to the given sequence number. It returns the move with the
+// sequence number, unless the server acknowledged it before.
+func (g *GameClient) acknowledgeMoves(sequence uint32) (predictedMove, bool) {
+	var acknowledged predictedMove
+
+	if sequence <= g.ackedSequence {
+		return acknowledged, false
+	}
+
+	g.ackedSequence = sequence
+	found := false
+
+	for len(g.predictedMoves) > 0 && g.predictedMoves[0].sequence <= sequence {
+		acknowledged, found = g.predictedMoves[0], g.predictedMoves[0].sequence == sequence
+		g.predictedMoves = g.predictedMoves[1:]
+	}
+
+	return acknowledged, found
+}
+
+// reconcileMove compares a move of the local player that the server acknowledged with its prediction. When the
+// server ended the move elsewhere, the moves that the server has not acknowledged yet are replayed, or the player
+// walks to the destination of the server if there are none.
+func (g *GameClient) reconcileMove(player *d2mapentity.Player, move *d2netpacket.MovePlayerPacket) {
+	if move.Sequence <= g.ackedSequence {
+		return
+	}
+
+	predicted, found := g.acknowledgeMoves(move.Sequence)
+	dest := d2vector.NewPositionTile(move.DestX, move.DestY)
+
+	if found && predicted.end.Distance(&dest.Vector) <= reconcileTolerance {
+		return
+	}
+
+	if !g.replayMoves(player) {
+		g.walkPlayer(player, player.Position, dest)
+	}
+}
+
+// replayMoves predicts the moves of the local player that the server has not acknowledged again, from where the
+// player is now. Each move replaces the path of the moves before it, so replaying the latest move replays them all.
+// It returns false when there is no move to replay.
+func (g *GameClient) replayMoves(player *d2mapentity.Player) bool {
+	if len(g.predictedMoves) == 0 {
+		return false
+	}
+
+	latest := &g.predictedMoves[len(g.predictedMoves)-1]
+	latest.end = g.walkPlayer(player, player.Position, d2vector.NewPositionTile(latest.destX, latest.destY))
+
+	return true
+}
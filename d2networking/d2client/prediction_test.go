@@ -0,0 +1,157 @@
+package d2client
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const testMapSize = 8
+
+// testServerConnection records the moves the client sends
+type testServerConnection struct {
+	moves []d2netpacket.MovePlayerPacket
+}
+
+func (c *testServerConnection) Open(string, string) error { return nil }
+
+func (c *testServerConnection) Close() error { return nil }
+
+func (c *testServerConnection) SetClientListener(d2networking.ClientListener) {}
+
+func (c *testServerConnection) SendPacketToServer(packet d2netpacket.NetPacket) error {
+	move, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	c.moves = append(c.moves, move)
+
+	return nil
+}
+
+// testPredictionClient creates a client with an open map, the local player stands at the tile 2,2
+func testPredictionClient() (*GameClient, *d2mapentity.Player, *testServerConnection) {
+	records := &d2records.RecordManager{}
+	records.Level.Types = d2records.LevelTypes{{}}
+	records.Item.Weapons = make(d2records.CommonItems)
+	records.Item.Armors = d2records.CommonItems{"buc": {}}
+
+	for _, code := range []string{"hax", "wnd", "ssd", "ktr", "sst", "jav", "clb"} {
+		records.Item.Weapons[code] = &d2records.ItemCommonRecord{}
+	}
+
+	mapEngine := d2mapengine.CreateMapEngine(&d2asset.AssetManager{Records: records})
+	mapEngine.ResetMap(0, testMapSize, testMapSize)
+
+	player := &d2mapentity.Player{}
+	player.SetPosition(d2vector.NewPositionTile(2, 2))
+
+	connection := &testServerConnection{}
+	client := &GameClient{
+		clientConnection: connection,
+		MapEngine:        mapEngine,
+		PlayerID:         "player",
+		Players:          map[string]*d2mapentity.Player{"player": player},
+	}
+
+	return client, player, connection
+}
+
+func expectTarget(t *testing.T, player *d2mapentity.Player, x, y float64) {
+	t.Helper()
+
+	if target := d2vector.NewPositionTile(x, y); !player.Target.Equals(&target.Vector) {
+		t.Errorf("expected the player to walk to %v, it walks to %v", target, player.Target)
+	}
+}
+
+func TestAcknowledgeMoves(t *testing.T) {
+	client, _, connection := testPredictionClient()
+
+	for _, x := range []float64{3, 4, 5} {
+		if err := client.MovePlayer(x, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(connection.moves) != 3 || connection.moves[2].Sequence != 3 || connection.moves[2].DestX != 5 {
+		t.Fatalf("expected 3 moves with increasing sequence numbers, got %+v", connection.moves)
+	}
+
+	// a lost acknowledgement is covered by the next one
+	if move, found := client.acknowledgeMoves(2); !found || move.sequence != 2 || move.destX != 4 {
+		t.Errorf("expected the second move, got %+v", move)
+	}
+
+	if len(client.predictedMoves) != 1 || client.predictedMoves[0].sequence != 3 {
+		t.Errorf("expected the third move to be left, got %+v", client.predictedMoves)
+	}
+
+	if _, found := client.acknowledgeMoves(2); found {
+		t.Error("a move was acknowledged twice")
+	}
+
+	if _, found := client.acknowledgeMoves(7); found || len(client.predictedMoves) != 0 || client.ackedSequence != 7 {
+		t.Errorf("expected an unknown move to acknowledge all moves, %d are left", len(client.predictedMoves))
+	}
+}
+
+func TestReconcileMove(t *testing.T) {
+	client, player, _ := testPredictionClient()
+	sentinel := d2vector.NewPositionTile(-1, -1)
+
+	for _, dest := range [][2]float64{{6, 2}, {6, 6}} {
+		if err := client.MovePlayer(dest[0], dest[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectTarget(t, player, 6, 6)
+
+	// the server agrees with the prediction, nothing is replayed
+	client.predictedMoves[1].end = sentinel
+	client.reconcileMove(player, &d2netpacket.MovePlayerPacket{Sequence: 1, DestX: 6, DestY: 2})
+
+	if len(client.predictedMoves) != 1 || !client.predictedMoves[0].end.Equals(&sentinel.Vector) {
+		t.Fatalf("expected the second move to stay as it was predicted, got %+v", client.predictedMoves)
+	}
+
+	if err := client.MovePlayer(2, 6); err != nil {
+		t.Fatal(err)
+	}
+
+	// the server corrects the second move, the third move is replayed from where the player is now
+	player.SetPosition(d2vector.NewPositionTile(4, 4))
+	client.predictedMoves[1].end = sentinel
+	client.reconcileMove(player, &d2netpacket.MovePlayerPacket{Sequence: 2, DestX: 4, DestY: 4})
+
+	if len(client.predictedMoves) != 1 || client.predictedMoves[0].sequence != 3 {
+		t.Fatalf("expected the third move to be left, got %+v", client.predictedMoves)
+	}
+
+	if end := d2vector.NewPositionTile(2, 6); !client.predictedMoves[0].end.Equals(&end.Vector) {
+		t.Errorf("expected the replayed move to end at %v, got %v", end, client.predictedMoves[0].end)
+	}
+
+	expectTarget(t, player, 2, 6)
+
+	// a stale acknowledgement changes nothing
+	client.reconcileMove(player, &d2netpacket.MovePlayerPacket{Sequence: 2, DestX: 7, DestY: 7})
+	expectTarget(t, player, 2, 6)
+
+	// the server corrects the last move, the player walks to where the server stopped it
+	client.reconcileMove(player, &d2netpacket.MovePlayerPacket{Sequence: 3, DestX: 3, DestY: 5})
+
+	if len(client.predictedMoves) != 0 {
+		t.Errorf("expected no moves to be left, got %+v", client.predictedMoves)
+	}
+
+	expectTarget(t, player, 3, 5)
+}
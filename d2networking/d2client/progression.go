@@ -0,0 +1,50 @@
+package d2client
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// handlePlayerStatsPacket updates the stats and skill points of a player. The stats are updated in place,
+// as the panels of the HUD hold on to them.
+func (g *GameClient) handlePlayerStatsPacket(packet d2netpacket.NetPacket) error {
+	playerStats, err := d2netpacket.UnmarshalPlayerStats(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	player := g.Players[playerStats.ID]
+	if player == nil || player.Stats == nil || playerStats.Stats == nil {
+		return nil
+	}
+
+	// the stamina is not sent, it is drained and regenerated by the client
+	stamina := player.Stats.Stamina
+	*player.Stats = *playerStats.Stats
+	player.Stats.NextLevelExp = g.asset.Records.GetExperienceBreakpoint(player.Class, player.Stats.Level)
+	player.Stats.Stamina = stamina
+
+	if player.Stats.Stamina > float64(player.Stats.MaxStamina) {
+		player.Stats.Stamina = float64(player.Stats.MaxStamina)
+	}
+
+	for id, skill := range playerStats.Skills {
+		if known := player.Skills[id]; known != nil && skill != nil {
+			known.SetSkillPoints(skill.SkillPoints)
+		}
+	}
+
+	return nil
+}
+
+// SpendStatPoint asks the server to spend a stat point of the player on the attribute, the server answers
+// with the new stats
+func (g *GameClient) SpendStatPoint(attribute d2hero.Attribute) error {
+	return g.SendPacketToServer(d2netpacket.CreateSpendStatPointPacket(attribute))
+}
+
+// LearnSkill asks the server to spend a skill point of the player on the skill, the server answers with the
+// new skill points
+func (g *GameClient) LearnSkill(skillID int) error {
+	return g.SendPacketToServer(d2netpacket.CreateLearnSkillPacket(skillID))
+}
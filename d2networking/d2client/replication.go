@@ -0,0 +1,255 @@
+package d2client
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// interpolationDelay is the number of ticks the replicated entities are shown behind the latest snapshot of
+	// the server, so that there is a later snapshot to interpolate towards
+	interpolationDelay = 4
+	// maxSnapshotLag is the number of ticks the entities can fall behind before they skip ahead
+	maxSnapshotLag = 25
+)
+
+// glider is a replicated map entity that moves, its position is interpolated between the snapshots
+type glider interface {
+	d2interface.MapEntity
+	Glide(position, destination d2vector.Position)
+}
+
+// positionSample is the position of an entity at a tick of the server
+type positionSample struct {
+	tick     float64
+	position d2vector.Position
+}
+
+// replicatedEntity is a map entity that was created from a snapshot of the server
+type replicatedEntity struct {
+	entity  d2interface.MapEntity
+	samples []positionSample
+}
+
+// addSample adds the position of an update. The server only sends the positions of entities that moved, so an
+// entity that has not been updated since before the previous snapshot stood still until then.
+func (r *replicatedEntity) addSample(previousTick, tick float64, position d2vector.Position) {
+	last := r.samples[len(r.samples)-1]
+	if tick <= last.tick {
+		return
+	}
+
+	if last.tick < previousTick {
+		r.samples = append(r.samples, positionSample{tick: previousTick, position: last.position})
+	}
+
+	r.samples = append(r.samples, positionSample{tick: tick, position: position})
+}
+
+// interpolate returns the position of the entity at the given tick and the position it moves towards. The
+// samples before the given tick are dropped, but for the one the position is interpolated from.
+func (r *replicatedEntity) interpolate(tick float64) (position, destination d2vector.Position) {
+	for len(r.samples) > 1 && r.samples[1].tick <= tick {
+		r.samples = r.samples[1:]
+	}
+
+	from := r.samples[0]
+	if len(r.samples) == 1 || tick <= from.tick {
+		return from.position, from.position
+	}
+
+	to := r.samples[1]
+	delta := to.position.Clone()
+	delta.Subtract(&from.position.Vector)
+	delta.Scale((tick - from.tick) / (to.tick - from.tick))
+
+	position = d2vector.NewPosition(from.position.X()+delta.X(), from.position.Y()+delta.Y())
+
+	return position, to.position
+}
+
+// snapshotClock is the tick of the server the replicated entities are shown at, it runs behind the latest
+// snapshot by the interpolation delay
+type snapshotClock struct {
+	previous float64 // tick of the snapshot before the latest one
+	latest   float64
+	render   float64
+}
+
+// observe takes the tick of a snapshot of the server
+func (c *snapshotClock) observe(tick float64) {
+	if tick > c.latest {
+		c.previous, c.latest = c.latest, tick
+	}
+
+	if c.render < c.latest-maxSnapshotLag || c.render > c.latest {
+		c.render = c.latest - interpolationDelay
+	}
+}
+
+// advance runs the clock at the tick rate of the server, it does not run past the latest snapshot
+func (c *snapshotClock) advance(elapsed float64) {
+	c.render += elapsed * d2monai.TicksPerSecond
+
+	if c.render > c.latest {
+		c.render = c.latest
+	}
+}
+
+// Advance moves the replicated entities to their interpolated positions, it is called once per frame after the
+// map engine advanced
+func (g *GameClient) Advance(elapsed float64) {
+	g.snapshots.advance(elapsed)
+
+	for _, replicated := range g.replicated {
+		if entity, ok := replicated.entity.(glider); ok {
+			entity.Glide(replicated.interpolate(g.snapshots.render))
+		}
+	}
+}
+
+func (g *GameClient) handleCreateEntitiesPacket(packet d2netpacket.NetPacket) error {
+	createEntities, err := d2netpacket.UnmarshalCreateEntities(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	tick := float64(createEntities.Tick)
+	g.snapshots.observe(tick)
+
+	for i := range createEntities.Entities {
+		state := &createEntities.Entities[i]
+		if g.replicated[state.ID] != nil {
+			continue
+		}
+
+		entity, err := g.createReplicatedEntity(state)
+		if err != nil {
+			log.Printf("GameClient: error creating entity %s: %s", state.ID, err)
+			continue
+		}
+
+		g.replicated[state.ID] = &replicatedEntity{
+			entity:  entity,
+			samples: []positionSample{{tick: tick, position: d2vector.NewPosition(state.X, state.Y)}},
+		}
+
+		g.MapEngine.AddEntity(entity)
+	}
+
+	return nil
+}
+
+func (g *GameClient) createReplicatedEntity(state *d2netpacket.EntityState) (d2interface.MapEntity, error) {
+	x, y := int(state.X), int(state.Y)
+
+	switch state.Kind {
+	case d2netpacket.EntityNPC:
+		monstat := g.asset.Records.Monster.Stats[state.Record]
+		if monstat == nil {
+			return nil, fmt.Errorf("no monstat entry for %q", state.Record)
+		}
+
+		npc, err := g.MapEngine.NewNPC(x, y, monstat, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		npc.SetID(state.ID)
+
+		if state.Dead {
+			npc.Kill()
+		}
+
+		return npc, nil
+	case d2netpacket.EntityObject:
+		record := g.asset.Records.Object.Details[state.Index]
+		if record == nil {
+			return nil, fmt.Errorf("no object entry for %d", state.Index)
+		}
+
+		object, err := g.MapEngine.NewObject(x, y, record, d2resource.PaletteUnits)
+		if err != nil {
+			return nil, err
+		}
+
+		object.SetID(state.ID)
+
+		return object, nil
+	case d2netpacket.EntityItem:
+		item, err := g.newReplicatedItem(x/numSubtilesPerTile, y/numSubtilesPerTile, state)
+		if err != nil {
+			return nil, err
+		}
+
+		item.SetID(state.ID)
+
+		return item, nil
+	case d2netpacket.EntityMissile:
+		record := g.asset.Records.Missiles[state.Index]
+		if record == nil {
+			return nil, fmt.Errorf("no missile entry for %d", state.Index)
+		}
+
+		missile, err := g.MapEngine.NewMissile(x, y, record)
+		if err != nil {
+			return nil, err
+		}
+
+		missile.SetID(state.ID)
+
+		return missile, nil
+	}
+
+	return nil, fmt.Errorf("unknown entity kind %d", state.Kind)
+}
+
+// newReplicatedItem creates the item the server rolled, servers that do not send the item only send its code
+func (g *GameClient) newReplicatedItem(tileX, tileY int, state *d2netpacket.EntityState) (*d2mapentity.Item, error) {
+	if len(state.Item) == 0 {
+		return g.MapEngine.NewItem(tileX, tileY, state.Record)
+	}
+
+	return g.MapEngine.NewSerializedItem(tileX, tileY, state.Item)
+}
+
+func (g *GameClient) handleUpdateEntitiesPacket(packet d2netpacket.NetPacket) error {
+	updateEntities, err := d2netpacket.UnmarshalUpdateEntities(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	tick := float64(updateEntities.Tick)
+	g.snapshots.observe(tick)
+
+	for _, update := range updateEntities.Entities {
+		if replicated := g.replicated[update.ID]; replicated != nil {
+			replicated.addSample(g.snapshots.previous, tick, d2vector.NewPosition(update.X, update.Y))
+		}
+	}
+
+	return nil
+}
+
+func (g *GameClient) handleDestroyEntitiesPacket(packet d2netpacket.NetPacket) error {
+	destroyEntities, err := d2netpacket.UnmarshalDestroyEntities(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range destroyEntities.IDs {
+		if replicated := g.replicated[id]; replicated != nil {
+			g.MapEngine.RemoveEntity(replicated.entity)
+			delete(g.replicated, id)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package d2client
+
+import (
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+)
+
+func TestReplicatedEntityInterpolate(t *testing.T) {
+	entity := &replicatedEntity{
+		samples: []positionSample{{tick: 10, position: d2vector.NewPosition(0, 0)}},
+	}
+
+	// the entity stood still until the snapshot at tick 20, then it moved
+	entity.addSample(20, 22, d2vector.NewPosition(10, 0))
+
+	tests := []struct {
+		tick     float64
+		x, destX float64
+	}{
+		{5, 0, 0},
+		{15, 0, 0},
+		{21, 5, 10},
+		{22, 10, 10},
+		{30, 10, 10},
+	}
+
+	for _, test := range tests {
+		position, destination := entity.interpolate(test.tick)
+		if position.X() != test.x || destination.X() != test.destX {
+			t.Errorf("at tick %v expected %v towards %v, got %v towards %v", test.tick, test.x, test.destX,
+				position.X(), destination.X())
+		}
+	}
+
+	if len(entity.samples) != 1 {
+		t.Errorf("expected the passed samples to be dropped, %d are left", len(entity.samples))
+	}
+}
+
+func TestSnapshotClock(t *testing.T) {
+	clock := snapshotClock{}
+	clock.observe(100)
+
+	if clock.render != 100-interpolationDelay {
+		t.Fatalf("expected the clock to start behind the snapshot, at %v", clock.render)
+	}
+
+	clock.advance(1)
+
+	if clock.render != 100 {
+		t.Errorf("expected the clock to stop at the latest snapshot, at %v", clock.render)
+	}
+
+	clock.observe(102)
+
+	if clock.previous != 100 || clock.render != 100 {
+		t.Errorf("unexpected clock %+v", clock)
+	}
+}
@@ -0,0 +1,208 @@
+package d2netpacket
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+const (
+	maxBinaryStringLength = 1 << 16
+	maxBinarySliceLength  = 1 << 16
+)
+
+var (
+	errBinaryShortBuffer = errors.New("binary packet data is too short")
+	errBinaryTooLong     = errors.New("binary packet data contains an oversized field")
+	errBinaryVarint      = errors.New("binary packet data contains an invalid varint")
+)
+
+// binaryPacket is implemented by the packet types which support the binary encoding.
+type binaryPacket interface {
+	marshalBinary(w *binaryWriter)
+	unmarshalBinary(r *binaryReader)
+}
+
+// binaryWriter appends little endian values and varints to a byte slice.
+type binaryWriter struct {
+	data    []byte
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func newBinaryWriter(capacity int) *binaryWriter {
+	return &binaryWriter{data: make([]byte, 0, capacity)}
+}
+
+func (w *binaryWriter) bytes() []byte {
+	return w.data
+}
+
+func (w *binaryWriter) writeByte(v byte) {
+	w.data = append(w.data, v)
+}
+
+func (w *binaryWriter) writeBool(v bool) {
+	if v {
+		w.writeByte(1)
+		return
+	}
+
+	w.writeByte(0)
+}
+
+// writeInt writes a zigzag encoded varint, small values of either sign take a single byte
+func (w *binaryWriter) writeInt(v int64) {
+	n := binary.PutVarint(w.scratch[:], v)
+	w.data = append(w.data, w.scratch[:n]...)
+}
+
+func (w *binaryWriter) writeUint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.data = append(w.data, w.scratch[:n]...)
+}
+
+func (w *binaryWriter) writeFloat(v float64) {
+	binary.LittleEndian.PutUint64(w.scratch[:8], math.Float64bits(v))
+	w.data = append(w.data, w.scratch[:8]...)
+}
+
+func (w *binaryWriter) writeString(v string) {
+	w.writeUint(uint64(len(v)))
+	w.data = append(w.data, v...)
+}
+
+func (w *binaryWriter) writeTime(v time.Time) {
+	w.writeInt(v.UnixNano())
+}
+
+// binaryReader reads the values written by binaryWriter. The first error encountered is kept,
+// all reads after an error return zero values.
+type binaryReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func newBinaryReader(data []byte) *binaryReader {
+	return &binaryReader{data: data}
+}
+
+func (r *binaryReader) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *binaryReader) readByte() byte {
+	if r.err != nil {
+		return 0
+	}
+
+	if r.pos >= len(r.data) {
+		r.fail(errBinaryShortBuffer)
+		return 0
+	}
+
+	v := r.data[r.pos]
+	r.pos++
+
+	return v
+}
+
+func (r *binaryReader) readBool() bool {
+	return r.readByte() != 0
+}
+
+func (r *binaryReader) readInt() int64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Varint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail(errBinaryVarint)
+		return 0
+	}
+
+	r.pos += n
+
+	return v
+}
+
+func (r *binaryReader) readUint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	v, n := binary.Uvarint(r.data[r.pos:])
+	if n <= 0 {
+		r.fail(errBinaryVarint)
+		return 0
+	}
+
+	r.pos += n
+
+	return v
+}
+
+func (r *binaryReader) readFloat() float64 {
+	const size = 8
+
+	if r.err != nil {
+		return 0
+	}
+
+	if r.pos+size > len(r.data) {
+		r.fail(errBinaryShortBuffer)
+		return 0
+	}
+
+	v := math.Float64frombits(binary.LittleEndian.Uint64(r.data[r.pos:]))
+	r.pos += size
+
+	return v
+}
+
+func (r *binaryReader) readString() string {
+	length := r.readLength(maxBinaryStringLength)
+
+	if r.err != nil {
+		return ""
+	}
+
+	if r.pos+length > len(r.data) {
+		r.fail(errBinaryShortBuffer)
+		return ""
+	}
+
+	v := string(r.data[r.pos : r.pos+length])
+	r.pos += length
+
+	return v
+}
+
+// readBytes reads a string as bytes, an empty string is nil
+func (r *binaryReader) readBytes() []byte {
+	if v := r.readString(); v != "" {
+		return []byte(v)
+	}
+
+	return nil
+}
+
+func (r *binaryReader) readTime() time.Time {
+	return time.Unix(0, r.readInt())
+}
+
+// readLength reads the length of a string or slice and checks it against the given maximum
+func (r *binaryReader) readLength(max int) int {
+	length := r.readUint()
+
+	if length > uint64(max) {
+		r.fail(errBinaryTooLong)
+		return 0
+	}
+
+	return int(length)
+}
@@ -0,0 +1,208 @@
+package d2netpacket
+
+import (
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+)
+
+// The hero state, equipment, stats and skills are shared by several packets, these are their binary layouts.
+
+func (w *binaryWriter) writeHeroState(s *d2hero.HeroState) {
+	w.writeBool(s != nil)
+
+	if s == nil {
+		return
+	}
+
+	w.writeString(s.HeroName)
+	w.writeInt(int64(s.HeroType))
+	w.writeInt(int64(s.HeroLevel))
+	w.writeInt(int64(s.Act))
+	w.writeEquipment(&s.Equipment)
+	w.writeHeroStats(s.Stats)
+	w.writeHeroSkills(s.Skills)
+	w.writeFloat(s.X)
+	w.writeFloat(s.Y)
+	w.writeInt(int64(s.LeftSkill))
+	w.writeInt(int64(s.RightSkill))
+}
+
+func (r *binaryReader) readHeroState() *d2hero.HeroState {
+	if !r.readBool() {
+		return nil
+	}
+
+	s := &d2hero.HeroState{}
+	s.HeroName = r.readString()
+	s.HeroType = d2enum.Hero(r.readInt())
+	s.HeroLevel = int(r.readInt())
+	s.Act = int(r.readInt())
+	r.readEquipment(&s.Equipment)
+	s.Stats = r.readHeroStats()
+	s.Skills = r.readHeroSkills()
+	s.X = r.readFloat()
+	s.Y = r.readFloat()
+	s.LeftSkill = int(r.readInt())
+	s.RightSkill = int(r.readInt())
+
+	return s
+}
+
+func (w *binaryWriter) writeEquipment(e *d2inventory.CharacterEquipment) {
+	for _, armor := range []*d2inventory.InventoryItemArmor{e.Head, e.Torso, e.Legs, e.RightArm, e.LeftArm, e.Shield} {
+		w.writeArmor(armor)
+	}
+
+	w.writeWeapon(e.LeftHand)
+	w.writeWeapon(e.RightHand)
+}
+
+func (r *binaryReader) readEquipment(e *d2inventory.CharacterEquipment) {
+	for _, armor := range []**d2inventory.InventoryItemArmor{&e.Head, &e.Torso, &e.Legs, &e.RightArm, &e.LeftArm, &e.Shield} {
+		*armor = r.readArmor()
+	}
+
+	e.LeftHand = r.readWeapon()
+	e.RightHand = r.readWeapon()
+}
+
+func (w *binaryWriter) writeArmor(item *d2inventory.InventoryItemArmor) {
+	w.writeBool(item != nil)
+
+	if item == nil {
+		return
+	}
+
+	w.writeInt(int64(item.InventorySizeX))
+	w.writeInt(int64(item.InventorySizeY))
+	w.writeInt(int64(item.InventorySlotX))
+	w.writeInt(int64(item.InventorySlotY))
+	w.writeString(item.ItemName)
+	w.writeString(item.ItemCode)
+	w.writeString(item.ArmorClass)
+}
+
+func (r *binaryReader) readArmor() *d2inventory.InventoryItemArmor {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2inventory.InventoryItemArmor{
+		InventorySizeX: int(r.readInt()),
+		InventorySizeY: int(r.readInt()),
+		InventorySlotX: int(r.readInt()),
+		InventorySlotY: int(r.readInt()),
+		ItemName:       r.readString(),
+		ItemCode:       r.readString(),
+		ArmorClass:     r.readString(),
+	}
+}
+
+func (w *binaryWriter) writeWeapon(item *d2inventory.InventoryItemWeapon) {
+	w.writeBool(item != nil)
+
+	if item == nil {
+		return
+	}
+
+	w.writeInt(int64(item.InventorySizeX))
+	w.writeInt(int64(item.InventorySizeY))
+	w.writeInt(int64(item.InventorySlotX))
+	w.writeInt(int64(item.InventorySlotY))
+	w.writeString(item.ItemName)
+	w.writeString(item.ItemCode)
+	w.writeString(item.WeaponClass)
+	w.writeString(item.WeaponClassOffHand)
+}
+
+func (r *binaryReader) readWeapon() *d2inventory.InventoryItemWeapon {
+	if !r.readBool() {
+		return nil
+	}
+
+	return &d2inventory.InventoryItemWeapon{
+		InventorySizeX:     int(r.readInt()),
+		InventorySizeY:     int(r.readInt()),
+		InventorySlotX:     int(r.readInt()),
+		InventorySlotY:     int(r.readInt()),
+		ItemName:           r.readString(),
+		ItemCode:           r.readString(),
+		WeaponClass:        r.readString(),
+		WeaponClassOffHand: r.readString(),
+	}
+}
+
+func (w *binaryWriter) writeHeroStats(s *d2hero.HeroStatsState) {
+	w.writeBool(s != nil)
+
+	if s == nil {
+		return
+	}
+
+	for _, v := range heroStatFields(s) {
+		w.writeInt(int64(*v))
+	}
+}
+
+func (r *binaryReader) readHeroStats() *d2hero.HeroStatsState {
+	if !r.readBool() {
+		return nil
+	}
+
+	s := &d2hero.HeroStatsState{}
+
+	for _, v := range heroStatFields(s) {
+		*v = int(r.readInt())
+	}
+
+	return s
+}
+
+// heroStatFields returns the serialized fields of the hero stats, in the order of the binary layout
+func heroStatFields(s *d2hero.HeroStatsState) []*int {
+	return []*int{
+		&s.Level, &s.Experience,
+		&s.Vitality, &s.Energy, &s.Strength, &s.Dexterity, &s.StatPoints, &s.SkillPoints,
+		&s.AttackRating, &s.DefenseRating,
+		&s.MaxStamina, &s.Health, &s.MaxHealth, &s.Mana, &s.MaxMana,
+		&s.FireResistance, &s.ColdResistance, &s.LightningResistance, &s.PoisonResistance,
+	}
+}
+
+// writeHeroSkills writes the skill IDs and points, the records are hydrated by the receiver
+func (w *binaryWriter) writeHeroSkills(skills map[int]*d2hero.HeroSkill) {
+	ids := make([]int, 0, len(skills))
+
+	for id := range skills {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	w.writeUint(uint64(len(ids)))
+
+	for _, id := range ids {
+		points := 0
+		if skills[id] != nil {
+			points = skills[id].SkillPoints
+		}
+
+		w.writeInt(int64(id))
+		w.writeInt(int64(points))
+	}
+}
+
+func (r *binaryReader) readHeroSkills() map[int]*d2hero.HeroSkill {
+	count := r.readLength(maxBinarySliceLength)
+	skills := make(map[int]*d2hero.HeroSkill, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		id := int(r.readInt())
+		skills[id] = d2hero.NewShallowHeroSkill(id, int(r.readInt()))
+	}
+
+	return skills
+}
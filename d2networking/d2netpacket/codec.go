@@ -0,0 +1,297 @@
+package d2netpacket
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// PacketEncoding is the encoding used for NetPackets on the wire.
+type PacketEncoding byte
+
+// Packet encodings
+const (
+	JSONEncoding   PacketEncoding = iota // Human readable, used as a fallback and for debugging
+	BinaryEncoding                       // Compact binary encoding, see BinaryProtocolVersion
+)
+
+func (e PacketEncoding) String() string {
+	switch e {
+	case JSONEncoding:
+		return "json"
+	case BinaryEncoding:
+		return "binary"
+	}
+
+	return fmt.Sprintf("unknown encoding %d", e)
+}
+
+// BinaryProtocolVersion is the version of the binary packet encoding. It is the first byte of binary packet
+// data, which also tells it apart from JSON packet data. It has to be incremented whenever the binary
+// layout of any packet changes.
+const BinaryProtocolVersion byte = 7
+
+const (
+	frameHeaderSize = 4       // uint32 frame length
+	maxFrameSize    = 1 << 20 // frames larger than this are treated as a broken stream
+)
+
+var (
+	errFrameTooLarge       = errors.New("network frame exceeds the maximum frame size")
+	errFrameEmpty          = errors.New("network frame is empty")
+	errBinaryNotSupported  = errors.New("packet type does not support the binary encoding")
+	errBinaryVersion       = errors.New("unsupported binary protocol version")
+	errBinaryTrailingBytes = errors.New("binary packet data has trailing bytes")
+)
+
+// marshalPacketData encodes the given packet struct. Packets implementing binaryPacket are encoded in the
+// binary encoding, all others are encoded to JSON.
+func marshalPacketData(packet interface{}) []byte {
+	if p, ok := packet.(binaryPacket); ok {
+		w := newBinaryWriter(64) //nolint:gomnd // most packets fit
+		w.writeByte(BinaryProtocolVersion)
+		p.marshalBinary(w)
+
+		return w.bytes()
+	}
+
+	b, err := json.Marshal(packet)
+	if err != nil {
+		log.Print(err)
+	}
+
+	return b
+}
+
+// unmarshalPacketData decodes packet data in either encoding into the given packet struct.
+func unmarshalPacketData(data []byte, packet interface{}) error {
+	if !isBinaryPacketData(data) {
+		return json.Unmarshal(data, packet)
+	}
+
+	p, ok := packet.(binaryPacket)
+	if !ok {
+		return errBinaryNotSupported
+	}
+
+	if data[0] != BinaryProtocolVersion {
+		return fmt.Errorf("%w: %d", errBinaryVersion, data[0])
+	}
+
+	r := newBinaryReader(data[1:])
+	p.unmarshalBinary(r)
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if r.pos != len(r.data) {
+		return errBinaryTrailingBytes
+	}
+
+	return nil
+}
+
+// isBinaryPacketData returns true if the data is in the binary encoding. JSON packet data always starts with
+// a printable character, binary packet data starts with the binary protocol version.
+func isBinaryPacketData(data []byte) bool {
+	const firstPrintable = 0x20
+
+	return len(data) > 0 && data[0] < firstPrintable && data[0] != '\t' && data[0] != '\n' && data[0] != '\r'
+}
+
+// newPacketBody returns an empty packet struct for the given packet type.
+// nolint:gocyclo // switch statement on packet type makes sense, no need to change
+func newPacketBody(packetType d2netpackettype.NetPacketType) (interface{}, error) {
+	switch packetType {
+	case d2netpackettype.UpdateServerInfo:
+		return &UpdateServerInfoPacket{}, nil
+	case d2netpackettype.GenerateMap:
+		return &GenerateMapPacket{}, nil
+	case d2netpackettype.AddPlayer:
+		return &AddPlayerPacket{}, nil
+	case d2netpackettype.MovePlayer:
+		return &MovePlayerPacket{}, nil
+	case d2netpackettype.PlayerConnectionRequest:
+		return &PlayerConnectionRequestPacket{}, nil
+	case d2netpackettype.PlayerDisconnectionNotification:
+		return &PlayerDisconnectRequestPacket{}, nil
+	case d2netpackettype.Ping:
+		return &PingPacket{}, nil
+	case d2netpackettype.Pong:
+		return &PongPacket{}, nil
+	case d2netpackettype.ServerClosed:
+		return &ServerClosedPacket{}, nil
+	case d2netpackettype.CastSkill:
+		return &CastPacket{}, nil
+	case d2netpackettype.SpawnItem:
+		return &SpawnItemPacket{}, nil
+	case d2netpackettype.SavePlayer:
+		return &SavePlayerPacket{}, nil
+	case d2netpackettype.ServerFull:
+		return &ServerFullPacket{}, nil
+	case d2netpackettype.SetPlayerPosition:
+		return &SetPlayerPositionPacket{}, nil
+	case d2netpackettype.AttackResult:
+		return &AttackResultPacket{}, nil
+	case d2netpackettype.ListGames:
+		return &ListGamesPacket{}, nil
+	case d2netpackettype.GameList:
+		return &GameListPacket{}, nil
+	case d2netpackettype.JoinGame:
+		return &JoinGamePacket{}, nil
+	case d2netpackettype.JoinGameResult:
+		return &JoinGameResultPacket{}, nil
+	case d2netpackettype.LeaveGame:
+		return &LeaveGamePacket{}, nil
+	case d2netpackettype.CreateEntities:
+		return &CreateEntitiesPacket{}, nil
+	case d2netpackettype.UpdateEntities:
+		return &UpdateEntitiesPacket{}, nil
+	case d2netpackettype.DestroyEntities:
+		return &DestroyEntitiesPacket{}, nil
+	case d2netpackettype.RemovePlayer:
+		return &RemovePlayerPacket{}, nil
+	case d2netpackettype.AuthenticationResult:
+		return &AuthenticationResultPacket{}, nil
+	case d2netpackettype.Chat:
+		return &ChatPacket{}, nil
+	case d2netpackettype.PlayerStats:
+		return &PlayerStatsPacket{}, nil
+	case d2netpackettype.SpendStatPoint:
+		return &SpendStatPointPacket{}, nil
+	case d2netpackettype.LearnSkill:
+		return &LearnSkillPacket{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown packet type %d", packetType)
+}
+
+// toJSONPacketData converts binary packet data to JSON, JSON packet data is returned as is.
+func toJSONPacketData(packetType d2netpackettype.NetPacketType, data []byte) ([]byte, error) {
+	if !isBinaryPacketData(data) {
+		return data, nil
+	}
+
+	body, err := newPacketBody(packetType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalPacketData(data, body); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(body)
+}
+
+// MarshalFrame encodes the packet into a frame body in the given encoding. The first byte of the frame body is
+// the encoding, followed by the JSON NetPacket or by the packet type and the binary packet data.
+func MarshalFrame(packet NetPacket, encoding PacketEncoding) ([]byte, error) {
+	return appendFrame(nil, packet, encoding)
+}
+
+func appendFrame(frame []byte, packet NetPacket, encoding PacketEncoding) ([]byte, error) {
+	switch encoding {
+	case BinaryEncoding:
+		if !isBinaryPacketData(packet.PacketData) {
+			// packets without binary support are sent as JSON
+			break
+		}
+
+		var scratch [binary.MaxVarintLen32]byte
+
+		n := binary.PutUvarint(scratch[:], uint64(packet.PacketType))
+		frame = append(frame, byte(BinaryEncoding))
+		frame = append(frame, scratch[:n]...)
+
+		return append(frame, packet.PacketData...), nil
+	case JSONEncoding:
+	default:
+		return nil, fmt.Errorf("cannot encode packet: %s", encoding)
+	}
+
+	data, err := toJSONPacketData(packet.PacketType, packet.PacketData)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(NetPacket{PacketType: packet.PacketType, PacketData: data})
+	if err != nil {
+		return nil, err
+	}
+
+	return append(append(frame, byte(JSONEncoding)), b...), nil
+}
+
+// UnmarshalFrame decodes a frame body created by MarshalFrame.
+func UnmarshalFrame(frame []byte) (NetPacket, error) {
+	if len(frame) == 0 {
+		return NetPacket{}, errFrameEmpty
+	}
+
+	switch PacketEncoding(frame[0]) {
+	case BinaryEncoding:
+		packetType, n := binary.Uvarint(frame[1:])
+		if n <= 0 {
+			return NetPacket{}, errBinaryVarint
+		}
+
+		return NetPacket{
+			PacketType: d2netpackettype.NetPacketType(packetType),
+			PacketData: frame[1+n:],
+		}, nil
+	case JSONEncoding:
+		return UnmarshalNetPacket(frame[1:])
+	}
+
+	return NetPacket{}, fmt.Errorf("cannot decode packet: %s", PacketEncoding(frame[0]))
+}
+
+// WriteFrame writes the packet to a stream as a length prefixed frame in the given encoding. The frame is
+// written with a single call to Write, so it is safe to call from multiple goroutines on a net.Conn.
+func WriteFrame(w io.Writer, packet NetPacket, encoding PacketEncoding) error {
+	capacity := frameHeaderSize + 1 + binary.MaxVarintLen32 + len(packet.PacketData)
+
+	frame, err := appendFrame(make([]byte, frameHeaderSize, capacity), packet, encoding)
+	if err != nil {
+		return err
+	}
+
+	if len(frame)-frameHeaderSize > maxFrameSize {
+		return errFrameTooLarge
+	}
+
+	binary.LittleEndian.PutUint32(frame, uint32(len(frame)-frameHeaderSize))
+
+	_, err = w.Write(frame)
+
+	return err
+}
+
+// ReadFrame reads a single length prefixed frame written by WriteFrame from a stream. The encoding of each
+// frame is detected from the frame itself, so the encoding can change at any point in the stream.
+func ReadFrame(r io.Reader) (NetPacket, error) {
+	var header [frameHeaderSize]byte
+
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return NetPacket{}, err
+	}
+
+	size := binary.LittleEndian.Uint32(header[:])
+	if size > maxFrameSize {
+		return NetPacket{}, errFrameTooLarge
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(r, frame); err != nil {
+		return NetPacket{}, err
+	}
+
+	return UnmarshalFrame(frame)
+}
@@ -0,0 +1,232 @@
+package d2netpacket
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+func testHeroState() *d2hero.HeroState {
+	return &d2hero.HeroState{
+		HeroName:  "Kashya",
+		HeroType:  d2enum.HeroAmazon,
+		HeroLevel: 12,
+		Act:       1,
+		Equipment: d2inventory.CharacterEquipment{
+			Torso:     &d2inventory.InventoryItemArmor{ItemCode: "qui", ArmorClass: "lit", InventorySizeX: 2},
+			RightHand: &d2inventory.InventoryItemWeapon{ItemCode: "jav", WeaponClass: "1hs", ItemName: "Javelin"},
+		},
+		Stats: &d2hero.HeroStatsState{Level: 12, Experience: -1, Health: 150, PoisonResistance: 40, StatPoints: 5, SkillPoints: 2},
+		Skills: map[int]*d2hero.HeroSkill{
+			6:  d2hero.NewShallowHeroSkill(6, 3),
+			10: d2hero.NewShallowHeroSkill(10, 1),
+		},
+		X:          12.5,
+		Y:          -3.25,
+		RightSkill: 6,
+	}
+}
+
+func TestCodec_PacketRoundTrip(t *testing.T) {
+	packets := []NetPacket{
+		CreateUpdateServerInfoPacket(-1234567890123, "player", d2enum.DifficultyNightmare, BinaryEncoding),
+		CreateGenerateMapPacket(d2enum.RegionAct2Desert, 41),
+		CreateMovePlayerPacket("player", 7, 1.5, -2.25, 100, 200.125, true),
+		CreateSetPlayerPositionPacket("player", 7, 3, 4),
+		CreateAttackResultPacket("player", "monster", true, 12, 30, "1hss", false),
+		CreateCastPacket("player", 42, 1.5, 2.5),
+		CreateSpawnItemPacket(5, 6, "hax", "amu", ""),
+		CreatePlayerDisconnectRequestPacket("player"),
+		CreatePingPacket(),
+		CreatePingPacket(PlayerLatency{ID: "player", Latency: 42 * time.Millisecond}),
+		CreatePongPacket("player", time.Unix(1600000000, 500)),
+		CreateRemovePlayerPacket("player"),
+		CreateServerClosedPacket(),
+		CreateAddPlayerPacket("player", "Kashya", 10, 20, d2enum.HeroAmazon, testHeroState().Stats,
+			testHeroState().Skills, testHeroState().Equipment, 0, 6),
+		{PacketType: d2netpackettype.SavePlayer, PacketData: marshalPacketData(&SavePlayerPacket{LeftSkill: 1})},
+		CreatePlayerConnectionRequestPacket("player", testHeroState(), Credentials{}, BinaryEncoding),
+		CreatePlayerConnectionRequestPacket("player", nil, Credentials{Account: "kashya", Password: "rogue"}, JSONEncoding),
+		CreateAuthenticationResultPacket(true, ""),
+		CreateAuthenticationResultPacket(false, "wrong password"),
+		CreateChatPacket(ChatAll, "", "", "hello"),
+		CreateChatPacket(ChatWhisper, "Kashya", "Akara", "/seed"),
+		CreatePlayerStatsPacket("player", testHeroState().Stats, testHeroState().Skills),
+		CreateSpendStatPointPacket(d2hero.AttributeVitality),
+		CreateLearnSkillPacket(36),
+		CreateServerFullPacket(),
+		CreateListGamesPacket(),
+		CreateGameListPacket([]GameInfo{
+			{Name: "baal runs", Difficulty: d2enum.DifficultyHell, Players: 3, MaxPlayers: 8, Password: true},
+			{Name: "cows", Players: 1, MaxPlayers: 4},
+		}),
+		CreateGameListPacket([]GameInfo{}),
+		CreateJoinGamePacket("cows", ""),
+		CreateNewGamePacket("baal runs", "secret", d2enum.DifficultyHell, 8, -42),
+		CreateJoinGameResultPacket("cows", false, "game is full"),
+		CreateJoinGameResultPacket("cows", true, ""),
+		CreateLeaveGamePacket(),
+		CreateCreateEntitiesPacket(120, []EntityState{
+			{ID: "npc", Kind: EntityNPC, Record: "fallen1", X: 120.4, Y: 64, Dead: true},
+			{ID: "waypoint", Kind: EntityObject, Index: 119, X: 30, Y: 45},
+			{ID: "axe", Kind: EntityItem, Record: "hax", X: 20, Y: 25, Item: []byte(`{"common":"hax","quality":4}`)},
+		}),
+		CreateUpdateEntitiesPacket(122, []EntityPosition{{ID: "npc", X: 121.5, Y: 63.25}}),
+		CreateDestroyEntitiesPacket([]string{"npc", "waypoint"}),
+	}
+
+	for _, packet := range packets {
+		if !isBinaryPacketData(packet.PacketData) {
+			t.Errorf("%s: expected binary packet data", packet.PacketType)
+			continue
+		}
+
+		want, err := newPacketBody(packet.PacketType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err = unmarshalPacketData(packet.PacketData, want); err != nil {
+			t.Fatalf("%s: %s", packet.PacketType, err)
+		}
+
+		for _, encoding := range []PacketEncoding{BinaryEncoding, JSONEncoding} {
+			var buf bytes.Buffer
+
+			if err = WriteFrame(&buf, packet, encoding); err != nil {
+				t.Fatalf("%s: write %s frame: %s", packet.PacketType, encoding, err)
+			}
+
+			decoded, err := ReadFrame(&buf)
+			if err != nil {
+				t.Fatalf("%s: read %s frame: %s", packet.PacketType, encoding, err)
+			}
+
+			if decoded.PacketType != packet.PacketType {
+				t.Errorf("%s: decoded packet type %s", packet.PacketType, decoded.PacketType)
+			}
+
+			got, _ := newPacketBody(packet.PacketType)
+			if err = unmarshalPacketData(decoded.PacketData, got); err != nil {
+				t.Fatalf("%s: unmarshal %s packet data: %s", packet.PacketType, encoding, err)
+			}
+
+			if !packetBodiesEqual(t, want, got) {
+				t.Errorf("%s: %s round trip mismatch\nwant %+v\ngot  %+v", packet.PacketType, encoding, want, got)
+			}
+		}
+	}
+}
+
+// packetBodiesEqual compares the JSON encoding of two packets, which ignores unexported and records fields
+func packetBodiesEqual(t *testing.T, a, b interface{}) bool {
+	t.Helper()
+
+	ja, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jb, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return bytes.Equal(ja, jb)
+}
+
+func TestCodec_HeroSkillPoints(t *testing.T) {
+	packet := CreatePlayerConnectionRequestPacket("player", testHeroState(), Credentials{}, BinaryEncoding)
+
+	request, err := UnmarshalPlayerConnectionRequest(packet.PacketData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := request.PlayerState.Skills[6].SkillPoints; got != 3 {
+		t.Errorf("expected 3 skill points, got %d", got)
+	}
+
+	if request.Encoding != BinaryEncoding || request.BinaryVersion != BinaryProtocolVersion {
+		t.Errorf("unexpected encoding %s version %d", request.Encoding, request.BinaryVersion)
+	}
+}
+
+func TestCodec_Time(t *testing.T) {
+	ts := time.Date(2020, 11, 2, 13, 4, 5, 6, time.UTC)
+	w := newBinaryWriter(0)
+	w.writeTime(ts)
+
+	r := newBinaryReader(w.bytes())
+	if got := r.readTime(); !got.Equal(ts) || r.err != nil {
+		t.Errorf("expected %s, got %s (%v)", ts, got, r.err)
+	}
+}
+
+func TestCodec_JSONPacketData(t *testing.T) {
+	data := []byte(`{"playerId":"player","startX":1,"startY":2,"destX":3,"destY":4,"running":true}`)
+
+	move, err := UnmarshalMovePlayer(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := MovePlayerPacket{PlayerID: "player", StartX: 1, StartY: 2, DestX: 3, DestY: 4, Running: true}
+	if !reflect.DeepEqual(move, want) {
+		t.Errorf("expected %+v, got %+v", want, move)
+	}
+}
+
+func TestCodec_Corrupt(t *testing.T) {
+	packet := CreateMovePlayerPacket("player", 1, 1, 2, 3, 4, false)
+
+	if _, err := UnmarshalMovePlayer(packet.PacketData[:len(packet.PacketData)-3]); err == nil {
+		t.Error("expected an error for truncated packet data")
+	}
+
+	if _, err := UnmarshalMovePlayer(append(packet.PacketData, 0)); err == nil {
+		t.Error("expected an error for trailing bytes")
+	}
+
+	future := append([]byte{BinaryProtocolVersion + 1}, packet.PacketData[1:]...)
+	if _, err := UnmarshalMovePlayer(future); err == nil {
+		t.Error("expected an error for an unknown protocol version")
+	}
+
+	var buf bytes.Buffer
+
+	buf.Write([]byte{0xff, 0xff, 0xff, 0xff})
+
+	if _, err := ReadFrame(&buf); err == nil {
+		t.Error("expected an error for an oversized frame")
+	}
+
+	if _, err := UnmarshalFrame([]byte{byte(BinaryEncoding), 0x80}); err == nil {
+		t.Error("expected an error for a broken packet type")
+	}
+
+	if _, err := UnmarshalFrame([]byte{0x7f}); err == nil {
+		t.Error("expected an error for an unknown encoding")
+	}
+}
+
+func TestCodec_Types(t *testing.T) {
+	for packetType := d2netpackettype.UpdateServerInfo; packetType <= d2netpackettype.AttackResult; packetType++ {
+		body, err := newPacketBody(packetType)
+		if err != nil {
+			t.Errorf("%s: %s", packetType, err)
+			continue
+		}
+
+		if _, ok := body.(binaryPacket); !ok {
+			t.Errorf("%s does not support the binary encoding", packetType)
+		}
+	}
+}
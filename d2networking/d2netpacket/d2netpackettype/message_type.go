@@ -29,9 +29,25 @@ const (
 	Pong                                                 // Responds to a Ping packet
 	ServerClosed                                         // Sent by the local host when it has closed the server
 	CastSkill                                            // Sent by client or server, indicates entity casting skill
-	SpawnItem                                            // Sent by server
+	SpawnItem                                            // Sent by the server, or by the debug console of an operator
 	SavePlayer                                           // Sent by the client, saves the player
 	ServerFull                                           // Sent by server when server has reached max connections
+	SetPlayerPosition                                    // Sent by the server, client snaps a player entity to a position
+	AttackResult                                         // Sent by the server, outcome of an attack
+	ListGames                                            // Sent by the remote client, requests a GameList packet
+	GameList                                             // Sent by the server, the games that can be joined
+	JoinGame                                             // Sent by the client, joins or creates a game
+	JoinGameResult                                       // Sent by the server, whether the client joined the game
+	LeaveGame                                            // Sent by the client, leaves the game for the lobby
+	CreateEntities                                       // Sent by the server, map entities came into view
+	UpdateEntities                                       // Sent by the server, positions of map entities in view
+	DestroyEntities                                      // Sent by the server, map entities were removed or left view
+	RemovePlayer                                         // Sent by the server, a player left the game
+	AuthenticationResult                                 // Sent by a closed server, whether the account was authenticated
+	Chat                                                 // Sent by client or server, a chat message or command
+	PlayerStats                                          // Sent by the server, the experience, stats and skills of a player
+	SpendStatPoint                                       // Sent by the client, spends a stat point on an attribute
+	LearnSkill                                           // Sent by the client, spends a skill point on a skill
 
 	UnknownPacketType = 666
 )
@@ -51,11 +67,33 @@ func (n NetPacketType) String() string {
 		SpawnItem:                       "SpawnItem",
 		SavePlayer:                      "SavePlayer",
 		ServerFull:                      "ServerFull",
+		SetPlayerPosition:               "SetPlayerPosition",
+		AttackResult:                    "AttackResult",
+		ListGames:                       "ListGames",
+		GameList:                        "GameList",
+		JoinGame:                        "JoinGame",
+		JoinGameResult:                  "JoinGameResult",
+		LeaveGame:                       "LeaveGame",
+		CreateEntities:                  "CreateEntities",
+		UpdateEntities:                  "UpdateEntities",
+		DestroyEntities:                 "DestroyEntities",
+		RemovePlayer:                    "RemovePlayer",
+		AuthenticationResult:            "AuthenticationResult",
+		Chat:                            "Chat",
+		PlayerStats:                     "PlayerStats",
+		SpendStatPoint:                  "SpendStatPoint",
+		LearnSkill:                      "LearnSkill",
 	}
 
 	return strings[n]
 }
 
+// Lossy returns true for the packets that can be lost on an unreliable transport, because the next packet
+// of the same type replaces them or the server corrects the client
+func (n NetPacketType) Lossy() bool {
+	return n == MovePlayer || n == UpdateEntities
+}
+
 // MarshalPacket marshals the packet to a byte slice
 func (n NetPacketType) MarshalPacket() []byte {
 	p, err := json.Marshal(n)
@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2inventory"
@@ -44,23 +41,44 @@ func CreateAddPlayerPacket(id, name string, x, y int, heroType d2enum.Hero,
 		RightSkill: rightSkill,
 	}
 
-	b, err := json.Marshal(addPlayerPacket)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.AddPlayer,
-		PacketData: b,
+		PacketData: marshalPacketData(&addPlayerPacket),
 	}
 }
 
 // UnmarshalAddPlayer unmarshals the packet data into an AddPlayerPacket struct
 func UnmarshalAddPlayer(packet []byte) (AddPlayerPacket, error) {
 	var p AddPlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *AddPlayerPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+	w.writeString(p.Name)
+	w.writeInt(int64(p.X))
+	w.writeInt(int64(p.Y))
+	w.writeInt(int64(p.HeroType))
+	w.writeEquipment(&p.Equipment)
+	w.writeHeroStats(p.Stats)
+	w.writeHeroSkills(p.Skills)
+	w.writeInt(int64(p.LeftSkill))
+	w.writeInt(int64(p.RightSkill))
+}
+
+func (p *AddPlayerPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+	p.Name = r.readString()
+	p.X = int(r.readInt())
+	p.Y = int(r.readInt())
+	p.HeroType = d2enum.Hero(r.readInt())
+	r.readEquipment(&p.Equipment)
+	p.Stats = r.readHeroStats()
+	p.Skills = r.readHeroSkills()
+	p.LeftSkill = int(r.readInt())
+	p.RightSkill = int(r.readInt())
+}
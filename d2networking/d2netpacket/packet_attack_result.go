@@ -0,0 +1,67 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// AttackResultPacket contains the outcome of an attack resolved by the
+// server. Clients show the hit effect and update the life of the defender.
+type AttackResultPacket struct {
+	AttackerID string `json:"attackerId"`
+	DefenderID string `json:"defenderId"`
+	Hit        bool   `json:"hit"`
+	Damage     int    `json:"damage"`
+	Life       int    `json:"life"`
+	HitClass   string `json:"hitClass"`
+	Killed     bool   `json:"killed"`
+}
+
+// CreateAttackResultPacket returns a NetPacket which declares an
+// AttackResultPacket with the given outcome.
+func CreateAttackResultPacket(attackerID, defenderID string, hit bool, damage, life int, hitClass string,
+	killed bool) NetPacket {
+	attackResultPacket := AttackResultPacket{
+		AttackerID: attackerID,
+		DefenderID: defenderID,
+		Hit:        hit,
+		Damage:     damage,
+		Life:       life,
+		HitClass:   hitClass,
+		Killed:     killed,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.AttackResult,
+		PacketData: marshalPacketData(&attackResultPacket),
+	}
+}
+
+// UnmarshalAttackResult unmarshals the given data to an AttackResultPacket struct
+func UnmarshalAttackResult(packet []byte) (AttackResultPacket, error) {
+	var p AttackResultPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *AttackResultPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.AttackerID)
+	w.writeString(p.DefenderID)
+	w.writeBool(p.Hit)
+	w.writeInt(int64(p.Damage))
+	w.writeInt(int64(p.Life))
+	w.writeString(p.HitClass)
+	w.writeBool(p.Killed)
+}
+
+func (p *AttackResultPacket) unmarshalBinary(r *binaryReader) {
+	p.AttackerID = r.readString()
+	p.DefenderID = r.readString()
+	p.Hit = r.readBool()
+	p.Damage = int(r.readInt())
+	p.Life = int(r.readInt())
+	p.HitClass = r.readString()
+	p.Killed = r.readBool()
+}
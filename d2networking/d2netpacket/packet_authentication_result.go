@@ -0,0 +1,51 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// AuthenticationResultPacket is sent by a closed server in response to a
+// PlayerConnectionRequestPacket. When the account was not authenticated,
+// Reason tells why and the server closes the connection.
+type AuthenticationResultPacket struct {
+	Authenticated bool   `json:"authenticated"`
+	Reason        string `json:"reason"`
+}
+
+// CreateAuthenticationResultPacket returns a NetPacket which declares an
+// AuthenticationResultPacket. The reason is ignored when the account was
+// authenticated.
+func CreateAuthenticationResultPacket(authenticated bool, reason string) NetPacket {
+	authenticationResult := AuthenticationResultPacket{
+		Authenticated: authenticated,
+	}
+
+	if !authenticated {
+		authenticationResult.Reason = reason
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.AuthenticationResult,
+		PacketData: marshalPacketData(&authenticationResult),
+	}
+}
+
+// UnmarshalAuthenticationResult unmarshals the given data to an AuthenticationResultPacket struct
+func UnmarshalAuthenticationResult(packet []byte) (AuthenticationResultPacket, error) {
+	var p AuthenticationResultPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *AuthenticationResultPacket) marshalBinary(w *binaryWriter) {
+	w.writeBool(p.Authenticated)
+	w.writeString(p.Reason)
+}
+
+func (p *AuthenticationResultPacket) unmarshalBinary(r *binaryReader) {
+	p.Authenticated = r.readBool()
+	p.Reason = r.readString()
+}
@@ -0,0 +1,78 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// ChatChannel is the channel of a chat message, which decides who receives it
+type ChatChannel byte
+
+// Chat channels
+const (
+	ChatAll     ChatChannel = iota // all players of the game
+	ChatParty                      // the players of the party of the sender
+	ChatWhisper                    // the player named by To
+	ChatSystem                     // messages of the server, such as the output of commands
+)
+
+func (c ChatChannel) String() string {
+	strings := map[ChatChannel]string{
+		ChatAll:     "all",
+		ChatParty:   "party",
+		ChatWhisper: "whisper",
+		ChatSystem:  "system",
+	}
+
+	return strings[c]
+}
+
+// ChatPacket is a chat message. Clients send it to the server, which sets the
+// name of the sender and sends it to the players of the channel. Messages that
+// start with a slash are commands, which the server answers on the system
+// channel.
+type ChatPacket struct {
+	Channel ChatChannel `json:"channel"`
+	From    string      `json:"from,omitempty"` // the name of the sender, set by the server
+	To      string      `json:"to,omitempty"`   // the name of the recipient of whispers
+	Message string      `json:"message"`
+}
+
+// CreateChatPacket returns a NetPacket which declares a ChatPacket. Clients
+// leave the sender empty.
+func CreateChatPacket(channel ChatChannel, from, to, message string) NetPacket {
+	chat := ChatPacket{
+		Channel: channel,
+		From:    from,
+		To:      to,
+		Message: message,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.Chat,
+		PacketData: marshalPacketData(&chat),
+	}
+}
+
+// UnmarshalChat unmarshals the given data to a ChatPacket struct
+func UnmarshalChat(packet []byte) (ChatPacket, error) {
+	var p ChatPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *ChatPacket) marshalBinary(w *binaryWriter) {
+	w.writeByte(byte(p.Channel))
+	w.writeString(p.From)
+	w.writeString(p.To)
+	w.writeString(p.Message)
+}
+
+func (p *ChatPacket) unmarshalBinary(r *binaryReader) {
+	p.Channel = ChatChannel(r.readByte())
+	p.From = r.readString()
+	p.To = r.readString()
+	p.Message = r.readString()
+}
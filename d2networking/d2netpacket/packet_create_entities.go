@@ -0,0 +1,95 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// EntityKind tells which kind of map entity is replicated
+type EntityKind byte
+
+// Kinds of replicated map entities, players are replicated with AddPlayerPackets instead
+const (
+	EntityNPC EntityKind = iota
+	EntityObject
+	EntityItem
+	EntityMissile
+)
+
+// EntityState is everything a client needs to create a map entity of the server
+type EntityState struct {
+	ID     string     `json:"id"`
+	Kind   EntityKind `json:"kind"`
+	Record string     `json:"record"` // the monstats.txt key of an NPC, the code of an item
+	Index  int        `json:"index"`  // the objects.txt index of an object, the missiles.txt ID of a missile
+	X      float64    `json:"x"`      // in sub tiles
+	Y      float64    `json:"y"`
+	Dead   bool       `json:"dead"` // an NPC that lies dead on the map
+	Item   []byte     `json:"item"` // the item with its quality, affixes and sockets, see Item.Serialize
+}
+
+// CreateEntitiesPacket is sent by the server when map entities come into
+// the view of the player.
+type CreateEntitiesPacket struct {
+	Tick     uint64        `json:"tick"`
+	Entities []EntityState `json:"entities"`
+}
+
+// CreateCreateEntitiesPacket returns a NetPacket which declares a
+// CreateEntitiesPacket with the given entities.
+func CreateCreateEntitiesPacket(tick uint64, entities []EntityState) NetPacket {
+	createEntities := CreateEntitiesPacket{
+		Tick:     tick,
+		Entities: entities,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.CreateEntities,
+		PacketData: marshalPacketData(&createEntities),
+	}
+}
+
+// UnmarshalCreateEntities unmarshals the given data to a CreateEntitiesPacket struct
+func UnmarshalCreateEntities(packet []byte) (CreateEntitiesPacket, error) {
+	var p CreateEntitiesPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *CreateEntitiesPacket) marshalBinary(w *binaryWriter) {
+	w.writeUint(p.Tick)
+	w.writeUint(uint64(len(p.Entities)))
+
+	for i := range p.Entities {
+		entity := &p.Entities[i]
+		w.writeString(entity.ID)
+		w.writeByte(byte(entity.Kind))
+		w.writeString(entity.Record)
+		w.writeInt(int64(entity.Index))
+		w.writeFloat(entity.X)
+		w.writeFloat(entity.Y)
+		w.writeBool(entity.Dead)
+		w.writeString(string(entity.Item))
+	}
+}
+
+func (p *CreateEntitiesPacket) unmarshalBinary(r *binaryReader) {
+	p.Tick = r.readUint()
+	count := r.readLength(maxBinarySliceLength)
+	p.Entities = make([]EntityState, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.Entities = append(p.Entities, EntityState{
+			ID:     r.readString(),
+			Kind:   EntityKind(r.readByte()),
+			Record: r.readString(),
+			Index:  int(r.readInt()),
+			X:      r.readFloat(),
+			Y:      r.readFloat(),
+			Dead:   r.readBool(),
+			Item:   r.readBytes(),
+		})
+	}
+}
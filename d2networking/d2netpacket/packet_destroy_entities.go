@@ -0,0 +1,51 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// DestroyEntitiesPacket is sent by the server when map entities are
+// removed or leave the view of the player.
+type DestroyEntitiesPacket struct {
+	IDs []string `json:"ids"`
+}
+
+// CreateDestroyEntitiesPacket returns a NetPacket which declares a
+// DestroyEntitiesPacket with the given entity IDs.
+func CreateDestroyEntitiesPacket(ids []string) NetPacket {
+	destroyEntities := DestroyEntitiesPacket{
+		IDs: ids,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.DestroyEntities,
+		PacketData: marshalPacketData(&destroyEntities),
+	}
+}
+
+// UnmarshalDestroyEntities unmarshals the given data to a DestroyEntitiesPacket struct
+func UnmarshalDestroyEntities(packet []byte) (DestroyEntitiesPacket, error) {
+	var p DestroyEntitiesPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *DestroyEntitiesPacket) marshalBinary(w *binaryWriter) {
+	w.writeUint(uint64(len(p.IDs)))
+
+	for _, id := range p.IDs {
+		w.writeString(id)
+	}
+}
+
+func (p *DestroyEntitiesPacket) unmarshalBinary(r *binaryReader) {
+	count := r.readLength(maxBinarySliceLength)
+	p.IDs = make([]string, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.IDs = append(p.IDs, r.readString())
+	}
+}
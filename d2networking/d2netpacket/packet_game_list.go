@@ -0,0 +1,72 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// GameInfo describes a game in the lobby of a server
+type GameInfo struct {
+	Name       string                `json:"name"`
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
+	Players    int                   `json:"players"`
+	MaxPlayers int                   `json:"maxPlayers"`
+	Password   bool                  `json:"password"` // the game can only be joined with a password
+}
+
+// GameListPacket contains the games of a server. It is sent by the server
+// in response to a ListGamesPacket.
+type GameListPacket struct {
+	Games []GameInfo `json:"games"`
+}
+
+// CreateGameListPacket returns a NetPacket which declares a GameListPacket
+// with the given games.
+func CreateGameListPacket(games []GameInfo) NetPacket {
+	gameList := GameListPacket{
+		Games: games,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.GameList,
+		PacketData: marshalPacketData(&gameList),
+	}
+}
+
+// UnmarshalGameList unmarshals the given data to a GameListPacket struct
+func UnmarshalGameList(packet []byte) (GameListPacket, error) {
+	var p GameListPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *GameListPacket) marshalBinary(w *binaryWriter) {
+	w.writeUint(uint64(len(p.Games)))
+
+	for i := range p.Games {
+		game := &p.Games[i]
+		w.writeString(game.Name)
+		w.writeInt(int64(game.Difficulty))
+		w.writeInt(int64(game.Players))
+		w.writeInt(int64(game.MaxPlayers))
+		w.writeBool(game.Password)
+	}
+}
+
+func (p *GameListPacket) unmarshalBinary(r *binaryReader) {
+	count := r.readLength(maxBinarySliceLength)
+	p.Games = make([]GameInfo, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.Games = append(p.Games, GameInfo{
+			Name:       r.readString(),
+			Difficulty: d2enum.DifficultyType(r.readInt()),
+			Players:    int(r.readInt()),
+			MaxPlayers: int(r.readInt()),
+			Password:   r.readBool(),
+		})
+	}
+}
@@ -1,44 +1,48 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// GenerateMapPacket contains an enumerable representing a region. It
-// is sent by the server to generate the map for the given region on
-// a client.
+// GenerateMapPacket contains the level to generate and an enumerable
+// representing its region. It is sent by the server to generate the map
+// for the given level on a client.
 type GenerateMapPacket struct {
 	RegionType d2enum.RegionIdType `json:"regionType"`
+	LevelID    int                 `json:"levelId"`
 }
 
 // CreateGenerateMapPacket returns a NetPacket which declares a
-// GenerateMapPacket with the given regionType.
-func CreateGenerateMapPacket(regionType d2enum.RegionIdType) NetPacket {
+// GenerateMapPacket with the given regionType and level ID.
+func CreateGenerateMapPacket(regionType d2enum.RegionIdType, levelID int) NetPacket {
 	generateMapPacket := GenerateMapPacket{
 		RegionType: regionType,
-	}
-
-	b, err := json.Marshal(generateMapPacket)
-	if err != nil {
-		log.Print(err)
+		LevelID:    levelID,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.GenerateMap,
-		PacketData: b,
+		PacketData: marshalPacketData(&generateMapPacket),
 	}
 }
 
 // UnmarshalGenerateMap unmarshals the given packet data into a GenerateMapPacket struct
 func UnmarshalGenerateMap(packet []byte) (GenerateMapPacket, error) {
 	var p GenerateMapPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *GenerateMapPacket) marshalBinary(w *binaryWriter) {
+	w.writeInt(int64(p.RegionType))
+	w.writeInt(int64(p.LevelID))
+}
+
+func (p *GenerateMapPacket) unmarshalBinary(r *binaryReader) {
+	p.RegionType = d2enum.RegionIdType(r.readInt())
+	p.LevelID = int(r.readInt())
+}
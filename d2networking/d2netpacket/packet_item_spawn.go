@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
@@ -23,23 +20,40 @@ func CreateSpawnItemPacket(x, y int, codes ...string) NetPacket {
 		Codes: codes,
 	}
 
-	b, err := json.Marshal(spawnItemPacket)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.SpawnItem,
-		PacketData: b,
+		PacketData: marshalPacketData(&spawnItemPacket),
 	}
 }
 
 // UnmarshalSpawnItem unmarshals the given data to a SpawnItemPacket struct
 func UnmarshalSpawnItem(packet []byte) (SpawnItemPacket, error) {
 	var p SpawnItemPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *SpawnItemPacket) marshalBinary(w *binaryWriter) {
+	w.writeInt(int64(p.X))
+	w.writeInt(int64(p.Y))
+	w.writeUint(uint64(len(p.Codes)))
+
+	for _, code := range p.Codes {
+		w.writeString(code)
+	}
+}
+
+func (p *SpawnItemPacket) unmarshalBinary(r *binaryReader) {
+	p.X = int(r.readInt())
+	p.Y = int(r.readInt())
+
+	count := r.readLength(maxBinarySliceLength)
+	p.Codes = make([]string, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.Codes = append(p.Codes, r.readString())
+	}
+}
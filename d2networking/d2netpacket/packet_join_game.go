@@ -0,0 +1,74 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// JoinGamePacket is sent by a client in the lobby to join a game, or to
+// create it when Create is set. The difficulty, the maximum number of
+// players and the seed are only used for new games, a seed of 0 picks a
+// random seed. The server answers with a JoinGameResultPacket.
+type JoinGamePacket struct {
+	Name       string                `json:"name"`
+	Password   string                `json:"password"`
+	Create     bool                  `json:"create"`
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
+	MaxPlayers int                   `json:"maxPlayers"`
+	Seed       int64                 `json:"seed"`
+}
+
+// CreateJoinGamePacket returns a NetPacket which declares a JoinGamePacket
+// that joins the game with the given name and password.
+func CreateJoinGamePacket(name, password string) NetPacket {
+	return createJoinGamePacket(&JoinGamePacket{Name: name, Password: password})
+}
+
+// CreateNewGamePacket returns a NetPacket which declares a JoinGamePacket
+// that creates a game with the given settings and joins it.
+func CreateNewGamePacket(name, password string, difficulty d2enum.DifficultyType, maxPlayers int,
+	seed int64) NetPacket {
+	return createJoinGamePacket(&JoinGamePacket{
+		Name:       name,
+		Password:   password,
+		Create:     true,
+		Difficulty: difficulty,
+		MaxPlayers: maxPlayers,
+		Seed:       seed,
+	})
+}
+
+func createJoinGamePacket(joinGame *JoinGamePacket) NetPacket {
+	return NetPacket{
+		PacketType: d2netpackettype.JoinGame,
+		PacketData: marshalPacketData(joinGame),
+	}
+}
+
+// UnmarshalJoinGame unmarshals the given data to a JoinGamePacket struct
+func UnmarshalJoinGame(packet []byte) (JoinGamePacket, error) {
+	var p JoinGamePacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *JoinGamePacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.Name)
+	w.writeString(p.Password)
+	w.writeBool(p.Create)
+	w.writeInt(int64(p.Difficulty))
+	w.writeInt(int64(p.MaxPlayers))
+	w.writeInt(p.Seed)
+}
+
+func (p *JoinGamePacket) unmarshalBinary(r *binaryReader) {
+	p.Name = r.readString()
+	p.Password = r.readString()
+	p.Create = r.readBool()
+	p.Difficulty = d2enum.DifficultyType(r.readInt())
+	p.MaxPlayers = int(r.readInt())
+	p.Seed = r.readInt()
+}
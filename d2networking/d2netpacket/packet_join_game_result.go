@@ -0,0 +1,56 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// JoinGameResultPacket is sent by the server in response to a
+// JoinGamePacket. When the client joined the game, the packets of the game
+// follow, starting with an UpdateServerInfoPacket. Otherwise Reason tells
+// why the game could not be joined and the client stays in the lobby.
+type JoinGameResultPacket struct {
+	Name   string `json:"name"`
+	Joined bool   `json:"joined"`
+	Reason string `json:"reason"`
+}
+
+// CreateJoinGameResultPacket returns a NetPacket which declares a
+// JoinGameResultPacket for the given game. The reason is ignored when the
+// game was joined.
+func CreateJoinGameResultPacket(name string, joined bool, reason string) NetPacket {
+	joinGameResult := JoinGameResultPacket{
+		Name:   name,
+		Joined: joined,
+	}
+
+	if !joined {
+		joinGameResult.Reason = reason
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.JoinGameResult,
+		PacketData: marshalPacketData(&joinGameResult),
+	}
+}
+
+// UnmarshalJoinGameResult unmarshals the given data to a JoinGameResultPacket struct
+func UnmarshalJoinGameResult(packet []byte) (JoinGameResultPacket, error) {
+	var p JoinGameResultPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *JoinGameResultPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.Name)
+	w.writeBool(p.Joined)
+	w.writeString(p.Reason)
+}
+
+func (p *JoinGameResultPacket) unmarshalBinary(r *binaryReader) {
+	p.Name = r.readString()
+	p.Joined = r.readBool()
+	p.Reason = r.readString()
+}
@@ -0,0 +1,43 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// LearnSkillPacket is sent by the client to spend one of the unspent
+// skill points of its player on a skill. The server answers with a
+// PlayerStatsPacket.
+type LearnSkillPacket struct {
+	SkillID int `json:"skillId"`
+}
+
+// CreateLearnSkillPacket returns a NetPacket which declares a
+// LearnSkillPacket for the given skill.
+func CreateLearnSkillPacket(skillID int) NetPacket {
+	learnSkill := LearnSkillPacket{
+		SkillID: skillID,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.LearnSkill,
+		PacketData: marshalPacketData(&learnSkill),
+	}
+}
+
+// UnmarshalLearnSkill unmarshals the given data to a LearnSkillPacket struct
+func UnmarshalLearnSkill(packet []byte) (LearnSkillPacket, error) {
+	var p LearnSkillPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *LearnSkillPacket) marshalBinary(w *binaryWriter) {
+	w.writeInt(int64(p.SkillID))
+}
+
+func (p *LearnSkillPacket) unmarshalBinary(r *binaryReader) {
+	p.SkillID = int(r.readInt())
+}
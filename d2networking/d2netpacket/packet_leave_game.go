@@ -0,0 +1,23 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// LeaveGamePacket is sent by a client to leave its game and return to the
+// lobby of the server. A game is closed when its last player leaves.
+type LeaveGamePacket struct{}
+
+// CreateLeaveGamePacket returns a NetPacket which declares a LeaveGamePacket.
+func CreateLeaveGamePacket() NetPacket {
+	leaveGame := LeaveGamePacket{}
+
+	return NetPacket{
+		PacketType: d2netpackettype.LeaveGame,
+		PacketData: marshalPacketData(&leaveGame),
+	}
+}
+
+func (p *LeaveGamePacket) marshalBinary(*binaryWriter) {}
+
+func (p *LeaveGamePacket) unmarshalBinary(*binaryReader) {}
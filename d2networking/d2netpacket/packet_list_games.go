@@ -0,0 +1,24 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// ListGamesPacket is sent by a remote client to request the games of the
+// server, which answers with a GameListPacket. It can be sent before the
+// PlayerConnectionRequestPacket, to browse the games without joining one.
+type ListGamesPacket struct{}
+
+// CreateListGamesPacket returns a NetPacket which declares a ListGamesPacket.
+func CreateListGamesPacket() NetPacket {
+	listGames := ListGamesPacket{}
+
+	return NetPacket{
+		PacketType: d2netpackettype.ListGames,
+		PacketData: marshalPacketData(&listGames),
+	}
+}
+
+func (p *ListGamesPacket) marshalBinary(*binaryWriter) {}
+
+func (p *ListGamesPacket) unmarshalBinary(*binaryReader) {}
@@ -1,51 +1,73 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
 // MovePlayerPacket contains a movement command for a specific player entity.
 // It is sent by the server to move a player entity on a client.
 // https://github.com/OpenDiablo2/OpenDiablo2/issues/825
+//
+// The client numbers its movement commands, the server sends the number back
+// with the move so that the client knows which of its predicted moves were
+// acknowledged.
 type MovePlayerPacket struct {
 	PlayerID string  `json:"playerId"`
+	Sequence uint32  `json:"sequence"`
 	StartX   float64 `json:"startX"`
 	StartY   float64 `json:"startY"`
 	DestX    float64 `json:"destX"`
 	DestY    float64 `json:"destY"`
+	Running  bool    `json:"running"`
 }
 
 // CreateMovePlayerPacket returns a NetPacket which declares a MovePlayerPacket
-// with the given ID and movement command.
-func CreateMovePlayerPacket(playerID string, startX, startY, destX, destY float64) NetPacket {
+// with the given ID, sequence number and movement command. The running flag
+// is used by the server to determine the maximum speed of the player.
+func CreateMovePlayerPacket(playerID string, sequence uint32, startX, startY, destX, destY float64,
+	running bool) NetPacket {
 	movePlayerPacket := MovePlayerPacket{
 		PlayerID: playerID,
+		Sequence: sequence,
 		StartX:   startX,
 		StartY:   startY,
 		DestX:    destX,
 		DestY:    destY,
-	}
-
-	b, err := json.Marshal(movePlayerPacket)
-	if err != nil {
-		log.Print(err)
+		Running:  running,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.MovePlayer,
-		PacketData: b,
+		PacketData: marshalPacketData(&movePlayerPacket),
 	}
 }
 
 // UnmarshalMovePlayer unmarshals the given data to a MovePlayerPacket struct
 func UnmarshalMovePlayer(packet []byte) (MovePlayerPacket, error) {
 	var p MovePlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *MovePlayerPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.PlayerID)
+	w.writeUint(uint64(p.Sequence))
+	w.writeFloat(p.StartX)
+	w.writeFloat(p.StartY)
+	w.writeFloat(p.DestX)
+	w.writeFloat(p.DestY)
+	w.writeBool(p.Running)
+}
+
+func (p *MovePlayerPacket) unmarshalBinary(r *binaryReader) {
+	p.PlayerID = r.readString()
+	p.Sequence = uint32(r.readUint())
+	p.StartX = r.readFloat()
+	p.StartY = r.readFloat()
+	p.DestX = r.readFloat()
+	p.DestY = r.readFloat()
+	p.Running = r.readBool()
+}
@@ -1,33 +1,66 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
 	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// PingPacket contains the time at which it was sent. It is sent by the
-// server and instructs the client to respond with a Pong packet.
+// PlayerLatency is the round trip time between the server and a player
+type PlayerLatency struct {
+	ID      string        `json:"id"`
+	Latency time.Duration `json:"latency"`
+}
+
+// PingPacket contains the time at which it was sent and the latencies of
+// the players in the game of the client. It is sent by the server and
+// instructs the client to respond with a Pong packet.
 type PingPacket struct {
-	TS time.Time `json:"ts"`
+	TS        time.Time       `json:"ts"`
+	Latencies []PlayerLatency `json:"latencies"`
 }
 
-// CreatePingPacket returns a NetPacket which declares a GenerateMapPacket
-// with the the current time.
-func CreatePingPacket() NetPacket {
+// CreatePingPacket returns a NetPacket which declares a PingPacket
+// with the the current time and the given latencies.
+func CreatePingPacket(latencies ...PlayerLatency) NetPacket {
 	ping := PingPacket{
-		TS: time.Now(),
-	}
-
-	b, err := json.Marshal(ping)
-	if err != nil {
-		log.Print(err)
+		TS:        time.Now(),
+		Latencies: latencies,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.Ping,
-		PacketData: b,
+		PacketData: marshalPacketData(&ping),
+	}
+}
+
+// UnmarshalPing unmarshals the given data to a PingPacket struct
+func UnmarshalPing(packet []byte) (PingPacket, error) {
+	var p PingPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *PingPacket) marshalBinary(w *binaryWriter) {
+	w.writeTime(p.TS)
+	w.writeUint(uint64(len(p.Latencies)))
+
+	for _, latency := range p.Latencies {
+		w.writeString(latency.ID)
+		w.writeInt(int64(latency.Latency))
+	}
+}
+
+func (p *PingPacket) unmarshalBinary(r *binaryReader) {
+	p.TS = r.readTime()
+
+	count := r.readLength(maxBinarySliceLength)
+	p.Latencies = make([]PlayerLatency, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.Latencies = append(p.Latencies, PlayerLatency{ID: r.readString(), Latency: time.Duration(r.readInt())})
 	}
 }
@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
@@ -29,23 +26,34 @@ func CreateCastPacket(entityID string, skillID int, targetX, targetY float64) Ne
 		TargetEntityID: "", // https://github.com/OpenDiablo2/OpenDiablo2/issues/826
 	}
 
-	b, err := json.Marshal(castPacket)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.CastSkill,
-		PacketData: b,
+		PacketData: marshalPacketData(&castPacket),
 	}
 }
 
 // UnmarshalCast unmarshals the given data to a CastPacket struct
 func UnmarshalCast(packet []byte) (CastPacket, error) {
 	var p CastPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *CastPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.SourceEntityID)
+	w.writeInt(int64(p.SkillID))
+	w.writeFloat(p.TargetX)
+	w.writeFloat(p.TargetY)
+	w.writeString(p.TargetEntityID)
+}
+
+func (p *CastPacket) unmarshalBinary(r *binaryReader) {
+	p.SourceEntityID = r.readString()
+	p.SkillID = int(r.readInt())
+	p.TargetX = r.readFloat()
+	p.TargetY = r.readFloat()
+	p.TargetEntityID = r.readString()
+}
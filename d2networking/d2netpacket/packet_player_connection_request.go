@@ -1,37 +1,48 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
+// Credentials authenticate an account on a closed server, with either the
+// password or a token of the account. They are sent in the clear.
+type Credentials struct {
+	Account  string `json:"account"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
 // PlayerConnectionRequestPacket contains a player ID and game state.
 // It is sent by a remote client to initiate a connection (join a game).
+// Encoding is the packet encoding preferred by the client, the server only
+// accepts the binary encoding if BinaryVersion matches its own version.
+//
+// A closed server ignores the game state except for the name and class of
+// the hero, it loads the character of the authenticated account instead.
 type PlayerConnectionRequestPacket struct {
-	ID          string            `json:"id"`
-	PlayerState *d2hero.HeroState `json:"gameState"`
+	ID            string            `json:"id"`
+	PlayerState   *d2hero.HeroState `json:"gameState"`
+	Credentials   Credentials       `json:"credentials"`
+	Encoding      PacketEncoding    `json:"encoding"`
+	BinaryVersion byte              `json:"binaryVersion"`
 }
 
 // CreatePlayerConnectionRequestPacket returns a NetPacket which defines a
-// PlayerConnectionRequestPacket with the given ID and game state.
-func CreatePlayerConnectionRequestPacket(id string, playerState *d2hero.HeroState) NetPacket {
+// PlayerConnectionRequestPacket with the given ID, game state, credentials and preferred encoding.
+func CreatePlayerConnectionRequestPacket(id string, playerState *d2hero.HeroState, credentials Credentials,
+	encoding PacketEncoding) NetPacket {
 	playerConnectionRequest := PlayerConnectionRequestPacket{
-		ID:          id,
-		PlayerState: playerState,
-	}
-
-	b, err := json.Marshal(playerConnectionRequest)
-	if err != nil {
-		log.Print(err)
+		ID:            id,
+		PlayerState:   playerState,
+		Credentials:   credentials,
+		Encoding:      encoding,
+		BinaryVersion: BinaryProtocolVersion,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.PlayerConnectionRequest,
-		PacketData: b,
+		PacketData: marshalPacketData(&playerConnectionRequest),
 	}
 }
 
@@ -40,9 +51,29 @@ func CreatePlayerConnectionRequestPacket(id string, playerState *d2hero.HeroStat
 func UnmarshalPlayerConnectionRequest(packet []byte) (PlayerConnectionRequestPacket, error) {
 	var resp PlayerConnectionRequestPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return PlayerConnectionRequestPacket{}, err
 	}
 
 	return resp, nil
 }
+
+func (p *PlayerConnectionRequestPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+	w.writeHeroState(p.PlayerState)
+	w.writeString(p.Credentials.Account)
+	w.writeString(p.Credentials.Password)
+	w.writeString(p.Credentials.Token)
+	w.writeByte(byte(p.Encoding))
+	w.writeByte(p.BinaryVersion)
+}
+
+func (p *PlayerConnectionRequestPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+	p.PlayerState = r.readHeroState()
+	p.Credentials.Account = r.readString()
+	p.Credentials.Password = r.readString()
+	p.Credentials.Token = r.readString()
+	p.Encoding = PacketEncoding(r.readByte())
+	p.BinaryVersion = r.readByte()
+}
@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
@@ -23,14 +20,9 @@ func CreatePlayerDisconnectRequestPacket(id string) NetPacket {
 		ID: id,
 	}
 
-	b, err := json.Marshal(playerDisconnectRequest)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.PlayerDisconnectionNotification,
-		PacketData: b,
+		PacketData: marshalPacketData(&playerDisconnectRequest),
 	}
 }
 
@@ -39,9 +31,19 @@ func CreatePlayerDisconnectRequestPacket(id string) NetPacket {
 func UnmarshalPlayerDisconnectionRequest(packet []byte) (PlayerDisconnectRequestPacket, error) {
 	var resp PlayerDisconnectRequestPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return resp, err
 	}
 
 	return resp, nil
 }
+
+func (p *PlayerDisconnectRequestPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+	w.writeHeroState(p.PlayerState)
+}
+
+func (p *PlayerDisconnectRequestPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+	p.PlayerState = r.readHeroState()
+}
@@ -0,0 +1,53 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// PlayerStatsPacket is sent by the server when the experience, level,
+// stats or skills of a player changed, for example after a kill or when
+// a stat or skill point was spent. The skills hold the points only, the
+// records are hydrated by the client.
+type PlayerStatsPacket struct {
+	ID     string                    `json:"id"`
+	Stats  *d2hero.HeroStatsState    `json:"stats"`
+	Skills map[int]*d2hero.HeroSkill `json:"skills"`
+}
+
+// CreatePlayerStatsPacket returns a NetPacket which declares a
+// PlayerStatsPacket with the stats and skills of the player.
+func CreatePlayerStatsPacket(id string, stats *d2hero.HeroStatsState, skills map[int]*d2hero.HeroSkill) NetPacket {
+	playerStats := PlayerStatsPacket{
+		ID:     id,
+		Stats:  stats,
+		Skills: skills,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.PlayerStats,
+		PacketData: marshalPacketData(&playerStats),
+	}
+}
+
+// UnmarshalPlayerStats unmarshals the given data to a PlayerStatsPacket struct
+func UnmarshalPlayerStats(packet []byte) (PlayerStatsPacket, error) {
+	var p PlayerStatsPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *PlayerStatsPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+	w.writeHeroStats(p.Stats)
+	w.writeHeroSkills(p.Skills)
+}
+
+func (p *PlayerStatsPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+	p.Stats = r.readHeroStats()
+	p.Skills = r.readHeroSkills()
+}
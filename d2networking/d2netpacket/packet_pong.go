@@ -1,36 +1,30 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
 	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// PongPacket contains the time at which it was sent and the ID of the
-// client. It is sent by the client in response to a Pong packet.
+// PongPacket contains the time of the ping it answers and the ID of the
+// client. It is sent by the client in response to a Ping packet, so the
+// server can measure the round trip time.
 type PongPacket struct {
 	ID string    `json:"id"`
 	TS time.Time `json:"ts"`
 }
 
 // CreatePongPacket returns a NetPacket which declares a PongPacket with
-// the current time and given ID.
-func CreatePongPacket(id string) NetPacket {
+// the given ID and the time of the ping.
+func CreatePongPacket(id string, ts time.Time) NetPacket {
 	pong := PongPacket{
 		ID: id,
-		TS: time.Now(),
-	}
-
-	b, err := json.Marshal(pong)
-	if err != nil {
-		log.Print(err)
+		TS: ts,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.Pong,
-		PacketData: b,
+		PacketData: marshalPacketData(&pong),
 	}
 }
 
@@ -38,9 +32,19 @@ func CreatePongPacket(id string) NetPacket {
 func UnmarshalPong(packet []byte) (PongPacket, error) {
 	var resp PongPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return resp, err
 	}
 
 	return resp, nil
 }
+
+func (p *PongPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+	w.writeTime(p.TS)
+}
+
+func (p *PongPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+	p.TS = r.readTime()
+}
@@ -0,0 +1,42 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// RemovePlayerPacket is sent by the server when a player has left the
+// game or was disconnected, so the clients remove the player entity.
+type RemovePlayerPacket struct {
+	ID string `json:"id"`
+}
+
+// CreateRemovePlayerPacket returns a NetPacket which declares a
+// RemovePlayerPacket with the given player ID.
+func CreateRemovePlayerPacket(id string) NetPacket {
+	removePlayer := RemovePlayerPacket{
+		ID: id,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.RemovePlayer,
+		PacketData: marshalPacketData(&removePlayer),
+	}
+}
+
+// UnmarshalRemovePlayer unmarshals the given data to a RemovePlayerPacket struct
+func UnmarshalRemovePlayer(packet []byte) (RemovePlayerPacket, error) {
+	var p RemovePlayerPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *RemovePlayerPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.ID)
+}
+
+func (p *RemovePlayerPacket) unmarshalBinary(r *binaryReader) {
+	p.ID = r.readString()
+}
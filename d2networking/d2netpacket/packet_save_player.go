@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
@@ -22,23 +19,28 @@ func CreateSavePlayerPacket(playerState *d2mapentity.Player) NetPacket {
 		RightSkill: playerState.RightSkill.ID,
 	}
 
-	b, err := json.Marshal(savePlayerData)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.SavePlayer,
-		PacketData: b,
+		PacketData: marshalPacketData(&savePlayerData),
 	}
 }
 
 // UnmarshalSavePlayer unmarshalls the given data to a SavePlayerPacket struct
 func UnmarshalSavePlayer(packet []byte) (SavePlayerPacket, error) {
 	var p SavePlayerPacket
-	if err := json.Unmarshal(packet, &p); err != nil {
+	if err := unmarshalPacketData(packet, &p); err != nil {
 		return p, err
 	}
 
 	return p, nil
 }
+
+func (p *SavePlayerPacket) marshalBinary(w *binaryWriter) {
+	w.writeInt(int64(p.LeftSkill))
+	w.writeInt(int64(p.RightSkill))
+}
+
+func (p *SavePlayerPacket) unmarshalBinary(r *binaryReader) {
+	p.LeftSkill = int(r.readInt())
+	p.RightSkill = int(r.readInt())
+}
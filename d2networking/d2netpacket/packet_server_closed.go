@@ -1,8 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
 	"time"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
@@ -21,14 +19,9 @@ func CreateServerClosedPacket() NetPacket {
 		TS: time.Now(),
 	}
 
-	b, err := json.Marshal(serverClosed)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.ServerClosed,
-		PacketData: b,
+		PacketData: marshalPacketData(&serverClosed),
 	}
 }
 
@@ -36,9 +29,17 @@ func CreateServerClosedPacket() NetPacket {
 func UnmarshalServerClosed(packet []byte) (ServerClosedPacket, error) {
 	var resp ServerClosedPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return resp, err
 	}
 
 	return resp, nil
 }
+
+func (p *ServerClosedPacket) marshalBinary(w *binaryWriter) {
+	w.writeTime(p.TS)
+}
+
+func (p *ServerClosedPacket) unmarshalBinary(r *binaryReader) {
+	p.TS = r.readTime()
+}
@@ -1,9 +1,6 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
@@ -15,14 +12,9 @@ type ServerFullPacket struct{}
 func CreateServerFullPacket() NetPacket {
 	serverClosed := ServerFullPacket{}
 
-	b, err := json.Marshal(serverClosed)
-	if err != nil {
-		log.Print(err)
-	}
-
 	return NetPacket{
 		PacketType: d2netpackettype.ServerFull,
-		PacketData: b,
+		PacketData: marshalPacketData(&serverClosed),
 	}
 }
 
@@ -30,9 +22,13 @@ func CreateServerFullPacket() NetPacket {
 func UnmarshalServerFull(packet []byte) (ServerFullPacket, error) {
 	var resp ServerFullPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return resp, err
 	}
 
 	return resp, nil
 }
+
+func (p *ServerFullPacket) marshalBinary(*binaryWriter) {}
+
+func (p *ServerFullPacket) unmarshalBinary(*binaryReader) {}
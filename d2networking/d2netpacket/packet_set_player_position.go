@@ -0,0 +1,58 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SetPlayerPositionPacket contains the authoritative position of a player
+// entity. It is sent by the server when it rejects a movement command, the
+// client places the player entity at the given position without walking.
+// The sequence number is the last movement command of the player that the
+// server has processed.
+type SetPlayerPositionPacket struct {
+	PlayerID string  `json:"playerId"`
+	Sequence uint32  `json:"sequence"`
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+}
+
+// CreateSetPlayerPositionPacket returns a NetPacket which declares a
+// SetPlayerPositionPacket with the given ID, sequence number and world
+// position.
+func CreateSetPlayerPositionPacket(playerID string, sequence uint32, x, y float64) NetPacket {
+	setPlayerPositionPacket := SetPlayerPositionPacket{
+		PlayerID: playerID,
+		Sequence: sequence,
+		X:        x,
+		Y:        y,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.SetPlayerPosition,
+		PacketData: marshalPacketData(&setPlayerPositionPacket),
+	}
+}
+
+// UnmarshalSetPlayerPosition unmarshals the given data to a SetPlayerPositionPacket struct
+func UnmarshalSetPlayerPosition(packet []byte) (SetPlayerPositionPacket, error) {
+	var p SetPlayerPositionPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *SetPlayerPositionPacket) marshalBinary(w *binaryWriter) {
+	w.writeString(p.PlayerID)
+	w.writeUint(uint64(p.Sequence))
+	w.writeFloat(p.X)
+	w.writeFloat(p.Y)
+}
+
+func (p *SetPlayerPositionPacket) unmarshalBinary(r *binaryReader) {
+	p.PlayerID = r.readString()
+	p.Sequence = uint32(r.readUint())
+	p.X = r.readFloat()
+	p.Y = r.readFloat()
+}
@@ -0,0 +1,44 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// SpendStatPointPacket is sent by the client to spend one of the unspent
+// stat points of its player on an attribute. The server answers with a
+// PlayerStatsPacket.
+type SpendStatPointPacket struct {
+	Attribute d2hero.Attribute `json:"attribute"`
+}
+
+// CreateSpendStatPointPacket returns a NetPacket which declares a
+// SpendStatPointPacket for the given attribute.
+func CreateSpendStatPointPacket(attribute d2hero.Attribute) NetPacket {
+	spendStatPoint := SpendStatPointPacket{
+		Attribute: attribute,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.SpendStatPoint,
+		PacketData: marshalPacketData(&spendStatPoint),
+	}
+}
+
+// UnmarshalSpendStatPoint unmarshals the given data to a SpendStatPointPacket struct
+func UnmarshalSpendStatPoint(packet []byte) (SpendStatPointPacket, error) {
+	var p SpendStatPointPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *SpendStatPointPacket) marshalBinary(w *binaryWriter) {
+	w.writeByte(byte(p.Attribute))
+}
+
+func (p *SpendStatPointPacket) unmarshalBinary(r *binaryReader) {
+	p.Attribute = d2hero.Attribute(r.readByte())
+}
@@ -0,0 +1,68 @@
+package d2netpacket
+
+import (
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// EntityPosition is the position of a replicated map entity at a tick of the server
+type EntityPosition struct {
+	ID string  `json:"id"`
+	X  float64 `json:"x"` // in sub tiles
+	Y  float64 `json:"y"`
+}
+
+// UpdateEntitiesPacket is sent by the server with the positions of the
+// entities in the view of the player that moved since the last update.
+type UpdateEntitiesPacket struct {
+	Tick     uint64           `json:"tick"`
+	Entities []EntityPosition `json:"entities"`
+}
+
+// CreateUpdateEntitiesPacket returns a NetPacket which declares an
+// UpdateEntitiesPacket with the given positions.
+func CreateUpdateEntitiesPacket(tick uint64, entities []EntityPosition) NetPacket {
+	updateEntities := UpdateEntitiesPacket{
+		Tick:     tick,
+		Entities: entities,
+	}
+
+	return NetPacket{
+		PacketType: d2netpackettype.UpdateEntities,
+		PacketData: marshalPacketData(&updateEntities),
+	}
+}
+
+// UnmarshalUpdateEntities unmarshals the given data to an UpdateEntitiesPacket struct
+func UnmarshalUpdateEntities(packet []byte) (UpdateEntitiesPacket, error) {
+	var p UpdateEntitiesPacket
+	if err := unmarshalPacketData(packet, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+func (p *UpdateEntitiesPacket) marshalBinary(w *binaryWriter) {
+	w.writeUint(p.Tick)
+	w.writeUint(uint64(len(p.Entities)))
+
+	for i := range p.Entities {
+		w.writeString(p.Entities[i].ID)
+		w.writeFloat(p.Entities[i].X)
+		w.writeFloat(p.Entities[i].Y)
+	}
+}
+
+func (p *UpdateEntitiesPacket) unmarshalBinary(r *binaryReader) {
+	p.Tick = r.readUint()
+	count := r.readLength(maxBinarySliceLength)
+	p.Entities = make([]EntityPosition, 0, count)
+
+	for i := 0; i < count && r.err == nil; i++ {
+		p.Entities = append(p.Entities, EntityPosition{
+			ID: r.readString(),
+			X:  r.readFloat(),
+			Y:  r.readFloat(),
+		})
+	}
+}
@@ -1,35 +1,36 @@
 package d2netpacket
 
 import (
-	"encoding/json"
-	"log"
-
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
 )
 
-// UpdateServerInfoPacket contains the ID for a player and the map seed.
+// UpdateServerInfoPacket contains the ID for a player, the map seed, the
+// difficulty of the game and the packet encoding used by the server for the
+// rest of the connection.
 // It is sent by the server to synchronize these values on the client.
 type UpdateServerInfoPacket struct {
-	Seed     int64  `json:"seed"`
-	PlayerID string `json:"playerId"`
+	Seed       int64                 `json:"seed"`
+	PlayerID   string                `json:"playerId"`
+	Difficulty d2enum.DifficultyType `json:"difficulty"`
+	Encoding   PacketEncoding        `json:"encoding"`
 }
 
 // CreateUpdateServerInfoPacket returns a NetPacket which declares an
-// UpdateServerInfoPacket with the given player ID and map seed.
-func CreateUpdateServerInfoPacket(seed int64, playerID string) NetPacket {
+// UpdateServerInfoPacket with the given player ID, map seed, difficulty and
+// encoding.
+func CreateUpdateServerInfoPacket(seed int64, playerID string, difficulty d2enum.DifficultyType,
+	encoding PacketEncoding) NetPacket {
 	updateServerInfo := UpdateServerInfoPacket{
-		Seed:     seed,
-		PlayerID: playerID,
-	}
-
-	b, err := json.Marshal(updateServerInfo)
-	if err != nil {
-		log.Print(err)
+		Seed:       seed,
+		PlayerID:   playerID,
+		Difficulty: difficulty,
+		Encoding:   encoding,
 	}
 
 	return NetPacket{
 		PacketType: d2netpackettype.UpdateServerInfo,
-		PacketData: b,
+		PacketData: marshalPacketData(&updateServerInfo),
 	}
 }
 
@@ -37,9 +38,23 @@ func CreateUpdateServerInfoPacket(seed int64, playerID string) NetPacket {
 func UnmarshalUpdateServerInfo(packet []byte) (UpdateServerInfoPacket, error) {
 	var resp UpdateServerInfoPacket
 
-	if err := json.Unmarshal(packet, &resp); err != nil {
+	if err := unmarshalPacketData(packet, &resp); err != nil {
 		return resp, err
 	}
 
 	return resp, nil
 }
+
+func (p *UpdateServerInfoPacket) marshalBinary(w *binaryWriter) {
+	w.writeInt(p.Seed)
+	w.writeString(p.PlayerID)
+	w.writeInt(int64(p.Difficulty))
+	w.writeByte(byte(p.Encoding))
+}
+
+func (p *UpdateServerInfoPacket) unmarshalBinary(r *binaryReader) {
+	p.Seed = r.readInt()
+	p.PlayerID = r.readString()
+	p.Difficulty = d2enum.DifficultyType(r.readInt())
+	p.Encoding = PacketEncoding(r.readByte())
+}
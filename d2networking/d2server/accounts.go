@@ -0,0 +1,158 @@
+package d2server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const maxAccountCharacters = 18 // the same limit as the accounts of battle.net
+
+var (
+	// ErrCharacterNotFound is returned by a CharacterStore when the account has no character of the name
+	ErrCharacterNotFound = errors.New("character not found")
+
+	errAuthentication    = errors.New("invalid credentials")
+	errAccountInUse      = errors.New("account is already connected")
+	errNoCharacter       = errors.New("no character was chosen")
+	errUnknownHero       = errors.New("unknown hero class")
+	errTooManyCharacters = errors.New("the account has too many characters")
+)
+
+// Authenticator checks the credentials of the accounts of a closed server
+type Authenticator interface {
+	Authenticate(credentials *d2netpacket.Credentials) error
+}
+
+// CharacterStore loads and saves the characters of the accounts of a closed server. Only the server writes
+// the characters, clients only choose the character they play by its name.
+type CharacterStore interface {
+	LoadCharacter(account, name string) (*d2hero.HeroState, error)
+	SaveCharacter(account string, state *d2hero.HeroState) error
+	CharacterCount(account string) (int, error)
+}
+
+// SetClosed makes the server a closed server, which has to be done before it is started. The remote clients
+// of a closed server authenticate with the credentials of an account, and play the characters of the
+// account that are stored by the server.
+func (g *GameServer) SetClosed(authenticator Authenticator, characters CharacterStore) {
+	g.authenticator = authenticator
+	g.characters = characters
+}
+
+// authenticate returns the account of the player and the state of the character, which is the state sent by
+// the client on open servers.
+func (g *GameServer) authenticate(request *d2netpacket.PlayerConnectionRequestPacket) (string, *d2hero.HeroState,
+	error) {
+	if g.authenticator == nil {
+		return "", request.PlayerState, nil
+	}
+
+	credentials := request.Credentials
+
+	if err := g.authenticator.Authenticate(&credentials); err != nil {
+		return "", nil, fmt.Errorf("%w: %s", errAuthentication, err)
+	}
+
+	if request.PlayerState == nil {
+		return "", nil, errNoCharacter
+	}
+
+	name := request.PlayerState.HeroName
+
+	state, err := g.characters.LoadCharacter(credentials.Account, name)
+	if errors.Is(err, ErrCharacterNotFound) {
+		state, err = g.createCharacter(credentials.Account, name, request.PlayerState)
+	}
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	return credentials.Account, state, nil
+}
+
+// createCharacter creates a new character with the name and class that the client has chosen, unless the
+// account has too many characters
+func (g *GameServer) createCharacter(account, name string, requested *d2hero.HeroState) (*d2hero.HeroState, error) {
+	count, err := g.characters.CharacterCount(account)
+	if err != nil {
+		return nil, err
+	}
+
+	if count >= maxAccountCharacters {
+		return nil, fmt.Errorf("%w: %d", errTooManyCharacters, maxAccountCharacters)
+	}
+
+	classStats := g.asset.Records.Character.Stats[requested.HeroType]
+	if classStats == nil {
+		return nil, fmt.Errorf("%w: %s", errUnknownHero, requested.HeroType)
+	}
+
+	stats := g.heroStateFactory.CreateHeroStatsState(requested.HeroType, classStats)
+
+	state, err := g.heroStateFactory.CreateHeroState(name, requested.HeroType, stats)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.characters.SaveCharacter(account, state); err != nil {
+		return nil, err
+	}
+
+	log.Printf("GameServer: created character %s of account %s", name, account)
+
+	return state, nil
+}
+
+// refusalReason returns the reason for refusing a connection that is sent to the client. Why the credentials
+// are invalid is only logged, it would tell whether the account exists.
+func refusalReason(err error) string {
+	if errors.Is(err, errAuthentication) {
+		return errAuthentication.Error()
+	}
+
+	return err.Error()
+}
+
+// accountConnected returns true when a client of the account is connected, the server must be locked
+func (g *GameServer) accountConnected(account string) bool {
+	if account == "" {
+		return false
+	}
+
+	for _, connected := range g.accounts {
+		if connected == account {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handleSavePlayer saves the player with the skills it has chosen
+func (g *GameServer) handleSavePlayer(client ClientConnection, packet *d2netpacket.SavePlayerPacket) {
+	playerState := client.GetPlayerState()
+	playerState.LeftSkill = packet.LeftSkill
+	playerState.RightSkill = packet.RightSkill
+
+	if err := g.savePlayer(client); err != nil {
+		log.Printf("GameServer: error saving Player: %s", err)
+	}
+}
+
+// savePlayer saves the state of the player, to the character store of a closed server
+func (g *GameServer) savePlayer(client ClientConnection) error {
+	g.RLock()
+	account, closed := g.accounts[client.GetUniqueID()]
+	g.RUnlock()
+
+	if !closed {
+		return g.heroStateFactory.Save(client.GetPlayerState())
+	}
+
+	return g.characters.SaveCharacter(account, client.GetPlayerState())
+}
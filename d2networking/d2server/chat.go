@@ -0,0 +1,212 @@
+package d2server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	maxChatMessageLength = 256 // longer messages are cut, in characters
+
+	// chatBurst is how many messages a player can send at once, after that one message per chatInterval
+	chatBurst    = 5
+	chatInterval = time.Second
+)
+
+var (
+	errInvalidChannel = errors.New("invalid chat channel")
+	errChatRateLimit  = errors.New("you are sending messages too fast")
+	errPlayerNotFound = errors.New("player not found")
+	errNoParty        = errors.New("you are not in a party")
+	errNoInvitation   = errors.New("you were not invited to the party")
+	errInviteYourself = errors.New("you can not invite yourself")
+	errChatNotInGame  = errors.New("you are not in a game")
+)
+
+// chatState is the party, the party invitations and the rate limit of the chat of a client
+type chatState struct {
+	party     string
+	invites   map[string]string // the parties the client was invited to, by the ID of the inviting client
+	allowance float64           // the messages that can be sent, up to chatBurst
+	last      time.Time
+}
+
+func newChatState(now time.Time) *chatState {
+	return &chatState{invites: make(map[string]string), allowance: chatBurst, last: now}
+}
+
+// allow returns true when the client can send a message now, the allowance grows by one message per
+// chatInterval
+func (s *chatState) allow(now time.Time) bool {
+	s.allowance += float64(now.Sub(s.last)) / float64(chatInterval)
+	s.last = now
+
+	if s.allowance > chatBurst {
+		s.allowance = chatBurst
+	}
+
+	if s.allowance < 1 {
+		return false
+	}
+
+	s.allowance--
+
+	return true
+}
+
+// handleChat sends the chat message of the client to the players of its channel, or runs the command of
+// the message. Errors of the player are sent back to it on the system channel.
+func (g *GameServer) handleChat(client ClientConnection, packet *d2netpacket.ChatPacket) error {
+	message := strings.TrimSpace(packet.Message)
+	if message == "" {
+		return nil
+	}
+
+	if runes := []rune(message); len(runes) > maxChatMessageLength {
+		message = string(runes[:maxChatMessageLength])
+	}
+
+	var err error
+
+	switch {
+	case !g.allowChat(client, time.Now()):
+		err = errChatRateLimit
+	case strings.HasPrefix(message, "/"):
+		err = g.runChatCommand(client, message)
+	default:
+		err = g.sendChat(client, packet.Channel, packet.To, message)
+	}
+
+	if err != nil {
+		return g.sendSystemMessage(client, err.Error())
+	}
+
+	return nil
+}
+
+// allowChat applies the rate limit of the chat
+func (g *GameServer) allowChat(client ClientConnection, now time.Time) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	return g.chatState(client.GetUniqueID(), now).allow(now)
+}
+
+// chatState returns the chat state of the client, the server must be locked
+func (g *GameServer) chatState(id string, now time.Time) *chatState {
+	state := g.chat[id]
+	if state == nil {
+		state = newChatState(now)
+		g.chat[id] = state
+	}
+
+	return state
+}
+
+// sendChat sends a message of the client to the players of the channel
+func (g *GameServer) sendChat(client ClientConnection, channel d2netpacket.ChatChannel, to, message string) error {
+	recipients, err := g.chatRecipients(client, channel, to)
+	if err != nil {
+		return err
+	}
+
+	if channel == d2netpacket.ChatWhisper {
+		to = playerName(recipients[0])
+	}
+
+	packet := d2netpacket.CreateChatPacket(channel, playerName(client), to, message)
+
+	// sent without the locks, the local client handles the packet right away
+	for _, recipient := range recipients {
+		if err := recipient.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending chat message to client %s: %s", recipient.GetUniqueID(), err)
+		}
+	}
+
+	return nil
+}
+
+// chatRecipients returns the clients that receive a message of the client on the channel. A whisper is
+// received by the named player first, and by the sender.
+func (g *GameServer) chatRecipients(client ClientConnection, channel d2netpacket.ChatChannel,
+	to string) ([]ClientConnection, error) {
+	switch channel {
+	case d2netpacket.ChatWhisper:
+		recipient := g.findPlayer(to)
+		if recipient == nil {
+			return nil, fmt.Errorf("%w: %s", errPlayerNotFound, to)
+		}
+
+		if recipient.GetUniqueID() == client.GetUniqueID() {
+			return []ClientConnection{recipient}, nil
+		}
+
+		return []ClientConnection{recipient, client}, nil
+	case d2netpacket.ChatAll, d2netpacket.ChatParty:
+	default:
+		return nil, fmt.Errorf("%w: %s", errInvalidChannel, channel)
+	}
+
+	game := g.clientGame(client)
+	if game == nil {
+		return nil, errChatNotInGame
+	}
+
+	players := game.clients()
+	if channel == d2netpacket.ChatAll {
+		return players, nil
+	}
+
+	g.RLock()
+	defer g.RUnlock()
+
+	state := g.chat[client.GetUniqueID()]
+	if state == nil || state.party == "" {
+		return nil, errNoParty
+	}
+
+	party := state.party
+
+	recipients := make([]ClientConnection, 0, len(players))
+
+	for _, player := range players {
+		if state := g.chat[player.GetUniqueID()]; state != nil && state.party == party {
+			recipients = append(recipients, player)
+		}
+	}
+
+	return recipients, nil
+}
+
+// sendSystemMessage sends a message of the server to the client
+func (g *GameServer) sendSystemMessage(client ClientConnection, message string) error {
+	return client.SendPacketToClient(d2netpacket.CreateChatPacket(d2netpacket.ChatSystem, "", "", message))
+}
+
+// findPlayer returns the client that plays the character of the name, or nil
+func (g *GameServer) findPlayer(name string) ClientConnection {
+	g.RLock()
+	defer g.RUnlock()
+
+	for _, client := range g.connections {
+		if strings.EqualFold(playerName(client), name) {
+			return client
+		}
+	}
+
+	return nil
+}
+
+// playerName returns the name of the character of the client
+func playerName(client ClientConnection) string {
+	if state := client.GetPlayerState(); state != nil {
+		return state.HeroName
+	}
+
+	return client.GetUniqueID()
+}
@@ -0,0 +1,391 @@
+package d2server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errCommandUsage   = errors.New("usage")
+	errCommandExists  = errors.New("chat command already exists")
+	errNotOperator    = errors.New("only operators can run this command")
+	errNotKickable    = errors.New("the player can not be kicked")
+	errNotFunction    = errors.New("not a function")
+)
+
+// chatCommand is a slash command of the chat, it returns the answer to the player that ran it. Each line
+// of the answer is sent as a message.
+type chatCommand struct {
+	usage    string // the arguments of the command
+	help     string
+	operator bool // only operators can run the command
+	run      func(client ClientConnection, args []string) (string, error)
+}
+
+// addChatCommands adds the commands that are built into the server
+func (g *GameServer) addChatCommands() {
+	whisper := &chatCommand{usage: "<player> <message>", help: "whispers to a player", run: g.chatWhisper}
+
+	g.commands = map[string]*chatCommand{
+		"help":    {help: "lists the commands", run: g.chatHelp},
+		"players": {help: "lists the players of the game and their latency", run: g.chatPlayers},
+		"seed":    {help: "shows the seed of the game", run: g.chatSeed},
+		"kick":    {usage: "<player>", help: "disconnects a player", operator: true, run: g.chatKick},
+		"invite":  {usage: "<player>", help: "invites a player to your party", run: g.chatInvite},
+		"accept":  {usage: "<player>", help: "joins the party of a player that invited you", run: g.chatAccept},
+		"party":   {help: "leaves the party", run: g.chatParty},
+		"p":       {usage: "<message>", help: "sends a message to the party", run: g.chatPartyMessage},
+		"w":       whisper,
+		"whisper": whisper,
+	}
+}
+
+// addChatCommand adds a command, the built in commands can not be replaced
+func (g *GameServer) addChatCommand(name string, command *chatCommand) error {
+	g.Lock()
+	defer g.Unlock()
+
+	if _, found := g.commands[name]; found {
+		return fmt.Errorf("%w: /%s", errCommandExists, name)
+	}
+
+	g.commands[name] = command
+
+	return nil
+}
+
+// SetOperators sets the accounts of a closed server that can run the commands of operators, such as /kick.
+// The player of the host of a game is always an operator.
+func (g *GameServer) SetOperators(accounts ...string) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.operators = make(map[string]bool, len(accounts))
+	for _, account := range accounts {
+		g.operators[account] = true
+	}
+}
+
+func (g *GameServer) isOperator(client ClientConnection) bool {
+	if client.GetConnectionType() == d2clientconnectiontype.Local {
+		return true
+	}
+
+	g.RLock()
+	defer g.RUnlock()
+
+	account, found := g.accounts[client.GetUniqueID()]
+
+	return found && g.operators[account]
+}
+
+// runChatCommand runs the command of the message, which starts with a slash, and sends the answer
+func (g *GameServer) runChatCommand(client ClientConnection, message string) error {
+	fields := strings.Fields(strings.TrimPrefix(message, "/"))
+	if len(fields) == 0 {
+		return fmt.Errorf("%w, type /help for the commands", errUnknownCommand)
+	}
+
+	name := strings.ToLower(fields[0])
+
+	g.RLock()
+	command := g.commands[name]
+	g.RUnlock()
+
+	switch {
+	case command == nil:
+		return fmt.Errorf("%w /%s, type /help for the commands", errUnknownCommand, name)
+	case command.operator && !g.isOperator(client):
+		return errNotOperator
+	}
+
+	answer, err := command.run(client, fields[1:])
+	if errors.Is(err, errCommandUsage) {
+		return fmt.Errorf("%w: /%s %s", errCommandUsage, name, command.usage)
+	} else if err != nil {
+		return err
+	}
+
+	for _, line := range strings.Split(answer, "\n") {
+		if line == "" {
+			continue
+		}
+
+		if err := g.sendSystemMessage(client, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *GameServer) chatHelp(ClientConnection, []string) (string, error) {
+	g.RLock()
+	defer g.RUnlock()
+
+	names := make([]string, 0, len(g.commands))
+	for name := range g.commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	lines := make([]string, len(names))
+
+	for i, name := range names {
+		command := g.commands[name]
+		lines[i] = strings.TrimSpace(fmt.Sprintf("/%s %s", name, command.usage)) + " - " + command.help
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func (g *GameServer) chatPlayers(client ClientConnection, _ []string) (string, error) {
+	game := g.clientGame(client)
+	if game == nil {
+		return "", errChatNotInGame
+	}
+
+	latencies := g.Latencies()
+	players := game.clients()
+	names := make([]string, len(players))
+
+	for i, player := range players {
+		names[i] = playerName(player)
+
+		if latency, found := latencies[player.GetUniqueID()]; found {
+			names[i] += fmt.Sprintf(" (%d ms)", latency.Milliseconds())
+		}
+	}
+
+	sort.Strings(names)
+
+	return fmt.Sprintf("%d players: %s", len(names), strings.Join(names, ", ")), nil
+}
+
+func (g *GameServer) chatSeed(client ClientConnection, _ []string) (string, error) {
+	game := g.clientGame(client)
+	if game == nil {
+		return "", errChatNotInGame
+	}
+
+	return fmt.Sprintf("seed of game %q: %d", game.name, game.seed), nil
+}
+
+func (g *GameServer) chatKick(client ClientConnection, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errCommandUsage
+	}
+
+	player := g.findPlayer(args[0])
+	if player == nil {
+		return "", fmt.Errorf("%w: %s", errPlayerNotFound, args[0])
+	}
+
+	g.RLock()
+	health := g.health[player.GetUniqueID()]
+	g.RUnlock()
+
+	// local clients can not be disconnected
+	if health == nil || health.disconnect == nil {
+		return "", fmt.Errorf("%w: %s", errNotKickable, playerName(player))
+	}
+
+	if err := g.sendSystemMessage(player, "you were kicked by "+playerName(client)); err != nil {
+		log.Printf("GameServer: error sending chat message to client %s: %s", player.GetUniqueID(), err)
+	}
+
+	log.Printf("GameServer: client %s was kicked by client %s", player.GetUniqueID(), client.GetUniqueID())
+
+	if err := health.disconnect(); err != nil {
+		return "", err
+	}
+
+	return "kicked " + playerName(player), nil
+}
+
+// chatInvite invites a player to the party of the client. A client that is not in a party starts one, which
+// is named after its player.
+func (g *GameServer) chatInvite(client ClientConnection, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errCommandUsage
+	}
+
+	player := g.findPlayer(args[0])
+
+	switch {
+	case player == nil:
+		return "", fmt.Errorf("%w: %s", errPlayerNotFound, args[0])
+	case player.GetUniqueID() == client.GetUniqueID():
+		return "", errInviteYourself
+	}
+
+	now := time.Now()
+
+	g.Lock()
+	state := g.chatState(client.GetUniqueID(), now)
+
+	started := state.party == ""
+	if started {
+		state.party = strings.ToLower(playerName(client))
+	}
+
+	party := state.party
+	g.chatState(player.GetUniqueID(), now).invites[client.GetUniqueID()] = party
+	game := g.clientGames[client.GetUniqueID()]
+	g.Unlock()
+
+	if started && game != nil {
+		game.setParty(client.GetUniqueID(), party)
+	}
+
+	invitation := fmt.Sprintf("%s invites you to the party %s, type /accept %s to join", playerName(client), party,
+		playerName(client))
+	if err := g.sendSystemMessage(player, invitation); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("you invited %s to the party %s", playerName(player), party), nil
+}
+
+// chatAccept joins the party of a player that invited the client, as long as the player is still in it
+func (g *GameServer) chatAccept(client ClientConnection, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", errCommandUsage
+	}
+
+	player := g.findPlayer(args[0])
+	if player == nil {
+		return "", fmt.Errorf("%w: %s", errPlayerNotFound, args[0])
+	}
+
+	g.Lock()
+	state := g.chatState(client.GetUniqueID(), time.Now())
+	party, invited := state.invites[player.GetUniqueID()]
+
+	if inviter := g.chat[player.GetUniqueID()]; !invited || inviter == nil || inviter.party != party {
+		g.Unlock()
+		return "", fmt.Errorf("%w of %s", errNoInvitation, playerName(player))
+	}
+
+	delete(state.invites, player.GetUniqueID())
+	state.party = party
+	game := g.clientGames[client.GetUniqueID()]
+	g.Unlock()
+
+	if game != nil {
+		game.setParty(client.GetUniqueID(), party)
+	}
+
+	return "you joined the party " + party, nil
+}
+
+// chatParty leaves the party of the client
+func (g *GameServer) chatParty(client ClientConnection, args []string) (string, error) {
+	if len(args) != 0 {
+		return "", errCommandUsage
+	}
+
+	g.Lock()
+	state := g.chatState(client.GetUniqueID(), time.Now())
+	left := state.party
+	state.party = ""
+	game := g.clientGames[client.GetUniqueID()]
+	g.Unlock()
+
+	if left == "" {
+		return "", errNoParty
+	}
+
+	if game != nil {
+		game.setParty(client.GetUniqueID(), "")
+	}
+
+	return "you left the party " + left, nil
+}
+
+func (g *GameServer) chatPartyMessage(client ClientConnection, args []string) (string, error) {
+	if len(args) == 0 {
+		return "", errCommandUsage
+	}
+
+	return "", g.sendChat(client, d2netpacket.ChatParty, "", strings.Join(args, " "))
+}
+
+func (g *GameServer) chatWhisper(client ClientConnection, args []string) (string, error) {
+	if len(args) < 2 { //nolint:gomnd // the player and the message
+		return "", errCommandUsage
+	}
+
+	return "", g.sendChat(client, d2netpacket.ChatWhisper, args[0], strings.Join(args[1:], " "))
+}
+
+// addScriptChatCommand is the addChatCommand function of the script engine, which adds a command:
+//
+// addChatCommand(name, help, function(player, args) { return answer }, operator)
+//
+// The function is called with the name of the player and the arguments of the command, it returns the
+// answer to the player. Only operators can run the command when operator is true.
+func (g *GameServer) addScriptChatCommand(call otto.FunctionCall) otto.Value {
+	name := strings.ToLower(call.Argument(0).String())
+	function := call.Argument(2) //nolint:gomnd // the third argument
+	operator, _ := call.Argument(3).ToBoolean()
+
+	if !function.IsFunction() {
+		log.Printf("GameServer: could not add the chat command /%s: %s", name, errNotFunction)
+		return otto.FalseValue()
+	}
+
+	command := &chatCommand{
+		help:     call.Argument(1).String(),
+		operator: operator,
+		run: func(client ClientConnection, args []string) (string, error) {
+			return g.callScriptFunction(function, playerName(client), args)
+		},
+	}
+
+	if err := g.addChatCommand(name, command); err != nil {
+		log.Printf("GameServer: could not add the chat command: %s", err)
+		return otto.FalseValue()
+	}
+
+	return otto.TrueValue()
+}
+
+// callScriptFunction calls a function of a script and returns its result as a string
+func (g *GameServer) callScriptFunction(function otto.Value, args ...interface{}) (string, error) {
+	g.scriptMutex.Lock()
+	defer g.scriptMutex.Unlock()
+
+	result, err := function.Call(otto.NullValue(), args...)
+	if err != nil {
+		return "", err
+	}
+
+	if result.IsUndefined() || result.IsNull() {
+		return "", nil
+	}
+
+	return result.String(), nil
+}
+
+// RunScript runs a script with the script engine of the server. Scripts can add chat commands with
+// addChatCommand, see addScriptChatCommand.
+func (g *GameServer) RunScript(path string) error {
+	g.scriptMutex.Lock()
+	defer g.scriptMutex.Unlock()
+
+	_, err := g.scriptEngine.RunScript(path)
+
+	return err
+}
@@ -0,0 +1,259 @@
+package d2server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// chatClient records the chat messages it receives
+type chatClient struct {
+	testClient
+	state    *d2hero.HeroState
+	messages []d2netpacket.ChatPacket
+}
+
+func newChatClient(id, name string) *chatClient {
+	return &chatClient{testClient: testClient{id}, state: &d2hero.HeroState{HeroName: name}}
+}
+
+func (c *chatClient) SendPacketToClient(packet d2netpacket.NetPacket) error {
+	chat, err := d2netpacket.UnmarshalChat(packet.PacketData)
+	if err != nil {
+		return err
+	}
+
+	c.messages = append(c.messages, chat)
+
+	return nil
+}
+
+func (c *chatClient) GetPlayerState() *d2hero.HeroState {
+	return c.state
+}
+
+// take returns the received messages and forgets them
+func (c *chatClient) take() []d2netpacket.ChatPacket {
+	messages := c.messages
+	c.messages = nil
+
+	return messages
+}
+
+func testChatServer(clients ...*chatClient) *GameServer {
+	game := &Game{name: "cows", seed: 42, connections: make(map[string]ClientConnection),
+		parties: make(map[string]string)}
+	server := &GameServer{
+		connections: make(map[string]ClientConnection),
+		clientGames: make(map[string]*Game),
+		health:      make(map[string]*connectionHealth),
+		accounts:    make(map[string]string),
+		chat:        make(map[string]*chatState),
+	}
+
+	server.addChatCommands()
+
+	for _, client := range clients {
+		server.connections[client.id] = client
+		server.clientGames[client.id] = game
+		game.connections[client.id] = client
+	}
+
+	return server
+}
+
+func chat(t *testing.T, server *GameServer, client ClientConnection, channel d2netpacket.ChatChannel, to,
+	message string) {
+	// the rate limit is tested by TestChatRateLimit
+	if state := server.chat[client.GetUniqueID()]; state != nil {
+		state.allowance = chatBurst
+	}
+
+	packet := d2netpacket.ChatPacket{Channel: channel, To: to, Message: message}
+	if err := server.handleChat(client, &packet); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expectSystemMessage(t *testing.T, client *chatClient, contains string) {
+	messages := client.take()
+	if len(messages) != 1 || messages[0].Channel != d2netpacket.ChatSystem ||
+		!strings.Contains(messages[0].Message, contains) {
+		t.Errorf("expected a system message with %q, got %+v", contains, messages)
+	}
+}
+
+func TestChatChannels(t *testing.T) {
+	kashya, akara, charsi := newChatClient("1", "Kashya"), newChatClient("2", "Akara"), newChatClient("3", "Charsi")
+	server := testChatServer(kashya, akara, charsi)
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "  hello  ")
+
+	for _, client := range []*chatClient{kashya, akara, charsi} {
+		messages := client.take()
+		if len(messages) != 1 || messages[0].From != "Kashya" || messages[0].Message != "hello" {
+			t.Errorf("%s received %+v", client.state.HeroName, messages)
+		}
+	}
+
+	chat(t, server, kashya, d2netpacket.ChatWhisper, "akara", "psst")
+
+	if messages := akara.take(); len(messages) != 1 || messages[0].To != "Akara" || messages[0].Message != "psst" {
+		t.Errorf("unexpected whisper %+v", messages)
+	}
+
+	if len(kashya.take()) != 1 || len(charsi.take()) != 0 {
+		t.Error("only the sender and the recipient should receive a whisper")
+	}
+
+	chat(t, server, kashya, d2netpacket.ChatWhisper, "Warriv", "psst")
+	expectSystemMessage(t, kashya, errPlayerNotFound.Error())
+
+	chat(t, server, kashya, d2netpacket.ChatParty, "", "anyone?")
+	expectSystemMessage(t, kashya, errNoParty.Error())
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/invite akara")
+	expectSystemMessage(t, kashya, "invited Akara to the party kashya")
+	expectSystemMessage(t, akara, "/accept Kashya")
+	chat(t, server, akara, d2netpacket.ChatAll, "", "/accept Kashya")
+	expectSystemMessage(t, akara, "joined the party kashya")
+
+	chat(t, server, akara, d2netpacket.ChatAll, "", "/p heal up")
+
+	if len(kashya.take()) != 1 || len(akara.take()) != 1 || len(charsi.take()) != 0 {
+		t.Error("only the party should receive a party message")
+	}
+
+	chat(t, server, kashya, d2netpacket.ChatSystem, "", "fake")
+	expectSystemMessage(t, kashya, errInvalidChannel.Error())
+}
+
+func TestChatCommands(t *testing.T) {
+	kashya, akara := newChatClient("1", "Kashya"), newChatClient("2", "Akara")
+	server := testChatServer(kashya, akara)
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/seed")
+	expectSystemMessage(t, kashya, "42")
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/players")
+	expectSystemMessage(t, kashya, "2 players: Akara, Kashya")
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/cow")
+	expectSystemMessage(t, kashya, errUnknownCommand.Error())
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/kick")
+	expectSystemMessage(t, kashya, errNotOperator.Error())
+
+	server.accounts[kashya.id] = "kashya"
+	server.SetOperators("kashya")
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/kick")
+	expectSystemMessage(t, kashya, "/kick <player>")
+
+	disconnected := false
+	server.health[akara.id] = newConnectionHealth(func() error {
+		disconnected = true
+		return nil
+	})
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/kick akara")
+	expectSystemMessage(t, kashya, "kicked Akara")
+	expectSystemMessage(t, akara, "kicked by Kashya")
+
+	if !disconnected {
+		t.Error("the kicked player was not disconnected")
+	}
+
+	if err := server.addChatCommand("kick", &chatCommand{}); err == nil {
+		t.Error("expected an error for replacing a command")
+	}
+}
+
+func TestChatPartyInvitations(t *testing.T) {
+	kashya, akara, charsi := newChatClient("1", "Kashya"), newChatClient("2", "Akara"), newChatClient("3", "Charsi")
+	server := testChatServer(kashya, akara, charsi)
+	game := server.clientGames[kashya.id]
+
+	chat(t, server, charsi, d2netpacket.ChatAll, "", "/accept Kashya")
+	expectSystemMessage(t, charsi, errNoInvitation.Error())
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/invite kashya")
+	expectSystemMessage(t, kashya, errInviteYourself.Error())
+
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/invite Akara")
+	kashya.take()
+	akara.take()
+
+	if game.parties[kashya.id] != "kashya" || game.parties[akara.id] != "" {
+		t.Errorf("expected only the inviting player in the party, got %v", game.parties)
+	}
+
+	// the invitation is for Akara only
+	chat(t, server, charsi, d2netpacket.ChatAll, "", "/accept Kashya")
+	expectSystemMessage(t, charsi, errNoInvitation.Error())
+
+	chat(t, server, akara, d2netpacket.ChatAll, "", "/accept kashya")
+	expectSystemMessage(t, akara, "joined the party kashya")
+
+	if game.parties[akara.id] != "kashya" {
+		t.Errorf("expected Akara in the party, got %v", game.parties)
+	}
+
+	// an invitation is accepted once
+	chat(t, server, akara, d2netpacket.ChatAll, "", "/party")
+	expectSystemMessage(t, akara, "left the party kashya")
+	chat(t, server, akara, d2netpacket.ChatAll, "", "/accept kashya")
+	expectSystemMessage(t, akara, errNoInvitation.Error())
+
+	// the invitation is void once the inviting player left the party
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/invite charsi")
+	kashya.take()
+	charsi.take()
+	chat(t, server, kashya, d2netpacket.ChatAll, "", "/party")
+	kashya.take()
+	chat(t, server, charsi, d2netpacket.ChatAll, "", "/accept kashya")
+	expectSystemMessage(t, charsi, errNoInvitation.Error())
+
+	if len(game.parties) != 0 {
+		t.Errorf("expected no parties, got %v", game.parties)
+	}
+}
+
+func TestChatRateLimit(t *testing.T) {
+	now := time.Now()
+	state := newChatState(now)
+
+	for i := 0; i < chatBurst; i++ {
+		if !state.allow(now) {
+			t.Fatalf("message %d of the burst was not allowed", i)
+		}
+	}
+
+	if state.allow(now) {
+		t.Error("expected the message after the burst to be limited")
+	}
+
+	if !state.allow(now.Add(chatInterval)) || state.allow(now.Add(chatInterval)) {
+		t.Error("expected one more message after the interval")
+	}
+}
+
+func TestChatLocalOperator(t *testing.T) {
+	server := testChatServer()
+
+	if !server.isOperator(localTestClient{testClient{"host"}}) || server.isOperator(testClient{"remote"}) {
+		t.Error("only the local client should be an operator")
+	}
+}
+
+type localTestClient struct {
+	testClient
+}
+
+func (localTestClient) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
+	return d2clientconnectiontype.Local
+}
@@ -0,0 +1,358 @@
+package d2server
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// targetRadius is the distance in sub tiles from the cast position within which a monster is hit
+	targetRadius = 3
+	// meleeReach is the distance in sub tiles from which a player hits a monster in melee
+	meleeReach = 4
+	// rangedReach is the distance in sub tiles from which ranged skills without a missile hit, about half of the
+	// screen
+	rangedReach = 25
+	// castCooldown is the time a player waits between two casts, the fastest attack animations take about as long
+	castCooldown = 250 * time.Millisecond
+
+	unarmedMinDamage = 1
+	unarmedMaxDamage = 2
+	unarmedHitClass  = "hth"
+
+	// the StrBonus and DexBonus of weapons are the damage percent of 100 points of strength and dexterity
+	statBonusPoints = 100
+)
+
+// addMonsterCombatants gives each monster of the level controlled by the AI the combat stats of its type
+func (g *Game) addMonsterCombatants(level *gameLevel) {
+	level.combatants = make(map[string]*d2combat.Combatant)
+
+	for id, entity := range level.mapEngine.Entities() {
+		monsterEntity, ok := entity.(d2monai.MonsterEntity)
+		if !ok || level.monsters.Monster(id) == nil {
+			continue
+		}
+
+		level.combatants[id] = d2combat.NewMonster(g.statFactory, id, monsterEntity.MonStats(), g.difficulty,
+			level.monsters.Monster(id).MaxLife)
+	}
+}
+
+// heroCombatant returns the combat stats of the hero of the given client. The stats are the effective stats of
+// the hero with its equipment, the server only knows the base items the hero wears.
+func (g *Game) heroCombatant(client ClientConnection) *d2combat.Combatant {
+	playerState := client.GetPlayerState()
+	sheet := g.statAggregator.Aggregate(playerState, g.heroEquipment(playerState), g.difficulty)
+
+	minDamage, maxDamage := unarmedMinDamage, unarmedMaxDamage
+	damagePercent := weaponDamagePercent(nil, sheet.Strength, sheet.Dexterity)
+
+	if weapon := playerState.Equipment.RightHand; weapon != nil {
+		if record, found := g.asset.Records.Item.Weapons[weapon.ItemCode]; found && record.MaxDamage > 0 {
+			minDamage, maxDamage = record.MinDamage, record.MaxDamage
+			damagePercent = weaponDamagePercent(record, sheet.Strength, sheet.Dexterity)
+		}
+	}
+
+	values := map[string]int{
+		d2combat.StatAttackRating:  sheet.AttackRating,
+		d2combat.StatDefense:       sheet.DefenseRating,
+		d2combat.StatMinDamage:     minDamage,
+		d2combat.StatMaxDamage:     maxDamage,
+		d2combat.StatDamagePercent: damagePercent,
+		d2combat.StatFireResist:    sheet.FireResistance,
+		d2combat.StatColdResist:    sheet.ColdResistance,
+		d2combat.StatLightResist:   sheet.LightningResistance,
+		d2combat.StatPoisonResist:  sheet.PoisonResistance,
+	}
+
+	hero := d2combat.NewCombatant(client.GetUniqueID(), playerState.Stats.Level, sheet.MaxHealth,
+		d2combat.NewStatList(g.statFactory, values))
+	hero.Life = playerState.Stats.Health
+	hero.MaxResist = d2combat.HeroMaxResist
+
+	return hero
+}
+
+// weaponDamagePercent returns the damage percent that strength and dexterity add to the damage of the weapon, as
+// set by its StrBonus and DexBonus. Bows scale with dexterity, throwing weapons with both. Without a weapon each
+// point of strength adds 1% damage.
+func weaponDamagePercent(record *d2records.ItemCommonRecord, strength, dexterity int) int {
+	if record == nil {
+		return strength
+	}
+
+	return (strength*record.StrengthBonus + dexterity*record.DexterityBonus) / statBonusPoints
+}
+
+// heroEquipment returns the items the hero wears
+func (g *Game) heroEquipment(playerState *d2hero.HeroState) []*diablo2item.Item {
+	equipment := playerState.Equipment
+	items := make([]*diablo2item.Item, 0)
+
+	for _, code := range []string{
+		equipment.Head.GetItemCode(), equipment.Torso.GetItemCode(), equipment.Legs.GetItemCode(),
+		equipment.RightArm.GetItemCode(), equipment.LeftArm.GetItemCode(), equipment.Shield.GetItemCode(),
+		equipment.LeftHand.GetItemCode(), equipment.RightHand.GetItemCode(),
+	} {
+		if code == "" {
+			continue
+		}
+
+		if item, err := g.itemFactory.NewItem(code); err == nil {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// heroHitClass returns the hit class of the weapon of the hero
+func (g *Game) heroHitClass(playerState *d2hero.HeroState) string {
+	if weapon := playerState.Equipment.RightHand; weapon != nil {
+		if record, found := g.asset.Records.Item.Weapons[weapon.ItemCode]; found && record.HitClass != "" {
+			return record.HitClass
+		}
+	}
+
+	return unarmedHitClass
+}
+
+// handleCastSkill broadcasts the cast of a player and resolves its missiles, summons and attack. Casts that come
+// faster than the cast cooldown are dropped.
+func (g *Game) handleCastSkill(client ClientConnection, packet d2netpacket.NetPacket, cast *d2netpacket.CastPacket) {
+	if !g.castReady(client.GetUniqueID(), time.Now()) {
+		log.Printf("GameServer: dropped cast of skill %d from client %s, it is on cooldown", cast.SkillID,
+			client.GetUniqueID())
+
+		return
+	}
+
+	g.sendPacketToViewers(client.GetUniqueID(), packet)
+	g.spawnSkillEntities(client, cast)
+	g.handlePlayerAttack(client, cast)
+}
+
+// castReady returns true when the cooldown of the last cast of the player is over, and starts the next cooldown
+func (g *Game) castReady(id string, now time.Time) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	if last, found := g.lastCasts[id]; found && now.Sub(last) < castCooldown {
+		return false
+	}
+
+	g.lastCasts[id] = now
+
+	return true
+}
+
+// skillReach returns the distance in sub tiles from which a skill hits a monster. Melee skills hit within the
+// melee reach, ranged skills as far as their missiles fly and skills that can do both within the farther of the two.
+func (g *Game) skillReach(skill *d2records.SkillRecord) float64 {
+	reach := 0.0
+
+	if skill.Range == "h2h" || skill.Range == "both" {
+		reach = meleeReach
+	}
+
+	if skill.Range != "rng" && skill.Range != "both" {
+		return reach
+	}
+
+	missileReach := 0.0
+
+	for _, name := range skillMissiles(skill) {
+		if record := g.asset.Records.GetMissileByName(name); record != nil {
+			missileReach = math.Max(missileReach, float64(record.Range))
+		}
+	}
+
+	if missileReach == 0 {
+		missileReach = rangedReach
+	}
+
+	return math.Max(reach, missileReach)
+}
+
+// handlePlayerAttack resolves the attack of a player casting a skill on the monster closest to the cast
+// position, when the monster is within the reach of the skill. Skills without a target, like auras, do not attack.
+func (g *Game) handlePlayerAttack(client ClientConnection, cast *d2netpacket.CastPacket) {
+	skill := g.asset.Records.Skill.Details[cast.SkillID]
+	if skill == nil || (skill.Range != "h2h" && skill.Range != "rng" && skill.Range != "both") {
+		return
+	}
+
+	g.Lock()
+
+	playerState := client.GetPlayerState()
+	if playerState.Stats == nil || playerState.Stats.Health <= 0 {
+		g.Unlock()
+		return
+	}
+
+	level := g.playerLevel(client.GetUniqueID())
+
+	target := findAttackTarget(level, cast)
+	if target == nil {
+		g.Unlock()
+		return
+	}
+
+	playerPosition := d2vector.NewPositionTile(playerState.X, playerState.Y)
+	targetPosition := target.GetPosition()
+
+	if playerPosition.Distance(&targetPosition.Vector) > g.skillReach(skill) {
+		g.Unlock()
+		return
+	}
+
+	result := g.combat.Resolve(&d2combat.Attack{
+		Attacker: g.heroCombatant(client),
+		Defender: level.combatants[target.ID()],
+		Skill:    d2combat.SkillStats(g.statFactory, skill),
+		HitClass: d2combat.FindHitClass(g.asset.Records.Animation.Token.HitClass, g.heroHitClass(playerState)),
+	})
+
+	if monster := level.monsters.Monster(target.ID()); monster != nil {
+		monster.Life = result.Life
+	}
+
+	if result.Killed {
+		g.killMonster(level, client, target)
+	}
+
+	g.Unlock()
+
+	g.sendPacketToLevel(level, createAttackResultPacket(result))
+}
+
+// findAttackTarget returns the living monster of the level closest to the cast position, it prefers the target
+// entity of the cast if there is one
+func findAttackTarget(level *gameLevel, cast *d2netpacket.CastPacket) d2monai.MonsterEntity {
+	entities := level.mapEngine.Entities()
+
+	if combatant := level.combatants[cast.TargetEntityID]; combatant != nil && !combatant.IsDead() {
+		if entity, ok := entities[cast.TargetEntityID].(d2monai.MonsterEntity); ok {
+			return entity
+		}
+	}
+
+	castPosition := d2vector.NewPositionTile(cast.TargetX, cast.TargetY)
+
+	var closest d2monai.MonsterEntity
+
+	closestDistance := float64(targetRadius)
+
+	for id, combatant := range level.combatants {
+		entity, ok := entities[id].(d2monai.MonsterEntity)
+		if !ok || combatant.IsDead() {
+			continue
+		}
+
+		position := entity.GetPosition()
+		distance := position.Distance(&castPosition.Vector)
+
+		// ties are broken by ID, the map order is random
+		if distance < closestDistance || (distance == closestDistance && closest != nil && id < closest.ID()) {
+			closest, closestDistance = entity, distance
+		}
+	}
+
+	return closest
+}
+
+// killMonster stops the AI of a slain monster, which stays on the map as a corpse, drops its loot at its level
+// and grants its experience to the killer
+func (g *Game) killMonster(level *gameLevel, killer ClientConnection, monster d2monai.MonsterEntity) {
+	level.monsters.Remove(monster.ID())
+	delete(level.combatants, monster.ID())
+	g.awardExperience(killer, monster)
+
+	if npc, ok := monster.(*d2mapentity.NPC); ok {
+		npc.Kill()
+	}
+
+	treasureClass := g.itemFactory.TreasureClass(d2combat.TreasureClass(monster.MonStats(), g.difficulty))
+	if treasureClass == nil {
+		return
+	}
+
+	position := monster.GetPosition()
+	tile := position.Tile()
+
+	// the items drop at the level of the monster
+	monsterLevel, _ := g.progression.MonsterExperience(monster.MonStats(), g.difficulty)
+
+	for _, item := range g.itemFactory.ItemsFromTreasureClass(treasureClass, monsterLevel) {
+		level.dropItem(int(tile.X()), int(tile.Y()), item)
+	}
+}
+
+// onMonsterStateChange is the state listener of the monster AI of a level, it resolves the attacks of the
+// monsters. It is called while the game is locked, so the packets are queued until the tick is done.
+func (g *Game) onMonsterStateChange(level *gameLevel, change d2monai.StateChange) {
+	if change.Behaviour != d2monai.BehaviourMelee && change.Behaviour != d2monai.BehaviourRanged {
+		return
+	}
+
+	attacker := level.combatants[change.MonsterID]
+	client := g.connections[change.TargetID]
+
+	if attacker == nil || client == nil || client.GetPlayerState().Stats == nil {
+		return
+	}
+
+	var hitClass string
+
+	if entity, ok := level.mapEngine.Entities()[change.MonsterID].(d2monai.MonsterEntity); ok {
+		if stats2 := g.asset.Records.Monster.Stats2[entity.MonStats().ExtraDataKey]; stats2 != nil {
+			hitClass = stats2.BaseWeaponClass
+		}
+	}
+
+	result := g.combat.Resolve(&d2combat.Attack{
+		Attacker: attacker,
+		Defender: g.heroCombatant(client),
+		HitClass: d2combat.FindHitClass(g.asset.Records.Animation.Token.HitClass, hitClass),
+	})
+
+	g.queueLevelPacket(level, createAttackResultPacket(result))
+
+	playerState := client.GetPlayerState()
+	playerState.Stats.Health = result.Life
+
+	if result.Killed {
+		g.queueViewerPacket(client.GetUniqueID(), g.respawnPlayer(level, client))
+	}
+}
+
+// respawnPlayer brings a slain player back to life at the start position of the map of its level
+func (g *Game) respawnPlayer(level *gameLevel, client ClientConnection) d2netpacket.NetPacket {
+	log.Printf("GameServer: player %s was slain", client.GetUniqueID())
+
+	x, y := level.mapEngine.GetStartPosition()
+
+	playerState := client.GetPlayerState()
+	playerState.Stats.Health = playerState.Stats.MaxHealth
+	playerState.X = x
+	playerState.Y = y
+
+	return g.placePlayer(client.GetUniqueID(), x, y)
+}
+
+func createAttackResultPacket(result *d2combat.Result) d2netpacket.NetPacket {
+	return d2netpacket.CreateAttackResultPacket(result.AttackerID, result.DefenderID, result.Hit, result.Total,
+		result.Life, result.HitClass, result.Killed)
+}
@@ -0,0 +1,70 @@
+package d2server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+func TestCastCooldown(t *testing.T) {
+	game := &Game{lastCasts: make(map[string]time.Time)}
+	now := time.Now()
+
+	if !game.castReady("amazon", now) {
+		t.Fatal("the first cast was dropped")
+	}
+
+	if game.castReady("amazon", now.Add(castCooldown/2)) {
+		t.Error("a cast during the cooldown was allowed")
+	}
+
+	if !game.castReady("sorceress", now) {
+		t.Error("the cooldown of a player held up another player")
+	}
+
+	if !game.castReady("amazon", now.Add(castCooldown)) {
+		t.Error("a cast after the cooldown was dropped")
+	}
+}
+
+func TestSkillReach(t *testing.T) {
+	game := &Game{asset: &d2asset.AssetManager{Records: &d2records.RecordManager{}}}
+
+	tests := []struct {
+		skillRange string
+		expected   float64
+	}{
+		{"h2h", meleeReach},
+		{"rng", rangedReach},
+		{"both", rangedReach},
+		{"none", 0},
+	}
+
+	for _, test := range tests {
+		if reach := game.skillReach(&d2records.SkillRecord{Range: test.skillRange}); reach != test.expected {
+			t.Errorf("%s: expected the reach %f, got %f", test.skillRange, test.expected, reach)
+		}
+	}
+}
+
+func TestWeaponDamagePercent(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   *d2records.ItemCommonRecord
+		expected int
+	}{
+		{"unarmed", nil, 60},
+		{"short sword", &d2records.ItemCommonRecord{StrengthBonus: 100}, 60},
+		{"short bow", &d2records.ItemCommonRecord{DexterityBonus: 100}, 40},
+		{"javelin", &d2records.ItemCommonRecord{StrengthBonus: 75, DexterityBonus: 75}, 75},
+		{"crystal sword", &d2records.ItemCommonRecord{StrengthBonus: 100, DexterityBonus: 50}, 80},
+	}
+
+	for _, test := range tests {
+		if percent := weaponDamagePercent(test.record, 60, 40); percent != test.expected {
+			t.Errorf("%s: expected %d%% damage, got %d%%", test.name, test.expected, percent)
+		}
+	}
+}
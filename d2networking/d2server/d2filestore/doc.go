@@ -0,0 +1,3 @@
+// Package d2filestore stores the accounts and characters of a closed server in a directory, with a
+// directory per account.
+package d2filestore
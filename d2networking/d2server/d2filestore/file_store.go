@@ -0,0 +1,285 @@
+package d2filestore
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server"
+)
+
+const (
+	accountFileName     = "account.json"
+	characterFileSuffix = ".od2"
+	mkdirPermission     = 0750
+	writefilePermission = 0600
+	saltSize            = 16
+	tokenSize           = 32
+	keySize             = sha256.Size
+	passwordIterations  = 100000
+)
+
+var (
+	errInvalidName     = errors.New("invalid name")
+	errAccountExists   = errors.New("account already exists")
+	errUnknownAccount  = errors.New("unknown account")
+	errInvalidPassword = errors.New("invalid password")
+	errInvalidToken    = errors.New("invalid token")
+	errLoadCharacter   = errors.New("failed to load character")
+)
+
+// names of accounts and characters, which are also the names of their files
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,31}$`) //nolint:gochecknoglobals // compiled once
+
+// account is the file of an account, the password and the tokens are only stored as derived keys
+type account struct {
+	Salt       string   `json:"salt"`
+	Iterations int      `json:"iterations"`
+	Key        string   `json:"key"`
+	Tokens     []string `json:"tokens,omitempty"` // sha256 of the tokens
+}
+
+// FileStore is the Authenticator and CharacterStore of a closed server. Each account is a directory in
+// the root directory with the account file and the characters of the account.
+type FileStore struct {
+	sync.Mutex
+	root   string
+	heroes *d2hero.HeroStateFactory
+}
+
+// NewFileStore creates a store in the root directory, which is created if it does not exist. The hero
+// state factory loads and saves the characters, it can be nil when only the accounts are used.
+func NewFileStore(root string, heroes *d2hero.HeroStateFactory) (*FileStore, error) {
+	if err := os.MkdirAll(root, mkdirPermission); err != nil {
+		return nil, err
+	}
+
+	return &FileStore{root: root, heroes: heroes}, nil
+}
+
+// CreateAccount creates a new account with a password
+func (s *FileStore) CreateAccount(name, password string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := os.Stat(s.accountPath(name)); err == nil {
+		return fmt.Errorf("%w: %s", errAccountExists, name)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(s.root, name), mkdirPermission); err != nil {
+		return err
+	}
+
+	return s.writeAccount(name, &account{
+		Salt:       hex.EncodeToString(salt),
+		Iterations: passwordIterations,
+		Key:        hex.EncodeToString(deriveKey(password, salt, passwordIterations)),
+	})
+}
+
+// CreateToken creates a new token for the account, which can be used instead of the password
+func (s *FileStore) CreateToken(name string) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	acc, err := s.readAccount(name)
+	if err != nil {
+		return "", err
+	}
+
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	encoded := hex.EncodeToString(token)
+	acc.Tokens = append(acc.Tokens, hashToken(encoded))
+
+	if err := s.writeAccount(name, acc); err != nil {
+		return "", err
+	}
+
+	return encoded, nil
+}
+
+// Authenticate checks the token or else the password of the account
+func (s *FileStore) Authenticate(credentials *d2netpacket.Credentials) error {
+	s.Lock()
+	acc, err := s.readAccount(credentials.Account)
+	s.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if credentials.Token != "" {
+		hash := []byte(hashToken(credentials.Token))
+
+		for _, token := range acc.Tokens {
+			if subtle.ConstantTimeCompare(hash, []byte(token)) == 1 {
+				return nil
+			}
+		}
+
+		return errInvalidToken
+	}
+
+	salt, err := hex.DecodeString(acc.Salt)
+	if err != nil {
+		return err
+	}
+
+	key, err := hex.DecodeString(acc.Key)
+	if err != nil {
+		return err
+	}
+
+	if subtle.ConstantTimeCompare(key, deriveKey(credentials.Password, salt, acc.Iterations)) != 1 {
+		return errInvalidPassword
+	}
+
+	return nil
+}
+
+// LoadCharacter loads a character of the account, d2server.ErrCharacterNotFound is returned when the
+// account has no character of the name
+func (s *FileStore) LoadCharacter(name, character string) (*d2hero.HeroState, error) {
+	path, err := s.characterPath(name, character)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", d2server.ErrCharacterNotFound, character)
+	}
+
+	state := s.heroes.LoadHeroState(path)
+	if state == nil {
+		return nil, fmt.Errorf("%w: %s", errLoadCharacter, path)
+	}
+
+	return state, nil
+}
+
+// SaveCharacter saves a character of the account, in the file of the name of the character
+func (s *FileStore) SaveCharacter(name string, state *d2hero.HeroState) error {
+	path, err := s.characterPath(name, state.HeroName)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	state.FilePath = path
+
+	return s.heroes.Save(state)
+}
+
+// CharacterCount returns the number of characters of the account
+func (s *FileStore) CharacterCount(name string) (int, error) {
+	if !validName.MatchString(name) {
+		return 0, fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	files, err := ioutil.ReadDir(filepath.Join(s.root, name))
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), characterFileSuffix) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (s *FileStore) accountPath(name string) string {
+	return filepath.Join(s.root, name, accountFileName)
+}
+
+func (s *FileStore) characterPath(name, character string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+
+	if !validName.MatchString(character) {
+		return "", fmt.Errorf("%w: %q", errInvalidName, character)
+	}
+
+	return filepath.Join(s.root, name, character+characterFileSuffix), nil
+}
+
+func (s *FileStore) readAccount(name string) (*account, error) {
+	if !validName.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", errInvalidName, name)
+	}
+
+	data, err := ioutil.ReadFile(s.accountPath(name))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", errUnknownAccount, name)
+	} else if err != nil {
+		return nil, err
+	}
+
+	acc := &account{}
+	if err := json.Unmarshal(data, acc); err != nil {
+		return nil, err
+	}
+
+	return acc, nil
+}
+
+func (s *FileStore) writeAccount(name string, acc *account) error {
+	data, err := json.MarshalIndent(acc, "", "   ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(s.accountPath(name), data, writefilePermission)
+}
+
+func hashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// deriveKey derives a key from the password with PBKDF2, using HMAC-SHA256
+func deriveKey(password string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(password), salt, iterations, keySize, sha256.New)
+}
@@ -0,0 +1,126 @@
+package d2filestore
+
+import (
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server"
+)
+
+func testStore(t *testing.T) (store *FileStore, cleanup func()) {
+	root, err := ioutil.TempDir("", "d2filestore")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewFileStore(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, func() { _ = os.RemoveAll(root) }
+}
+
+func TestDeriveKey(t *testing.T) {
+	// test vector of RFC 7914, section 11
+	key := deriveKey("passwd", []byte("salt"), 1)
+	expected := "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc"
+
+	if hex.EncodeToString(key) != expected {
+		t.Errorf("expected key %s, got %x", expected, key)
+	}
+}
+
+func TestFileStoreAccounts(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	if err := store.CreateAccount("tester", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.CreateAccount("tester", "other"); !errors.Is(err, errAccountExists) {
+		t.Errorf("expected the account to exist, got %v", err)
+	}
+
+	token, err := store.CreateToken("tester")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		credentials d2netpacket.Credentials
+		expected    error
+	}{
+		{d2netpacket.Credentials{Account: "tester", Password: "secret"}, nil},
+		{d2netpacket.Credentials{Account: "tester", Password: "wrong"}, errInvalidPassword},
+		{d2netpacket.Credentials{Account: "tester", Token: token}, nil},
+		{d2netpacket.Credentials{Account: "tester", Token: "wrong"}, errInvalidToken},
+		{d2netpacket.Credentials{Account: "nobody", Password: "secret"}, errUnknownAccount},
+		{d2netpacket.Credentials{Account: "../tester", Password: "secret"}, errInvalidName},
+	}
+
+	for _, test := range tests {
+		credentials := test.credentials
+		if err := store.Authenticate(&credentials); !errors.Is(err, test.expected) {
+			t.Errorf("%+v: expected %v, got %v", credentials, test.expected, err)
+		}
+	}
+}
+
+func TestFileStoreCharacterNames(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	if _, err := store.LoadCharacter("tester", "Nobody"); !errors.Is(err, d2server.ErrCharacterNotFound) {
+		t.Errorf("expected the character not to be found, got %v", err)
+	}
+
+	for _, name := range []string{"../../Saves/0", "a/b", "", ".hidden"} {
+		if _, err := store.LoadCharacter("tester", name); !errors.Is(err, errInvalidName) {
+			t.Errorf("%q: expected an invalid name, got %v", name, err)
+		}
+
+		if err := store.SaveCharacter("tester", &d2hero.HeroState{HeroName: name}); !errors.Is(err, errInvalidName) {
+			t.Errorf("%q: expected an invalid name, got %v", name, err)
+		}
+	}
+}
+
+func TestFileStoreCharacterCount(t *testing.T) {
+	store, cleanup := testStore(t)
+	defer cleanup()
+
+	if count, err := store.CharacterCount("tester"); err != nil || count != 0 {
+		t.Errorf("expected no characters for a new account, got %d, %v", count, err)
+	}
+
+	if err := store.CreateAccount("tester", "secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Akara", "Kashya"} {
+		path, err := store.characterPath("tester", name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte("{}"), writefilePermission); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if count, err := store.CharacterCount("tester"); err != nil || count != 2 {
+		t.Errorf("expected 2 characters, got %d, %v", count, err)
+	}
+
+	if _, err := store.CharacterCount(filepath.Join("..", "tester")); !errors.Is(err, errInvalidName) {
+		t.Errorf("expected an invalid name, got %v", err)
+	}
+}
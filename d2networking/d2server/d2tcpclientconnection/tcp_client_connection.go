@@ -2,7 +2,6 @@
 package d2tcpclientconnection
 
 import (
-	"encoding/json"
 	"net"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
@@ -16,6 +15,7 @@ type TCPClientConnection struct {
 	id            string
 	tcpConnection net.Conn
 	playerState   *d2hero.HeroState
+	encoding      d2netpacket.PacketEncoding
 }
 
 // CreateTCPClientConnection creates a new tcp client connection instance
@@ -31,19 +31,20 @@ func (t TCPClientConnection) GetUniqueID() string {
 	return t.id
 }
 
-// SendPacketToClient marshals and sends (writes) NetPackets
+// SendPacketToClient marshals and sends (writes) NetPackets as length prefixed frames
 func (t *TCPClientConnection) SendPacketToClient(p d2netpacket.NetPacket) error {
-	packet, err := json.Marshal(p)
-	if err != nil {
-		return err
-	}
+	return d2netpacket.WriteFrame(t.tcpConnection, p, t.encoding)
+}
 
-	_, err = t.tcpConnection.Write(packet)
-	if err != nil {
-		return err
-	}
+// SetPacketEncoding sets the encoding of the packets sent to the client.
+// The encoding is negotiated in the PlayerConnectionRequestPacket, it defaults to JSON.
+func (t *TCPClientConnection) SetPacketEncoding(encoding d2netpacket.PacketEncoding) {
+	t.encoding = encoding
+}
 
-	return nil
+// GetPacketEncoding returns the encoding of the packets sent to the client
+func (t *TCPClientConnection) GetPacketEncoding() d2netpacket.PacketEncoding {
+	return t.encoding
 }
 
 // SetPlayerState sets the game client player state
@@ -2,36 +2,29 @@
 package d2udpclientconnection
 
 import (
-	"bytes"
-	"compress/gzip"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net"
-
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
 
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2udp"
 )
 
 // UDPClientConnection is the implementation of the
 // d2server.ClientConnection interface to represent remote client from the
 // server perspective.
 type UDPClientConnection struct {
-	id            string            // ID of the associated RemoteClientConnection
-	address       *net.UDPAddr      // IP address of the associated RemoteClientConnection
-	udpConnection *net.UDPConn      // Server's UDP Connection
-	playerState   *d2hero.HeroState // Client's game state
+	id            string                     // ID of the associated RemoteClientConnection
+	udpConnection *d2udp.Conn                // UDP connection to the client
+	playerState   *d2hero.HeroState          // Client's game state
+	encoding      d2netpacket.PacketEncoding // Encoding of the packets sent to the client
 }
 
 // CreateUDPClientConnection constructs a new UDPClientConnection and
 // returns a pointer to it.
-func CreateUDPClientConnection(udpConnection *net.UDPConn, id string, address *net.UDPAddr) *UDPClientConnection {
+func CreateUDPClientConnection(udpConnection *d2udp.Conn, id string) *UDPClientConnection {
 	result := &UDPClientConnection{
 		id:            id,
-		address:       address,
 		udpConnection: udpConnection,
 	}
 
@@ -46,50 +39,37 @@ func (u UDPClientConnection) GetUniqueID() string {
 // GetConnectionType returns an enum representing the connection type.
 // See: d2clientconnectiontype.
 func (u UDPClientConnection) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
-	return d2clientconnectiontype.LANClient
+	return d2clientconnectiontype.LANClientUDP
 }
 
-// SendPacketToClient compresses the JSON encoding of a NetPacket and
-// sends it to the client.
+// SendPacketToClient sends a NetPacket to the client as a single message, which is
+// fragmented when it does not fit into a datagram. Lossy packets are sent unreliably.
 func (u *UDPClientConnection) SendPacketToClient(packet d2netpacket.NetPacket) error {
-	data, err := json.Marshal(packet.PacketData)
+	data, err := d2netpacket.MarshalFrame(packet, u.encoding)
 	if err != nil {
 		return err
 	}
 
-	var buff bytes.Buffer
-
-	buff.WriteByte(byte(packet.PacketType))
-
-	writer, err := gzip.NewWriterLevel(&buff, gzip.BestCompression)
-	if err != nil {
-		log.Print(err)
-	}
-
-	if written, writeErr := writer.Write(data); writeErr != nil {
-		return writeErr
-	} else if written == 0 {
-		return fmt.Errorf("RemoteClientConnection: attempted to send empty %v packet body",
-			packet.PacketType)
-	}
-
-	if writeErr := writer.Close(); writeErr != nil {
-		return writeErr
-	}
+	return u.udpConnection.WriteMessage(data, !packet.PacketType.Lossy())
+}
 
-	if _, udpErr := u.udpConnection.WriteToUDP(buff.Bytes(), u.address); udpErr != nil {
-		return udpErr
-	}
+// SetPacketEncoding sets the encoding of the packets sent to the client.
+// The encoding is negotiated in the PlayerConnectionRequestPacket, it defaults to JSON.
+func (u *UDPClientConnection) SetPacketEncoding(encoding d2netpacket.PacketEncoding) {
+	u.encoding = encoding
+}
 
-	return nil
+// GetPacketEncoding returns the encoding of the packets sent to the client
+func (u *UDPClientConnection) GetPacketEncoding() d2netpacket.PacketEncoding {
+	return u.encoding
 }
 
-// SetHeroState sets UDP.playerState to the given value.
-func (u *UDPClientConnection) SetHeroState(playerState *d2hero.HeroState) {
+// SetPlayerState sets UDPClientConnection.playerState to the given value.
+func (u *UDPClientConnection) SetPlayerState(playerState *d2hero.HeroState) {
 	u.playerState = playerState
 }
 
-// GetHeroState returns UDPClientConnection.playerState.
-func (u *UDPClientConnection) GetHeroState() *d2hero.HeroState {
+// GetPlayerState returns UDPClientConnection.playerState.
+func (u *UDPClientConnection) GetPlayerState() *d2hero.HeroState {
 	return u.playerState
 }
@@ -1,5 +1,11 @@
 // Package d2server provides connection management and client synchronization.
 /*
-Data is encoded to JSON and compressed using gzip. Transport is over UDP.
-The server is authoritative for both local and remote clients.*/
+Packets are sent over TCP as length prefixed frames, see d2netpacket.WriteFrame, or over UDP as
+a frame per message, see d2udp. The server accepts both on the same port. The encoding of
+the packets is negotiated when a client connects, the compact binary encoding is preferred and
+JSON is used as a fallback and for debugging.
+The server is authoritative for both local and remote clients.
+On closed servers, see GameServer.SetClosed, remote clients authenticate with an account and
+the server loads and saves their characters, the hero state sent by the client only names the
+character.*/
 package d2server
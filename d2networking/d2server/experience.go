@@ -0,0 +1,134 @@
+package d2server
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// partyExperienceRange is the distance in sub tiles from the kill within which the party members of the killer
+// share the experience, the members that can see the kill
+const partyExperienceRange = viewRadius
+
+// setParty sets the party of the player of the client, the players of a party in the game share the
+// experience of their kills
+func (g *Game) setParty(id, party string) {
+	g.Lock()
+	defer g.Unlock()
+
+	if _, found := g.connections[id]; !found || party == "" {
+		delete(g.parties, id)
+		return
+	}
+
+	g.parties[id] = party
+}
+
+// partyMembers returns the client and the clients in its party that are on its level, within
+// partyExperienceRange of the position
+func (g *Game) partyMembers(client ClientConnection, position d2vector.Position) []ClientConnection {
+	party := g.parties[client.GetUniqueID()]
+	if party == "" {
+		return []ClientConnection{client}
+	}
+
+	level := g.playerLevels[client.GetUniqueID()]
+	members := []ClientConnection{client}
+
+	for id, connection := range g.connections {
+		if id == client.GetUniqueID() || g.parties[id] != party || g.playerLevels[id] != level {
+			continue
+		}
+
+		playerState := connection.GetPlayerState()
+		if playerState.Stats == nil {
+			continue
+		}
+
+		playerPosition := d2vector.NewPositionTile(playerState.X, playerState.Y)
+		if playerPosition.Distance(&position.Vector) <= partyExperienceRange {
+			members = append(members, connection)
+		}
+	}
+
+	return members
+}
+
+// awardExperience grants the experience of a slain monster to the killer and the party members near the kill.
+// It is called while the game is locked, so the packets are queued until the tick is done.
+func (g *Game) awardExperience(killer ClientConnection, monster d2monai.MonsterEntity) {
+	if killer.GetPlayerState().Stats == nil {
+		return
+	}
+
+	monsterLevel, experience := g.progression.MonsterExperience(monster.MonStats(), g.difficulty)
+	if experience <= 0 {
+		return
+	}
+
+	members := g.partyMembers(killer, monster.GetPosition())
+	levels := make([]int, len(members))
+
+	for i, member := range members {
+		levels[i] = member.GetPlayerState().Stats.Level
+	}
+
+	for i, share := range d2hero.PartyShares(experience, levels) {
+		playerState := members[i].GetPlayerState()
+		gained := g.progression.KillExperience(playerState.Stats.Level, monsterLevel, share)
+
+		if g.progression.AddExperience(playerState, gained) > 0 {
+			log.Printf("GameServer: client %s reached level %d", members[i].GetUniqueID(), playerState.Stats.Level)
+		}
+
+		g.pendingPackets = append(g.pendingPackets, createPlayerStatsPacket(members[i]))
+	}
+}
+
+// handleSpendStatPoint spends a stat point of the player on the attribute of the packet
+func (g *Game) handleSpendStatPoint(client ClientConnection, packet *d2netpacket.SpendStatPointPacket) error {
+	g.Lock()
+	err := g.progression.SpendStatPoint(client.GetPlayerState(), packet.Attribute)
+	g.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	g.sendPlayerStats(client)
+
+	return nil
+}
+
+// handleLearnSkill spends a skill point of the player on the skill of the packet
+func (g *Game) handleLearnSkill(client ClientConnection, packet *d2netpacket.LearnSkillPacket) error {
+	g.Lock()
+	err := g.progression.LearnSkill(client.GetPlayerState(), packet.SkillID)
+	g.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	g.sendPlayerStats(client)
+
+	return nil
+}
+
+// sendPlayerStats sends the stats and skills of the player of the client to all players
+func (g *Game) sendPlayerStats(client ClientConnection) {
+	g.RLock()
+	packet := createPlayerStatsPacket(client)
+	g.RUnlock()
+
+	g.sendPacketToClients(packet)
+}
+
+func createPlayerStatsPacket(client ClientConnection) d2netpacket.NetPacket {
+	playerState := client.GetPlayerState()
+
+	return d2netpacket.CreatePlayerStatsPacket(client.GetUniqueID(), playerState.Stats, playerState.Skills)
+}
@@ -0,0 +1,58 @@
+package d2server
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+)
+
+func TestPartyMembersInRange(t *testing.T) {
+	game := &Game{connections: make(map[string]ClientConnection), parties: make(map[string]string),
+		playerLevels: make(map[string]int)}
+
+	players := []struct {
+		id, party string
+		level     int
+		x         float64
+	}{
+		{"killer", "rogues", 1, 10},
+		{"near", "rogues", 1, 15},
+		{"far", "rogues", 1, 10 + partyExperienceRange/subtilesPerTile + 1},
+		{"elsewhere", "rogues", 2, 10},
+		{"stranger", "", 1, 10},
+	}
+
+	for _, player := range players {
+		client := newChatClient(player.id, player.id)
+		client.state.Stats = &d2hero.HeroStatsState{Level: 1}
+		client.state.X, client.state.Y = player.x, 10
+
+		game.connections[player.id] = client
+		game.playerLevels[player.id] = player.level
+
+		if player.party != "" {
+			game.parties[player.id] = player.party
+		}
+	}
+
+	members := game.partyMembers(game.connections["killer"], d2vector.NewPositionTile(10, 10))
+	ids := make([]string, len(members))
+
+	for i, member := range members {
+		ids[i] = member.GetUniqueID()
+	}
+
+	sort.Strings(ids)
+
+	if len(ids) != 2 || ids[0] != "killer" || ids[1] != "near" {
+		t.Errorf("expected the killer and the near party member, got %v", ids)
+	}
+
+	// a killer without a party keeps the experience, wherever the kill is
+	stranger := game.partyMembers(game.connections["stranger"], d2vector.NewPositionTile(1000, 1000))
+	if len(stranger) != 1 || stranger[0].GetUniqueID() != "stranger" {
+		t.Errorf("expected only the killer, got %d members", len(stranger))
+	}
+}
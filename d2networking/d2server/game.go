@@ -0,0 +1,363 @@
+package d2server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+var errGameFull = errors.New("game is full")
+
+// GameOptions are the settings of a new game
+type GameOptions struct {
+	Name       string
+	Password   string
+	Difficulty d2enum.DifficultyType
+	MaxPlayers int
+	Seed       int64 // 0 picks a random seed
+	Persistent bool  // the game is kept when the last player leaves, like the default game of a dedicated server
+}
+
+// Game is a game hosted by the GameServer. Each game is an isolated world with its own map, monsters and
+// players, which is simulated until the last player leaves, unless it is persistent.
+type Game struct {
+	sync.RWMutex
+	name           string
+	password       string
+	difficulty     d2enum.DifficultyType
+	maxPlayers     int
+	persistent     bool // the game is not closed when it is empty
+	seed           int64
+	ctx            context.Context
+	cancel         context.CancelFunc
+	asset          *d2asset.AssetManager
+	connections    map[string]ClientConnection
+	levels         map[int]*gameLevel // the levels players entered, by level ID
+	playerLevels   map[string]int     // the level each player is on, by client ID
+	startLevel     int                // the level players start on when they join
+	combat         *d2combat.Resolver
+	statFactory    *diablo2stats.StatFactory
+	itemFactory    *diablo2item.ItemFactory
+	progression    *d2hero.Progression
+	statAggregator *d2hero.StatAggregator
+	parties        map[string]string       // the party of each player, by client ID
+	pendingPackets []d2netpacket.NetPacket // packets for all clients, queued while the game is locked
+	queuedPackets  []clientPacket          // packets for single clients, queued while the game is locked
+	movements      map[string]*playerMovement
+	lastCasts      map[string]time.Time   // the time of the last cast of each player, by client ID
+	views          map[string]*clientView // the entities replicated to each client
+	clock          float64                // seconds the game has been simulated
+	snapshotTick   uint64                 // tick of the last snapshot of the entities
+}
+
+// newGame generates the town of the first act for a new game. The game is simulated once it is started.
+func newGame(ctx context.Context, asset *d2asset.AssetManager, statFactory *diablo2stats.StatFactory,
+	options *GameOptions) (*Game, error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	game := &Game{
+		name:         options.Name,
+		password:     options.Password,
+		difficulty:   options.Difficulty,
+		maxPlayers:   options.MaxPlayers,
+		persistent:   options.Persistent,
+		seed:         options.Seed,
+		ctx:          ctx,
+		cancel:       cancel,
+		asset:        asset,
+		connections:  make(map[string]ClientConnection),
+		levels:       make(map[int]*gameLevel),
+		playerLevels: make(map[string]int),
+		startLevel:   d2mapgen.TownLevelID(1),
+		statFactory:  statFactory,
+		progression:  d2hero.NewProgression(asset.Records),
+		parties:      make(map[string]string),
+		movements:    make(map[string]*playerMovement),
+		lastCasts:    make(map[string]time.Time),
+		views:        make(map[string]*clientView),
+	}
+
+	var err error
+
+	game.itemFactory, err = diablo2item.NewItemFactory(asset)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	game.itemFactory.SetSeed(game.seed)
+
+	game.statAggregator, err = d2hero.NewStatAggregator(asset)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	game.combat = d2combat.NewResolver(game.seed)
+
+	if _, err := game.enterLevel(game.startLevel); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return game, nil
+}
+
+// Name returns the name of the game
+func (g *Game) Name() string {
+	return g.name
+}
+
+// Info returns the description of the game that is listed in the lobby
+func (g *Game) Info() d2netpacket.GameInfo {
+	g.RLock()
+	defer g.RUnlock()
+
+	return d2netpacket.GameInfo{
+		Name:       g.name,
+		Difficulty: g.difficulty,
+		Players:    len(g.connections),
+		MaxPlayers: g.maxPlayers,
+		Password:   g.password != "",
+	}
+}
+
+func (g *Game) start() {
+	go g.simulate()
+}
+
+func (g *Game) stop() {
+	g.cancel()
+}
+
+// stopIfEmpty stops the game when no player is in it and it is not persistent, it returns true when the game was
+// stopped. Clients can not join a game that was stopped.
+func (g *Game) stopIfEmpty() bool {
+	g.Lock()
+	defer g.Unlock()
+
+	if g.persistent || len(g.connections) > 0 {
+		return false
+	}
+
+	g.cancel()
+
+	return true
+}
+
+// join adds the player of the client to the game, at the start position of the start level. It sends the
+// following packets to the client: JoinGameResultPacket (when sendResult is set), UpdateServerInfoPacket,
+// GenerateMapPacket, AddPlayerPacket.
+//
+// The player and the other players on the level are added to each other with the snapshots, once they are
+// within the view radius of each other.
+func (g *Game) join(client ClientConnection, encoding d2netpacket.PacketEncoding, sendResult bool) error {
+	g.Lock()
+
+	if len(g.connections) >= g.maxPlayers {
+		g.Unlock()
+		return errGameFull
+	}
+
+	if g.ctx.Err() != nil {
+		g.Unlock()
+		return fmt.Errorf("%w: %s", errGameNotFound, g.name)
+	}
+
+	if sendResult {
+		if err := client.SendPacketToClient(d2netpacket.CreateJoinGameResultPacket(g.name, true, "")); err != nil {
+			g.Unlock()
+			return err
+		}
+	}
+
+	// Temporary position hack --------------------------------------------
+	// https://github.com/OpenDiablo2/OpenDiablo2/issues/829
+	sx, sy := g.levels[g.startLevel].mapEngine.GetStartPosition()
+	clientPlayerState := client.GetPlayerState()
+	clientPlayerState.X = sx
+	clientPlayerState.Y = sy
+	// --------------------------------------------------------------------
+
+	g.connections[client.GetUniqueID()] = client
+	g.playerLevels[client.GetUniqueID()] = g.startLevel
+	g.Unlock()
+
+	log.Printf("GameServer: client %s joined game %q", client.GetUniqueID(), g.name)
+
+	g.handleClientConnection(client, sx, sy, encoding)
+
+	return nil
+}
+
+// leave removes the player of the client from the game and the other clients, it returns true when the
+// game is empty
+func (g *Game) leave(client ClientConnection) bool {
+	g.Lock()
+	defer g.Unlock()
+
+	// the clients the player was added to remove it, the others never knew it
+	for _, viewer := range g.viewers(client.GetUniqueID()) {
+		if viewer != client {
+			g.queuedPackets = append(g.queuedPackets,
+				clientPacket{viewer, d2netpacket.CreateRemovePlayerPacket(client.GetUniqueID())})
+		}
+	}
+
+	for _, view := range g.views {
+		delete(view.players, client.GetUniqueID())
+	}
+
+	delete(g.connections, client.GetUniqueID())
+	delete(g.movements, client.GetUniqueID())
+	delete(g.lastCasts, client.GetUniqueID())
+	delete(g.views, client.GetUniqueID())
+	delete(g.playerLevels, client.GetUniqueID())
+	delete(g.parties, client.GetUniqueID())
+
+	log.Printf("GameServer: client %s left game %q", client.GetUniqueID(), g.name)
+
+	return len(g.connections) == 0
+}
+
+// sendPacketToClients sends the packet to all players in the game. It must not be called while the game is locked.
+func (g *Game) sendPacketToClients(packet d2netpacket.NetPacket) {
+	for _, c := range g.clients() {
+		if err := c.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending packet: %s to client %s: %s", packet.PacketType, c.GetUniqueID(), err)
+		}
+	}
+}
+
+func (g *Game) handleClientConnection(client ClientConnection, x, y float64,
+	encoding d2netpacket.PacketEncoding) {
+	err := client.SendPacketToClient(d2netpacket.CreateUpdateServerInfoPacket(g.seed, client.GetUniqueID(), g.difficulty,
+		encoding))
+	if err != nil {
+		log.Printf("GameServer: error sending UpdateServerInfoPacket to client %s: %s", client.GetUniqueID(), err)
+	}
+
+	g.RLock()
+	level := g.playerLevel(client.GetUniqueID())
+	generateMapPacket := d2netpacket.CreateGenerateMapPacket(d2enum.RegionIdType(level.mapEngine.LevelType().ID),
+		level.ID)
+	g.RUnlock()
+
+	err = client.SendPacketToClient(generateMapPacket)
+	if err != nil {
+		log.Printf("GameServer: error sending GenerateMapPacket to client %s: %s", client.GetUniqueID(), err)
+	}
+
+	playerState := client.GetPlayerState()
+
+	// these are in subtiles
+	playerX := int(x*subtilesPerTile) + middleOfTileOffset
+	playerY := int(y*subtilesPerTile) + middleOfTileOffset
+
+	d2hero.HydrateSkills(playerState.Skills, g.asset)
+
+	g.Lock()
+	movement := newPlayerMovement(float64(playerX), float64(playerY))
+	g.setStamina(movement, playerState)
+	g.movements[client.GetUniqueID()] = movement
+	createPlayerPacket := createAddPlayerPacket(client)
+	g.Unlock()
+
+	if err := client.SendPacketToClient(createPlayerPacket); err != nil {
+		log.Printf("GameServer: error sending %T to client %s: %s", createPlayerPacket, client.GetUniqueID(), err)
+	}
+
+	g.watch(client)
+}
+
+// createAddPlayerPacket returns the packet that adds the player of the client to the other clients, at its
+// current position. It is called while the game is locked.
+func createAddPlayerPacket(client ClientConnection) d2netpacket.NetPacket {
+	playerState := client.GetPlayerState()
+
+	return d2netpacket.CreateAddPlayerPacket(
+		client.GetUniqueID(),
+		playerState.HeroName,
+		int(playerState.X*subtilesPerTile)+middleOfTileOffset,
+		int(playerState.Y*subtilesPerTile)+middleOfTileOffset,
+		playerState.HeroType,
+		playerState.Stats,
+		playerState.Skills,
+		playerState.Equipment,
+		playerState.LeftSkill,
+		playerState.RightSkill,
+	)
+}
+
+// onPacketReceived handles the packets of a client that is in the game
+func (g *Game) onPacketReceived(client ClientConnection, packet d2netpacket.NetPacket) error {
+	switch packet.PacketType {
+	case d2netpackettype.MovePlayer:
+		movePacket, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		return g.handleMovePlayer(client, &movePacket)
+	case d2netpackettype.CastSkill:
+		castPacket, err := d2netpacket.UnmarshalCast(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		g.handleCastSkill(client, packet, &castPacket)
+	case d2netpackettype.SpawnItem:
+		spawnPacket, err := d2netpacket.UnmarshalSpawnItem(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		g.Lock()
+		g.playerLevel(client.GetUniqueID()).spawnItem(spawnPacket.X, spawnPacket.Y, spawnPacket.Codes...)
+		g.Unlock()
+	case d2netpackettype.SpendStatPoint:
+		spendPacket, err := d2netpacket.UnmarshalSpendStatPoint(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		return g.handleSpendStatPoint(client, &spendPacket)
+	case d2netpackettype.LearnSkill:
+		learnPacket, err := d2netpacket.UnmarshalLearnSkill(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		return g.handleLearnSkill(client, &learnPacket)
+	default:
+		log.Printf("GameServer: received unknown packet %T", packet)
+	}
+
+	return nil
+}
+
+// clients returns the clients of the players in the game
+func (g *Game) clients() []ClientConnection {
+	g.RLock()
+	defer g.RUnlock()
+
+	clients := make([]ClientConnection, 0, len(g.connections))
+	for _, client := range g.connections {
+		clients = append(clients, client)
+	}
+
+	return clients
+}
@@ -2,7 +2,6 @@ package d2server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -12,14 +11,13 @@ import (
 
 	"github.com/robertkrimen/otto"
 
-	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
 	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
-	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2stats/diablo2stats"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
 	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
-	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2tcpclientconnection"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2udp"
 	"github.com/OpenDiablo2/OpenDiablo2/d2script"
 )
 
@@ -32,32 +30,55 @@ const (
 
 var (
 	errPlayerAlreadyExists = errors.New("player already exists")
-	errServerFull          = errors.New("server full") // Server currently at maximum TCP connections
+	errServerFull          = errors.New("server full") // Server currently at maximum connections
+	errNotInGame           = errors.New("packet from a client that is not in a game")
 )
 
-// GameServer manages a copy of the map and entities as well as manages packet routing and connections.
+// GameServer manages the games of the server as well as manages packet routing and connections.
 // It can accept connections from localhost as well remote clients. It can also be started in a standalone mode.
+// Connected clients are in the lobby of the server until they join a game.
 type GameServer struct {
 	sync.RWMutex
-	connections       map[string]ClientConnection
+	connections       map[string]ClientConnection // all clients, in the lobby or in a game
+	games             map[string]*Game
+	maxGames          int                          // the number of games the server can have, 0 for no limit
+	clientGames       map[string]*Game             // the game of each client that is not in the lobby
+	health            map[string]*connectionHealth // the heartbeat of each client
+	defaultGame       string                       // the game that is joined when no game name is given
 	listener          net.Listener
+	udpListener       *d2udp.Listener
 	networkServer     bool
 	ctx               context.Context
 	cancel            context.CancelFunc
 	asset             *d2asset.AssetManager
-	mapEngines        []*d2mapengine.MapEngine
+	statFactory       *diablo2stats.StatFactory
 	scriptEngine      *d2script.ScriptEngine
-	seed              int64
+	cube              *diablo2item.HoradricCube // the cube of the transmute function of the script engine
 	maxConnections    int
-	packetManagerChan chan []byte
+	packetManagerChan chan clientPacket
 	heroStateFactory  *d2hero.HeroStateFactory
+	authenticator     Authenticator     // nil on open servers
+	characters        CharacterStore    // the characters of the accounts of a closed server
+	accounts          map[string]string // the account of each client of a closed server
+	operators         map[string]bool   // the accounts that can run the commands of operators
+	chat              map[string]*chatState
+	commands          map[string]*chatCommand // the slash commands of the chat
+	scriptMutex       sync.Mutex              // the script engine runs one script at a time
 }
 
-// NewGameServer builds a new GameServer that can be started
+// clientPacket is a packet read from a remote connection along with the connection it was received from, or a
+// packet for a single client.
+type clientPacket struct {
+	client ClientConnection
+	packet d2netpacket.NetPacket
+}
+
+// NewGameServer builds a new GameServer that can be started. The server has no games, they are created
+// with CreateGame or by the clients in the lobby.
 //
 // ctx: required context item
 // networkServer: true = 0.0.0.0 | false = 127.0.0.1
-// maxConnections (default: 8): maximum number of TCP connections allowed open
+// maxConnections (default: 8): maximum number of TCP and UDP connections allowed open
 func NewGameServer(asset *d2asset.AssetManager, networkServer bool,
 	maxConnections ...int) (*GameServer,
 	error) {
@@ -77,30 +98,52 @@ func NewGameServer(asset *d2asset.AssetManager, networkServer bool,
 		cancel:            cancel,
 		asset:             asset,
 		connections:       make(map[string]ClientConnection),
+		games:             make(map[string]*Game),
+		maxGames:          DefaultMaxGames,
+		clientGames:       make(map[string]*Game),
+		health:            make(map[string]*connectionHealth),
+		accounts:          make(map[string]string),
+		operators:         make(map[string]bool),
+		chat:              make(map[string]*chatState),
 		networkServer:     networkServer,
 		maxConnections:    maxConnections[0],
-		packetManagerChan: make(chan []byte),
-		mapEngines:        make([]*d2mapengine.MapEngine, 0),
+		packetManagerChan: make(chan clientPacket),
 		scriptEngine:      d2script.CreateScriptEngine(),
-		seed:              time.Now().UnixNano(),
 		heroStateFactory:  heroStateFactory,
 	}
 
-	mapEngine := d2mapengine.CreateMapEngine(asset)
-	mapEngine.SetSeed(gameServer.seed)
-	mapEngine.ResetMap(d2enum.RegionAct1Town, 100, 100)
-
-	mapGen, err := d2mapgen.NewMapGenerator(asset, mapEngine)
+	gameServer.statFactory, err = diablo2stats.NewStatFactory(asset)
 	if err != nil {
 		return nil, err
 	}
 
-	mapGen.GenerateAct1Overworld()
+	itemFactory, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		return nil, err
+	}
 
-	gameServer.mapEngines = append(gameServer.mapEngines, mapEngine)
+	gameServer.cube = diablo2item.NewHoradricCube(itemFactory)
 
 	gameServer.scriptEngine.AddFunction("getMapEngines", func(call otto.FunctionCall) otto.Value {
-		val, err := gameServer.scriptEngine.ToValue(gameServer.mapEngines)
+		val, err := gameServer.scriptEngine.ToValue(gameServer.mapEngines())
+		if err != nil {
+			fmt.Print(err.Error())
+		}
+		return val
+	})
+
+	gameServer.scriptEngine.AddFunction("transmute", gameServer.scriptTransmute)
+
+	gameServer.addChatCommands()
+	gameServer.scriptEngine.AddFunction("addChatCommand", gameServer.addScriptChatCommand)
+
+	gameServer.scriptEngine.AddFunction("getLatencies", func(call otto.FunctionCall) otto.Value {
+		latencies := make(map[string]int64)
+		for id, latency := range gameServer.Latencies() {
+			latencies[id] = latency.Milliseconds()
+		}
+
+		val, err := gameServer.scriptEngine.ToValue(latencies)
 		if err != nil {
 			fmt.Print(err.Error())
 		}
@@ -110,8 +153,8 @@ func NewGameServer(asset *d2asset.AssetManager, networkServer bool,
 	return gameServer, nil
 }
 
-// Start essentially starts all of the game server go routines as well as begins listening for connection. This will
-// return an error if it is unable to bind to a socket.
+// Start essentially starts all of the game server go routines as well as begins listening for connection. Clients
+// can connect over TCP and UDP on the same port. This will return an error if it is unable to bind to a socket.
 func (g *GameServer) Start() error {
 	listenerAddress := "127.0.0.1:" + port
 	if g.networkServer {
@@ -125,33 +168,64 @@ func (g *GameServer) Start() error {
 		return err
 	}
 
+	g.udpListener, err = d2udp.Listen(listenerAddress)
+	if err != nil {
+		_ = l.Close()
+		return err
+	}
+
 	g.listener = l
 
 	go g.packetManager()
+	go g.heartbeat()
+	go g.acceptTCP()
+	go g.acceptUDP()
 
-	go func() {
-		for {
-			c, err := g.listener.Accept()
-			if err != nil {
-				log.Printf("Unable to accept connection: %s\n", err)
-				return
-			}
+	return nil
+}
 
-			go g.handleConnection(c)
+func (g *GameServer) acceptTCP() {
+	for {
+		c, err := g.listener.Accept()
+		if err != nil {
+			log.Printf("Unable to accept connection: %s\n", err)
+			return
 		}
-	}()
 
-	return nil
+		go g.handleConnection(newTCPConnection(c))
+	}
 }
 
-// Stop stops the game server
+func (g *GameServer) acceptUDP() {
+	for {
+		c, err := g.udpListener.Accept()
+		if err != nil {
+			log.Printf("Unable to accept UDP connection: %s\n", err)
+			return
+		}
+
+		go g.handleConnection(udpConnection{c})
+	}
+}
+
+// Stop stops the game server and closes all games
 func (g *GameServer) Stop() {
 	g.Lock()
+	defer g.Unlock()
+
 	g.cancel()
 
+	for _, game := range g.games {
+		game.stop()
+	}
+
 	if err := g.listener.Close(); err != nil {
 		log.Printf("failed to close the listener %s, err: %v\n", g.listener.Addr(), err)
 	}
+
+	if err := g.udpListener.Close(); err != nil {
+		log.Printf("failed to close the listener %s, err: %v\n", g.udpListener.Addr(), err)
+	}
 }
 
 // packetManager is meant to be started as a Goroutine and is used to manage routing of packets to clients.
@@ -164,289 +238,289 @@ func (g *GameServer) packetManager() {
 		case <-g.ctx.Done():
 			return
 		case p := <-g.packetManagerChan:
-			switch d2netpacket.InspectPacketType(p) {
-			case d2netpackettype.PlayerConnectionRequest:
-				player, err := d2netpacket.UnmarshalNetPacket(p)
-				if err != nil {
-					log.Printf("Unable to unmarshal PlayerConnectionRequestPacket: %s\n", err)
-				}
-
-				g.sendPacketToClients(player)
-			case d2netpackettype.MovePlayer:
-				move, err := d2netpacket.UnmarshalNetPacket(p)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-
-				g.sendPacketToClients(move)
-			case d2netpackettype.CastSkill:
-				castSkill, err := d2netpacket.UnmarshalNetPacket(p)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-
-				g.sendPacketToClients(castSkill)
-			case d2netpackettype.SpawnItem:
-				item, err := d2netpacket.UnmarshalNetPacket(p)
-				if err != nil {
-					log.Println(err)
-					continue
-				}
-
-				g.sendPacketToClients(item)
-			case d2netpackettype.ServerClosed:
-				g.Stop()
+			// Remote clients go through the same validation as the local client
+			if err := g.OnPacketReceived(p.client, p.packet); err != nil {
+				log.Printf("GameServer: error handling %s packet from client %s: %s",
+					p.packet.PacketType, p.client.GetUniqueID(), err)
 			}
 		}
 	}
 }
 
-func (g *GameServer) sendPacketToClients(packet d2netpacket.NetPacket) {
-	for _, c := range g.connections {
-		if err := c.SendPacketToClient(packet); err != nil {
-			log.Printf("GameServer: error sending packet: %s to client %s: %s", packet.PacketType, c.GetUniqueID(), err)
-		}
-	}
-}
-
 // handleConnection accepts an individual connection and starts pooling for new packets. It is recommended this is called
 // via Go Routine. Context should be a property of the GameServer Struct.
-func (g *GameServer) handleConnection(conn net.Conn) {
-	var connected int
-
-	var packet d2netpacket.NetPacket
+func (g *GameServer) handleConnection(conn remoteConnection) {
+	var client ClientConnection
 
 	log.Printf("Accepting connection: %s\n", conn.RemoteAddr().String())
 
 	defer func() {
+		if client != nil {
+			g.OnClientDisconnected(client)
+		}
+
 		if err := conn.Close(); err != nil {
 			log.Printf("failed to close the connection: %s\n", conn.RemoteAddr())
 		}
 	}()
 
-	decoder := json.NewDecoder(conn)
-
 	for {
-		err := decoder.Decode(&packet)
+		packet, err := conn.readPacket()
 		if err != nil {
 			log.Println(err)
 			return // exit this connection as we could not read the first packet
 		}
 
 		// If this is the first packet we are seeing from this specific connection we first need to see if the client
-		// is sending a valid request. If this is a valid request, we will register it and flip the connected switch
-		// to.
-		if connected == 0 {
+		// is sending a valid request. If this is a valid request, we will register it and route all following
+		// packets through the packet manager. The games can be listed without registering, to browse them.
+		if client == nil {
+			if packet.PacketType == d2netpackettype.ListGames {
+				if err := conn.writePacket(g.createGameListPacket()); err != nil {
+					log.Println(err)
+					return
+				}
+
+				continue
+			}
+
 			if packet.PacketType != d2netpackettype.PlayerConnectionRequest {
 				log.Printf("Closing connection with %s: did not receive new player connection request...\n", conn.RemoteAddr().String())
+				return
 			}
 
-			if err := g.registerConnection(packet.PacketData, conn); err != nil {
-				switch err {
-				case errServerFull: // Server is currently full and not accepting new connections.
-					_, errServerFullPacket := conn.Write(d2netpacket.MarshalPacket(d2netpacket.CreateServerFullPacket()))
+			client, err = g.registerConnection(packet.PacketData, conn)
+			if err != nil {
+				switch {
+				case errors.Is(err, errServerFull): // Server is currently full and not accepting new connections.
+					errServerFullPacket := conn.writePacket(d2netpacket.CreateServerFullPacket())
 					log.Println(errServerFullPacket)
-				case errPlayerAlreadyExists: // Player is already registered and did not disconnection correctly.
+				case errors.Is(err, errPlayerAlreadyExists): // Player is already registered and did not disconnection correctly.
 					log.Println(err)
+				default: // The account or character of a closed server was refused.
+					log.Printf("Refused connection from %s: %s\n", conn.RemoteAddr().String(), err)
+
+					if err := conn.writePacket(d2netpacket.CreateAuthenticationResultPacket(false, refusalReason(err))); err != nil {
+						log.Println(err)
+					}
 				}
 
 				return
 			}
 
-			connected = 1
+			if g.authenticator != nil {
+				if err := conn.writePacket(d2netpacket.CreateAuthenticationResultPacket(true, "")); err != nil {
+					log.Println(err)
+					return
+				}
+			}
+
+			continue
+		}
+
+		if packet.PacketType == d2netpackettype.PlayerDisconnectionNotification {
+			return
 		}
 
 		select {
 		case <-g.ctx.Done():
 			return
 		default:
-			g.packetManagerChan <- packet.PacketData
+			g.packetManagerChan <- clientPacket{client: client, packet: packet}
 		}
 	}
 }
 
-// registerConnection accepts a PlayerConnectionRequestPacket and thread safely updates the connection pool
+// registerConnection accepts a PlayerConnectionRequestPacket and thread safely updates the connection pool.
+// The client is in the lobby until it joins a game. On closed servers the account of the client is
+// authenticated and its character is loaded first.
+//
+// The credentials of the request, including the password of the account, are sent in plaintext over TCP or
+// UDP, which are not encrypted. Anyone on the network path can read them, so players must not use a password
+// they use anywhere else. A challenge from the server, or a session token handed out over an encrypted channel,
+// would keep the password off the wire.
 //
 // Errors:
+// - the errors of unmarshaling the request
 // - errServerFull
 // - errPlayerAlreadyExists
-func (g *GameServer) registerConnection(b []byte, conn net.Conn) error {
-	g.Lock()
+// - errAccountInUse
+// - the errors of authenticate
+func (g *GameServer) registerConnection(b []byte, conn remoteConnection) (ClientConnection, error) {
+	packet, err := d2netpacket.UnmarshalPlayerConnectionRequest(b)
+	if err != nil {
+		return nil, err
+	}
 
-	// check to see if the server is full
-	if len(g.connections) >= g.maxConnections {
-		return errServerFull
+	// a full server does not authenticate, which loads the character from the store
+	if g.isFull() {
+		return nil, errServerFull
 	}
 
-	// if it is not full, unmarshal the playerConnectionRequest
-	packet, err := d2netpacket.UnmarshalPlayerConnectionRequest(b)
+	// the character store is not used while the server is locked
+	account, playerState, err := g.authenticate(&packet)
 	if err != nil {
-		log.Printf("Failed to unmarshal PlayerConnectionRequest: %s\n", err)
+		return nil, err
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	// the server could have filled up while the client was authenticated
+	if len(g.connections) >= g.maxConnections {
+		return nil, errServerFull
 	}
 
 	// check to see if the player is already registered
 	if _, ok := g.connections[packet.ID]; ok {
-		return errPlayerAlreadyExists
+		return nil, errPlayerAlreadyExists
 	}
 
-	// Client a new TCP Client Connection and add it to the connections map
-	client := d2tcpclientconnection.CreateTCPClientConnection(conn, packet.ID)
-	client.SetPlayerState(packet.PlayerState)
-	log.Printf("Client connected with an id of %s", client.GetUniqueID())
+	// an account can only play one character at a time
+	if g.accountConnected(account) {
+		return nil, fmt.Errorf("%w: %s", errAccountInUse, account)
+	}
+
+	// Client a new TCP or UDP Client Connection and add it to the connections map
+	client := conn.createClient(packet.ID)
+	client.SetPlayerState(playerState)
+	client.SetPacketEncoding(negotiatePacketEncoding(&packet))
+	log.Printf("Client connected with an id of %s, using %s packets", client.GetUniqueID(), client.GetPacketEncoding())
 	g.connections[client.GetUniqueID()] = client
+	g.health[client.GetUniqueID()] = newConnectionHealth(func() error {
+		// the pending read fails, which disconnects the client
+		return conn.SetReadDeadline(time.Now())
+	})
 
-	// Temporary position hack --------------------------------------------
-	// https://github.com/OpenDiablo2/OpenDiablo2/issues/829
-	sx, sy := g.mapEngines[0].GetStartPosition()
-	clientPlayerState := client.GetPlayerState()
-	clientPlayerState.X = sx
-	clientPlayerState.Y = sy
-	// ---------
+	if account != "" {
+		g.accounts[client.GetUniqueID()] = account
+	}
 
-	// This really should be deferred however to much time will be spend holding a lock when we attempt to send a packet
-	g.Unlock()
+	return client, nil
+}
 
-	g.handleClientConnection(client, sx, sy)
+// isFull returns true when the server has no room for another connection
+func (g *GameServer) isFull() bool {
+	g.RLock()
+	defer g.RUnlock()
 
-	return nil
+	return len(g.connections) >= g.maxConnections
 }
 
-// OnClientConnected initializes the given ClientConnection. It sends the
-// following packets to the newly connected client: UpdateServerInfoPacket,
-// GenerateMapPacket, AddPlayerPacket.
-//
-// It also sends AddPlayerPackets for each other player entity to the new
-// player and vice versa, so all player entities exist on all clients.
+// negotiatePacketEncoding returns the packet encoding for a new connection. The binary encoding is only used
+// if the client asks for it and speaks the same version of it, JSON is used otherwise.
+func negotiatePacketEncoding(request *d2netpacket.PlayerConnectionRequestPacket) d2netpacket.PacketEncoding {
+	if request.Encoding == d2netpacket.BinaryEncoding && request.BinaryVersion == d2netpacket.BinaryProtocolVersion {
+		return d2netpacket.BinaryEncoding
+	}
+
+	return d2netpacket.JSONEncoding
+}
+
+// OnClientConnected registers the given local ClientConnection and joins the
+// default game of the server, see JoinGame.
 //
 // For more information, see d2networking.d2netpacket.
-func (g *GameServer) OnClientConnected(client ClientConnection) {
-	// Temporary position hack --------------------------------------------
-	// https://github.com/OpenDiablo2/OpenDiablo2/issues/829
-	sx, sy := g.mapEngines[0].GetStartPosition()
-	clientPlayerState := client.GetPlayerState()
-	clientPlayerState.X = sx
-	clientPlayerState.Y = sy
-	// --------------------------------------------------------------------
-
+func (g *GameServer) OnClientConnected(client ClientConnection) error {
 	log.Printf("Client connected with an id of %s", client.GetUniqueID())
+
+	g.Lock()
 	g.connections[client.GetUniqueID()] = client
+	g.health[client.GetUniqueID()] = newConnectionHealth(nil)
+	g.Unlock()
 
-	g.handleClientConnection(client, sx, sy)
+	return g.JoinGame(client, "", "")
 }
 
-func (g *GameServer) handleClientConnection(client ClientConnection, x, y float64) {
-	err := client.SendPacketToClient(d2netpacket.CreateUpdateServerInfoPacket(g.seed, client.GetUniqueID()))
-	if err != nil {
-		log.Printf("GameServer: error sending UpdateServerInfoPacket to client %s: %s", client.GetUniqueID(), err)
-	}
-
-	err = client.SendPacketToClient(d2netpacket.CreateGenerateMapPacket(d2enum.RegionAct1Town))
-	if err != nil {
-		log.Printf("GameServer: error sending GenerateMapPacket to client %s: %s", client.GetUniqueID(), err)
-	}
-
-	playerState := client.GetPlayerState()
-
-	// these are in subtiles
-	playerX := int(x*subtilesPerTile) + middleOfTileOffset
-	playerY := int(y*subtilesPerTile) + middleOfTileOffset
-
-	d2hero.HydrateSkills(playerState.Skills, g.asset)
-
-	createPlayerPacket := d2netpacket.CreateAddPlayerPacket(
-		client.GetUniqueID(),
-		playerState.HeroName,
-		playerX,
-		playerY,
-		playerState.HeroType,
-		playerState.Stats,
-		playerState.Skills,
-		playerState.Equipment,
-		playerState.LeftSkill,
-		playerState.RightSkill,
-	)
-
-	for _, connection := range g.connections {
-		err := connection.SendPacketToClient(createPlayerPacket)
-		if err != nil {
-			log.Printf("GameServer: error sending %T to client %s: %s", createPlayerPacket, connection.GetUniqueID(), err)
-		}
-
-		if connection.GetUniqueID() == client.GetUniqueID() {
-			continue
-		}
+// OnClientDisconnected removes the given client from its game and from the
+// list of client connections.
+func (g *GameServer) OnClientDisconnected(client ClientConnection) {
+	log.Printf("Client disconnected with an id of %s", client.GetUniqueID())
+	g.LeaveGame(client)
 
-		conPlayerState := connection.GetPlayerState()
-		playerX := int(conPlayerState.X*subtilesPerTile) + middleOfTileOffset
-		playerY := int(conPlayerState.Y*subtilesPerTile) + middleOfTileOffset
-		err = client.SendPacketToClient(
-			d2netpacket.CreateAddPlayerPacket(
-				connection.GetUniqueID(),
-				conPlayerState.HeroName,
-				playerX,
-				playerY,
-				conPlayerState.HeroType,
-				conPlayerState.Stats,
-				conPlayerState.Skills,
-				conPlayerState.Equipment,
-				conPlayerState.LeftSkill,
-				conPlayerState.RightSkill,
-			),
-		)
+	g.RLock()
+	_, closed := g.accounts[client.GetUniqueID()]
+	g.RUnlock()
 
-		if err != nil {
-			log.Printf("GameServer: error sending CreateAddPlayerPacket to client %s: %s", connection.GetUniqueID(), err)
+	// the characters of closed servers are saved when their players leave
+	if closed {
+		if err := g.savePlayer(client); err != nil {
+			log.Printf("GameServer: error saving the character of client %s: %s", client.GetUniqueID(), err)
 		}
 	}
-}
 
-// OnClientDisconnected removes the given client from the list
-// of client connections.
-func (g *GameServer) OnClientDisconnected(client ClientConnection) {
-	log.Printf("Client disconnected with an id of %s", client.GetUniqueID())
+	g.Lock()
 	delete(g.connections, client.GetUniqueID())
+	delete(g.health, client.GetUniqueID())
+	delete(g.accounts, client.GetUniqueID())
+	delete(g.chat, client.GetUniqueID())
+	g.Unlock()
 }
 
 // OnPacketReceived is called by the local client to 'send' a packet to the server.
-// nolint:gocyclo // switch statement on packet type makes sense, no need to change
+// The lobby packets are handled by the server, all others by the game of the client.
 func (g *GameServer) OnPacketReceived(client ClientConnection, packet d2netpacket.NetPacket) error {
 	if g == nil {
 		return errors.New("game server is nil")
 	}
 
+	if packet.PacketType != d2netpackettype.Pong {
+		g.recordActivity(client)
+	}
+
 	switch packet.PacketType {
-	case d2netpackettype.MovePlayer:
-		movePacket, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+	case d2netpackettype.Pong:
+		pongPacket, err := d2netpacket.UnmarshalPong(packet.PacketData)
 		if err != nil {
 			return err
 		}
 
-		playerState := g.connections[client.GetUniqueID()].GetPlayerState()
-		playerState.X = movePacket.DestX
-		playerState.Y = movePacket.DestY
+		g.handlePong(client, &pongPacket)
+	case d2netpackettype.ListGames:
+		return client.SendPacketToClient(g.createGameListPacket())
+	case d2netpackettype.Chat:
+		chatPacket, err := d2netpacket.UnmarshalChat(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		return g.handleChat(client, &chatPacket)
+	case d2netpackettype.JoinGame:
+		joinPacket, err := d2netpacket.UnmarshalJoinGame(packet.PacketData)
+		if err != nil {
+			return err
+		}
 
-		g.sendPacketToClients(packet)
-	case d2netpackettype.CastSkill, d2netpackettype.SpawnItem:
-		g.sendPacketToClients(packet)
+		return g.handleJoinGame(client, &joinPacket)
+	case d2netpackettype.LeaveGame:
+		g.LeaveGame(client)
 	case d2netpackettype.SavePlayer:
 		savePacket, err := d2netpacket.UnmarshalSavePlayer(packet.PacketData)
 		if err != nil {
 			return err
 		}
 
-		playerState := g.connections[client.GetUniqueID()].GetPlayerState()
-		playerState.LeftSkill = savePacket.LeftSkill
-		playerState.RightSkill = savePacket.RightSkill
-
-		err = g.heroStateFactory.Save(playerState)
-		if err != nil {
-			log.Printf("GameServer: error saving saving Player: %s", err)
+		g.handleSavePlayer(client, &savePacket)
+	case d2netpackettype.SpawnItem:
+		// items are spawned with the debug console, only operators can create them out of thin air
+		if !g.isOperator(client) {
+			return fmt.Errorf("%w: %s", errNotOperator, packet.PacketType)
 		}
+
+		return g.gamePacketReceived(client, packet)
 	default:
-		log.Printf("GameServer: received unknown packet %T", packet)
+		return g.gamePacketReceived(client, packet)
 	}
 
 	return nil
 }
+
+// gamePacketReceived passes a packet of the client on to the game the client is in
+func (g *GameServer) gamePacketReceived(client ClientConnection, packet d2netpacket.NetPacket) error {
+	game := g.clientGame(client)
+	if game == nil {
+		return fmt.Errorf("%w: %s", errNotInGame, packet.PacketType)
+	}
+
+	return game.onPacketReceived(client, packet)
+}
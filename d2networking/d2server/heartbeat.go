@@ -0,0 +1,159 @@
+package d2server
+
+import (
+	"log"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	pingInterval = time.Second
+
+	// deadTimeout is how long a remote client has to answer the pings before it is disconnected
+	deadTimeout = 15 * time.Second
+
+	// idleTimeout is how long a remote client stays connected without sending anything but pongs
+	idleTimeout = 30 * time.Minute
+)
+
+// connectionHealth is the heartbeat of a client connection
+type connectionHealth struct {
+	latency      time.Duration // round trip time of the last ping
+	lastPong     time.Time
+	lastActivity time.Time    // the last packet that was not a pong
+	disconnect   func() error // disconnects a remote client, local clients do not time out
+}
+
+func newConnectionHealth(disconnect func() error) *connectionHealth {
+	now := time.Now()
+
+	return &connectionHealth{lastPong: now, lastActivity: now, disconnect: disconnect}
+}
+
+// Latencies returns the round trip times of the connected clients, by client ID
+func (g *GameServer) Latencies() map[string]time.Duration {
+	g.RLock()
+	defer g.RUnlock()
+
+	latencies := make(map[string]time.Duration, len(g.health))
+
+	for id, health := range g.health {
+		latencies[id] = health.latency
+	}
+
+	return latencies
+}
+
+// heartbeat pings the clients and disconnects the remote clients that timed out, it is meant to be started as
+// a goroutine
+func (g *GameServer) heartbeat() {
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case now := <-ticker.C:
+			g.disconnectTimedOut(now)
+			g.sendPings()
+		}
+	}
+}
+
+func (g *GameServer) disconnectTimedOut(now time.Time) {
+	g.RLock()
+
+	var timedOut []func() error
+
+	for id, health := range g.health {
+		if health.disconnect == nil {
+			continue
+		}
+
+		switch {
+		case now.Sub(health.lastPong) > deadTimeout:
+			log.Printf("GameServer: client %s did not answer the pings", id)
+		case now.Sub(health.lastActivity) > idleTimeout:
+			log.Printf("GameServer: client %s was idle for too long", id)
+		default:
+			continue
+		}
+
+		timedOut = append(timedOut, health.disconnect)
+	}
+
+	g.RUnlock()
+
+	for _, disconnect := range timedOut {
+		if err := disconnect(); err != nil {
+			log.Printf("GameServer: error disconnecting client: %s", err)
+		}
+	}
+}
+
+// sendPings pings all clients. Each ping has the latencies of the players in the game of the client.
+func (g *GameServer) sendPings() {
+	g.RLock()
+
+	clients := make([]ClientConnection, 0, len(g.connections))
+	latencies := make(map[*Game][]d2netpacket.PlayerLatency)
+
+	for id, client := range g.connections {
+		clients = append(clients, client)
+
+		if game := g.clientGames[id]; game != nil {
+			latency := d2netpacket.PlayerLatency{ID: id}
+			if health := g.health[id]; health != nil {
+				latency.Latency = health.latency
+			}
+
+			latencies[game] = append(latencies[game], latency)
+		}
+	}
+
+	pings := make([]d2netpacket.NetPacket, len(clients))
+	for i, client := range clients {
+		pings[i] = d2netpacket.CreatePingPacket(latencies[g.clientGames[client.GetUniqueID()]]...)
+	}
+
+	g.RUnlock()
+
+	// sent without the lock, the local client answers right away
+	for i, client := range clients {
+		if err := client.SendPacketToClient(pings[i]); err != nil {
+			log.Printf("GameServer: error sending ping to client %s: %s", client.GetUniqueID(), err)
+		}
+	}
+}
+
+// handlePong measures the round trip time of the ping that the client answered
+func (g *GameServer) handlePong(client ClientConnection, pong *d2netpacket.PongPacket) {
+	now := time.Now()
+
+	g.Lock()
+	defer g.Unlock()
+
+	health := g.health[client.GetUniqueID()]
+	if health == nil {
+		return
+	}
+
+	health.lastPong = now
+	health.latency = now.Sub(pong.TS)
+
+	if health.latency < 0 {
+		health.latency = 0
+	}
+}
+
+// recordActivity keeps the client from timing out as idle
+func (g *GameServer) recordActivity(client ClientConnection) {
+	g.Lock()
+	defer g.Unlock()
+
+	if health := g.health[client.GetUniqueID()]; health != nil {
+		health.lastActivity = time.Now()
+	}
+}
@@ -0,0 +1,70 @@
+package d2server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2client/d2clientconnectiontype"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+type testClient struct {
+	id string
+}
+
+func (c testClient) GetUniqueID() string {
+	return c.id
+}
+
+func (c testClient) GetConnectionType() d2clientconnectiontype.ClientConnectionType {
+	return d2clientconnectiontype.LANClient
+}
+
+func (c testClient) SendPacketToClient(d2netpacket.NetPacket) error {
+	return nil
+}
+
+func (c testClient) GetPlayerState() *d2hero.HeroState {
+	return nil
+}
+
+func (c testClient) SetPlayerState(*d2hero.HeroState) {}
+
+func TestHeartbeatTimeouts(t *testing.T) {
+	disconnected := make(map[string]bool)
+	disconnect := func(id string) func() error {
+		return func() error {
+			disconnected[id] = true
+			return nil
+		}
+	}
+
+	server := &GameServer{health: map[string]*connectionHealth{
+		"remote": newConnectionHealth(disconnect("remote")),
+		"dead":   newConnectionHealth(disconnect("dead")),
+		"local":  newConnectionHealth(nil),
+	}}
+
+	server.handlePong(testClient{"remote"}, &d2netpacket.PongPacket{TS: time.Now().Add(-50 * time.Millisecond)})
+
+	if latency := server.Latencies()["remote"]; latency < 50*time.Millisecond || latency > time.Second {
+		t.Errorf("unexpected latency %s", latency)
+	}
+
+	now := time.Now().Add(deadTimeout + time.Second)
+	server.health["remote"].lastPong = now
+	server.disconnectTimedOut(now)
+
+	if !disconnected["dead"] || disconnected["remote"] || disconnected["local"] {
+		t.Errorf("expected only the client without pongs to be disconnected, got %v", disconnected)
+	}
+
+	now = now.Add(idleTimeout)
+	server.health["remote"].lastPong = now
+	server.disconnectTimedOut(now)
+
+	if !disconnected["remote"] || disconnected["local"] {
+		t.Errorf("expected the idle client to be disconnected, got %v", disconnected)
+	}
+}
@@ -0,0 +1,158 @@
+package d2server
+
+import (
+	"log"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2combat"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// gameLevel is a level of a game with its own map, monsters and entities. A level is generated when the first
+// player enters it and it is kept until the game is closed, so that the players find it as it was left.
+type gameLevel struct {
+	*d2mapgen.Level
+	mapEngine  *d2mapengine.MapEngine
+	monsters   *d2monai.Controller
+	combatants map[string]*d2combat.Combatant // monsters that can be attacked
+}
+
+// isInTown returns true when the given world position is on a town tile of the map of the level
+func (l *gameLevel) isInTown(x, y float64) bool {
+	tile := l.mapEngine.TileAt(int(x), int(y))
+
+	return tile != nil && tile.RegionType.IsTown()
+}
+
+// generateLevel generates the map of the level with the given ID and populates it with monsters. The clients
+// generate the same map from the seed of the game.
+func (g *Game) generateLevel(id int) (*gameLevel, error) {
+	mapEngine := d2mapengine.CreateMapEngine(g.asset)
+	mapEngine.SetSeed(g.seed)
+
+	mapGen, err := d2mapgen.NewMapGenerator(g.asset, mapEngine)
+	if err != nil {
+		return nil, err
+	}
+
+	generated, err := mapGen.GenerateLevel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	level := &gameLevel{Level: generated, mapEngine: mapEngine}
+	g.populateLevel(level)
+
+	return level, nil
+}
+
+// enterLevel returns the level with the given ID, it is generated when no player entered it before. It is called
+// while the game is locked.
+func (g *Game) enterLevel(id int) (*gameLevel, error) {
+	if level, found := g.levels[id]; found {
+		return level, nil
+	}
+
+	level, err := g.generateLevel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	g.levels[id] = level
+
+	return level, nil
+}
+
+// playerLevel returns the level the player with the given client ID is on. It is called while the game is locked.
+func (g *Game) playerLevel(id string) *gameLevel {
+	return g.levels[g.playerLevels[id]]
+}
+
+// levelClients returns the clients of the players on the level. It is called while the game is locked.
+func (g *Game) levelClients(level *gameLevel) []ClientConnection {
+	clients := make([]ClientConnection, 0, len(g.connections))
+
+	for id, client := range g.connections {
+		if g.playerLevel(id) == level {
+			clients = append(clients, client)
+		}
+	}
+
+	return clients
+}
+
+// sendPacketToLevel sends the packet to the players on the level. It must not be called while the game is locked.
+func (g *Game) sendPacketToLevel(level *gameLevel, packet d2netpacket.NetPacket) {
+	g.RLock()
+	clients := g.levelClients(level)
+	g.RUnlock()
+
+	for _, c := range clients {
+		if err := c.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending packet: %s to client %s: %s", packet.PacketType, c.GetUniqueID(), err)
+		}
+	}
+}
+
+// queueLevelPacket queues the packet for the players on the level until the tick is done. It is called while the
+// game is locked.
+func (g *Game) queueLevelPacket(level *gameLevel, packet d2netpacket.NetPacket) {
+	for _, client := range g.levelClients(level) {
+		g.queuedPackets = append(g.queuedPackets, clientPacket{client, packet})
+	}
+}
+
+// changeLevel takes the player of the client through the warp to the level it leads to, the other players stay
+// where they are. The client generates the level from the seed it got from the server. The client removes the
+// players it knew on the old level, the players of the old level remove the player and the player and the players
+// of the new level are added to each other with the next snapshot. The packets are queued until the tick is done,
+// so that the players and the entities of the new level are replicated after the map. It is called while the game
+// is locked.
+func (g *Game) changeLevel(client ClientConnection, warp *d2mapgen.Warp) error {
+	id := client.GetUniqueID()
+
+	to, err := g.enterLevel(warp.Destination)
+	if err != nil {
+		return err
+	}
+
+	x, y, ok := to.Arrival(warp.LevelID)
+	if !ok {
+		x, y = to.mapEngine.GetStartPosition()
+	}
+
+	if view := g.views[id]; view != nil {
+		known := make([]string, 0, len(view.players))
+		for other := range view.players {
+			known = append(known, other)
+		}
+
+		sort.Strings(known)
+
+		for _, other := range known {
+			g.queuedPackets = append(g.queuedPackets, clientPacket{client, d2netpacket.CreateRemovePlayerPacket(other)})
+		}
+	}
+
+	g.playerLevels[id] = warp.Destination
+
+	playerState := client.GetPlayerState()
+	playerState.X = x
+	playerState.Y = y
+
+	regionType := d2enum.RegionIdType(to.mapEngine.LevelType().ID)
+	g.queuedPackets = append(g.queuedPackets,
+		clientPacket{client, d2netpacket.CreateGenerateMapPacket(regionType, warp.Destination)},
+		clientPacket{client, g.placePlayer(id, x, y)})
+
+	// the client forgets all players and entities with the old map
+	g.views[id] = newClientView()
+
+	log.Printf("GameServer: client %s warped from level %d to level %d", id, warp.LevelID, warp.Destination)
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package d2server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// testWarpGame creates a game with two levels, the warp in the middle of the first one leads to the second one.
+// Both clients are on the first level and know each other.
+func testWarpGame(walker, stayer *moveClient, now time.Time) *Game {
+	game := testMoveGame(walker, now)
+	game.levels[1] = testLevel(1, d2mapgen.Warp{LevelID: 1, Destination: 0, TileX: 1, TileY: 1})
+	game.levels[0].Warps = []d2mapgen.Warp{{LevelID: 0, Destination: 1, TileX: 4, TileY: 4}}
+
+	game.connections[stayer.id] = stayer
+	game.playerLevels[stayer.id] = 0
+	game.movements[stayer.id] = newPlayerMovement(testStartX*subtilesPerTile, testStartY*subtilesPerTile)
+
+	for _, pair := range [][2]string{{walker.id, stayer.id}, {stayer.id, walker.id}} {
+		game.views[pair[0]] = newClientView()
+		game.views[pair[0]].players[pair[1]] = true
+	}
+
+	return game
+}
+
+func walkTo(t *testing.T, game *Game, client *moveClient, x, y float64, now time.Time) {
+	move := d2netpacket.MovePlayerPacket{PlayerID: client.id, Sequence: 1, StartX: testStartX, StartY: testStartY,
+		DestX: x, DestY: y}
+
+	if err := game.movePlayer(client, &move, now); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// queuedPacketTypes returns the types of the packets queued for the client
+func queuedPacketTypes(game *Game, client ClientConnection) []d2netpackettype.NetPacketType {
+	types := make([]d2netpackettype.NetPacketType, 0)
+
+	for _, queued := range game.queuedPackets {
+		if queued.client == client {
+			types = append(types, queued.packet.PacketType)
+		}
+	}
+
+	return types
+}
+
+func TestWarpOnArrival(t *testing.T) {
+	walker, stayer := newMoveClient("walker", 0), newMoveClient("stayer", 0)
+	start := time.Now()
+	game := testWarpGame(walker, stayer, start)
+
+	walkTo(t, game, walker, 4.5, 4.5, start)
+
+	if game.playerLevels[walker.id] != 0 {
+		t.Fatal("the player took the warp before it got there")
+	}
+
+	game.advancePlayers(start.Add(100 * time.Millisecond))
+
+	if game.playerLevels[walker.id] != 0 {
+		t.Fatal("the player took the warp on its way there")
+	}
+
+	game.advancePlayers(start.Add(10 * time.Second))
+
+	if game.playerLevels[walker.id] != 1 || game.playerLevels[stayer.id] != 0 {
+		t.Fatalf("expected only the walker to take the warp, the players are on the levels %v", game.playerLevels)
+	}
+
+	if walker.state.X != 1.5 || walker.state.Y != 1.5 {
+		t.Errorf("expected the player to arrive at the warp back, it is at %f,%f", walker.state.X, walker.state.Y)
+	}
+
+	expected := []d2netpackettype.NetPacketType{d2netpackettype.RemovePlayer, d2netpackettype.GenerateMap,
+		d2netpackettype.SetPlayerPosition}
+	if types := queuedPacketTypes(game, walker); len(types) != len(expected) {
+		t.Errorf("expected the packets %v for the walker, got %v", expected, types)
+	} else {
+		for idx := range expected {
+			if types[idx] != expected[idx] {
+				t.Errorf("expected the packets %v for the walker, got %v", expected, types)
+				break
+			}
+		}
+	}
+
+	if types := queuedPacketTypes(game, stayer); len(types) != 0 {
+		t.Errorf("expected no packets for the other client before the snapshot, got %v", types)
+	}
+
+	// the other client removes the walker with the next snapshot, the walker is alone on its new level
+	game.queuedPackets = game.replicate(1)
+
+	if types := queuedPacketTypes(game, stayer); len(types) != 1 || types[0] != d2netpackettype.RemovePlayer {
+		t.Errorf("expected the walker to be removed from the other client, got %v", types)
+	}
+
+	if types := queuedPacketTypes(game, walker); len(types) != 0 {
+		t.Errorf("expected no packets for the walker, got %v", types)
+	}
+}
+
+func TestWalkPastWarp(t *testing.T) {
+	walker, stayer := newMoveClient("walker", 0), newMoveClient("stayer", 0)
+	start := time.Now()
+	game := testWarpGame(walker, stayer, start)
+
+	// the path leads over the warp, but ends out of its reach
+	walkTo(t, game, walker, testMapSize-0.5, 4.5, start)
+
+	for tick := 1; tick <= 100; tick++ {
+		game.advancePlayers(start.Add(time.Duration(tick) * 100 * time.Millisecond))
+	}
+
+	if game.playerLevels[walker.id] != 0 || len(game.queuedPackets) != 0 {
+		t.Errorf("the player took the warp it walked past")
+	}
+
+	if walker.state.X < testMapSize-1 {
+		t.Errorf("expected the player to walk to the end of the map, it is at %f", walker.state.X)
+	}
+}
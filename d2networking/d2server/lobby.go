@@ -0,0 +1,293 @@
+package d2server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	maxGameNameLength = 15 // the same limit as the game names of battle.net
+	maxGamePlayers    = 8
+
+	// DefaultMaxGames is the number of games a server can have, unless it is changed with SetMaxGames
+	DefaultMaxGames = 32
+
+	// DefaultGameName is the name of the default game of dedicated servers
+	DefaultGameName = "OpenDiablo2"
+)
+
+var (
+	errGameName      = errors.New("invalid game name")
+	errGameExists    = errors.New("game already exists")
+	errGameNotFound  = errors.New("game does not exist")
+	errGamePassword  = errors.New("wrong password")
+	errGameNoDefault = errors.New("no game name given")
+	errDifficulty    = errors.New("invalid difficulty")
+	errTooManyGames  = errors.New("too many games")
+)
+
+// SetMaxGames sets the number of games the server can have, the games that exist are kept. 0 removes the limit.
+func (g *GameServer) SetMaxGames(maxGames int) {
+	g.Lock()
+	defer g.Unlock()
+
+	g.maxGames = maxGames
+}
+
+// CreateGame creates a new game and starts simulating it. The first game that is created becomes the
+// default game of the server, which clients join when they do not give a game name.
+func (g *GameServer) CreateGame(options *GameOptions) (*Game, error) {
+	if options.Name == "" || len(options.Name) > maxGameNameLength {
+		return nil, fmt.Errorf("%w: %q", errGameName, options.Name)
+	}
+
+	if options.Difficulty < d2enum.DifficultyNormal || options.Difficulty > d2enum.DifficultyHell {
+		return nil, fmt.Errorf("%w: %d", errDifficulty, options.Difficulty)
+	}
+
+	gameOptions := *options
+	gameOptions.MaxPlayers = d2math.ClampInt(options.MaxPlayers, 1, maxGamePlayers)
+
+	if gameOptions.Seed == 0 {
+		gameOptions.Seed = time.Now().UnixNano()
+	}
+
+	if g.findGame(gameOptions.Name) != nil {
+		return nil, fmt.Errorf("%w: %s", errGameExists, gameOptions.Name)
+	}
+
+	g.RLock()
+	full := g.tooManyGames()
+	g.RUnlock()
+
+	if full {
+		return nil, errTooManyGames
+	}
+
+	// the map is generated before the server is locked, it takes a while
+	game, err := newGame(g.ctx, g.asset, g.statFactory, &gameOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	g.Lock()
+
+	if _, found := g.games[game.name]; found {
+		g.Unlock()
+		game.stop()
+
+		return nil, fmt.Errorf("%w: %s", errGameExists, game.name)
+	}
+
+	if g.tooManyGames() {
+		g.Unlock()
+		game.stop()
+
+		return nil, errTooManyGames
+	}
+
+	g.games[game.name] = game
+
+	if g.defaultGame == "" {
+		g.defaultGame = game.name
+	}
+
+	g.Unlock()
+
+	game.start()
+
+	log.Printf("GameServer: created game %q with seed %d", game.name, game.seed)
+
+	return game, nil
+}
+
+// JoinGame moves the client from the lobby or its current game to the game with the given name. An empty
+// name joins the default game of the server.
+func (g *GameServer) JoinGame(client ClientConnection, name, password string) error {
+	return g.joinGame(client, name, password, false)
+}
+
+// joinGame joins a game, when sendResult is set the client is sent a JoinGameResultPacket before the
+// packets of the game
+func (g *GameServer) joinGame(client ClientConnection, name, password string, sendResult bool) error {
+	game := g.findGame(name)
+
+	switch {
+	case name == "" && game == nil:
+		return errGameNoDefault
+	case game == nil:
+		return fmt.Errorf("%w: %s", errGameNotFound, name)
+	case game.password != password:
+		return errGamePassword
+	case g.clientGame(client) == game:
+		return nil
+	}
+
+	g.LeaveGame(client)
+
+	if err := game.join(client, packetEncoding(client), sendResult); err != nil {
+		return err
+	}
+
+	g.Lock()
+	g.clientGames[client.GetUniqueID()] = game
+
+	party := ""
+	if state := g.chat[client.GetUniqueID()]; state != nil {
+		party = state.party
+	}
+
+	g.Unlock()
+
+	game.setParty(client.GetUniqueID(), party)
+
+	return nil
+}
+
+// LeaveGame moves the client back to the lobby. The game is closed when it is empty.
+func (g *GameServer) LeaveGame(client ClientConnection) {
+	g.Lock()
+
+	game := g.clientGames[client.GetUniqueID()]
+	delete(g.clientGames, client.GetUniqueID())
+	g.Unlock()
+
+	if game == nil || !game.leave(client) {
+		return
+	}
+
+	g.closeGame(game)
+}
+
+// closeGame closes the game when it is empty and not persistent. The clients that joined the game in the meantime keep it open,
+// the clients that join it after it was closed are told that it does not exist.
+func (g *GameServer) closeGame(game *Game) {
+	g.Lock()
+	defer g.Unlock()
+
+	if !game.stopIfEmpty() {
+		return
+	}
+
+	if g.games[game.name] == game {
+		delete(g.games, game.name)
+	}
+
+	if g.defaultGame == game.name {
+		g.defaultGame = ""
+	}
+
+	log.Printf("GameServer: closed game %q", game.name)
+}
+
+// Games returns the games of the server, sorted by name
+func (g *GameServer) Games() []d2netpacket.GameInfo {
+	g.RLock()
+	defer g.RUnlock()
+
+	games := make([]d2netpacket.GameInfo, 0, len(g.games))
+
+	for _, game := range g.games {
+		games = append(games, game.Info())
+	}
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].Name < games[j].Name
+	})
+
+	return games
+}
+
+func (g *GameServer) createGameListPacket() d2netpacket.NetPacket {
+	return d2netpacket.CreateGameListPacket(g.Games())
+}
+
+// handleJoinGame creates or joins the game of a JoinGamePacket. The client is told why when it can not join.
+func (g *GameServer) handleJoinGame(client ClientConnection, packet *d2netpacket.JoinGamePacket) error {
+	var err error
+
+	if packet.Create {
+		_, err = g.CreateGame(&GameOptions{
+			Name:       packet.Name,
+			Password:   packet.Password,
+			Difficulty: packet.Difficulty,
+			MaxPlayers: packet.MaxPlayers,
+			Seed:       packet.Seed,
+		})
+	}
+
+	if err == nil {
+		err = g.joinGame(client, packet.Name, packet.Password, true)
+	}
+
+	if err != nil {
+		return client.SendPacketToClient(d2netpacket.CreateJoinGameResultPacket(packet.Name, false, err.Error()))
+	}
+
+	return nil
+}
+
+// findGame returns the game with the given name, or the default game for an empty name
+func (g *GameServer) findGame(name string) *Game {
+	g.RLock()
+	defer g.RUnlock()
+
+	if name == "" {
+		name = g.defaultGame
+	}
+
+	return g.games[name]
+}
+
+// tooManyGames returns true when no more games can be created. It is called while the server is locked.
+func (g *GameServer) tooManyGames() bool {
+	return g.maxGames > 0 && len(g.games) >= g.maxGames
+}
+
+// clientGame returns the game the client is in, or nil when it is in the lobby
+func (g *GameServer) clientGame(client ClientConnection) *Game {
+	g.RLock()
+	defer g.RUnlock()
+
+	return g.clientGames[client.GetUniqueID()]
+}
+
+// mapEngines returns the map engines of all games
+func (g *GameServer) mapEngines() []*d2mapengine.MapEngine {
+	g.RLock()
+	defer g.RUnlock()
+
+	mapEngines := make([]*d2mapengine.MapEngine, 0, len(g.games))
+
+	for _, game := range g.games {
+		game.RLock()
+
+		for _, level := range game.levels {
+			mapEngines = append(mapEngines, level.mapEngine)
+		}
+
+		game.RUnlock()
+	}
+
+	return mapEngines
+}
+
+// packetEncoding returns the packet encoding of a remote client. Local clients receive the packets without
+// serializing them, so the encoding does not matter for them.
+func packetEncoding(client ClientConnection) d2netpacket.PacketEncoding {
+	if encoder, ok := client.(interface {
+		GetPacketEncoding() d2netpacket.PacketEncoding
+	}); ok {
+		return encoder.GetPacketEncoding()
+	}
+
+	return d2netpacket.BinaryEncoding
+}
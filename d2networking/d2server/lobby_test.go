@@ -0,0 +1,358 @@
+package d2server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// testLobbyServer creates a server with a game on an open map, which is the default game. The game is not
+// simulated, generating a real map needs the assets.
+func testLobbyServer(password string, maxPlayers int) (*GameServer, *Game) {
+	ctx, cancel := context.WithCancel(context.Background())
+	game := &Game{
+		name:         "cows",
+		password:     password,
+		maxPlayers:   maxPlayers,
+		ctx:          ctx,
+		cancel:       cancel,
+		connections:  make(map[string]ClientConnection),
+		levels:       map[int]*gameLevel{0: testLevel(0)},
+		playerLevels: make(map[string]int),
+		parties:      make(map[string]string),
+		movements:    make(map[string]*playerMovement),
+		views:        make(map[string]*clientView),
+	}
+
+	server := &GameServer{
+		connections: make(map[string]ClientConnection),
+		games:       map[string]*Game{game.name: game},
+		clientGames: make(map[string]*Game),
+		defaultGame: game.name,
+		chat:        make(map[string]*chatState),
+		ctx:         context.Background(),
+	}
+
+	return server, game
+}
+
+func countPackets(client *moveClient, packetType d2netpackettype.NetPacketType) int {
+	client.Lock()
+	defer client.Unlock()
+
+	count := 0
+
+	for _, received := range client.packets {
+		if received == packetType {
+			count++
+		}
+	}
+
+	return count
+}
+
+func TestCreateGameErrors(t *testing.T) {
+	server, _ := testLobbyServer("", maxGamePlayers)
+
+	tests := []struct {
+		options  GameOptions
+		expected error
+	}{
+		{GameOptions{Name: ""}, errGameName},
+		{GameOptions{Name: "a game name that is too long"}, errGameName},
+		{GameOptions{Name: "hell", Difficulty: d2enum.DifficultyHell + 1}, errDifficulty},
+		{GameOptions{Name: "cows"}, errGameExists},
+	}
+
+	for _, test := range tests {
+		test := test
+		if _, err := server.CreateGame(&test.options); !errors.Is(err, test.expected) {
+			t.Errorf("%+v: expected %q, got %v", test.options, test.expected, err)
+		}
+	}
+
+	server.SetMaxGames(1)
+
+	if _, err := server.CreateGame(&GameOptions{Name: "baal"}); !errors.Is(err, errTooManyGames) {
+		t.Errorf("expected %q, got %v", errTooManyGames, err)
+	}
+}
+
+func TestJoinAndLeaveGame(t *testing.T) {
+	server, game := testLobbyServer("moo", 2)
+	kashya, akara, charsi := newMoveClient("kashya", 0), newMoveClient("akara", 0), newMoveClient("charsi", 0)
+
+	if err := server.JoinGame(kashya, "cows", "baa"); !errors.Is(err, errGamePassword) {
+		t.Errorf("expected %q, got %v", errGamePassword, err)
+	}
+
+	if err := server.JoinGame(kashya, "pigs", "moo"); !errors.Is(err, errGameNotFound) {
+		t.Errorf("expected %q, got %v", errGameNotFound, err)
+	}
+
+	for _, client := range []*moveClient{kashya, akara} {
+		if err := server.JoinGame(client, "", "moo"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := server.JoinGame(charsi, "cows", "moo"); !errors.Is(err, errGameFull) {
+		t.Errorf("expected %q, got %v", errGameFull, err)
+	}
+
+	if server.clientGame(kashya) != game || server.clientGame(charsi) != nil {
+		t.Error("the clients are not in the games they joined")
+	}
+
+	if info := game.Info(); info.Players != 2 || info.MaxPlayers != 2 || !info.Password {
+		t.Errorf("unexpected game info %+v", info)
+	}
+
+	// the players are added to each other with the next snapshot, the game is not simulated
+	game.Lock()
+	snapshots := game.replicate(1)
+	game.Unlock()
+
+	sendClientPackets(snapshots)
+
+	// each client gets its own player when it joins and the other player with the snapshot
+	if added := countPackets(kashya, d2netpackettype.AddPlayer); added != 2 {
+		t.Errorf("expected 2 players to be added to the first client, got %d", added)
+	}
+
+	if added := countPackets(akara, d2netpackettype.AddPlayer); added != 2 {
+		t.Errorf("expected 2 players to be added to the second client, got %d", added)
+	}
+
+	server.LeaveGame(kashya)
+
+	if server.findGame("cows") != game || game.ctx.Err() != nil {
+		t.Fatal("the game was closed while a player is in it")
+	}
+
+	if _, found := game.movements[kashya.id]; found {
+		t.Error("the player that left is still simulated")
+	}
+
+	if len(game.queuedPackets) != 1 || game.queuedPackets[0].client != akara ||
+		game.queuedPackets[0].packet.PacketType != d2netpackettype.RemovePlayer {
+		t.Errorf("expected the player that left to be removed from the other client, got %+v", game.queuedPackets)
+	}
+
+	server.LeaveGame(akara)
+
+	if server.findGame("cows") != nil || server.defaultGame != "" || game.ctx.Err() == nil {
+		t.Error("the empty game was not closed")
+	}
+
+	if err := server.JoinGame(kashya, "", ""); !errors.Is(err, errGameNoDefault) {
+		t.Errorf("expected %q, got %v", errGameNoDefault, err)
+	}
+}
+
+// TestJoinWhileLeaving joins the game while its last player leaves it, which must not close the game
+func TestJoinWhileLeaving(t *testing.T) {
+	server, game := testLobbyServer("", maxGamePlayers)
+	kashya, akara := newMoveClient("kashya", 0), newMoveClient("akara", 0)
+
+	if err := server.JoinGame(kashya, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// the last player left, and another client joins before the game is closed
+	if !game.leave(kashya) {
+		t.Fatal("the game is not empty")
+	}
+
+	if err := game.join(akara, d2netpacket.BinaryEncoding, false); err != nil {
+		t.Fatal(err)
+	}
+
+	server.closeGame(game)
+
+	if server.findGame("cows") != game || game.ctx.Err() != nil {
+		t.Fatal("the game was closed while a player is in it")
+	}
+
+	game.leave(akara)
+	server.closeGame(game)
+
+	if err := game.join(kashya, d2netpacket.BinaryEncoding, false); !errors.Is(err, errGameNotFound) {
+		t.Errorf("expected %q for a closed game, got %v", errGameNotFound, err)
+	}
+}
+
+func TestPersistentGame(t *testing.T) {
+	server, game := testLobbyServer("", maxGamePlayers)
+	game.persistent = true
+	kashya := newMoveClient("kashya", 0)
+
+	if err := server.JoinGame(kashya, "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	server.LeaveGame(kashya)
+
+	if server.findGame("") != game || game.ctx.Err() != nil {
+		t.Fatal("the persistent default game was closed")
+	}
+
+	if err := server.JoinGame(kashya, "", ""); err != nil {
+		t.Errorf("the default game can not be joined again: %v", err)
+	}
+}
+
+// TestConcurrentJoinAndBroadcast joins and leaves the game while packets are sent to all of its players, which
+// the race detector checks
+func TestConcurrentJoinAndBroadcast(t *testing.T) {
+	server, game := testLobbyServer("", maxGamePlayers)
+
+	// a player stays in the game, so that it is not closed
+	if err := server.JoinGame(newMoveClient("warriv", 0), "", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for idx := 0; idx < maxGamePlayers-1; idx++ {
+		client := newMoveClient(fmt.Sprintf("player%d", idx), 0)
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for round := 0; round < 20; round++ {
+				if err := server.JoinGame(client, "", ""); err != nil {
+					t.Error(err)
+					return
+				}
+
+				server.LeaveGame(client)
+			}
+		}()
+	}
+
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for round := 0; round < 200; round++ {
+			game.sendPacketToClients(d2netpacket.CreatePingPacket())
+		}
+	}()
+
+	wg.Wait()
+
+	if players := game.Info().Players; players != 1 {
+		t.Errorf("expected 1 player to be left in the game, got %d", players)
+	}
+}
+
+func TestSpawnItemOperatorOnly(t *testing.T) {
+	server, _ := testLobbyServer("", maxGamePlayers)
+	server.accounts = make(map[string]string)
+	kashya := newMoveClient("kashya", 0)
+
+	if err := server.JoinGame(kashya, "cows", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	spawn := d2netpacket.CreateSpawnItemPacket(1, 1, "hax")
+
+	if err := server.OnPacketReceived(kashya, spawn); !errors.Is(err, errNotOperator) {
+		t.Errorf("expected %q, got %v", errNotOperator, err)
+	}
+
+	server.accounts[kashya.id] = "kashya"
+	server.SetOperators("kashya")
+
+	if err := server.OnPacketReceived(kashya, spawn); err != nil {
+		t.Errorf("an operator could not spawn an item: %s", err)
+	}
+}
+
+// countingAuthenticator refuses all credentials and counts how often it was asked
+type countingAuthenticator struct {
+	calls int
+}
+
+func (a *countingAuthenticator) Authenticate(*d2netpacket.Credentials) error {
+	a.calls++
+	return errors.New("refused")
+}
+
+func TestRegisterConnectionErrors(t *testing.T) {
+	server, _ := testLobbyServer("", maxGamePlayers)
+	authenticator := &countingAuthenticator{}
+	server.SetClosed(authenticator, nil)
+	server.maxConnections = 1
+
+	request := d2netpacket.CreatePlayerConnectionRequestPacket("akara", &d2hero.HeroState{HeroName: "Akara"},
+		d2netpacket.Credentials{Account: "akara", Password: "secret"}, d2netpacket.JSONEncoding)
+
+	if _, err := server.registerConnection([]byte("{"), nil); err == nil {
+		t.Error("expected an error for a request that can not be unmarshaled")
+	}
+
+	_, err := server.registerConnection(request.PacketData, nil)
+	if !errors.Is(err, errAuthentication) {
+		t.Errorf("expected %q, got %v", errAuthentication, err)
+	}
+
+	// the client is not told why, which would tell whether the account exists
+	if reason := refusalReason(err); reason != errAuthentication.Error() {
+		t.Errorf("expected the client to be told %q, got %q", errAuthentication, reason)
+	}
+
+	server.connections["kashya"] = newMoveClient("kashya", 0)
+
+	if _, err := server.registerConnection(request.PacketData, nil); !errors.Is(err, errServerFull) {
+		t.Errorf("expected %q, got %v", errServerFull, err)
+	}
+
+	if authenticator.calls != 1 {
+		t.Errorf("expected a full server not to authenticate, the authenticator was asked %d times", authenticator.calls)
+	}
+}
+
+// fullCharacterStore is the character store of an account that has the most characters an account can have
+type fullCharacterStore struct {
+	saved int
+}
+
+func (s *fullCharacterStore) LoadCharacter(_, name string) (*d2hero.HeroState, error) {
+	return nil, fmt.Errorf("%w: %s", ErrCharacterNotFound, name)
+}
+
+func (s *fullCharacterStore) SaveCharacter(string, *d2hero.HeroState) error {
+	s.saved++
+	return nil
+}
+
+func (s *fullCharacterStore) CharacterCount(string) (int, error) {
+	return maxAccountCharacters, nil
+}
+
+func TestCreateCharacterLimit(t *testing.T) {
+	server, _ := testLobbyServer("", maxGamePlayers)
+	store := &fullCharacterStore{}
+	server.SetClosed(nil, store)
+
+	_, err := server.createCharacter("akara", "Akara", &d2hero.HeroState{HeroType: d2enum.HeroSorceress})
+	if !errors.Is(err, errTooManyCharacters) {
+		t.Errorf("expected %q, got %v", errTooManyCharacters, err)
+	}
+
+	if store.saved != 0 {
+		t.Error("a character was created for an account with too many characters")
+	}
+}
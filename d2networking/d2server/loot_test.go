@@ -0,0 +1,251 @@
+package d2server
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2dc6"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2txt"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2resource"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+)
+
+// lootTestdata are the item records the drop simulations of diablo2item are rolled with
+const lootTestdata = "../../d2core/d2item/diablo2item/testdata"
+
+// lootRecordPaths are the records the loot of the tests is rolled with
+// nolint:gochecknoglobals // just a test
+var lootRecordPaths = []string{
+	d2resource.Weapons, d2resource.Armor, d2resource.Misc, d2resource.ItemTypes,
+	d2resource.MagicPrefix, d2resource.MagicSuffix, d2resource.RarePrefix, d2resource.RareSuffix,
+	d2resource.UniqueItems, d2resource.Sets, d2resource.SetItems, d2resource.TreasureClassEx,
+	d2resource.MonStats,
+}
+
+// testMonster is a monster that stands still
+type testMonster struct {
+	id       string
+	position d2vector.Position
+	monStats *d2records.MonStatsRecord
+}
+
+func (m *testMonster) ID() string {
+	return m.id
+}
+
+func (m *testMonster) GetPosition() d2vector.Position {
+	return m.position
+}
+
+func (m *testMonster) SetPath(_ []d2vector.Position, _ func()) {}
+
+func (m *testMonster) StopMoving() {}
+
+func (m *testMonster) MonStats() *d2records.MonStatsRecord {
+	return m.monStats
+}
+
+// testLootAsset loads the item records of the drop simulations, the graphics of all items are a single pixel in
+// the given directory
+func testLootAsset(t *testing.T, dir string) *d2asset.AssetManager {
+	asset, err := d2asset.NewAssetManager()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	asset.SetLogLevel(d2util.LogLevelNone)
+
+	records, err := d2records.NewRecordManager(d2util.LogLevelNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, recordPath := range lootRecordPaths {
+		data, err := ioutil.ReadFile(filepath.Join(lootTestdata, path.Base(recordPath)))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := records.Load(recordPath, d2txt.LoadDataDictionary(data)); err != nil {
+			t.Fatalf("loading %s: %s", recordPath, err)
+		}
+	}
+
+	// the map engine needs the default equipment of the heroes, which the rolls do not know of
+	for _, code := range []string{"wnd", "ssd", "ktr", "sst", "jav", "clb"} {
+		if records.Item.Weapons[code] == nil {
+			records.Item.Weapons[code] = &d2records.ItemCommonRecord{Code: code}
+		}
+	}
+
+	if records.Item.Armors["buc"] == nil {
+		records.Item.Armors["buc"] = &d2records.ItemCommonRecord{Code: "buc"}
+	}
+
+	records.Level.Types = d2records.LevelTypes{{}}
+	asset.Records = records
+
+	pixel := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black, color.White})
+	pixel.Pix[0] = 1
+
+	dc6, err := d2dc6.Encode([][]*image.Paletted{{pixel}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{d2resource.PaletteUnits: make([]byte, 256*3)}
+
+	for _, record := range records.Item.All {
+		files[path.Join(d2resource.ItemGraphics, record.FlippyFile+".DC6")] = dc6.Marshal()
+	}
+
+	for name, data := range files {
+		name = filepath.Join(dir, filepath.FromSlash(name))
+
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(name, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := asset.Loader.AddSource(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	return asset
+}
+
+// testLootGame creates a game on a level with the item records of the drop simulations
+func testLootGame(t *testing.T, dir string, seed int64) *Game {
+	asset := testLootAsset(t, dir)
+
+	itemFactory, err := diablo2item.NewItemFactory(asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	itemFactory.SetSeed(seed)
+
+	mapEngine := d2mapengine.CreateMapEngine(asset)
+	mapEngine.ResetMap(0, testMapSize, testMapSize)
+
+	return &Game{
+		asset:        asset,
+		itemFactory:  itemFactory,
+		progression:  d2hero.NewProgression(asset.Records),
+		difficulty:   d2enum.DifficultyNormal,
+		connections:  make(map[string]ClientConnection),
+		playerLevels: make(map[string]int),
+		levels: map[int]*gameLevel{0: {
+			Level:     testLevel(0).Level,
+			mapEngine: mapEngine,
+			monsters:  d2monai.NewController(nil, asset.Records, d2enum.DifficultyNormal, 1),
+		}},
+	}
+}
+
+// droppedItems returns the items that lie on the map of the level
+func droppedItems(level *gameLevel) []*d2mapentity.Item {
+	items := make([]*d2mapentity.Item, 0)
+
+	for _, entity := range level.mapEngine.Entities() {
+		if item, ok := entity.(*d2mapentity.Item); ok {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func TestKillMonsterDropsRolledItems(t *testing.T) {
+	dir, err := ioutil.TempDir("", "d2server")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	const seed = 3
+
+	game := testLootGame(t, dir, seed)
+	level := game.levels[0]
+	killer := newChatClient("killer", "killer")
+	// the items drop at the level of the monster
+	const monsterLevel = 30
+
+	zombie := *game.asset.Records.Monster.Stats["zombie1"]
+	zombie.LevelNormal = monsterLevel
+
+	// the same rolls with a factory of the same seed
+	expected, err := diablo2item.NewItemFactory(game.asset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected.SetSeed(seed)
+	treasureClass := expected.TreasureClass(zombie.TreasureClassNormal)
+
+	for kill := 0; kill < 100; kill++ {
+		monster := &testMonster{id: "zombie", position: d2vector.NewPositionTile(10, 10), monStats: &zombie}
+		game.killMonster(level, killer, monster)
+
+		rolled := expected.ItemsFromTreasureClass(treasureClass, monsterLevel)
+		dropped := droppedItems(level)
+
+		if len(dropped) != len(rolled) {
+			t.Fatalf("kill %d: expected %d items, %d dropped", kill, len(rolled), len(dropped))
+		}
+
+		magic := false
+
+		for _, item := range dropped {
+			level.mapEngine.RemoveEntity(item)
+
+			found := false
+
+			for _, roll := range rolled {
+				found = found || bytes.Equal(roll.Serialize(), item.Item.Serialize())
+			}
+
+			if !found {
+				t.Fatalf("kill %d: the dropped %s is not one of the rolled items", kill, item.Item.GetItemCode())
+			}
+
+			if item.Item.ItemLevel() != monsterLevel {
+				t.Fatalf("kill %d: the dropped %s has item level %d, expected %d", kill, item.Item.GetItemCode(),
+					item.Item.ItemLevel(), monsterLevel)
+			}
+
+			state, _ := entityState(item)
+			if !bytes.Equal(state.Item, item.Item.Serialize()) {
+				t.Fatalf("kill %d: the dropped %s is not replicated with its rolls", kill, item.Item.GetItemCode())
+			}
+
+			magic = magic || item.Item.Quality() > d2enum.Normal
+		}
+
+		if magic {
+			return
+		}
+	}
+
+	t.Error("no magic item dropped in 100 kills")
+}
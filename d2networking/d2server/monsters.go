@@ -0,0 +1,104 @@
+package d2server
+
+import (
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+)
+
+// playerTarget is a player as seen by the monster AI, the server does not keep map entities for players
+type playerTarget struct {
+	id       string
+	position d2vector.Position
+	inTown   bool
+}
+
+func (p *playerTarget) ID() string {
+	return p.id
+}
+
+func (p *playerTarget) GetPosition() d2vector.Position {
+	return p.position
+}
+
+func (p *playerTarget) IsInTown() bool {
+	return p.inTown
+}
+
+// populateLevel gives the monsters of the level an AI controller. The controller is seeded from the game seed
+// and the level, so that a level plays out the same for the same seed.
+func (g *Game) populateLevel(level *gameLevel) {
+	level.monsters = d2monai.NewController(level.mapEngine, g.asset.Records, g.difficulty, g.seed+int64(level.ID))
+	level.monsters.SetTargetSource(func() []d2monai.Target {
+		return g.playerTargets(level)
+	})
+	level.monsters.SetStateListener(func(change d2monai.StateChange) {
+		g.onMonsterStateChange(level, change)
+	})
+	level.monsters.Populate()
+	g.addMonsterCombatants(level)
+}
+
+// playerTargets returns the players on the level the monsters can attack, where the server walked them to
+func (g *Game) playerTargets(level *gameLevel) []d2monai.Target {
+	targets := make([]d2monai.Target, 0, len(g.connections))
+
+	for _, connection := range g.levelClients(level) {
+		playerState := connection.GetPlayerState()
+		if playerState.Stats != nil && playerState.Stats.Health <= 0 {
+			continue
+		}
+
+		position := d2vector.NewPositionTile(playerState.X, playerState.Y)
+		tile := level.mapEngine.TileAt(int(playerState.X), int(playerState.Y))
+
+		targets = append(targets, &playerTarget{
+			id:       connection.GetUniqueID(),
+			position: position,
+			inTown:   tile == nil || tile.RegionType.IsTown(),
+		})
+	}
+
+	return targets
+}
+
+// simulate is meant to be started as a Goroutine, it advances the players, the monsters and the map entities of
+// all levels at the tick rate of the monster AI and replicates the entities to the clients, until the game is
+// closed or the server is stopped.
+func (g *Game) simulate() {
+	ticker := time.NewTicker(time.Second / d2monai.TicksPerSecond)
+	defer ticker.Stop()
+
+	last := time.Now()
+
+	for {
+		select {
+		case <-g.ctx.Done():
+			return
+		case now := <-ticker.C:
+			elapsed := now.Sub(last).Seconds()
+			last = now
+
+			g.Lock()
+			g.advancePlayers(now)
+
+			for _, level := range g.levels {
+				level.monsters.Advance(elapsed)
+				level.mapEngine.Advance(elapsed)
+			}
+
+			packets, queued := g.pendingPackets, g.queuedPackets
+			g.pendingPackets, g.queuedPackets = nil, nil
+			snapshots := g.replicate(elapsed)
+			g.Unlock()
+
+			for _, packet := range packets {
+				g.sendPacketToClients(packet)
+			}
+
+			sendClientPackets(queued)
+			sendClientPackets(snapshots)
+		}
+	}
+}
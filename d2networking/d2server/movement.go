@@ -0,0 +1,281 @@
+package d2server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// moveDistanceSlack is the distance in sub tiles the start of a move can be away from the server position, to
+	// absorb the network jitter between the client and the server. It does not add up over moves, the path of a
+	// move always starts at the server position.
+	moveDistanceSlack = 2.5
+	// staminaDrainDivisor scales the run drain of the class to stamina per second, like the client does
+	staminaDrainDivisor = 5
+)
+
+var (
+	errMoveUnknownPlayer = errors.New("move from unknown player")
+	errMoveOutOfBounds   = errors.New("move outside of the map")
+	errMoveTooFast       = errors.New("move exceeds the maximum player speed")
+	errMoveSequence      = errors.New("move is older than the last move of the player")
+)
+
+// playerMovement is a player as simulated by the server. The player walks along its path at the speed the server
+// allows, so its position is where the server knows it to be, whatever its client claims.
+type playerMovement struct {
+	position     d2vector.Position   // in sub tiles
+	path         []d2vector.Position // the rest of the path the player walks along, in sub tiles
+	running      bool                // the player wants to run, it only runs while it has stamina
+	stamina      float64
+	maxStamina   float64
+	staminaDrain float64 // stamina per second running drains and walking or standing regenerates
+	timestamp    time.Time
+	sequence     uint32 // sequence number of the last move of the player that was processed
+}
+
+func newPlayerMovement(subTileX, subTileY float64) *playerMovement {
+	return &playerMovement{
+		position:  d2vector.NewPosition(subTileX, subTileY),
+		timestamp: time.Now(),
+	}
+}
+
+// setStamina takes the stamina of the player from its stats and the drain from the stats of its class
+func (g *Game) setStamina(movement *playerMovement, playerState *d2hero.HeroState) {
+	if playerState.Stats != nil {
+		movement.stamina = playerState.Stats.Stamina
+		movement.maxStamina = float64(playerState.Stats.MaxStamina)
+	}
+
+	if g.asset == nil {
+		return
+	}
+
+	if charStats := g.asset.Records.Character.Stats[playerState.HeroType]; charStats != nil {
+		movement.staminaDrain = float64(charStats.StaminaRunDrain) / staminaDrainDivisor
+	}
+}
+
+// isRunning returns true when the player runs, it has to want to and it needs stamina outside of town
+func (p *playerMovement) isRunning(inTown bool) bool {
+	return p.running && (inTown || p.stamina > 0)
+}
+
+// advance walks the player along its path for the time since the last advance. Running drains the stamina
+// outside of town, once it is gone the player walks until walking and standing regenerated some of it.
+func (p *playerMovement) advance(now time.Time, inTown bool) {
+	elapsed := now.Sub(p.timestamp).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	p.timestamp = now
+
+	for elapsed > 0 && len(p.path) > 0 {
+		running := p.isRunning(inTown)
+		speed, duration := d2mapentity.BaseWalkSpeed, elapsed
+
+		if running {
+			speed = d2mapentity.BaseRunSpeed
+
+			if !inTown && p.staminaDrain > 0 {
+				duration = math.Min(elapsed, p.stamina/p.staminaDrain)
+			}
+		}
+
+		travelled := p.walk(speed * duration)
+		if len(p.path) == 0 {
+			duration = travelled / speed
+		}
+
+		if running && !inTown {
+			p.stamina = math.Max(0, p.stamina-p.staminaDrain*duration)
+		} else {
+			p.regenerate(duration)
+		}
+
+		elapsed -= duration
+	}
+
+	p.regenerate(elapsed)
+}
+
+// walk moves the player the given distance along its path and returns how far it got
+func (p *playerMovement) walk(distance float64) float64 {
+	travelled := 0.0
+
+	for len(p.path) > 0 {
+		next := p.path[0]
+		segment := p.position.Distance(&next.Vector)
+
+		if travelled+segment > distance {
+			p.position.Lerp(&next.Vector, (distance-travelled)/segment)
+			return distance
+		}
+
+		travelled += segment
+		p.position = next
+		p.path = p.path[1:]
+	}
+
+	return travelled
+}
+
+func (p *playerMovement) regenerate(seconds float64) {
+	if seconds > 0 {
+		p.stamina = math.Min(p.maxStamina, p.stamina+p.staminaDrain*seconds)
+	}
+}
+
+// validateMove checks the given move against the position of the player known by the server and the collision
+// data of the map engine. It returns the path of the move, which starts at the server position of the player.
+func validateMove(mapEngine *d2mapengine.MapEngine, last *playerMovement,
+	move *d2netpacket.MovePlayerPacket) ([]d2vector.Position, error) {
+	start := d2vector.NewPositionTile(move.StartX, move.StartY)
+	dest := d2vector.NewPositionTile(move.DestX, move.DestY)
+
+	if !isInsideMap(mapEngine, &start) || !isInsideMap(mapEngine, &dest) {
+		return nil, errMoveOutOfBounds
+	}
+
+	// the client can not have walked further than the server let the player walk
+	if start.Distance(&last.position.Vector) > moveDistanceSlack {
+		return nil, errMoveTooFast
+	}
+
+	// an empty path means there is nowhere to go, the player stays where it is
+	return mapEngine.PathFind(last.position, dest), nil
+}
+
+func isInsideMap(mapEngine *d2mapengine.MapEngine, p *d2vector.Position) bool {
+	size := mapEngine.Size()
+	maxX := float64(size.Width * subtilesPerTile)
+	maxY := float64(size.Height * subtilesPerTile)
+
+	return p.X() >= 0 && p.Y() >= 0 && p.X() < maxX && p.Y() < maxY
+}
+
+// handleMovePlayer validates a MovePlayerPacket sent by the given client. Valid moves are sent to the viewers of
+// the player, starting at the server position of the player with the destination clamped to what is
+// reachable. Invalid moves are answered with a SetPlayerPositionPacket so that the offending client snaps back to
+// the position known by the server. Both carry the sequence number of the move, which acknowledges it to the client
+// that predicted it.
+func (g *Game) handleMovePlayer(client ClientConnection, move *d2netpacket.MovePlayerPacket) error {
+	return g.movePlayer(client, move, time.Now())
+}
+
+func (g *Game) movePlayer(client ClientConnection, move *d2netpacket.MovePlayerPacket, now time.Time) error {
+	g.Lock()
+
+	last, ok := g.movements[client.GetUniqueID()]
+	if !ok {
+		g.Unlock()
+		return errMoveUnknownPlayer
+	}
+
+	// a move that was delayed or replayed must not undo the moves after it
+	if move.Sequence <= last.sequence {
+		sequence := last.sequence
+		g.Unlock()
+
+		return fmt.Errorf("%w: %d after %d", errMoveSequence, move.Sequence, sequence)
+	}
+
+	level := g.playerLevel(client.GetUniqueID())
+	last.sequence = move.Sequence
+	g.advancePlayer(level, client, last, now)
+
+	path, err := validateMove(level.mapEngine, last, move)
+	if err != nil {
+		position := last.position.World()
+		g.Unlock()
+
+		log.Printf("GameServer: rejected move from client %s: %s", client.GetUniqueID(), err)
+
+		correction := d2netpacket.CreateSetPlayerPositionPacket(client.GetUniqueID(), move.Sequence, position.X(),
+			position.Y())
+
+		return client.SendPacketToClient(correction)
+	}
+
+	last.path = path
+	last.running = move.Running
+
+	start := last.position.World()
+	dest := start
+
+	if len(path) > 0 {
+		dest = path[len(path)-1].World()
+	}
+
+	running := last.isRunning(level.isInTown(start.X(), start.Y()))
+	g.Unlock()
+
+	g.sendPacketToViewers(client.GetUniqueID(), d2netpacket.CreateMovePlayerPacket(client.GetUniqueID(), move.Sequence,
+		start.X(), start.Y(), dest.X(), dest.Y(), running))
+
+	return nil
+}
+
+// advancePlayers walks the players along their paths, it is called on every tick while the game is locked. A
+// player whose path ends on a warp is taken to the level the warp leads to once it gets there.
+func (g *Game) advancePlayers(now time.Time) {
+	for id, movement := range g.movements {
+		client, ok := g.connections[id]
+		if !ok {
+			continue
+		}
+
+		level := g.playerLevel(id)
+		walking := len(movement.path) > 0
+		g.advancePlayer(level, client, movement, now)
+
+		if !walking || len(movement.path) > 0 {
+			continue
+		}
+
+		position := movement.position.World()
+		if warp := level.WarpAt(int(position.X()), int(position.Y())); warp != nil {
+			if err := g.changeLevel(client, warp); err != nil {
+				log.Printf("GameServer: client %s could not take the warp to level %d: %s", id, warp.Destination, err)
+			}
+		}
+	}
+}
+
+// advancePlayer walks the player of the client along its path and puts its hero where the player got to
+func (g *Game) advancePlayer(level *gameLevel, client ClientConnection, movement *playerMovement, now time.Time) {
+	playerState := client.GetPlayerState()
+	movement.advance(now, level.isInTown(playerState.X, playerState.Y))
+
+	position := movement.position.World()
+	playerState.X = position.X()
+	playerState.Y = position.Y()
+}
+
+// placePlayer puts the player with the given ID at the given world position and returns the packet that snaps the
+// player entity there on the clients. The packet carries the sequence number of the last move of the player, so
+// that its client replays the moves it made since. It is called while the game is locked.
+func (g *Game) placePlayer(id string, x, y float64) d2netpacket.NetPacket {
+	movement := newPlayerMovement(x*subtilesPerTile, y*subtilesPerTile)
+
+	if last, ok := g.movements[id]; ok {
+		movement.sequence = last.sequence
+		movement.running = last.running
+		movement.stamina, movement.maxStamina, movement.staminaDrain = last.stamina, last.maxStamina, last.staminaDrain
+	}
+
+	g.movements[id] = movement
+
+	return d2netpacket.CreateSetPlayerPositionPacket(id, movement.sequence, x, y)
+}
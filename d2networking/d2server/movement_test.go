@@ -0,0 +1,237 @@
+package d2server
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2hero"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapgen"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+// the players start at the left edge of the open test map, in the middle of a row
+const (
+	testStartX, testStartY = 0.5, 4.1
+	testMapSize            = 8
+)
+
+// moveClient records the moves and corrections it receives, along with the types of all packets
+type moveClient struct {
+	testClient
+	sync.Mutex
+	state       *d2hero.HeroState
+	packets     []d2netpackettype.NetPacketType
+	moves       []d2netpacket.MovePlayerPacket
+	corrections []d2netpacket.SetPlayerPositionPacket
+}
+
+func newMoveClient(id string, stamina int) *moveClient {
+	stats := &d2hero.HeroStatsState{MaxStamina: stamina, Stamina: float64(stamina)}
+
+	return &moveClient{testClient: testClient{id}, state: &d2hero.HeroState{HeroName: id, Stats: stats}}
+}
+
+func (c *moveClient) SendPacketToClient(packet d2netpacket.NetPacket) error {
+	c.Lock()
+	defer c.Unlock()
+
+	c.packets = append(c.packets, packet.PacketType)
+
+	switch packet.PacketType {
+	case d2netpackettype.MovePlayer:
+		move, err := d2netpacket.UnmarshalMovePlayer(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		c.moves = append(c.moves, move)
+	case d2netpackettype.SetPlayerPosition:
+		correction, err := d2netpacket.UnmarshalSetPlayerPosition(packet.PacketData)
+		if err != nil {
+			return err
+		}
+
+		c.corrections = append(c.corrections, correction)
+	}
+
+	return nil
+}
+
+func (c *moveClient) GetPlayerState() *d2hero.HeroState {
+	return c.state
+}
+
+// testMapEngine creates an open map without any tiles. The map entity factory of the map engine needs the
+// records of the default equipment of the heroes.
+func testMapEngine() *d2mapengine.MapEngine {
+	records := &d2records.RecordManager{}
+	records.Level.Types = d2records.LevelTypes{{}}
+	records.Item.Weapons = make(d2records.CommonItems)
+	records.Item.Armors = d2records.CommonItems{"buc": {}}
+
+	for _, code := range []string{"hax", "wnd", "ssd", "ktr", "sst", "jav", "clb"} {
+		records.Item.Weapons[code] = &d2records.ItemCommonRecord{}
+	}
+
+	mapEngine := d2mapengine.CreateMapEngine(&d2asset.AssetManager{Records: records})
+	mapEngine.ResetMap(0, testMapSize, testMapSize)
+
+	return mapEngine
+}
+
+// testLevel creates a level with an open map and without monsters
+func testLevel(id int, warps ...d2mapgen.Warp) *gameLevel {
+	return &gameLevel{Level: &d2mapgen.Level{ID: id, Warps: warps}, mapEngine: testMapEngine()}
+}
+
+// testMoveGame creates a game with a level on an open map, the client is placed at the start position
+func testMoveGame(client *moveClient, now time.Time) *Game {
+	game := &Game{
+		connections:  map[string]ClientConnection{client.id: client},
+		levels:       map[int]*gameLevel{0: testLevel(0)},
+		playerLevels: map[string]int{client.id: 0},
+		movements:    make(map[string]*playerMovement),
+		views:        make(map[string]*clientView),
+	}
+
+	movement := newPlayerMovement(testStartX*subtilesPerTile, testStartY*subtilesPerTile)
+	movement.timestamp = now
+	game.setStamina(movement, client.state)
+	movement.staminaDrain = 1
+	game.movements[client.id] = movement
+
+	return game
+}
+
+// replayMoves sends a move to the right every interval, which claims that the player walked at the given speed in
+// sub tiles per second. It returns the number of moves that were accepted.
+func replayMoves(t *testing.T, game *Game, client *moveClient, start time.Time, count int, interval time.Duration,
+	speed float64, running bool) int {
+	for idx := 0; idx < count; idx++ {
+		elapsed := interval * time.Duration(idx)
+		x := testStartX + speed*elapsed.Seconds()/subtilesPerTile
+		move := d2netpacket.MovePlayerPacket{PlayerID: client.id, Sequence: uint32(idx + 1), StartX: x, StartY: testStartY,
+			DestX: testMapSize - 1, DestY: testStartY, Running: running}
+
+		if err := game.movePlayer(client, &move, start.Add(elapsed)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return len(client.moves)
+}
+
+func TestMovePlayerAtRunSpeed(t *testing.T) {
+	client := newMoveClient("runner", 100)
+	start := time.Now()
+	game := testMoveGame(client, start)
+
+	if accepted := replayMoves(t, game, client, start, 20, 100*time.Millisecond, d2mapentity.BaseRunSpeed, true); accepted != 20 {
+		t.Errorf("expected all moves at run speed to be accepted, got %d with corrections %+v", accepted, client.corrections)
+	}
+
+	for _, move := range client.moves {
+		if !move.Running {
+			t.Fatalf("expected the player with stamina to run, got %+v", move)
+		}
+	}
+
+	movement := game.movements[client.id]
+	if movement.stamina >= 100 {
+		t.Errorf("running did not drain the stamina")
+	}
+
+	if x := client.state.X; x <= testStartX+1 {
+		t.Errorf("expected the player to walk towards the destination, it is at %f", x)
+	}
+}
+
+func TestMovePlayerSpam(t *testing.T) {
+	client := newMoveClient("spammer", 100)
+	start := time.Now()
+	game := testMoveGame(client, start)
+
+	// 100 moves a second that each claim to be a sub tile further, ten times the run speed
+	accepted := replayMoves(t, game, client, start, 50, 10*time.Millisecond, 100, true)
+	if accepted == 50 || len(client.corrections) == 0 {
+		t.Errorf("expected the moves to be rejected, %d of 50 were accepted", accepted)
+	}
+
+	game.advancePlayers(start.Add(500 * time.Millisecond))
+
+	maxX := testStartX + d2mapentity.BaseRunSpeed*0.5/subtilesPerTile
+	if client.state.X > maxX+0.01 {
+		t.Errorf("the player got to %f in half a second, it can not get further than %f", client.state.X, maxX)
+	}
+
+	last := client.corrections[len(client.corrections)-1]
+	if last.X > maxX+0.01 {
+		t.Errorf("the player was corrected to %f, further than it can run", last.X)
+	}
+}
+
+func TestMovePlayerFakedRunning(t *testing.T) {
+	client := newMoveClient("walker", 0)
+	start := time.Now()
+	game := testMoveGame(client, start)
+
+	// the client claims to run, but the player has no stamina
+	accepted := replayMoves(t, game, client, start, 20, 250*time.Millisecond, d2mapentity.BaseRunSpeed, true)
+	if accepted == 20 || len(client.corrections) == 0 {
+		t.Errorf("expected the moves at run speed to be rejected, %d of 20 were accepted", accepted)
+	}
+
+	for _, move := range client.moves {
+		if move.Running {
+			t.Fatalf("a player without stamina was broadcast running: %+v", move)
+		}
+	}
+
+	client.moves, client.corrections = nil, nil
+	walker := newMoveClient("walker", 0)
+	game = testMoveGame(walker, start)
+
+	if accepted := replayMoves(t, game, walker, start, 20, 250*time.Millisecond, d2mapentity.BaseWalkSpeed, true); accepted != 20 {
+		t.Errorf("expected all moves at walk speed to be accepted, got %d", accepted)
+	}
+}
+
+func TestMovePlayerStaleSequence(t *testing.T) {
+	client := newMoveClient("walker", 0)
+	start := time.Now()
+	game := testMoveGame(client, start)
+
+	tests := []struct {
+		sequence uint32
+		stale    bool
+	}{
+		{2, false}, // the first move was lost
+		{2, true},  // replayed
+		{1, true},  // delayed
+		{3, false},
+	}
+
+	for _, test := range tests {
+		move := d2netpacket.MovePlayerPacket{PlayerID: client.id, Sequence: test.sequence, StartX: testStartX,
+			StartY: testStartY, DestX: testStartX, DestY: float64(test.sequence)}
+
+		if err := game.movePlayer(client, &move, start); errors.Is(err, errMoveSequence) != test.stale {
+			t.Errorf("move %d: unexpected error %v", test.sequence, err)
+		}
+	}
+
+	if len(client.moves) != 2 || client.moves[0].Sequence != 2 || client.moves[1].Sequence != 3 {
+		t.Errorf("expected only the moves 2 and 3 to be broadcast, got %+v", client.moves)
+	}
+
+	if len(client.corrections) != 0 {
+		t.Errorf("stale moves must not be corrected, got %+v", client.corrections)
+	}
+}
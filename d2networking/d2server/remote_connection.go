@@ -0,0 +1,79 @@
+package d2server
+
+import (
+	"bufio"
+	"net"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2tcpclientconnection"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2server/d2udpclientconnection"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2udp"
+)
+
+// remoteConnection is the connection of a remote client, over TCP or UDP. Packets are written as JSON
+// until the client is registered, then they are sent through the ClientConnection of the client.
+type remoteConnection interface {
+	readPacket() (d2netpacket.NetPacket, error)
+	writePacket(packet d2netpacket.NetPacket) error
+	createClient(id string) remoteClient
+	SetReadDeadline(t time.Time) error
+	RemoteAddr() net.Addr
+	Close() error
+}
+
+// remoteClient is the ClientConnection of a remote client
+type remoteClient interface {
+	ClientConnection
+	SetPacketEncoding(encoding d2netpacket.PacketEncoding)
+	GetPacketEncoding() d2netpacket.PacketEncoding
+}
+
+// tcpConnection reads length prefixed frames from a TCP connection
+type tcpConnection struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func newTCPConnection(conn net.Conn) *tcpConnection {
+	return &tcpConnection{Conn: conn, reader: bufio.NewReader(conn)}
+}
+
+func (c *tcpConnection) readPacket() (d2netpacket.NetPacket, error) {
+	return d2netpacket.ReadFrame(c.reader)
+}
+
+func (c *tcpConnection) writePacket(packet d2netpacket.NetPacket) error {
+	return d2netpacket.WriteFrame(c.Conn, packet, d2netpacket.JSONEncoding)
+}
+
+func (c *tcpConnection) createClient(id string) remoteClient {
+	return d2tcpclientconnection.CreateTCPClientConnection(c.Conn, id)
+}
+
+// udpConnection reads a frame from each message of a UDP connection
+type udpConnection struct {
+	*d2udp.Conn
+}
+
+func (c udpConnection) readPacket() (d2netpacket.NetPacket, error) {
+	message, err := c.ReadMessage()
+	if err != nil {
+		return d2netpacket.NetPacket{}, err
+	}
+
+	return d2netpacket.UnmarshalFrame(message)
+}
+
+func (c udpConnection) writePacket(packet d2netpacket.NetPacket) error {
+	message, err := d2netpacket.MarshalFrame(packet, d2netpacket.JSONEncoding)
+	if err != nil {
+		return err
+	}
+
+	return c.WriteMessage(message, true)
+}
+
+func (c udpConnection) createClient(id string) remoteClient {
+	return d2udpclientconnection.CreateUDPClientConnection(c.Conn, id)
+}
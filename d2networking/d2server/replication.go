@@ -0,0 +1,301 @@
+package d2server
+
+import (
+	"log"
+	"math"
+	"sort"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2interface"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapentity"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2monai"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+const (
+	// viewRadius is the distance in sub tiles within which the entities of the map are replicated to a player
+	viewRadius = 30 * subtilesPerTile
+	// forgetRadius is the distance in sub tiles beyond which a replicated entity is destroyed on the client. It is
+	// larger than the view radius, so that entities at the edge of the view are not created and destroyed in turn.
+	forgetRadius = 35 * subtilesPerTile
+	// ticksPerSnapshot is the number of ticks between the snapshots of the entities that are sent to the clients
+	ticksPerSnapshot = 2
+	// moveEpsilon is the distance in sub tiles an entity has to move before its position is sent again
+	moveEpsilon = 0.01
+	// refreshSnapshots is the number of snapshots after which the positions of all known entities are sent again.
+	// UpdateEntitiesPackets can be lost, the refresh moves the entities whose last update was lost to where they are.
+	refreshSnapshots = 10
+)
+
+// clientView holds the entities the server has replicated to a client, at the position they were last sent at,
+// and the other players the client was sent AddPlayerPackets for
+type clientView struct {
+	entities  map[string]d2netpacket.EntityPosition
+	players   map[string]bool
+	snapshots int // the number of snapshots the entities were updated with
+}
+
+func newClientView() *clientView {
+	return &clientView{entities: make(map[string]d2netpacket.EntityPosition), players: make(map[string]bool)}
+}
+
+// update compares the entities of the map with the entities known to the client of the view. It returns the
+// entities the client has to create, the positions of the known entities that moved and the IDs of the entities
+// the client has to destroy, then it remembers what was returned. Every refreshSnapshots updates the positions
+// of all known entities are returned, whether they moved or not.
+func (v *clientView) update(states []d2netpacket.EntityState, center d2vector.Position) (
+	created []d2netpacket.EntityState, moved []d2netpacket.EntityPosition, destroyed []string) {
+	seen := make(map[string]bool, len(v.entities))
+
+	v.snapshots++
+	refresh := v.snapshots%refreshSnapshots == 0
+
+	for i := range states {
+		state := &states[i]
+		position := d2netpacket.EntityPosition{ID: state.ID, X: state.X, Y: state.Y}
+		distance := math.Hypot(state.X-center.X(), state.Y-center.Y())
+		last, known := v.entities[state.ID]
+
+		switch {
+		case known && distance <= forgetRadius:
+			seen[state.ID] = true
+
+			if refresh || math.Abs(last.X-state.X) > moveEpsilon || math.Abs(last.Y-state.Y) > moveEpsilon {
+				moved = append(moved, position)
+				v.entities[state.ID] = position
+			}
+		case !known && distance <= viewRadius:
+			seen[state.ID] = true
+
+			created = append(created, *state)
+			v.entities[state.ID] = position
+		}
+	}
+
+	for id := range v.entities {
+		if !seen[id] {
+			destroyed = append(destroyed, id)
+			delete(v.entities, id)
+		}
+	}
+
+	sort.Strings(destroyed)
+
+	return created, moved, destroyed
+}
+
+// updatePlayers compares the players of the level, by their positions in sub tiles, with the players known to
+// the client of the view, which is the client of the player with the given ID. The players that come within the
+// view radius are added, those beyond the forget radius or not on the level anymore are removed. It returns the
+// sorted IDs of the added and the removed players, then it remembers what was returned.
+func (v *clientView) updatePlayers(id string, positions map[string]d2vector.Position, center d2vector.Position) (
+	added, removed []string) {
+	for other, position := range positions {
+		if other == id {
+			continue
+		}
+
+		distance := position.Distance(&center.Vector)
+
+		switch known := v.players[other]; {
+		case !known && distance <= viewRadius:
+			added = append(added, other)
+			v.players[other] = true
+		case known && distance > forgetRadius:
+			removed = append(removed, other)
+			delete(v.players, other)
+		}
+	}
+
+	for other := range v.players {
+		if _, found := positions[other]; !found {
+			removed = append(removed, other)
+			delete(v.players, other)
+		}
+	}
+
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
+
+// entityState returns the state of a map entity that is replicated to the clients. Only the entities that the
+// clients can create from records are replicated, players are added with AddPlayerPackets.
+func entityState(entity d2interface.MapEntity) (d2netpacket.EntityState, bool) {
+	position := entity.GetPosition()
+	state := d2netpacket.EntityState{ID: entity.ID(), X: position.X(), Y: position.Y()}
+
+	switch e := entity.(type) {
+	case *d2mapentity.NPC:
+		if e.MonStats() == nil {
+			return state, false
+		}
+
+		state.Kind = d2netpacket.EntityNPC
+		state.Record = e.MonStats().Key
+		state.Dead = e.IsDead()
+	case *d2mapentity.Object:
+		state.Kind = d2netpacket.EntityObject
+		state.Index = e.ObjectRecord().Index
+	case *d2mapentity.Item:
+		state.Kind = d2netpacket.EntityItem
+		state.Record = e.Item.GetItemCode()
+		state.Item = e.Item.Serialize()
+	case *d2mapentity.Missile:
+		state.Kind = d2netpacket.EntityMissile
+		state.Index = e.MissileRecord().Id
+	default:
+		return state, false
+	}
+
+	return state, true
+}
+
+// replicate advances the clock of the game and returns the snapshots of the entities for the clients, once every
+// ticksPerSnapshot ticks. Each client gets the players and the entities around its player on the level its player
+// is on. It is called while the game is locked.
+func (g *Game) replicate(elapsed float64) []clientPacket {
+	g.clock += elapsed
+
+	tick := uint64(g.clock * d2monai.TicksPerSecond)
+	if tick < g.snapshotTick+ticksPerSnapshot {
+		return nil
+	}
+
+	g.snapshotTick = tick
+
+	states := make(map[*gameLevel][]d2netpacket.EntityState, len(g.levels))
+	players := make(map[*gameLevel]map[string]d2vector.Position, len(g.levels))
+	packets := make([]clientPacket, 0)
+
+	for id, view := range g.views {
+		client := g.connections[id]
+		if client == nil {
+			continue
+		}
+
+		level := g.playerLevel(id)
+		if _, found := states[level]; !found {
+			states[level] = levelEntityStates(level)
+			players[level] = g.levelPlayerPositions(level)
+		}
+
+		playerState := client.GetPlayerState()
+		center := d2vector.NewPositionTile(playerState.X, playerState.Y)
+		added, removed := view.updatePlayers(id, players[level], center)
+
+		for _, other := range removed {
+			packets = append(packets, clientPacket{client, d2netpacket.CreateRemovePlayerPacket(other)})
+		}
+
+		for _, other := range added {
+			packets = append(packets, clientPacket{client, createAddPlayerPacket(g.connections[other])})
+		}
+
+		created, moved, destroyed := view.update(states[level], center)
+
+		if len(destroyed) > 0 {
+			packets = append(packets, clientPacket{client, d2netpacket.CreateDestroyEntitiesPacket(destroyed)})
+		}
+
+		if len(created) > 0 {
+			packets = append(packets, clientPacket{client, d2netpacket.CreateCreateEntitiesPacket(tick, created)})
+		}
+
+		if len(moved) > 0 {
+			packets = append(packets, clientPacket{client, d2netpacket.CreateUpdateEntitiesPacket(tick, moved)})
+		}
+	}
+
+	return packets
+}
+
+// levelEntityStates returns the states of the entities of the level that are replicated, sorted by ID
+func levelEntityStates(level *gameLevel) []d2netpacket.EntityState {
+	entities := level.mapEngine.Entities()
+	states := make([]d2netpacket.EntityState, 0, len(entities))
+
+	for _, entity := range entities {
+		if state, ok := entityState(entity); ok {
+			states = append(states, state)
+		}
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].ID < states[j].ID
+	})
+
+	return states
+}
+
+// levelPlayerPositions returns the positions in sub tiles of the players on the level, by their client IDs
+func (g *Game) levelPlayerPositions(level *gameLevel) map[string]d2vector.Position {
+	clients := g.levelClients(level)
+	positions := make(map[string]d2vector.Position, len(clients))
+
+	for _, client := range clients {
+		playerState := client.GetPlayerState()
+		positions[client.GetUniqueID()] = d2vector.NewPositionTile(playerState.X, playerState.Y)
+	}
+
+	return positions
+}
+
+// viewers returns the client of the player with the given ID and the clients the player was added to, which are
+// the clients that get the packets of the player. It is called while the game is locked.
+func (g *Game) viewers(id string) []ClientConnection {
+	viewers := make([]ClientConnection, 0, len(g.views)+1)
+
+	if client := g.connections[id]; client != nil {
+		viewers = append(viewers, client)
+	}
+
+	for viewerID, view := range g.views {
+		if client := g.connections[viewerID]; client != nil && view.players[id] {
+			viewers = append(viewers, client)
+		}
+	}
+
+	return viewers
+}
+
+// sendPacketToViewers sends a packet of the player with the given ID to its viewers. It must not be called while
+// the game is locked.
+func (g *Game) sendPacketToViewers(id string, packet d2netpacket.NetPacket) {
+	g.RLock()
+	viewers := g.viewers(id)
+	g.RUnlock()
+
+	for _, client := range viewers {
+		if err := client.SendPacketToClient(packet); err != nil {
+			log.Printf("GameServer: error sending packet: %s to client %s: %s", packet.PacketType,
+				client.GetUniqueID(), err)
+		}
+	}
+}
+
+// queueViewerPacket queues a packet of the player with the given ID for its viewers until the tick is done. It is
+// called while the game is locked.
+func (g *Game) queueViewerPacket(id string, packet d2netpacket.NetPacket) {
+	for _, client := range g.viewers(id) {
+		g.queuedPackets = append(g.queuedPackets, clientPacket{client, packet})
+	}
+}
+
+// watch starts replicating the players and the entities around the player of the client, after the client has the
+// map
+func (g *Game) watch(client ClientConnection) {
+	g.Lock()
+	g.views[client.GetUniqueID()] = newClientView()
+	g.Unlock()
+}
+
+func sendClientPackets(packets []clientPacket) {
+	for _, p := range packets {
+		if err := p.client.SendPacketToClient(p.packet); err != nil {
+			log.Printf("GameServer: error sending packet: %s to client %s: %s", p.packet.PacketType,
+				p.client.GetUniqueID(), err)
+		}
+	}
+}
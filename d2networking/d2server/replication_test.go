@@ -0,0 +1,136 @@
+package d2server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math/d2vector"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket/d2netpackettype"
+)
+
+func TestClientViewUpdate(t *testing.T) {
+	view := newClientView()
+	center := d2vector.NewPosition(0, 0)
+	states := []d2netpacket.EntityState{
+		{ID: "near", X: 10, Y: 10},
+		{ID: "edge", X: viewRadius + 1},
+		{ID: "far", X: forgetRadius + 1},
+	}
+
+	created, moved, destroyed := view.update(states, center)
+	if len(created) != 1 || created[0].ID != "near" || len(moved) != 0 || len(destroyed) != 0 {
+		t.Fatalf("expected only the near entity to be created, got %v %v %v", created, moved, destroyed)
+	}
+
+	// the near entity moves to the edge of the view, where it is kept
+	states[0].X = viewRadius + 2
+
+	created, moved, destroyed = view.update(states, center)
+	if len(created) != 0 || !reflect.DeepEqual(moved, []d2netpacket.EntityPosition{{ID: "near", X: viewRadius + 2, Y: 10}}) ||
+		len(destroyed) != 0 {
+		t.Fatalf("expected the near entity to move, got %v %v %v", created, moved, destroyed)
+	}
+
+	created, moved, _ = view.update(states, center)
+	if len(created) != 0 || len(moved) != 0 {
+		t.Errorf("expected no changes for entities that did not move, got %v %v", created, moved)
+	}
+
+	// the player walks away and the entity that was removed from the map is destroyed
+	_, _, destroyed = view.update(states[1:], d2vector.NewPosition(2*forgetRadius+viewRadius, 0))
+	if !reflect.DeepEqual(destroyed, []string{"near"}) {
+		t.Errorf("expected the removed entity to be destroyed, got %v", destroyed)
+	}
+}
+
+func TestClientViewRefresh(t *testing.T) {
+	view := newClientView()
+	center := d2vector.NewPosition(0, 0)
+	states := []d2netpacket.EntityState{{ID: "still", X: 10, Y: 10}}
+
+	view.update(states, center)
+
+	// an entity that stands still is sent again every refreshSnapshots snapshots, in case its last update was lost
+	for snapshot := 2; snapshot <= 2*refreshSnapshots; snapshot++ {
+		_, moved, _ := view.update(states, center)
+
+		if refresh := snapshot%refreshSnapshots == 0; refresh != (len(moved) == 1) {
+			t.Errorf("snapshot %d: unexpected positions %v", snapshot, moved)
+		}
+	}
+}
+
+func TestClientViewPlayers(t *testing.T) {
+	view := newClientView()
+	center := d2vector.NewPosition(0, 0)
+	positions := map[string]d2vector.Position{
+		"self": center,
+		"near": d2vector.NewPosition(10, 10),
+		"edge": d2vector.NewPosition(viewRadius+1, 0),
+	}
+
+	added, removed := view.updatePlayers("self", positions, center)
+	if !reflect.DeepEqual(added, []string{"near"}) || len(removed) != 0 {
+		t.Fatalf("expected only the near player to be added, got %v %v", added, removed)
+	}
+
+	// the near player walks to the edge of the view, where it is kept, and the other player comes closer
+	positions["near"] = d2vector.NewPosition(forgetRadius, 0)
+	positions["edge"] = d2vector.NewPosition(viewRadius, 0)
+
+	added, removed = view.updatePlayers("self", positions, center)
+	if !reflect.DeepEqual(added, []string{"edge"}) || len(removed) != 0 {
+		t.Fatalf("expected the edge player to be added, got %v %v", added, removed)
+	}
+
+	// the near player walks out of sight and the edge player leaves the level
+	positions["near"] = d2vector.NewPosition(forgetRadius+1, 0)
+	delete(positions, "edge")
+
+	added, removed = view.updatePlayers("self", positions, center)
+	if len(added) != 0 || !reflect.DeepEqual(removed, []string{"edge", "near"}) {
+		t.Errorf("expected both players to be removed, got %v %v", added, removed)
+	}
+}
+
+func TestViewers(t *testing.T) {
+	near, far := newMoveClient("near", 0), newMoveClient("far", 0)
+	mover := newMoveClient("mover", 0)
+	start := time.Now()
+	game := testMoveGame(mover, start)
+
+	for _, client := range []*moveClient{mover, near, far} {
+		client.state.X, client.state.Y = testStartX, testStartY
+	}
+
+	far.state.X += forgetRadius/subtilesPerTile + 1
+
+	for _, client := range []*moveClient{near, far} {
+		game.connections[client.id] = client
+		game.playerLevels[client.id] = 0
+		game.movements[client.id] = newPlayerMovement(client.state.X*subtilesPerTile, testStartY*subtilesPerTile)
+	}
+
+	for _, client := range []*moveClient{mover, near, far} {
+		game.views[client.id] = newClientView()
+	}
+
+	sendClientPackets(game.replicate(1))
+
+	move := d2netpacket.MovePlayerPacket{PlayerID: mover.id, Sequence: 1, StartX: testStartX, StartY: testStartY,
+		DestX: testStartX + 1, DestY: testStartY}
+	if err := game.movePlayer(mover, &move, start); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mover.moves) != 1 || len(near.moves) != 1 || len(far.moves) != 0 {
+		t.Errorf("expected the move to be sent to the mover and the near player, got %d, %d and %d moves",
+			len(mover.moves), len(near.moves), len(far.moves))
+	}
+
+	if countPackets(far, d2netpackettype.AddPlayer) != 0 || countPackets(near, d2netpackettype.AddPlayer) != 1 {
+		t.Error("expected the mover to be added to the near player only")
+	}
+}
@@ -0,0 +1,97 @@
+package d2server
+
+import (
+	"log"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2math"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2map/d2mapengine"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2records"
+	"github.com/OpenDiablo2/OpenDiablo2/d2networking/d2netpacket"
+)
+
+// spawnSkillEntities adds the missiles and the summoned monster of the skill a player casts to the map. They are
+// replicated to the clients like all entities of the map, the clients only play the cast animations.
+func (g *Game) spawnSkillEntities(client ClientConnection, cast *d2netpacket.CastPacket) {
+	skill := g.asset.Records.Skill.Details[cast.SkillID]
+	if skill == nil {
+		return
+	}
+
+	g.Lock()
+	defer g.Unlock()
+
+	mapEngine := g.playerLevel(client.GetUniqueID()).mapEngine
+	playerState := client.GetPlayerState()
+	x, y := playerState.X*subtilesPerTile, playerState.Y*subtilesPerTile
+	castX, castY := cast.TargetX*subtilesPerTile, cast.TargetY*subtilesPerTile
+
+	for _, name := range skillMissiles(skill) {
+		if record := g.asset.Records.GetMissileByName(name); record != nil {
+			g.spawnMissile(mapEngine, record, x, y, castX, castY)
+		}
+	}
+
+	if skill.Summon == "" {
+		return
+	}
+
+	monstat := g.asset.Records.Monster.Stats[skill.Summon]
+	if monstat == nil {
+		log.Printf("GameServer: cannot cast skill %d, no monstat entry for %q", cast.SkillID, skill.Summon)
+		return
+	}
+
+	// https://github.com/OpenDiablo2/OpenDiablo2/issues/803
+	summon, err := mapEngine.NewNPC(int(castX), int(castY), monstat, 0)
+	if err != nil {
+		log.Printf("GameServer: error summoning %q: %s", skill.Summon, err)
+		return
+	}
+
+	mapEngine.AddEntity(summon)
+}
+
+// skillMissiles returns the names of the missiles a skill shoots
+func skillMissiles(skill *d2records.SkillRecord) []string {
+	return []string{skill.Cltmissile, skill.Cltmissilea, skill.Cltmissileb, skill.Cltmissilec, skill.Cltmissiled}
+}
+
+// spawnMissile adds a missile flying from the given position towards the cast position, it is removed from the
+// map once it reached its range
+func (g *Game) spawnMissile(mapEngine *d2mapengine.MapEngine, record *d2records.MissileRecord, x, y, castX,
+	castY float64) {
+	missile, err := mapEngine.NewMissile(int(x), int(y), g.asset.Records.Missiles[record.Id])
+	if err != nil {
+		log.Printf("GameServer: error creating missile %q: %s", record.Name, err)
+		return
+	}
+
+	missile.SetRadians(d2math.GetRadiansBetween(x, y, castX, castY), func() {
+		mapEngine.RemoveEntity(missile)
+	})
+
+	mapEngine.AddEntity(missile)
+}
+
+// spawnItem drops an item with the given codes on the tile of the level
+func (l *gameLevel) spawnItem(tileX, tileY int, codes ...string) {
+	item, err := l.mapEngine.NewItem(tileX, tileY, codes...)
+	if err != nil {
+		log.Printf("GameServer: error spawning item %v: %s", codes, err)
+		return
+	}
+
+	l.mapEngine.AddEntity(item)
+}
+
+// dropItem drops an item that was rolled, like the loot of a monster, on the tile of the level
+func (l *gameLevel) dropItem(tileX, tileY int, item *diablo2item.Item) {
+	entity, err := l.mapEngine.NewItemEntity(tileX, tileY, item)
+	if err != nil {
+		log.Printf("GameServer: error dropping item %s: %s", item.GetItemCode(), err)
+		return
+	}
+
+	l.mapEngine.AddEntity(entity)
+}
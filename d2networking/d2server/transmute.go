@@ -0,0 +1,59 @@
+package d2server
+
+import (
+	"log"
+	"strings"
+
+	"github.com/robertkrimen/otto"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+)
+
+// scriptTransmute is the transmute function of the script engine, which transmutes items in a horadric cube:
+//
+// transmute("r01", "r01", "r01")
+//
+// Each argument holds the codes of an item, like "hax,Jagged" for a magic Hand Axe. It returns the recipe,
+// the portal it opens and the code, name, quality and sockets of each output, or null when no recipe matches.
+func (g *GameServer) scriptTransmute(call otto.FunctionCall) otto.Value {
+	factory := g.cube.Factory()
+	items := make([]*diablo2item.Item, 0, len(call.ArgumentList))
+
+	for _, arg := range call.ArgumentList {
+		item, err := factory.NewItem(strings.Split(arg.String(), ",")...)
+		if err != nil {
+			log.Printf("GameServer: could not transmute %s: %s", arg.String(), err)
+			return otto.NullValue()
+		}
+
+		items = append(items, item)
+	}
+
+	result, err := g.cube.Transmute(items)
+	if err != nil {
+		return otto.NullValue()
+	}
+
+	outputs := make([]map[string]interface{}, len(result.Outputs))
+
+	for idx, item := range result.Outputs {
+		outputs[idx] = map[string]interface{}{
+			"code":    item.CommonCode,
+			"name":    item.Label(),
+			"quality": item.Quality().String(),
+			"sockets": item.Sockets(),
+		}
+	}
+
+	value, err := g.scriptEngine.ToValue(map[string]interface{}{
+		"recipe":  result.Recipe.Description,
+		"portal":  result.Portal,
+		"outputs": outputs,
+	})
+	if err != nil {
+		log.Printf("GameServer: could not transmute: %s", err)
+		return otto.NullValue()
+	}
+
+	return value
+}
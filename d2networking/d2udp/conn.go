@@ -0,0 +1,213 @@
+package d2udp
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// updateInterval is how often the connections resend fragments and send acks
+const updateInterval = 20 * time.Millisecond
+
+var (
+	// ErrClosed is returned when the connection was closed
+	ErrClosed = errors.New("udp connection closed")
+
+	// ErrClosedByPeer is returned when the peer has closed the connection
+	ErrClosedByPeer = errors.New("udp connection closed by peer")
+)
+
+// Conn is a connection with a peer over UDP, it is safe for concurrent use. Messages are read and
+// written whole, reliable messages arrive once and in order.
+type Conn struct {
+	sync.Mutex
+	session   *session
+	remote    *net.UDPAddr
+	queue     [][]byte      // messages that were received and not read yet
+	ready     chan struct{} // signaled when messages were queued
+	done      chan struct{}
+	closeOnce sync.Once
+	err       error // why the connection was closed
+	deadline  time.Time
+	onClose   func()
+}
+
+func newConn(id uint32, remote *net.UDPAddr, send func(data []byte) error, onClose func()) *Conn {
+	return &Conn{
+		session: newSession(id, send, time.Now()),
+		remote:  remote,
+		ready:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		onClose: onClose,
+	}
+}
+
+// RemoteAddr returns the address of the peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetTimeout sets how long the connection stays open without hearing from the peer, see DefaultTimeout
+func (c *Conn) SetTimeout(timeout time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.session.timeout = timeout
+}
+
+// SetReadDeadline sets when ReadMessage stops waiting for a message and returns ErrTimeout, this
+// includes a pending ReadMessage. A zero time waits for messages without a deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.Lock()
+	c.deadline = t
+	c.Unlock()
+
+	c.signal()
+
+	return nil
+}
+
+// ReadMessage waits for the next message from the peer
+func (c *Conn) ReadMessage() ([]byte, error) {
+	for {
+		c.Lock()
+
+		if len(c.queue) > 0 {
+			message := c.queue[0]
+			c.queue = c.queue[1:]
+			c.Unlock()
+
+			return message, nil
+		}
+
+		err, deadline := c.err, c.deadline
+		c.Unlock()
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := c.wait(deadline); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// wait waits until messages were queued, the connection was closed or the deadline was changed
+func (c *Conn) wait(deadline time.Time) error {
+	var timeout <-chan time.Time
+
+	if !deadline.IsZero() {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return ErrTimeout
+		}
+
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case <-c.ready:
+		return nil
+	case <-c.done:
+		return nil
+	case <-timeout:
+		return ErrTimeout
+	}
+}
+
+// signal wakes up a pending ReadMessage
+func (c *Conn) signal() {
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+// WriteMessage sends a message to the peer. A reliable message is resent until the peer has received
+// it, an unreliable message is sent once.
+func (c *Conn) WriteMessage(message []byte, reliable bool) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.err != nil {
+		return c.err
+	}
+
+	return c.session.sendMessage(message, reliable, time.Now())
+}
+
+// Close tells the peer that the connection is closed and closes it. The messages that the peer has not
+// received yet are lost.
+func (c *Conn) Close() error {
+	c.Lock()
+	closed := c.err != nil
+
+	var err error
+	if !closed {
+		err = c.session.sendControl(kindDisconnect, time.Now())
+	}
+
+	c.Unlock()
+
+	c.close(ErrClosed)
+
+	return err
+}
+
+func (c *Conn) close(err error) {
+	c.closeOnce.Do(func() {
+		c.Lock()
+		c.err = err
+		c.Unlock()
+
+		close(c.done)
+
+		if c.onClose != nil {
+			c.onClose()
+		}
+	})
+}
+
+// handle handles a datagram of the session of the connection
+func (c *Conn) handle(d *datagram) {
+	if d.kind == kindDisconnect {
+		c.close(ErrClosedByPeer)
+		return
+	}
+
+	c.Lock()
+
+	if c.err != nil {
+		c.Unlock()
+		return
+	}
+
+	messages := c.session.receive(d, time.Now())
+	c.queue = append(c.queue, messages...)
+	c.Unlock()
+
+	if len(messages) > 0 {
+		c.signal()
+	}
+}
+
+func (c *Conn) update(now time.Time) {
+	c.Lock()
+
+	if c.err != nil {
+		c.Unlock()
+		return
+	}
+
+	err := c.session.update(now)
+	c.Unlock()
+
+	if err != nil {
+		c.close(err)
+	}
+}
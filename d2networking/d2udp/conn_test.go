@@ -0,0 +1,55 @@
+package d2udp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestListenDial(t *testing.T) {
+	listener, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	client, err := Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message := bytes.Repeat([]byte("fragmented "), MaxDatagramSize)
+
+	if err := client.WriteMessage(message, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := server.SetReadDeadline(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	received, err := server.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(received, message) {
+		t.Error("the message was not received whole")
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := server.ReadMessage(); err != ErrClosedByPeer {
+		t.Errorf("expected the connection to be closed by the client, got %v", err)
+	}
+}
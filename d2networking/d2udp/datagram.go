@@ -0,0 +1,137 @@
+package d2udp
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+const (
+	protocolID      uint16 = 0x4432 // "D2"
+	protocolVersion byte   = 1
+
+	// MaxDatagramSize is the size of the largest datagram that is sent. It is below the MTU of common
+	// links, so the datagrams are not fragmented by IP, which would lose a whole datagram with any fragment.
+	MaxDatagramSize = 1200
+
+	headerSize         = 20 // protocol, version, kind, session, sequence, ack, ack bits
+	fragmentHeaderSize = 9  // reliable, message, fragment, fragments
+	maxFragmentSize    = MaxDatagramSize - headerSize - fragmentHeaderSize
+	maxFragments       = 1024
+)
+
+var (
+	errDatagramShort   = errors.New("datagram is too short")
+	errDatagramInvalid = errors.New("datagram is not part of the protocol")
+	errDatagramVersion = errors.New("datagram is from a different protocol version")
+)
+
+type datagramKind byte
+
+const (
+	kindConnect    datagramKind = iota + 1 // sent by the client to start a session
+	kindAccept                             // sent by the server with the session of the client
+	kindData                               // a fragment of a message
+	kindAck                                // acknowledges data, also sent as a heartbeat
+	kindDisconnect                         // the session was closed
+)
+
+// datagram is a single UDP datagram of a session. Each datagram acknowledges the latest data datagram
+// received from the peer, as well as which of the 32 before it were received.
+type datagram struct {
+	kind     datagramKind
+	session  uint32
+	sequence uint32
+	ack      uint32
+	ackBits  uint32
+
+	// fragment of a message, only for data datagrams
+	reliable  bool
+	message   uint32
+	fragment  uint16
+	fragments uint16
+	payload   []byte
+}
+
+func (d *datagram) marshal() []byte {
+	size := headerSize
+	if d.kind == kindData {
+		size += fragmentHeaderSize + len(d.payload)
+	}
+
+	data := make([]byte, size)
+
+	binary.LittleEndian.PutUint16(data, protocolID)
+	data[2] = protocolVersion
+	data[3] = byte(d.kind)
+	binary.LittleEndian.PutUint32(data[4:], d.session)
+	binary.LittleEndian.PutUint32(data[8:], d.sequence)
+	binary.LittleEndian.PutUint32(data[12:], d.ack)
+	binary.LittleEndian.PutUint32(data[16:], d.ackBits)
+
+	if d.kind != kindData {
+		return data
+	}
+
+	if d.reliable {
+		data[headerSize] = 1
+	}
+
+	binary.LittleEndian.PutUint32(data[headerSize+1:], d.message)
+	binary.LittleEndian.PutUint16(data[headerSize+5:], d.fragment)
+	binary.LittleEndian.PutUint16(data[headerSize+7:], d.fragments)
+	copy(data[headerSize+fragmentHeaderSize:], d.payload)
+
+	return data
+}
+
+// unmarshalDatagram decodes a datagram, the payload is copied so the buffer can be reused
+func unmarshalDatagram(data []byte) (*datagram, error) {
+	if len(data) < headerSize {
+		return nil, errDatagramShort
+	}
+
+	if binary.LittleEndian.Uint16(data) != protocolID {
+		return nil, errDatagramInvalid
+	}
+
+	if data[2] != protocolVersion {
+		return nil, errDatagramVersion
+	}
+
+	d := &datagram{
+		kind:     datagramKind(data[3]),
+		session:  binary.LittleEndian.Uint32(data[4:]),
+		sequence: binary.LittleEndian.Uint32(data[8:]),
+		ack:      binary.LittleEndian.Uint32(data[12:]),
+		ackBits:  binary.LittleEndian.Uint32(data[16:]),
+	}
+
+	switch d.kind {
+	case kindConnect, kindAccept, kindAck, kindDisconnect:
+		return d, nil
+	case kindData:
+	default:
+		return nil, errDatagramInvalid
+	}
+
+	if len(data) < headerSize+fragmentHeaderSize {
+		return nil, errDatagramShort
+	}
+
+	d.reliable = data[headerSize] != 0
+	d.message = binary.LittleEndian.Uint32(data[headerSize+1:])
+	d.fragment = binary.LittleEndian.Uint16(data[headerSize+5:])
+	d.fragments = binary.LittleEndian.Uint16(data[headerSize+7:])
+	d.payload = append([]byte(nil), data[headerSize+fragmentHeaderSize:]...)
+
+	if d.fragments == 0 || d.fragments > maxFragments || d.fragment >= d.fragments {
+		return nil, errDatagramInvalid
+	}
+
+	return d, nil
+}
+
+// newer returns true when sequence a comes after b, sequences wrap around
+func newer(a, b uint32) bool {
+	return int32(a-b) > 0
+}
@@ -0,0 +1,9 @@
+// Package d2udp provides connections over UDP for the game server and its remote clients.
+/*
+A connection starts with a handshake, in which the server assigns a session to the address of the
+client. Messages are split into datagrams that fit into the MTU of common links and are reassembled
+by the peer. Reliable messages are resent until the peer acknowledges them and arrive in order,
+unreliable messages can be lost and are dropped when a newer unreliable message arrived first.
+A connection is closed when the peer has not been heard from for a while, idle connections send
+heartbeats to stay open.*/
+package d2udp
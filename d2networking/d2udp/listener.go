@@ -0,0 +1,307 @@
+package d2udp
+
+import (
+	"log"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	network          = "udp4"
+	acceptQueueSize  = 16
+	readBufferSize   = 2 * MaxDatagramSize
+	handshakeTimeout = 5 * time.Second
+	handshakeRetry   = 250 * time.Millisecond
+)
+
+// Listener accepts connections from clients on a UDP socket and routes their datagrams to them
+type Listener struct {
+	sync.Mutex
+	socket    *net.UDPConn
+	conns     map[string]*Conn // by the address of the client
+	accept    chan *Conn
+	done      chan struct{}
+	closeOnce sync.Once
+	rand      *rand.Rand
+}
+
+// Listen listens for connections on the UDP address
+func Listen(address string) (*Listener, error) {
+	udpAddress, err := net.ResolveUDPAddr(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	socket, err := net.ListenUDP(network, udpAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Listener{
+		socket: socket,
+		conns:  make(map[string]*Conn),
+		accept: make(chan *Conn, acceptQueueSize),
+		done:   make(chan struct{}),
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())), //nolint:gosec // sessions are not secrets
+	}
+
+	go l.serve()
+	go l.update()
+
+	return l, nil
+}
+
+// Addr returns the address of the socket
+func (l *Listener) Addr() net.Addr {
+	return l.socket.LocalAddr()
+}
+
+// Accept waits for the next connection
+func (l *Listener) Accept() (*Conn, error) {
+	select {
+	case conn := <-l.accept:
+		return conn, nil
+	case <-l.done:
+		return nil, ErrClosed
+	}
+}
+
+// Close closes the listener and all of its connections
+func (l *Listener) Close() error {
+	var err error
+
+	l.closeOnce.Do(func() {
+		close(l.done)
+
+		l.Lock()
+		conns := make([]*Conn, 0, len(l.conns))
+		for _, conn := range l.conns {
+			conns = append(conns, conn)
+		}
+		l.Unlock()
+
+		for _, conn := range conns {
+			_ = conn.Close()
+		}
+
+		err = l.socket.Close()
+	})
+
+	return err
+}
+
+func (l *Listener) serve() {
+	buffer := make([]byte, readBufferSize)
+
+	for {
+		n, address, err := l.socket.ReadFromUDP(buffer)
+		if err != nil {
+			select {
+			case <-l.done:
+				return
+			default:
+				// an unreachable client can be reported by the next read, it does not affect the others
+				log.Printf("UDP listener: %s", err)
+				continue
+			}
+		}
+
+		d, err := unmarshalDatagram(buffer[:n])
+		if err != nil {
+			continue
+		}
+
+		l.route(d, address)
+	}
+}
+
+// route passes the datagram to the connection of its client, a connect datagram creates the connection
+func (l *Listener) route(d *datagram, address *net.UDPAddr) {
+	key := address.String()
+
+	l.Lock()
+	conn := l.conns[key]
+
+	if conn == nil && d.kind == kindConnect {
+		conn = l.newConn(key, address)
+	}
+	l.Unlock()
+
+	switch {
+	case conn == nil:
+		return
+	case d.kind == kindConnect:
+		// the accept datagram is sent again, the client has not received it when it connects again
+		accept := &datagram{kind: kindAccept, session: conn.session.id}
+
+		if _, err := l.socket.WriteToUDP(accept.marshal(), address); err != nil {
+			log.Printf("UDP listener: %s", err)
+		}
+	case d.session == conn.session.id:
+		conn.handle(d)
+	}
+}
+
+// newConn creates the connection of a new client, it returns nil when too many connections were not
+// accepted yet
+func (l *Listener) newConn(key string, address *net.UDPAddr) *Conn {
+	if len(l.accept) == cap(l.accept) {
+		return nil
+	}
+
+	id := l.rand.Uint32() | 1 // sessions are never 0, which is the session of a connect datagram
+
+	send := func(data []byte) error {
+		_, err := l.socket.WriteToUDP(data, address)
+		return err
+	}
+
+	conn := newConn(id, address, send, func() {
+		l.Lock()
+		delete(l.conns, key)
+		l.Unlock()
+	})
+
+	l.conns[key] = conn
+	l.accept <- conn
+
+	return conn
+}
+
+func (l *Listener) update() {
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case now := <-ticker.C:
+			l.Lock()
+			conns := make([]*Conn, 0, len(l.conns))
+			for _, conn := range l.conns {
+				conns = append(conns, conn)
+			}
+			l.Unlock()
+
+			for _, conn := range conns {
+				conn.update(now)
+			}
+		}
+	}
+}
+
+// Dial connects to the listener at the UDP address. The handshake is retried until the listener answers
+// or the handshake times out.
+func Dial(address string) (*Conn, error) {
+	remote, err := net.ResolveUDPAddr(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	socket, err := net.DialUDP(network, nil, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := handshake(socket)
+	if err != nil {
+		_ = socket.Close()
+		return nil, err
+	}
+
+	send := func(data []byte) error {
+		_, err := socket.Write(data)
+		return err
+	}
+
+	conn := newConn(id, remote, send, func() {
+		_ = socket.Close()
+	})
+
+	go serveDialed(socket, conn)
+	go updateDialed(conn)
+
+	return conn, nil
+}
+
+// handshake sends connect datagrams until the listener accepts them, it returns the session
+func handshake(socket *net.UDPConn) (uint32, error) {
+	connect := (&datagram{kind: kindConnect}).marshal()
+	buffer := make([]byte, readBufferSize)
+	deadline := time.Now().Add(handshakeTimeout)
+
+	for time.Now().Before(deadline) {
+		if _, err := socket.Write(connect); err != nil {
+			return 0, err
+		}
+
+		if err := socket.SetReadDeadline(time.Now().Add(handshakeRetry)); err != nil {
+			return 0, err
+		}
+
+		id, err := readAccept(socket, buffer)
+		if err == nil {
+			return id, socket.SetReadDeadline(time.Time{})
+		}
+
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			// the connect was refused, the listener is not up yet
+			time.Sleep(handshakeRetry)
+		}
+	}
+
+	return 0, ErrTimeout
+}
+
+func readAccept(socket *net.UDPConn, buffer []byte) (uint32, error) {
+	for {
+		n, err := socket.Read(buffer)
+		if err != nil {
+			return 0, err
+		}
+
+		if d, err := unmarshalDatagram(buffer[:n]); err == nil && d.kind == kindAccept {
+			return d.session, nil
+		}
+	}
+}
+
+func serveDialed(socket *net.UDPConn, conn *Conn) {
+	buffer := make([]byte, readBufferSize)
+
+	for {
+		n, err := socket.Read(buffer)
+		if err != nil {
+			select {
+			case <-conn.done:
+				return
+			default:
+				continue
+			}
+		}
+
+		d, err := unmarshalDatagram(buffer[:n])
+		if err != nil || d.session != conn.session.id {
+			continue
+		}
+
+		conn.handle(d)
+	}
+}
+
+func updateDialed(conn *Conn) {
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-conn.done:
+			return
+		case now := <-ticker.C:
+			conn.update(now)
+		}
+	}
+}
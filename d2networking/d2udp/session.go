@@ -0,0 +1,323 @@
+package d2udp
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultTimeout is how long a connection stays open without hearing from the peer
+	DefaultTimeout = 10 * time.Second
+
+	heartbeatInterval  = time.Second            // an ack is sent when nothing was sent for this long
+	resendInterval     = 200 * time.Millisecond // reliable fragments are resent when not acked for this long
+	ackBitsSize        = 32
+	maxPendingMessages = 1024 // incomplete or undelivered messages that are kept, per channel
+)
+
+const (
+	unreliableChannel = iota
+	reliableChannel
+	channelCount
+)
+
+var (
+	// ErrTimeout is returned when the peer has not been heard from or when a read deadline has passed
+	ErrTimeout = errors.New("udp connection timed out")
+
+	errMessageTooLarge = errors.New("message is too large")
+)
+
+// fragment is a part of a message that fits into a datagram
+type fragment struct {
+	reliable bool
+	message  uint32
+	index    uint16
+	count    uint16
+	payload  []byte
+	sentAt   time.Time
+}
+
+// partialMessage is a message of which not all fragments have arrived yet
+type partialMessage struct {
+	fragments [][]byte
+	received  int
+}
+
+// session implements the protocol of a connection. It is not safe for concurrent use, and it sends
+// datagrams with the send function of its connection.
+type session struct {
+	id      uint32
+	send    func(data []byte) error
+	timeout time.Duration
+
+	// sent to the peer
+	sequence     uint32               // of the last data datagram
+	nextMessages [channelCount]uint32 // the last message of each channel
+	unacked      map[uint32]*fragment // reliable fragments, by the datagram that carried them last
+	lastSent     time.Time
+
+	// received from the peer
+	received     uint32 // the latest data datagram
+	receivedBits uint32 // which of the data datagrams before it were received
+	ackPending   bool
+	lastReceived time.Time
+	partial      [channelCount]map[uint32]*partialMessage
+	delivered    [channelCount]uint32 // the last message of each channel that was delivered
+	complete     map[uint32][]byte    // reliable messages that wait for the messages before them
+}
+
+func newSession(id uint32, send func(data []byte) error, now time.Time) *session {
+	s := &session{
+		id:           id,
+		send:         send,
+		timeout:      DefaultTimeout,
+		unacked:      make(map[uint32]*fragment),
+		lastSent:     now,
+		lastReceived: now,
+		complete:     make(map[uint32][]byte),
+	}
+
+	for channel := range s.partial {
+		s.partial[channel] = make(map[uint32]*partialMessage)
+	}
+
+	return s
+}
+
+// sendMessage splits the message into fragments and sends them
+func (s *session) sendMessage(message []byte, reliable bool, now time.Time) error {
+	count := (len(message) + maxFragmentSize - 1) / maxFragmentSize
+	if count == 0 {
+		count = 1
+	}
+
+	if count > maxFragments {
+		return errMessageTooLarge
+	}
+
+	channel := channelOf(reliable)
+	s.nextMessages[channel]++
+
+	for index := 0; index < count; index++ {
+		end := (index + 1) * maxFragmentSize
+		if end > len(message) {
+			end = len(message)
+		}
+
+		f := &fragment{
+			reliable: reliable,
+			message:  s.nextMessages[channel],
+			index:    uint16(index),
+			count:    uint16(count),
+			payload:  message[index*maxFragmentSize : end],
+		}
+
+		if err := s.sendFragment(f, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *session) sendFragment(f *fragment, now time.Time) error {
+	s.sequence++
+
+	d := s.newDatagram(kindData)
+	d.sequence = s.sequence
+	d.reliable = f.reliable
+	d.message = f.message
+	d.fragment = f.index
+	d.fragments = f.count
+	d.payload = f.payload
+
+	if f.reliable {
+		f.sentAt = now
+		s.unacked[s.sequence] = f
+	}
+
+	s.lastSent = now
+	s.ackPending = false
+
+	return s.send(d.marshal())
+}
+
+// sendControl sends a datagram without data, such as an ack or a disconnect
+func (s *session) sendControl(kind datagramKind, now time.Time) error {
+	s.lastSent = now
+	s.ackPending = false
+
+	return s.send(s.newDatagram(kind).marshal())
+}
+
+func (s *session) newDatagram(kind datagramKind) *datagram {
+	return &datagram{kind: kind, session: s.id, ack: s.received, ackBits: s.receivedBits}
+}
+
+// receive handles a data or ack datagram from the peer, it returns the messages that can be delivered
+func (s *session) receive(d *datagram, now time.Time) [][]byte {
+	s.lastReceived = now
+	s.acknowledge(d.ack, d.ackBits)
+
+	if d.kind != kindData {
+		return nil
+	}
+
+	channel := channelOf(d.reliable)
+
+	_, waiting := s.complete[d.message]
+
+	if !newer(d.message, s.delivered[channel]) || (d.reliable && waiting) {
+		// an old unreliable message, or a reliable message that was resent because the ack was lost
+		s.ackPending = s.ackPending || d.reliable
+		return nil
+	}
+
+	partial := s.partial[channel][d.message]
+	if partial == nil && len(s.partial[channel])+len(s.complete) >= maxPendingMessages {
+		// not acked, so a reliable message is resent once there is room for it
+		return nil
+	}
+
+	if !s.recordReceived(d.sequence) {
+		return nil
+	}
+
+	s.ackPending = s.ackPending || d.reliable
+
+	if partial == nil {
+		partial = &partialMessage{fragments: make([][]byte, d.fragments)}
+		s.partial[channel][d.message] = partial
+	}
+
+	if int(d.fragments) != len(partial.fragments) || partial.fragments[d.fragment] != nil {
+		return nil
+	}
+
+	partial.fragments[d.fragment] = d.payload
+	partial.received++
+
+	if partial.received < len(partial.fragments) {
+		return nil
+	}
+
+	delete(s.partial[channel], d.message)
+
+	message := make([]byte, 0, len(partial.fragments)*maxFragmentSize)
+	for _, payload := range partial.fragments {
+		message = append(message, payload...)
+	}
+
+	if !d.reliable {
+		s.delivered[unreliableChannel] = d.message
+		s.dropStaleMessages()
+
+		return [][]byte{message}
+	}
+
+	s.complete[d.message] = message
+
+	return s.deliverReliable()
+}
+
+// deliverReliable returns the complete reliable messages that follow the last delivered one
+func (s *session) deliverReliable() [][]byte {
+	var messages [][]byte
+
+	for {
+		next := s.delivered[reliableChannel] + 1
+
+		message, found := s.complete[next]
+		if !found {
+			return messages
+		}
+
+		delete(s.complete, next)
+		s.delivered[reliableChannel] = next
+		messages = append(messages, message)
+	}
+}
+
+// dropStaleMessages drops the incomplete unreliable messages that are older than the last delivered one
+func (s *session) dropStaleMessages() {
+	for message := range s.partial[unreliableChannel] {
+		if !newer(message, s.delivered[unreliableChannel]) {
+			delete(s.partial[unreliableChannel], message)
+		}
+	}
+}
+
+// recordReceived records the data datagram for the acks, it returns false when it was received before
+func (s *session) recordReceived(sequence uint32) bool {
+	if newer(sequence, s.received) {
+		shift := sequence - s.received
+		if shift > ackBitsSize {
+			s.receivedBits = 0
+		} else {
+			s.receivedBits = s.receivedBits<<shift | 1<<(shift-1)
+		}
+
+		s.received = sequence
+
+		return true
+	}
+
+	if sequence == s.received {
+		return false
+	}
+
+	bit := s.received - sequence - 1
+	if bit >= ackBitsSize || s.receivedBits&(1<<bit) != 0 {
+		return false
+	}
+
+	s.receivedBits |= 1 << bit
+
+	return true
+}
+
+// acknowledge removes the fragments that the peer has received from the fragments to resend
+func (s *session) acknowledge(ack, ackBits uint32) {
+	delete(s.unacked, ack)
+
+	for bit := uint32(0); bit < ackBitsSize; bit++ {
+		if ackBits&(1<<bit) != 0 {
+			delete(s.unacked, ack-bit-1)
+		}
+	}
+}
+
+// update resends the reliable fragments that were not acked, and sends an ack when data was received or
+// nothing was sent for a while. It returns ErrTimeout when the peer has not been heard from.
+func (s *session) update(now time.Time) error {
+	if now.Sub(s.lastReceived) > s.timeout {
+		return ErrTimeout
+	}
+
+	for sequence, f := range s.unacked {
+		if now.Sub(f.sentAt) < resendInterval {
+			continue
+		}
+
+		delete(s.unacked, sequence)
+
+		if err := s.sendFragment(f, now); err != nil {
+			return err
+		}
+	}
+
+	if s.ackPending || now.Sub(s.lastSent) >= heartbeatInterval {
+		return s.sendControl(kindAck, now)
+	}
+
+	return nil
+}
+
+func channelOf(reliable bool) int {
+	if reliable {
+		return reliableChannel
+	}
+
+	return unreliableChannel
+}
@@ -0,0 +1,176 @@
+package d2udp
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// link passes the datagrams of a session to its peer, dropping some of them
+type link struct {
+	datagrams [][]byte
+	drop      func(n int) bool
+	sent      int
+}
+
+func (l *link) send(data []byte) error {
+	l.sent++
+
+	if l.drop == nil || !l.drop(l.sent) {
+		l.datagrams = append(l.datagrams, data)
+	}
+
+	return nil
+}
+
+// flush delivers the datagrams to the peer, in reverse order when reverse is set
+func (l *link) flush(t *testing.T, peer *session, now time.Time, reverse bool) [][]byte {
+	datagrams := l.datagrams
+	l.datagrams = nil
+
+	var messages [][]byte
+
+	for i := range datagrams {
+		data := datagrams[i]
+		if reverse {
+			data = datagrams[len(datagrams)-1-i]
+		}
+
+		d, err := unmarshalDatagram(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		messages = append(messages, peer.receive(d, now)...)
+	}
+
+	return messages
+}
+
+func newSessionPair(dropAB func(n int) bool) (a, b *session, ab, ba *link) {
+	now := time.Time{}
+	ab = &link{drop: dropAB}
+	ba = &link{}
+
+	return newSession(1, ab.send, now), newSession(1, ba.send, now), ab, ba
+}
+
+func TestSessionFragments(t *testing.T) {
+	a, b, ab, _ := newSessionPair(nil)
+	now := time.Time{}
+
+	message := make([]byte, 3*maxFragmentSize+10)
+	for i := range message {
+		message[i] = byte(i)
+	}
+
+	if err := a.sendMessage(message, true, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ab.datagrams) != 4 {
+		t.Fatalf("expected 4 fragments, got %d", len(ab.datagrams))
+	}
+
+	for _, data := range ab.datagrams {
+		if len(data) > MaxDatagramSize {
+			t.Fatalf("datagram of %d bytes is larger than the MTU", len(data))
+		}
+	}
+
+	messages := ab.flush(t, b, now, true)
+	if len(messages) != 1 || !bytes.Equal(messages[0], message) {
+		t.Fatal("the fragments were not reassembled")
+	}
+
+	if err := a.sendMessage(make([]byte, maxFragments*maxFragmentSize+1), true, now); err == nil {
+		t.Error("expected an error for a message that is too large")
+	}
+}
+
+func TestSessionReliable(t *testing.T) {
+	// every other datagram from a to b is lost
+	a, b, ab, ba := newSessionPair(func(n int) bool { return n%2 == 0 })
+	now := time.Time{}
+
+	const count = 10
+
+	for i := 0; i < count; i++ {
+		if err := a.sendMessage([]byte{byte(i)}, true, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var received [][]byte
+
+	for round := 0; round < count && len(received) < count; round++ {
+		received = append(received, ab.flush(t, b, now, true)...)
+		now = now.Add(resendInterval)
+
+		if err := b.update(now); err != nil {
+			t.Fatal(err)
+		}
+
+		ba.flush(t, a, now, false)
+
+		if err := a.update(now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(received) != count {
+		t.Fatalf("expected %d messages, got %d", count, len(received))
+	}
+
+	for i, message := range received {
+		if message[0] != byte(i) {
+			t.Fatalf("message %d arrived as message %d", message[0], i)
+		}
+	}
+
+	if len(a.unacked) != 0 {
+		t.Errorf("%d fragments were not acked", len(a.unacked))
+	}
+}
+
+func TestSessionUnreliable(t *testing.T) {
+	a, b, ab, _ := newSessionPair(func(n int) bool { return n == 2 })
+	now := time.Time{}
+
+	for i := 0; i < 4; i++ {
+		if err := a.sendMessage([]byte{byte(i)}, false, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the messages arrive in reverse, the older ones are dropped
+	messages := ab.flush(t, b, now, true)
+	if len(messages) != 1 || messages[0][0] != 3 {
+		t.Errorf("expected only the newest message, got %v", messages)
+	}
+
+	if err := a.update(now.Add(resendInterval)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ab.flush(t, b, now, false)) != 0 {
+		t.Error("an unreliable message was resent")
+	}
+}
+
+func TestSessionTimeout(t *testing.T) {
+	a, _, ab, _ := newSessionPair(nil)
+	now := time.Time{}
+
+	if err := a.update(now.Add(heartbeatInterval)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ab.datagrams) != 1 {
+		t.Error("expected a heartbeat")
+	}
+
+	if err := a.update(now.Add(DefaultTimeout + time.Second)); err != ErrTimeout {
+		t.Errorf("expected a timeout, got %v", err)
+	}
+}
@@ -40,6 +40,16 @@ func StartDedicatedServer(
 		return err
 	}
 
+	// the clients that connect without a game name join the default game
+	_, err = server.CreateGame(&d2server.GameOptions{
+		Name:       d2server.DefaultGameName,
+		MaxPlayers: maxPlayers,
+		Persistent: true,
+	})
+	if err != nil {
+		return err
+	}
+
 	err = server.Start()
 	if err != nil {
 		return err
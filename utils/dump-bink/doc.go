@@ -0,0 +1,15 @@
+// This command line utility decodes a bink video without a window and writes its frames as PNG files,
+// for example to compare the decoder output between versions.
+//
+// Flags:
+// -o [directory] Output directory (default: the video name without its extension)
+// -n [count] The number of frames to write (default: all)
+// -s [step] Write every step-th frame (default: 1)
+// -v Enable verbose output
+//
+// Usage:
+// First run `go install dump-bink.go` in this directory.
+// Then run dump-bink(.exe) with the video file, which can be extracted from d2video.mpq with extract-mpq.
+//
+// dump-bink -o ./frames -s 25 ./output/d2video.mpq/data/local/video/New_Bliz640x480.bik
+package main
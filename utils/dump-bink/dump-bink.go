@@ -0,0 +1,100 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2data/d2video"
+)
+
+func main() {
+	var (
+		outPath string
+		count   int
+		step    int
+		verbose bool
+	)
+
+	flag.StringVar(&outPath, "o", "", "output directory")
+	flag.IntVar(&count, "n", 0, "the number of frames to write, 0 writes all")
+	flag.IntVar(&step, "s", 1, "write every step-th frame")
+	flag.BoolVar(&verbose, "v", false, "verbose output")
+	flag.Parse()
+
+	if len(flag.Args()) != 1 || step < 1 {
+		fmt.Printf("Usage: %s [flags] video.bik\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	inPath := flag.Arg(0)
+
+	if outPath == "" {
+		outPath = strings.TrimSuffix(inPath, filepath.Ext(inPath))
+	}
+
+	data, err := ioutil.ReadFile(inPath) //nolint:gosec // reading the video is the point
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	decoder, err := d2video.CreateBinkDecoder(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.MkdirAll(outPath, os.ModePerm); err != nil {
+		log.Fatal(err)
+	}
+
+	written := 0
+
+	for count == 0 || written < count {
+		frame, err := decoder.DecodeFrame()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if frame.Index%step != 0 {
+			continue
+		}
+
+		if err := writeFrame(outPath, frame); err != nil {
+			log.Fatal(err)
+		}
+
+		written++
+
+		if verbose {
+			fmt.Printf("frame %d at %v\n", frame.Index, frame.Time)
+		}
+	}
+
+	if verbose {
+		fmt.Printf("Wrote %d of %d frames to %s\n", written, decoder.FrameCount(), outPath)
+	}
+}
+
+func writeFrame(outPath string, frame *d2video.BinkFrame) error {
+	file, err := os.Create(filepath.Join(outPath, fmt.Sprintf("frame%05d.png", frame.Index)))
+	if err != nil {
+		return err
+	}
+
+	if err := frame.WritePNG(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+
+	return file.Close()
+}
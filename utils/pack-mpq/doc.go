@@ -0,0 +1,16 @@
+// This command line utility packs the files of a directory into an mpq file.
+//
+// Flags:
+// -o [filename] Output mpq file (default: the directory name with an .mpq extension)
+// -a Append to or replace files in the output mpq, instead of creating a new one
+// -c [none|zlib|pkware] Compression method (default: zlib)
+// -e Encrypt the files
+// -v Enable verbose output
+//
+// Usage:
+// First run `go install pack-mpq.go` in this directory.
+// Then run pack-mpq(.exe) with the directory to be packed. The paths of the files in the mpq
+// are relative to the directory, so a directory extracted with extract-mpq can be packed again.
+//
+// pack-mpq -o patch_d2.mpq ./output/patch_d2.mpq
+package main
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2fileformats/d2mpq"
+)
+
+func main() {
+	var (
+		outPath     string
+		compression string
+		appendFiles bool
+		encrypt     bool
+		verbose     bool
+	)
+
+	flag.StringVar(&outPath, "o", "", "output mpq file")
+	flag.BoolVar(&appendFiles, "a", false, "append to or replace files in the output mpq")
+	flag.StringVar(&compression, "c", "zlib", "compression method, one of none, zlib, pkware")
+	flag.BoolVar(&encrypt, "e", false, "encrypt the files")
+	flag.BoolVar(&verbose, "v", false, "verbose output")
+	flag.Parse()
+
+	if len(flag.Args()) != 1 {
+		fmt.Printf("Usage: %s [flags] directory\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	inPath := filepath.Clean(flag.Arg(0))
+
+	if outPath == "" {
+		outPath = strings.TrimSuffix(inPath, string(filepath.Separator)) + ".mpq"
+	}
+
+	options, err := fileOptions(compression, encrypt)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	writer := d2mpq.NewWriter()
+
+	if appendFiles {
+		if writer, err = d2mpq.NewWriterFromArchive(outPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	writer.Options = options
+
+	err = filepath.Walk(inPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		return addFile(writer, inPath, filePath, verbose)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writer.Save(outPath); err != nil {
+		log.Fatal(err)
+	}
+
+	if verbose {
+		fmt.Printf("Wrote %d files to %s\n", len(writer.FileNames()), outPath)
+	}
+}
+
+func fileOptions(compression string, encrypt bool) (d2mpq.FileOptions, error) {
+	options := d2mpq.DefaultFileOptions()
+	options.Encrypted = encrypt
+	options.FixKey = encrypt
+
+	switch compression {
+	case "none":
+		options.Compression = d2mpq.CompressionNone
+	case "zlib":
+		options.Compression = d2mpq.CompressionZlib
+	case "pkware":
+		options.Compression = d2mpq.CompressionPKWare
+	default:
+		return options, fmt.Errorf("unknown compression method: %s", compression)
+	}
+
+	return options, nil
+}
+
+func addFile(writer *d2mpq.Writer, inPath, filePath string, verbose bool) error {
+	name, err := filepath.Rel(inPath, filePath)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(filePath) //nolint:gosec // reading the files to pack is the point
+	if err != nil {
+		return err
+	}
+
+	name = strings.ReplaceAll(filepath.ToSlash(name), "/", `\`)
+	writer.AddFile(name, data)
+
+	if verbose {
+		fmt.Printf("Adding: %s\n", name)
+	}
+
+	return nil
+}
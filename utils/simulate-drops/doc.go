@@ -0,0 +1,23 @@
+// This command line utility rolls the item drops of a monster or a treasure class many times and reports how
+// often each quality, base item, affix, unique and set item dropped, to compare the item generation with the
+// original game. The drops are seeded, the same flags always report the same drops.
+//
+// Flags:
+// -mpq [directory] The directory of the Diablo II MPQ files (default: the default MpqPath of the config)
+// -monster [id] The monster to roll the treasure class of, an Id of monstats.txt
+// -tc [name] The treasure class to roll, when no monster is given
+// -difficulty [normal|nightmare|hell] The difficulty of the monster treasure class (default: normal)
+// -mf [percent] Magic find (default: 0)
+// -ilvl [level] The item level of the drops, the level of the monster or area (default: the levels of the base items)
+// -n [count] The number of drops to roll (default: 1000000)
+// -seed [seed] The seed of the drops (default: 0)
+// -format [csv|json] The report format (default: csv)
+// -o [file] Output file (default: stdout)
+// -v Enable verbose output
+//
+// Usage:
+// First run `go install simulate-drops.go` in this directory.
+// Then run simulate-drops(.exe) with the monster or treasure class to roll.
+//
+// simulate-drops -monster zombie1 -difficulty hell -mf 300 -n 5000000 -format json -o zombie1.json
+package main
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2enum"
+	"github.com/OpenDiablo2/OpenDiablo2/d2common/d2util"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2asset"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2config"
+	"github.com/OpenDiablo2/OpenDiablo2/d2core/d2item/diablo2item"
+)
+
+const (
+	defaultDrops = 1000000
+
+	formatCSV  = "csv"
+	formatJSON = "json"
+)
+
+func main() {
+	var (
+		mpqPath, difficulty, format, outPath string
+		simulation                           diablo2item.DropSimulation
+		verbose                              bool
+	)
+
+	flag.StringVar(&mpqPath, "mpq", d2config.DefaultConfig().MpqPath, "directory of the Diablo II MPQ files")
+	flag.StringVar(&simulation.Monster, "monster", "", "monster to roll the treasure class of")
+	flag.StringVar(&simulation.TreasureClass, "tc", "", "treasure class to roll, when no monster is given")
+	flag.StringVar(&difficulty, "difficulty", "normal", "difficulty of the monster treasure class")
+	flag.IntVar(&simulation.MagicFind, "mf", 0, "magic find, in percent")
+	flag.IntVar(&simulation.ItemLevel, "ilvl", 0, "item level of the drops, 0 for the levels of the base items")
+	flag.IntVar(&simulation.Drops, "n", defaultDrops, "number of drops to roll")
+	flag.Int64Var(&simulation.Seed, "seed", 0, "seed of the drops")
+	flag.StringVar(&format, "format", formatCSV, "report format, csv or json")
+	flag.StringVar(&outPath, "o", "", "output file, the report is written to stdout without one")
+	flag.BoolVar(&verbose, "v", false, "verbose output")
+	flag.Parse()
+
+	difficulties := map[string]d2enum.DifficultyType{
+		"normal":    d2enum.DifficultyNormal,
+		"nightmare": d2enum.DifficultyNightmare,
+		"hell":      d2enum.DifficultyHell,
+	}
+
+	var found bool
+
+	simulation.Difficulty, found = difficulties[difficulty]
+
+	if !found || (format != formatCSV && format != formatJSON) ||
+		(simulation.Monster == "" && simulation.TreasureClass == "") {
+		fmt.Printf("Usage: %s [flags] -monster id | -tc name\n", os.Args[0])
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+
+	factory, err := loadItemFactory(mpqPath, verbose)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := factory.SimulateDrops(&simulation)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := writeReport(report, format, outPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// loadItemFactory loads the records from the MPQ files in the directory
+func loadItemFactory(mpqPath string, verbose bool) (*diablo2item.ItemFactory, error) {
+	asset, err := d2asset.NewAssetManager()
+	if err != nil {
+		return nil, err
+	}
+
+	if !verbose {
+		asset.SetLogLevel(d2util.LogLevelNone)
+	}
+
+	for _, mpqName := range d2config.DefaultConfig().MpqLoadOrder {
+		if _, err := asset.AddSource(filepath.Join(filepath.Clean(mpqPath), mpqName)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := asset.LoadRecordTables(); err != nil {
+		return nil, err
+	}
+
+	return diablo2item.NewItemFactory(asset)
+}
+
+func writeReport(report *diablo2item.DropReport, format, outPath string) error {
+	var out io.Writer = os.Stdout
+
+	if outPath != "" {
+		file, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if err := file.Close(); err != nil {
+				log.Print(err)
+			}
+		}()
+
+		out = file
+	}
+
+	if format == formatJSON {
+		return report.WriteJSON(out)
+	}
+
+	return report.WriteCSV(out)
+}